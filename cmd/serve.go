@@ -93,7 +93,7 @@ func runServ(k *cli.Context) {
 	keys := strings.Split(os.Args[2], "-")
 	if len(keys) != 2 {
 		println("Gogs: auth file format error")
-		qlog.Fatal("Invalid auth file format: %s", os.Args[2])
+		qlog.Fatalf("Invalid auth file format: %s", os.Args[2])
 	}
 
 	keyId, err := strconv.ParseInt(keys[1], 10, 64)
@@ -101,18 +101,60 @@ func runServ(k *cli.Context) {
 		println("Gogs: auth file format error")
 		qlog.Fatalf("Invalid auth file format: %v", err)
 	}
-	user, err := models.GetUserByKeyId(keyId)
-	if err != nil {
-		if err == models.ErrUserNotKeyOwner {
-			println("Gogs: you are not the owner of SSH key")
-			qlog.Fatalf("Invalid owner of SSH key: %d", keyId)
+
+	var user *models.User
+	var deployKey *models.DeployKey
+	switch keys[0] {
+	case "key":
+		key, err := models.GetPublicKeyById(keyId)
+		if err != nil {
+			if err == models.ErrKeyNotExist {
+				println("Gogs: SSH key does not exist")
+				qlog.Fatalf("SSH key does not exist: %d", keyId)
+			}
+			println("Gogs: internal error:", err)
+			qlog.Fatalf("Fail to get SSH key by ID(%d): %v", keyId, err)
+		} else if key.IsExpired() {
+			println("Gogs: your SSH key has expired, please add a new one")
+			qlog.Fatalf("SSH key has expired: %d", keyId)
 		}
-		println("Gogs: internal error:", err)
-		qlog.Fatalf("Fail to get user by key ID(%d): %v", keyId, err)
+		if err = models.UpdatePublicKeyUsed(key); err != nil {
+			qlog.Errorf("Fail to update last used time of SSH key(%d): %v", keyId, err)
+		}
+
+		user, err = models.GetUserByKeyId(keyId)
+		if err != nil {
+			if err == models.ErrUserNotKeyOwner {
+				println("Gogs: you are not the owner of SSH key")
+				qlog.Fatalf("Invalid owner of SSH key: %d", keyId)
+			}
+			println("Gogs: internal error:", err)
+			qlog.Fatalf("Fail to get user by key ID(%d): %v", keyId, err)
+		} else if user.IsSuspended {
+			println("Gogs: your account has been suspended")
+			qlog.Fatalf("Suspended user tried to access: %s", user.Name)
+		}
+	case "deploy":
+		deployKey, err = models.GetDeployKeyById(keyId)
+		if err != nil {
+			if err == models.ErrDeployKeyNotExist {
+				println("Gogs: deploy key does not exist")
+				qlog.Fatalf("Deploy key does not exist: %d", keyId)
+			}
+			println("Gogs: internal error:", err)
+			qlog.Fatalf("Fail to get deploy key by ID(%d): %v", keyId, err)
+		}
+	default:
+		println("Gogs: auth file format error")
+		qlog.Fatalf("Invalid auth file format: %s", os.Args[2])
 	}
 
 	cmd := os.Getenv("SSH_ORIGINAL_COMMAND")
 	if cmd == "" {
+		if deployKey != nil {
+			println("Hi there! You've successfully authenticated with a deploy key, but Gogs does not provide shell access.")
+			return
+		}
 		println("Hi", user.Name, "! You've successfully authenticated, but Gogs does not provide shell access.")
 		return
 	}
@@ -126,6 +168,8 @@ func runServ(k *cli.Context) {
 	}
 	repoUserName := rr[0]
 	repoName := strings.TrimSuffix(rr[1], ".git")
+	// Wiki shares access rules with its repository.
+	repoName = strings.TrimSuffix(repoName, ".wiki")
 
 	isWrite := In(verb, COMMANDS_WRITE)
 	isRead := In(verb, COMMANDS_READONLY)
@@ -140,8 +184,38 @@ func runServ(k *cli.Context) {
 		qlog.Fatalf("Fail to get repository owner(%s): %v", repoUserName, err)
 	}
 
+	// Deploy key only has access to the repository it belongs to.
+	if deployKey != nil {
+		if !isWrite && !isRead {
+			println("Unknown command")
+			return
+		}
+
+		repo, err := models.GetRepositoryByName(repoUser.Id, repoName)
+		if err != nil {
+			if err == models.ErrRepoNotExist {
+				println("Gogs: given repository does not exist")
+				qlog.Fatalf("Repository does not exist: %s/%s", repoUser.Name, repoName)
+			}
+			println("Gogs: internal error:", err)
+			qlog.Fatalf("Fail to get repository: %v", err)
+		}
+
+		if repo.Id != deployKey.RepoId {
+			println("You have no right to access this repository")
+			qlog.Fatalf("Deploy key %d has no right to access repository %s", deployKey.Id, repoPath)
+		} else if isWrite && !deployKey.IsWritable {
+			println("You have no right to write this repository")
+			qlog.Fatalf("Deploy key %d has no right to write repository %s", deployKey.Id, repoPath)
+		}
+
+		// Changes pushed by deploy key are on behalf of repository owner.
+		user = repoUser
+	}
+
 	// Access check.
 	switch {
+	case deployKey != nil:
 	case isWrite:
 		has, err := models.HasAccess(user.Name, path.Join(repoUserName, repoName), models.AU_WRITABLE)
 		if err != nil {
@@ -179,6 +253,17 @@ func runServ(k *cli.Context) {
 		return
 	}
 
+	if isWrite {
+		repo, err := models.GetRepositoryByName(repoUser.Id, repoName)
+		if err != nil {
+			println("Gogs: internal error:", err)
+			qlog.Fatalf("Fail to get repository: %v", err)
+		} else if repo.IsArchived {
+			println("Gogs: repository is archived and read-only")
+			qlog.Fatalf("Repository is archived: %s", repoPath)
+		}
+	}
+
 	models.SetRepoEnvs(user.Id, user.Name, repoName, repoUserName)
 
 	gitcmd := exec.Command(verb, repoPath)
@@ -13,6 +13,7 @@ import (
 	qlog "github.com/qiniu/log"
 
 	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/base"
 	"github.com/gogits/gogs/modules/setting"
 )
 
@@ -32,8 +33,16 @@ func updateEnv(refName, oldCommitId, newCommitId string) {
 }
 
 func runUpdate(c *cli.Context) {
+	// Changes made by Gogs itself(e.g. merging pull request) are trusted,
+	// only custom update hook is run in repository directory that Git has changed to.
 	cmd := os.Getenv("SSH_ORIGINAL_COMMAND")
-	if cmd == "" {
+	isHttpPush := os.Getenv("isHttpPush") == "true"
+	if cmd == "" && !isHttpPush {
+		if args := c.Args(); len(args) == 3 {
+			if err := models.RunCustomUpdateHook(".", args[0], args[1], args[2]); err != nil {
+				os.Exit(1)
+			}
+		}
 		return
 	}
 
@@ -54,5 +63,45 @@ func runUpdate(c *cli.Context) {
 	repoUserName := os.Getenv("repoUserName")
 	repoName := os.Getenv("repoName")
 
-	models.Update(args[0], args[1], args[2], userName, repoUserName, repoName, userId)
+	repoUser, err := models.GetUserByName(repoUserName)
+	if err != nil {
+		println("Gogs: internal error:", err.Error())
+		qlog.Fatalf("Fail to get repository owner(%s): %v", repoUserName, err)
+	}
+	repo, err := models.GetRepositoryByName(repoUser.Id, repoName)
+	if err != nil {
+		println("Gogs: internal error:", err.Error())
+		qlog.Fatalf("Fail to get repository(%s/%s): %v", repoUserName, repoName, err)
+	}
+	if err = models.CheckBranchPush(repo, models.RepoPath(repoUserName, repoName), userId,
+		args[0], args[1], args[2]); err != nil {
+		println("Gogs:", err.Error())
+		qlog.Fatalf("User %s is not allowed to update %s of %s/%s: %v", userName, args[0], repoUserName, repoName, err)
+	}
+
+	// Objects being pushed are already in repository, so quota can be checked before updating reference.
+	q, err := models.CheckRepoQuota(repo, models.RepoPath(repoUserName, repoName))
+	if err == models.ErrRepoSizeExceeded {
+		println("Gogs: push rejected:", err.Error(), "("+base.FileSize(q.RepoSize), "of", base.FileSize(q.RepoLimit)+")")
+		qlog.Fatalf("Push of %s to %s/%s exceeds size limit of repository", userName, repoUserName, repoName)
+	} else if err == models.ErrUserQuotaExceeded {
+		println("Gogs: push rejected:", err.Error(), "("+base.FileSize(q.UserSize), "of", base.FileSize(q.UserLimit)+")")
+		qlog.Fatalf("Push of %s to %s/%s exceeds quota of owner", userName, repoUserName, repoName)
+	} else if err != nil {
+		println("Gogs: internal error:", err.Error())
+		qlog.Fatalf("Fail to check quota of %s/%s: %v", repoUserName, repoName, err)
+	}
+
+	if err = models.RunCustomUpdateHook(models.RepoPath(repoUserName, repoName), args[0], args[1], args[2]); err != nil {
+		println("Gogs: push rejected by custom update hook of repository")
+		qlog.Fatalf("Custom update hook of %s/%s rejected push of %s to %s: %v", repoUserName, repoName, userName, args[0], err)
+	}
+
+	// Pushes over HTTP are recorded after receive-pack has finished.
+	if isHttpPush {
+		return
+	}
+	if err = models.Update(args[0], args[1], args[2], userName, repoUserName, repoName, userId); err != nil {
+		qlog.Fatalf("Fail to update %s of %s/%s: %v", args[0], repoUserName, repoName, err)
+	}
 }
@@ -15,6 +15,7 @@ import (
 	"github.com/codegangsta/cli"
 	"github.com/go-martini/martini"
 
+	"github.com/gogits/gogs/models"
 	"github.com/gogits/gogs/modules/auth"
 	"github.com/gogits/gogs/modules/auth/apiv1"
 	"github.com/gogits/gogs/modules/avatar"
@@ -76,23 +77,37 @@ func runWeb(*cli.Context) {
 		IndentJSON: true,
 	}))
 	m.Use(middleware.InitContext())
+	m.Use(middleware.Csrf())
 
 	reqSignIn := middleware.Toggle(&middleware.ToggleOptions{SignInRequire: true})
+	reqActive := middleware.Toggle(&middleware.ToggleOptions{SignInRequire: true, ActiveRequire: true})
 	ignSignIn := middleware.Toggle(&middleware.ToggleOptions{SignInRequire: setting.Service.RequireSignInView})
 	ignSignInAndCsrf := middleware.Toggle(&middleware.ToggleOptions{DisableCsrf: true})
 
 	reqSignOut := middleware.Toggle(&middleware.ToggleOptions{SignOutRequire: true})
 
+	// Validates CSRF token for routes that do not have any toggle above.
+	reqCsrf := middleware.ValidateCsrf()
+
+	// Restricts networks that are able to sign in or access admin panel.
+	authIpFilter := middleware.IpFilter(setting.AuthAllowNets, setting.AuthDenyNets)
+	adminIpFilter := middleware.IpFilter(setting.AdminAllowNets, setting.AdminDenyNets)
+
 	bindIgnErr := binding.BindIgnErr
 
 	// Routers.
 	m.Get("/", ignSignIn, routers.Home)
 	m.Get("/install", bindIgnErr(auth.InstallForm{}), routers.Install)
-	m.Post("/install", bindIgnErr(auth.InstallForm{}), routers.InstallPost)
+	m.Post("/install", reqCsrf, bindIgnErr(auth.InstallForm{}), routers.InstallPost)
+	m.Get("/explore", ignSignIn, routers.Explore)
+	m.Get("/search", ignSignIn, routers.SearchCode)
 	m.Group("", func(r martini.Router) {
 		r.Get("/issues", user.Issues)
 		r.Get("/pulls", user.Pulls)
 		r.Get("/stars", user.Stars)
+		r.Get("/notifications", user.Notifications)
+		r.Get("/notifications/:id", user.NotificationRedirect)
+		r.Post("/notifications/read", user.MarkNotificationsRead)
 	}, reqSignIn)
 
 	m.Group("/api", func(r martini.Router) {
@@ -102,12 +117,156 @@ func runWeb(*cli.Context) {
 			r.Post("/markdown/raw", v1.MarkdownRaw)
 
 			// Users.
-			r.Get("/users/search", v1.SearchUser)
+			r.Get("/users/search", middleware.ApiReqScope(models.SCOPE_USER), v1.SearchUser)
+			r.Get("/users/:username", middleware.ApiReqScope(models.SCOPE_USER), v1.GetUser)
+			r.Get("/users/:username/keys", middleware.ApiReqScope(models.SCOPE_USER), v1.ListUserKeys)
+			r.Get("/users/:username/repos", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.ListUserRepos)
+			r.Get("/users/:username/events", middleware.ApiReqScope(models.SCOPE_USER), v1.ListUserEvents)
+
+			// Signed in user, its keys, e-mails and repositories.
+			m.Group("/user", func(r martini.Router) {
+				r.Get("", middleware.ApiReqScope(models.SCOPE_USER), v1.GetAuthenticatedUser)
+				r.Patch("", middleware.ApiReqScope(models.SCOPE_USER), bindIgnErr(apiv1.EditUserForm{}), v1.EditAuthenticatedUser)
+				r.Get("/emails", middleware.ApiReqScope(models.SCOPE_USER), v1.ListEmails)
+				r.Get("/keys", middleware.ApiReqScope(models.SCOPE_USER), v1.ListMyKeys)
+				r.Post("/keys", middleware.ApiReqActive(), middleware.ApiReqScope(models.SCOPE_USER),
+					bindIgnErr(apiv1.AddPublicKeyForm{}), v1.AddMyKey)
+				r.Get("/keys/:id", middleware.ApiReqScope(models.SCOPE_USER), v1.GetMyKey)
+				r.Delete("/keys/:id", middleware.ApiReqScope(models.SCOPE_USER), v1.DeleteMyKey)
+				r.Get("/repos", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.ListMyRepos)
+				r.Post("/repos", middleware.ApiReqActive(), middleware.ApiReqScope(models.SCOPE_REPO_WRITE),
+					bindIgnErr(apiv1.CreateRepoForm{}), v1.CreateRepo)
+			}, middleware.ApiReqSignIn())
+
+			// Notifications of signed in user.
+			m.Group("/notifications", func(r martini.Router) {
+				r.Get("", v1.ListNotifications)
+				r.Put("", v1.MarkNotificationsRead)
+				r.Get("/threads/:id", v1.GetNotification)
+				r.Patch("/threads/:id", v1.MarkNotificationRead)
+			}, middleware.ApiReqSignIn(), middleware.ApiReqScope(models.SCOPE_USER))
+
+			// Site administration.
+			m.Group("/admin", func(r martini.Router) {
+				r.Get("/stats", v1.GetStatistics)
+				r.Post("/users", bindIgnErr(apiv1.CreateUserForm{}), v1.AdminCreateUser)
+				r.Delete("/users/:username", v1.AdminDeleteUser)
+				r.Put("/users/:username/suspended", v1.AdminSuspendUser)
+				r.Delete("/users/:username/suspended", v1.AdminUnsuspendUser)
+				r.Post("/users/:username/repos", bindIgnErr(apiv1.CreateRepoForm{}), v1.AdminCreateRepo)
+				r.Get("/users/:username/keys", v1.AdminListUserKeys)
+				r.Post("/users/:username/keys", bindIgnErr(apiv1.AddPublicKeyForm{}), v1.AdminAddUserKey)
+				r.Delete("/users/:username/keys/:id", v1.AdminDeleteUserKey)
+			}, middleware.ApiReqAdmin(), middleware.ApiReqScope(models.SCOPE_ADMIN))
+
+			// Search, private repositories are only searched when token is granted to read them.
+			r.Get("/code/search", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.SearchCode)
+			m.Group("/search", func(r martini.Router) {
+				r.Get("/repos", v1.SearchRepos)
+				r.Get("/users", middleware.ApiReqScope(models.SCOPE_USER), v1.SearchUsers)
+				r.Get("/code", v1.SearchCode)
+			})
+
+			// Repositories, commit statuses, collaborators, deploy keys, webhooks, issues,
+			// labels, milestones, releases and Git data.
+			m.Group("/repos/:username/:reponame", func(r martini.Router) {
+				r.Get("", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.GetRepo)
+				r.Patch("", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), bindIgnErr(apiv1.EditRepoForm{}), v1.EditRepo)
+				r.Delete("", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.DeleteRepo)
+				r.Get("/events", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.ListRepoEvents)
+				r.Get("/issues", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.ListIssues)
+				r.Get("/issues/pinned", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.ListPinnedIssues)
+				r.Put("/issues/:index/pin", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.PinIssue)
+				r.Delete("/issues/:index/pin", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.UnpinIssue)
+				r.Patch("/issues/:index/pin/:position", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.MovePinnedIssue)
+				r.Post("/issues", middleware.ApiReqSignIn(), middleware.ApiReqScope(models.SCOPE_REPO_WRITE),
+					bindIgnErr(apiv1.CreateIssueForm{}), v1.CreateIssue)
+				r.Get("/issues/:index", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.GetIssue)
+				r.Patch("/issues/:index", middleware.ApiReqSignIn(), middleware.ApiReqScope(models.SCOPE_REPO_WRITE),
+					bindIgnErr(apiv1.EditIssueForm{}), v1.EditIssue)
+				r.Get("/issues/:index/comments", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.ListIssueComments)
+				r.Post("/issues/:index/comments", middleware.ApiReqSignIn(), middleware.ApiReqScope(models.SCOPE_REPO_WRITE),
+					bindIgnErr(apiv1.CreateCommentForm{}), v1.CreateIssueComment)
+				r.Patch("/issues/:index/comments/:id", middleware.ApiReqSignIn(), middleware.ApiReqScope(models.SCOPE_REPO_WRITE),
+					bindIgnErr(apiv1.CreateCommentForm{}), v1.EditIssueComment)
+				r.Delete("/issues/:index/comments/:id", middleware.ApiReqSignIn(), middleware.ApiReqScope(models.SCOPE_REPO_WRITE),
+					v1.DeleteIssueComment)
+				r.Get("/labels", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.ListLabels)
+				r.Post("/labels", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), bindIgnErr(apiv1.CreateLabelForm{}), v1.CreateLabel)
+				r.Get("/labels/:id", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.GetLabel)
+				r.Patch("/labels/:id", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), bindIgnErr(apiv1.CreateLabelForm{}), v1.EditLabel)
+				r.Delete("/labels/:id", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.DeleteLabel)
+				r.Get("/milestones", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.ListMilestones)
+				r.Post("/milestones", middleware.ApiReqScope(models.SCOPE_REPO_WRITE),
+					bindIgnErr(apiv1.CreateMilestoneForm{}), v1.CreateMilestone)
+				r.Get("/milestones/:index", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.GetMilestone)
+				r.Patch("/milestones/:index", middleware.ApiReqScope(models.SCOPE_REPO_WRITE),
+					bindIgnErr(apiv1.EditMilestoneForm{}), v1.EditMilestone)
+				r.Delete("/milestones/:index", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.DeleteMilestone)
+				r.Get("/statuses/:sha", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.ListCommitStatuses)
+				r.Post("/statuses/:sha", middleware.ApiReqScope(models.SCOPE_REPO_WRITE),
+					bindIgnErr(apiv1.CreateCommitStatusForm{}), v1.CreateCommitStatus)
+				r.Get("/commits", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.ListCommits)
+				r.Get("/commits/:sha", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.GetCommit)
+				r.Get("/commits/:sha/status", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.GetCombinedCommitStatus)
+				r.Get("/branches", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.ListBranches)
+				r.Get("/branches/**", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.GetBranch)
+				r.Get("/compare/**", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.CompareCommits)
+				r.Get("/collaborators", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.ListCollaborators)
+				r.Put("/collaborators/:collaborator", middleware.ApiReqScope(models.SCOPE_REPO_WRITE),
+					bindIgnErr(apiv1.AddCollaboratorForm{}), v1.AddCollaborator)
+				r.Delete("/collaborators/:collaborator", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.RemoveCollaborator)
+				r.Get("/keys", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.ListDeployKeys)
+				r.Post("/keys", middleware.ApiReqActive(), middleware.ApiReqScope(models.SCOPE_REPO_WRITE),
+					bindIgnErr(apiv1.AddDeployKeyForm{}), v1.AddDeployKey)
+				r.Get("/keys/:id", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.GetDeployKey)
+				r.Delete("/keys/:id", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.DeleteDeployKey)
+				r.Get("/hooks", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.ListHooks)
+				r.Post("/hooks", middleware.ApiReqScope(models.SCOPE_REPO_WRITE),
+					bindIgnErr(apiv1.CreateHookForm{}), v1.CreateHook)
+				r.Get("/hooks/:id", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.GetHook)
+				r.Patch("/hooks/:id", middleware.ApiReqScope(models.SCOPE_REPO_WRITE),
+					bindIgnErr(apiv1.EditHookForm{}), v1.EditHook)
+				r.Delete("/hooks/:id", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.DeleteHook)
+				r.Post("/hooks/:id/tests", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.TestHook)
+				r.Get("/hooks/:id/deliveries", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.ListHookDeliveries)
+				r.Get("/hooks/:id/deliveries/:delivery", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.GetHookDelivery)
+				r.Post("/hooks/:id/deliveries/:delivery/redeliver", middleware.ApiReqScope(models.SCOPE_REPO_WRITE),
+					v1.RedeliverHookDelivery)
+				r.Get("/releases", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.ListReleases)
+				r.Post("/releases", middleware.ApiReqScope(models.SCOPE_REPO_WRITE),
+					bindIgnErr(apiv1.CreateReleaseForm{}), v1.CreateRelease)
+				r.Get("/releases/tags/:tag", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.GetReleaseByTag)
+				r.Get("/releases/:id", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.GetRelease)
+				r.Patch("/releases/:id", middleware.ApiReqScope(models.SCOPE_REPO_WRITE),
+					bindIgnErr(apiv1.EditReleaseForm{}), v1.EditRelease)
+				r.Delete("/releases/:id", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.DeleteRelease)
+				r.Get("/releases/:id/assets", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.ListReleaseAssets)
+				r.Post("/releases/:id/assets", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.UploadReleaseAsset)
+				r.Get("/releases/:id/assets/:asset", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.GetReleaseAsset)
+				r.Get("/releases/:id/assets/:asset/download", middleware.ApiReqScope(models.SCOPE_REPO_READ),
+					v1.DownloadReleaseAsset)
+				r.Delete("/releases/:id/assets/:asset", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.DeleteReleaseAsset)
+				r.Get("/contents", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.GetContents)
+				r.Get("/contents/**", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.GetContents)
+				r.Put("/contents/**", middleware.ApiReqScope(models.SCOPE_REPO_WRITE),
+					bindIgnErr(apiv1.UpdateFileForm{}), v1.UpdateContents)
+				r.Delete("/contents/**", middleware.ApiReqScope(models.SCOPE_REPO_WRITE),
+					bindIgnErr(apiv1.DeleteFileForm{}), v1.DeleteContents)
+				r.Get("/git/blobs/:sha", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.GetGitBlob)
+				r.Get("/git/blobs/:sha/raw", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.GetRawGitBlob)
+				r.Get("/git/trees/:sha", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.GetGitTree)
+				r.Get("/git/refs", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.ListGitRefs)
+				r.Get("/git/refs/**", middleware.ApiReqScope(models.SCOPE_REPO_READ), v1.ListGitRefs)
+				r.Post("/git/refs", middleware.ApiReqScope(models.SCOPE_REPO_WRITE),
+					bindIgnErr(apiv1.CreateRefForm{}), v1.CreateGitRef)
+				r.Delete("/git/refs/**", middleware.ApiReqScope(models.SCOPE_REPO_WRITE), v1.DeleteGitRef)
+			})
 
 			r.Any("**", func(ctx *middleware.Context) {
 				ctx.JSON(404, &base.ApiJsonErr{"Not Found", v1.DOC_URL})
 			})
-		})
+		}, middleware.ApiRateLimit(), middleware.ApiCsrf())
 	})
 
 	avt := avatar.CacheServer("public/img/avatar/", "public/img/avatar_default.jpg")
@@ -117,60 +276,100 @@ func runWeb(*cli.Context) {
 	m.Group("/user", func(r martini.Router) {
 		r.Get("/login", user.SignIn)
 		r.Post("/login", bindIgnErr(auth.LogInForm{}), user.SignInPost)
-		r.Get("/login/:name", user.SocialSignIn)
+		r.Get("/login/two_factor", user.TwoFactor)
+		r.Post("/login/two_factor", bindIgnErr(auth.TwoFactorAuthForm{}), user.TwoFactorPost)
+		r.Get("/login/two_factor_scratch", user.TwoFactorScratch)
+		r.Post("/login/two_factor_scratch", bindIgnErr(auth.TwoFactorScratchAuthForm{}), user.TwoFactorScratchPost)
 		r.Get("/sign_up", user.SignUp)
 		r.Post("/sign_up", bindIgnErr(auth.RegisterForm{}), user.SignUpPost)
 		r.Get("/reset_password", user.ResetPasswd)
 		r.Post("/reset_password", user.ResetPasswdPost)
-	}, reqSignOut)
+	}, authIpFilter, reqSignOut)
 	m.Group("/user", func(r martini.Router) {
 		r.Get("/delete", user.Delete)
 		r.Post("/delete", user.DeletePost)
 		r.Get("/settings", user.Setting)
 		r.Post("/settings", bindIgnErr(auth.UpdateProfileForm{}), user.SettingPost)
+		r.Get("/transfers", user.Transfers)
+		r.Post("/transfers/:id/accept", user.AcceptTransfer)
+		r.Post("/transfers/:id/decline", user.DeclineTransfer)
 	}, reqSignIn)
 	m.Group("/user", func(r martini.Router) {
 		r.Get("/feeds", binding.Bind(auth.FeedsForm{}), user.Feeds)
 		r.Any("/activate", user.Activate)
 		r.Get("/email2user", user.Email2User)
+		r.Get("/login/oauth2/:name", authIpFilter, user.SocialSignIn)
 		r.Get("/forget_password", user.ForgotPasswd)
 		r.Post("/forget_password", user.ForgotPasswdPost)
 		r.Get("/logout", user.SignOut)
-	})
+	}, reqCsrf)
 	m.Group("/user/settings", func(r martini.Router) {
 		r.Get("/social", user.SettingSocial)
 		r.Get("/password", user.SettingPassword)
 		r.Post("/password", bindIgnErr(auth.UpdatePasswdForm{}), user.SettingPasswordPost)
-		r.Any("/ssh", bindIgnErr(auth.AddSSHKeyForm{}), user.SettingSSHKeys)
+		r.Any("/ssh", reqActive, bindIgnErr(auth.AddSSHKeyForm{}), user.SettingSSHKeys)
+		r.Get("/gpg", user.SettingGPGKeys)
+		r.Post("/gpg", bindIgnErr(auth.AddGPGKeyForm{}), user.SettingGPGKeysPost)
+		r.Post("/gpg/delete", user.SettingGPGKeysDelete)
+		r.Get("/applications", user.SettingApplications)
+		r.Post("/applications", bindIgnErr(auth.NewAccessTokenForm{}), user.SettingApplicationsPost)
+		r.Post("/applications/delete", user.SettingApplicationsDelete)
+		r.Post("/applications/oauth2", bindIgnErr(auth.NewOauthApplicationForm{}), user.SettingOauthApplicationPost)
+		r.Post("/applications/oauth2/delete", user.SettingOauthApplicationDelete)
 		r.Get("/notification", user.SettingNotification)
 		r.Get("/security", user.SettingSecurity)
+		r.Get("/security/two_factor/enroll", user.SettingTwoFactorEnroll)
+		r.Post("/security/two_factor/enroll", bindIgnErr(auth.TwoFactorAuthForm{}), user.SettingTwoFactorEnrollPost)
+		r.Post("/security/two_factor/regenerate_scratch", user.SettingTwoFactorRegenerateScratch)
+		r.Post("/security/two_factor/disable", user.SettingTwoFactorDisable)
+		r.Get("/sessions", user.SettingSessions)
+		r.Post("/sessions/revoke", user.SettingSessionsRevoke)
+		r.Post("/sessions/revoke_all", user.SettingSessionsRevokeAll)
 	}, reqSignIn)
 
 	m.Get("/user/:username", ignSignIn, user.Profile)
+	m.Get("/user/:username/activity.atom", ignSignIn, routers.UserFeed)
+	m.Get("/user/:username/activity.rss", ignSignIn, routers.UserFeed)
+
+	m.Group("/login/oauth", func(r martini.Router) {
+		r.Get("/authorize", reqSignIn, user.OauthAuthorize)
+		r.Post("/authorize", reqSignIn, user.OauthAuthorizePost)
+		r.Post("/access_token", ignSignInAndCsrf, user.OauthAccessToken)
+	}, authIpFilter)
 
 	m.Group("/repo", func(r martini.Router) {
 		r.Get("/create", repo.Create)
 		r.Post("/create", bindIgnErr(auth.CreateRepoForm{}), repo.CreatePost)
 		r.Get("/migrate", repo.Migrate)
 		r.Post("/migrate", bindIgnErr(auth.MigrateRepoForm{}), repo.MigratePost)
-	}, reqSignIn)
+	}, reqActive)
 
 	adminReq := middleware.Toggle(&middleware.ToggleOptions{SignInRequire: true, AdminRequire: true})
 
-	m.Get("/admin", adminReq, admin.Dashboard)
+	m.Get("/admin", adminIpFilter, adminReq, admin.Dashboard)
 	m.Group("/admin", func(r martini.Router) {
 		r.Get("/users", admin.Users)
 		r.Get("/repos", admin.Repositories)
+		r.Post("/repos/:id/size_limit", admin.RepoSizeLimitPost)
 		r.Get("/config", admin.Config)
 		r.Get("/auths", admin.Auths)
-	}, adminReq)
+		r.Get("/audit", admin.AuditLogs)
+		r.Get("/domains", admin.EmailDomains)
+		r.Post("/domains", bindIgnErr(auth.EmailDomainForm{}), admin.EmailDomainsPost)
+		r.Post("/domains/:id/delete", admin.DeleteEmailDomain)
+	}, adminIpFilter, adminReq)
 	m.Group("/admin/users", func(r martini.Router) {
 		r.Get("/new", admin.NewUser)
 		r.Post("/new", bindIgnErr(auth.RegisterForm{}), admin.NewUserPost)
+		r.Get("/new_bot", admin.NewBot)
+		r.Post("/new_bot", bindIgnErr(auth.NewBotForm{}), admin.NewBotPost)
 		r.Get("/:userid", admin.EditUser)
 		r.Post("/:userid", bindIgnErr(auth.AdminEditUserForm{}), admin.EditUserPost)
+		r.Post("/:userid/unlock", admin.UnlockUser)
+		r.Post("/:userid/tokens", admin.BotTokenPost)
+		r.Post("/:userid/keys", admin.BotSSHKeyPost)
 		r.Get("/:userid/delete", admin.DeleteUser)
-	}, adminReq)
+	}, adminIpFilter, adminReq)
 
 	m.Group("/admin/auths", func(r martini.Router) {
 		r.Get("/new", admin.NewAuthSource)
@@ -178,42 +377,84 @@ func runWeb(*cli.Context) {
 		r.Get("/:authid", admin.EditAuthSource)
 		r.Post("/:authid", bindIgnErr(auth.AuthenticationForm{}), admin.EditAuthSourcePost)
 		r.Get("/:authid/delete", admin.DeleteAuthSource)
-	}, adminReq)
+	}, adminIpFilter, adminReq)
 
 	if martini.Env == martini.Dev {
 		m.Get("/template/**", dev.TemplatePreview)
 	}
 
 	reqOwner := middleware.RequireOwner()
+	reqAdmin := middleware.RequireAdmin()
+	reqUnarchived := middleware.RequireUnarchived()
 
 	m.Group("/:username/:reponame", func(r martini.Router) {
 		r.Get("/settings", repo.Setting)
 		r.Post("/settings", bindIgnErr(auth.RepoSettingForm{}), repo.SettingPost)
+		r.Post("/settings/mirror", bindIgnErr(auth.MirrorSettingForm{}), repo.MirrorSettingPost)
+		r.Post("/settings/mirror/sync", repo.MirrorSync)
+		r.Post("/settings/transfer/cancel", repo.CancelTransfer)
+		r.Get("/settings/migration", repo.MigrationStatus)
 
 		m.Group("/settings", func(r martini.Router) {
 			r.Get("/collaboration", repo.Collaboration)
 			r.Post("/collaboration", repo.CollaborationPost)
+			r.Post("/collaboration/access_mode", repo.ChangeCollaborationAccessMode)
+			r.Get("/labels", repo.Labels)
+			r.Post("/labels/new", bindIgnErr(auth.CreateLabelForm{}), repo.NewLabel)
+			r.Post("/labels/:id", bindIgnErr(auth.CreateLabelForm{}), repo.UpdateLabel)
+			r.Post("/labels/:id/delete", repo.DeleteLabel)
 			r.Get("/hooks", repo.WebHooks)
 			r.Get("/hooks/add", repo.WebHooksAdd)
 			r.Post("/hooks/add", bindIgnErr(auth.NewWebhookForm{}), repo.WebHooksAddPost)
+			r.Get("/hooks/git", repo.GitHooks)
+			r.Get("/hooks/git/:name", repo.GitHooksEdit)
+			r.Post("/hooks/git/:name", repo.GitHooksEditPost)
 			r.Get("/hooks/:id", repo.WebHooksEdit)
 			r.Post("/hooks/:id", bindIgnErr(auth.NewWebhookForm{}), repo.WebHooksEditPost)
+			r.Get("/keys", repo.DeployKeys)
+			r.Post("/keys", reqActive, bindIgnErr(auth.AddDeployKeyForm{}), repo.DeployKeysPost)
+			r.Post("/keys/delete", repo.DeployKeysDelete)
+			r.Get("/branches", repo.ProtectedBranches)
+			r.Post("/branches", bindIgnErr(auth.ProtectedBranchForm{}), repo.ProtectedBranchesPost)
+			r.Post("/branches/:id", bindIgnErr(auth.ProtectedBranchForm{}), repo.UpdateProtectedBranch)
+			r.Post("/branches/:id/delete", repo.DeleteProtectedBranch)
+			r.Get("/push_mirrors", repo.PushMirrors)
+			r.Post("/push_mirrors", bindIgnErr(auth.PushMirrorForm{}), repo.PushMirrorsPost)
+			r.Post("/push_mirrors/:id/sync", repo.SyncPushMirror)
+			r.Post("/push_mirrors/:id/delete", repo.DeletePushMirror)
 		})
+
+		r.Post("/branches/default", repo.ChangeDefaultBranchPost)
+	}, reqSignIn, middleware.RepoAssignment(true), reqAdmin)
+
+	m.Group("/:username/:reponame", func(r martini.Router) {
+		r.Post("/branches/delete", reqUnarchived, repo.DeleteBranchPost)
+		r.Post("/tags/new", reqUnarchived, bindIgnErr(auth.NewTagForm{}), repo.NewTagPost)
+		r.Post("/tags/delete", reqUnarchived, repo.DeleteTagPost)
 	}, reqSignIn, middleware.RepoAssignment(true), reqOwner)
 
 	m.Group("/:username/:reponame", func(r martini.Router) {
 		r.Get("/action/:action", repo.Action)
 
 		m.Group("/issues", func(r martini.Router) {
-			r.Get("/new", repo.CreateIssue)
-			r.Post("/new", bindIgnErr(auth.CreateIssueForm{}), repo.CreateIssuePost)
+			r.Get("/new", reqUnarchived, repo.CreateIssue)
+			r.Post("/new", reqUnarchived, bindIgnErr(auth.CreateIssueForm{}), repo.CreateIssuePost)
+			r.Post("/attachments", reqUnarchived, repo.UploadIssueAttachment)
+			r.Post("/filters", bindIgnErr(auth.SaveIssueFilterForm{}), repo.SaveIssueFilter)
+			r.Post("/bulk", reqOwner, reqUnarchived, repo.BulkEditIssues)
+			r.Post("/filters/:id/delete", repo.DeleteIssueFilter)
 			r.Post("/:index", bindIgnErr(auth.CreateIssueForm{}), repo.UpdateIssue)
 			r.Post("/:index/label", repo.UpdateIssueLabel)
 			r.Post("/:index/milestone", repo.UpdateIssueMilestone)
+			r.Post("/:index/deadline", repo.UpdateIssueDeadline)
+			r.Post("/:index/dependencies", reqOwner, repo.AddIssueDependency)
+			r.Post("/:index/dependencies/:dependency/delete", reqOwner, repo.RemoveIssueDependency)
 			r.Post("/:index/assignee", repo.UpdateAssignee)
-			r.Post("/labels/new", bindIgnErr(auth.CreateLabelForm{}), repo.NewLabel)
-			r.Post("/labels/edit", bindIgnErr(auth.CreateLabelForm{}), repo.UpdateLabel)
-			r.Post("/labels/delete", repo.DeleteLabel)
+			r.Post("/:index/subscribe", repo.SubscribeIssue)
+			r.Post("/:index/pin", reqOwner, repo.PinIssue)
+			r.Post("/:index/pin/move", reqOwner, repo.MovePinnedIssue)
+			r.Post("/:index/comments/:id/edit", reqUnarchived, repo.EditComment)
+			r.Post("/:index/comments/:id/delete", reqUnarchived, repo.DeleteComment)
 			r.Get("/milestones", repo.Milestones)
 			r.Get("/milestones/new", repo.NewMilestone)
 			r.Post("/milestones/new", bindIgnErr(auth.CreateMilestoneForm{}), repo.NewMilestonePost)
@@ -222,33 +463,115 @@ func runWeb(*cli.Context) {
 			r.Get("/milestones/:index/:action", repo.UpdateMilestone)
 		})
 
-		r.Post("/comment/:action", repo.Comment)
-		r.Get("/releases/new", repo.ReleasesNew)
+		r.Get("/fork", reqActive, repo.Fork)
+		r.Post("/fork", reqActive, bindIgnErr(auth.ForkRepoForm{}), repo.ForkPost)
+		r.Post("/comment/:action", reqUnarchived, repo.Comment)
+		r.Get("/releases/new", reqUnarchived, repo.ReleasesNew)
+		r.Post("/compare/**", reqUnarchived, bindIgnErr(auth.CreatePullRequestForm{}), repo.CompareAndPullRequestPost)
+		r.Post("/pulls/:index/merge", reqUnarchived, repo.MergePullRequest)
+		r.Post("/pulls/:index/auto_merge", reqUnarchived, repo.AutoMergePullRequest)
+		r.Post("/pulls/:index/ready", reqUnarchived, repo.ReadyPullRequest)
+		r.Get("/pulls/:index/conflicts", repo.PullConflicts)
+		r.Post("/pulls/:index/conflicts", reqUnarchived, repo.PullConflictsPost)
+		r.Post("/pulls/:index/reviewers", reqUnarchived, repo.RequestPullReview)
+		r.Post("/pulls/:index/reviewers/:uid/delete", reqUnarchived, repo.RemovePullReviewRequest)
+		r.Post("/pulls/:index/reviews", reqUnarchived, repo.NewReviewThread)
+		r.Post("/pulls/:index/reviews/submit", reqUnarchived, repo.SubmitPullReview)
+		r.Post("/pulls/:index/reviews/:id", reqUnarchived, repo.ReplyReviewThread)
+		r.Post("/pulls/:index/reviews/:id/resolve", reqUnarchived, repo.ResolveReviewThread)
+		r.Post("/pulls/:index/reviews/:id/apply", reqUnarchived, repo.ApplyReviewSuggestion)
+		r.Post("/pulls/:index/branch/delete", repo.DeletePullHeadBranch)
+		r.Post("/pulls/:index/branch/restore", repo.RestorePullHeadBranch)
 	}, reqSignIn, middleware.RepoAssignment(true))
 
 	m.Group("/:username/:reponame", func(r martini.Router) {
-		r.Post("/releases/new", bindIgnErr(auth.NewReleaseForm{}), repo.ReleasesNewPost)
+		r.Post("/releases/new", reqUnarchived, bindIgnErr(auth.NewReleaseForm{}), repo.ReleasesNewPost)
+		r.Get("/releases/edit/:id", repo.ReleasesEdit)
+		r.Post("/releases/edit/:id", bindIgnErr(auth.NewReleaseForm{}), repo.ReleasesEditPost)
+		r.Post("/releases/attachments/:sha1/delete", repo.ReleaseAttachmentDelete)
+		r.Post("/commit/:branchname/comments", reqUnarchived, repo.NewCommitComment)
+		r.Post("/commit/:branchname/comments/:id/delete", reqUnarchived, repo.DeleteCommitComment)
 	}, reqSignIn, middleware.RepoAssignment(true, true))
 
+	m.Group("/:username/:reponame", func(r martini.Router) {
+		r.Get("/_new/:branchname", repo.NewFile)
+		r.Post("/_new/:branchname", bindIgnErr(auth.EditRepoFileForm{}), repo.NewFilePost)
+		r.Get("/_new/:branchname/**", repo.NewFile)
+		r.Post("/_new/:branchname/**", bindIgnErr(auth.EditRepoFileForm{}), repo.NewFilePost)
+		r.Get("/_edit/:branchname/**", repo.EditFile)
+		r.Post("/_edit/:branchname/**", bindIgnErr(auth.EditRepoFileForm{}), repo.EditFilePost)
+		r.Get("/_delete/:branchname/**", repo.DeleteFile)
+		r.Post("/_delete/:branchname/**", bindIgnErr(auth.DeleteRepoFileForm{}), repo.DeleteFilePost)
+		r.Post("/_cherry-pick/:branchname", repo.CherryPickPost)
+	}, reqSignIn, middleware.RepoAssignment(true, true), reqOwner, reqUnarchived)
+
+	m.Group("/:username/:reponame/projects", func(r martini.Router) {
+		r.Get("/new", repo.NewProject)
+		r.Post("/new", bindIgnErr(auth.CreateProjectForm{}), repo.NewProjectPost)
+		r.Get("/:id/edit", repo.EditProject)
+		r.Post("/:id/edit", bindIgnErr(auth.CreateProjectForm{}), repo.EditProjectPost)
+		r.Post("/:id/columns", bindIgnErr(auth.ProjectColumnForm{}), repo.NewProjectColumnPost)
+		r.Post("/:id/columns/:column/edit", bindIgnErr(auth.ProjectColumnForm{}), repo.EditProjectColumnPost)
+		r.Post("/:id/columns/:column/delete", repo.DeleteProjectColumn)
+		r.Post("/:id/cards", repo.NewProjectCardPost)
+		r.Post("/:id/cards/:card/move", repo.MoveProjectCard)
+		r.Post("/:id/cards/:card/delete", repo.DeleteProjectCard)
+		r.Post("/:id/:action", repo.ChangeProjectStatus)
+	}, reqSignIn, middleware.RepoAssignment(true), reqOwner, reqUnarchived)
+
+	m.Group("/:username/:reponame/wiki", func(r martini.Router) {
+		r.Get("/_new", repo.NewWikiPage)
+		r.Post("/_new", bindIgnErr(auth.WikiPageForm{}), repo.NewWikiPagePost)
+		r.Get("/:page/_edit", repo.EditWikiPage)
+		r.Post("/:page/_edit", bindIgnErr(auth.WikiPageForm{}), repo.EditWikiPagePost)
+		r.Post("/:page/_delete", repo.DeleteWikiPage)
+	}, reqSignIn, middleware.RepoAssignment(true), reqOwner, reqUnarchived)
+
+	m.Get("/:username/:reponame/wiki", ignSignIn, middleware.RepoAssignment(true), repo.Wiki)
+	m.Group("/:username/:reponame/wiki", func(r martini.Router) {
+		r.Get("/_pages", repo.WikiPages)
+		r.Get("/:page", repo.Wiki)
+		r.Get("/:page/_history", repo.WikiHistory)
+	}, ignSignIn, middleware.RepoAssignment(true))
+
 	m.Group("/:username/:reponame", func(r martini.Router) {
 		r.Get("/issues", repo.Issues)
+		r.Get("/issues/export", repo.ExportIssues)
 		r.Get("/issues/:index", repo.ViewIssue)
+		r.Get("/issues/:index/comments/:id/history", repo.CommentHistory)
+		r.Get("/issues/attachments/:sha1", repo.IssueAttachmentDownload)
+		r.Get("/issues/attachments/:sha1/thumbnail", repo.IssueAttachmentThumbnail)
+		r.Get("/projects", repo.Projects)
+		r.Get("/projects/:id", repo.ViewProject)
+		r.Get("/forks", repo.Forks)
 		r.Get("/pulls", repo.Pulls)
+		r.Get("/pulls/:index", repo.ViewPull)
+		r.Get("/pulls/:index/commits", repo.ViewPullCommits)
+		r.Get("/pulls/:index/files", repo.ViewPullFiles)
+		r.Get("/pulls/:index/files/diff", repo.ViewPullFileDiff)
+		r.Get("/compare", repo.CompareAndPullRequest)
+		r.Get("/compare/**", repo.CompareAndPullRequest)
 		r.Get("/branches", repo.Branches)
+		r.Get("/graphs", repo.Graphs)
+		r.Get("/graphs/:kind/data", repo.GraphsData)
+		r.Get("/activity.atom", routers.RepoFeed)
+		r.Get("/activity.rss", routers.RepoFeed)
 	}, ignSignIn, middleware.RepoAssignment(true))
 
 	m.Group("/:username/:reponame", func(r martini.Router) {
 		r.Get("/src/:branchname", repo.Single)
 		r.Get("/src/:branchname/**", repo.Single)
 		r.Get("/raw/:branchname/**", repo.SingleDownload)
+		r.Get("/blame/:branchname/**", repo.Blame)
 		r.Get("/commits/:branchname", repo.Commits)
 		r.Get("/commits/:branchname/search", repo.SearchCommits)
 		r.Get("/commits/:branchname/**", repo.FileHistory)
 		r.Get("/commit/:branchname", repo.Diff)
 		r.Get("/commit/:branchname/**", repo.Diff)
 		r.Get("/releases", repo.Releases)
-		r.Get("/archive/:branchname/:reponame.zip", repo.ZipDownload)
-		r.Get("/archive/:branchname/:reponame.tar.gz", repo.TarGzDownload)
+		r.Get("/tags", repo.Tags)
+		r.Get("/releases/attachments/:sha1", repo.ReleaseAttachmentDownload)
+		r.Get("/archive/**", repo.Archive)
 	}, ignSignIn, middleware.RepoAssignment(true, true))
 
 	m.Group("/:username", func(r martini.Router) {
@@ -11,12 +11,40 @@ import (
 	"github.com/go-xorm/xorm"
 )
 
-// Access types.
+// Access types, higher one includes lower ones.
 const (
 	AU_READABLE = iota + 1
 	AU_WRITABLE
+	AU_ADMIN // Can also manage settings and collaborators of repository.
 )
 
+var accessModeNames = map[int]string{
+	AU_READABLE: "Read",
+	AU_WRITABLE: "Write",
+	AU_ADMIN:    "Admin",
+}
+
+// IsValidAccessMode returns true if given mode is one of access types.
+func IsValidAccessMode(mode int) bool {
+	_, ok := accessModeNames[mode]
+	return ok
+}
+
+// ParseAccessMode returns access type of given name, or 0 if name is not valid.
+func ParseAccessMode(name string) int {
+	for mode, n := range accessModeNames {
+		if strings.EqualFold(n, name) {
+			return mode
+		}
+	}
+	return 0
+}
+
+// AccessModeName returns display name of access type.
+func AccessModeName(mode int) string {
+	return accessModeNames[mode]
+}
+
 // Access represents the accessibility of user to repository.
 type Access struct {
 	Id       int64
@@ -57,6 +85,27 @@ func UpdateAccessWithSession(sess *xorm.Session, access *Access) error {
 	return nil
 }
 
+// GetAccessMode returns access type of user to given repository, or 0 if user has no access.
+// The repoName should be in format <username>/<reponame>.
+func GetAccessMode(uname, repoName string) (int, error) {
+	if len(repoName) == 0 {
+		return 0, nil
+	}
+	access := new(Access)
+	has, err := orm.Where("user_name=? AND repo_name=?", strings.ToLower(uname), strings.ToLower(repoName)).Get(access)
+	if err != nil || !has {
+		return 0, err
+	}
+	return access.Mode, nil
+}
+
+// ChangeAccessMode changes access type of user to given repository.
+func ChangeAccessMode(uname, repoName string, mode int) error {
+	_, err := orm.Where("user_name=? AND repo_name=?", strings.ToLower(uname), strings.ToLower(repoName)).
+		Cols("mode").Update(&Access{Mode: mode})
+	return err
+}
+
 // HasAccess returns true if someone can read or write to given repository.
 // The repoName should be in format <username>/<reponame>.
 func HasAccess(uname, repoName string, mode int) (bool, error) {
@@ -77,3 +126,14 @@ func HasAccess(uname, repoName string, mode int) (bool, error) {
 	}
 	return true, nil
 }
+
+// Collaborator represents a user who has access to repository.
+type Collaborator struct {
+	*User
+	Mode int
+}
+
+// ModeName returns display name of access type of collaborator.
+func (c *Collaborator) ModeName() string {
+	return AccessModeName(c.Mode)
+}
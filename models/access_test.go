@@ -0,0 +1,76 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestParseAccessMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected int
+	}{
+		{"read", AU_READABLE},
+		{"Write", AU_WRITABLE},
+		{"ADMIN", AU_ADMIN},
+		{"owner", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if mode := ParseAccessMode(tt.name); mode != tt.expected {
+			t.Errorf("ParseAccessMode(%q) = %d, expected %d", tt.name, mode, tt.expected)
+		}
+		if valid := IsValidAccessMode(tt.expected); valid != (tt.expected > 0) {
+			t.Errorf("IsValidAccessMode(%d) = %v, expected %v", tt.expected, valid, tt.expected > 0)
+		}
+	}
+}
+
+func TestChangeAccessMode(t *testing.T) {
+	defer prepareTestEnv(t)()
+	owner, u := newTestUser(t, "owner"), newTestUser(t, "user1")
+	newTestRepo(t, owner, "repo1")
+	for _, a := range []*Access{
+		{UserName: owner.LowerName, RepoName: "owner/repo1", Mode: AU_WRITABLE},
+		{UserName: u.LowerName, RepoName: "owner/repo1", Mode: AU_READABLE},
+	} {
+		if err := AddAccess(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if mode, err := GetAccessMode("", "owner/repo1"); err != nil || mode != 0 {
+		t.Errorf("GetAccessMode(anonymous) = (%d, %v), expected 0", mode, err)
+	}
+	if mode, err := GetAccessMode("User1", "Owner/Repo1"); err != nil || mode != AU_READABLE {
+		t.Errorf("GetAccessMode = (%d, %v), expected %d", mode, err, AU_READABLE)
+	}
+
+	if err := ChangeAccessMode(u.Name, "owner/repo1", AU_ADMIN); err != nil {
+		t.Fatalf("ChangeAccessMode: %v", err)
+	}
+	// Higher access type includes lower ones.
+	for _, mode := range []int{AU_READABLE, AU_WRITABLE, AU_ADMIN} {
+		if has, err := HasAccess(u.Name, "owner/repo1", mode); err != nil || !has {
+			t.Errorf("HasAccess(%d) = (%v, %v), expected true", mode, has, err)
+		}
+	}
+	if has, err := HasAccess(owner.Name, "owner/repo1", AU_ADMIN); err != nil || has {
+		t.Errorf("HasAccess(writer, admin) = (%v, %v), expected false", has, err)
+	}
+
+	collaborators, err := GetRepoCollaborators("owner/repo1")
+	if err != nil {
+		t.Fatalf("GetRepoCollaborators: %v", err)
+	}
+	modes := make(map[string]string)
+	for _, c := range collaborators {
+		modes[c.Name] = c.ModeName()
+	}
+	if len(modes) != 2 || modes["owner"] != "Write" || modes["user1"] != "Admin" {
+		t.Errorf("GetRepoCollaborators returns %v, expected owner with Write and user1 with Admin", modes)
+	}
+}
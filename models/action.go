@@ -32,6 +32,7 @@ const (
 	OP_TRANSFER_REPO
 	OP_PUSH_TAG
 	OP_COMMENT_ISSUE
+	OP_PUBLISH_RELEASE
 )
 
 // Action represents user operation type and other information to repository.,
@@ -171,8 +172,9 @@ func CommitRepoAction(userId, repoUserId int64, userName, actEmail string,
 			continue
 		}
 
-		p.Secret = w.Secret
-		hooks.AddHookTask(&hooks.HookTask{hooks.HTT_WEBHOOK, w.Url, p, w.ContentType, w.IsSsl})
+		if _, err = deliverWebhook(w, "push", p); err != nil {
+			return errors.New("action.CommitRepoAction(deliverWebhook): " + err.Error())
+		}
 	}
 	return nil
 }
@@ -180,7 +182,8 @@ func CommitRepoAction(userId, repoUserId int64, userName, actEmail string,
 // NewRepoAction adds new action for creating repository.
 func NewRepoAction(user *User, repo *Repository) (err error) {
 	if err = NotifyWatchers(&Action{ActUserId: user.Id, ActUserName: user.Name, ActEmail: user.Email,
-		OpType: OP_CREATE_REPO, RepoId: repo.Id, RepoName: repo.Name, IsPrivate: repo.IsPrivate}); err != nil {
+		OpType: OP_CREATE_REPO, RepoId: repo.Id, RepoUserName: user.Name, RepoName: repo.Name,
+		IsPrivate: repo.IsPrivate}); err != nil {
 		log.Error("action.NewRepoAction(notify watchers): %d/%s", user.Id, repo.Name)
 		return err
 	}
@@ -192,8 +195,8 @@ func NewRepoAction(user *User, repo *Repository) (err error) {
 // TransferRepoAction adds new action for transfering repository.
 func TransferRepoAction(user, newUser *User, repo *Repository) (err error) {
 	if err = NotifyWatchers(&Action{ActUserId: user.Id, ActUserName: user.Name, ActEmail: user.Email,
-		OpType: OP_TRANSFER_REPO, RepoId: repo.Id, RepoName: repo.Name, Content: newUser.Name,
-		IsPrivate: repo.IsPrivate}); err != nil {
+		OpType: OP_TRANSFER_REPO, RepoId: repo.Id, RepoUserName: user.Name, RepoName: repo.Name,
+		Content: newUser.Name, IsPrivate: repo.IsPrivate}); err != nil {
 		log.Error("action.TransferRepoAction(notify watchers): %d/%s", user.Id, repo.Name)
 		return err
 	}
@@ -202,6 +205,61 @@ func TransferRepoAction(user, newUser *User, repo *Repository) (err error) {
 	return err
 }
 
+// PublishReleaseAction adds new action for publishing release of repository.
+func PublishReleaseAction(user *User, repo *Repository, rel *Release) error {
+	if err := repo.GetOwner(); err != nil {
+		return err
+	}
+	if err := NotifyWatchers(&Action{ActUserId: user.Id, ActUserName: user.Name, ActEmail: user.Email,
+		OpType: OP_PUBLISH_RELEASE, RepoId: repo.Id, RepoUserName: repo.Owner.Name, RepoName: repo.Name,
+		RefName: rel.TagName, Content: rel.TagName + "|" + rel.Title, IsPrivate: repo.IsPrivate}); err != nil {
+		return errors.New("action.PublishReleaseAction(NotifyWatchers): " + err.Error())
+	}
+	return nil
+}
+
+// ActionListOptions represents conditions of listing actions.
+type ActionListOptions struct {
+	ActUserId      int64 // Actions performed by the user when it is positive.
+	RepoId         int64 // Actions on the repository when it is positive.
+	IncludePrivate bool  // Whether actions on private repositories are included.
+	Page           int   // Starts from 1.
+	PageSize       int
+}
+
+// ListActions returns actions that match given conditions newest first, and total number of them.
+// Every action is only returned once although a copy is kept for each watcher.
+func ListActions(opts *ActionListOptions) ([]*Action, int64, error) {
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+
+	cond := "user_id=act_user_id"
+	args := make([]interface{}, 0, 3)
+	if opts.ActUserId > 0 {
+		cond += " AND act_user_id=?"
+		args = append(args, opts.ActUserId)
+	}
+	if opts.RepoId > 0 {
+		cond += " AND repo_id=?"
+		args = append(args, opts.RepoId)
+	}
+	if !opts.IncludePrivate {
+		cond += " AND is_private=?"
+		args = append(args, false)
+	}
+
+	total, err := orm.Where(cond, args...).Count(new(Action))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	actions := make([]*Action, 0, opts.PageSize)
+	err = orm.Where(cond, args...).Desc("id").
+		Limit(opts.PageSize, (opts.Page-1)*opts.PageSize).Find(&actions)
+	return actions, total, err
+}
+
 // GetFeeds returns action list of given user in given context.
 func GetFeeds(userid, offset int64, isProfile bool) ([]*Action, error) {
 	actions := make([]*Action, 0, 20)
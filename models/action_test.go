@@ -0,0 +1,70 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestListActions(t *testing.T) {
+	defer prepareTestEnv(t)()
+	owner, u := newTestUser(t, "owner"), newTestUser(t, "user1")
+	public, private := newTestRepo(t, owner, "public"), newTestRepo(t, owner, "private")
+	private.IsPrivate = true
+	for _, repo := range []*Repository{public, private} {
+		if err := NewRepoAction(owner, repo); err != nil {
+			t.Fatalf("NewRepoAction: %v", err)
+		}
+	}
+	if err := WatchRepo(u.Id, public.Id, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := PublishReleaseAction(owner, public, &Release{TagName: "v1.0", Title: "First"}); err != nil {
+		t.Fatalf("PublishReleaseAction: %v", err)
+	}
+
+	// Watchers get their own copy of the action.
+	if n, err := orm.Count(&Action{UserId: u.Id, OpType: OP_PUBLISH_RELEASE}); err != nil || n != 1 {
+		t.Errorf("watcher has (%d, %v) release actions, expected 1", n, err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     *ActionListOptions
+		expected []int // Operation types.
+		total    int64
+	}{
+		{"public", &ActionListOptions{ActUserId: owner.Id, PageSize: 10}, []int{OP_PUBLISH_RELEASE, OP_CREATE_REPO}, 2},
+		{"private", &ActionListOptions{ActUserId: owner.Id, IncludePrivate: true, PageSize: 10}, []int{OP_PUBLISH_RELEASE, OP_CREATE_REPO, OP_CREATE_REPO}, 3},
+		{"page", &ActionListOptions{ActUserId: owner.Id, IncludePrivate: true, Page: 2, PageSize: 1}, []int{OP_CREATE_REPO}, 3},
+		{"repository", &ActionListOptions{RepoId: private.Id, IncludePrivate: true, PageSize: 10}, []int{OP_CREATE_REPO}, 1},
+		{"other user", &ActionListOptions{ActUserId: u.Id, IncludePrivate: true, PageSize: 10}, []int{}, 0},
+	}
+	for _, tt := range tests {
+		actions, total, err := ListActions(tt.opts)
+		if err != nil {
+			t.Fatalf("ListActions(%s): %v", tt.name, err)
+		}
+		types := make([]int, len(actions))
+		for i := range actions {
+			types[i] = actions[i].OpType
+		}
+		if !reflect.DeepEqual(types, tt.expected) || total != tt.total {
+			t.Errorf("ListActions(%s) = (%v, %d), expected (%v, %d)", tt.name, types, total, tt.expected, tt.total)
+		}
+	}
+
+	actions, _, err := ListActions(&ActionListOptions{RepoId: public.Id, PageSize: 1})
+	if err != nil || len(actions) != 1 {
+		t.Fatalf("ListActions(public) = (%d, %v), expected 1 action", len(actions), err)
+	}
+	if act := actions[0]; act.RepoUserName != owner.Name || act.RefName != "v1.0" || act.Content != "v1.0|First" {
+		t.Errorf("release action = %+v, expected owner name, tag name and title", act)
+	}
+	if actions[0].UserId != owner.Id {
+		t.Errorf("ListActions returns copy of user %d, expected %d", actions[0].UserId, owner.Id)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/setting"
+)
+
+var (
+	ErrActivationNotExist = errors.New("Activation code does not exist or has expired")
+)
+
+// EmailActivation represents a pending e-mail address confirmation,
+// only signature of code is stored so it cannot be forged from database.
+type EmailActivation struct {
+	Id      int64
+	Uid     int64     `xorm:"INDEX"`
+	Email   string    // E-mail address that code was sent to.
+	Sign    string    `xorm:"UNIQUE VARCHAR(40)"`
+	Created time.Time `xorm:"CREATED"`
+}
+
+func signActivationCode(code string) string {
+	h := hmac.New(sha1.New, []byte(setting.SecretKey))
+	h.Write([]byte(code))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func activationExpireTime() time.Time {
+	return time.Now().Add(-time.Duration(setting.Service.ActiveCodeLives) * time.Minute)
+}
+
+// NewEmailActivation creates a new activation code for given user,
+// all previous codes of the user become invalid.
+func NewEmailActivation(u *User) (string, error) {
+	code := base.GetRandomString(40)
+
+	sess := orm.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return "", err
+	}
+
+	if _, err := sess.Where("uid=?", u.Id).Delete(new(EmailActivation)); err != nil {
+		sess.Rollback()
+		return "", err
+	}
+	if _, err := sess.Insert(&EmailActivation{
+		Uid:   u.Id,
+		Email: u.Email,
+		Sign:  signActivationCode(code),
+	}); err != nil {
+		sess.Rollback()
+		return "", err
+	}
+	return code, sess.Commit()
+}
+
+// ActivateUserByCode activates user who owns given activation code,
+// the code can only be used once.
+func ActivateUserByCode(code string) (*User, error) {
+	if len(code) == 0 {
+		return nil, ErrActivationNotExist
+	}
+
+	a := &EmailActivation{Sign: signActivationCode(code)}
+	has, err := orm.Get(a)
+	if err != nil {
+		return nil, err
+	} else if !has || a.Created.Before(activationExpireTime()) {
+		return nil, ErrActivationNotExist
+	}
+
+	u, err := GetUserById(a.Uid)
+	if err != nil {
+		return nil, err
+	} else if u.Email != a.Email {
+		// E-mail address has been changed since code was sent.
+		return nil, ErrActivationNotExist
+	}
+
+	sess := orm.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	// Only the request that deletes the code can use it, concurrent ones fail.
+	if affected, err := sess.Id(a.Id).Delete(new(EmailActivation)); err != nil {
+		sess.Rollback()
+		return nil, err
+	} else if affected != 1 {
+		sess.Rollback()
+		return nil, ErrActivationNotExist
+	}
+	if _, err = sess.Where("uid=?", u.Id).Delete(new(EmailActivation)); err != nil {
+		sess.Rollback()
+		return nil, err
+	}
+	u.IsActive = true
+	u.Rands = GetUserSalt()
+	if _, err = sess.Id(u.Id).AllCols().Update(u); err != nil {
+		sess.Rollback()
+		return nil, err
+	}
+	return u, sess.Commit()
+}
+
+// DeleteExpiredEmailActivations deletes activation codes that have expired.
+func DeleteExpiredEmailActivations() {
+	if _, err := orm.Where("created<?", activationExpireTime()).Delete(new(EmailActivation)); err != nil {
+		log.Error("DeleteExpiredEmailActivations: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/gogits/gogs/modules/setting"
+)
+
+func TestActivateUserByCode(t *testing.T) {
+	defer prepareTestEnv(t)()
+	defer func(lives int) { setting.Service.ActiveCodeLives = lives }(setting.Service.ActiveCodeLives)
+	setting.Service.ActiveCodeLives = 180
+
+	u := newTestUser(t, "user1")
+	u.IsActive = false
+	if err := UpdateUser(u); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+
+	oldCode, err := NewEmailActivation(u)
+	if err != nil {
+		t.Fatalf("NewEmailActivation: %v", err)
+	}
+	code, err := NewEmailActivation(u)
+	if err != nil {
+		t.Fatalf("NewEmailActivation: %v", err)
+	}
+
+	if _, err = ActivateUserByCode(oldCode); err != ErrActivationNotExist {
+		t.Errorf("ActivateUserByCode(replaced code) error = %v, expected %v", err, ErrActivationNotExist)
+	}
+	if _, err = ActivateUserByCode(code[1:]); err != ErrActivationNotExist {
+		t.Errorf("ActivateUserByCode(wrong code) error = %v, expected %v", err, ErrActivationNotExist)
+	}
+
+	activated, err := ActivateUserByCode(code)
+	if err != nil {
+		t.Fatalf("ActivateUserByCode: %v", err)
+	} else if activated.Id != u.Id || !activated.IsActive {
+		t.Errorf("ActivateUserByCode activates user %d (active %v), expected %d", activated.Id, activated.IsActive, u.Id)
+	}
+	if _, err = ActivateUserByCode(code); err != ErrActivationNotExist {
+		t.Errorf("ActivateUserByCode(used code) error = %v, expected %v", err, ErrActivationNotExist)
+	}
+
+	// Code is only valid for the e-mail address it was sent to.
+	if code, err = NewEmailActivation(u); err != nil {
+		t.Fatalf("NewEmailActivation: %v", err)
+	}
+	u.Email = "user1@example.com"
+	if err = UpdateUser(u); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	if _, err = ActivateUserByCode(code); err != ErrActivationNotExist {
+		t.Errorf("ActivateUserByCode(e-mail changed) error = %v, expected %v", err, ErrActivationNotExist)
+	}
+}
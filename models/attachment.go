@@ -0,0 +1,257 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nfnt/resize"
+
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/setting"
+)
+
+var (
+	ErrAttachmentNotExist       = errors.New("Attachment does not exist")
+	ErrAttachmentTooLarge       = errors.New("Attachment is too large")
+	ErrAttachmentTypeNotAllowed = errors.New("Attachment type is not allowed")
+)
+
+// Width in pixels of thumbnails generated for image attachments.
+const THUMBNAIL_WIDTH = 600
+
+// Attachment represents a binary file attached to a release, an issue or a comment.
+// Issue attachments are uploaded before the issue or comment is created,
+// and linked to it once the form is submitted.
+type Attachment struct {
+	Id            int64
+	Sha1          string `xorm:"UNIQUE NOT NULL"`
+	ReleaseId     int64  `xorm:"INDEX"`
+	RepoId        int64  `xorm:"INDEX"`
+	IssueId       int64  `xorm:"INDEX"`
+	CommentId     int64  `xorm:"INDEX"`
+	Name          string
+	Size          int64
+	IsImage       bool
+	DownloadCount int64
+	Created       time.Time `xorm:"CREATED"`
+}
+
+// LocalPath returns where attachment file is stored in local file system.
+func (a *Attachment) LocalPath() string {
+	return filepath.Join(setting.AttachmentPath, a.Sha1[0:1], a.Sha1[1:2], a.Sha1)
+}
+
+// ThumbnailPath returns where thumbnail of image attachment is stored in local file system.
+func (a *Attachment) ThumbnailPath() string {
+	return a.LocalPath() + ".thumb"
+}
+
+// NewAttachment saves content of file to disk and creates a new attachment of given release.
+func NewAttachment(releaseId int64, name string, r io.Reader) (*Attachment, error) {
+	a := &Attachment{
+		Sha1:      base.EncodeSha1(base.GetRandomString(40)),
+		ReleaseId: releaseId,
+		Name:      filepath.Base(name),
+	}
+	if err := saveAttachment(a, r, setting.AttachmentMaxSize); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// isAllowedAttachmentType returns true if given MIME type can be attached to issues.
+func isAllowedAttachmentType(typ string) bool {
+	for _, t := range setting.IssueAttachmentAllowedTypes {
+		if t == typ || (strings.HasSuffix(t, "/*") && strings.HasPrefix(typ, t[:len(t)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewIssueAttachment checks type of file, saves its content to disk and creates
+// a new attachment in given repository that is not linked to any issue yet.
+func NewIssueAttachment(repoId int64, name string, r io.Reader) (*Attachment, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+
+	typ := http.DetectContentType(head)
+	if !isAllowedAttachmentType(typ) {
+		return nil, ErrAttachmentTypeNotAllowed
+	}
+
+	a := &Attachment{
+		Sha1:    base.EncodeSha1(base.GetRandomString(40)),
+		RepoId:  repoId,
+		Name:    filepath.Base(name),
+		IsImage: strings.HasPrefix(typ, "image/"),
+	}
+	if err = saveAttachment(a, io.MultiReader(bytes.NewReader(head), r), setting.IssueAttachmentMaxSize); err != nil {
+		return nil, err
+	}
+
+	if a.IsImage {
+		if err = a.generateThumbnail(); err != nil {
+			log.Error("Fail to generate thumbnail of attachment(%s): %v", a.Sha1, err)
+		}
+	}
+	return a, nil
+}
+
+// generateThumbnail creates a scaled down copy of image attachment.
+func (a *Attachment) generateThumbnail() error {
+	f, err := os.Open(a.LocalPath())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return err
+	}
+	if img.Bounds().Dx() > THUMBNAIL_WIDTH {
+		img = resize.Resize(THUMBNAIL_WIDTH, 0, img, resize.Lanczos3)
+	}
+
+	fw, err := os.Create(a.ThumbnailPath())
+	if err != nil {
+		return err
+	}
+	defer fw.Close()
+	return jpeg.Encode(fw, img, nil)
+}
+
+// saveAttachment saves content of file to disk and inserts attachment record.
+func saveAttachment(a *Attachment, r io.Reader, maxSizeMB int64) (err error) {
+	localPath := a.LocalPath()
+	if err = os.MkdirAll(filepath.Dir(localPath), os.ModePerm); err != nil {
+		return err
+	}
+	fw, err := os.Create(localPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		fw.Close()
+		if err != nil {
+			os.Remove(localPath)
+		}
+	}()
+
+	maxSize := maxSizeMB * 1024 * 1024
+	if a.Size, err = io.Copy(fw, io.LimitReader(r, maxSize+1)); err != nil {
+		return err
+	} else if a.Size > maxSize {
+		return ErrAttachmentTooLarge
+	}
+
+	_, err = orm.Insert(a)
+	return err
+}
+
+// GetAttachmentBySha1 returns attachment by given SHA1 key.
+func GetAttachmentBySha1(sha1 string) (*Attachment, error) {
+	a := &Attachment{Sha1: sha1}
+	has, err := orm.Get(a)
+	if err != nil {
+		return nil, err
+	} else if !has || len(sha1) == 0 {
+		return nil, ErrAttachmentNotExist
+	}
+	return a, nil
+}
+
+// GetAttachmentsByReleaseId returns all attachments of given release.
+func GetAttachmentsByReleaseId(releaseId int64) ([]*Attachment, error) {
+	attachments := make([]*Attachment, 0, 5)
+	err := orm.Where("release_id=?", releaseId).Asc("id").Find(&attachments)
+	return attachments, err
+}
+
+// GetAttachmentsByIssueId returns all attachments of given issue and its comments.
+func GetAttachmentsByIssueId(issueId int64) ([]*Attachment, error) {
+	attachments := make([]*Attachment, 0, 5)
+	err := orm.Where("issue_id=?", issueId).Asc("id").Find(&attachments)
+	return attachments, err
+}
+
+// LinkIssueAttachments links attachments uploaded to repository to given issue or comment.
+// Attachments that are already linked are left untouched.
+func LinkIssueAttachments(repoId, issueId, commentId int64, sha1s []string) error {
+	if len(sha1s) == 0 {
+		return nil
+	}
+	_, err := orm.Where("repo_id=? AND issue_id=0", repoId).In("sha1", sha1s).
+		Cols("issue_id", "comment_id").Update(&Attachment{IssueId: issueId, CommentId: commentId})
+	return err
+}
+
+// IncreaseAttachmentDownloadCount increases download count of attachment by one.
+func IncreaseAttachmentDownloadCount(a *Attachment) error {
+	_, err := orm.Exec("UPDATE `attachment` SET download_count = download_count + 1 WHERE id = ?", a.Id)
+	return err
+}
+
+// DeleteAttachment deletes attachment and its file.
+func DeleteAttachment(a *Attachment) error {
+	if _, err := orm.Id(a.Id).Delete(new(Attachment)); err != nil {
+		return err
+	}
+	if err := os.Remove(a.LocalPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if a.IsImage {
+		if err := os.Remove(a.ThumbnailPath()); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteRepoIssueAttachments deletes all issue attachments of given repository.
+func DeleteRepoIssueAttachments(repoId int64) error {
+	attachments := make([]*Attachment, 0, 5)
+	if err := orm.Where("repo_id=?", repoId).Find(&attachments); err != nil {
+		return err
+	}
+	for _, a := range attachments {
+		if err := DeleteAttachment(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteReleaseAttachments deletes all attachments of given release.
+func DeleteReleaseAttachments(releaseId int64) error {
+	attachments, err := GetAttachmentsByReleaseId(releaseId)
+	if err != nil {
+		return err
+	}
+	for _, a := range attachments {
+		if err = DeleteAttachment(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"testing"
+
+	"github.com/gogits/gogs/modules/setting"
+)
+
+func TestReleaseAttachments(t *testing.T) {
+	defer prepareTestEnv(t)()
+	defer func(maxSize int64) { setting.AttachmentMaxSize = maxSize }(setting.AttachmentMaxSize)
+	setting.AttachmentMaxSize = 1
+
+	a, err := NewAttachment(1, "../../dist/gogs.zip", bytes.NewReader([]byte("gogs")))
+	if err != nil {
+		t.Fatalf("NewAttachment: %v", err)
+	} else if a.Name != "gogs.zip" || a.Size != 4 {
+		t.Errorf("attachment has name %q and size %d, expected %q and 4", a.Name, a.Size, "gogs.zip")
+	}
+	if _, err = os.Stat(a.LocalPath()); err != nil {
+		t.Fatalf("attachment file is not saved: %v", err)
+	}
+
+	large := bytes.NewReader(make([]byte, 1024*1024+1))
+	if _, err = NewAttachment(1, "large.bin", large); err != ErrAttachmentTooLarge {
+		t.Errorf("NewAttachment(too large) error = %v, expected %v", err, ErrAttachmentTooLarge)
+	}
+	if attachments, err := GetAttachmentsByReleaseId(1); err != nil || len(attachments) != 1 {
+		t.Fatalf("GetAttachmentsByReleaseId = (%d attachments, %v), expected 1", len(attachments), err)
+	}
+
+	if _, err = GetAttachmentBySha1(""); err != ErrAttachmentNotExist {
+		t.Errorf("GetAttachmentBySha1(empty) error = %v, expected %v", err, ErrAttachmentNotExist)
+	}
+	if got, err := GetAttachmentBySha1(a.Sha1); err != nil || got.Id != a.Id {
+		t.Fatalf("GetAttachmentBySha1 = (%v, %v), expected attachment %d", got, err, a.Id)
+	}
+
+	if err = DeleteReleaseAttachments(1); err != nil {
+		t.Fatalf("DeleteReleaseAttachments: %v", err)
+	}
+	if _, err = os.Stat(a.LocalPath()); !os.IsNotExist(err) {
+		t.Errorf("attachment file still exists: %v", err)
+	}
+	if _, err = GetAttachmentBySha1(a.Sha1); err != ErrAttachmentNotExist {
+		t.Errorf("GetAttachmentBySha1(deleted) error = %v, expected %v", err, ErrAttachmentNotExist)
+	}
+}
+
+func TestIsAllowedAttachmentType(t *testing.T) {
+	defer func(types []string) { setting.IssueAttachmentAllowedTypes = types }(setting.IssueAttachmentAllowedTypes)
+	setting.IssueAttachmentAllowedTypes = []string{"image/*", "text/plain; charset=utf-8"}
+
+	tests := []struct {
+		typ      string
+		expected bool
+	}{
+		{"image/png", true},
+		{"image/", true},
+		{"text/plain; charset=utf-8", true},
+		{"text/plain", false},
+		{"application/zip", false},
+		{"imagex/png", false},
+	}
+	for _, tt := range tests {
+		if ok := isAllowedAttachmentType(tt.typ); ok != tt.expected {
+			t.Errorf("isAllowedAttachmentType(%q) = %v, expected %v", tt.typ, ok, tt.expected)
+		}
+	}
+}
+
+func TestIssueAttachments(t *testing.T) {
+	defer prepareTestEnv(t)()
+	defer func(types []string) { setting.IssueAttachmentAllowedTypes = types }(setting.IssueAttachmentAllowedTypes)
+	defer func(maxSize int64) { setting.IssueAttachmentMaxSize = maxSize }(setting.IssueAttachmentMaxSize)
+	setting.IssueAttachmentAllowedTypes = []string{"image/*", "text/plain; charset=utf-8"}
+	setting.IssueAttachmentMaxSize = 1
+
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, image.NewRGBA(image.Rect(0, 0, THUMBNAIL_WIDTH*2, 10))); err != nil {
+		t.Fatal(err)
+	}
+	img, err := NewIssueAttachment(1, "screenshot.png", buf)
+	if err != nil {
+		t.Fatalf("NewIssueAttachment(image): %v", err)
+	} else if !img.IsImage || img.IssueId != 0 {
+		t.Errorf("image attachment has IsImage %v and issue %d, expected true and 0", img.IsImage, img.IssueId)
+	}
+	f, err := os.Open(img.ThumbnailPath())
+	if err != nil {
+		t.Fatalf("thumbnail is not generated: %v", err)
+	}
+	thumb, err := jpeg.DecodeConfig(f)
+	f.Close()
+	if err != nil || thumb.Width != THUMBNAIL_WIDTH {
+		t.Errorf("thumbnail has width %d (%v), expected %d", thumb.Width, err, THUMBNAIL_WIDTH)
+	}
+
+	text, err := NewIssueAttachment(1, "log.txt", bytes.NewReader([]byte("short log")))
+	if err != nil {
+		t.Fatalf("NewIssueAttachment(text): %v", err)
+	} else if text.IsImage || text.Size != 9 {
+		t.Errorf("text attachment has IsImage %v and size %d, expected false and 9", text.IsImage, text.Size)
+	}
+	other, err := NewIssueAttachment(2, "other.txt", bytes.NewReader([]byte("other")))
+	if err != nil {
+		t.Fatalf("NewIssueAttachment(other repository): %v", err)
+	}
+
+	if _, err = NewIssueAttachment(1, "gogs.zip", bytes.NewReader([]byte("PK\x03\x04gogs"))); err != ErrAttachmentTypeNotAllowed {
+		t.Errorf("NewIssueAttachment(zip) error = %v, expected %v", err, ErrAttachmentTypeNotAllowed)
+	}
+	if _, err = NewIssueAttachment(1, "large.txt", bytes.NewReader(bytes.Repeat([]byte("a"), 1024*1024+1))); err != ErrAttachmentTooLarge {
+		t.Errorf("NewIssueAttachment(too large) error = %v, expected %v", err, ErrAttachmentTooLarge)
+	}
+
+	sha1s := []string{img.Sha1, text.Sha1, other.Sha1}
+	if err = LinkIssueAttachments(1, 5, 0, sha1s); err != nil {
+		t.Fatalf("LinkIssueAttachments: %v", err)
+	}
+	// Linked attachments are not moved to another issue.
+	if err = LinkIssueAttachments(1, 6, 3, sha1s); err != nil {
+		t.Fatalf("LinkIssueAttachments(linked): %v", err)
+	}
+	attachments, err := GetAttachmentsByIssueId(5)
+	if err != nil {
+		t.Fatalf("GetAttachmentsByIssueId: %v", err)
+	} else if len(attachments) != 2 || attachments[0].Id != img.Id || attachments[1].Id != text.Id {
+		t.Errorf("GetAttachmentsByIssueId returns %d attachments, expected %d and %d", len(attachments), img.Id, text.Id)
+	}
+	if attachments, err = GetAttachmentsByIssueId(6); err != nil || len(attachments) != 0 {
+		t.Errorf("GetAttachmentsByIssueId(6) = (%d attachments, %v), expected none", len(attachments), err)
+	}
+
+	if err = DeleteRepoIssueAttachments(1); err != nil {
+		t.Fatalf("DeleteRepoIssueAttachments: %v", err)
+	}
+	for _, p := range []string{img.LocalPath(), img.ThumbnailPath(), text.LocalPath()} {
+		if _, err = os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("attachment file %s still exists: %v", p, err)
+		}
+	}
+	if _, err = GetAttachmentBySha1(other.Sha1); err != nil {
+		t.Errorf("GetAttachmentBySha1(other repository): %v", err)
+	}
+}
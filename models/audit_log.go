@@ -0,0 +1,117 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gogits/gogs/modules/log"
+)
+
+// AuditAction represents type of security-relevant event.
+type AuditAction int
+
+const (
+	AUDIT_LOGIN AuditAction = iota + 1
+	AUDIT_LOGIN_FAILED
+	AUDIT_CHANGE_PASSWORD
+	AUDIT_RESET_PASSWORD
+	AUDIT_ADD_SSH_KEY
+	AUDIT_DELETE_SSH_KEY
+	AUDIT_ADD_DEPLOY_KEY
+	AUDIT_DELETE_DEPLOY_KEY
+	AUDIT_ADD_COLLABORATOR
+	AUDIT_REMOVE_COLLABORATOR
+	AUDIT_CHANGE_USER_PERMISSION
+	AUDIT_CHANGE_COLLABORATOR_ACCESS
+)
+
+var auditActionNames = map[AuditAction]string{
+	AUDIT_LOGIN:                      "Log in",
+	AUDIT_LOGIN_FAILED:               "Failed log in",
+	AUDIT_CHANGE_PASSWORD:            "Change password",
+	AUDIT_RESET_PASSWORD:             "Reset password",
+	AUDIT_ADD_SSH_KEY:                "Add SSH key",
+	AUDIT_DELETE_SSH_KEY:             "Delete SSH key",
+	AUDIT_ADD_DEPLOY_KEY:             "Add deploy key",
+	AUDIT_DELETE_DEPLOY_KEY:          "Delete deploy key",
+	AUDIT_ADD_COLLABORATOR:           "Add collaborator",
+	AUDIT_REMOVE_COLLABORATOR:        "Remove collaborator",
+	AUDIT_CHANGE_USER_PERMISSION:     "Change user permission",
+	AUDIT_CHANGE_COLLABORATOR_ACCESS: "Change collaborator access",
+}
+
+// AuditActions returns all types of audit action in order.
+func AuditActions() []AuditAction {
+	actions := make([]AuditAction, 0, len(auditActionNames))
+	for a := AUDIT_LOGIN; a <= AUDIT_CHANGE_COLLABORATOR_ACCESS; a++ {
+		actions = append(actions, a)
+	}
+	return actions
+}
+
+func (a AuditAction) Id() int {
+	return int(a)
+}
+
+func (a AuditAction) String() string {
+	return auditActionNames[a]
+}
+
+// AuditLog represents a security-relevant event.
+type AuditLog struct {
+	Id        int64
+	ActorId   int64       `xorm:"INDEX"` // 0 when actor is unknown, e.g. failed log in.
+	ActorName string      `xorm:"INDEX"` // Lower cased user name.
+	Action    AuditAction `xorm:"INDEX"`
+	Ip        string      `xorm:"INDEX VARCHAR(50)"`
+	Content   string      `xorm:"TEXT"`
+	Created   time.Time   `xorm:"CREATED INDEX"`
+}
+
+// RecordAudit records a security-relevant event, error is logged
+// instead of returned so that it never interrupts the operation.
+func RecordAudit(actorId int64, actorName string, action AuditAction, ip, content string) {
+	if _, err := orm.Insert(&AuditLog{
+		ActorId:   actorId,
+		ActorName: strings.ToLower(actorName),
+		Action:    action,
+		Ip:        ip,
+		Content:   content,
+	}); err != nil {
+		log.Error("RecordAudit(%s, %s): %v", actorName, action, err)
+	}
+}
+
+// AuditLogOptions represents conditions of searching audit logs.
+type AuditLogOptions struct {
+	ActorName string
+	Action    AuditAction
+	Ip        string
+	Page      int
+	PageSize  int
+}
+
+// SearchAuditLogs returns audit logs that match given conditions
+// in reverse chronological order, and total number of matched logs.
+func SearchAuditLogs(opt AuditLogOptions) ([]*AuditLog, int64, error) {
+	cond := &AuditLog{
+		ActorName: strings.ToLower(opt.ActorName),
+		Action:    opt.Action,
+		Ip:        opt.Ip,
+	}
+	count, err := orm.Count(cond)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if opt.Page <= 0 {
+		opt.Page = 1
+	}
+	logs := make([]*AuditLog, 0, opt.PageSize)
+	err = orm.Desc("id").Limit(opt.PageSize, (opt.Page-1)*opt.PageSize).Find(&logs, cond)
+	return logs, count, err
+}
@@ -0,0 +1,54 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestAuditActions(t *testing.T) {
+	actions := AuditActions()
+	if len(actions) != len(auditActionNames) {
+		t.Fatalf("AuditActions returns %d actions, expected %d", len(actions), len(auditActionNames))
+	}
+	for _, a := range actions {
+		if len(a.String()) == 0 {
+			t.Errorf("audit action %d has no name", a)
+		}
+	}
+}
+
+func TestSearchAuditLogs(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	RecordAudit(1, "Alice", AUDIT_LOGIN, "10.0.0.1", "")
+	RecordAudit(0, "alice", AUDIT_LOGIN_FAILED, "10.0.0.2", "")
+	RecordAudit(2, "bob", AUDIT_LOGIN, "10.0.0.1", "")
+	RecordAudit(1, "alice", AUDIT_ADD_SSH_KEY, "10.0.0.1", "laptop")
+
+	tests := []struct {
+		opt           AuditLogOptions
+		count, length int
+		firstAction   AuditAction
+	}{
+		{AuditLogOptions{PageSize: 10}, 4, 4, AUDIT_ADD_SSH_KEY},
+		{AuditLogOptions{ActorName: "ALICE", PageSize: 10}, 3, 3, AUDIT_ADD_SSH_KEY},
+		{AuditLogOptions{Action: AUDIT_LOGIN, PageSize: 10}, 2, 2, AUDIT_LOGIN},
+		{AuditLogOptions{ActorName: "alice", Ip: "10.0.0.2", PageSize: 10}, 1, 1, AUDIT_LOGIN_FAILED},
+		{AuditLogOptions{PageSize: 3, Page: 2}, 4, 1, AUDIT_LOGIN},
+		{AuditLogOptions{ActorName: "carol", PageSize: 10}, 0, 0, 0},
+	}
+	for i, tt := range tests {
+		logs, count, err := SearchAuditLogs(tt.opt)
+		if err != nil {
+			t.Fatalf("#%d: SearchAuditLogs: %v", i, err)
+		}
+		if int(count) != tt.count || len(logs) != tt.length {
+			t.Errorf("#%d: SearchAuditLogs returns %d of %d logs, expected %d of %d", i, len(logs), count, tt.length, tt.count)
+		} else if len(logs) > 0 && logs[0].Action != tt.firstAction {
+			t.Errorf("#%d: first log is %q, expected %q", i, logs[0].Action, tt.firstAction)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bufio"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Unknwon/com"
+
+	"github.com/gogits/gogs/modules/base"
+)
+
+// BlameCommit represents a commit that last changed some lines of file.
+type BlameCommit struct {
+	Id         string
+	Author     string
+	AuthorMail string
+	AuthorTime time.Time
+	Summary    string
+	// Commit and path of file before this commit, empty if lines were added by root commit.
+	PreviousId   string
+	PreviousPath string
+}
+
+// BlamePart represents continuous lines that are last changed by same commit.
+type BlamePart struct {
+	Commit    *BlameCommit
+	StartLine int // Number of first line, starts from 1.
+	Lines     []string
+}
+
+type blamePartList []*BlamePart
+
+func (bl blamePartList) Len() int           { return len(bl) }
+func (bl blamePartList) Less(i, j int) bool { return bl[i].StartLine < bl[j].StartLine }
+func (bl blamePartList) Swap(i, j int)      { bl[i], bl[j] = bl[j], bl[i] }
+
+// parseBlameIncremental parses output of "git blame --incremental" into parts
+// ordered by line number, parts have no content yet.
+func parseBlameIncremental(output string) ([]*BlamePart, error) {
+	commits := make(map[string]*BlameCommit)
+	parts := make([]*BlamePart, 0, 10)
+
+	var cur *BlameCommit
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		// Every hunk starts with "<commit> <original line> <final line> <number of lines>".
+		if cur == nil {
+			if len(fields) != 4 || len(fields[0]) != 40 {
+				return nil, errors.New("invalid blame hunk header: " + line)
+			}
+			id := fields[0]
+			if cur = commits[id]; cur == nil {
+				cur = &BlameCommit{Id: id}
+				commits[id] = cur
+			}
+			start, _ := base.StrTo(fields[2]).Int()
+			num, _ := base.StrTo(fields[3]).Int()
+			parts = append(parts, &BlamePart{Commit: cur, StartLine: start, Lines: make([]string, num)})
+			continue
+		}
+
+		key, value := line, ""
+		if i := strings.Index(line, " "); i > -1 {
+			key, value = line[:i], line[i+1:]
+		}
+		switch key {
+		case "author":
+			cur.Author = value
+		case "author-mail":
+			cur.AuthorMail = strings.Trim(value, "<>")
+		case "author-time":
+			sec, _ := base.StrTo(value).Int64()
+			cur.AuthorTime = time.Unix(sec, 0)
+		case "summary":
+			cur.Summary = value
+		case "previous":
+			if i := strings.Index(value, " "); i > -1 {
+				cur.PreviousId, cur.PreviousPath = value[:i], value[i+1:]
+			}
+		case "filename":
+			// Last line of every hunk.
+			cur = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Sort(blamePartList(parts))
+	return parts, nil
+}
+
+// GetBlame returns lines of file at given commit grouped by commits that last changed them.
+func GetBlame(repoPath, commitId, treePath string) ([]*BlamePart, error) {
+	stdout, stderr, err := com.ExecCmdDir(repoPath, "git", "blame", "--incremental", commitId, "--", treePath)
+	if err != nil {
+		return nil, errors.New("git blame: " + stderr)
+	}
+	parts, err := parseBlameIncremental(stdout)
+	if err != nil {
+		return nil, err
+	}
+
+	content, stderr, err := com.ExecCmdDir(repoPath, "git", "cat-file", "blob", commitId+":"+treePath)
+	if err != nil {
+		return nil, errors.New("git cat-file: " + stderr)
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+
+	// Merge adjacent hunks of same commit and fill in content.
+	merged := make([]*BlamePart, 0, len(parts))
+	for _, p := range parts {
+		for i := range p.Lines {
+			if n := p.StartLine - 1 + i; n < len(lines) {
+				p.Lines[i] = lines[n]
+			}
+		}
+		if last := len(merged) - 1; last >= 0 && merged[last].Commit == p.Commit &&
+			merged[last].StartLine+len(merged[last].Lines) == p.StartLine {
+			merged[last].Lines = append(merged[last].Lines, p.Lines...)
+			continue
+		}
+		merged = append(merged, p)
+	}
+	return merged, nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseBlameIncremental(t *testing.T) {
+	for _, output := range []string{
+		"author Gogs\n",
+		"0123456789 1 1 1\n",
+	} {
+		if _, err := parseBlameIncremental(output); err == nil {
+			t.Errorf("parseBlameIncremental(%q) returns no error", output)
+		}
+	}
+}
+
+func TestGetBlame(t *testing.T) {
+	repoPath, err := ioutil.TempDir("", "gogs-blame")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoPath)
+	if _, err = execGitCmd(repoPath, nil, nil, "init", "-q", "--bare"); err != nil {
+		t.Fatal(err)
+	}
+	firstId := testCommitFiles(t, repoPath, "master", "", map[string]string{"a.txt": "a\nb\nc\nd\n"}, "Add a")
+	lastId := testCommitFiles(t, repoPath, "master", "", map[string]string{"a.txt": "a\nB\nC\nd\n"}, "Change b and c")
+
+	parts, err := GetBlame(repoPath, lastId, "a.txt")
+	if err != nil {
+		t.Fatalf("GetBlame: %v", err)
+	}
+	expected := []struct {
+		commitId string
+		start    int
+		lines    string
+	}{
+		{firstId, 1, "a"},
+		{lastId, 2, "B|C"},
+		{firstId, 4, "d"},
+	}
+	if len(parts) != len(expected) {
+		t.Fatalf("GetBlame returns %d parts, expected %d", len(parts), len(expected))
+	}
+	for i, e := range expected {
+		p := parts[i]
+		if p.Commit.Id != e.commitId || p.StartLine != e.start || strings.Join(p.Lines, "|") != e.lines {
+			t.Errorf("#%d: part of %s starts at %d with %q, expected %s at %d with %q",
+				i, p.Commit.Id, p.StartLine, p.Lines, e.commitId, e.start, e.lines)
+		}
+	}
+	if c := parts[1].Commit; c.Summary != "Change b and c" || c.AuthorMail != "gogs@example.com" ||
+		c.PreviousId != firstId || c.PreviousPath != "a.txt" {
+		t.Errorf("commit is %+v, expected changed from %s", c, firstId)
+	}
+	if parts[0].Commit != parts[2].Commit || len(parts[0].Commit.PreviousId) > 0 {
+		t.Errorf("parts of root commit have commits %+v and %+v", parts[0].Commit, parts[2].Commit)
+	}
+
+	if _, err = GetBlame(repoPath, lastId, "none.txt"); err == nil {
+		t.Error("GetBlame(missing file) returns no error")
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Unknwon/com"
+
+	"github.com/gogits/git"
+)
+
+var (
+	ErrDeleteDefaultBranch  = errors.New("Default branch cannot be deleted")
+	ErrDeleteUnmergedBranch = errors.New("Branch has commits that are not merged into default branch")
+)
+
+// Branch represents a branch of repository compared with default branch.
+type Branch struct {
+	Name          string
+	Commit        *git.Commit
+	NumAhead      int // Number of commits that default branch does not have.
+	NumBehind     int // Number of commits of default branch that branch does not have.
+	AheadPercent  int // Relative to the most diverged branch, for drawing graph.
+	BehindPercent int
+	IsProtected   bool
+}
+
+// IsMerged returns true if all commits of branch are in default branch.
+func (br *Branch) IsMerged() bool {
+	return br.NumAhead == 0
+}
+
+type branchList []*Branch
+
+func (bl branchList) Len() int      { return len(bl) }
+func (bl branchList) Swap(i, j int) { bl[i], bl[j] = bl[j], bl[i] }
+func (bl branchList) Less(i, j int) bool {
+	return bl[i].Commit.Committer.When.After(bl[j].Commit.Committer.When)
+}
+
+// countDivergence returns number of commits that are only in head and only in base.
+func countDivergence(repoPath, base, head string) (ahead, behind int, err error) {
+	stdout, stderr, err := com.ExecCmdDir(repoPath, "git", "rev-list", "--left-right", "--count",
+		"refs/heads/"+base+"...refs/heads/"+head)
+	if err != nil {
+		return 0, 0, errors.New("git rev-list: " + stderr)
+	}
+	if _, err = fmt.Sscan(stdout, &behind, &ahead); err != nil {
+		return 0, 0, fmt.Errorf("parse divergence '%s': %v", stdout, err)
+	}
+	return ahead, behind, nil
+}
+
+// GetBranches returns default branch and the other branches of repository ordered by
+// time of last commit, every branch is compared with default branch.
+func GetBranches(repo *Repository, gitRepo *git.Repository) (*Branch, []*Branch, error) {
+	names, err := gitRepo.GetBranches()
+	if err != nil {
+		return nil, nil, err
+	}
+	pbs, err := GetProtectedBranches(repo.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+	protected := make(map[string]bool, len(pbs))
+	for _, pb := range pbs {
+		protected[pb.BranchName] = true
+	}
+
+	var defaultBranch *Branch
+	branches := make([]*Branch, 0, len(names))
+	maxAhead, maxBehind := 0, 0
+	for _, name := range names {
+		br := &Branch{Name: name, IsProtected: protected[name]}
+		if br.Commit, err = gitRepo.GetCommitOfBranch(name); err != nil {
+			return nil, nil, err
+		}
+		if name == repo.DefaultBranch {
+			defaultBranch = br
+			continue
+		}
+
+		if gitRepo.IsBranchExist(repo.DefaultBranch) {
+			if br.NumAhead, br.NumBehind, err = countDivergence(gitRepo.Path, repo.DefaultBranch, name); err != nil {
+				return nil, nil, err
+			}
+		}
+		if br.NumAhead > maxAhead {
+			maxAhead = br.NumAhead
+		}
+		if br.NumBehind > maxBehind {
+			maxBehind = br.NumBehind
+		}
+		branches = append(branches, br)
+	}
+
+	for _, br := range branches {
+		if maxAhead > 0 {
+			br.AheadPercent = br.NumAhead * 100 / maxAhead
+		}
+		if maxBehind > 0 {
+			br.BehindPercent = br.NumBehind * 100 / maxBehind
+		}
+	}
+	sort.Sort(branchList(branches))
+	return defaultBranch, branches, nil
+}
+
+// DeleteBranch deletes a branch that has been merged into default branch,
+// deletion is subject to same protection rules as pushes.
+func DeleteBranch(doer *User, repo *Repository, branchName string) error {
+	if branchName == repo.DefaultBranch {
+		return ErrDeleteDefaultBranch
+	}
+
+	repoPath := RepoPath(repo.Owner.Name, repo.Name)
+	refName := "refs/heads/" + branchName
+	commitId, err := execGitCmd(repoPath, nil, nil, "rev-parse", "--verify", "-q", refName)
+	if err != nil {
+		return ErrBranchNotExist
+	}
+
+	ahead, _, err := countDivergence(repoPath, repo.DefaultBranch, branchName)
+	if err != nil {
+		return err
+	} else if ahead > 0 {
+		return ErrDeleteUnmergedBranch
+	}
+
+	if err = CheckBranchPush(repo, repoPath, doer.Id, refName, commitId, _EMPTY_COMMIT_ID); err != nil {
+		return err
+	}
+	if _, err = execGitCmd(repoPath, nil, nil, "update-ref", "-d", refName, commitId); err != nil {
+		return err
+	}
+
+	return Update(refName, commitId, _EMPTY_COMMIT_ID, doer.Name, repo.Owner.Name, repo.Name, doer.Id)
+}
+
+// ChangeDefaultBranch changes default branch of repository,
+// HEAD is updated as well so it is checked out by clones.
+func ChangeDefaultBranch(repo *Repository, branchName string) error {
+	repoPath := RepoPath(repo.Owner.Name, repo.Name)
+	if _, err := execGitCmd(repoPath, nil, nil, "rev-parse", "--verify", "-q", "refs/heads/"+branchName); err != nil {
+		return ErrBranchNotExist
+	}
+	if _, err := execGitCmd(repoPath, nil, nil, "symbolic-ref", "HEAD", "refs/heads/"+branchName); err != nil {
+		return err
+	}
+
+	repo.DefaultBranch = branchName
+	if err := UpdateRepository(repo); err != nil {
+		return err
+	}
+	if err := MarkRepoIndexerPending(repo.Id); err != nil {
+		return err
+	}
+	return MarkRepoStatsPending(repo.Id)
+}
@@ -0,0 +1,90 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/gogits/git"
+)
+
+func TestGetBranches(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo := newTestRepo(t, u, "repo1")
+	repoPath := RepoPath(u.Name, repo.Name)
+
+	testCommitFiles(t, repoPath, "feature", "master", map[string]string{"a.txt": "a\n"}, "Add a")
+	testCommitFiles(t, repoPath, "feature", "", map[string]string{"b.txt": "b\n"}, "Add b")
+	testCommitFiles(t, repoPath, "old", "master", map[string]string{"c.txt": "c\n"}, "Add c")
+	testCommitFiles(t, repoPath, "master", "", map[string]string{"d.txt": "d\n"}, "Add d")
+
+	gitRepo, err := git.OpenRepository(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaultBranch, branches, err := GetBranches(repo, gitRepo)
+	if err != nil {
+		t.Fatalf("GetBranches: %v", err)
+	} else if defaultBranch == nil || defaultBranch.Name != "master" {
+		t.Fatalf("GetBranches returns default branch %v, expected master", defaultBranch)
+	}
+
+	expected := map[string][4]int{
+		"feature": {2, 1, 100, 100},
+		"old":     {1, 1, 50, 100},
+	}
+	if len(branches) != len(expected) {
+		t.Fatalf("GetBranches returns %d branches, expected %d", len(branches), len(expected))
+	}
+	for _, br := range branches {
+		if e := expected[br.Name]; br.NumAhead != e[0] || br.NumBehind != e[1] || br.AheadPercent != e[2] || br.BehindPercent != e[3] {
+			t.Errorf("branch %s is %d/%d ahead/behind, %d%%/%d%%, expected %v", br.Name,
+				br.NumAhead, br.NumBehind, br.AheadPercent, br.BehindPercent, e)
+		}
+	}
+}
+
+func TestDeleteBranch(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo := newTestRepo(t, u, "repo1")
+	repoPath := RepoPath(u.Name, repo.Name)
+
+	testCommitFiles(t, repoPath, "unmerged", "master", map[string]string{"a.txt": "a\n"}, "Add a")
+	if _, err := execGitCmd(repoPath, nil, nil, "branch", "merged", "master"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		expected error
+	}{
+		{"master", ErrDeleteDefaultBranch},
+		{"none", ErrBranchNotExist},
+		{"unmerged", ErrDeleteUnmergedBranch},
+		{"merged", nil},
+		{"merged", ErrBranchNotExist},
+	}
+	for _, tt := range tests {
+		if err := DeleteBranch(u, repo, tt.name); err != tt.expected {
+			t.Errorf("DeleteBranch(%s) error = %v, expected %v", tt.name, err, tt.expected)
+		}
+	}
+
+	if err := ChangeDefaultBranch(repo, "none"); err != ErrBranchNotExist {
+		t.Errorf("ChangeDefaultBranch(none) error = %v, expected %v", err, ErrBranchNotExist)
+	}
+	if err := ChangeDefaultBranch(repo, "unmerged"); err != nil {
+		t.Fatalf("ChangeDefaultBranch: %v", err)
+	}
+	if head, _ := execGitCmd(repoPath, nil, nil, "symbolic-ref", "HEAD"); head != "refs/heads/unmerged" {
+		t.Errorf("HEAD is %q, expected refs/heads/unmerged", head)
+	}
+	// Branch is merged into new default branch.
+	if err := DeleteBranch(u, repo, "master"); err != nil {
+		t.Errorf("DeleteBranch(previous default branch): %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+)
+
+// CodeOwnersPaths are paths of CODEOWNERS file to look for in order.
+var CodeOwnersPaths = []string{"CODEOWNERS", ".gogs/CODEOWNERS", "docs/CODEOWNERS"}
+
+// CodeOwnerRule represents a line of CODEOWNERS file.
+type CodeOwnerRule struct {
+	Pattern string
+	Owners  []string // User names without "@" or emails.
+	re      *regexp.Regexp
+}
+
+// compileCodeOwnerPattern converts gitignore style pattern to regular expression.
+// Pattern that starts with or contains "/" is relative to root, otherwise it matches at any level,
+// and pattern matches everything under it if it is a directory.
+func compileCodeOwnerPattern(pattern string) (*regexp.Regexp, error) {
+	p := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+
+	buf := make([]byte, 0, len(p)*2+16)
+	buf = append(buf, '^')
+	if !anchored {
+		buf = append(buf, "(.*/)?"...)
+	}
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; c {
+		case '*':
+			if i+1 < len(p) && p[i+1] == '*' {
+				// "**/" matches zero or more directories.
+				if i+2 < len(p) && p[i+2] == '/' {
+					buf = append(buf, "(.*/)?"...)
+					i += 2
+				} else {
+					buf = append(buf, ".*"...)
+					i++
+				}
+			} else {
+				buf = append(buf, "[^/]*"...)
+			}
+		case '?':
+			buf = append(buf, "[^/]"...)
+		default:
+			buf = append(buf, regexp.QuoteMeta(string(c))...)
+		}
+	}
+	buf = append(buf, "(/.*)?$"...)
+	return regexp.Compile(string(buf))
+}
+
+// ParseCodeOwners parses content of CODEOWNERS file, lines with invalid pattern are skipped.
+func ParseCodeOwners(content string) []*CodeOwnerRule {
+	rules := make([]*CodeOwnerRule, 0, 10)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		fields := strings.Fields(line)
+
+		rule := &CodeOwnerRule{Pattern: fields[0], Owners: make([]string, 0, len(fields)-1)}
+		var err error
+		if rule.re, err = compileCodeOwnerPattern(rule.Pattern); err != nil {
+			continue
+		}
+		for _, owner := range fields[1:] {
+			if owner[0] == '#' {
+				break
+			}
+			rule.Owners = append(rule.Owners, strings.TrimPrefix(owner, "@"))
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// GetCodeOwnerRules returns rules of CODEOWNERS file in given branch,
+// it returns nil if branch has no such file.
+func GetCodeOwnerRules(repoPath, branch string) []*CodeOwnerRule {
+	for _, p := range CodeOwnersPaths {
+		content, err := execGitCmd(repoPath, nil, nil, "cat-file", "blob", branch+":"+p)
+		if err == nil {
+			return ParseCodeOwners(content)
+		}
+	}
+	return nil
+}
+
+// MatchCodeOwners returns owners of given file, last matching rule takes precedence.
+func MatchCodeOwners(rules []*CodeOwnerRule, treePath string) []string {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].re.MatchString(treePath) {
+			return rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// GetCodeOwners returns collaborators of repository that own any of given files
+// according to CODEOWNERS file in default branch, owner of repository must be loaded.
+func GetCodeOwners(repo *Repository, paths []string) ([]*User, error) {
+	rules := GetCodeOwnerRules(RepoPath(repo.Owner.Name, repo.Name), repo.DefaultBranch)
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	collaborators, err := GetCollaborators(repo.Owner.Name + "/" + repo.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := make([]*User, 0, 3)
+	// Same user may be listed by both name and email.
+	seen := make(map[int64]bool)
+	for _, p := range paths {
+		for _, name := range MatchCodeOwners(rules, p) {
+			name = strings.ToLower(name)
+			for _, u := range collaborators {
+				if u.LowerName == name || strings.ToLower(u.Email) == name {
+					if !seen[u.Id] {
+						seen[u.Id] = true
+						owners = append(owners, u)
+					}
+					break
+				}
+			}
+		}
+	}
+	return owners, nil
+}
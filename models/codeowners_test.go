@@ -0,0 +1,71 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+const testCodeOwners = `# Default owners.
+*            @alice
+*.go         @bob carol@example.com # Go files
+/docs/       @dave
+build/**/*.sh @erin
+a?.txt       @frank
+`
+
+func TestMatchCodeOwners(t *testing.T) {
+	rules := ParseCodeOwners(testCodeOwners)
+	if len(rules) != 5 {
+		t.Fatalf("ParseCodeOwners returns %d rules, expected 5", len(rules))
+	}
+
+	tests := []struct {
+		path, expected string
+	}{
+		{"README.md", "alice"},
+		{"main.go", "bob|carol@example.com"},
+		{"models/repo.go", "bob|carol@example.com"},
+		{"docs/index.md", "dave"},
+		{"docs/api/main.go", "dave"},
+		{"models/docs/index.md", "alice"},
+		{"build/release.sh", "erin"},
+		{"build/scripts/ci/test.sh", "erin"},
+		{"scripts/build/test.sh", "alice"},
+		{"ab.txt", "frank"},
+		{"abc.txt", "alice"},
+	}
+	for _, tt := range tests {
+		if owners := MatchCodeOwners(rules, tt.path); strings.Join(owners, "|") != tt.expected {
+			t.Errorf("MatchCodeOwners(%q) = %v, expected %q", tt.path, owners, tt.expected)
+		}
+	}
+}
+
+func TestGetCodeOwners(t *testing.T) {
+	defer prepareTestEnv(t)()
+	owner, u := newTestUser(t, "owner"), newTestUser(t, "user1")
+	newTestUser(t, "user2")
+	repo := newTestRepo(t, owner, "repo1")
+	if err := AddAccess(&Access{UserName: u.LowerName, RepoName: "owner/repo1", Mode: AU_WRITABLE}); err != nil {
+		t.Fatal(err)
+	}
+
+	if owners, err := GetCodeOwners(repo, []string{"main.go"}); err != nil || len(owners) != 0 {
+		t.Errorf("GetCodeOwners(no CODEOWNERS) = (%v, %v), expected nobody", owners, err)
+	}
+
+	testCommitFiles(t, RepoPath(owner.Name, repo.Name), "master", "", map[string]string{
+		".gogs/CODEOWNERS": "*.go @User1 @user2\n*.md user1@gogs.io\n",
+	}, "Add CODEOWNERS")
+	// Users who are not collaborators are not suggested.
+	owners, err := GetCodeOwners(repo, []string{"main.go", "README.md"})
+	if err != nil {
+		t.Fatalf("GetCodeOwners: %v", err)
+	} else if len(owners) != 1 || owners[0].Id != u.Id {
+		t.Errorf("GetCodeOwners returns %v, expected only %s", owners, u.Name)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrCommentNotExist = errors.New("Comment does not exist")
+)
+
+// CommentRevision represents a previous content of an edited or deleted comment.
+type CommentRevision struct {
+	Id        int64
+	CommentId int64 `xorm:"INDEX"`
+	EditorId  int64
+	Editor    *User  `xorm:"-"`
+	Content   string `xorm:"TEXT"`
+	// Indicates revision was made by deleting the comment.
+	IsDeleted bool
+	Created   time.Time `xorm:"CREATED"`
+}
+
+// GetCommentById returns a plain comment of given issue by ID.
+func GetCommentById(issueId, id int64) (*Comment, error) {
+	c := new(Comment)
+	has, err := orm.Where("id=? AND issue_id=?", id, issueId).And("type=?", IT_PLAIN).
+		And("is_deleted=?", false).Get(c)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrCommentNotExist
+	}
+	return c, nil
+}
+
+// EditComment changes content of comment and keeps the previous one as a revision.
+func EditComment(c *Comment, editorId int64, content string) error {
+	if c.Content == content {
+		return nil
+	}
+
+	sess := orm.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.Insert(&CommentRevision{CommentId: c.Id, EditorId: editorId, Content: c.Content}); err != nil {
+		sess.Rollback()
+		return err
+	}
+
+	c.Content = content
+	c.NumRevisions++
+	if _, err := sess.Id(c.Id).Cols("content", "num_revisions").Update(c); err != nil {
+		sess.Rollback()
+		return err
+	}
+	return sess.Commit()
+}
+
+// DeleteComment marks comment as deleted, its content is kept as the last revision.
+func DeleteComment(c *Comment, editorId int64) error {
+	sess := orm.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.Insert(&CommentRevision{CommentId: c.Id, EditorId: editorId,
+		Content: c.Content, IsDeleted: true}); err != nil {
+		sess.Rollback()
+		return err
+	}
+
+	c.Content = ""
+	c.IsDeleted = true
+	c.NumRevisions++
+	if _, err := sess.Id(c.Id).Cols("content", "is_deleted", "num_revisions").Update(c); err != nil {
+		sess.Rollback()
+		return err
+	}
+
+	rawSql := "UPDATE `issue` SET num_comments = num_comments - 1 WHERE id = ?"
+	if _, err := sess.Exec(rawSql, c.IssueId); err != nil {
+		sess.Rollback()
+		return err
+	}
+	return sess.Commit()
+}
+
+// GetCommentRevisions returns previous revisions of comment, newest first.
+func GetCommentRevisions(commentId int64) ([]*CommentRevision, error) {
+	revs := make([]*CommentRevision, 0, 5)
+	err := orm.Where("comment_id=?", commentId).Desc("id").Find(&revs)
+	return revs, err
+}
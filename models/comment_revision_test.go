@@ -0,0 +1,81 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestCommentRevisions(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	repo := newTestRepo(t, u1, "repo1")
+
+	issue := &Issue{RepoId: repo.Id, Index: 1, Name: "issue", PosterId: u1.Id}
+	if err := NewIssue(issue); err != nil {
+		t.Fatalf("NewIssue: %v", err)
+	}
+	c, err := CreateComment(u1.Id, repo.Id, issue.Id, 0, 0, IT_PLAIN, "first")
+	if err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	}
+	closed, err := CreateComment(u1.Id, repo.Id, issue.Id, 0, 0, IT_CLOSE, "")
+	if err != nil {
+		t.Fatalf("CreateComment(close): %v", err)
+	}
+
+	for _, ids := range [][2]int64{{issue.Id + 1, c.Id}, {issue.Id, 0}, {issue.Id, closed.Id}} {
+		if _, err = GetCommentById(ids[0], ids[1]); err != ErrCommentNotExist {
+			t.Errorf("GetCommentById(%d, %d) error = %v, expected %v", ids[0], ids[1], err, ErrCommentNotExist)
+		}
+	}
+	if c, err = GetCommentById(issue.Id, c.Id); err != nil {
+		t.Fatalf("GetCommentById: %v", err)
+	}
+
+	// Unchanged content does not make a revision.
+	if err = EditComment(c, u1.Id, "first"); err != nil {
+		t.Fatalf("EditComment(unchanged): %v", err)
+	} else if err = EditComment(c, u2.Id, "second"); err != nil {
+		t.Fatalf("EditComment: %v", err)
+	}
+	if saved, err := GetCommentById(issue.Id, c.Id); err != nil || saved.Content != "second" || saved.NumRevisions != 1 {
+		t.Errorf("GetCommentById = (%+v, %v), expected content %q with 1 revision", saved, err, "second")
+	}
+
+	if err = DeleteComment(c, u1.Id); err != nil {
+		t.Fatalf("DeleteComment: %v", err)
+	}
+	if _, err = GetCommentById(issue.Id, c.Id); err != ErrCommentNotExist {
+		t.Errorf("GetCommentById(deleted) error = %v, expected %v", err, ErrCommentNotExist)
+	}
+	comments, err := GetIssueComments(issue.Id)
+	if err != nil {
+		t.Fatalf("GetIssueComments: %v", err)
+	} else if len(comments) != 1 || comments[0].Id != closed.Id {
+		t.Errorf("GetIssueComments returns %d comments, expected only comment %d", len(comments), closed.Id)
+	}
+	if saved, err := GetIssueById(issue.Id); err != nil || saved.NumComments != 0 {
+		t.Errorf("GetIssueById = (%+v, %v), expected no comments", saved, err)
+	}
+
+	revs, err := GetCommentRevisions(c.Id)
+	if err != nil {
+		t.Fatalf("GetCommentRevisions: %v", err)
+	}
+	expected := []CommentRevision{
+		{CommentId: c.Id, EditorId: u1.Id, Content: "second", IsDeleted: true},
+		{CommentId: c.Id, EditorId: u2.Id, Content: "first"},
+	}
+	if len(revs) != len(expected) {
+		t.Fatalf("GetCommentRevisions returns %d revisions, expected %d", len(revs), len(expected))
+	}
+	for i, rev := range revs {
+		if rev.CommentId != expected[i].CommentId || rev.EditorId != expected[i].EditorId ||
+			rev.Content != expected[i].Content || rev.IsDeleted != expected[i].IsDeleted {
+			t.Errorf("GetCommentRevisions[%d] = %+v, expected %+v", i, rev, expected[i])
+		}
+	}
+}
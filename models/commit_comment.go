@@ -0,0 +1,110 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrCommitCommentNotExist = errors.New("Commit comment does not exist")
+	ErrCommitLineNotExist    = errors.New("Line does not exist in diff of commit")
+	ErrCommitCommentEmpty    = errors.New("Commit comment cannot be empty")
+)
+
+// CommitComment represents a comment on a commit, or on a line of its diff.
+type CommitComment struct {
+	Id              int64
+	RepoId          int64  `xorm:"INDEX"`
+	CommitId        string `xorm:"VARCHAR(40) INDEX"`
+	PosterId        int64
+	Poster          *User `xorm:"-"`
+	TreePath        string
+	Line            int // Line number in given side of diff, zero for comments on whole commit.
+	IsOldSide       bool
+	Content         string    `xorm:"TEXT"`
+	RenderedContent string    `xorm:"-"`
+	Created         time.Time `xorm:"CREATED"`
+}
+
+// IsLineComment returns true if comment is on a line of diff.
+func (c *CommitComment) IsLineComment() bool {
+	return len(c.TreePath) > 0 && c.Line > 0
+}
+
+// CreateCommitComment creates a comment on commit of repository, line comments
+// must be on a line of given diff of the commit.
+func CreateCommitComment(diff *Diff, c *CommitComment) error {
+	if len(c.Content) == 0 {
+		return ErrCommitCommentEmpty
+	}
+	if len(c.TreePath) > 0 {
+		if findDiffLine(diff, c.TreePath, c.Line, c.IsOldSide) == nil {
+			return ErrCommitLineNotExist
+		}
+	} else {
+		c.Line = 0
+		c.IsOldSide = false
+	}
+	_, err := orm.Insert(c)
+	return err
+}
+
+// GetCommitComments returns comments of commit in repository with their posters, oldest first.
+func GetCommitComments(repoId int64, commitId string) ([]*CommitComment, error) {
+	comments := make([]*CommitComment, 0, 5)
+	if err := orm.Where("repo_id=? AND commit_id=?", repoId, commitId).Asc("id").Find(&comments); err != nil {
+		return nil, err
+	}
+
+	var err error
+	for _, c := range comments {
+		if c.Poster, err = GetUserById(c.PosterId); err != nil {
+			if err != ErrUserNotExist {
+				return nil, err
+			}
+			c.Poster = &User{Name: "FakeUser"}
+		}
+	}
+	return comments, nil
+}
+
+// GetCommitCommentById returns comment of repository by given ID.
+func GetCommitCommentById(repoId, id int64) (*CommitComment, error) {
+	c := new(CommitComment)
+	has, err := orm.Where("id=? AND repo_id=?", id, repoId).Get(c)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrCommitCommentNotExist
+	}
+	return c, nil
+}
+
+// DeleteCommitComment deletes commit comment.
+func DeleteCommitComment(c *CommitComment) error {
+	_, err := orm.Id(c.Id).Delete(new(CommitComment))
+	return err
+}
+
+// AttachCommitComments attaches line comments to lines of diff of the commit,
+// it returns comments on whole commit and comments whose line is not in diff.
+func AttachCommitComments(diff *Diff, comments []*CommitComment) []*CommitComment {
+	rest := make([]*CommitComment, 0, len(comments))
+	for _, c := range comments {
+		if !c.IsLineComment() {
+			rest = append(rest, c)
+			continue
+		}
+		line := findDiffLine(diff, c.TreePath, c.Line, c.IsOldSide)
+		if line == nil {
+			rest = append(rest, c)
+			continue
+		}
+		line.CommitComments = append(line.CommitComments, c)
+	}
+	return rest
+}
@@ -0,0 +1,100 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCommitComments(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	repo := newTestRepo(t, u1, "repo1")
+	repoPath := RepoPath(u1.Name, repo.Name)
+
+	initId, err := ResolveCommitId(repoPath, "master")
+	if err != nil {
+		t.Fatalf("ResolveCommitId: %v", err)
+	}
+	commitId := testCommitFiles(t, repoPath, "master", "", map[string]string{"a.txt": "one\ntwo\n"}, "Add a")
+	diff, err := GetDiffRange(repoPath, initId, commitId)
+	if err != nil {
+		t.Fatalf("GetDiffRange: %v", err)
+	}
+
+	tests := []struct {
+		c        *CommitComment
+		expected error
+	}{
+		{&CommitComment{TreePath: "a.txt", Line: 1}, ErrCommitCommentEmpty},
+		{&CommitComment{TreePath: "a.txt", Line: 3, Content: "Where?"}, ErrCommitLineNotExist},
+		{&CommitComment{TreePath: "a.txt", Line: 1, IsOldSide: true, Content: "Where?"}, ErrCommitLineNotExist},
+		{&CommitComment{TreePath: "a.txt", Line: 2, Content: "Why two?"}, nil},
+		{&CommitComment{Line: 5, IsOldSide: true, Content: "Nice commit"}, nil},
+	}
+	for i, tt := range tests {
+		tt.c.RepoId, tt.c.CommitId, tt.c.PosterId = repo.Id, commitId, u2.Id
+		if err = CreateCommitComment(diff, tt.c); err != tt.expected {
+			t.Errorf("#%d: CreateCommitComment error = %v, expected %v", i, err, tt.expected)
+		}
+	}
+	lineComment, commitComment := tests[3].c, tests[4].c
+	if commitComment.Line != 0 || commitComment.IsOldSide || commitComment.IsLineComment() {
+		t.Errorf("comment on whole commit is on line %d, old side %v", commitComment.Line, commitComment.IsOldSide)
+	}
+	// Line may be gone from diff that is shown.
+	if _, err = orm.Insert(&CommitComment{RepoId: repo.Id, CommitId: commitId, PosterId: u1.Id, TreePath: "b.txt", Line: 1, Content: "Gone"}); err != nil {
+		t.Fatal(err)
+	}
+
+	comments, err := GetCommitComments(repo.Id, commitId)
+	if err != nil {
+		t.Fatalf("GetCommitComments: %v", err)
+	} else if len(comments) != 3 || comments[0].Id != lineComment.Id || comments[0].Poster.Id != u2.Id {
+		t.Fatalf("GetCommitComments returns %d comments, expected 3 oldest first", len(comments))
+	}
+	rest := AttachCommitComments(diff, comments)
+	if len(rest) != 2 || rest[0].Id != commitComment.Id || rest[1].TreePath != "b.txt" {
+		t.Errorf("AttachCommitComments returns %d comments, expected comment on commit and on missing line", len(rest))
+	}
+	if l := findDiffLine(diff, "a.txt", 2, false); l == nil || len(l.CommitComments) != 1 || l.CommitComments[0].Id != lineComment.Id {
+		t.Error("line comment is not attached to line 2 of diff")
+	}
+
+	for _, repoId := range []int64{0, repo.Id + 1} {
+		if _, err = GetCommitCommentById(repoId, lineComment.Id); err != ErrCommitCommentNotExist {
+			t.Errorf("GetCommitCommentById(repo %d) error = %v, expected %v", repoId, err, ErrCommitCommentNotExist)
+		}
+	}
+	if _, err = GetCommitCommentById(repo.Id, lineComment.Id); err != nil {
+		t.Errorf("GetCommitCommentById: %v", err)
+	}
+
+	if err = NewCommitCommentNotification(u2, repo, lineComment, u1); err != nil {
+		t.Fatalf("NewCommitCommentNotification: %v", err)
+	}
+	ns, err := GetNotifications(u1.Id, 1)
+	if err != nil {
+		t.Fatalf("GetNotifications: %v", err)
+	} else if len(ns) != 1 || !ns[0].IsCommitComment() {
+		t.Fatalf("GetNotifications returns %d notifications, expected a commit comment", len(ns))
+	}
+	link := fmt.Sprintf("/user1/repo1/commit/%s#commit-comment-%d", commitId, lineComment.Id)
+	if ns[0].Link() != link {
+		t.Errorf("notification links to %q, expected %q", ns[0].Link(), link)
+	}
+
+	if err = DeleteCommitComment(&CommitComment{}); err != nil {
+		t.Fatalf("DeleteCommitComment(zero ID): %v", err)
+	} else if comments, _ = GetCommitComments(repo.Id, commitId); len(comments) != 3 {
+		t.Errorf("DeleteCommitComment(zero ID) leaves %d comments, expected 3", len(comments))
+	}
+	if err = DeleteCommitComment(lineComment); err != nil {
+		t.Fatalf("DeleteCommitComment: %v", err)
+	} else if _, err = GetCommitCommentById(repo.Id, lineComment.Id); err != ErrCommitCommentNotExist {
+		t.Errorf("GetCommitCommentById(deleted) error = %v, expected %v", err, ErrCommitCommentNotExist)
+	}
+}
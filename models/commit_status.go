@@ -0,0 +1,169 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"container/list"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gogits/gogs/modules/log"
+)
+
+var (
+	ErrCommitStatusStateIllegal = errors.New("Commit status state is not valid")
+)
+
+// Commit status states.
+const (
+	COMMIT_STATUS_PENDING = "pending"
+	COMMIT_STATUS_SUCCESS = "success"
+	COMMIT_STATUS_ERROR   = "error"
+	COMMIT_STATUS_FAILURE = "failure"
+)
+
+// commitStatusPriority is used to combine states, higher one wins.
+var commitStatusPriority = map[string]int{
+	COMMIT_STATUS_SUCCESS: 1,
+	COMMIT_STATUS_PENDING: 2,
+	COMMIT_STATUS_ERROR:   3,
+	COMMIT_STATUS_FAILURE: 4,
+}
+
+// IsValidCommitStatusState returns true if given state is one of commit status states.
+func IsValidCommitStatusState(state string) bool {
+	return commitStatusPriority[state] > 0
+}
+
+// CommitStatus represents a state of commit reported by external system such as CI.
+type CommitStatus struct {
+	Id          int64
+	RepoId      int64  `xorm:"INDEX(s)"`
+	Sha         string `xorm:"VARCHAR(40) INDEX(s)"`
+	State       string `xorm:"VARCHAR(7) NOT NULL"`
+	TargetUrl   string
+	Description string
+	Context     string // Identifies the system that reports status, e.g. "ci/build".
+	CreatorId   int64
+	Creator     *User     `xorm:"-"`
+	Created     time.Time `xorm:"CREATED"`
+}
+
+// GetCreator loads user who created the status.
+func (s *CommitStatus) GetCreator() (err error) {
+	s.Creator, err = GetUserById(s.CreatorId)
+	return err
+}
+
+// NewCommitStatus creates a status for given commit of repository,
+// commit ID can be abbreviated and is saved in full.
+func NewCommitStatus(repo *Repository, creator *User, sha string, status *CommitStatus) error {
+	if !IsValidCommitStatusState(status.State) {
+		return ErrCommitStatusStateIllegal
+	} else if repo.Owner == nil {
+		if err := repo.GetOwner(); err != nil {
+			return err
+		}
+	}
+
+	commitId, err := ResolveCommitId(RepoPath(repo.Owner.Name, repo.Name), sha)
+	if err != nil {
+		return err
+	}
+
+	status.RepoId = repo.Id
+	status.Sha = commitId
+	status.CreatorId = creator.Id
+	status.Creator = creator
+	status.Context = strings.TrimSpace(status.Context)
+	if len(status.Context) == 0 {
+		status.Context = "default"
+	}
+	_, err = orm.Insert(status)
+	return err
+}
+
+// GetCommitStatuses returns all statuses of given commit, newest first.
+func GetCommitStatuses(repoId int64, sha string) ([]*CommitStatus, error) {
+	statuses := make([]*CommitStatus, 0, 5)
+	return statuses, orm.Where("repo_id=? AND sha=?", repoId, sha).Desc("id").Find(&statuses)
+}
+
+// GetLatestCommitStatuses returns latest status of every context of given commit.
+func GetLatestCommitStatuses(repoId int64, sha string) ([]*CommitStatus, error) {
+	statuses, err := GetCommitStatuses(repoId, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make([]*CommitStatus, 0, len(statuses))
+	seen := make(map[string]bool)
+	for _, s := range statuses {
+		if seen[s.Context] {
+			continue
+		}
+		seen[s.Context] = true
+		latest = append(latest, s)
+	}
+	return latest, nil
+}
+
+// CombineCommitStatuses returns overall state of given statuses, failure takes precedence
+// over error, error over pending, and pending over success. It returns empty string if no status.
+func CombineCommitStatuses(statuses []*CommitStatus) string {
+	state := ""
+	for _, s := range statuses {
+		if commitStatusPriority[s.State] > commitStatusPriority[state] {
+			state = s.State
+		}
+	}
+	return state
+}
+
+// GetCombinedCommitStatuses returns combined states of given commits keyed by commit ID,
+// commits without any status are not included.
+func GetCombinedCommitStatuses(repoId int64, shas []string) (map[string]string, error) {
+	states := make(map[string]string)
+	if len(shas) == 0 {
+		return states, nil
+	}
+
+	statuses := make([]*CommitStatus, 0, len(shas))
+	if err := orm.Where("repo_id=?", repoId).In("sha", shas).Desc("id").Find(&statuses); err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string][]*CommitStatus)
+	seen := make(map[string]bool)
+	for _, s := range statuses {
+		if key := s.Sha + "/" + s.Context; !seen[key] {
+			seen[key] = true
+			latest[s.Sha] = append(latest[s.Sha], s)
+		}
+	}
+	for sha := range latest {
+		states[sha] = CombineCommitStatuses(latest[sha])
+	}
+	return states, nil
+}
+
+// LoadCommitsStatus loads combined states of a list of *SignCommit.
+func LoadCommitsStatus(repoId int64, commits *list.List) *list.List {
+	shas := make([]string, 0, commits.Len())
+	for e := commits.Front(); e != nil; e = e.Next() {
+		shas = append(shas, e.Value.(*SignCommit).Id.String())
+	}
+	states, err := GetCombinedCommitStatuses(repoId, shas)
+	if err != nil {
+		log.Error("LoadCommitsStatus(GetCombinedCommitStatuses): %v", err)
+		return commits
+	}
+	for e := commits.Front(); e != nil; e = e.Next() {
+		sc := e.Value.(*SignCommit)
+		sc.Status = states[sc.Id.String()]
+	}
+	return commits
+}
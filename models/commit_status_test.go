@@ -0,0 +1,77 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestCombineCommitStatuses(t *testing.T) {
+	tests := []struct {
+		states   []string
+		expected string
+	}{
+		{nil, ""},
+		{[]string{"success"}, "success"},
+		{[]string{"success", "pending"}, "pending"},
+		{[]string{"failure", "error", "pending"}, "failure"},
+		{[]string{"success", "error"}, "error"},
+	}
+	for _, tt := range tests {
+		statuses := make([]*CommitStatus, len(tt.states))
+		for i := range tt.states {
+			statuses[i] = &CommitStatus{State: tt.states[i]}
+		}
+		if state := CombineCommitStatuses(statuses); state != tt.expected {
+			t.Errorf("CombineCommitStatuses(%v) = %q, expected %q", tt.states, state, tt.expected)
+		}
+	}
+}
+
+func TestNewCommitStatus(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo := newTestRepo(t, u, "repo1")
+	repoPath := RepoPath(u.Name, repo.Name)
+	firstId, _ := execGitCmd(repoPath, nil, nil, "rev-parse", "master")
+	lastId := testCommitFiles(t, repoPath, "master", "", map[string]string{"a.txt": "a\n"}, "Add a")
+
+	if err := NewCommitStatus(repo, u, lastId, &CommitStatus{State: "unknown"}); err != ErrCommitStatusStateIllegal {
+		t.Errorf("NewCommitStatus(invalid state) error = %v, expected %v", err, ErrCommitStatusStateIllegal)
+	}
+	if err := NewCommitStatus(repo, u, "none", &CommitStatus{State: COMMIT_STATUS_SUCCESS}); err != ErrRevisionNotExist {
+		t.Errorf("NewCommitStatus(missing commit) error = %v, expected %v", err, ErrRevisionNotExist)
+	}
+
+	for _, s := range []struct {
+		sha, state, context string
+	}{
+		{lastId[:7], COMMIT_STATUS_PENDING, "ci/build"},
+		{"master", COMMIT_STATUS_SUCCESS, " ci/build "},
+		{lastId, COMMIT_STATUS_ERROR, ""},
+		{lastId, COMMIT_STATUS_SUCCESS, "default"},
+		{firstId, COMMIT_STATUS_FAILURE, "ci/build"},
+	} {
+		if err := NewCommitStatus(repo, u, s.sha, &CommitStatus{State: s.state, Context: s.context}); err != nil {
+			t.Fatalf("NewCommitStatus(%s, %s): %v", s.sha, s.state, err)
+		}
+	}
+
+	// Statuses are saved with full commit ID and latest one of every context is used.
+	latest, err := GetLatestCommitStatuses(repo.Id, lastId)
+	if err != nil {
+		t.Fatalf("GetLatestCommitStatuses: %v", err)
+	} else if len(latest) != 2 || latest[0].Context != "default" || latest[0].State != COMMIT_STATUS_SUCCESS ||
+		latest[1].Context != "ci/build" || latest[1].State != COMMIT_STATUS_SUCCESS {
+		t.Errorf("GetLatestCommitStatuses returns %v, expected success of default and ci/build", latest)
+	}
+
+	states, err := GetCombinedCommitStatuses(repo.Id, []string{firstId, lastId, "none"})
+	if err != nil {
+		t.Fatalf("GetCombinedCommitStatuses: %v", err)
+	} else if len(states) != 2 || states[firstId] != COMMIT_STATUS_FAILURE || states[lastId] != COMMIT_STATUS_SUCCESS {
+		t.Errorf("GetCombinedCommitStatuses returns %v, expected failure of first commit and success of last one", states)
+	}
+}
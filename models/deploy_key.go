@@ -0,0 +1,119 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	_TPL_DEPLOY_KEY = `command="%s serv deploy-%d",no-port-forwarding,no-X11-forwarding,no-agent-forwarding,no-pty %s` + "\n"
+)
+
+var (
+	ErrDeployKeyNotExist = errors.New("Deploy key does not exist")
+)
+
+// DeployKey represents a SSH key that has access to a single repository.
+type DeployKey struct {
+	Id          int64
+	RepoId      int64  `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Name        string `xorm:"UNIQUE(s) NOT NULL"`
+	Fingerprint string
+	Content     string    `xorm:"TEXT NOT NULL"`
+	IsWritable  bool      // Allows pushing to repository.
+	Created     time.Time `xorm:"CREATED"`
+	Updated     time.Time `xorm:"UPDATED"`
+}
+
+// GetAuthorizedString generates and returns formatted deploy key string for authorized_keys file.
+func (key *DeployKey) GetAuthorizedString() string {
+	return fmt.Sprintf(_TPL_DEPLOY_KEY, appPath, key.Id, key.Content)
+}
+
+// AddDeployKey adds new deploy key to database and authorized_keys file.
+func AddDeployKey(key *DeployKey) (err error) {
+	has, err := orm.Get(&DeployKey{RepoId: key.RepoId, Name: key.Name})
+	if err != nil {
+		return err
+	} else if has {
+		return ErrKeyAlreadyExist
+	}
+
+	if key.Fingerprint, err = calcFingerprint(key.Content); err != nil {
+		return err
+	}
+	if has, err = isKeyFingerprintUsed(key.Fingerprint); err != nil {
+		return err
+	} else if has {
+		return ErrKeyAlreadyExist
+	}
+
+	if _, err = orm.Insert(key); err != nil {
+		return err
+	} else if err = saveAuthorizedKeyFile(key.GetAuthorizedString()); err != nil {
+		// Roll back.
+		if _, err2 := orm.Delete(key); err2 != nil {
+			return err2
+		}
+		return err
+	}
+	return nil
+}
+
+// GetDeployKeyById returns deploy key by given ID.
+func GetDeployKeyById(id int64) (*DeployKey, error) {
+	key := new(DeployKey)
+	has, err := orm.Id(id).Get(key)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrDeployKeyNotExist
+	}
+	return key, nil
+}
+
+// ListDeployKeys returns all deploy keys of given repository.
+func ListDeployKeys(repoId int64) ([]*DeployKey, error) {
+	keys := make([]*DeployKey, 0, 5)
+	return keys, orm.Where("repo_id=?", repoId).Find(&keys)
+}
+
+// DeleteDeployKey deletes deploy key of given repository
+// both in database and authorized_keys file.
+func DeleteDeployKey(repoId, id int64) error {
+	// Zero ID must not be left to xorm, which ignores zero value conditions.
+	if id <= 0 {
+		return ErrDeployKeyNotExist
+	}
+	key := new(DeployKey)
+	has, err := orm.Where("id=? AND repo_id=?", id, repoId).Get(key)
+	if err != nil {
+		return err
+	} else if !has {
+		return ErrDeployKeyNotExist
+	}
+
+	if _, err = orm.Id(key.Id).Delete(new(DeployKey)); err != nil {
+		return err
+	}
+	return removeAuthorizedKey(fmt.Sprintf("deploy-%d", key.Id), key.Content)
+}
+
+// DeleteRepoDeployKeys deletes all deploy keys of given repository.
+func DeleteRepoDeployKeys(repoId int64) error {
+	keys, err := ListDeployKeys(repoId)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err = DeleteDeployKey(repoId, key.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testSSHKey1 = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIGi7bd5U03d2IdLe5PhB8J9vKOktQfyP1m4RE11UATya"
+	testSSHKey2 = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINInT1ed4EjQeaZrf4rB4P7sWxwFiGo4lUKbC1JKWg4r"
+)
+
+// prepareTestSSHPath points SSH path to a temporary directory,
+// and returns function to restore it.
+func prepareTestSSHPath(t *testing.T) func() {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen is not available")
+	}
+	dir, err := ioutil.TempDir("", "gogs-ssh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldSSHPath := sshPath
+	sshPath = dir
+	return func() {
+		sshPath = oldSSHPath
+		os.RemoveAll(dir)
+	}
+}
+
+func TestDeleteDeployKey(t *testing.T) {
+	defer prepareTestEnv(t)()
+	defer prepareTestSSHPath(t)()
+
+	key1 := &DeployKey{RepoId: 1, Name: "ci", Content: testSSHKey1}
+	key2 := &DeployKey{RepoId: 2, Name: "ci", Content: testSSHKey2}
+	for _, key := range []*DeployKey{key1, key2} {
+		if err := AddDeployKey(key); err != nil {
+			t.Fatalf("AddDeployKey: %v", err)
+		}
+	}
+	if err := AddDeployKey(&DeployKey{RepoId: 3, Name: "ci", Content: testSSHKey1}); err != ErrKeyAlreadyExist {
+		t.Errorf("AddDeployKey(used key) error = %v, expected %v", err, ErrKeyAlreadyExist)
+	}
+
+	if keys, err := ListDeployKeys(0); err != nil || len(keys) != 0 {
+		t.Errorf("ListDeployKeys(zero ID) = (%d keys, %v), expected none", len(keys), err)
+	}
+	if err := DeleteDeployKey(1, 0); err != ErrDeployKeyNotExist {
+		t.Errorf("DeleteDeployKey(zero ID) error = %v, expected %v", err, ErrDeployKeyNotExist)
+	}
+	if err := DeleteDeployKey(1, key2.Id); err != ErrDeployKeyNotExist {
+		t.Errorf("DeleteDeployKey(other repository) error = %v, expected %v", err, ErrDeployKeyNotExist)
+	}
+	if err := DeleteDeployKey(1, key1.Id); err != nil {
+		t.Fatalf("DeleteDeployKey: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(sshPath, "authorized_keys"))
+	if err != nil {
+		t.Fatalf("read authorized_keys: %v", err)
+	}
+	if strings.Contains(string(data), testSSHKey1) {
+		t.Error("deleted key is still in authorized_keys")
+	} else if !strings.Contains(string(data), key2.GetAuthorizedString()) {
+		t.Errorf("authorized_keys does not contain key of other repository: %q", data)
+	}
+	if keys, err := ListDeployKeys(2); err != nil || len(keys) != 1 {
+		t.Errorf("ListDeployKeys = (%d keys, %v), expected 1", len(keys), err)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrEmailDomainAlreadyExist = errors.New("E-mail domain rule already exists")
+	ErrEmailDomainInvalid      = errors.New("E-mail domain is not valid")
+)
+
+// EmailDomain represents a registration rule of e-mail domain,
+// it either allows or denies sign up with addresses of the domain.
+type EmailDomain struct {
+	Id        int64
+	Domain    string `xorm:"UNIQUE NOT NULL"`
+	IsAllowed bool
+	Created   time.Time `xorm:"CREATED"`
+}
+
+// AddEmailDomain adds new registration rule of e-mail domain.
+func AddEmailDomain(domain string, isAllowed bool) error {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	domain = strings.TrimPrefix(domain, "@")
+	if len(domain) == 0 || strings.ContainsAny(domain, "@ \t") {
+		return ErrEmailDomainInvalid
+	}
+
+	has, err := orm.Get(&EmailDomain{Domain: domain})
+	if err != nil {
+		return err
+	} else if has {
+		return ErrEmailDomainAlreadyExist
+	}
+	_, err = orm.Insert(&EmailDomain{Domain: domain, IsAllowed: isAllowed})
+	return err
+}
+
+// GetEmailDomains returns all registration rules of e-mail domain.
+func GetEmailDomains() ([]*EmailDomain, error) {
+	domains := make([]*EmailDomain, 0, 10)
+	err := orm.Asc("domain").Find(&domains)
+	return domains, err
+}
+
+// DeleteEmailDomain deletes registration rule of e-mail domain by given ID.
+func DeleteEmailDomain(id int64) error {
+	_, err := orm.Id(id).Delete(new(EmailDomain))
+	return err
+}
+
+// matchEmailDomain returns true if domain is the rule domain or its subdomain.
+func matchEmailDomain(domain, rule string) bool {
+	return domain == rule || strings.HasSuffix(domain, "."+rule)
+}
+
+// IsEmailDomainAllowed returns true if given e-mail address is allowed to sign up.
+// Denied domains always take effect, and when there is any allowed domain,
+// address must belong to one of them.
+func IsEmailDomainAllowed(email string) (bool, error) {
+	domains, err := GetEmailDomains()
+	if err != nil {
+		return false, err
+	} else if len(domains) == 0 {
+		return true, nil
+	}
+
+	domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+	hasAllowed, isAllowed := false, false
+	for _, d := range domains {
+		if !d.IsAllowed {
+			if matchEmailDomain(domain, d.Domain) {
+				return false, nil
+			}
+			continue
+		}
+		hasAllowed = true
+		if matchEmailDomain(domain, d.Domain) {
+			isAllowed = true
+		}
+	}
+	return !hasAllowed || isAllowed, nil
+}
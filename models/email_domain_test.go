@@ -0,0 +1,80 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+)
+
+var matchEmailDomainTests = []struct {
+	domain, rule string
+	expected     bool
+}{
+	{"example.com", "example.com", true},
+	{"mail.example.com", "example.com", true},
+	{"badexample.com", "example.com", false},
+	{"example.com.evil.org", "example.com", false},
+	{"example.com", "mail.example.com", false},
+}
+
+func TestMatchEmailDomain(t *testing.T) {
+	for _, tt := range matchEmailDomainTests {
+		if ok := matchEmailDomain(tt.domain, tt.rule); ok != tt.expected {
+			t.Errorf("matchEmailDomain(%q, %q) = %v, expected %v", tt.domain, tt.rule, ok, tt.expected)
+		}
+	}
+}
+
+func TestIsEmailDomainAllowed(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	isAllowed := func(email string) bool {
+		ok, err := IsEmailDomainAllowed(email)
+		if err != nil {
+			t.Fatalf("IsEmailDomainAllowed(%q): %v", email, err)
+		}
+		return ok
+	}
+
+	if !isAllowed("alice@example.com") {
+		t.Error("address is denied without any rule")
+	}
+
+	if err := AddEmailDomain(" @Spam.org ", false); err != nil {
+		t.Fatalf("AddEmailDomain: %v", err)
+	}
+	if err := AddEmailDomain("spam.org", true); err != ErrEmailDomainAlreadyExist {
+		t.Errorf("AddEmailDomain(duplicate) error = %v, expected %v", err, ErrEmailDomainAlreadyExist)
+	}
+	if err := AddEmailDomain("a@b.org", true); err != ErrEmailDomainInvalid {
+		t.Errorf("AddEmailDomain(invalid) error = %v, expected %v", err, ErrEmailDomainInvalid)
+	}
+	if isAllowed("alice@mail.SPAM.org") {
+		t.Error("address of denied domain is allowed")
+	} else if !isAllowed("alice@example.com") {
+		t.Error("address is denied with only deny rules")
+	}
+
+	if err := AddEmailDomain("example.com", true); err != nil {
+		t.Fatalf("AddEmailDomain: %v", err)
+	}
+	if err := AddEmailDomain("bad.example.com", false); err != nil {
+		t.Fatalf("AddEmailDomain: %v", err)
+	}
+	tests := []struct {
+		email    string
+		expected bool
+	}{
+		{"alice@example.com", true},
+		{"alice@dev.example.com", true},
+		{"alice@bad.example.com", false},
+		{"alice@gogs.io", false},
+	}
+	for _, tt := range tests {
+		if ok := isAllowed(tt.email); ok != tt.expected {
+			t.Errorf("IsEmailDomainAllowed(%q) = %v, expected %v", tt.email, ok, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"container/list"
+	"strings"
+
+	"github.com/gogits/git"
+)
+
+// FileRename represents a commit that renamed or copied the file in history.
+type FileRename struct {
+	CommitId string
+	OldPath  string
+	NewPath  string
+	IsCopy   bool
+}
+
+// FileHistory represents history of a file that follows renames.
+type FileHistory struct {
+	CommitIds []string      // All commits that changed the file, newest first.
+	Renames   []*FileRename // Renames of the file, newest first.
+}
+
+// parseFollowLog parses output of "git log --follow -z --format=%H --name-status".
+func parseFollowLog(output string) *FileHistory {
+	h := &FileHistory{
+		CommitIds: make([]string, 0, 50),
+		Renames:   make([]*FileRename, 0, 2),
+	}
+	tokens := strings.Split(output, "\x00")
+	for i := 0; i < len(tokens); i++ {
+		// Commit ID is followed by status and path(s), except for merge commits.
+		commitId := strings.TrimSpace(tokens[i])
+		if len(commitId) == 0 {
+			continue
+		}
+		h.CommitIds = append(h.CommitIds, commitId)
+		if i+1 >= len(tokens) || !strings.HasPrefix(tokens[i+1], "\n") {
+			continue
+		}
+		i++
+		status := strings.TrimSpace(tokens[i])
+		if len(status) == 0 {
+			continue
+		}
+		switch status[0] {
+		case 'R', 'C':
+			if i+2 < len(tokens) {
+				h.Renames = append(h.Renames, &FileRename{
+					CommitId: commitId,
+					OldPath:  tokens[i+1],
+					NewPath:  tokens[i+2],
+					IsCopy:   status[0] == 'C',
+				})
+			}
+			i += 2
+		default:
+			i++
+		}
+	}
+	return h
+}
+
+// GetFileHistory returns history of file at given revision that follows renames of the file.
+func GetFileHistory(repoPath, rev, treePath string) (*FileHistory, error) {
+	stdout, err := execGitCmd(repoPath, nil, nil, "log", "--follow", "-z", "--format=%H",
+		"--name-status", rev, "--", treePath)
+	if err != nil {
+		return nil, err
+	}
+	return parseFollowLog(stdout), nil
+}
+
+// GetCommitsByPage returns commits of history in given page, page starts from 1.
+func (h *FileHistory) GetCommitsByPage(gitRepo *git.Repository, page, pageSize int) (*list.List, error) {
+	commits := list.New()
+	start := (page - 1) * pageSize
+	for i := start; i < start+pageSize && i < len(h.CommitIds); i++ {
+		c, err := gitRepo.GetCommit(h.CommitIds[i])
+		if err != nil {
+			return nil, err
+		}
+		commits.PushBack(c)
+	}
+	return commits, nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseFollowLog(t *testing.T) {
+	output := "c3\x00\nM\x00b.txt\x00" +
+		"c2\x00\nR100\x00a.txt\x00b.txt\x00" +
+		"m1\x00" +
+		"c1\x00\nC075\x00orig.txt\x00a.txt\x00" +
+		"c0\x00\nA\x00orig.txt\x00"
+	h := parseFollowLog(output)
+
+	expectedIds := []string{"c3", "c2", "m1", "c1", "c0"}
+	if strings.Join(h.CommitIds, ",") != strings.Join(expectedIds, ",") {
+		t.Errorf("parseFollowLog commits = %v, expected %v", h.CommitIds, expectedIds)
+	}
+	expectedRenames := []FileRename{
+		{"c2", "a.txt", "b.txt", false},
+		{"c1", "orig.txt", "a.txt", true},
+	}
+	if len(h.Renames) != len(expectedRenames) {
+		t.Fatalf("parseFollowLog returns %d renames, expected %d", len(h.Renames), len(expectedRenames))
+	}
+	for i, r := range h.Renames {
+		if *r != expectedRenames[i] {
+			t.Errorf("parseFollowLog rename[%d] = %+v, expected %+v", i, *r, expectedRenames[i])
+		}
+	}
+
+	if h = parseFollowLog(""); len(h.CommitIds) != 0 || len(h.Renames) != 0 {
+		t.Errorf("parseFollowLog(empty) = %+v, expected no commits", h)
+	}
+}
+
+func TestGetFileHistory(t *testing.T) {
+	repoPath, err := ioutil.TempDir("", "gogs-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoPath)
+	if _, err = execGitCmd(repoPath, nil, nil, "init", "-q", "--bare"); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "line 1\nline 2\nline 3\nline 4\n"
+	ids := []string{
+		testCommitFiles(t, repoPath, "master", "", map[string]string{"a.txt": content}, "Add a"),
+		testCommitFiles(t, repoPath, "master", "", map[string]string{"a.txt": content + "line 5\n"}, "Change a"),
+	}
+	testCommitFiles(t, repoPath, "master", "", map[string]string{"other.txt": "other\n"}, "Add other")
+	ids = append(ids,
+		testCommitFiles(t, repoPath, "master", "", map[string]string{"a.txt": "", "b.txt": content + "line 5\n"}, "Rename a to b"),
+		testCommitFiles(t, repoPath, "master", "", map[string]string{"b.txt": content + "line 6\n"}, "Change b"))
+
+	h, err := GetFileHistory(repoPath, "master", "b.txt")
+	if err != nil {
+		t.Fatalf("GetFileHistory: %v", err)
+	}
+	for i, j := 0, len(ids)-1; i < j; i, j = i+1, j-1 {
+		ids[i], ids[j] = ids[j], ids[i]
+	}
+	if strings.Join(h.CommitIds, ",") != strings.Join(ids, ",") {
+		t.Errorf("GetFileHistory commits = %v, expected %v", h.CommitIds, ids)
+	}
+	expected := FileRename{ids[1], "a.txt", "b.txt", false}
+	if len(h.Renames) != 1 || *h.Renames[0] != expected {
+		t.Errorf("GetFileHistory renames = %v, expected [%+v]", h.Renames, expected)
+	}
+
+	if _, err = GetFileHistory(repoPath, "none", "b.txt"); err == nil {
+		t.Error("GetFileHistory(unknown revision) returns no error")
+	}
+}
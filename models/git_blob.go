@@ -0,0 +1,77 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bytes"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/gogits/gogs/modules/base"
+)
+
+// blobReader reads content of blob from output of git command.
+type blobReader struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+// Close stops reading and waits for command to exit.
+func (r *blobReader) Close() error {
+	r.ReadCloser.Close()
+	// Command is killed if content has not been fully read.
+	r.cmd.Process.Kill()
+	r.cmd.Wait()
+	return nil
+}
+
+// OpenBlob returns a reader that streams content of blob without loading it into memory,
+// reader must be closed after use.
+func OpenBlob(repoPath, blobId string) (io.ReadCloser, error) {
+	cmd := exec.Command("git", "cat-file", "blob", blobId)
+	cmd.Dir = repoPath
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	} else if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &blobReader{stdout, cmd}, nil
+}
+
+// LFS_POINTER_MAX_SIZE is max size of a Git LFS pointer file in bytes.
+const LFS_POINTER_MAX_SIZE = 1024
+
+// LFSPointer represents a Git LFS pointer file, which is stored in repository in place of large file.
+type LFSPointer struct {
+	Oid  string // SHA-256 of object.
+	Size int64  // Size of object in bytes.
+}
+
+// ParseLFSPointer returns pointer if given content is a Git LFS pointer file, or nil otherwise.
+func ParseLFSPointer(data []byte) *LFSPointer {
+	if len(data) > LFS_POINTER_MAX_SIZE || !bytes.HasPrefix(data, []byte("version https://git-lfs.github.com/spec/")) {
+		return nil
+	}
+
+	p := new(LFSPointer)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "oid":
+			p.Oid = strings.TrimPrefix(fields[1], "sha256:")
+		case "size":
+			p.Size, _ = base.StrTo(fields[1]).Int64()
+		}
+	}
+	if len(p.Oid) != 64 {
+		return nil
+	}
+	return p
+}
@@ -0,0 +1,104 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/gogits/git"
+
+	"github.com/gogits/gogs/modules/base"
+)
+
+// EMPTY_TREE_ID is ID of the empty tree, which every repository has implicitly,
+// changes of a root commit are the ones against it.
+const EMPTY_TREE_ID = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// Formats of raw patch.
+const (
+	PATCH_DIFF = "diff"  // Output of git diff.
+	PATCH_MAIL = "patch" // Output of git format-patch, one mail per commit.
+)
+
+// revListArgs returns arguments of git rev-list for commits of given revision
+// or range like "before..after", limited to given path if it is not empty.
+func revListArgs(rev, treePath string, opts ...string) []string {
+	args := append([]string{"rev-list"}, opts...)
+	args = append(args, rev, "--")
+	if len(treePath) > 0 {
+		args = append(args, treePath)
+	}
+	return args
+}
+
+// CountCommits returns number of commits of given revision or range like "before..after",
+// only commits that change given path are counted if it is not empty.
+func CountCommits(repoPath, rev, treePath string) (int64, error) {
+	stdout, err := execGitCmd(repoPath, []string{"GIT_LITERAL_PATHSPECS=1"}, nil,
+		revListArgs(rev, treePath, "--count")...)
+	if err != nil {
+		return 0, err
+	}
+	return base.StrTo(stdout).Int64()
+}
+
+// GetCommitsPage returns commits of given revision or range like "before..after" on given page
+// newest first, and total number of them. Only commits that change given path are included
+// if it is not empty.
+func GetCommitsPage(repoPath, rev, treePath string, page, pageSize int) ([]*git.Commit, int64, error) {
+	total, err := CountCommits(repoPath, rev, treePath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	stdout, err := execGitCmd(repoPath, []string{"GIT_LITERAL_PATHSPECS=1"}, nil, revListArgs(rev, treePath,
+		"--skip="+base.ToStr((page-1)*pageSize), "--max-count="+base.ToStr(pageSize))...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	repo, err := git.OpenRepository(repoPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	commits := make([]*git.Commit, 0, pageSize)
+	for _, id := range strings.Fields(stdout) {
+		c, err := repo.GetCommit(id)
+		if err != nil {
+			return nil, 0, err
+		}
+		commits = append(commits, c)
+	}
+	return commits, total, nil
+}
+
+// WritePatch writes changes between two commits to w in given format, in mail format
+// every commit that is reachable from after commit but not from before commit is a mail.
+// Before commit can be EMPTY_TREE_ID for changes of a root commit.
+func WritePatch(w io.Writer, repoPath, beforeCommitId, afterCommitId, format string) error {
+	var args []string
+	switch {
+	case format == PATCH_DIFF:
+		args = []string{"diff", "--binary", beforeCommitId, afterCommitId}
+	case beforeCommitId == EMPTY_TREE_ID:
+		args = []string{"format-patch", "--stdout", "--binary", "--root", afterCommitId}
+	default:
+		args = []string{"format-patch", "--stdout", "--binary", beforeCommitId + ".." + afterCommitId}
+	}
+
+	stderr := new(bytes.Buffer)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoPath
+	cmd.Stdout = w
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return errors.New("git " + args[0] + ": " + stderr.String())
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRevListArgs(t *testing.T) {
+	tests := []struct {
+		rev, treePath string
+		opts          []string
+		expected      []string
+	}{
+		{"master", "", nil, []string{"rev-list", "master", "--"}},
+		{"v1.0..master", "", []string{"--count"}, []string{"rev-list", "--count", "v1.0..master", "--"}},
+		{"master", "-p", []string{"--skip=10", "--max-count=10"}, []string{"rev-list", "--skip=10", "--max-count=10", "master", "--", "-p"}},
+	}
+	for _, tt := range tests {
+		if args := revListArgs(tt.rev, tt.treePath, tt.opts...); !reflect.DeepEqual(args, tt.expected) {
+			t.Errorf("revListArgs(%q, %q, %q) = %q, expected %q", tt.rev, tt.treePath, tt.opts, args, tt.expected)
+		}
+	}
+}
+
+func TestCommitsAndPatches(t *testing.T) {
+	repoPath, err := ioutil.TempDir("", "gogs-commit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoPath)
+
+	if _, err = execGitCmd(repoPath, nil, nil, "init", "-q", "--bare"); err != nil {
+		t.Fatal(err)
+	}
+	first := testCommitFiles(t, repoPath, "master", "", map[string]string{"a.txt": "a\n"}, "Add a")
+	testCommitFiles(t, repoPath, "master", "", map[string]string{"b.txt": "b\n"}, "Add b")
+	last := testCommitFiles(t, repoPath, "master", "", map[string]string{"a.txt": "aa\n"}, "Change a")
+
+	tests := []struct {
+		rev, treePath string
+		expected      int64
+	}{
+		{"master", "", 3},
+		{"master", "a.txt", 2},
+		{"master", "*.txt", 0}, // Paths are literal.
+		{first + "..master", "", 2},
+	}
+	for _, tt := range tests {
+		if n, err := CountCommits(repoPath, tt.rev, tt.treePath); err != nil || n != tt.expected {
+			t.Errorf("CountCommits(%q, %q) = (%d, %v), expected %d", tt.rev, tt.treePath, n, err, tt.expected)
+		}
+	}
+	if _, err = CountCommits(repoPath, "none", ""); err == nil {
+		t.Errorf("CountCommits(unknown revision) returns no error")
+	}
+
+	commits, total, err := GetCommitsPage(repoPath, "master", "a.txt", 2, 1)
+	if err != nil {
+		t.Fatalf("GetCommitsPage: %v", err)
+	} else if total != 2 || len(commits) != 1 || commits[0].Id.String() != first {
+		t.Errorf("GetCommitsPage(a.txt, page 2) returns %d commits of total %d, expected first commit of total 2", len(commits), total)
+	}
+
+	patchTests := []struct {
+		before, format string
+		mails          int
+		contains       []string
+	}{
+		{first, PATCH_DIFF, 0, []string{"+b\n", "-a\n+aa\n"}},
+		{first, PATCH_MAIL, 2, []string{"Subject: [PATCH 1/2] Add b", "Subject: [PATCH 2/2] Change a"}},
+		{EMPTY_TREE_ID, PATCH_DIFF, 0, []string{"+aa\n", "+b\n"}},
+		{EMPTY_TREE_ID, PATCH_MAIL, 3, []string{"Subject: [PATCH 1/3] Add a"}},
+	}
+	for _, tt := range patchTests {
+		buf := new(bytes.Buffer)
+		if err = WritePatch(buf, repoPath, tt.before, last, tt.format); err != nil {
+			t.Fatalf("WritePatch(%s, %s): %v", tt.before, tt.format, err)
+		}
+		patch := buf.String()
+		if n := strings.Count(patch, "\nSubject: "); n != tt.mails {
+			t.Errorf("WritePatch(%s, %s) has %d mails, expected %d", tt.before, tt.format, n, tt.mails)
+		}
+		for _, s := range tt.contains {
+			if !strings.Contains(patch, s) {
+				t.Errorf("WritePatch(%s, %s) does not contain %q:\n%s", tt.before, tt.format, s, patch)
+			}
+		}
+	}
+	if err = WritePatch(new(bytes.Buffer), repoPath, "none", last, PATCH_DIFF); err == nil {
+		t.Errorf("WritePatch(unknown commit) returns no error")
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gogits/gogs/modules/base"
+)
+
+var (
+	ErrGitObjectNotExist = errors.New("Git object does not exist")
+	ErrRefAlreadyExist   = errors.New("Reference already exists")
+	ErrRefNotExist       = errors.New("Reference does not exist")
+	ErrRefNameIllegal    = errors.New("Reference name must be a valid branch or tag reference")
+)
+
+// GitRef represents a branch or tag reference of repository.
+type GitRef struct {
+	Name     string
+	ObjectId string
+	Type     string // Type of object that reference points to, "commit" or "tag".
+}
+
+// GetGitRefs returns branch and tag references of repository whose names start with given prefix.
+func GetGitRefs(repoPath, prefix string) ([]*GitRef, error) {
+	stdout, err := execGitCmd(repoPath, nil, nil, "for-each-ref",
+		"--format=%(objectname) %(objecttype) %(refname)", "refs/heads/", "refs/tags/")
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]*GitRef, 0, 10)
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 || !strings.HasPrefix(fields[2], prefix) {
+			continue
+		}
+		refs = append(refs, &GitRef{fields[2], fields[0], fields[1]})
+	}
+	return refs, nil
+}
+
+// GetGitRef returns reference of repository by given full name.
+func GetGitRef(repoPath, refName string) (*GitRef, error) {
+	refs, err := GetGitRefs(repoPath, refName)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range refs {
+		if ref.Name == refName {
+			return ref, nil
+		}
+	}
+	return nil, ErrRefNotExist
+}
+
+// isBranchOrTagRef returns true if given name is a valid branch or tag reference.
+func isBranchOrTagRef(repoPath, refName string) bool {
+	if !strings.HasPrefix(refName, "refs/heads/") && !strings.HasPrefix(refName, "refs/tags/") {
+		return false
+	}
+	_, err := execGitCmd(repoPath, nil, nil, "check-ref-format", refName)
+	return err == nil
+}
+
+// CreateGitRef creates a branch or tag reference pointing to given commit,
+// it is subject to same protection rules and hooks as pushes.
+func CreateGitRef(doer *User, repo *Repository, refName, commitId string) error {
+	if err := repo.GetOwner(); err != nil {
+		return err
+	}
+	repoPath := RepoPath(repo.Owner.Name, repo.Name)
+	if !isBranchOrTagRef(repoPath, refName) {
+		return ErrRefNameIllegal
+	} else if _, err := execGitCmd(repoPath, nil, nil, "rev-parse", "--verify", "-q", refName); err == nil {
+		return ErrRefAlreadyExist
+	}
+
+	commitId, err := ResolveCommitId(repoPath, commitId)
+	if err != nil {
+		return ErrGitObjectNotExist
+	}
+
+	if err = CheckBranchPush(repo, repoPath, doer.Id, refName, _EMPTY_COMMIT_ID, commitId); err != nil {
+		return err
+	} else if _, err = execGitCmd(repoPath, nil, nil, "update-ref", refName, commitId, _EMPTY_COMMIT_ID); err != nil {
+		return err
+	}
+
+	return Update(refName, _EMPTY_COMMIT_ID, commitId, doer.Name, repo.Owner.Name, repo.Name, doer.Id)
+}
+
+// DeleteGitRef deletes a branch or tag reference, default branch cannot be deleted.
+func DeleteGitRef(doer *User, repo *Repository, refName string) error {
+	if err := repo.GetOwner(); err != nil {
+		return err
+	}
+	if strings.HasPrefix(refName, "refs/tags/") {
+		return DeleteTag(doer, repo, strings.TrimPrefix(refName, "refs/tags/"))
+	} else if refName == "refs/heads/"+repo.DefaultBranch {
+		return ErrDeleteDefaultBranch
+	}
+
+	repoPath := RepoPath(repo.Owner.Name, repo.Name)
+	if !isBranchOrTagRef(repoPath, refName) {
+		return ErrRefNotExist
+	}
+	commitId, err := execGitCmd(repoPath, nil, nil, "rev-parse", "--verify", "-q", refName)
+	if err != nil {
+		return ErrRefNotExist
+	}
+
+	if err = CheckBranchPush(repo, repoPath, doer.Id, refName, commitId, _EMPTY_COMMIT_ID); err != nil {
+		return err
+	} else if _, err = execGitCmd(repoPath, nil, nil, "update-ref", "-d", refName, commitId); err != nil {
+		return err
+	}
+
+	return Update(refName, commitId, _EMPTY_COMMIT_ID, doer.Name, repo.Owner.Name, repo.Name, doer.Id)
+}
+
+// GitTreeEntry represents an entry of tree object.
+type GitTreeEntry struct {
+	Mode string
+	Type string // "blob", "tree" or "commit" for submodules.
+	Id   string
+	Size int64 // Size of blob in bytes, -1 for other types.
+	Path string
+}
+
+// GetGitTree returns entries of tree that given tree-ish points to,
+// entries of sub-trees are included when it is recursive.
+func GetGitTree(repoPath, treeish string, recursive bool) ([]*GitTreeEntry, error) {
+	if strings.HasPrefix(treeish, "-") {
+		return nil, ErrGitObjectNotExist
+	}
+	args := []string{"ls-tree", "-l", "-z"}
+	if recursive {
+		args = append(args, "-r", "-t")
+	}
+	stdout, err := execGitCmd(repoPath, nil, nil, append(args, treeish)...)
+	if err != nil {
+		return nil, ErrGitObjectNotExist
+	}
+	return parseGitTree(stdout), nil
+}
+
+// GetGitTreeEntry returns entry of given path in tree that tree-ish points to.
+func GetGitTreeEntry(repoPath, treeish, treePath string) (*GitTreeEntry, error) {
+	if strings.HasPrefix(treeish, "-") {
+		return nil, ErrGitObjectNotExist
+	}
+	stdout, err := execGitCmd(repoPath, nil, nil, "ls-tree", "-l", "-z", treeish, "--", treePath)
+	if err != nil {
+		return nil, ErrGitObjectNotExist
+	}
+	entries := parseGitTree(stdout)
+	if len(entries) != 1 || entries[0].Path != treePath {
+		return nil, ErrGitObjectNotExist
+	}
+	return entries[0], nil
+}
+
+// parseGitTree parses output of "git ls-tree -l -z".
+func parseGitTree(stdout string) []*GitTreeEntry {
+	entries := make([]*GitTreeEntry, 0, 10)
+	for _, line := range strings.Split(stdout, "\x00") {
+		// Format: <mode> SP <type> SP <object> SP <size> TAB <path>
+		infos := strings.SplitN(line, "\t", 2)
+		if len(infos) != 2 {
+			continue
+		}
+		fields := strings.Fields(infos[0])
+		if len(fields) != 4 {
+			continue
+		}
+		e := &GitTreeEntry{Mode: fields[0], Type: fields[1], Id: fields[2], Size: -1, Path: infos[1]}
+		if e.Type == "blob" {
+			e.Size, _ = base.StrTo(fields[3]).Int64()
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// GetBlobSize returns size of blob in bytes.
+func GetBlobSize(repoPath, blobId string) (int64, error) {
+	if strings.HasPrefix(blobId, "-") {
+		return 0, ErrGitObjectNotExist
+	} else if typ, err := execGitCmd(repoPath, nil, nil, "cat-file", "-t", blobId); err != nil || typ != "blob" {
+		return 0, ErrGitObjectNotExist
+	}
+	return getBlobSize(repoPath, blobId)
+}
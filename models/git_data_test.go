@@ -0,0 +1,169 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseGitTree(t *testing.T) {
+	stdout := "100644 blob 8d0e41234f24b6da002d962a26c2495ea16a425f       4\tREADME.md\x00" +
+		"040000 tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904       -\tdocs\x00" +
+		"160000 commit 1111111111111111111111111111111111111111       -\tlib\x00" +
+		"100644 blob 8d0e41234f24b6da002d962a26c2495ea16a425f       4\tname\twith tab\x00" +
+		"broken line\x00"
+	expected := []*GitTreeEntry{
+		{"100644", "blob", "8d0e41234f24b6da002d962a26c2495ea16a425f", 4, "README.md"},
+		{"040000", "tree", "4b825dc642cb6eb9a060e54bf8d69288fbee4904", -1, "docs"},
+		{"160000", "commit", "1111111111111111111111111111111111111111", -1, "lib"},
+		{"100644", "blob", "8d0e41234f24b6da002d962a26c2495ea16a425f", 4, "name\twith tab"},
+	}
+	entries := parseGitTree(stdout)
+	if len(entries) != len(expected) {
+		t.Fatalf("parseGitTree returns %d entries, expected %d", len(entries), len(expected))
+	}
+	for i := range entries {
+		if *entries[i] != *expected[i] {
+			t.Errorf("parseGitTree entry #%d = %+v, expected %+v", i, entries[i], expected[i])
+		}
+	}
+}
+
+func TestGetGitTree(t *testing.T) {
+	repoPath, err := ioutil.TempDir("", "gogs-tree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoPath)
+	if _, err = execGitCmd(repoPath, nil, nil, "init", "-q", "--bare"); err != nil {
+		t.Fatal(err)
+	}
+	testCommitFiles(t, repoPath, "master", "", map[string]string{"README.md": "abc\n", "docs/a.md": "a\n"}, "Initial commit")
+
+	paths := func(entries []*GitTreeEntry) []string {
+		names := make([]string, len(entries))
+		for i, e := range entries {
+			names[i] = e.Path
+		}
+		return names
+	}
+	tests := []struct {
+		recursive bool
+		expected  []string
+	}{
+		{false, []string{"README.md", "docs"}},
+		{true, []string{"README.md", "docs", "docs/a.md"}},
+	}
+	for _, tt := range tests {
+		entries, err := GetGitTree(repoPath, "master", tt.recursive)
+		if err != nil {
+			t.Fatalf("GetGitTree(recursive %v): %v", tt.recursive, err)
+		} else if names := paths(entries); !reflect.DeepEqual(names, tt.expected) {
+			t.Errorf("GetGitTree(recursive %v) = %v, expected %v", tt.recursive, names, tt.expected)
+		}
+	}
+	for _, treeish := range []string{"none", "--all"} {
+		if _, err = GetGitTree(repoPath, treeish, false); err != ErrGitObjectNotExist {
+			t.Errorf("GetGitTree(%q) error = %v, expected %v", treeish, err, ErrGitObjectNotExist)
+		}
+	}
+
+	e, err := GetGitTreeEntry(repoPath, "master", "README.md")
+	if err != nil {
+		t.Fatalf("GetGitTreeEntry: %v", err)
+	} else if e.Type != "blob" || e.Size != 4 {
+		t.Errorf("GetGitTreeEntry(README.md) = %+v, expected blob of 4 bytes", e)
+	}
+	for _, treePath := range []string{"docs/b.md", "docs/"} {
+		if _, err = GetGitTreeEntry(repoPath, "master", treePath); err != ErrGitObjectNotExist {
+			t.Errorf("GetGitTreeEntry(%q) error = %v, expected %v", treePath, err, ErrGitObjectNotExist)
+		}
+	}
+
+	if size, err := GetBlobSize(repoPath, e.Id); err != nil || size != 4 {
+		t.Errorf("GetBlobSize = (%d, %v), expected 4", size, err)
+	}
+	for _, id := range []string{"master", "-s"} {
+		if _, err = GetBlobSize(repoPath, id); err != ErrGitObjectNotExist {
+			t.Errorf("GetBlobSize(%q) error = %v, expected %v", id, err, ErrGitObjectNotExist)
+		}
+	}
+}
+
+func TestGitRefs(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo := newTestRepo(t, u, "repo1")
+	repoPath := RepoPath(u.Name, repo.Name)
+	if _, err := execGitCmd(repoPath, testGitEnv, nil, "tag", "-a", "-m", "Release", "v1.0", "master"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		refName, commitId string
+		expected          error
+	}{
+		{"refs/heads/feature", "master", nil},
+		{"refs/tags/v0.1", "master", nil},
+		{"refs/heads/feature", "master", ErrRefAlreadyExist},
+		{"refs/notes/feature", "master", ErrRefNameIllegal},
+		{"refs/heads/bad..name", "master", ErrRefNameIllegal},
+		{"heads/feature-2", "master", ErrRefNameIllegal},
+		{"refs/heads/feature-2", "none", ErrGitObjectNotExist},
+	}
+	for _, tt := range tests {
+		if err := CreateGitRef(u, repo, tt.refName, tt.commitId); err != tt.expected {
+			t.Errorf("CreateGitRef(%q, %q) error = %v, expected %v", tt.refName, tt.commitId, err, tt.expected)
+		}
+	}
+
+	refs, err := GetGitRefs(repoPath, "refs/")
+	if err != nil {
+		t.Fatalf("GetGitRefs: %v", err)
+	}
+	types := make(map[string]string, len(refs))
+	for _, ref := range refs {
+		types[ref.Name] = ref.Type
+	}
+	expected := map[string]string{
+		"refs/heads/feature": "commit",
+		"refs/heads/master":  "commit",
+		"refs/tags/v0.1":     "commit",
+		"refs/tags/v1.0":     "tag",
+	}
+	if !reflect.DeepEqual(types, expected) {
+		t.Errorf("GetGitRefs = %v, expected %v", types, expected)
+	}
+	if refs, err = GetGitRefs(repoPath, "refs/tags/"); err != nil || len(refs) != 2 {
+		t.Errorf("GetGitRefs(refs/tags/) returns %d references, expected 2", len(refs))
+	}
+	// Prefix of a reference name does not match the reference.
+	if _, err = GetGitRef(repoPath, "refs/heads/feat"); err != ErrRefNotExist {
+		t.Errorf("GetGitRef(prefix) error = %v, expected %v", err, ErrRefNotExist)
+	}
+
+	for _, tt := range []struct {
+		refName  string
+		expected error
+	}{
+		{"refs/heads/master", ErrDeleteDefaultBranch},
+		{"refs/heads/none", ErrRefNotExist},
+		{"refs/tags/none", ErrTagNotExist},
+		{"refs/heads/feature", nil},
+		{"refs/tags/v0.1", nil},
+	} {
+		if err = DeleteGitRef(u, repo, tt.refName); err != tt.expected {
+			t.Errorf("DeleteGitRef(%q) error = %v, expected %v", tt.refName, err, tt.expected)
+		}
+	}
+	for _, refName := range []string{"refs/heads/feature", "refs/tags/v0.1"} {
+		if _, err = GetGitRef(repoPath, refName); err != ErrRefNotExist {
+			t.Errorf("GetGitRef(%q) error = %v, expected %v after deletion", refName, err, ErrRefNotExist)
+		}
+	}
+}
@@ -6,11 +6,16 @@ package models
 
 import (
 	"bufio"
+	"container/list"
+	"errors"
 	"io"
 	"os"
 	"os/exec"
+	"path"
 	"strings"
 
+	"github.com/Unknwon/com"
+
 	"github.com/gogits/git"
 
 	"github.com/gogits/gogs/modules/base"
@@ -36,6 +41,9 @@ type DiffLine struct {
 	RightIdx int
 	Type     int
 	Content  string
+	Threads  []*ReviewThread // Review threads of pull request on this line.
+
+	CommitComments []*CommitComment // Comments of commit on this line.
 }
 
 func (d DiffLine) GetType() int {
@@ -54,20 +62,66 @@ type DiffFile struct {
 	Type               int
 	IsBin              bool
 	Sections           []*DiffSection
+
+	// Abbreviated blob IDs before and after change, empty if file does not exist.
+	OldBlobId, NewBlobId string
+	// Following fields are only set for binary files.
+	IsImage          bool
+	OldSize, NewSize int64
+
+	// Following fields are only set for paginated diffs.
+	Page           int    // Page that file is shown on.
+	IsCollapsed    bool   // Content is not loaded unless requested.
+	CollapseReason string // One of "generated", "vendored" and "large".
 }
 
 type Diff struct {
 	TotalAddition, TotalDeletion int
 	Files                        []*DiffFile
+
+	// Current page and number of pages of paginated diff, content of files
+	// that are on other pages is not loaded.
+	Page, NumPages int
 }
 
 func (diff *Diff) NumFiles() int {
 	return len(diff.Files)
 }
 
+// IsFileShown returns true if content of file is loaded in diff.
+func (diff *Diff) IsFileShown(f *DiffFile) bool {
+	return !f.IsCollapsed && f.Page == diff.Page
+}
+
+// GetFile returns file of diff by its path, or nil if file is not changed.
+func (diff *Diff) GetFile(treePath string) *DiffFile {
+	for _, f := range diff.Files {
+		if f.Name == treePath {
+			return f
+		}
+	}
+	return nil
+}
+
 const DIFF_HEAD = "diff --git "
 
+const (
+	// Maximum number of lines of patch that is parsed at once.
+	_DIFF_MAX_LINES = 5000
+	// Maximum number of changed lines of a file that is shown without request.
+	_DIFF_MAX_FILE_LINES = 1000
+	// Maximum number of files and changed lines shown on a page of paginated diff.
+	_DIFF_PAGE_MAX_FILES = 50
+	_DIFF_PAGE_MAX_LINES = 3000
+)
+
 func ParsePatch(cmd *exec.Cmd, reader io.Reader) (*Diff, error) {
+	return parsePatch(cmd, reader, _DIFF_MAX_LINES)
+}
+
+// parsePatch parses output of git diff command, diff is empty if patch has more than
+// given number of lines. Zero means no limit.
+func parsePatch(cmd *exec.Cmd, reader io.Reader, maxLines int) (*Diff, error) {
 	scanner := bufio.NewScanner(reader)
 	var (
 		curFile    *DiffFile
@@ -90,7 +144,7 @@ func ParsePatch(cmd *exec.Cmd, reader io.Reader) (*Diff, error) {
 		i = i + 1
 
 		// Diff data too large.
-		if i == 5000 {
+		if i == maxLines {
 			log.Warn("Diff data too large")
 			return &Diff{}, nil
 		}
@@ -136,6 +190,9 @@ func ParsePatch(cmd *exec.Cmd, reader io.Reader) (*Diff, error) {
 		case strings.HasPrefix(line, "Binary"):
 			curFile.IsBin = true
 			continue
+		case strings.HasPrefix(line, "index ") && curFile != nil:
+			parseDiffIndex(curFile, line)
+			continue
 		}
 
 		// Get new file.
@@ -160,6 +217,7 @@ func ParsePatch(cmd *exec.Cmd, reader io.Reader) (*Diff, error) {
 					curFile.Type = DIFF_FILE_DEL
 				case strings.HasPrefix(scanner.Text(), "index"):
 					curFile.Type = DIFF_FILE_CHANGE
+					parseDiffIndex(curFile, scanner.Text())
 				}
 				if curFile.Type > 0 {
 					break
@@ -189,7 +247,6 @@ func GetDiff(repoPath, commitid string) (*Diff, error) {
 		return nil, err
 	}
 
-	rd, wr := io.Pipe()
 	var cmd *exec.Cmd
 	// First commit of repository.
 	if commit.ParentCount() == 0 {
@@ -198,6 +255,224 @@ func GetDiff(repoPath, commitid string) (*Diff, error) {
 		c, _ := commit.Parent(0)
 		cmd = exec.Command("git", "diff", c.Id.String(), commitid)
 	}
+	return runDiffCmd(repoPath, cmd, _DIFF_MAX_LINES)
+}
+
+// GetDiffRange returns diff between two commits.
+func GetDiffRange(repoPath, beforeCommitId, afterCommitId string) (*Diff, error) {
+	return runDiffCmd(repoPath, exec.Command("git", "diff", beforeCommitId, afterCommitId), _DIFF_MAX_LINES)
+}
+
+var ErrDiffFileNotExist = errors.New("File is not changed in diff")
+
+// getDiffRangeStats returns diff between two commits that has all changed files
+// with their numbers of changed lines but without content.
+func getDiffRangeStats(repoPath, beforeCommitId, afterCommitId string) (*Diff, error) {
+	stdout, err := execGitCmd(repoPath, nil, nil, "-c", "core.quotepath=false",
+		"diff", "--no-renames", "--numstat", "--summary", beforeCommitId, afterCommitId)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &Diff{Files: make([]*DiffFile, 0, 10)}
+	files := make(map[string]*DiffFile)
+	for _, line := range strings.Split(stdout, "\n") {
+		// Numbers of added and deleted lines are "-" for binary files.
+		if fields := strings.SplitN(line, "\t", 3); len(fields) == 3 {
+			f := &DiffFile{
+				Name:  fields[2],
+				Index: len(diff.Files) + 1,
+				Type:  DIFF_FILE_CHANGE,
+				IsBin: fields[0] == "-",
+			}
+			f.Addition, _ = base.StrTo(fields[0]).Int()
+			f.Deletion, _ = base.StrTo(fields[1]).Int()
+			diff.TotalAddition += f.Addition
+			diff.TotalDeletion += f.Deletion
+			diff.Files = append(diff.Files, f)
+			files[f.Name] = f
+			continue
+		}
+
+		// Summary lines look like " create mode 100644 path".
+		fields := strings.SplitN(strings.TrimSpace(line), " ", 4)
+		if len(fields) < 4 || fields[1] != "mode" || files[fields[3]] == nil {
+			continue
+		}
+		switch fields[0] {
+		case "create":
+			files[fields[3]].Type = DIFF_FILE_ADD
+		case "delete":
+			files[fields[3]].Type = DIFF_FILE_DEL
+		}
+	}
+	return diff, nil
+}
+
+// gitAttrRule represents a line of .gitattributes file.
+type gitAttrRule struct {
+	pattern string
+	attrs   map[string]bool
+}
+
+// getGitAttrRules returns rules of .gitattributes file in root directory of given commit.
+func getGitAttrRules(repoPath, commitId string) []*gitAttrRule {
+	stdout, err := execGitCmd(repoPath, nil, nil, "cat-file", "blob", commitId+":.gitattributes")
+	if err != nil {
+		return nil
+	}
+
+	rules := make([]*gitAttrRule, 0, 5)
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		r := &gitAttrRule{pattern: fields[0], attrs: make(map[string]bool)}
+		for _, attr := range fields[1:] {
+			switch {
+			case strings.HasPrefix(attr, "-"), strings.HasPrefix(attr, "!"):
+				r.attrs[attr[1:]] = false
+			case strings.Contains(attr, "="):
+				kv := strings.SplitN(attr, "=", 2)
+				r.attrs[kv[0]] = kv[1] != "false"
+			default:
+				r.attrs[attr] = true
+			}
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// matchGitAttrPattern returns true if path matches pattern of .gitattributes,
+// only commonly used forms of patterns are supported.
+func matchGitAttrPattern(pattern, treePath string) bool {
+	switch {
+	case strings.HasSuffix(pattern, "/**"):
+		return strings.HasPrefix(treePath, strings.TrimPrefix(strings.TrimSuffix(pattern, "**"), "/"))
+	case strings.HasPrefix(pattern, "**/"):
+		pattern = pattern[3:]
+		for {
+			if ok, _ := path.Match(pattern, treePath); ok {
+				return true
+			}
+			i := strings.Index(treePath, "/")
+			if i == -1 {
+				return false
+			}
+			treePath = treePath[i+1:]
+		}
+	case !strings.Contains(pattern, "/"):
+		ok, _ := path.Match(pattern, path.Base(treePath))
+		return ok
+	}
+	ok, _ := path.Match(strings.TrimPrefix(pattern, "/"), treePath)
+	return ok
+}
+
+// gitAttrValue returns whether attribute is set for path, later rules override earlier ones.
+func gitAttrValue(rules []*gitAttrRule, treePath, attr string) bool {
+	isSet := false
+	for _, r := range rules {
+		if v, ok := r.attrs[attr]; ok && matchGitAttrPattern(r.pattern, treePath) {
+			isSet = v
+		}
+	}
+	return isSet
+}
+
+// GetDiffRangePage returns diff between two commits that only has content of files
+// on given page, files are paginated by both number of files and changed lines.
+// Files marked as generated or vendored by linguist attributes in .gitattributes and
+// files with too many changed lines are collapsed.
+func GetDiffRangePage(repoPath, beforeCommitId, afterCommitId string, page int) (*Diff, error) {
+	diff, err := getDiffRangeStats(repoPath, beforeCommitId, afterCommitId)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := getGitAttrRules(repoPath, afterCommitId)
+	diff.NumPages = 1
+	numFiles, numLines := 0, 0
+	for _, f := range diff.Files {
+		switch {
+		case gitAttrValue(rules, f.Name, "linguist-generated"):
+			f.CollapseReason = "generated"
+		case gitAttrValue(rules, f.Name, "linguist-vendored"):
+			f.CollapseReason = "vendored"
+		case f.Addition+f.Deletion > _DIFF_MAX_FILE_LINES:
+			f.CollapseReason = "large"
+		}
+		f.IsCollapsed = len(f.CollapseReason) > 0
+
+		lines := 0
+		if !f.IsCollapsed {
+			lines = f.Addition + f.Deletion
+		}
+		if numFiles == _DIFF_PAGE_MAX_FILES || (numFiles > 0 && numLines+lines > _DIFF_PAGE_MAX_LINES) {
+			diff.NumPages++
+			numFiles, numLines = 0, 0
+		}
+		f.Page = diff.NumPages
+		numFiles++
+		numLines += lines
+	}
+	if page < 1 || page > diff.NumPages {
+		page = 1
+	}
+	diff.Page = page
+
+	paths := make([]string, 0, _DIFF_PAGE_MAX_FILES)
+	for _, f := range diff.Files {
+		if diff.IsFileShown(f) {
+			paths = append(paths, f.Name)
+		}
+	}
+	if len(paths) == 0 {
+		return diff, nil
+	}
+	args := append([]string{"--literal-pathspecs", "diff", "--no-renames", beforeCommitId, afterCommitId, "--"}, paths...)
+	pageDiff, err := runDiffCmd(repoPath, exec.Command("git", args...), 0)
+	if err != nil {
+		return nil, err
+	}
+	for i, f := range diff.Files {
+		if pf := pageDiff.GetFile(f.Name); pf != nil {
+			pf.Index, pf.Page = f.Index, f.Page
+			diff.Files[i] = pf
+		}
+	}
+	return diff, nil
+}
+
+// GetDiffRangeFile returns diff between two commits that only has given file
+// with its full content regardless of its size.
+func GetDiffRangeFile(repoPath, beforeCommitId, afterCommitId, treePath string) (*Diff, error) {
+	stats, err := getDiffRangeStats(repoPath, beforeCommitId, afterCommitId)
+	if err != nil {
+		return nil, err
+	}
+	f := stats.GetFile(treePath)
+	if f == nil {
+		return nil, ErrDiffFileNotExist
+	}
+
+	diff, err := runDiffCmd(repoPath, exec.Command("git", "--literal-pathspecs", "diff", "--no-renames",
+		beforeCommitId, afterCommitId, "--", treePath), 0)
+	if err != nil {
+		return nil, err
+	} else if len(diff.Files) == 0 {
+		return nil, ErrDiffFileNotExist
+	}
+	diff.Files[0].Index = f.Index
+	return diff, nil
+}
+
+// runDiffCmd runs given git command in repository and parses its output as patch
+// that has at most given number of lines.
+func runDiffCmd(repoPath string, cmd *exec.Cmd, maxLines int) (*Diff, error) {
+	rd, wr := io.Pipe()
 	cmd.Dir = repoPath
 	cmd.Stdout = wr
 	cmd.Stdin = os.Stdin
@@ -207,5 +482,116 @@ func GetDiff(repoPath, commitid string) (*Diff, error) {
 		wr.Close()
 	}()
 	defer rd.Close()
-	return ParsePatch(cmd, rd)
+	diff, err := parsePatch(cmd, rd, maxLines)
+	if err != nil {
+		return nil, err
+	}
+	loadBinaryFileInfo(repoPath, diff)
+	return diff, nil
+}
+
+// parseDiffIndex parses blob IDs from line like "index 1234567..89abcde 100644".
+func parseDiffIndex(file *DiffFile, line string) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return
+	}
+	ids := strings.SplitN(fields[1], "..", 2)
+	if len(ids) != 2 {
+		return
+	}
+	// Blob ID of nonexistent file is all zeros.
+	if strings.Trim(ids[0], "0") != "" {
+		file.OldBlobId = ids[0]
+	}
+	if strings.Trim(ids[1], "0") != "" {
+		file.NewBlobId = ids[1]
+	}
+}
+
+var imageExts = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".gif":  true,
+	".bmp":  true,
+	".ico":  true,
+	".webp": true,
+}
+
+// getBlobSize returns size of blob in bytes.
+func getBlobSize(repoPath, blobId string) (int64, error) {
+	stdout, err := execGitCmd(repoPath, nil, nil, "cat-file", "-s", blobId)
+	if err != nil {
+		return 0, err
+	}
+	return base.StrTo(strings.TrimSpace(stdout)).Int64()
+}
+
+// loadBinaryFileInfo detects images and loads sizes of binary files in diff.
+func loadBinaryFileInfo(repoPath string, diff *Diff) {
+	var err error
+	for _, f := range diff.Files {
+		if !f.IsBin {
+			continue
+		}
+		f.IsImage = imageExts[strings.ToLower(path.Ext(f.Name))]
+		if len(f.OldBlobId) > 0 {
+			if f.OldSize, err = getBlobSize(repoPath, f.OldBlobId); err != nil {
+				log.Error("git_diff.loadBinaryFileInfo(%s): %v", f.OldBlobId, err)
+			}
+		}
+		if len(f.NewBlobId) > 0 {
+			if f.NewSize, err = getBlobSize(repoPath, f.NewBlobId); err != nil {
+				log.Error("git_diff.loadBinaryFileInfo(%s): %v", f.NewBlobId, err)
+			}
+		}
+	}
+}
+
+var ErrRevisionNotExist = errors.New("Revision does not exist")
+
+// ResolveCommitId returns ID of commit that given branch, tag or (abbreviated) commit ID points to.
+func ResolveCommitId(repoPath, rev string) (string, error) {
+	if len(rev) == 0 || strings.HasPrefix(rev, "-") {
+		return "", ErrRevisionNotExist
+	}
+	stdout, _, err := com.ExecCmdDir(repoPath, "git", "rev-parse", "--verify", "-q", rev+"^{commit}")
+	if err != nil {
+		return "", ErrRevisionNotExist
+	}
+	return strings.TrimSpace(stdout), nil
+}
+
+// GetMergeBase returns ID of best common ancestor commit of two commits or branches.
+func GetMergeBase(repoPath, base, head string) (string, error) {
+	stdout, stderr, err := com.ExecCmdDir(repoPath, "git", "merge-base", base, head)
+	if err != nil {
+		return "", errors.New("git merge-base: " + stderr)
+	}
+	return strings.TrimSpace(stdout), nil
+}
+
+// GetCommitsRange returns commits that are reachable from after commit but not from before commit,
+// newest first.
+func GetCommitsRange(repoPath, beforeCommitId, afterCommitId string) (*list.List, error) {
+	repo, err := git.OpenRepository(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, stderr, err := com.ExecCmdDir(repoPath, "git", "rev-list", beforeCommitId+".."+afterCommitId)
+	if err != nil {
+		return nil, errors.New("git rev-list: " + stderr)
+	}
+
+	commits := list.New()
+	for _, id := range strings.Fields(stdout) {
+		c, err := repo.GetCommit(id)
+		if err != nil {
+			return nil, err
+		}
+		commits.PushBack(c)
+	}
+	return commits, nil
 }
@@ -0,0 +1,214 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseDiffIndex(t *testing.T) {
+	tests := []struct {
+		line, oldId, newId string
+	}{
+		{"index 1234567..89abcde 100644", "1234567", "89abcde"},
+		{"index 0000000..89abcde", "", "89abcde"},
+		{"index 1234567..0000000", "1234567", ""},
+		{"index 1234567", "", ""},
+		{"index", "", ""},
+	}
+	for _, tt := range tests {
+		f := new(DiffFile)
+		parseDiffIndex(f, tt.line)
+		if f.OldBlobId != tt.oldId || f.NewBlobId != tt.newId {
+			t.Errorf("parseDiffIndex(%q) = (%q, %q), expected (%q, %q)", tt.line, f.OldBlobId, f.NewBlobId, tt.oldId, tt.newId)
+		}
+	}
+}
+
+func TestGetDiffRangeBinary(t *testing.T) {
+	repoPath, err := ioutil.TempDir("", "gogs-diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoPath)
+	if _, err = execGitCmd(repoPath, nil, nil, "init", "-q", "--bare"); err != nil {
+		t.Fatal(err)
+	}
+
+	png := "\x89PNG\r\n\x1a\n\x00"
+	firstId := testCommitFiles(t, repoPath, "master", "", map[string]string{"README.md": "a\n"}, "Initial commit")
+	secondId := testCommitFiles(t, repoPath, "master", "", map[string]string{
+		"logo.PNG": png + strings.Repeat("\x00", 91),
+		"data.bin": "\x00\x01",
+	}, "Add binary files")
+	lastId := testCommitFiles(t, repoPath, "master", "", map[string]string{
+		"logo.PNG":  png + strings.Repeat("\x00", 191),
+		"README.md": "b\n",
+	}, "Change logo")
+
+	tests := []struct {
+		before, after, name string
+		isBin, isImage      bool
+		oldSize, newSize    int64
+	}{
+		{firstId, secondId, "logo.PNG", true, true, 0, 100},
+		{firstId, secondId, "data.bin", true, false, 0, 2},
+		{secondId, lastId, "logo.PNG", true, true, 100, 200},
+		{secondId, lastId, "README.md", false, false, 0, 0},
+	}
+	for _, tt := range tests {
+		diff, err := GetDiffRange(repoPath, tt.before, tt.after)
+		if err != nil {
+			t.Fatalf("GetDiffRange: %v", err)
+		}
+		f := diff.GetFile(tt.name)
+		if f == nil {
+			t.Errorf("GetDiffRange(%s..%s) does not have file %s", tt.before, tt.after, tt.name)
+			continue
+		}
+		if f.IsBin != tt.isBin || f.IsImage != tt.isImage || f.OldSize != tt.oldSize || f.NewSize != tt.newSize {
+			t.Errorf("file %s is binary %v, image %v, sizes %d -> %d, expected %v, %v, %d -> %d", tt.name,
+				f.IsBin, f.IsImage, f.OldSize, f.NewSize, tt.isBin, tt.isImage, tt.oldSize, tt.newSize)
+		}
+		if (len(f.OldBlobId) > 0) != (tt.before == secondId) || len(f.NewBlobId) == 0 {
+			t.Errorf("file %s has blob IDs %q and %q", tt.name, f.OldBlobId, f.NewBlobId)
+		}
+	}
+}
+
+func TestMatchGitAttrPattern(t *testing.T) {
+	tests := []struct {
+		pattern, treePath string
+		expected          bool
+	}{
+		{"dist/**", "dist/app.js", true},
+		{"/dist/**", "dist/js/app.js", true},
+		{"dist/**", "src/dist/app.js", false},
+		{"**/gen/*.go", "a/b/gen/x.go", true},
+		{"**/gen/*.go", "gen/x.go", true},
+		{"**/gen/*.go", "gen/y/x.go", false},
+		{"*.min.js", "js/app.min.js", true},
+		{"*.min.js", "js/app.js", false},
+		{"js/*.js", "js/app.js", true},
+		{"/js/*.js", "js/app.js", true},
+		{"js/*.js", "js/lib/app.js", false},
+	}
+	for _, tt := range tests {
+		if ok := matchGitAttrPattern(tt.pattern, tt.treePath); ok != tt.expected {
+			t.Errorf("matchGitAttrPattern(%q, %q) = %v, expected %v", tt.pattern, tt.treePath, ok, tt.expected)
+		}
+	}
+}
+
+func TestGitAttrValue(t *testing.T) {
+	rules := []*gitAttrRule{
+		{"vendor/**", map[string]bool{"linguist-vendored": true}},
+		{"vendor/keep/**", map[string]bool{"linguist-vendored": false}},
+		{"*.pb.go", map[string]bool{"linguist-generated": true}},
+	}
+	tests := []struct {
+		treePath, attr string
+		expected       bool
+	}{
+		{"vendor/a.go", "linguist-vendored", true},
+		{"vendor/keep/b.go", "linguist-vendored", false},
+		{"vendor/a.go", "linguist-generated", false},
+		{"src/x.pb.go", "linguist-generated", true},
+		{"src/x.go", "linguist-generated", false},
+	}
+	for _, tt := range tests {
+		if v := gitAttrValue(rules, tt.treePath, tt.attr); v != tt.expected {
+			t.Errorf("gitAttrValue(%q, %q) = %v, expected %v", tt.treePath, tt.attr, v, tt.expected)
+		}
+	}
+}
+
+func TestGetDiffRangePage(t *testing.T) {
+	repoPath, err := ioutil.TempDir("", "gogs-diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoPath)
+	if _, err = execGitCmd(repoPath, nil, nil, "init", "-q", "--bare"); err != nil {
+		t.Fatal(err)
+	}
+
+	beforeId := testCommitFiles(t, repoPath, "master", "", map[string]string{"README.md": "a\n"}, "Initial commit")
+	files := map[string]string{
+		".gitattributes": "dist/** linguist-generated=true\nvendor/** linguist-vendored\n# vendor/keep.go is shown\nvendor/keep.go -linguist-vendored\n",
+		"a.txt":          "a\n",
+		"big.txt":        strings.Repeat("line\n", _DIFF_MAX_FILE_LINES+1),
+		"dist/app.js":    "app\n",
+		"vendor/keep.go": "keep\n",
+		"vendor/lib.go":  "lib\n",
+	}
+	// 66 files in total, the first 50 of them are on page 1.
+	for i := 0; i < 60; i++ {
+		files[fmt.Sprintf("files/f%02d.txt", i)] = "f\n"
+	}
+	afterId := testCommitFiles(t, repoPath, "master", "", files, "Add files")
+
+	diff, err := GetDiffRangePage(repoPath, beforeId, afterId, 9)
+	if err != nil {
+		t.Fatalf("GetDiffRangePage: %v", err)
+	} else if diff.Page != 1 || diff.NumPages != 2 || len(diff.Files) != 66 {
+		t.Fatalf("GetDiffRangePage returns page %d of %d with %d files, expected page 1 of 2 with 66 files",
+			diff.Page, diff.NumPages, len(diff.Files))
+	} else if diff.TotalAddition != 1069 {
+		t.Errorf("GetDiffRangePage has %d additions, expected 1069", diff.TotalAddition)
+	}
+
+	tests := []struct {
+		name   string
+		page   int
+		reason string
+	}{
+		{"a.txt", 1, ""},
+		{"big.txt", 1, "large"},
+		{"dist/app.js", 1, "generated"},
+		{"files/f45.txt", 1, ""},
+		{"files/f46.txt", 2, ""},
+		{"vendor/keep.go", 2, ""},
+		{"vendor/lib.go", 2, "vendored"},
+	}
+	for _, tt := range tests {
+		f := diff.GetFile(tt.name)
+		if f == nil {
+			t.Errorf("GetDiffRangePage does not have file %s", tt.name)
+			continue
+		}
+		if f.Page != tt.page || f.CollapseReason != tt.reason || f.IsCollapsed != (len(tt.reason) > 0) {
+			t.Errorf("file %s is on page %d and collapsed as %q, expected page %d and %q", tt.name, f.Page, f.CollapseReason, tt.page, tt.reason)
+		}
+		// Only content of files shown on current page is loaded.
+		if isLoaded := len(f.Sections) > 0; isLoaded != diff.IsFileShown(f) || isLoaded != (tt.page == 1 && len(tt.reason) == 0) {
+			t.Errorf("content of file %s is loaded %v, shown %v", tt.name, isLoaded, diff.IsFileShown(f))
+		}
+	}
+
+	// Threads on files that are not loaded are not outdated.
+	threads := []*ReviewThread{{TreePath: "big.txt", Line: 1, LineContent: "line"}, {TreePath: "files/f59.txt", Line: 1, LineContent: "f"}}
+	if err = AttachReviewThreads(diff, threads, afterId); err != nil {
+		t.Fatalf("AttachReviewThreads: %v", err)
+	}
+	for _, th := range threads {
+		if th.IsOutdated {
+			t.Errorf("thread on %s is outdated", th.TreePath)
+		}
+	}
+
+	if diff, err = GetDiffRangeFile(repoPath, beforeId, afterId, "big.txt"); err != nil {
+		t.Fatalf("GetDiffRangeFile: %v", err)
+	} else if len(diff.Files) != 1 || diff.Files[0].Index != 3 || diff.Files[0].Addition != _DIFF_MAX_FILE_LINES+1 {
+		t.Errorf("GetDiffRangeFile(big.txt) does not have full content of big.txt")
+	}
+	if _, err = GetDiffRangeFile(repoPath, beforeId, afterId, "README.md"); err != ErrDiffFileNotExist {
+		t.Errorf("GetDiffRangeFile(unchanged) error = %v, expected %v", err, ErrDiffFileNotExist)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrGitHookNotExist = errors.New("Git hook does not exist")
+)
+
+// GitHookNames are names of server-side git hooks that can be customized.
+var GitHookNames = []string{"pre-receive", "update", "post-receive"}
+
+// GitHook represents a custom server-side git hook script of repository.
+type GitHook struct {
+	Name    string
+	Content string
+}
+
+// IsActive returns true if hook has a script.
+func (h *GitHook) IsActive() bool {
+	return len(h.Content) > 0
+}
+
+// gitHookPath returns path of custom hook script in hooks directory of repository.
+// Update hook is used by Gogs itself, so custom script is saved aside and called by Gogs.
+func gitHookPath(repoPath, name string) string {
+	if name == "update" {
+		return filepath.Join(repoPath, "hooks", "update.custom")
+	}
+	return filepath.Join(repoPath, "hooks", name)
+}
+
+func isValidGitHookName(name string) bool {
+	for _, n := range GitHookNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// GetGitHook returns custom hook of given name of repository.
+func GetGitHook(repoPath, name string) (*GitHook, error) {
+	if !isValidGitHookName(name) {
+		return nil, ErrGitHookNotExist
+	}
+
+	h := &GitHook{Name: name}
+	data, err := ioutil.ReadFile(gitHookPath(repoPath, name))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	h.Content = string(data)
+	return h, nil
+}
+
+// GetGitHooks returns all custom hooks of repository.
+func GetGitHooks(repoPath string) ([]*GitHook, error) {
+	hooks := make([]*GitHook, 0, len(GitHookNames))
+	for _, name := range GitHookNames {
+		h, err := GetGitHook(repoPath, name)
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, h)
+	}
+	return hooks, nil
+}
+
+// UpdateGitHook saves script of custom hook, empty content removes the hook.
+func UpdateGitHook(repoPath string, h *GitHook) error {
+	if !isValidGitHookName(h.Name) {
+		return ErrGitHookNotExist
+	}
+
+	hookPath := gitHookPath(repoPath, h.Name)
+	h.Content = strings.Replace(h.Content, "\r", "", -1)
+	if len(strings.TrimSpace(h.Content)) == 0 {
+		h.Content = ""
+		if err := os.Remove(hookPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	if !strings.HasSuffix(h.Content, "\n") {
+		h.Content += "\n"
+	}
+	if err := ioutil.WriteFile(hookPath, []byte(h.Content), 0755); err != nil {
+		return err
+	}
+	// Permission is only applied when file is created.
+	return os.Chmod(hookPath, 0755)
+}
+
+// RunCustomUpdateHook runs custom update hook of repository if there is one,
+// standard streams and environment variables are passed through.
+func RunCustomUpdateHook(repoPath, refName, oldCommitId, newCommitId string) error {
+	hookPath := gitHookPath(repoPath, "update")
+	if _, err := os.Stat(hookPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	cmd := exec.Command(hookPath, refName, oldCommitId, newCommitId)
+	cmd.Dir = repoPath
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// IsRefUpdated returns true if reference points to given commit after push,
+// it is used to tell if update has been rejected by hooks.
+func IsRefUpdated(repoPath, refName, newCommitId string) bool {
+	stdout, err := execGitCmd(repoPath, nil, nil, "rev-parse", "--verify", "-q", refName)
+	if strings.Trim(newCommitId, "0") == "" {
+		// Reference has been deleted.
+		return err != nil
+	}
+	return err == nil && strings.TrimSpace(stdout) == newCommitId
+}
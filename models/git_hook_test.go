@@ -0,0 +1,114 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestUpdateGitHook(t *testing.T) {
+	repoPath, err := ioutil.TempDir("", "gogs-hook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoPath)
+	if err = os.MkdirAll(filepath.Join(repoPath, "hooks"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"post-update", "../config", ""} {
+		if err = UpdateGitHook(repoPath, &GitHook{Name: name, Content: "exit 0"}); err != ErrGitHookNotExist {
+			t.Errorf("UpdateGitHook(%q) error = %v, expected %v", name, err, ErrGitHookNotExist)
+		}
+		if _, err = GetGitHook(repoPath, name); err != ErrGitHookNotExist {
+			t.Errorf("GetGitHook(%q) error = %v, expected %v", name, err, ErrGitHookNotExist)
+		}
+	}
+
+	h := &GitHook{Name: "update", Content: "#!/bin/sh\r\nexit 0"}
+	if err = UpdateGitHook(repoPath, h); err != nil {
+		t.Fatalf("UpdateGitHook: %v", err)
+	} else if h.Content != "#!/bin/sh\nexit 0\n" {
+		t.Errorf("saved content is %q, expected without CR and ending with new line", h.Content)
+	}
+	// Update hook used by Gogs is not replaced.
+	fi, err := os.Stat(filepath.Join(repoPath, "hooks", "update.custom"))
+	if err != nil {
+		t.Fatalf("custom update hook is not saved: %v", err)
+	} else if runtime.GOOS != "windows" && fi.Mode().Perm() != 0755 {
+		t.Errorf("custom update hook has mode %v, expected executable", fi.Mode().Perm())
+	}
+
+	hooks, err := GetGitHooks(repoPath)
+	if err != nil {
+		t.Fatalf("GetGitHooks: %v", err)
+	} else if len(hooks) != len(GitHookNames) {
+		t.Fatalf("GetGitHooks returns %d hooks, expected %d", len(hooks), len(GitHookNames))
+	}
+	for _, h := range hooks {
+		if h.IsActive() != (h.Name == "update") {
+			t.Errorf("hook %s is active %v", h.Name, h.IsActive())
+		}
+	}
+
+	// Blank content removes hook.
+	if err = UpdateGitHook(repoPath, &GitHook{Name: "update", Content: " \r\n"}); err != nil {
+		t.Fatalf("UpdateGitHook(blank): %v", err)
+	} else if h, err = GetGitHook(repoPath, "update"); err != nil || h.IsActive() {
+		t.Errorf("GetGitHook(removed) = (%+v, %v), expected inactive hook", h, err)
+	}
+}
+
+func TestRunCustomUpdateHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Hook scripts are not executable on Windows")
+	}
+	repoPath, err := ioutil.TempDir("", "gogs-hook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoPath)
+	if _, err = execGitCmd(repoPath, nil, nil, "init", "-q", "--bare"); err != nil {
+		t.Fatal(err)
+	}
+	commitId := testCommitFiles(t, repoPath, "master", "", map[string]string{"a.txt": "a\n"}, "Add a")
+
+	// Nothing is run without custom hook.
+	if err = RunCustomUpdateHook(repoPath, "refs/heads/master", commitId, commitId); err != nil {
+		t.Errorf("RunCustomUpdateHook(no hook): %v", err)
+	}
+
+	script := "#!/bin/sh\ntest \"$1\" != refs/heads/master\n"
+	if err = UpdateGitHook(repoPath, &GitHook{Name: "update", Content: script}); err != nil {
+		t.Fatalf("UpdateGitHook: %v", err)
+	}
+	if err = RunCustomUpdateHook(repoPath, "refs/heads/master", commitId, commitId); err == nil {
+		t.Error("RunCustomUpdateHook(rejected) returns no error")
+	}
+	if err = RunCustomUpdateHook(repoPath, "refs/heads/dev", commitId, commitId); err != nil {
+		t.Errorf("RunCustomUpdateHook(accepted): %v", err)
+	}
+
+	zero := strings.Repeat("0", 40)
+	tests := []struct {
+		refName, commitId string
+		expected          bool
+	}{
+		{"refs/heads/master", commitId, true},
+		{"refs/heads/master", zero, false},
+		{"refs/heads/dev", commitId, false},
+		{"refs/heads/dev", zero, true},
+	}
+	for _, tt := range tests {
+		if updated := IsRefUpdated(repoPath, tt.refName, tt.commitId); updated != tt.expected {
+			t.Errorf("IsRefUpdated(%s, %s) = %v, expected %v", tt.refName, tt.commitId, updated, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.google.com/p/go.crypto/openpgp"
+	"code.google.com/p/go.crypto/openpgp/armor"
+	"code.google.com/p/go.crypto/openpgp/packet"
+
+	"github.com/gogits/git"
+
+	"github.com/gogits/gogs/modules/log"
+)
+
+var (
+	ErrGPGKeyNotExist     = errors.New("GPG key does not exist")
+	ErrGPGKeyAlreadyExist = errors.New("GPG key already exists")
+	ErrGPGKeyInvalid      = errors.New("GPG key is not a valid armored public key")
+)
+
+// GPGKey represents a GPG public key of user.
+type GPGKey struct {
+	Id        int64
+	OwnerId   int64     `xorm:"INDEX NOT NULL"`
+	KeyId     string    `xorm:"INDEX VARCHAR(16) NOT NULL"` // Key ID of primary key.
+	SubKeyIds string    // Key IDs of sub keys, separated by comma.
+	Emails    string    // E-mail addresses of identities, separated by comma.
+	Content   string    `xorm:"TEXT NOT NULL"`
+	Created   time.Time `xorm:"CREATED"`
+}
+
+// EmailList returns e-mail addresses of identities of the key.
+func (k *GPGKey) EmailList() []string {
+	if len(k.Emails) == 0 {
+		return nil
+	}
+	return strings.Split(k.Emails, ",")
+}
+
+// AddGPGKey parses and adds armored GPG public key to given user.
+func AddGPGKey(ownerId int64, content string) (*GPGKey, error) {
+	content = strings.TrimSpace(content)
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(content))
+	if err != nil || len(entities) != 1 {
+		return nil, ErrGPGKeyInvalid
+	}
+	e := entities[0]
+
+	key := &GPGKey{
+		OwnerId: ownerId,
+		KeyId:   e.PrimaryKey.KeyIdString(),
+		Content: content,
+	}
+	has, err := orm.Get(&GPGKey{KeyId: key.KeyId})
+	if err != nil {
+		return nil, err
+	} else if has {
+		return nil, ErrGPGKeyAlreadyExist
+	}
+
+	subKeyIds := make([]string, 0, len(e.Subkeys))
+	for _, sub := range e.Subkeys {
+		subKeyIds = append(subKeyIds, sub.PublicKey.KeyIdString())
+	}
+	key.SubKeyIds = strings.Join(subKeyIds, ",")
+
+	emails := make([]string, 0, len(e.Identities))
+	for _, ident := range e.Identities {
+		if ident.UserId != nil && len(ident.UserId.Email) > 0 {
+			emails = append(emails, ident.UserId.Email)
+		}
+	}
+	key.Emails = strings.Join(emails, ",")
+
+	if _, err = orm.Insert(key); err != nil {
+		return nil, err
+	}
+	resetTagVerificationCache()
+	return key, nil
+}
+
+// ListGPGKeys returns all GPG keys of given user.
+func ListGPGKeys(uid int64) ([]*GPGKey, error) {
+	keys := make([]*GPGKey, 0, 5)
+	return keys, orm.Where("owner_id=?", uid).Find(&keys)
+}
+
+// GetGPGKeysByKeyId returns GPG keys that primary key or sub keys have given key ID.
+func GetGPGKeysByKeyId(keyId string) ([]*GPGKey, error) {
+	keys := make([]*GPGKey, 0, 1)
+	return keys, orm.Where("key_id=?", keyId).Or("sub_key_ids LIKE ?", "%"+keyId+"%").Find(&keys)
+}
+
+// DeleteGPGKey deletes GPG key of given user by ID.
+func DeleteGPGKey(uid, id int64) error {
+	if id <= 0 {
+		return ErrGPGKeyNotExist
+	}
+	affected, err := orm.Where("id=? AND owner_id=?", id, uid).Delete(new(GPGKey))
+	if err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrGPGKeyNotExist
+	}
+	resetTagVerificationCache()
+	return nil
+}
+
+// CommitVerification represents result of commit signature verification.
+type CommitVerification struct {
+	Verified    bool
+	Reason      string
+	SigningUser *User
+	SigningKey  *GPGKey
+}
+
+// SignCommit represents a commit with its signature verification result,
+// verification is nil if commit is not signed.
+type SignCommit struct {
+	*git.Commit
+	Verification *CommitVerification
+	Status       string // Combined state of commit statuses, empty if there is none.
+}
+
+// readRawCommits returns raw content of given commits in one git process.
+func readRawCommits(repoPath string, ids []string) (map[string][]byte, error) {
+	cmd := exec.Command("git", "cat-file", "--batch")
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader(strings.Join(ids, "\n") + "\n")
+	stdout, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	raws := make(map[string][]byte, len(ids))
+	for len(stdout) > 0 {
+		idx := bytes.IndexByte(stdout, '\n')
+		if idx == -1 {
+			break
+		}
+		// Header line is "<sha> <type> <size>" or "<sha> missing".
+		fields := strings.Fields(string(stdout[:idx]))
+		stdout = stdout[idx+1:]
+		if len(fields) != 3 {
+			continue
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil || size > len(stdout) {
+			return nil, fmt.Errorf("invalid object size: %s", fields[2])
+		}
+		raws[fields[0]] = stdout[:size]
+		stdout = bytes.TrimPrefix(stdout[size:], []byte("\n"))
+	}
+	return raws, nil
+}
+
+// splitCommitSignature separates armored signature from raw commit content,
+// the rest is the payload that was signed.
+func splitCommitSignature(raw []byte) (payload, sig []byte) {
+	var pl, sl bytes.Buffer
+	inHeader, inSig := true, false
+	for _, line := range bytes.SplitAfter(raw, []byte("\n")) {
+		if inHeader {
+			switch {
+			case len(line) == 1 && line[0] == '\n':
+				inHeader, inSig = false, false
+			case bytes.HasPrefix(line, []byte("gpgsig ")):
+				inSig = true
+				sl.Write(line[7:])
+				continue
+			case inSig && bytes.HasPrefix(line, []byte(" ")):
+				sl.Write(line[1:])
+				continue
+			default:
+				inSig = false
+			}
+		}
+		pl.Write(line)
+	}
+	return pl.Bytes(), sl.Bytes()
+}
+
+// signatureKeyId returns ID of key that made given armored signature.
+func signatureKeyId(sig []byte) (string, error) {
+	block, err := armor.Decode(bytes.NewReader(sig))
+	if err != nil {
+		return "", err
+	}
+	p, err := packet.Read(block.Body)
+	if err != nil {
+		return "", err
+	}
+
+	switch s := p.(type) {
+	case *packet.Signature:
+		if s.IssuerKeyId != nil {
+			return fmt.Sprintf("%016X", *s.IssuerKeyId), nil
+		}
+	case *packet.SignatureV3:
+		return fmt.Sprintf("%016X", s.IssuerKeyId), nil
+	}
+	return "", errors.New("signature has no issuer")
+}
+
+// verifyCommitSignature checks signature against GPG keys of users,
+// keys is used to cache keys by key ID.
+func verifyCommitSignature(c *git.Commit, payload, sig []byte, keys map[string][]*GPGKey) *CommitVerification {
+	var email string
+	if c.Committer != nil {
+		email = c.Committer.Email
+	}
+	return verifySignature(payload, sig, email, "Committer", keys)
+}
+
+// verifySignature checks signature against GPG keys of users, and e-mail of signer
+// against e-mail of key owner, role is how signer is called in reason of failure.
+func verifySignature(payload, sig []byte, email, role string, keys map[string][]*GPGKey) *CommitVerification {
+	keyId, err := signatureKeyId(sig)
+	if err != nil {
+		return &CommitVerification{Reason: "Invalid signature"}
+	}
+
+	candidates, ok := keys[keyId]
+	if !ok {
+		if candidates, err = GetGPGKeysByKeyId(keyId); err != nil {
+			log.Error("verifySignature(GetGPGKeysByKeyId): %v", err)
+		}
+		keys[keyId] = candidates
+	}
+
+	for _, key := range candidates {
+		keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key.Content))
+		if err != nil {
+			log.Error("verifySignature(ReadArmoredKeyRing[%d]): %v", key.Id, err)
+			continue
+		}
+		if _, err = openpgp.CheckArmoredDetachedSignature(keyring,
+			bytes.NewReader(payload), bytes.NewReader(sig)); err != nil {
+			continue
+		}
+
+		u, err := GetUserById(key.OwnerId)
+		if err != nil {
+			log.Error("verifySignature(GetUserById[%d]): %v", key.OwnerId, err)
+			continue
+		}
+		if len(email) == 0 || !strings.EqualFold(email, u.Email) {
+			return &CommitVerification{
+				Reason:      role + " e-mail does not match the key owner",
+				SigningUser: u,
+				SigningKey:  key,
+			}
+		}
+		return &CommitVerification{
+			Verified:    true,
+			SigningUser: u,
+			SigningKey:  key,
+		}
+	}
+	return &CommitVerification{Reason: "No known key found for signature " + keyId}
+}
+
+// ParseCommitsWithSignature verifies signatures of given list of commits,
+// and returns a new list of *SignCommit.
+func ParseCommitsWithSignature(repoPath string, oldCommits *list.List) *list.List {
+	newCommits := list.New()
+	if oldCommits == nil || oldCommits.Len() == 0 {
+		return newCommits
+	}
+
+	ids := make([]string, 0, oldCommits.Len())
+	for e := oldCommits.Front(); e != nil; e = e.Next() {
+		ids = append(ids, e.Value.(*git.Commit).Id.String())
+	}
+	raws, err := readRawCommits(repoPath, ids)
+	if err != nil {
+		log.Error("ParseCommitsWithSignature(readRawCommits): %v", err)
+	}
+
+	keys := make(map[string][]*GPGKey)
+	for e := oldCommits.Front(); e != nil; e = e.Next() {
+		c := e.Value.(*git.Commit)
+		sc := &SignCommit{Commit: c}
+		if payload, sig := splitCommitSignature(raws[c.Id.String()]); len(sig) > 0 {
+			sc.Verification = verifyCommitSignature(c, payload, sig, keys)
+		}
+		newCommits.PushBack(sc)
+	}
+	return newCommits
+}
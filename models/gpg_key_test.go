@@ -0,0 +1,53 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+)
+
+const testSignedCommit = `tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904
+author Gogs <gogs@example.com> 1400000000 +0800
+committer Gogs <gogs@example.com> 1400000000 +0800
+gpgsig -----BEGIN PGP SIGNATURE-----
+ 
+ iQEcBAABAgAGBQJTcHYAAAoJEP
+ =abcd
+ -----END PGP SIGNATURE-----
+
+Signed commit
+
+ gpgsig in message is not a signature
+`
+
+func TestSplitCommitSignature(t *testing.T) {
+	payload, sig := splitCommitSignature([]byte(testSignedCommit))
+
+	expectedPayload := `tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904
+author Gogs <gogs@example.com> 1400000000 +0800
+committer Gogs <gogs@example.com> 1400000000 +0800
+
+Signed commit
+
+ gpgsig in message is not a signature
+`
+	expectedSig := `-----BEGIN PGP SIGNATURE-----
+
+iQEcBAABAgAGBQJTcHYAAAoJEP
+=abcd
+-----END PGP SIGNATURE-----
+`
+	if string(payload) != expectedPayload {
+		t.Errorf("splitCommitSignature payload = %q, expected %q", payload, expectedPayload)
+	}
+	if string(sig) != expectedSig {
+		t.Errorf("splitCommitSignature signature = %q, expected %q", sig, expectedSig)
+	}
+
+	payload, sig = splitCommitSignature([]byte(expectedPayload))
+	if string(payload) != expectedPayload || len(sig) != 0 {
+		t.Errorf("splitCommitSignature(unsigned) = (%q, %q), expected payload unchanged and no signature", payload, sig)
+	}
+}
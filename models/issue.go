@@ -33,20 +33,33 @@ type Issue struct {
 	LabelIds        string   `xorm:"TEXT"`
 	Labels          []*Label `xorm:"-"`
 	MilestoneId     int64
-	AssigneeId      int64
-	Assignee        *User `xorm:"-"`
-	IsRead          bool  `xorm:"-"`
-	IsPull          bool  // Indicates whether is a pull request or not.
+	AssigneeId      int64   // First assignee, all assignees are recorded by issue-user pairs.
+	Assignee        *User   `xorm:"-"`
+	Assignees       []*User `xorm:"-"`
+	IsRead          bool    `xorm:"-"`
+	IsPull          bool    // Indicates whether is a pull request or not.
 	IsClosed        bool
-	Content         string `xorm:"TEXT"`
-	RenderedContent string `xorm:"-"`
+	Content         string        `xorm:"TEXT"`
+	RenderedContent string        `xorm:"-"`
+	Attachments     []*Attachment `xorm:"-"`
 	Priority        int
+	PinOrder        int // Position among pinned issues of repository, zero if not pinned.
 	NumComments     int
 	Deadline        time.Time
 	Created         time.Time `xorm:"CREATED"`
 	Updated         time.Time `xorm:"UPDATED"`
 }
 
+// HasDeadline returns true if issue has a due date.
+func (i *Issue) HasDeadline() bool {
+	return i.Deadline.Year() > 1970
+}
+
+// IsOverdue returns true if issue is still open after its due date.
+func (i *Issue) IsOverdue() bool {
+	return i.HasDeadline() && !i.IsClosed && time.Now().After(i.Deadline.AddDate(0, 0, 1))
+}
+
 func (i *Issue) GetPoster() (err error) {
 	i.Poster, err = GetUserById(i.PosterId)
 	if err == ErrUserNotExist {
@@ -90,6 +103,43 @@ func (i *Issue) GetAssignee() (err error) {
 	return err
 }
 
+// GetAssignees loads all users that are assigned to the issue.
+func (i *Issue) GetAssignees() error {
+	ius := make([]*IssueUser, 0, 2)
+	if err := orm.Where("issue_id=?", i.Id).And("is_assigned=?", true).Asc("id").Find(&ius); err != nil {
+		return err
+	}
+
+	i.Assignees = make([]*User, 0, len(ius))
+	for _, iu := range ius {
+		u, err := GetUserById(iu.Uid)
+		if err != nil {
+			if err == ErrUserNotExist {
+				continue
+			}
+			return err
+		}
+		i.Assignees = append(i.Assignees, u)
+	}
+	return nil
+}
+
+// HasLabel returns true if issue has label of given ID.
+func (i *Issue) HasLabel(labelId int64) bool {
+	return strings.Contains(i.LabelIds, "$"+base.ToStr(labelId)+"|")
+}
+
+// IsAssigned returns true if given user is assigned to the issue,
+// assignees must be loaded before calling.
+func (i *Issue) IsAssigned(uid int64) bool {
+	for _, u := range i.Assignees {
+		if u.Id == uid {
+			return true
+		}
+	}
+	return false
+}
+
 // CreateIssue creates new issue for repository.
 func NewIssue(issue *Issue) (err error) {
 	sess := orm.NewSession()
@@ -135,33 +185,88 @@ func GetIssueById(id int64) (*Issue, error) {
 	return issue, nil
 }
 
-// GetIssues returns a list of issues by given conditions.
-func GetIssues(uid, rid, pid, mid int64, page int, isClosed bool, labelIds, sortType string) ([]Issue, error) {
-	sess := orm.Limit(20, (page-1)*20)
+// ISSUE_DUE_SOON_DAYS is the number of days issue is considered as due soon before its due date.
+const ISSUE_DUE_SOON_DAYS = 7
 
-	if rid > 0 {
-		sess.Where("repo_id=?", rid).And("is_closed=?", isClosed)
+// noDeadline is the upper bound of deadlines of issues without due date.
+var noDeadline = time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func beginningOfToday() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// IssuesOptions represents conditions of searching issues.
+type IssuesOptions struct {
+	RepoId      int64
+	AssigneeId  int64
+	PosterId    int64
+	MilestoneId int64
+	IsClosed    bool
+	LabelIds    string // Comma separated label IDs, issues must have all of them.
+	Keyword     string // Matched against issue titles and contents.
+	DueFilter   string // One of "overdue", "soon" and "none".
+	SortType    string
+	Page        int
+	PageSize    int
+}
+
+func buildIssuesSession(opts *IssuesOptions) *xorm.Session {
+	var sess *xorm.Session
+	if opts.RepoId > 0 {
+		sess = orm.Where("repo_id=?", opts.RepoId).And("is_closed=?", opts.IsClosed)
 	} else {
-		sess.Where("is_closed=?", isClosed)
+		sess = orm.Where("is_closed=?", opts.IsClosed)
 	}
 
-	if uid > 0 {
-		sess.And("assignee_id=?", uid)
-	} else if pid > 0 {
-		sess.And("poster_id=?", pid)
+	if opts.AssigneeId > 0 {
+		sess.And("id IN (SELECT issue_id FROM issue_user WHERE uid=? AND is_assigned=?)", opts.AssigneeId, true)
+	}
+	if opts.PosterId > 0 {
+		sess.And("poster_id=?", opts.PosterId)
 	}
 
-	if mid > 0 {
-		sess.And("milestone_id=?", mid)
+	if opts.MilestoneId > 0 {
+		sess.And("milestone_id=?", opts.MilestoneId)
 	}
 
-	if len(labelIds) > 0 {
-		for _, label := range strings.Split(labelIds, ",") {
-			sess.And("label_ids like '%$" + label + "|%'")
+	if len(opts.LabelIds) > 0 {
+		for _, label := range strings.Split(opts.LabelIds, ",") {
+			if id, _ := base.StrTo(label).Int64(); id > 0 {
+				sess.And("label_ids like ?", "%$"+base.ToStr(id)+"|%")
+			}
 		}
 	}
 
-	switch sortType {
+	// Issues without due date have zero time as deadline.
+	today := beginningOfToday()
+	switch opts.DueFilter {
+	case "overdue":
+		sess.And("deadline > ?", noDeadline).And("deadline < ?", today)
+	case "soon":
+		sess.And("deadline >= ?", today).And("deadline < ?", today.AddDate(0, 0, ISSUE_DUE_SOON_DAYS))
+	case "none":
+		sess.And("deadline < ?", noDeadline)
+	}
+
+	for _, word := range strings.Fields(opts.Keyword) {
+		word = "%" + strings.ToLower(word) + "%"
+		sess.And("(LOWER(name) LIKE ? OR LOWER(content) LIKE ?)", word, word)
+	}
+	return sess
+}
+
+// SearchIssues returns a list of issues by given conditions.
+func SearchIssues(opts *IssuesOptions) ([]Issue, error) {
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = 20
+	}
+	sess := buildIssuesSession(opts).Limit(opts.PageSize, (opts.Page-1)*opts.PageSize)
+
+	switch opts.SortType {
 	case "oldest":
 		sess.Asc("created")
 	case "recentupdate":
@@ -174,6 +279,11 @@ func GetIssues(uid, rid, pid, mid int64, page int, isClosed bool, labelIds, sort
 		sess.Asc("num_comments")
 	case "priority":
 		sess.Desc("priority")
+	case "nearestdue":
+		// Issues without due date are always listed last.
+		sess.OrderBy("CASE WHEN deadline < '1971-01-01' THEN 1 ELSE 0 END, deadline ASC")
+	case "farthestdue":
+		sess.Desc("deadline")
 	default:
 		sess.Desc("created")
 	}
@@ -183,6 +293,26 @@ func GetIssues(uid, rid, pid, mid int64, page int, isClosed bool, labelIds, sort
 	return issues, err
 }
 
+// CountIssues returns number of issues by given conditions, pagination and sort type are ignored.
+func CountIssues(opts *IssuesOptions) int64 {
+	count, _ := buildIssuesSession(opts).Count(new(Issue))
+	return count
+}
+
+// GetIssues returns a list of issues by given conditions.
+func GetIssues(uid, rid, pid, mid int64, page int, isClosed bool, labelIds, sortType string) ([]Issue, error) {
+	return SearchIssues(&IssuesOptions{
+		RepoId:      rid,
+		AssigneeId:  uid,
+		PosterId:    pid,
+		MilestoneId: mid,
+		IsClosed:    isClosed,
+		LabelIds:    labelIds,
+		SortType:    sortType,
+		Page:        page,
+	})
+}
+
 type IssueStatus int
 
 const (
@@ -193,7 +323,16 @@ const (
 // GetIssuesByLabel returns a list of issues by given label and repository.
 func GetIssuesByLabel(repoId int64, label string) ([]*Issue, error) {
 	issues := make([]*Issue, 0, 10)
-	err := orm.Where("repo_id=?", repoId).And("label_ids like '%$" + label + "|%'").Find(&issues)
+	err := orm.Where("repo_id=?", repoId).And("label_ids like ?", "%$"+label+"|%").Find(&issues)
+	return issues, err
+}
+
+// GetIssuesDueWithin returns open issues of all repositories due from today to given number of days later.
+func GetIssuesDueWithin(days int) ([]*Issue, error) {
+	today := beginningOfToday()
+	issues := make([]*Issue, 0, 10)
+	err := orm.Where("is_closed=?", false).And("deadline >= ?", today).
+		And("deadline < ?", today.AddDate(0, 0, days+1)).Find(&issues)
 	return issues, err
 }
 
@@ -350,7 +489,7 @@ func GetIssueStats(rid, uid int64, isShowClosed bool, filterMode int) *IssueStat
 		sess = orm.Where("repo_id=?", rid)
 		switch filterMode {
 		case FM_ASSIGN:
-			sess.And("assignee_id=?", uid)
+			sess.And("id IN (SELECT issue_id FROM issue_user WHERE uid=? AND is_assigned=?)", uid, true)
 		case FM_CREATE:
 			sess.And("poster_id=?", uid)
 		default:
@@ -368,7 +507,7 @@ func GetIssueStats(rid, uid int64, isShowClosed bool, filterMode int) *IssueStat
 		stats.ClosedCount, _ = tmpSess.And("is_closed=?", true).Count(new(IssueUser))
 	}
 nofilter:
-	stats.AssignCount, _ = orm.Where("repo_id=?", rid).And("is_closed=?", isShowClosed).And("assignee_id=?", uid).Count(issue)
+	stats.AssignCount, _ = orm.Where("repo_id=?", rid).And("uid=?", uid).And("is_closed=?", isShowClosed).And("is_assigned=?", true).Count(new(IssueUser))
 	stats.CreateCount, _ = orm.Where("repo_id=?", rid).And("is_closed=?", isShowClosed).And("poster_id=?", uid).Count(issue)
 	stats.MentionCount, _ = orm.Where("repo_id=?", rid).And("uid=?", uid).And("is_closed=?", isShowClosed).And("is_mentioned=?", true).Count(new(IssueUser))
 	return stats
@@ -378,7 +517,7 @@ nofilter:
 func GetUserIssueStats(uid int64, filterMode int) *IssueStats {
 	stats := &IssueStats{}
 	issue := new(Issue)
-	stats.AssignCount, _ = orm.Where("assignee_id=?", uid).And("is_closed=?", false).Count(issue)
+	stats.AssignCount, _ = orm.Where("uid=?", uid).And("is_closed=?", false).And("is_assigned=?", true).Count(new(IssueUser))
 	stats.CreateCount, _ = orm.Where("poster_id=?", uid).And("is_closed=?", false).Count(issue)
 	return stats
 }
@@ -389,6 +528,49 @@ func UpdateIssue(issue *Issue) error {
 	return err
 }
 
+// ChangeIssueStatus closes or reopens issue and records it by a comment of doer.
+func ChangeIssueStatus(doer *User, issue *Issue, isClosed bool) (err error) {
+	issue.IsClosed = isClosed
+	if err = UpdateIssue(issue); err != nil {
+		return err
+	} else if err = UpdateIssueUserPairsByStatus(issue.Id, isClosed); err != nil {
+		return err
+	}
+
+	cmtType := IT_CLOSE
+	if !isClosed {
+		cmtType = IT_REOPEN
+	}
+	_, err = CreateComment(doer.Id, issue.RepoId, issue.Id, 0, 0, cmtType, "")
+	return err
+}
+
+// ChangeIssueLabel adds label to issue or removes it, and updates issue counts of label.
+func ChangeIssueLabel(issue *Issue, label *Label, isAttach bool) error {
+	if issue.HasLabel(label.Id) == isAttach {
+		return nil
+	}
+
+	strId := "$" + base.ToStr(label.Id) + "|"
+	if isAttach {
+		issue.LabelIds += strId
+		label.NumIssues++
+		if issue.IsClosed {
+			label.NumClosedIssues++
+		}
+	} else {
+		issue.LabelIds = strings.Replace(issue.LabelIds, strId, "", -1)
+		label.NumIssues--
+		if issue.IsClosed {
+			label.NumClosedIssues--
+		}
+	}
+	if err := UpdateIssue(issue); err != nil {
+		return err
+	}
+	return UpdateLabel(label)
+}
+
 // UpdateIssueUserByStatus updates issue-user pairs by issue status.
 func UpdateIssueUserPairsByStatus(iid int64, isClosed bool) error {
 	rawSql := "UPDATE `issue_user` SET is_closed = ? WHERE issue_id = ?"
@@ -396,20 +578,70 @@ func UpdateIssueUserPairsByStatus(iid int64, isClosed bool) error {
 	return err
 }
 
-// UpdateIssueUserPairByAssignee updates issue-user pair for assigning.
-func UpdateIssueUserPairByAssignee(aid, iid int64) error {
-	rawSql := "UPDATE `issue_user` SET is_assigned = ? WHERE issue_id = ?"
-	if _, err := orm.Exec(rawSql, false, iid); err != nil {
+// ChangeIssueAssignee assigns given user to issue or unassigns the user,
+// first assignee of issue is updated accordingly.
+func ChangeIssueAssignee(issue *Issue, uid int64, isAssign bool) (err error) {
+	sess := orm.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
 		return err
 	}
 
-	// Assignee ID equals to 0 means clear assignee.
-	if aid == 0 {
-		return nil
+	// Users that got access after issue was created have no pair yet.
+	iu := &IssueUser{Uid: uid, IssueId: issue.Id}
+	has, err := sess.Where("uid=? AND issue_id=?", uid, issue.Id).Get(iu)
+	if err != nil {
+		sess.Rollback()
+		return err
 	}
-	rawSql = "UPDATE `issue_user` SET is_assigned = true WHERE uid = ? AND issue_id = ?"
-	_, err := orm.Exec(rawSql, aid, iid)
-	return err
+	iu.IsAssigned = isAssign
+	if has {
+		_, err = sess.Id(iu.Id).Cols("is_assigned").Update(iu)
+	} else if isAssign {
+		iu.RepoId = issue.RepoId
+		iu.MilestoneId = issue.MilestoneId
+		iu.IsClosed = issue.IsClosed
+		_, err = sess.Insert(iu)
+	}
+	if err != nil {
+		sess.Rollback()
+		return err
+	}
+
+	first := new(IssueUser)
+	if has, err = sess.Where("issue_id=?", issue.Id).And("is_assigned=?", true).Asc("id").Get(first); err != nil {
+		sess.Rollback()
+		return err
+	}
+	issue.AssigneeId = 0
+	if has {
+		issue.AssigneeId = first.Uid
+	}
+	if _, err = sess.Id(issue.Id).Cols("assignee_id").Update(issue); err != nil {
+		sess.Rollback()
+		return err
+	}
+	return sess.Commit()
+}
+
+// ClearIssueAssignees unassigns all users from issue.
+func ClearIssueAssignees(issue *Issue) (err error) {
+	sess := orm.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err = sess.Exec("UPDATE `issue_user` SET is_assigned = ? WHERE issue_id = ?", false, issue.Id); err != nil {
+		sess.Rollback()
+		return err
+	}
+	issue.AssigneeId = 0
+	if _, err = sess.Id(issue.Id).Cols("assignee_id").Update(issue); err != nil {
+		sess.Rollback()
+		return err
+	}
+	return sess.Commit()
 }
 
 // UpdateIssueUserPairByRead updates issue-user pair for reading.
@@ -454,6 +686,7 @@ type Label struct {
 	RepoId          int64 `xorm:"INDEX"`
 	Name            string
 	Color           string `xorm:"VARCHAR(7)"`
+	Description     string
 	NumIssues       int
 	NumClosedIssues int
 	NumOpenIssues   int  `xorm:"-"`
@@ -487,6 +720,17 @@ func GetLabelById(id int64) (*Label, error) {
 	return l, nil
 }
 
+// GetRepoLabelById returns a label of given repository by ID.
+func GetRepoLabelById(repoId, id int64) (*Label, error) {
+	l, err := GetLabelById(id)
+	if err != nil {
+		return nil, err
+	} else if l.RepoId != repoId {
+		return nil, ErrLabelNotExist
+	}
+	return l, nil
+}
+
 // GetLabels returns a list of labels of given repository ID.
 func GetLabels(repoId int64) ([]*Label, error) {
 	labels := make([]*Label, 0, 10)
@@ -496,14 +740,13 @@ func GetLabels(repoId int64) ([]*Label, error) {
 
 // UpdateLabel updates label information.
 func UpdateLabel(l *Label) error {
-	_, err := orm.Id(l.Id).Update(l)
+	_, err := orm.Id(l.Id).AllCols().Update(l)
 	return err
 }
 
 // DeleteLabel delete a label of given repository.
-func DeleteLabel(repoId int64, strId string) error {
-	id, _ := base.StrTo(strId).Int64()
-	l, err := GetLabelById(id)
+func DeleteLabel(repoId, id int64) error {
+	l, err := GetRepoLabelById(repoId, id)
 	if err != nil {
 		if err == ErrLabelNotExist {
 			return nil
@@ -511,6 +754,7 @@ func DeleteLabel(repoId int64, strId string) error {
 		return err
 	}
 
+	strId := base.ToStr(id)
 	issues, err := GetIssuesByLabel(repoId, strId)
 	if err != nil {
 		return err
@@ -757,36 +1001,51 @@ func DeleteMilestone(m *Milestone) (err error) {
 
 // Issue types.
 const (
-	IT_PLAIN  = iota // Pure comment.
-	IT_REOPEN        // Issue reopen status change prompt.
-	IT_CLOSE         // Issue close status change prompt.
+	IT_PLAIN      = iota // Pure comment.
+	IT_REOPEN            // Issue reopen status change prompt.
+	IT_CLOSE             // Issue close status change prompt.
+	IT_COMMIT_REF        // Reference from a commit message.
+	IT_ISSUE_REF         // Reference from another issue or its comments.
+	IT_CHANGE            // Issue labels, milestone or assignees change prompt.
+	IT_LABEL             // Label added or removed.
+	IT_MILESTONE         // Milestone set or removed.
+	IT_ASSIGNEE          // User assigned or unassigned.
+	IT_CLOSE_REF         // Issue closed by a commit referencing it.
 )
 
 // Comment represents a comment in commit and issue page.
 type Comment struct {
-	Id       int64
-	Type     int
-	PosterId int64
-	Poster   *User `xorm:"-"`
-	IssueId  int64
-	CommitId int64
-	Line     int64
-	Content  string    `xorm:"TEXT"`
-	Created  time.Time `xorm:"CREATED"`
+	Id              int64
+	Type            int
+	PosterId        int64
+	Poster          *User `xorm:"-"`
+	IssueId         int64
+	CommitId        int64
+	Line            int64
+	Content         string `xorm:"TEXT"`
+	RenderedContent string `xorm:"-"`
+	// Number of times comment has been edited, previous contents are kept as revisions.
+	NumRevisions int           `xorm:"NOT NULL DEFAULT 0"`
+	IsDeleted    bool          `xorm:"NOT NULL DEFAULT false"`
+	Role         string        `xorm:"-"` // Role of poster in repository.
+	CanEdit      bool          `xorm:"-"`
+	Attachments  []*Attachment `xorm:"-"`
+	Created      time.Time     `xorm:"CREATED"`
 }
 
 // CreateComment creates comment of issue or commit.
-func CreateComment(userId, repoId, issueId, commitId, line int64, cmtType int, content string) error {
+func CreateComment(userId, repoId, issueId, commitId, line int64, cmtType int, content string) (*Comment, error) {
 	sess := orm.NewSession()
 	defer sess.Close()
 	if err := sess.Begin(); err != nil {
-		return err
+		return nil, err
 	}
 
-	if _, err := sess.Insert(&Comment{PosterId: userId, Type: cmtType, IssueId: issueId,
-		CommitId: commitId, Line: line, Content: content}); err != nil {
+	c := &Comment{PosterId: userId, Type: cmtType, IssueId: issueId,
+		CommitId: commitId, Line: line, Content: content}
+	if _, err := sess.Insert(c); err != nil {
 		sess.Rollback()
-		return err
+		return nil, err
 	}
 
 	// Check comment type.
@@ -795,27 +1054,27 @@ func CreateComment(userId, repoId, issueId, commitId, line int64, cmtType int, c
 		rawSql := "UPDATE `issue` SET num_comments = num_comments + 1 WHERE id = ?"
 		if _, err := sess.Exec(rawSql, issueId); err != nil {
 			sess.Rollback()
-			return err
+			return nil, err
 		}
 	case IT_REOPEN:
 		rawSql := "UPDATE `repository` SET num_closed_issues = num_closed_issues - 1 WHERE id = ?"
 		if _, err := sess.Exec(rawSql, repoId); err != nil {
 			sess.Rollback()
-			return err
+			return nil, err
 		}
-	case IT_CLOSE:
+	case IT_CLOSE, IT_CLOSE_REF:
 		rawSql := "UPDATE `repository` SET num_closed_issues = num_closed_issues + 1 WHERE id = ?"
 		if _, err := sess.Exec(rawSql, repoId); err != nil {
 			sess.Rollback()
-			return err
+			return nil, err
 		}
 	}
-	return sess.Commit()
+	return c, sess.Commit()
 }
 
 // GetIssueComments returns list of comment by given issue id.
 func GetIssueComments(issueId int64) ([]Comment, error) {
 	comments := make([]Comment, 0, 10)
-	err := orm.Asc("created").Find(&comments, &Comment{IssueId: issueId})
+	err := orm.Where("is_deleted=?", false).Asc("created").Find(&comments, &Comment{IssueId: issueId})
 	return comments, err
 }
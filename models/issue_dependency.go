@@ -0,0 +1,104 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrDependencyExist    = errors.New("Issue dependency already exists")
+	ErrDependencyNotExist = errors.New("Issue dependency does not exist")
+	ErrDependencyCircular = errors.New("Issue dependency cannot be circular")
+	ErrIssueBlocked       = errors.New("Issue is blocked by open issues")
+)
+
+// IssueDependency represents an issue that is blocked by another issue of same repository.
+type IssueDependency struct {
+	Id           int64
+	IssueId      int64     `xorm:"UNIQUE(s) INDEX"`
+	DependencyId int64     `xorm:"UNIQUE(s) INDEX"` // ID of blocking issue.
+	Created      time.Time `xorm:"CREATED"`
+}
+
+// isBlockedBy returns true if issue is blocked by given issue directly or indirectly.
+func isBlockedBy(issueId, blockerId int64) (bool, error) {
+	visited := map[int64]bool{issueId: true}
+	queue := []int64{issueId}
+	for len(queue) > 0 {
+		deps := make([]*IssueDependency, 0, 5)
+		if err := orm.Where("issue_id=?", queue[0]).Find(&deps); err != nil {
+			return false, err
+		}
+		queue = queue[1:]
+
+		for _, dep := range deps {
+			if dep.DependencyId == blockerId {
+				return true, nil
+			} else if !visited[dep.DependencyId] {
+				visited[dep.DependencyId] = true
+				queue = append(queue, dep.DependencyId)
+			}
+		}
+	}
+	return false, nil
+}
+
+// CreateIssueDependency makes issue blocked by given blocking issue.
+func CreateIssueDependency(issue, blocker *Issue) error {
+	if issue.Id == blocker.Id {
+		return ErrDependencyCircular
+	}
+
+	has, err := orm.Where("issue_id=? AND dependency_id=?", issue.Id, blocker.Id).Get(new(IssueDependency))
+	if err != nil {
+		return err
+	} else if has {
+		return ErrDependencyExist
+	}
+
+	if has, err = isBlockedBy(blocker.Id, issue.Id); err != nil {
+		return err
+	} else if has {
+		return ErrDependencyCircular
+	}
+
+	_, err = orm.Insert(&IssueDependency{IssueId: issue.Id, DependencyId: blocker.Id})
+	return err
+}
+
+// RemoveIssueDependency removes blocking issue from dependencies of issue.
+func RemoveIssueDependency(issueId, blockerId int64) error {
+	n, err := orm.Where("issue_id=? AND dependency_id=?", issueId, blockerId).Delete(new(IssueDependency))
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return ErrDependencyNotExist
+	}
+	return nil
+}
+
+// GetBlockingIssues returns issues that block given issue.
+func GetBlockingIssues(issueId int64) ([]*Issue, error) {
+	issues := make([]*Issue, 0, 5)
+	err := orm.Where("id IN (SELECT dependency_id FROM issue_dependency WHERE issue_id=?)", issueId).
+		Asc("id").Find(&issues)
+	return issues, err
+}
+
+// GetBlockedIssues returns issues that are blocked by given issue.
+func GetBlockedIssues(issueId int64) ([]*Issue, error) {
+	issues := make([]*Issue, 0, 5)
+	err := orm.Where("id IN (SELECT issue_id FROM issue_dependency WHERE dependency_id=?)", issueId).
+		Asc("id").Find(&issues)
+	return issues, err
+}
+
+// CountOpenBlockingIssues returns number of open issues that block given issue.
+func CountOpenBlockingIssues(issueId int64) (int64, error) {
+	return orm.Where("id IN (SELECT dependency_id FROM issue_dependency WHERE issue_id=?)", issueId).
+		And("is_closed=?", false).Count(new(Issue))
+}
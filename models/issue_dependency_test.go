@@ -0,0 +1,87 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestIssueDependencies(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo := newTestRepo(t, u, "repo1")
+
+	issues := make([]*Issue, 3)
+	for i := range issues {
+		issues[i] = &Issue{RepoId: repo.Id, Index: int64(i + 1), Name: fmt.Sprint("issue ", i+1), PosterId: u.Id}
+		if err := NewIssue(issues[i]); err != nil {
+			t.Fatalf("NewIssue: %v", err)
+		}
+	}
+	i1, i2, i3 := issues[0], issues[1], issues[2]
+
+	tests := []struct {
+		issue, blocker *Issue
+		expected       error
+	}{
+		{i1, i2, nil},
+		{i1, i2, ErrDependencyExist},
+		{i1, i1, ErrDependencyCircular},
+		{i2, i3, nil},
+		{i2, i1, ErrDependencyCircular},
+		// Issue 1 is blocked by issue 3 through issue 2.
+		{i3, i1, ErrDependencyCircular},
+		{i1, i3, nil},
+	}
+	for _, tt := range tests {
+		if err := CreateIssueDependency(tt.issue, tt.blocker); err != tt.expected {
+			t.Errorf("CreateIssueDependency(%d, %d) error = %v, expected %v", tt.issue.Index, tt.blocker.Index, err, tt.expected)
+		}
+	}
+
+	blocking, err := GetBlockingIssues(i1.Id)
+	if err != nil || len(blocking) != 2 || blocking[0].Id != i2.Id || blocking[1].Id != i3.Id {
+		t.Errorf("GetBlockingIssues = (%d issues, %v), expected %d and %d", len(blocking), err, i2.Id, i3.Id)
+	}
+	blocked, err := GetBlockedIssues(i3.Id)
+	if err != nil || len(blocked) != 2 || blocked[0].Id != i1.Id || blocked[1].Id != i2.Id {
+		t.Errorf("GetBlockedIssues = (%d issues, %v), expected %d and %d", len(blocked), err, i1.Id, i2.Id)
+	}
+
+	i2.IsClosed = true
+	if err = UpdateIssue(i2); err != nil {
+		t.Fatalf("UpdateIssue: %v", err)
+	}
+	if count, err := CountOpenBlockingIssues(i1.Id); err != nil || count != 1 {
+		t.Errorf("CountOpenBlockingIssues = (%d, %v), expected 1", count, err)
+	}
+
+	// Commit does not close issue that is blocked by open issue.
+	repo.BlockOnDependencies = true
+	if err = UpdateIssuesByCommit(u, repo, "1111111", "Fixes #1", true); err != nil {
+		t.Fatalf("UpdateIssuesByCommit: %v", err)
+	} else if saved, err := GetIssueById(i1.Id); err != nil || saved.IsClosed {
+		t.Errorf("GetIssueById = (%+v, %v), expected blocked issue to stay open", saved, err)
+	}
+
+	if err = RemoveIssueDependency(i1.Id, 0); err != ErrDependencyNotExist {
+		t.Errorf("RemoveIssueDependency(zero ID) error = %v, expected %v", err, ErrDependencyNotExist)
+	}
+	if err = RemoveIssueDependency(i1.Id, i3.Id); err != nil {
+		t.Fatalf("RemoveIssueDependency: %v", err)
+	} else if err = RemoveIssueDependency(i1.Id, i3.Id); err != ErrDependencyNotExist {
+		t.Errorf("RemoveIssueDependency(removed) error = %v, expected %v", err, ErrDependencyNotExist)
+	}
+	if count, err := CountOpenBlockingIssues(i1.Id); err != nil || count != 0 {
+		t.Errorf("CountOpenBlockingIssues = (%d, %v), expected 0", count, err)
+	}
+
+	if err = UpdateIssuesByCommit(u, repo, "2222222", "Fixes #1", true); err != nil {
+		t.Fatalf("UpdateIssuesByCommit: %v", err)
+	} else if saved, err := GetIssueById(i1.Id); err != nil || !saved.IsClosed {
+		t.Errorf("GetIssueById = (%+v, %v), expected unblocked issue to be closed", saved, err)
+	}
+}
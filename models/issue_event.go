@@ -0,0 +1,88 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gogits/gogs/modules/base"
+)
+
+// IssueEvent represents the change recorded by IT_LABEL, IT_MILESTONE or IT_ASSIGNEE comment.
+// Name and color are kept as they were, so that event still renders after label, milestone
+// or user has been renamed or deleted.
+type IssueEvent struct {
+	IsRemoved bool
+	Id        int64 // Zero for removal of all assignees.
+	Color     string
+	Name      string
+}
+
+func (ev *IssueEvent) String() string {
+	op := "+"
+	if ev.IsRemoved {
+		op = "-"
+	}
+	return fmt.Sprintf("%s|%d|%s|%s", op, ev.Id, ev.Color, ev.Name)
+}
+
+// IssueEvent parses content of IT_LABEL, IT_MILESTONE and IT_ASSIGNEE comment.
+func (c *Comment) IssueEvent() *IssueEvent {
+	parts := strings.SplitN(c.Content, "|", 4)
+	if len(parts) != 4 {
+		return nil
+	}
+	id, _ := base.StrTo(parts[1]).Int64()
+	return &IssueEvent{parts[0] == "-", id, parts[2], parts[3]}
+}
+
+func createIssueEvent(doer *User, issue *Issue, cmtType int, ev *IssueEvent) error {
+	_, err := CreateComment(doer.Id, issue.RepoId, issue.Id, 0, 0, cmtType, ev.String())
+	return err
+}
+
+// CreateLabelEvent records label being added to or removed from issue in its timeline.
+func CreateLabelEvent(doer *User, issue *Issue, label *Label, isAttach bool) error {
+	return createIssueEvent(doer, issue, IT_LABEL, &IssueEvent{
+		IsRemoved: !isAttach,
+		Id:        label.Id,
+		Color:     label.Color,
+		Name:      label.Name,
+	})
+}
+
+// CreateMilestoneEvent records milestone of issue being changed from oldMid to mid
+// in its timeline, nothing is recorded when milestone does not exist.
+func CreateMilestoneEvent(doer *User, issue *Issue, oldMid, mid int64) error {
+	ev := &IssueEvent{Id: mid}
+	if mid == 0 {
+		ev.IsRemoved = true
+		ev.Id = oldMid
+	}
+	if ev.Id == 0 {
+		return nil
+	}
+	m, err := GetMilestoneById(ev.Id)
+	if err != nil {
+		if err == ErrMilestoneNotExist {
+			return nil
+		}
+		return err
+	}
+	ev.Name = m.Name
+	return createIssueEvent(doer, issue, IT_MILESTONE, ev)
+}
+
+// CreateAssigneeEvent records user being assigned to or unassigned from issue in its timeline,
+// nil user means all assignees have been removed.
+func CreateAssigneeEvent(doer *User, issue *Issue, u *User, isAssign bool) error {
+	ev := &IssueEvent{IsRemoved: !isAssign}
+	if u != nil {
+		ev.Id = u.Id
+		ev.Name = u.Name
+	}
+	return createIssueEvent(doer, issue, IT_ASSIGNEE, ev)
+}
@@ -0,0 +1,94 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestIssueEvent(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected *IssueEvent
+	}{
+		{"+|3|#ee0701|bug", &IssueEvent{false, 3, "#ee0701", "bug"}},
+		{"-|0||", &IssueEvent{true, 0, "", ""}},
+		{"+|2||v1.0|beta", &IssueEvent{false, 2, "", "v1.0|beta"}},
+		{"+|2|v1.0", nil},
+		{"added label bug", nil},
+	}
+	for _, tt := range tests {
+		ev := (&Comment{Content: tt.content}).IssueEvent()
+		if (ev == nil) != (tt.expected == nil) || (ev != nil && *ev != *tt.expected) {
+			t.Errorf("IssueEvent(%q) = %+v, expected %+v", tt.content, ev, tt.expected)
+			continue
+		}
+		if ev != nil && ev.String() != tt.content {
+			t.Errorf("IssueEvent(%q).String() = %q", tt.content, ev.String())
+		}
+	}
+}
+
+func TestCreateIssueEvents(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	repo := newTestRepo(t, u1, "repo1")
+
+	issue := &Issue{RepoId: repo.Id, Index: 1, Name: "issue", PosterId: u1.Id}
+	if err := NewIssue(issue); err != nil {
+		t.Fatalf("NewIssue: %v", err)
+	}
+	label := &Label{RepoId: repo.Id, Name: "bug", Color: "#ee0701"}
+	m := &Milestone{RepoId: repo.Id, Index: 1, Name: "v1.0"}
+	if _, err := orm.Insert(label); err != nil {
+		t.Fatal(err)
+	} else if err = NewMilestone(m); err != nil {
+		t.Fatalf("NewMilestone: %v", err)
+	}
+
+	steps := []func() error{
+		func() error { return CreateLabelEvent(u1, issue, label, true) },
+		func() error { return CreateMilestoneEvent(u1, issue, 0, m.Id) },
+		func() error { return CreateMilestoneEvent(u1, issue, m.Id, 0) },
+		// Nothing is recorded for milestones that do not exist.
+		func() error { return CreateMilestoneEvent(u1, issue, 0, 0) },
+		func() error { return CreateMilestoneEvent(u1, issue, 0, m.Id+1) },
+		func() error { return CreateAssigneeEvent(u1, issue, u2, true) },
+		func() error { return CreateAssigneeEvent(u1, issue, nil, false) },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+	}
+	// Events keep names even after label is renamed.
+	label.Name = "defect"
+	if _, err := orm.Id(label.Id).Cols("name").Update(label); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		tp int
+		ev IssueEvent
+	}{
+		{IT_LABEL, IssueEvent{false, label.Id, "#ee0701", "bug"}},
+		{IT_MILESTONE, IssueEvent{false, m.Id, "", "v1.0"}},
+		{IT_MILESTONE, IssueEvent{true, m.Id, "", "v1.0"}},
+		{IT_ASSIGNEE, IssueEvent{false, u2.Id, "", "user2"}},
+		{IT_ASSIGNEE, IssueEvent{true, 0, "", ""}},
+	}
+	// Events may be created within the same second, so they are ordered by ID.
+	comments := make([]*Comment, 0, len(expected))
+	if err := orm.Where("issue_id=?", issue.Id).Asc("id").Find(&comments); err != nil {
+		t.Fatal(err)
+	} else if len(comments) != len(expected) {
+		t.Fatalf("issue has %d comments, expected %d", len(comments), len(expected))
+	}
+	for i, c := range comments {
+		if ev := c.IssueEvent(); c.Type != expected[i].tp || ev == nil || *ev != expected[i].ev {
+			t.Errorf("#%d: comment of type %d has event %+v, expected type %d with %+v", i, c.Type, ev, expected[i].tp, expected[i].ev)
+		}
+	}
+}
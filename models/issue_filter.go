@@ -0,0 +1,107 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrIssueFilterExist    = errors.New("Saved filter already exists")
+	ErrIssueFilterNotExist = errors.New("Saved filter does not exist")
+)
+
+// IssueFilter represents a named combination of issue filters saved by user for a repository.
+type IssueFilter struct {
+	Id      int64
+	UserId  int64       `xorm:"UNIQUE(s) INDEX"`
+	RepoId  int64       `xorm:"UNIQUE(s) INDEX"`
+	Repo    *Repository `xorm:"-"`
+	Name    string      `xorm:"UNIQUE(s)"`
+	Query   string      `xorm:"TEXT"`
+	Created time.Time   `xorm:"CREATED"`
+}
+
+// Link returns relative link to issue list of repository with saved filters applied.
+func (f *IssueFilter) Link() string {
+	return "/" + f.Repo.Owner.Name + "/" + f.Repo.Name + "/issues?" + f.Query
+}
+
+// NewIssueFilter saves new issue filter, names are unique per user and repository.
+func NewIssueFilter(f *IssueFilter) error {
+	has, err := orm.Where("user_id=? AND repo_id=? AND name=?", f.UserId, f.RepoId, f.Name).Get(new(IssueFilter))
+	if err != nil {
+		return err
+	} else if has {
+		return ErrIssueFilterExist
+	}
+	_, err = orm.Insert(f)
+	return err
+}
+
+// GetIssueFilterById returns saved issue filter of user by given ID.
+func GetIssueFilterById(uid, id int64) (*IssueFilter, error) {
+	f := new(IssueFilter)
+	has, err := orm.Where("id=? AND user_id=?", id, uid).Get(f)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrIssueFilterNotExist
+	}
+	return f, nil
+}
+
+// GetIssueFilters returns saved issue filters of user for given repository.
+func GetIssueFilters(uid, repoId int64) ([]*IssueFilter, error) {
+	filters := make([]*IssueFilter, 0, 5)
+	return filters, orm.Where("user_id=? AND repo_id=?", uid, repoId).Asc("name").Find(&filters)
+}
+
+// GetUserIssueFilters returns saved issue filters of user for all repositories,
+// filters of repositories user has no longer access to are skipped.
+func GetUserIssueFilters(u *User) ([]*IssueFilter, error) {
+	filters := make([]*IssueFilter, 0, 10)
+	if err := orm.Where("user_id=?", u.Id).Asc("repo_id").Asc("name").Find(&filters); err != nil {
+		return nil, err
+	}
+
+	var err error
+	valid := filters[:0]
+	for _, f := range filters {
+		if f.Repo, err = GetRepositoryById(f.RepoId); err != nil {
+			if err == ErrRepoNotExist {
+				continue
+			}
+			return nil, err
+		} else if err = f.Repo.GetOwner(); err != nil {
+			return nil, err
+		}
+		if f.Repo.IsPrivate && f.Repo.OwnerId != u.Id {
+			has, err := HasAccess(u.Name, f.Repo.Owner.Name+"/"+f.Repo.Name, AU_READABLE)
+			if err != nil {
+				return nil, err
+			} else if !has {
+				continue
+			}
+		}
+		valid = append(valid, f)
+	}
+	return valid, nil
+}
+
+// DeleteIssueFilter deletes saved issue filter of user by given ID.
+func DeleteIssueFilter(uid, id int64) error {
+	if id <= 0 {
+		return ErrIssueFilterNotExist
+	}
+	affected, err := orm.Where("id=? AND user_id=?", id, uid).Delete(new(IssueFilter))
+	if err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrIssueFilterNotExist
+	}
+	return nil
+}
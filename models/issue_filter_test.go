@@ -0,0 +1,73 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestIssueFilters(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	repo := newTestRepo(t, u1, "repo1")
+	private, gone := newTestRepo(t, u2, "private"), newTestRepo(t, u2, "gone")
+	private.IsPrivate = true
+	if _, err := orm.Id(private.Id).Cols("is_private").Update(private); err != nil {
+		t.Fatal(err)
+	}
+
+	filters := []*IssueFilter{
+		{UserId: u1.Id, RepoId: repo.Id, Name: "Mine", Query: "type=your_repositories"},
+		{UserId: u1.Id, RepoId: repo.Id, Name: "Bugs", Query: "labels=1"},
+		{UserId: u1.Id, RepoId: private.Id, Name: "Bugs", Query: "labels=2"},
+		{UserId: u1.Id, RepoId: gone.Id, Name: "Bugs", Query: "labels=3"},
+		{UserId: u2.Id, RepoId: repo.Id, Name: "Bugs", Query: "labels=1"},
+	}
+	for _, f := range filters {
+		if err := NewIssueFilter(f); err != nil {
+			t.Fatalf("NewIssueFilter(%d, %d, %s): %v", f.UserId, f.RepoId, f.Name, err)
+		}
+	}
+	if err := NewIssueFilter(&IssueFilter{UserId: u1.Id, RepoId: repo.Id, Name: "Bugs"}); err != ErrIssueFilterExist {
+		t.Errorf("NewIssueFilter(same name) error = %v, expected %v", err, ErrIssueFilterExist)
+	}
+
+	if fs, err := GetIssueFilters(u1.Id, repo.Id); err != nil || len(fs) != 2 || fs[0].Name != "Bugs" || fs[1].Name != "Mine" {
+		t.Errorf("GetIssueFilters = (%d filters, %v), expected Bugs and Mine", len(fs), err)
+	}
+	for _, ids := range [][2]int64{{u2.Id, filters[0].Id}, {u1.Id, 0}} {
+		if _, err := GetIssueFilterById(ids[0], ids[1]); err != ErrIssueFilterNotExist {
+			t.Errorf("GetIssueFilterById(%d, %d) error = %v, expected %v", ids[0], ids[1], err, ErrIssueFilterNotExist)
+		}
+	}
+
+	// Filters of deleted repositories and those user cannot read are skipped.
+	if _, err := orm.Id(gone.Id).Delete(new(Repository)); err != nil {
+		t.Fatal(err)
+	}
+	fs, err := GetUserIssueFilters(u1)
+	if err != nil {
+		t.Fatalf("GetUserIssueFilters: %v", err)
+	} else if len(fs) != 2 {
+		t.Fatalf("GetUserIssueFilters returns %d filters, expected 2", len(fs))
+	} else if link := fs[0].Link(); link != "/user1/repo1/issues?labels=1" {
+		t.Errorf("Link = %q, expected %q", link, "/user1/repo1/issues?labels=1")
+	}
+	if _, err = orm.Insert(&Access{UserName: "user1", RepoName: "user2/private", Mode: AU_READABLE}); err != nil {
+		t.Fatal(err)
+	}
+	if fs, err = GetUserIssueFilters(u1); err != nil || len(fs) != 3 {
+		t.Errorf("GetUserIssueFilters(with access) = (%d filters, %v), expected 3", len(fs), err)
+	}
+
+	if err = DeleteIssueFilter(u2.Id, filters[0].Id); err != ErrIssueFilterNotExist {
+		t.Errorf("DeleteIssueFilter(other user) error = %v, expected %v", err, ErrIssueFilterNotExist)
+	} else if err = DeleteIssueFilter(u1.Id, filters[0].Id); err != nil {
+		t.Fatalf("DeleteIssueFilter: %v", err)
+	}
+	if _, err = GetIssueFilterById(u1.Id, filters[0].Id); err != ErrIssueFilterNotExist {
+		t.Errorf("GetIssueFilterById(deleted) error = %v, expected %v", err, ErrIssueFilterNotExist)
+	}
+}
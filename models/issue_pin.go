@@ -0,0 +1,112 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+)
+
+// Maximum number of pinned issues of a repository.
+const MAX_PINNED_ISSUES = 3
+
+var (
+	ErrPinnedIssuesLimit = errors.New("Repository already has maximum number of pinned issues")
+	ErrIssueNotPinned    = errors.New("Issue is not pinned")
+	ErrPinPullRequest    = errors.New("Pull requests cannot be pinned")
+)
+
+// IsPinned returns true if issue is pinned above issue list of repository.
+func (i *Issue) IsPinned() bool {
+	return i.PinOrder > 0
+}
+
+// GetPinnedIssues returns pinned issues of repository in their order.
+func GetPinnedIssues(repoId int64) ([]*Issue, error) {
+	issues := make([]*Issue, 0, MAX_PINNED_ISSUES)
+	err := orm.Where("repo_id=? AND pin_order>?", repoId, 0).Asc("pin_order").Find(&issues)
+	return issues, err
+}
+
+// savePinOrders saves positions of pinned issues by their order in given list.
+func savePinOrders(issues []*Issue) error {
+	for i, issue := range issues {
+		issue.PinOrder = i + 1
+		if _, err := orm.Id(issue.Id).Cols("pin_order").Update(issue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PinIssue pins issue after other pinned issues of its repository.
+func PinIssue(issue *Issue) error {
+	if issue.IsPull {
+		return ErrPinPullRequest
+	} else if issue.IsPinned() {
+		return nil
+	}
+
+	pinned, err := GetPinnedIssues(issue.RepoId)
+	if err != nil {
+		return err
+	} else if len(pinned) >= MAX_PINNED_ISSUES {
+		return ErrPinnedIssuesLimit
+	}
+	return savePinOrders(append(pinned, issue))
+}
+
+// UnpinIssue unpins issue, following pinned issues move up.
+func UnpinIssue(issue *Issue) error {
+	if !issue.IsPinned() {
+		return ErrIssueNotPinned
+	}
+
+	pinned, err := GetPinnedIssues(issue.RepoId)
+	if err != nil {
+		return err
+	}
+	rest := make([]*Issue, 0, len(pinned))
+	for _, i := range pinned {
+		if i.Id != issue.Id {
+			rest = append(rest, i)
+		}
+	}
+
+	issue.PinOrder = 0
+	if _, err = orm.Id(issue.Id).Cols("pin_order").Update(issue); err != nil {
+		return err
+	}
+	return savePinOrders(rest)
+}
+
+// MovePinnedIssue moves pinned issue to given position starting from 1,
+// positions beyond last pinned issue move it to the end.
+func MovePinnedIssue(issue *Issue, position int) error {
+	if !issue.IsPinned() {
+		return ErrIssueNotPinned
+	}
+
+	pinned, err := GetPinnedIssues(issue.RepoId)
+	if err != nil {
+		return err
+	}
+	rest := make([]*Issue, 0, len(pinned))
+	for _, i := range pinned {
+		if i.Id != issue.Id {
+			rest = append(rest, i)
+		}
+	}
+	if position < 1 {
+		position = 1
+	} else if position > len(rest)+1 {
+		position = len(rest) + 1
+	}
+
+	ordered := make([]*Issue, 0, len(pinned))
+	ordered = append(ordered, rest[:position-1]...)
+	ordered = append(ordered, issue)
+	ordered = append(ordered, rest[position-1:]...)
+	return savePinOrders(ordered)
+}
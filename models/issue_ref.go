@@ -0,0 +1,237 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gogits/gogs/modules/base"
+)
+
+var (
+	// issueClosingPattern matches references to issues prefixed by closing keywords,
+	// e.g. "fixes #123" or "closes owner/repo#45".
+	issueClosingPattern = regexp.MustCompile(`(?i)(?:^|[\s(\[])(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?)\s*:?\s+(?:([0-9a-zA-Z_.\-]+)/([0-9a-zA-Z_.\-]+))?#([0-9]+)\b`)
+	// issueRefPattern matches any reference to issues, e.g. "#123" or "owner/repo#45".
+	issueRefPattern = regexp.MustCompile(`(?:^|[\s(\[])(?:([0-9a-zA-Z_.\-]+)/([0-9a-zA-Z_.\-]+))?#([0-9]+)\b`)
+)
+
+// IssueReference represents a reference to an issue found in text.
+type IssueReference struct {
+	// Owner and repository name are empty when issue is in the same repository.
+	OwnerName string
+	RepoName  string
+	Index     int64
+	IsClosing bool // Indicates whether reference is prefixed by a closing keyword.
+}
+
+// findIssueReferences returns distinct issues referenced in text.
+func findIssueReferences(text string) []*IssueReference {
+	refs := make([]*IssueReference, 0, 2)
+	seen := make(map[string]*IssueReference)
+	for i, pattern := range []*regexp.Regexp{issueClosingPattern, issueRefPattern} {
+		for _, m := range pattern.FindAllStringSubmatch(text, -1) {
+			index, _ := base.StrTo(m[3]).Int64()
+			if index == 0 {
+				continue
+			}
+
+			key := strings.ToLower(m[1] + "/" + m[2] + "#" + m[3])
+			if ref, ok := seen[key]; ok {
+				ref.IsClosing = ref.IsClosing || i == 0
+				continue
+			}
+			ref := &IssueReference{m[1], m[2], index, i == 0}
+			seen[key] = ref
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// CommitRef represents the commit referencing an issue, as stored in IT_COMMIT_REF and IT_CLOSE_REF comment.
+type CommitRef struct {
+	RepoName string // In form of "owner/repo".
+	CommitId string
+	Summary  string
+}
+
+// CommitRef parses content of IT_COMMIT_REF and IT_CLOSE_REF comment.
+func (c *Comment) CommitRef() *CommitRef {
+	parts := strings.SplitN(c.Content, "|", 3)
+	if len(parts) != 3 {
+		return nil
+	}
+	return &CommitRef{parts[0], parts[1], parts[2]}
+}
+
+// getReferencedRepository returns repository of issue reference relative to given repository,
+// or nil if it does not exist.
+func getReferencedRepository(repo *Repository, ref *IssueReference) (*Repository, error) {
+	if len(ref.OwnerName) == 0 ||
+		(strings.EqualFold(ref.OwnerName, repo.Owner.Name) && strings.EqualFold(ref.RepoName, repo.Name)) {
+		return repo, nil
+	}
+
+	u, err := GetUserByName(ref.OwnerName)
+	if err != nil {
+		if err == ErrUserNotExist {
+			return nil, nil
+		}
+		return nil, err
+	}
+	refRepo, err := GetRepositoryByName(u.Id, ref.RepoName)
+	if err != nil {
+		if err == ErrRepoNotExist {
+			return nil, nil
+		}
+		return nil, err
+	}
+	refRepo.Owner = u
+	return refRepo, nil
+}
+
+// hasCommitRefComment returns true if issue already has a comment referencing given commit.
+func hasCommitRefComment(issueId int64, commitId string) (bool, error) {
+	return orm.Where("issue_id=? AND type=?", issueId, IT_COMMIT_REF).
+		And("content LIKE ?", "%|"+commitId+"|%").Get(new(Comment))
+}
+
+// UpdateIssuesByCommit leaves a comment on issues referenced in commit message linking back to the commit,
+// and closes those referenced with closing keywords if canClose is true.
+// Issues of other repositories are only closed when doer has write access to them,
+// and never referenced by commits of private repositories so that they are not exposed.
+func UpdateIssuesByCommit(doer *User, repo *Repository, commitId, message string, canClose bool) error {
+	refs := findIssueReferences(message)
+	if len(refs) == 0 {
+		return nil
+	}
+	if err := repo.GetOwner(); err != nil {
+		return err
+	}
+
+	summary := strings.TrimSpace(strings.Split(message, "\n")[0])
+	content := fmt.Sprintf("%s/%s|%s|%s", repo.Owner.Name, repo.Name, commitId, summary)
+	for _, ref := range refs {
+		refRepo, err := getReferencedRepository(repo, ref)
+		if err != nil {
+			return err
+		} else if refRepo == nil || (refRepo.Id != repo.Id && repo.IsPrivate) {
+			continue
+		}
+
+		issue, err := GetIssueByIndex(refRepo.Id, ref.Index)
+		if err != nil {
+			if err == ErrIssueNotExist {
+				continue
+			}
+			return err
+		}
+
+		// Same commit may be pushed to several branches, and only closes issues
+		// once it reaches default branch.
+		if has, err := hasCommitRefComment(issue.Id, commitId); err != nil {
+			return err
+		} else if !has {
+			if _, err = CreateComment(doer.Id, refRepo.Id, issue.Id, 0, 0, IT_COMMIT_REF, content); err != nil {
+				return err
+			}
+		}
+
+		if !canClose || !ref.IsClosing || issue.IsClosed || issue.IsPull {
+			continue
+		} else if refRepo.Id != repo.Id {
+			has, err := HasAccess(doer.Name, refRepo.Owner.Name+"/"+refRepo.Name, AU_WRITABLE)
+			if err != nil {
+				return err
+			} else if !has {
+				continue
+			}
+		}
+
+		if refRepo.BlockOnDependencies {
+			count, err := CountOpenBlockingIssues(issue.Id)
+			if err != nil {
+				return err
+			} else if count > 0 {
+				continue
+			}
+		}
+
+		issue.IsClosed = true
+		if err = UpdateIssue(issue); err != nil {
+			return err
+		} else if err = UpdateIssueUserPairsByStatus(issue.Id, true); err != nil {
+			return err
+		} else if _, err = CreateComment(doer.Id, refRepo.Id, issue.Id, 0, 0, IT_CLOSE_REF, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IssueRef represents the issue mentioning another issue, as stored in IT_ISSUE_REF comment.
+type IssueRef struct {
+	RepoName string // In form of "owner/repo".
+	Index    int64
+	Title    string
+}
+
+// IssueRef parses content of IT_ISSUE_REF comment.
+func (c *Comment) IssueRef() *IssueRef {
+	parts := strings.SplitN(c.Content, "|", 3)
+	if len(parts) != 3 {
+		return nil
+	}
+	index, _ := base.StrTo(parts[1]).Int64()
+	return &IssueRef{parts[0], index, parts[2]}
+}
+
+// UpdateIssuesByContent records a timeline event on issues referenced in content
+// of given issue or one of its comments. Issues of other repositories are never
+// referenced from private repositories so that they are not exposed.
+func UpdateIssuesByContent(doer *User, repo *Repository, issue *Issue, content string) error {
+	refs := findIssueReferences(content)
+	if len(refs) == 0 {
+		return nil
+	}
+	if err := repo.GetOwner(); err != nil {
+		return err
+	}
+
+	source := fmt.Sprintf("%s/%s|%d|", repo.Owner.Name, repo.Name, issue.Index)
+	for _, ref := range refs {
+		refRepo, err := getReferencedRepository(repo, ref)
+		if err != nil {
+			return err
+		} else if refRepo == nil || (refRepo.Id != repo.Id && repo.IsPrivate) {
+			continue
+		} else if refRepo.Id == repo.Id && ref.Index == issue.Index {
+			continue
+		}
+
+		refIssue, err := GetIssueByIndex(refRepo.Id, ref.Index)
+		if err != nil {
+			if err == ErrIssueNotExist {
+				continue
+			}
+			return err
+		}
+
+		has, err := orm.Where("issue_id=? AND type=?", refIssue.Id, IT_ISSUE_REF).
+			And("content LIKE ?", source+"%").Get(new(Comment))
+		if err != nil {
+			return err
+		} else if has {
+			continue
+		}
+		if _, err = CreateComment(doer.Id, refRepo.Id, refIssue.Id, 0, 0, IT_ISSUE_REF, source+issue.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFindIssueReferences(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []IssueReference
+	}{
+		{"No reference", nil},
+		{"Fixes #12", []IssueReference{{"", "", 12, true}}},
+		{"fix: crash (closes #3), see #4 and #3", []IssueReference{{"", "", 3, true}, {"", "", 4, false}}},
+		{"Resolved: gogits/gogs#45", []IssueReference{{"gogits", "gogs", 45, true}}},
+		{"Related to gogits/gogs#45 and #0", []IssueReference{{"gogits", "gogs", 45, false}}},
+		{"prefixes#1 and fixes#2 are not references", nil},
+		{"Closes #7\nFixes #7", []IssueReference{{"", "", 7, true}}},
+	}
+	for _, tt := range tests {
+		refs := findIssueReferences(tt.text)
+		if len(refs) != len(tt.expected) {
+			t.Errorf("findIssueReferences(%q) returns %d references, expected %d", tt.text, len(refs), len(tt.expected))
+			continue
+		}
+		for i, ref := range refs {
+			if *ref != tt.expected[i] {
+				t.Errorf("findIssueReferences(%q)[%d] = %+v, expected %+v", tt.text, i, *ref, tt.expected[i])
+			}
+		}
+	}
+}
+
+func TestUpdateIssuesByCommit(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	repo := newTestRepo(t, u1, "repo1")
+	readonly, writable := newTestRepo(t, u2, "readonly"), newTestRepo(t, u2, "writable")
+	if _, err := orm.Insert(&Access{UserName: "user1", RepoName: "user2/writable", Mode: AU_WRITABLE}); err != nil {
+		t.Fatal(err)
+	}
+
+	newIssue := func(repo *Repository, index int64, isPull bool) *Issue {
+		issue := &Issue{RepoId: repo.Id, Index: index, Name: fmt.Sprint("issue ", index), PosterId: u1.Id, IsPull: isPull}
+		if err := NewIssue(issue); err != nil {
+			t.Fatalf("NewIssue: %v", err)
+		}
+		return issue
+	}
+	issue, pull := newIssue(repo, 1, false), newIssue(repo, 2, true)
+	readonlyIssue, writableIssue := newIssue(readonly, 1, false), newIssue(writable, 1, false)
+
+	countComments := func(issue *Issue, typ int) int64 {
+		count, err := orm.Where("issue_id=? AND type=?", issue.Id, typ).Count(new(Comment))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return count
+	}
+	isClosed := func(issue *Issue) bool {
+		saved, err := GetIssueById(issue.Id)
+		if err != nil {
+			t.Fatalf("GetIssueById: %v", err)
+		}
+		return saved.IsClosed
+	}
+
+	message := "Fix crash\n\nFixes #1, fixes #2, closes user2/readonly#1, closes user2/writable#1, see #9"
+	// Commit pushed to other branch only leaves references.
+	if err := UpdateIssuesByCommit(u1, repo, "1111111", message, false); err != nil {
+		t.Fatalf("UpdateIssuesByCommit(cannot close): %v", err)
+	}
+	if err := UpdateIssuesByCommit(u1, repo, "1111111", message, true); err != nil {
+		t.Fatalf("UpdateIssuesByCommit: %v", err)
+	}
+
+	tests := []struct {
+		issue    *Issue
+		isClosed bool
+	}{
+		{issue, true},
+		{pull, false},
+		{readonlyIssue, false},
+		{writableIssue, true},
+	}
+	for _, tt := range tests {
+		if n := countComments(tt.issue, IT_COMMIT_REF); n != 1 {
+			t.Errorf("issue %d has %d commit references, expected 1", tt.issue.Id, n)
+		}
+		if closed := isClosed(tt.issue); closed != tt.isClosed {
+			t.Errorf("issue %d is closed = %v, expected %v", tt.issue.Id, closed, tt.isClosed)
+		}
+		if n := countComments(tt.issue, IT_CLOSE_REF); (n == 1) != tt.isClosed {
+			t.Errorf("issue %d has %d close references, expected closed = %v", tt.issue.Id, n, tt.isClosed)
+		}
+	}
+
+	c := new(Comment)
+	if has, err := orm.Where("issue_id=? AND type=?", writableIssue.Id, IT_COMMIT_REF).Get(c); err != nil || !has {
+		t.Fatalf("Get(commit reference) = (%v, %v), expected found", has, err)
+	}
+	expected := CommitRef{"user1/repo1", "1111111", "Fix crash"}
+	if ref := c.CommitRef(); ref == nil || *ref != expected {
+		t.Errorf("CommitRef = %+v, expected %+v", ref, expected)
+	}
+
+	// Private repository does not reference issues of other repositories.
+	repo.IsPrivate = true
+	if err := UpdateIssuesByCommit(u1, repo, "2222222", "See user2/readonly#1 and #1", true); err != nil {
+		t.Fatalf("UpdateIssuesByCommit(private): %v", err)
+	}
+	if n := countComments(readonlyIssue, IT_COMMIT_REF); n != 1 {
+		t.Errorf("issue of other repository has %d commit references, expected 1", n)
+	}
+	if n := countComments(issue, IT_COMMIT_REF); n != 2 {
+		t.Errorf("issue of same repository has %d commit references, expected 2", n)
+	}
+}
+
+func TestUpdateIssuesByContent(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	repo, other, secret := newTestRepo(t, u1, "repo1"), newTestRepo(t, u2, "other"), newTestRepo(t, u1, "secret")
+	secret.IsPrivate = true
+
+	newIssue := func(repo *Repository, index int64) *Issue {
+		issue := &Issue{RepoId: repo.Id, Index: index, Name: fmt.Sprint("issue ", index), PosterId: u1.Id}
+		if err := NewIssue(issue); err != nil {
+			t.Fatalf("NewIssue: %v", err)
+		}
+		return issue
+	}
+	issue1, issue2 := newIssue(repo, 1), newIssue(repo, 2)
+	otherIssue, secretIssue := newIssue(other, 1), newIssue(secret, 1)
+
+	content := "Same as #1, see #2 and user2/other#1 and #9"
+	// Mentioning again does not record another event.
+	for i := 0; i < 2; i++ {
+		if err := UpdateIssuesByContent(u1, repo, issue1, content); err != nil {
+			t.Fatalf("UpdateIssuesByContent: %v", err)
+		}
+	}
+	if err := UpdateIssuesByContent(u1, secret, secretIssue, "See user1/repo1#2"); err != nil {
+		t.Fatalf("UpdateIssuesByContent(private): %v", err)
+	}
+
+	tests := []struct {
+		issue    *Issue
+		expected int
+	}{
+		{issue1, 0},
+		{issue2, 1},
+		{otherIssue, 1},
+	}
+	for _, tt := range tests {
+		comments := make([]*Comment, 0, 2)
+		if err := orm.Where("issue_id=? AND type=?", tt.issue.Id, IT_ISSUE_REF).Find(&comments); err != nil {
+			t.Fatal(err)
+		} else if len(comments) != tt.expected {
+			t.Errorf("issue %d has %d issue references, expected %d", tt.issue.Id, len(comments), tt.expected)
+			continue
+		}
+		expected := IssueRef{"user1/repo1", 1, "issue 1"}
+		for _, c := range comments {
+			if ref := c.IssueRef(); ref == nil || *ref != expected {
+				t.Errorf("IssueRef = %+v, expected %+v", ref, expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"time"
+)
+
+// IssueSubscription represents choice of user to receive or not receive notifications
+// of an issue or pull request, regardless of whether user watches the repository.
+type IssueSubscription struct {
+	Id           int64
+	UserId       int64 `xorm:"UNIQUE(s) INDEX"`
+	IssueId      int64 `xorm:"UNIQUE(s) INDEX"`
+	IsSubscribed bool
+	Updated      time.Time `xorm:"UPDATED"`
+}
+
+// SubscribeIssue subscribes user to or unsubscribes user from notifications of issue.
+func SubscribeIssue(uid, issueId int64, subscribe bool) error {
+	s := &IssueSubscription{UserId: uid, IssueId: issueId}
+	has, err := orm.Where("user_id=? AND issue_id=?", uid, issueId).Get(s)
+	if err != nil {
+		return err
+	}
+	s.IsSubscribed = subscribe
+	if has {
+		_, err = orm.Id(s.Id).Cols("is_subscribed").Update(s)
+	} else {
+		_, err = orm.Insert(s)
+	}
+	return err
+}
+
+// isIssueParticipant returns true if user has posted, commented on, been assigned to
+// or been mentioned in issue.
+func isIssueParticipant(uid int64, issue *Issue) (bool, error) {
+	if issue.PosterId == uid {
+		return true, nil
+	}
+	has, err := orm.Where("issue_id=? AND uid=?", issue.Id, uid).
+		And("(is_poster=? OR is_assigned=? OR is_mentioned=?)", true, true, true).Get(new(IssueUser))
+	if err != nil || has {
+		return has, err
+	}
+	return orm.Where("issue_id=? AND poster_id=?", issue.Id, uid).Get(new(Comment))
+}
+
+// IsIssueSubscribed returns true if user receives notifications of issue. Without explicit
+// choice, users who watch the repository or participate in issue are subscribed.
+func IsIssueSubscribed(uid int64, issue *Issue) (bool, error) {
+	s := new(IssueSubscription)
+	has, err := orm.Where("user_id=? AND issue_id=?", uid, issue.Id).Get(s)
+	if err != nil {
+		return false, err
+	} else if has {
+		return s.IsSubscribed, nil
+	}
+	if IsWatching(uid, issue.RepoId) {
+		return true, nil
+	}
+	return isIssueParticipant(uid, issue)
+}
+
+// GetIssueSubscribers returns users who receive notifications of issue of given repository,
+// users who can no longer access private repository are skipped.
+func GetIssueSubscribers(repo *Repository, issue *Issue) ([]*User, error) {
+	uids := make(map[int64]bool)
+	watches, err := GetWatchers(issue.RepoId)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range watches {
+		uids[w.UserId] = true
+	}
+
+	uids[issue.PosterId] = true
+	ius := make([]*IssueUser, 0, 5)
+	if err = orm.Where("issue_id=?", issue.Id).
+		And("(is_poster=? OR is_assigned=? OR is_mentioned=?)", true, true, true).Find(&ius); err != nil {
+		return nil, err
+	}
+	for _, iu := range ius {
+		uids[iu.Uid] = true
+	}
+	comments := make([]*Comment, 0, 10)
+	if err = orm.Where("issue_id=?", issue.Id).Cols("poster_id").Find(&comments); err != nil {
+		return nil, err
+	}
+	for _, c := range comments {
+		uids[c.PosterId] = true
+	}
+
+	// Explicit choices override defaults above.
+	subs := make([]*IssueSubscription, 0, 5)
+	if err = orm.Where("issue_id=?", issue.Id).Find(&subs); err != nil {
+		return nil, err
+	}
+	for _, s := range subs {
+		uids[s.UserId] = s.IsSubscribed
+	}
+
+	if err = repo.GetOwner(); err != nil {
+		return nil, err
+	}
+	users := make([]*User, 0, len(uids))
+	for uid, isSubscribed := range uids {
+		if !isSubscribed || uid == 0 {
+			continue
+		}
+		u, err := GetUserById(uid)
+		if err != nil {
+			if err == ErrUserNotExist {
+				continue
+			}
+			return nil, err
+		}
+		if repo.IsPrivate && repo.OwnerId != u.Id {
+			has, err := HasAccess(u.Name, repo.Owner.Name+"/"+repo.Name, AU_READABLE)
+			if err != nil {
+				return nil, err
+			} else if !has {
+				continue
+			}
+		}
+		users = append(users, u)
+	}
+	return users, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestIssueSubscriptions(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, poster := newTestUser(t, "user1"), newTestUser(t, "poster")
+	watcher, commenter := newTestUser(t, "watcher"), newTestUser(t, "commenter")
+	assignee, bystander := newTestUser(t, "assignee"), newTestUser(t, "bystander")
+	repo := newTestRepo(t, u1, "repo1")
+
+	issues := make([]*Issue, 2)
+	for i := range issues {
+		issues[i] = &Issue{RepoId: repo.Id, Index: int64(i + 1), Name: "issue", PosterId: poster.Id}
+		if err := NewIssue(issues[i]); err != nil {
+			t.Fatalf("NewIssue: %v", err)
+		}
+	}
+	issue := issues[0]
+	if err := WatchRepo(watcher.Id, repo.Id, true); err != nil {
+		t.Fatalf("WatchRepo: %v", err)
+	} else if _, err = CreateComment(commenter.Id, repo.Id, issue.Id, 0, 0, IT_PLAIN, "Hi"); err != nil {
+		t.Fatalf("CreateComment: %v", err)
+	} else if err = ChangeIssueAssignee(issue, assignee.Id, true); err != nil {
+		t.Fatalf("ChangeIssueAssignee: %v", err)
+	} else if err = ChangeIssueAssignee(issues[1], bystander.Id, true); err != nil {
+		t.Fatalf("ChangeIssueAssignee(other issue): %v", err)
+	}
+
+	// checkSubscribed reports mismatch of subscription state of users.
+	checkSubscribed := func(expected map[*User]bool) {
+		for u, subscribed := range expected {
+			if ok, err := IsIssueSubscribed(u.Id, issue); err != nil {
+				t.Fatalf("IsIssueSubscribed(%s): %v", u.Name, err)
+			} else if ok != subscribed {
+				t.Errorf("IsIssueSubscribed(%s) = %v, expected %v", u.Name, ok, subscribed)
+			}
+		}
+	}
+	checkSubscribed(map[*User]bool{poster: true, watcher: true, commenter: true, assignee: true, bystander: false, u1: false})
+
+	// Explicit choices override watching and participation.
+	for _, s := range []struct {
+		u         *User
+		subscribe bool
+	}{
+		{bystander, false},
+		{bystander, true},
+		{watcher, false},
+	} {
+		if err := SubscribeIssue(s.u.Id, issue.Id, s.subscribe); err != nil {
+			t.Fatalf("SubscribeIssue(%s, %v): %v", s.u.Name, s.subscribe, err)
+		}
+	}
+	checkSubscribed(map[*User]bool{watcher: false, bystander: true})
+	if ok, err := IsIssueSubscribed(0, issue); err != nil || ok {
+		t.Errorf("IsIssueSubscribed(zero ID) = (%v, %v), expected false", ok, err)
+	}
+
+	tests := []struct {
+		isPrivate bool
+		expected  []int64
+	}{
+		{false, []int64{poster.Id, commenter.Id, assignee.Id, bystander.Id}},
+		{true, []int64{assignee.Id}},
+	}
+	if _, err := orm.Insert(&Access{UserName: "assignee", RepoName: "user1/repo1", Mode: AU_READABLE}); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		repo.IsPrivate = tt.isPrivate
+		users, err := GetIssueSubscribers(repo, issue)
+		if err != nil {
+			t.Fatalf("GetIssueSubscribers: %v", err)
+		}
+		ids := make([]int64, len(users))
+		for i, u := range users {
+			ids[i] = u.Id
+		}
+		sort.Sort(int64Slice(ids))
+		if !reflect.DeepEqual(ids, tt.expected) {
+			t.Errorf("GetIssueSubscribers(private %v) = %v, expected %v", tt.isPrivate, ids, tt.expected)
+		}
+	}
+	repo.IsPrivate = false
+
+	// Doer and users notified otherwise are skipped.
+	if err := NewSubscriptionNotifications(NOTIFY_COMMENT, commenter, repo, issue, 0, []string{"bystander"}); err != nil {
+		t.Fatalf("NewSubscriptionNotifications: %v", err)
+	}
+	for u, n := range map[*User]int{poster: 1, assignee: 1, commenter: 0, bystander: 0, watcher: 0} {
+		if ns, err := GetNotifications(u.Id, 1); err != nil {
+			t.Fatalf("GetNotifications(%s): %v", u.Name, err)
+		} else if len(ns) != n {
+			t.Errorf("%s has %d notifications, expected %d", u.Name, len(ns), n)
+		} else if n > 0 && ns[0].Type != NOTIFY_COMMENT {
+			t.Errorf("%s has notification of type %d, expected %d", u.Name, ns[0].Type, NOTIFY_COMMENT)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/gogits/git"
+)
+
+// ISSUE_TEMPLATE_PATH is the path of issue templates in default branch,
+// it is either a single template file or a directory of named templates.
+const ISSUE_TEMPLATE_PATH = ".gogs/ISSUE_TEMPLATE"
+
+// PULL_REQUEST_TEMPLATE_PATH is the path of pull request template.
+const PULL_REQUEST_TEMPLATE_PATH = ".gogs/PULL_REQUEST_TEMPLATE.md"
+
+// IssueTemplate represents a template of new issue.
+type IssueTemplate struct {
+	FileName string
+	Name     string
+	Title    string
+	Content  string
+}
+
+// parseIssueTemplate parses template content with optional front matter like:
+//
+//	---
+//	name: Bug report
+//	title: "[Bug] "
+//	---
+func parseIssueTemplate(fileName string, data []byte) *IssueTemplate {
+	t := &IssueTemplate{
+		FileName: fileName,
+		Name:     strings.Replace(strings.TrimSuffix(fileName, path.Ext(fileName)), "_", " ", -1),
+		Content:  string(data),
+	}
+
+	content := strings.Replace(t.Content, "\r\n", "\n", -1)
+	if !strings.HasPrefix(content, "---\n") {
+		return t
+	}
+	end := strings.Index(content[4:], "\n---")
+	if end == -1 {
+		return t
+	}
+	for _, line := range strings.Split(content[4:4+end], "\n") {
+		infos := strings.SplitN(line, ":", 2)
+		if len(infos) != 2 {
+			continue
+		}
+		val := strings.Trim(strings.TrimSpace(infos[1]), `"'`)
+		switch strings.TrimSpace(infos[0]) {
+		case "name":
+			if len(val) > 0 {
+				t.Name = val
+			}
+		case "title":
+			t.Title = val
+		}
+	}
+	t.Content = strings.TrimLeft(content[4+end+4:], "\n")
+	return t
+}
+
+func readBlob(blob *git.Blob) ([]byte, error) {
+	dataRc, err := blob.Data()
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(dataRc)
+}
+
+// GetIssueTemplates returns issue templates in default branch of repository.
+func GetIssueTemplates(repo *Repository) ([]*IssueTemplate, error) {
+	if err := repo.GetOwner(); err != nil {
+		return nil, err
+	}
+
+	gitRepo, err := git.OpenRepository(RepoPath(repo.Owner.Name, repo.Name))
+	if err != nil {
+		return nil, err
+	} else if !gitRepo.IsBranchExist(repo.DefaultBranch) {
+		return nil, nil
+	}
+	commit, err := gitRepo.GetCommitOfBranch(repo.DefaultBranch)
+	if err != nil {
+		return nil, err
+	}
+
+	// Single template file is allowed to have an extension.
+	for _, p := range []string{ISSUE_TEMPLATE_PATH, ISSUE_TEMPLATE_PATH + ".md"} {
+		entry, err := commit.GetTreeEntryByPath(p)
+		if err != nil {
+			if err == git.ErrNotExist {
+				continue
+			}
+			return nil, err
+		} else if entry.IsDir() {
+			continue
+		}
+
+		data, err := readBlob(entry.Blob())
+		if err != nil {
+			return nil, err
+		}
+		return []*IssueTemplate{parseIssueTemplate(entry.Name(), data)}, nil
+	}
+
+	tree, err := commit.SubTree(ISSUE_TEMPLATE_PATH)
+	if err != nil {
+		if err == git.ErrNotExist {
+			return nil, nil
+		}
+		return nil, err
+	}
+	entries := tree.ListEntries()
+	entries.Sort()
+
+	templates := make([]*IssueTemplate, 0, len(entries))
+	for _, te := range entries {
+		if te.IsDir() || strings.HasPrefix(te.Name(), ".") {
+			continue
+		}
+		data, err := readBlob(te.Blob())
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, parseIssueTemplate(te.Name(), data))
+	}
+	return templates, nil
+}
+
+// GetPullRequestTemplate returns content of pull request template in given branch,
+// it returns empty string if branch has no such file.
+func GetPullRequestTemplate(repoPath, branch string) string {
+	content, err := execGitCmd(repoPath, nil, nil, "cat-file", "blob", branch+":"+PULL_REQUEST_TEMPLATE_PATH)
+	if err != nil {
+		return ""
+	}
+	return content
+}
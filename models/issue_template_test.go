@@ -0,0 +1,106 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+)
+
+var parseIssueTemplateTests = []struct {
+	fileName, data string
+	expected       IssueTemplate
+}{
+	{"bug_report.md", "Describe the bug\n",
+		IssueTemplate{"bug_report.md", "bug report", "", "Describe the bug\n"}},
+	{"bug.md", "---\nname: Bug report\ntitle: \"[Bug] \"\n---\n\nSteps\n",
+		IssueTemplate{"bug.md", "Bug report", "[Bug] ", "Steps\n"}},
+	{"feature.md", "---\r\nname: 'Feature'\r\nabout\r\n---\r\nBody",
+		IssueTemplate{"feature.md", "Feature", "", "Body"}},
+	{"empty_name", "---\nname:\n---\nBody",
+		IssueTemplate{"empty_name", "empty name", "", "Body"}},
+	{"unclosed.md", "---\nname: Unclosed\nBody",
+		IssueTemplate{"unclosed.md", "unclosed", "", "---\nname: Unclosed\nBody"}},
+}
+
+func TestParseIssueTemplate(t *testing.T) {
+	for _, tt := range parseIssueTemplateTests {
+		if tpl := parseIssueTemplate(tt.fileName, []byte(tt.data)); *tpl != tt.expected {
+			t.Errorf("parseIssueTemplate(%q, %q) = %+v, expected %+v", tt.fileName, tt.data, *tpl, tt.expected)
+		}
+	}
+}
+
+func TestGetIssueTemplates(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+
+	dir := newTestRepo(t, u, "dir")
+	testCommitFiles(t, RepoPath(u.Name, dir.Name), "master", "", map[string]string{
+		ISSUE_TEMPLATE_PATH + "/feature.md": "---\nname: Feature request\n---\nIdea\n",
+		ISSUE_TEMPLATE_PATH + "/bug.md":     "Steps\n",
+		ISSUE_TEMPLATE_PATH + "/.hidden":    "Hidden\n",
+		ISSUE_TEMPLATE_PATH + "/sub/a.md":   "Nested\n",
+	}, "Add issue templates")
+	single := newTestRepo(t, u, "single")
+	testCommitFiles(t, RepoPath(u.Name, single.Name), "master", "", map[string]string{
+		ISSUE_TEMPLATE_PATH + ".md": "Describe the issue\n",
+	}, "Add issue template")
+	none := newTestRepo(t, u, "none")
+
+	tests := []struct {
+		repo     *Repository
+		expected []string // Names of templates.
+	}{
+		{dir, []string{"bug", "Feature request"}},
+		{single, []string{"ISSUE TEMPLATE"}},
+		{none, nil},
+	}
+	for _, tt := range tests {
+		tpls, err := GetIssueTemplates(tt.repo)
+		if err != nil {
+			t.Fatalf("GetIssueTemplates(%s): %v", tt.repo.Name, err)
+		} else if len(tpls) != len(tt.expected) {
+			t.Errorf("GetIssueTemplates(%s) returns %d templates, expected %d", tt.repo.Name, len(tpls), len(tt.expected))
+			continue
+		}
+		for i, tpl := range tpls {
+			if tpl.Name != tt.expected[i] {
+				t.Errorf("GetIssueTemplates(%s)[%d].Name = %q, expected %q", tt.repo.Name, i, tpl.Name, tt.expected[i])
+			}
+		}
+	}
+
+	// Repository without default branch has no template.
+	if _, err := execGitCmd(RepoPath(u.Name, none.Name), nil, nil, "branch", "-m", "master", "develop"); err != nil {
+		t.Fatal(err)
+	}
+	if tpls, err := GetIssueTemplates(none); err != nil || len(tpls) != 0 {
+		t.Errorf("GetIssueTemplates(no default branch) = (%v, %v), expected none", tpls, err)
+	}
+}
+
+func TestGetPullRequestTemplate(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo := newTestRepo(t, u, "repo1")
+	repoPath := RepoPath(u.Name, repo.Name)
+
+	testCommitFiles(t, repoPath, "feature", "master", map[string]string{
+		PULL_REQUEST_TEMPLATE_PATH: "## Changes\n\n## Test plan\n",
+	}, "Add pull request template")
+
+	tests := []struct {
+		branch, expected string
+	}{
+		{"feature", "## Changes\n\n## Test plan"},
+		{"master", ""},
+		{"none", ""},
+	}
+	for _, tt := range tests {
+		if content := GetPullRequestTemplate(repoPath, tt.branch); content != tt.expected {
+			t.Errorf("GetPullRequestTemplate(%s) = %q, expected %q", tt.branch, content, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,420 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+type int64Slice []int64
+
+func (s int64Slice) Len() int           { return len(s) }
+func (s int64Slice) Less(i, j int) bool { return s[i] < s[j] }
+func (s int64Slice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+func TestLabels(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo1, repo2 := newTestRepo(t, u, "repo1"), newTestRepo(t, u, "repo2")
+
+	bug := &Label{RepoId: repo1.Id, Name: "bug", Color: "#ee0701", Description: "Something is not working"}
+	feature := &Label{RepoId: repo1.Id, Name: "feature", Color: "#84b6eb"}
+	other := &Label{RepoId: repo2.Id, Name: "bug", Color: "#ee0701"}
+	for _, l := range []*Label{bug, feature, other} {
+		if err := NewLabel(l); err != nil {
+			t.Fatalf("NewLabel: %v", err)
+		}
+	}
+
+	issues := []*Issue{
+		{RepoId: repo1.Id, Index: 1, Name: "both", PosterId: u.Id, LabelIds: fmt.Sprintf("$%d|$%d|", bug.Id, feature.Id)},
+		{RepoId: repo1.Id, Index: 2, Name: "bug", PosterId: u.Id, LabelIds: fmt.Sprintf("$%d|", bug.Id)},
+	}
+	for _, issue := range issues {
+		if err := NewIssue(issue); err != nil {
+			t.Fatalf("NewIssue: %v", err)
+		}
+	}
+
+	tests := []struct {
+		labelIds string
+		expected int
+	}{
+		{fmt.Sprint(bug.Id), 2},
+		{fmt.Sprintf("%d,%d", bug.Id, feature.Id), 1},
+		{fmt.Sprint(other.Id), 0},
+		// Invalid IDs are ignored instead of being put into query.
+		{"0' OR '1'='1", 2},
+	}
+	for _, tt := range tests {
+		if result, err := GetIssues(0, repo1.Id, 0, 0, 1, false, tt.labelIds, ""); err != nil || len(result) != tt.expected {
+			t.Errorf("GetIssues(labels %q) = (%d issues, %v), expected %d issues", tt.labelIds, len(result), err, tt.expected)
+		}
+	}
+
+	if _, err := GetRepoLabelById(repo1.Id, other.Id); err != ErrLabelNotExist {
+		t.Errorf("GetRepoLabelById(other repository) error = %v, expected %v", err, ErrLabelNotExist)
+	}
+	bug.Description = ""
+	if err := UpdateLabel(bug); err != nil {
+		t.Fatalf("UpdateLabel: %v", err)
+	}
+	if l, err := GetRepoLabelById(repo1.Id, bug.Id); err != nil || l.Description != "" {
+		t.Errorf("GetRepoLabelById = (%+v, %v), expected description to be cleared", l, err)
+	}
+
+	// Label of other repository is not deleted.
+	if err := DeleteLabel(repo1.Id, other.Id); err != nil {
+		t.Fatalf("DeleteLabel(other repository): %v", err)
+	} else if _, err = GetLabelById(other.Id); err != nil {
+		t.Errorf("GetLabelById(other repository): %v", err)
+	}
+
+	if err := DeleteLabel(repo1.Id, bug.Id); err != nil {
+		t.Fatalf("DeleteLabel: %v", err)
+	}
+	if _, err := GetLabelById(bug.Id); err != ErrLabelNotExist {
+		t.Errorf("GetLabelById(deleted) error = %v, expected %v", err, ErrLabelNotExist)
+	}
+	expected := []string{fmt.Sprintf("$%d|", feature.Id), ""}
+	for i, issue := range issues {
+		if saved, err := GetIssueById(issue.Id); err != nil || saved.LabelIds != expected[i] {
+			t.Errorf("GetIssueById(%d) = (%+v, %v), expected labels %q", issue.Id, saved, err, expected[i])
+		}
+	}
+}
+
+func TestChangeIssueAssignee(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2, u3 := newTestUser(t, "user1"), newTestUser(t, "user2"), newTestUser(t, "user3")
+	repo := newTestRepo(t, u1, "repo1")
+
+	issue := &Issue{RepoId: repo.Id, Index: 1, Name: "issue", PosterId: u1.Id}
+	if err := NewIssue(issue); err != nil {
+		t.Fatalf("NewIssue: %v", err)
+	}
+
+	assignees := func() []int64 {
+		if err := issue.GetAssignees(); err != nil {
+			t.Fatalf("GetAssignees: %v", err)
+		}
+		ids := make([]int64, len(issue.Assignees))
+		for i, u := range issue.Assignees {
+			ids[i] = u.Id
+		}
+		return ids
+	}
+
+	// Users without issue-user pair get one on assignment.
+	for _, u := range []*User{u2, u3} {
+		if err := ChangeIssueAssignee(issue, u.Id, true); err != nil {
+			t.Fatalf("ChangeIssueAssignee(%s): %v", u.Name, err)
+		}
+	}
+	if ids := assignees(); fmt.Sprint(ids) != fmt.Sprint([]int64{u2.Id, u3.Id}) || issue.AssigneeId != u2.Id {
+		t.Errorf("assignees = %v with first %d, expected [%d %d] with first %d", ids, issue.AssigneeId, u2.Id, u3.Id, u2.Id)
+	}
+	if !issue.IsAssigned(u3.Id) || issue.IsAssigned(u1.Id) {
+		t.Errorf("IsAssigned(%d, %d) = (%v, %v), expected (true, false)", u3.Id, u1.Id, issue.IsAssigned(u3.Id), issue.IsAssigned(u1.Id))
+	}
+	if result, err := GetIssues(u3.Id, repo.Id, 0, 0, 1, false, "", ""); err != nil || len(result) != 1 {
+		t.Errorf("GetIssues(assignee %d) = (%d issues, %v), expected 1 issue", u3.Id, len(result), err)
+	}
+	if stats := GetUserIssueStats(u3.Id, FM_ASSIGN); stats.AssignCount != 1 {
+		t.Errorf("GetUserIssueStats(%d).AssignCount = %d, expected 1", u3.Id, stats.AssignCount)
+	}
+
+	if err := ChangeIssueAssignee(issue, u2.Id, false); err != nil {
+		t.Fatalf("ChangeIssueAssignee(unassign): %v", err)
+	}
+	if ids := assignees(); len(ids) != 1 || ids[0] != u3.Id || issue.AssigneeId != u3.Id {
+		t.Errorf("assignees = %v with first %d, expected [%d] with first %d", ids, issue.AssigneeId, u3.Id, u3.Id)
+	}
+	if saved, err := GetIssueById(issue.Id); err != nil || saved.AssigneeId != u3.Id {
+		t.Errorf("GetIssueById = (%+v, %v), expected first assignee %d", saved, err, u3.Id)
+	}
+	if result, err := GetIssues(u2.Id, repo.Id, 0, 0, 1, false, "", ""); err != nil || len(result) != 0 {
+		t.Errorf("GetIssues(unassigned %d) = (%d issues, %v), expected no issue", u2.Id, len(result), err)
+	}
+
+	if err := ClearIssueAssignees(issue); err != nil {
+		t.Fatalf("ClearIssueAssignees: %v", err)
+	}
+	if ids := assignees(); len(ids) != 0 || issue.AssigneeId != 0 {
+		t.Errorf("assignees = %v with first %d, expected none", ids, issue.AssigneeId)
+	}
+}
+
+func TestSearchIssues(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	repo := newTestRepo(t, u1, "repo1")
+
+	issues := []*Issue{
+		{Name: "Crash on start", Content: "Segfault in main", PosterId: u1.Id, MilestoneId: 1, NumComments: 3},
+		{Name: "Typo in README", PosterId: u2.Id, NumComments: 5},
+		{Name: "Crash on exit", PosterId: u2.Id, MilestoneId: 1, NumComments: 1},
+		{Name: "Closed crash", PosterId: u1.Id, IsClosed: true},
+	}
+	for i, issue := range issues {
+		issue.RepoId, issue.Index = repo.Id, int64(i+1)
+		if err := NewIssue(issue); err != nil {
+			t.Fatalf("NewIssue: %v", err)
+		}
+	}
+
+	tests := []struct {
+		opts     IssuesOptions
+		expected []int64 // Indexes of issues, sorted unless sort type is given.
+	}{
+		{IssuesOptions{}, []int64{1, 2, 3}},
+		{IssuesOptions{IsClosed: true}, []int64{4}},
+		{IssuesOptions{PosterId: u2.Id}, []int64{2, 3}},
+		{IssuesOptions{MilestoneId: 1}, []int64{1, 3}},
+		{IssuesOptions{PosterId: u2.Id, MilestoneId: 1}, []int64{3}},
+		{IssuesOptions{Keyword: "CRASH", SortType: "mostcomment"}, []int64{1, 3}},
+		{IssuesOptions{Keyword: "crash segfault"}, []int64{1}},
+		{IssuesOptions{SortType: "leastcomment", PageSize: 2}, []int64{3, 1}},
+		{IssuesOptions{SortType: "leastcomment", Page: 2, PageSize: 2}, []int64{2}},
+	}
+	for _, tt := range tests {
+		opts := tt.opts
+		opts.RepoId = repo.Id
+		result, err := SearchIssues(&opts)
+		if err != nil {
+			t.Fatalf("SearchIssues(%+v): %v", tt.opts, err)
+		}
+		indexes := make([]int64, len(result))
+		for i := range result {
+			indexes[i] = result[i].Index
+		}
+		// Issues are created within same second.
+		if len(opts.SortType) == 0 {
+			sort.Sort(int64Slice(indexes))
+		}
+		if fmt.Sprint(indexes) != fmt.Sprint(tt.expected) {
+			t.Errorf("SearchIssues(%+v) = %v, expected %v", tt.opts, indexes, tt.expected)
+		}
+	}
+
+	// Pagination does not apply to count.
+	if count := CountIssues(&IssuesOptions{RepoId: repo.Id, Page: 2, PageSize: 1}); count != 3 {
+		t.Errorf("CountIssues = %d, expected 3", count)
+	}
+	if count := CountIssues(&IssuesOptions{RepoId: repo.Id, Keyword: "crash", IsClosed: true}); count != 1 {
+		t.Errorf("CountIssues(closed crash) = %d, expected 1", count)
+	}
+}
+
+func TestIssueDeadline(t *testing.T) {
+	today := beginningOfToday()
+	tests := []struct {
+		deadline             time.Time
+		isClosed             bool
+		hasDeadline, overdue bool
+	}{
+		{time.Time{}, false, false, false},
+		{today.AddDate(0, 0, -2), false, true, true},
+		{today.AddDate(0, 0, -2), true, true, false},
+		// Issue is due at the end of the day.
+		{today, false, true, false},
+		{today.AddDate(0, 0, 3), false, true, false},
+	}
+	for _, tt := range tests {
+		issue := &Issue{Deadline: tt.deadline, IsClosed: tt.isClosed}
+		if issue.HasDeadline() != tt.hasDeadline || issue.IsOverdue() != tt.overdue {
+			t.Errorf("Issue{Deadline: %v, IsClosed: %v}: HasDeadline = %v, IsOverdue = %v, expected %v and %v",
+				tt.deadline, tt.isClosed, issue.HasDeadline(), issue.IsOverdue(), tt.hasDeadline, tt.overdue)
+		}
+	}
+}
+
+func TestSearchIssuesByDue(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo := newTestRepo(t, u, "repo1")
+
+	today := beginningOfToday()
+	issues := []*Issue{
+		{Name: "overdue", Deadline: today.AddDate(0, 0, -3)},
+		{Name: "soon", Deadline: today.AddDate(0, 0, 2)},
+		{Name: "later", Deadline: today.AddDate(0, 0, 30)},
+		{Name: "none"},
+		{Name: "closed", Deadline: today.AddDate(0, 0, 1), IsClosed: true},
+	}
+	for i, issue := range issues {
+		issue.RepoId, issue.Index, issue.PosterId = repo.Id, int64(i+1), u.Id
+		if err := NewIssue(issue); err != nil {
+			t.Fatalf("NewIssue: %v", err)
+		}
+	}
+
+	tests := []struct {
+		dueFilter, sortType string
+		expected            []int64
+	}{
+		{"overdue", "", []int64{1}},
+		{"soon", "", []int64{2}},
+		{"none", "", []int64{4}},
+		// Issues without due date are listed last in both directions.
+		{"", "nearestdue", []int64{1, 2, 3, 4}},
+		{"", "farthestdue", []int64{3, 2, 1, 4}},
+	}
+	for _, tt := range tests {
+		result, err := SearchIssues(&IssuesOptions{RepoId: repo.Id, DueFilter: tt.dueFilter, SortType: tt.sortType})
+		if err != nil {
+			t.Fatalf("SearchIssues(%q, %q): %v", tt.dueFilter, tt.sortType, err)
+		}
+		indexes := make([]int64, len(result))
+		for i := range result {
+			indexes[i] = result[i].Index
+		}
+		if fmt.Sprint(indexes) != fmt.Sprint(tt.expected) {
+			t.Errorf("SearchIssues(%q, %q) = %v, expected %v", tt.dueFilter, tt.sortType, indexes, tt.expected)
+		}
+	}
+
+	due, err := GetIssuesDueWithin(ISSUE_DUE_SOON_DAYS)
+	if err != nil {
+		t.Fatalf("GetIssuesDueWithin: %v", err)
+	} else if len(due) != 1 || due[0].Id != issues[1].Id {
+		t.Errorf("GetIssuesDueWithin returns %d issues, expected only issue %d", len(due), issues[1].Id)
+	}
+}
+
+func TestChangeIssueLabel(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo := newTestRepo(t, u, "repo1")
+
+	label := &Label{RepoId: repo.Id, Name: "bug", Color: "#ee0701"}
+	if err := NewLabel(label); err != nil {
+		t.Fatalf("NewLabel: %v", err)
+	}
+	open := &Issue{RepoId: repo.Id, Index: 1, Name: "open", PosterId: u.Id}
+	closed := &Issue{RepoId: repo.Id, Index: 2, Name: "closed", PosterId: u.Id, IsClosed: true}
+	for _, issue := range []*Issue{open, closed} {
+		if err := NewIssue(issue); err != nil {
+			t.Fatalf("NewIssue: %v", err)
+		}
+	}
+
+	tests := []struct {
+		issue             *Issue
+		isAttach          bool
+		numIssues, closed int
+	}{
+		{open, true, 1, 0},
+		// Attaching label again does not count issue twice.
+		{open, true, 1, 0},
+		{closed, true, 2, 1},
+		{open, false, 1, 1},
+		{open, false, 1, 1},
+		{closed, false, 0, 0},
+	}
+	for _, tt := range tests {
+		if err := ChangeIssueLabel(tt.issue, label, tt.isAttach); err != nil {
+			t.Fatalf("ChangeIssueLabel(%s, %v): %v", tt.issue.Name, tt.isAttach, err)
+		}
+		saved, err := GetLabelById(label.Id)
+		if err != nil {
+			t.Fatalf("GetLabelById: %v", err)
+		} else if saved.NumIssues != tt.numIssues || saved.NumClosedIssues != tt.closed {
+			t.Errorf("ChangeIssueLabel(%s, %v): label has %d issues and %d closed, expected %d and %d",
+				tt.issue.Name, tt.isAttach, saved.NumIssues, saved.NumClosedIssues, tt.numIssues, tt.closed)
+		}
+		if issue, err := GetIssueById(tt.issue.Id); err != nil || issue.HasLabel(label.Id) != tt.isAttach {
+			t.Errorf("ChangeIssueLabel(%s, %v): issue has label = %v (%v)", tt.issue.Name, tt.isAttach, !tt.isAttach, err)
+		}
+	}
+}
+
+func TestPinIssue(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo := newTestRepo(t, u, "repo1")
+
+	issues := make([]*Issue, 5)
+	for i := range issues {
+		issues[i] = &Issue{RepoId: repo.Id, Index: int64(i + 1), Name: fmt.Sprint("issue ", i+1), PosterId: u.Id, IsPull: i == 4}
+		if err := NewIssue(issues[i]); err != nil {
+			t.Fatalf("NewIssue: %v", err)
+		}
+	}
+	// checkPinned reports mismatch of pinned issues by their indexes.
+	checkPinned := func(expected []int64) {
+		pinned, err := GetPinnedIssues(repo.Id)
+		if err != nil {
+			t.Fatalf("GetPinnedIssues: %v", err)
+		}
+		indexes := make([]int64, len(pinned))
+		for i, issue := range pinned {
+			indexes[i] = issue.Index
+			if issue.PinOrder != i+1 {
+				t.Errorf("issue #%d has pin order %d, expected %d", issue.Index, issue.PinOrder, i+1)
+			}
+		}
+		if !reflect.DeepEqual(indexes, expected) {
+			t.Errorf("pinned issues are %v, expected %v", indexes, expected)
+		}
+	}
+
+	if err := PinIssue(issues[4]); err != ErrPinPullRequest {
+		t.Errorf("PinIssue(pull request) error = %v, expected %v", err, ErrPinPullRequest)
+	}
+	if err := UnpinIssue(issues[0]); err != ErrIssueNotPinned {
+		t.Errorf("UnpinIssue(not pinned) error = %v, expected %v", err, ErrIssueNotPinned)
+	}
+	for _, i := range []int{2, 0, 1, 0} {
+		if err := PinIssue(issues[i]); err != nil {
+			t.Fatalf("PinIssue(#%d): %v", issues[i].Index, err)
+		}
+	}
+	checkPinned([]int64{3, 1, 2})
+	if err := PinIssue(issues[3]); err != ErrPinnedIssuesLimit {
+		t.Errorf("PinIssue(over limit) error = %v, expected %v", err, ErrPinnedIssuesLimit)
+	}
+
+	tests := []struct {
+		index    int
+		position int
+		expected []int64
+	}{
+		{0, 1, []int64{1, 3, 2}},
+		{0, 9, []int64{3, 2, 1}},
+		{1, 0, []int64{2, 3, 1}},
+		{2, 2, []int64{2, 3, 1}},
+	}
+	for _, tt := range tests {
+		// Pin orders of other issues change when one is moved.
+		issue, err := GetIssueById(issues[tt.index].Id)
+		if err != nil {
+			t.Fatalf("GetIssueById: %v", err)
+		}
+		if err = MovePinnedIssue(issue, tt.position); err != nil {
+			t.Fatalf("MovePinnedIssue(#%d, %d): %v", issue.Index, tt.position, err)
+		}
+		checkPinned(tt.expected)
+	}
+
+	issue, err := GetIssueById(issues[2].Id)
+	if err != nil {
+		t.Fatalf("GetIssueById: %v", err)
+	} else if err = UnpinIssue(issue); err != nil {
+		t.Fatalf("UnpinIssue: %v", err)
+	}
+	checkPinned([]int64{2, 1})
+	if err = MovePinnedIssue(issue, 1); err != ErrIssueNotPinned {
+		t.Errorf("MovePinnedIssue(not pinned) error = %v, expected %v", err, ErrIssueNotPinned)
+	}
+	if err = PinIssue(issues[3]); err != nil {
+		t.Fatalf("PinIssue(#4): %v", err)
+	}
+	checkPinned([]int64{2, 1, 4})
+}
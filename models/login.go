@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"net/smtp"
+	"net/textproto"
 	"strings"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/go-xorm/xorm"
 
 	"github.com/gogits/gogs/modules/auth/ldap"
+	"github.com/gogits/gogs/modules/auth/pam"
 	"github.com/gogits/gogs/modules/log"
 )
 
@@ -26,23 +28,52 @@ const (
 	LT_PLAIN
 	LT_LDAP
 	LT_SMTP
+	LT_PAM
 )
 
 var (
 	ErrAuthenticationAlreadyExist = errors.New("Authentication already exist")
 	ErrAuthenticationNotExist     = errors.New("Authentication does not exist")
 	ErrAuthenticationUserUsed     = errors.New("Authentication has been used by some users")
+	ErrPAMNotSupported            = errors.New("PAM authentication is not supported by this build")
 )
 
-var LoginTypes = map[int]string{
-	LT_LDAP: "LDAP",
-	LT_SMTP: "SMTP",
+var LoginTypes = map[int]string{}
+
+// ExternalUser represents a user that has been authenticated by a login source.
+type ExternalUser struct {
+	Name     string
+	FullName string
+	Email    string
+}
+
+// LoginSourceConfig is the interface that config of every type of login source must implement.
+type LoginSourceConfig interface {
+	core.Conversion
+	// Authenticate verifies given login name and password against the source.
+	Authenticate(name, passwd string) (*ExternalUser, error)
+}
+
+var loginSourceConfigs = map[int]func() LoginSourceConfig{}
+
+// RegisterLoginType registers a new type of login source,
+// newConfig is called to create an empty config before reading from database.
+func RegisterLoginType(tp int, name string, newConfig func() LoginSourceConfig) {
+	LoginTypes[tp] = name
+	loginSourceConfigs[tp] = newConfig
+}
+
+func init() {
+	RegisterLoginType(LT_LDAP, "LDAP", func() LoginSourceConfig { return new(LDAPConfig) })
+	RegisterLoginType(LT_SMTP, "SMTP", func() LoginSourceConfig { return new(SMTPConfig) })
+	RegisterLoginType(LT_PAM, "PAM", func() LoginSourceConfig { return new(PAMConfig) })
 }
 
 // Ensure structs implmented interface.
 var (
-	_ core.Conversion = &LDAPConfig{}
-	_ core.Conversion = &SMTPConfig{}
+	_ LoginSourceConfig = &LDAPConfig{}
+	_ LoginSourceConfig = &SMTPConfig{}
+	_ LoginSourceConfig = &PAMConfig{}
 )
 
 type LDAPConfig struct {
@@ -58,11 +89,45 @@ func (cfg *LDAPConfig) ToDB() ([]byte, error) {
 	return json.Marshal(cfg.Ldapsource)
 }
 
+func (cfg *LDAPConfig) Authenticate(name, passwd string) (*ExternalUser, error) {
+	uname, fullName, mail, logged := cfg.Ldapsource.SearchEntry(name, passwd)
+	if !logged {
+		// user not in LDAP, do nothing
+		return nil, ErrUserNotExist
+	}
+	return &ExternalUser{
+		Name:     uname,
+		FullName: fullName,
+		Email:    mail,
+	}, nil
+}
+
 type SMTPConfig struct {
-	Auth string
-	Host string
-	Port int
-	TLS  bool
+	Auth           string
+	Host           string
+	Port           int
+	TLS            bool
+	AllowedDomains string // Separated by comma, empty to allow all.
+}
+
+// IsDomainAllowed returns true if domain of given e-mail address
+// is allowed to sign in by the SMTP source.
+func (cfg *SMTPConfig) IsDomainAllowed(email string) bool {
+	if len(strings.TrimSpace(cfg.AllowedDomains)) == 0 {
+		return true
+	}
+
+	idx := strings.LastIndex(email, "@")
+	if idx == -1 {
+		return false
+	}
+	domain := strings.ToLower(email[idx+1:])
+	for _, d := range strings.Split(cfg.AllowedDomains, ",") {
+		if strings.ToLower(strings.TrimSpace(d)) == domain {
+			return true
+		}
+	}
+	return false
 }
 
 // implement
@@ -74,6 +139,71 @@ func (cfg *SMTPConfig) ToDB() ([]byte, error) {
 	return json.Marshal(cfg)
 }
 
+func (cfg *SMTPConfig) Authenticate(name, passwd string) (*ExternalUser, error) {
+	if !cfg.IsDomainAllowed(name) {
+		return nil, ErrUserNotExist
+	}
+
+	var auth smtp.Auth
+	if cfg.Auth == SMTP_PLAIN {
+		auth = smtp.PlainAuth("", name, passwd, cfg.Host)
+	} else if cfg.Auth == SMTP_LOGIN {
+		auth = LoginAuth(name, passwd)
+	} else {
+		return nil, errors.New("Unsupported SMTP auth type")
+	}
+
+	if err := SmtpAuth(cfg.Host, cfg.Port, auth, cfg.TLS); err != nil {
+		// 535: authentication credentials invalid.
+		if tpErr, ok := err.(*textproto.Error); ok && tpErr.Code == 535 {
+			return nil, ErrUserNotExist
+		} else if strings.Contains(err.Error(), "Username and Password not accepted") {
+			return nil, ErrUserNotExist
+		}
+		return nil, err
+	}
+
+	var loginName = name
+	idx := strings.Index(name, "@")
+	if idx > -1 {
+		loginName = name[:idx]
+	}
+	return &ExternalUser{
+		Name:  loginName,
+		Email: name,
+	}, nil
+}
+
+type PAMConfig struct {
+	ServiceName string // PAM service, e.g. system-auth.
+}
+
+// implement
+func (cfg *PAMConfig) FromDB(bs []byte) error {
+	return json.Unmarshal(bs, cfg)
+}
+
+func (cfg *PAMConfig) ToDB() ([]byte, error) {
+	return json.Marshal(cfg)
+}
+
+func (cfg *PAMConfig) Authenticate(name, passwd string) (*ExternalUser, error) {
+	if !pam.Supported {
+		return nil, ErrPAMNotSupported
+	}
+
+	if err := pam.PAMAuth(cfg.ServiceName, name, passwd); err != nil {
+		if strings.Contains(err.Error(), "Authentication failure") {
+			return nil, ErrUserNotExist
+		}
+		return nil, err
+	}
+	return &ExternalUser{
+		Name:  name,
+		Email: name + "@localhost",
+	}, nil
+}
+
 type LoginSource struct {
 	Id                int64
 	Type              int
@@ -89,6 +219,12 @@ func (source *LoginSource) TypeString() string {
 	return LoginTypes[source.Type]
 }
 
+// Config returns config of login source as LoginSourceConfig.
+func (source *LoginSource) Config() LoginSourceConfig {
+	cfg, _ := source.Cfg.(LoginSourceConfig)
+	return cfg
+}
+
 func (source *LoginSource) LDAP() *LDAPConfig {
 	return source.Cfg.(*LDAPConfig)
 }
@@ -97,15 +233,16 @@ func (source *LoginSource) SMTP() *SMTPConfig {
 	return source.Cfg.(*SMTPConfig)
 }
 
+func (source *LoginSource) PAM() *PAMConfig {
+	return source.Cfg.(*PAMConfig)
+}
+
 // for xorm callback
 func (source *LoginSource) BeforeSet(colName string, val xorm.Cell) {
 	if colName == "type" {
 		ty := (*val).(int64)
-		switch ty {
-		case LT_LDAP:
-			source.Cfg = new(LDAPConfig)
-		case LT_SMTP:
-			source.Cfg = new(SMTPConfig)
+		if newConfig, ok := loginSourceConfigs[int(ty)]; ok {
+			source.Cfg = newConfig()
 		}
 	}
 }
@@ -176,9 +313,7 @@ func UserSignIn(uname, passwd string) (*User, error) {
 			return nil, ErrUserNotExist
 		}
 
-		newUser := &User{Passwd: passwd, Salt: u.Salt}
-		newUser.EncodePasswd()
-		if u.Passwd != newUser.Passwd {
+		if !u.ValidatePassword(passwd) {
 			return nil, ErrUserNotExist
 		}
 		return u, nil
@@ -191,23 +326,11 @@ func UserSignIn(uname, passwd string) (*User, error) {
 			}
 
 			for _, source := range sources {
-				if source.Type == LT_LDAP {
-					u, err := LoginUserLdapSource(nil, uname, passwd,
-						source.Id, source.Cfg.(*LDAPConfig), true)
-					if err == nil {
-						return u, nil
-					} else {
-						log.Warn("Fail to login(%s) by LDAP(%s): %v", uname, source.Name, err)
-					}
-				} else if source.Type == LT_SMTP {
-					u, err := LoginUserSMTPSource(nil, uname, passwd,
-						source.Id, source.Cfg.(*SMTPConfig), true)
-					if err == nil {
-						return u, nil
-					} else {
-						log.Warn("Fail to login(%s) by SMTP(%s): %v", uname, source.Name, err)
-					}
+				u, err := ExternalUserSignIn(nil, uname, passwd, &source, true)
+				if err == nil {
+					return u, nil
 				}
+				log.Warn("Fail to login(%s) by %s(%s): %v", uname, source.TypeString(), source.Name, err)
 			}
 
 			return nil, ErrUserNotExist
@@ -223,44 +346,57 @@ func UserSignIn(uname, passwd string) (*User, error) {
 			return nil, ErrLoginSourceNotActived
 		}
 
-		switch u.LoginType {
-		case LT_LDAP:
-			return LoginUserLdapSource(u, u.LoginName, passwd,
-				source.Id, source.Cfg.(*LDAPConfig), false)
-		case LT_SMTP:
-			return LoginUserSMTPSource(u, u.LoginName, passwd,
-				source.Id, source.Cfg.(*SMTPConfig), false)
-		}
-		return nil, ErrUnsupportedLoginType
+		return ExternalUserSignIn(u, u.LoginName, passwd, &source, false)
 	}
 }
 
-// Query if name/passwd can login against the LDAP direcotry pool
-// Create a local user if success
-// Return the same LoginUserPlain semantic
-func LoginUserLdapSource(user *User, name, passwd string, sourceId int64, cfg *LDAPConfig, autoRegister bool) (*User, error) {
-	mail, logged := cfg.Ldapsource.SearchEntry(name, passwd)
-	if !logged {
-		// user not in LDAP, do nothing
-		return nil, ErrUserNotExist
+// ExternalUserSignIn verifies name and password against given login source,
+// and creates a local user when it succeeds for the first time.
+func ExternalUserSignIn(u *User, name, passwd string, source *LoginSource, autoRegister bool) (*User, error) {
+	cfg := source.Config()
+	if cfg == nil {
+		return nil, ErrUnsupportedLoginType
+	}
+
+	eu, err := cfg.Authenticate(name, passwd)
+	if err == ErrPAMNotSupported && u != nil {
+		// Fall back to local password, which is kept in sync on every
+		// successful PAM authentication.
+		log.Warn("PAM is unavailable, use local password for user: %s", u.Name)
+		if !u.ValidatePassword(passwd) {
+			return nil, ErrUserNotExist
+		}
+		return u, nil
+	} else if err != nil {
+		return nil, err
 	}
 	if !autoRegister {
-		return user, nil
+		if source.Type == LT_PAM && !u.ValidatePassword(passwd) {
+			u.Passwd = passwd
+			u.EncodePasswd()
+			if err = UpdateUser(u); err != nil {
+				return nil, err
+			}
+		}
+		return u, nil
 	}
 
+	if len(eu.Name) == 0 {
+		eu.Name = name
+	}
 	// fake a local user creation
-	user = &User{
-		LowerName:   strings.ToLower(name),
-		Name:        strings.ToLower(name),
-		LoginType:   LT_LDAP,
-		LoginSource: sourceId,
+	u = &User{
+		LowerName:   strings.ToLower(eu.Name),
+		Name:        strings.ToLower(eu.Name),
+		FullName:    eu.FullName,
+		LoginType:   source.Type,
+		LoginSource: source.Id,
 		LoginName:   name,
 		IsActive:    true,
 		Passwd:      passwd,
-		Email:       mail,
+		Email:       eu.Email,
 	}
-
-	return RegisterUser(user)
+	return RegisterUser(u)
 }
 
 type loginAuth struct {
@@ -324,47 +460,3 @@ func SmtpAuth(host string, port int, a smtp.Auth, useTls bool) error {
 		return ErrUnsupportedLoginType
 	}
 }
-
-// Query if name/passwd can login against the LDAP direcotry pool
-// Create a local user if success
-// Return the same LoginUserPlain semantic
-func LoginUserSMTPSource(user *User, name, passwd string, sourceId int64, cfg *SMTPConfig, autoRegister bool) (*User, error) {
-	var auth smtp.Auth
-	if cfg.Auth == SMTP_PLAIN {
-		auth = smtp.PlainAuth("", name, passwd, cfg.Host)
-	} else if cfg.Auth == SMTP_LOGIN {
-		auth = LoginAuth(name, passwd)
-	} else {
-		return nil, errors.New("Unsupported SMTP auth type")
-	}
-
-	if err := SmtpAuth(cfg.Host, cfg.Port, auth, cfg.TLS); err != nil {
-		if strings.Contains(err.Error(), "Username and Password not accepted") {
-			return nil, ErrUserNotExist
-		}
-		return nil, err
-	}
-
-	if !autoRegister {
-		return user, nil
-	}
-
-	var loginName = name
-	idx := strings.Index(name, "@")
-	if idx > -1 {
-		loginName = name[:idx]
-	}
-	// fake a local user creation
-	user = &User{
-		LowerName:   strings.ToLower(loginName),
-		Name:        strings.ToLower(loginName),
-		LoginType:   LT_SMTP,
-		LoginSource: sourceId,
-		LoginName:   name,
-		IsActive:    true,
-		Passwd:      passwd,
-		Email:       name,
-	}
-
-	return RegisterUser(user)
-}
@@ -0,0 +1,78 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/setting"
+)
+
+// LoginAttempt represents a failed login attempt.
+type LoginAttempt struct {
+	Id       int64
+	UserName string    `xorm:"INDEX"` // Lower cased login name.
+	Ip       string    `xorm:"INDEX VARCHAR(50)"`
+	Created  time.Time `xorm:"CREATED INDEX"`
+}
+
+func loginFailureWindowStart() time.Time {
+	return time.Now().Add(-time.Duration(setting.LoginFailureWindow) * time.Minute)
+}
+
+// NewLoginAttempt records a failed login attempt.
+func NewLoginAttempt(uname, ip string) error {
+	_, err := orm.Insert(&LoginAttempt{
+		UserName: strings.ToLower(uname),
+		Ip:       ip,
+	})
+	return err
+}
+
+// CountUserLoginAttempts returns number of failed login attempts
+// of given user name within the failure window.
+func CountUserLoginAttempts(uname string) (int64, error) {
+	return orm.Where("user_name=?", strings.ToLower(uname)).
+		And("created>?", loginFailureWindowStart()).Count(new(LoginAttempt))
+}
+
+// IsLoginLocked returns true if given user name or IP address has
+// too many failed login attempts within the failure window.
+func IsLoginLocked(uname, ip string) (bool, error) {
+	if setting.LoginMaxFailures > 0 {
+		count, err := CountUserLoginAttempts(uname)
+		if err != nil {
+			return false, err
+		} else if count >= int64(setting.LoginMaxFailures) {
+			return true, nil
+		}
+	}
+
+	if setting.LoginMaxFailuresPerIp > 0 && len(ip) > 0 {
+		count, err := orm.Where("ip=?", ip).
+			And("created>?", loginFailureWindowStart()).Count(new(LoginAttempt))
+		if err != nil {
+			return false, err
+		} else if count >= int64(setting.LoginMaxFailuresPerIp) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// UnlockUserLogin deletes all failed login attempts of given user name.
+func UnlockUserLogin(uname string) error {
+	_, err := orm.Where("user_name=?", strings.ToLower(uname)).Delete(new(LoginAttempt))
+	return err
+}
+
+// DeleteExpiredLoginAttempts deletes failed login attempts out of failure window.
+func DeleteExpiredLoginAttempts() {
+	if _, err := orm.Where("created<?", loginFailureWindowStart()).Delete(new(LoginAttempt)); err != nil {
+		log.Error("DeleteExpiredLoginAttempts: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/gogits/gogs/modules/setting"
+)
+
+func TestIsLoginLocked(t *testing.T) {
+	defer prepareTestEnv(t)()
+	defer func(maxFailures, maxFailuresPerIp, window int) {
+		setting.LoginMaxFailures, setting.LoginMaxFailuresPerIp, setting.LoginFailureWindow =
+			maxFailures, maxFailuresPerIp, window
+	}(setting.LoginMaxFailures, setting.LoginMaxFailuresPerIp, setting.LoginFailureWindow)
+	setting.LoginMaxFailures, setting.LoginMaxFailuresPerIp, setting.LoginFailureWindow = 3, 5, 15
+
+	isLocked := func(uname, ip string) bool {
+		locked, err := IsLoginLocked(uname, ip)
+		if err != nil {
+			t.Fatalf("IsLoginLocked(%q, %q): %v", uname, ip, err)
+		}
+		return locked
+	}
+	newAttempt := func(uname, ip string) {
+		if err := NewLoginAttempt(uname, ip); err != nil {
+			t.Fatalf("NewLoginAttempt: %v", err)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		if isLocked("alice", "10.0.0.1") {
+			t.Fatalf("user is locked after %d failures, expected 3", i)
+		}
+		newAttempt("Alice", "10.0.0.1")
+	}
+	if !isLocked("ALICE", "10.0.0.2") {
+		t.Error("user is not locked after 3 failures")
+	}
+	if isLocked("bob", "10.0.0.2") {
+		t.Error("other user is locked")
+	}
+
+	newAttempt("bob", "10.0.0.1")
+	if isLocked("carol", "10.0.0.1") {
+		t.Error("IP address is locked after 4 failures, expected 5")
+	}
+	newAttempt("carol", "10.0.0.1")
+	if !isLocked("dave", "10.0.0.1") {
+		t.Error("IP address is not locked after 5 failures")
+	}
+
+	if err := UnlockUserLogin(""); err != nil {
+		t.Fatalf("UnlockUserLogin(empty): %v", err)
+	} else if !isLocked("alice", "10.0.0.2") {
+		t.Error("user is unlocked by unlocking empty name")
+	}
+	if err := UnlockUserLogin("alice"); err != nil {
+		t.Fatalf("UnlockUserLogin: %v", err)
+	} else if isLocked("alice", "10.0.0.2") {
+		t.Error("user is still locked after unlocking")
+	}
+	if count, err := CountUserLoginAttempts("bob"); err != nil || count != 1 {
+		t.Errorf("CountUserLoginAttempts(other user) = (%d, %v), expected 1", count, err)
+	}
+}
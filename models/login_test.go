@@ -0,0 +1,145 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gogits/gogs/modules/auth/pam"
+)
+
+const LT_TEST = 99
+
+// testLoginConfig authenticates users against passwords it holds.
+type testLoginConfig struct {
+	Passwds map[string]string
+}
+
+func (cfg *testLoginConfig) FromDB(bs []byte) error {
+	return json.Unmarshal(bs, cfg)
+}
+
+func (cfg *testLoginConfig) ToDB() ([]byte, error) {
+	return json.Marshal(cfg)
+}
+
+func (cfg *testLoginConfig) Authenticate(name, passwd string) (*ExternalUser, error) {
+	if p, ok := cfg.Passwds[name]; !ok || p != passwd {
+		return nil, ErrUserNotExist
+	}
+	return &ExternalUser{
+		Name:  name,
+		Email: name + "@example.com",
+	}, nil
+}
+
+func init() {
+	RegisterLoginType(LT_TEST, "Test", func() LoginSourceConfig { return new(testLoginConfig) })
+}
+
+func TestExternalUserSignIn(t *testing.T) {
+	defer prepareTestEnv(t)()
+	// Take the first ID, which is granted admin on registration.
+	newTestUser(t, "admin")
+
+	source := &LoginSource{
+		Type:              LT_TEST,
+		Name:              "test",
+		IsActived:         true,
+		AllowAutoRegister: true,
+		Cfg:               &testLoginConfig{Passwds: map[string]string{"alice": "secret"}},
+	}
+	if err := AddSource(source); err != nil {
+		t.Fatalf("AddSource: %v", err)
+	}
+
+	if _, err := UserSignIn("alice", "wrong"); err != ErrUserNotExist {
+		t.Errorf("UserSignIn(wrong password) error = %v, expected %v", err, ErrUserNotExist)
+	}
+
+	u, err := UserSignIn("alice", "secret")
+	if err != nil {
+		t.Fatalf("UserSignIn(auto register): %v", err)
+	} else if u.LoginType != LT_TEST || u.LoginSource != source.Id || u.IsAdmin {
+		t.Errorf("registered user has login type %d, source %d, admin %v, expected %d, %d, false",
+			u.LoginType, u.LoginSource, u.IsAdmin, LT_TEST, source.Id)
+	}
+
+	// Config of existing user's source is restored from database by registered type.
+	if u2, err := UserSignIn("alice", "secret"); err != nil || u2.Id != u.Id {
+		t.Errorf("UserSignIn(registered) = (%v, %v), expected user %d", u2, err, u.Id)
+	}
+	if _, err = UserSignIn("alice", "wrong"); err != ErrUserNotExist {
+		t.Errorf("UserSignIn(registered, wrong password) error = %v, expected %v", err, ErrUserNotExist)
+	}
+
+	source.IsActived = false
+	if err = UpdateSource(source); err != nil {
+		t.Fatalf("UpdateSource: %v", err)
+	}
+	if _, err = UserSignIn("alice", "secret"); err != ErrLoginSourceNotActived {
+		t.Errorf("UserSignIn(inactive source) error = %v, expected %v", err, ErrLoginSourceNotActived)
+	}
+}
+
+func TestSMTPIsDomainAllowed(t *testing.T) {
+	tests := []struct {
+		allowed, email string
+		expected       bool
+	}{
+		{"", "alice@example.com", true},
+		{" ", "alice", true},
+		{"example.com", "alice@example.com", true},
+		{"example.com", "alice@EXAMPLE.com", true},
+		{"gogs.io, Example.com", "alice@example.com", true},
+		{"example.com", "alice@sub.example.com", false},
+		{"example.com", "alice@example.com.evil.org", false},
+		{"example.com", "example.com", false},
+	}
+	for _, tt := range tests {
+		cfg := &SMTPConfig{AllowedDomains: tt.allowed}
+		if ok := cfg.IsDomainAllowed(tt.email); ok != tt.expected {
+			t.Errorf("SMTPConfig{AllowedDomains: %q}.IsDomainAllowed(%q) = %v, expected %v", tt.allowed, tt.email, ok, tt.expected)
+		}
+	}
+
+	// Disallowed domain is rejected without connecting to server.
+	cfg := &SMTPConfig{Auth: SMTP_PLAIN, Host: "127.0.0.1", Port: 1, AllowedDomains: "example.com"}
+	if _, err := cfg.Authenticate("alice@evil.org", "secret"); err != ErrUserNotExist {
+		t.Errorf("Authenticate(disallowed domain) error = %v, expected %v", err, ErrUserNotExist)
+	}
+}
+
+func TestPAMSignInFallback(t *testing.T) {
+	if pam.Supported {
+		t.Skip("PAM is supported by this build")
+	}
+	defer prepareTestEnv(t)()
+
+	source := &LoginSource{Type: LT_PAM, Name: "pam", IsActived: true, Cfg: &PAMConfig{ServiceName: "system-auth"}}
+	if err := AddSource(source); err != nil {
+		t.Fatalf("AddSource: %v", err)
+	}
+
+	u := newTestUser(t, "alice")
+	u.LoginType, u.LoginSource, u.LoginName = LT_PAM, source.Id, "alice"
+	u.Salt = "salt"
+	u.Passwd = encodePbkdf2Passwd("secret", u.Salt)
+	if err := UpdateUser(u); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+
+	if _, err := UserSignIn("alice", "wrong"); err != ErrUserNotExist {
+		t.Errorf("UserSignIn(wrong password) error = %v, expected %v", err, ErrUserNotExist)
+	}
+	if signed, err := UserSignIn("alice", "secret"); err != nil || signed.Id != u.Id {
+		t.Errorf("UserSignIn = (%v, %v), expected user %d", signed, err, u.Id)
+	}
+	// New users cannot be registered by local password.
+	if _, err := UserSignIn("bob", "secret"); err != ErrUserNotExist {
+		t.Errorf("UserSignIn(unknown user) error = %v, expected %v", err, ErrUserNotExist)
+	}
+}
@@ -0,0 +1,389 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gogits/git"
+
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/migrations"
+)
+
+var (
+	ErrMigrationNotExist = errors.New("Migration does not exist")
+)
+
+const (
+	MIGRATION_RUNNING = iota + 1
+	MIGRATION_DONE
+	MIGRATION_FAILED
+)
+
+// Migration represents progress of importing metadata of a migrated repository.
+type Migration struct {
+	Id               int64
+	RepoId           int64 `xorm:"UNIQUE NOT NULL"`
+	Service          string
+	Status           int
+	Stage            string // Description of current step.
+	NumLabels        int
+	NumMilestones    int
+	NumIssues        int
+	NumComments      int
+	NumReleases      int
+	NumCollaborators int
+	Errors           string    `xorm:"TEXT"` // Problems of skipped items, one per line.
+	Created          time.Time `xorm:"CREATED"`
+	Updated          time.Time `xorm:"UPDATED"`
+}
+
+func (m *Migration) IsRunning() bool {
+	return m.Status == MIGRATION_RUNNING
+}
+
+func (m *Migration) IsFailed() bool {
+	return m.Status == MIGRATION_FAILED
+}
+
+// ErrorList returns problems happened during migration.
+func (m *Migration) ErrorList() []string {
+	if len(m.Errors) == 0 {
+		return nil
+	}
+	return strings.Split(m.Errors, "\n")
+}
+
+func (m *Migration) addError(format string, args ...interface{}) {
+	msg := strings.Replace(fmt.Sprintf(format, args...), "\n", " ", -1)
+	if len(m.Errors) > 0 {
+		m.Errors += "\n"
+	}
+	m.Errors += msg
+}
+
+// setStage saves progress of migration with description of current step.
+func (m *Migration) setStage(format string, args ...interface{}) error {
+	m.Stage = fmt.Sprintf(format, args...)
+	_, err := orm.Id(m.Id).AllCols().Update(m)
+	return err
+}
+
+// NewMigration creates a new running migration of repository.
+func NewMigration(m *Migration) error {
+	m.Status = MIGRATION_RUNNING
+	m.Stage = "Waiting to start"
+	_, err := orm.Insert(m)
+	return err
+}
+
+// GetMigrationByRepoId returns migration of given repository.
+func GetMigrationByRepoId(repoId int64) (*Migration, error) {
+	m := &Migration{RepoId: repoId}
+	has, err := orm.Get(m)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrMigrationNotExist
+	}
+	return m, nil
+}
+
+// migrator imports metadata downloaded from remote repository.
+type migrator struct {
+	doer       *User
+	repo       *Repository
+	downloader migrations.Downloader
+	m          *Migration
+	users      map[string]*User // Remote user name -> local user, nil if not found.
+	labels     map[string]*Label
+	milestones map[int64]int64 // Remote milestone number -> local milestone ID.
+}
+
+// getUser returns local user that has same name as remote user,
+// or the user who runs migration if there is none.
+func (mg *migrator) getUser(name string) (*User, bool, error) {
+	u, ok := mg.users[name]
+	if !ok {
+		var err error
+		if u, err = GetUserByName(name); err != nil {
+			if err != ErrUserNotExist {
+				return nil, false, err
+			}
+			u = nil
+		}
+		mg.users[name] = u
+	}
+	if u == nil {
+		return mg.doer, false, nil
+	}
+	return u, true, nil
+}
+
+// attribute returns content with note of original poster.
+func attribute(content, poster string, created time.Time) string {
+	return fmt.Sprintf("> Originally posted by **%s** on %s\n\n%s", poster, created.Format("Jan 2, 2006"), content)
+}
+
+func (mg *migrator) migrateCollaborators() error {
+	mg.m.setStage("Importing collaborators")
+	names, err := mg.downloader.GetCollaborators()
+	if err != nil {
+		mg.m.addError("Fail to get collaborators: %v", err)
+		return nil
+	}
+
+	repoLink := strings.ToLower(mg.repo.Owner.Name + "/" + mg.repo.Name)
+	for _, name := range names {
+		u, found, err := mg.getUser(name)
+		if err != nil {
+			return err
+		} else if !found {
+			mg.m.addError("Collaborator '%s' is skipped: user does not exist", name)
+			continue
+		} else if u.Id == mg.repo.OwnerId {
+			continue
+		}
+
+		has, err := HasAccess(u.Name, repoLink, AU_WRITABLE)
+		if err != nil {
+			return err
+		} else if has {
+			continue
+		}
+		if err = AddAccess(&Access{UserName: u.Name, RepoName: repoLink, Mode: AU_WRITABLE}); err != nil {
+			return err
+		}
+		mg.m.NumCollaborators++
+	}
+	return nil
+}
+
+func (mg *migrator) migrateLabels() error {
+	mg.m.setStage("Importing labels")
+	labels, err := mg.downloader.GetLabels()
+	if err != nil {
+		mg.m.addError("Fail to get labels: %v", err)
+		return nil
+	}
+
+	for _, l := range labels {
+		label := &Label{RepoId: mg.repo.Id, Name: l.Name, Color: l.Color}
+		if err = NewLabel(label); err != nil {
+			return err
+		}
+		mg.labels[l.Name] = label
+		mg.m.NumLabels++
+	}
+	return nil
+}
+
+func (mg *migrator) migrateMilestones() error {
+	mg.m.setStage("Importing milestones")
+	miles, err := mg.downloader.GetMilestones()
+	if err != nil {
+		mg.m.addError("Fail to get milestones: %v", err)
+		return nil
+	}
+
+	for i, ml := range miles {
+		mile := &Milestone{
+			RepoId:   mg.repo.Id,
+			Index:    int64(mg.repo.NumMilestones + i + 1),
+			Name:     ml.Title,
+			Content:  ml.Content,
+			Deadline: ml.Deadline,
+		}
+		if mile.Deadline.IsZero() {
+			// Same as milestone created without deadline.
+			mile.Deadline, _ = time.Parse("2006-01-02", "9999-12-31")
+		}
+		if err = NewMilestone(mile); err != nil {
+			return err
+		} else if ml.IsClosed {
+			mile.ClosedDate = time.Now()
+			if err = ChangeMilestoneStatus(mile, true); err != nil {
+				return err
+			}
+		}
+		mg.milestones[ml.Number] = mile.Id
+		mg.m.NumMilestones++
+	}
+	return nil
+}
+
+func (mg *migrator) migrateIssues() error {
+	mg.m.setStage("Downloading issues")
+	issues, err := mg.downloader.GetIssues()
+	if err != nil {
+		mg.m.addError("Fail to get issues: %v", err)
+		return nil
+	}
+
+	for i, is := range issues {
+		mg.m.setStage("Importing issues (%d/%d)", i+1, len(issues))
+
+		poster, found, err := mg.getUser(is.Poster)
+		if err != nil {
+			return err
+		}
+		issue := &Issue{
+			RepoId:   mg.repo.Id,
+			Index:    int64(mg.repo.NumIssues + i + 1),
+			Name:     is.Title,
+			PosterId: poster.Id,
+			IsClosed: is.IsClosed,
+			Content:  is.Content,
+		}
+		if !found {
+			issue.Content = attribute(is.Content, is.Poster, is.Created)
+		}
+		for _, name := range is.Labels {
+			if l, ok := mg.labels[name]; ok {
+				issue.LabelIds += "$" + fmt.Sprint(l.Id) + "|"
+				l.NumIssues++
+				if is.IsClosed {
+					l.NumClosedIssues++
+				}
+			}
+		}
+		if is.MilestoneId > 0 {
+			issue.MilestoneId = mg.milestones[is.MilestoneId]
+		}
+
+		if err = NewIssue(issue); err != nil {
+			return err
+		} else if err = NewIssueUserPairs(mg.repo.Id, issue.Id, mg.repo.OwnerId, poster.Id, 0,
+			mg.repo.Owner.Name+"/"+mg.repo.Name); err != nil {
+			return err
+		} else if issue.MilestoneId > 0 {
+			if err = ChangeMilestoneAssign(0, issue.MilestoneId, issue); err != nil {
+				return err
+			}
+		}
+		mg.m.NumIssues++
+
+		comments, err := mg.downloader.GetComments(is.Number)
+		if err != nil {
+			mg.m.addError("Fail to get comments of issue #%d: %v", is.Number, err)
+		}
+		for _, c := range comments {
+			commenter, found, err := mg.getUser(c.Poster)
+			if err != nil {
+				return err
+			}
+			content := c.Content
+			if !found {
+				content = attribute(c.Content, c.Poster, c.Created)
+			}
+			if _, err = CreateComment(commenter.Id, mg.repo.Id, issue.Id, 0, 0, IT_PLAIN, content); err != nil {
+				return err
+			}
+			mg.m.NumComments++
+		}
+
+		if issue.IsClosed {
+			if err = UpdateIssueUserPairsByStatus(issue.Id, true); err != nil {
+				return err
+			} else if _, err = CreateComment(mg.doer.Id, mg.repo.Id, issue.Id, 0, 0, IT_CLOSE, ""); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, l := range mg.labels {
+		if err = UpdateLabel(l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (mg *migrator) migrateReleases() error {
+	mg.m.setStage("Importing releases")
+	rels, err := mg.downloader.GetReleases()
+	if err != nil {
+		mg.m.addError("Fail to get releases: %v", err)
+		return nil
+	}
+
+	gitRepo, err := git.OpenRepository(RepoPath(mg.repo.Owner.Name, mg.repo.Name))
+	if err != nil {
+		return err
+	}
+	for _, r := range rels {
+		rel := &Release{
+			RepoId:       mg.repo.Id,
+			PublisherId:  mg.doer.Id,
+			Title:        r.Title,
+			TagName:      r.TagName,
+			Note:         r.Note,
+			IsDraft:      r.IsDraft,
+			IsPrerelease: r.IsPrerelease,
+		}
+		if len(rel.Title) == 0 {
+			rel.Title = rel.TagName
+		}
+		if !rel.IsDraft && !gitRepo.IsTagExist(rel.TagName) {
+			mg.m.addError("Release '%s' is imported as draft: tag does not exist", rel.TagName)
+			rel.IsDraft = true
+		}
+
+		if err = CreateRelease(gitRepo, rel); err != nil {
+			if err == ErrReleaseAlreadyExist {
+				mg.m.addError("Release '%s' is skipped: it already exists", rel.TagName)
+				continue
+			} else if err == ErrRefNameIllegal {
+				mg.m.addError("Release '%s' is skipped: tag name is illegal", rel.TagName)
+				continue
+			}
+			return err
+		}
+		mg.m.NumReleases++
+	}
+	return nil
+}
+
+// RunMigration imports collaborators, labels, milestones, issues and releases of remote
+// repository. Problems of single item are recorded and skipped, migration is failed
+// only when data cannot be saved.
+func RunMigration(doer *User, repo *Repository, d migrations.Downloader, m *Migration) {
+	mg := &migrator{
+		doer:       doer,
+		repo:       repo,
+		downloader: d,
+		m:          m,
+		users:      make(map[string]*User),
+		labels:     make(map[string]*Label),
+		milestones: make(map[int64]int64),
+	}
+
+	var err error
+	if err = repo.GetOwner(); err == nil {
+		for _, step := range []func() error{mg.migrateCollaborators, mg.migrateLabels,
+			mg.migrateMilestones, mg.migrateIssues, mg.migrateReleases} {
+			if err = step(); err != nil {
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		log.Error("models.RunMigration(%d): %v", repo.Id, err)
+		m.Status = MIGRATION_FAILED
+		m.addError("Migration is aborted: %v", err)
+		err = m.setStage("Failed")
+	} else {
+		m.Status = MIGRATION_DONE
+		err = m.setStage("Done")
+	}
+	if err != nil {
+		log.Error("models.RunMigration(%d): %v", repo.Id, err)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogits/gogs/modules/migrations"
+)
+
+// testDownloader returns fixed metadata of remote repository.
+type testDownloader struct{}
+
+func (d testDownloader) GetLabels() ([]*migrations.Label, error) {
+	return []*migrations.Label{{Name: "bug", Color: "#ee0701"}}, nil
+}
+
+func (d testDownloader) GetMilestones() ([]*migrations.Milestone, error) {
+	return []*migrations.Milestone{{Number: 7, Title: "v1.0", IsClosed: true}}, nil
+}
+
+func (d testDownloader) GetIssues() ([]*migrations.Issue, error) {
+	return []*migrations.Issue{
+		{Number: 1, Title: "Crash", Poster: "user2", Labels: []string{"bug", "unknown"}, MilestoneId: 7},
+		{Number: 2, Title: "Typo", Poster: "ghost", IsClosed: true, Content: "Fix it", Created: time.Now()},
+	}, nil
+}
+
+func (d testDownloader) GetComments(issueNumber int64) ([]*migrations.Comment, error) {
+	if issueNumber == 1 {
+		return nil, errors.New("comments are not available")
+	}
+	return []*migrations.Comment{{Poster: "user2", Content: "Done"}}, nil
+}
+
+func (d testDownloader) GetReleases() ([]*migrations.Release, error) {
+	return []*migrations.Release{{TagName: "v1.0", Note: "First release"}, {TagName: "v1..0"}}, nil
+}
+
+func (d testDownloader) GetCollaborators() ([]string, error) {
+	return []string{"user1", "user2", "ghost"}, nil
+}
+
+func TestRunMigration(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	repo := newTestRepo(t, u, "repo1")
+
+	m := &Migration{RepoId: repo.Id, Service: migrations.SERVICE_GITHUB}
+	if err := NewMigration(m); err != nil {
+		t.Fatalf("NewMigration: %v", err)
+	}
+	RunMigration(u, repo, testDownloader{}, m)
+
+	m, err := GetMigrationByRepoId(repo.Id)
+	if err != nil {
+		t.Fatalf("GetMigrationByRepoId: %v", err)
+	} else if m.Status != MIGRATION_DONE {
+		t.Fatalf("migration has status %d, expected %d: %v", m.Status, MIGRATION_DONE, m.ErrorList())
+	}
+	if m.NumCollaborators != 1 || m.NumLabels != 1 || m.NumMilestones != 1 ||
+		m.NumIssues != 2 || m.NumComments != 1 || m.NumReleases != 1 {
+		t.Errorf("migration imported %d collaborators, %d labels, %d milestones, %d issues, %d comments, %d releases",
+			m.NumCollaborators, m.NumLabels, m.NumMilestones, m.NumIssues, m.NumComments, m.NumReleases)
+	}
+	// Problems of single items are recorded: comments of issue #1, collaborator and releases.
+	if errs := m.ErrorList(); len(errs) != 4 {
+		t.Errorf("migration has errors %q, expected 4", errs)
+	}
+
+	if has, err := HasAccess(u2.Name, "user1/repo1", AU_WRITABLE); err != nil || !has {
+		t.Errorf("HasAccess(collaborator) = (%v, %v), expected true", has, err)
+	}
+
+	issue, err := GetIssueByIndex(repo.Id, 1)
+	if err != nil {
+		t.Fatalf("GetIssueByIndex(1): %v", err)
+	} else if issue.PosterId != u2.Id || issue.MilestoneId == 0 || strings.Count(issue.LabelIds, "|") != 1 {
+		t.Errorf("issue #1 has poster %d, milestone %d, labels %q", issue.PosterId, issue.MilestoneId, issue.LabelIds)
+	}
+	// Issue of remote user who does not exist is posted by who runs migration.
+	if issue, err = GetIssueByIndex(repo.Id, 2); err != nil {
+		t.Fatalf("GetIssueByIndex(2): %v", err)
+	} else if issue.PosterId != u.Id || !issue.IsClosed || !strings.HasPrefix(issue.Content, "> Originally posted by **ghost**") {
+		t.Errorf("issue #2 has poster %d, closed %v, content %q", issue.PosterId, issue.IsClosed, issue.Content)
+	}
+
+	// Release of tag that does not exist is imported as draft.
+	rels, err := GetReleasesByRepoId(repo.Id)
+	if err != nil {
+		t.Fatalf("GetReleasesByRepoId: %v", err)
+	} else if len(rels) != 1 || !rels[0].IsDraft || rels[0].Title != "v1.0" {
+		t.Errorf("GetReleasesByRepoId = %v, expected draft release v1.0", rels)
+	}
+}
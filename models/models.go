@@ -35,7 +35,18 @@ func init() {
 	tables = append(tables, new(User), new(PublicKey), new(Repository), new(Watch),
 		new(Action), new(Access), new(Issue), new(Comment), new(Oauth2), new(Follow),
 		new(Mirror), new(Release), new(LoginSource), new(Webhook), new(IssueUser),
-		new(Milestone), new(Label))
+		new(Milestone), new(Label), new(TwoFactor), new(AccessToken),
+		new(OauthApplication), new(OauthCode), new(LoginAttempt), new(EmailActivation),
+		new(UserSession), new(GPGKey), new(DeployKey), new(AuditLog),
+		new(RememberToken), new(EmailDomain), new(Attachment), new(PullRequest),
+		new(ProtectedBranch), new(PushMirror), new(Migration),
+		new(RepoTransfer), new(RepoRedirect), new(RepoIndexerStatus),
+		new(Topic), new(CommitStatus), new(RepoStats),
+		new(CommentRevision), new(Notification), new(IssueDependency),
+		new(Project), new(ProjectColumn), new(ProjectCard), new(IssueFilter),
+		new(PullApproval), new(ReviewThread), new(ReviewComment),
+		new(PullReview), new(ReviewRequest), new(IssueSubscription),
+		new(CommitComment), new(HookDelivery))
 }
 
 func LoadModelsConfig() {
@@ -146,7 +157,7 @@ type Statistic struct {
 }
 
 func GetStatistic() (stats Statistic) {
-	stats.Counter.User, _ = orm.Count(new(User))
+	stats.Counter.User, _ = orm.Where("type!=?", UT_BOT).Count(new(User))
 	stats.Counter.PublicKey, _ = orm.Count(new(PublicKey))
 	stats.Counter.Repo, _ = orm.Count(new(Repository))
 	stats.Counter.Watch, _ = orm.Count(new(Watch))
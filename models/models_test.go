@@ -0,0 +1,140 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-xorm/xorm"
+
+	"github.com/gogits/gogs/modules/setting"
+)
+
+// prepareTestEnv sets up database, repository root and attachment paths in a temporary directory,
+// and returns function to restore them. Test is skipped when SQLite3 is not enabled,
+// i.e. run by "go test -tags sqlite".
+func prepareTestEnv(t *testing.T) func() {
+	if !EnableSQLite3 {
+		t.Skip("SQLite3 is not enabled")
+	}
+
+	dir, err := ioutil.TempDir("", "gogs-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := xorm.NewEngine("sqlite3", filepath.Join(dir, "gogs.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	} else if err = x.Sync(tables...); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	oldOrm, oldRepoRootPath, oldAttachmentPath := orm, setting.RepoRootPath, setting.AttachmentPath
+	orm = x
+	setting.RepoRootPath = filepath.Join(dir, "repositories")
+	setting.AttachmentPath = filepath.Join(dir, "attachments")
+	return func() {
+		x.Close()
+		orm, setting.RepoRootPath, setting.AttachmentPath = oldOrm, oldRepoRootPath, oldAttachmentPath
+		os.RemoveAll(dir)
+	}
+}
+
+// newTestUser inserts an active individual user with given name.
+func newTestUser(t *testing.T, name string) *User {
+	u := &User{
+		LowerName:   strings.ToLower(name),
+		Name:        name,
+		Email:       name + "@gogs.io",
+		Passwd:      "-",
+		Type:        UT_INDIVIDUAL,
+		Avatar:      "-",
+		AvatarEmail: name + "@gogs.io",
+		IsActive:    true,
+	}
+	if _, err := orm.Insert(u); err != nil {
+		t.Fatalf("newTestUser(%s): %v", name, err)
+	}
+	return u
+}
+
+// newTestRepo inserts a repository of given owner, and initializes its bare git
+// repository with an initial commit on master branch.
+func newTestRepo(t *testing.T, owner *User, name string) *Repository {
+	repo := &Repository{
+		OwnerId:       owner.Id,
+		Owner:         owner,
+		LowerName:     strings.ToLower(name),
+		Name:          name,
+		DefaultBranch: "master",
+	}
+	if _, err := orm.Insert(repo); err != nil {
+		t.Fatalf("newTestRepo(%s): %v", name, err)
+	}
+
+	repoPath := RepoPath(owner.Name, name)
+	if err := os.MkdirAll(repoPath, os.ModePerm); err != nil {
+		t.Fatalf("newTestRepo(%s): %v", name, err)
+	} else if _, err = execGitCmd(repoPath, nil, nil, "init", "-q", "--bare"); err != nil {
+		t.Fatalf("newTestRepo(%s): %v", name, err)
+	}
+	testCommitFiles(t, repoPath, "master", "", map[string]string{"README.md": "# " + name + "\n"}, "Initial commit")
+	return repo
+}
+
+var testGitEnv = []string{
+	"GIT_AUTHOR_NAME=Gogs", "GIT_AUTHOR_EMAIL=gogs@example.com",
+	"GIT_COMMITTER_NAME=Gogs", "GIT_COMMITTER_EMAIL=gogs@example.com",
+}
+
+// testCommitFiles commits given files to branch of repository and returns ID of new commit,
+// empty content deletes the file. Branch is created from base if it does not exist,
+// or as an orphan if base is empty.
+func testCommitFiles(t *testing.T, repoPath, branch, base string, files map[string]string, message string) string {
+	tmpDir, err := ioutil.TempDir("", "gogs-commit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	git := func(args ...string) string {
+		stdout, err := execGitCmd(tmpDir, testGitEnv, nil, args...)
+		if err != nil {
+			t.Fatalf("testCommitFiles(%s): %v", branch, err)
+		}
+		return stdout
+	}
+	git("init", "-q")
+	git("fetch", "-q", repoPath, "+refs/heads/*:refs/remotes/origin/*")
+	if _, err = execGitCmd(tmpDir, nil, nil, "rev-parse", "--verify", "-q", "origin/"+branch); err == nil {
+		git("checkout", "-q", "-b", branch, "origin/"+branch)
+	} else if len(base) > 0 {
+		git("checkout", "-q", "-b", branch, "origin/"+base)
+	} else {
+		git("checkout", "-q", "--orphan", branch)
+	}
+
+	for name, content := range files {
+		fpath := filepath.Join(tmpDir, name)
+		if len(content) == 0 {
+			err = os.Remove(fpath)
+		} else if err = os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err == nil {
+			err = ioutil.WriteFile(fpath, []byte(content), 0644)
+		}
+		if err != nil {
+			t.Fatalf("testCommitFiles(%s): %v", branch, err)
+		}
+	}
+	git("add", "-A")
+	git("commit", "-q", "-m", message)
+	git("push", "-q", repoPath, "HEAD:refs/heads/"+branch)
+	return git("rev-parse", "HEAD")
+}
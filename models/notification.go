@@ -0,0 +1,317 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Unknwon/com"
+
+	"github.com/gogits/gogs/modules/base"
+)
+
+var (
+	ErrNotificationNotExist = errors.New("Notification does not exist")
+)
+
+// Notification types.
+const (
+	NOTIFY_MENTION = iota + 1
+	NOTIFY_REVIEW_REQUEST
+	NOTIFY_NEW_ISSUE
+	NOTIFY_COMMENT
+	NOTIFY_COMMIT_COMMENT
+)
+
+// Notification represents a web notification of user about an issue, comment or commit comment.
+type Notification struct {
+	Id        int64
+	UserId    int64 `xorm:"INDEX"`
+	Type      int
+	ActUserId int64
+	ActUser   *User `xorm:"-"`
+	RepoId    int64
+	Repo      *Repository `xorm:"-"`
+	IssueId   int64
+	Issue     *Issue `xorm:"-"`
+	CommentId int64
+	CommitId  string
+	IsRead    bool      `xorm:"INDEX NOT NULL DEFAULT false"`
+	Created   time.Time `xorm:"CREATED"`
+	Updated   time.Time `xorm:"UPDATED"` // Changed when notification is marked as read.
+}
+
+// IsCommitComment returns true if notification is about a comment on commit,
+// which has no issue.
+func (n *Notification) IsCommitComment() bool {
+	return n.Type == NOTIFY_COMMIT_COMMENT
+}
+
+// GetAttributes loads actor, repository with its owner and issue of notification.
+func (n *Notification) GetAttributes() (err error) {
+	if n.ActUser, err = GetUserById(n.ActUserId); err != nil {
+		return err
+	} else if n.Repo, err = GetRepositoryById(n.RepoId); err != nil {
+		return err
+	} else if err = n.Repo.GetOwner(); err != nil {
+		return err
+	} else if n.IsCommitComment() {
+		return nil
+	}
+	n.Issue, err = GetIssueById(n.IssueId)
+	return err
+}
+
+// Link returns relative link to issue or comment of notification.
+func (n *Notification) Link() string {
+	if n.IsCommitComment() {
+		return "/" + n.Repo.Owner.Name + "/" + n.Repo.Name + "/commit/" + n.CommitId +
+			"#commit-comment-" + base.ToStr(n.CommentId)
+	}
+	link := "/" + n.Repo.Owner.Name + "/" + n.Repo.Name + "/issues/" + base.ToStr(n.Issue.Index)
+	if n.CommentId > 0 {
+		link += "#issue-comment-" + base.ToStr(n.CommentId)
+	}
+	return link
+}
+
+// GetMentionedUsers returns users mentioned in content who have access to given repository,
+// doer never gets notified by own mentions.
+func GetMentionedUsers(doer *User, repo *Repository, content string) ([]*User, error) {
+	ms := base.MentionPattern.FindAllString(content, -1)
+	if len(ms) == 0 {
+		return nil, nil
+	}
+	if err := repo.GetOwner(); err != nil {
+		return nil, err
+	}
+
+	repoName := repo.Owner.Name + "/" + repo.Name
+	seen := make(map[string]bool)
+	users := make([]*User, 0, len(ms))
+	for _, m := range ms {
+		name := strings.ToLower(m[1:])
+		if seen[name] || name == doer.LowerName {
+			continue
+		}
+		seen[name] = true
+
+		u, err := GetUserByName(name)
+		if err != nil {
+			if err == ErrUserNotExist {
+				continue
+			}
+			return nil, err
+		}
+		if repo.IsPrivate {
+			has, err := HasAccess(u.Name, repoName, AU_READABLE)
+			if err != nil {
+				return nil, err
+			} else if !has {
+				continue
+			}
+		}
+		users = append(users, u)
+	}
+	return users, nil
+}
+
+// NewMentionNotifications creates notifications for users mentioned in issue or comment.
+func NewMentionNotifications(doer *User, repo *Repository, issue *Issue, commentId int64, users []*User) error {
+	for _, u := range users {
+		if _, err := orm.Insert(&Notification{
+			UserId:    u.Id,
+			Type:      NOTIFY_MENTION,
+			ActUserId: doer.Id,
+			RepoId:    repo.Id,
+			IssueId:   issue.Id,
+			CommentId: commentId,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewReviewRequestNotifications creates notifications for users requested to review pull request.
+func NewReviewRequestNotifications(doer *User, repo *Repository, issue *Issue, users []*User) error {
+	for _, u := range users {
+		if _, err := orm.Insert(&Notification{
+			UserId:    u.Id,
+			Type:      NOTIFY_REVIEW_REQUEST,
+			ActUserId: doer.Id,
+			RepoId:    repo.Id,
+			IssueId:   issue.Id,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewSubscriptionNotifications creates notifications of given type for subscribers of issue,
+// except doer and users with given lower names who have been notified otherwise.
+func NewSubscriptionNotifications(tp int, doer *User, repo *Repository, issue *Issue, commentId int64, skips []string) error {
+	users, err := GetIssueSubscribers(repo, issue)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if u.Id == doer.Id || u.IsBot() || com.IsSliceContainsStr(skips, u.LowerName) {
+			continue
+		}
+		if _, err = orm.Insert(&Notification{
+			UserId:    u.Id,
+			Type:      tp,
+			ActUserId: doer.Id,
+			RepoId:    repo.Id,
+			IssueId:   issue.Id,
+			CommentId: commentId,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewCommitCommentNotification creates notification for author of commit about comment on it.
+func NewCommitCommentNotification(doer *User, repo *Repository, c *CommitComment, author *User) error {
+	_, err := orm.Insert(&Notification{
+		UserId:    author.Id,
+		Type:      NOTIFY_COMMIT_COMMENT,
+		ActUserId: doer.Id,
+		RepoId:    repo.Id,
+		CommentId: c.Id,
+		CommitId:  c.CommitId,
+	})
+	return err
+}
+
+// GetNotifications returns notifications of user by given page, unread ones first.
+func GetNotifications(uid int64, page int) ([]*Notification, error) {
+	if page <= 0 {
+		page = 1
+	}
+	ns := make([]*Notification, 0, 20)
+	if err := orm.Limit(20, (page-1)*20).Where("user_id=?", uid).
+		Asc("is_read").Desc("id").Find(&ns); err != nil {
+		return nil, err
+	}
+	return validNotifications(ns)
+}
+
+// validNotifications loads attributes of notifications, those whose actor,
+// repository or issue has been deleted since are skipped.
+func validNotifications(ns []*Notification) ([]*Notification, error) {
+	valid := ns[:0]
+	for _, n := range ns {
+		if err := n.GetAttributes(); err != nil {
+			if err == ErrUserNotExist || err == ErrRepoNotExist || err == ErrIssueNotExist {
+				continue
+			}
+			return nil, err
+		}
+		valid = append(valid, n)
+	}
+	return valid, nil
+}
+
+// NotificationListOptions represents conditions of listing notifications of user.
+type NotificationListOptions struct {
+	UserId   int64
+	All      bool      // Whether read notifications are included.
+	Since    time.Time // Only notifications created or changed since the time when it is not zero.
+	Page     int       // Starts from 1.
+	PageSize int
+}
+
+// ListNotifications returns notifications that match given conditions newest first,
+// and total number of them.
+func ListNotifications(opts *NotificationListOptions) ([]*Notification, int64, error) {
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+
+	cond := "user_id=?"
+	args := []interface{}{opts.UserId}
+	if !opts.All {
+		cond += " AND is_read=?"
+		args = append(args, false)
+	}
+	if !opts.Since.IsZero() {
+		cond += " AND (created>=? OR updated>=?)"
+		args = append(args, opts.Since, opts.Since)
+	}
+
+	total, err := orm.Where(cond, args...).Count(new(Notification))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ns := make([]*Notification, 0, opts.PageSize)
+	if err = orm.Where(cond, args...).Desc("id").
+		Limit(opts.PageSize, (opts.Page-1)*opts.PageSize).Find(&ns); err != nil {
+		return nil, 0, err
+	}
+	ns, err = validNotifications(ns)
+	return ns, total, err
+}
+
+// GetNotificationsModified returns last time that any notification of user
+// is created or changed, or zero time if user has none.
+func GetNotificationsModified(uid int64) (time.Time, error) {
+	var modified time.Time
+	for _, col := range []string{"created", "updated"} {
+		n := new(Notification)
+		has, err := orm.Where("user_id=? AND "+col+" IS NOT NULL", uid).Desc(col).Get(n)
+		if err != nil {
+			return modified, err
+		} else if !has {
+			break
+		}
+		if n.Created.After(modified) {
+			modified = n.Created
+		}
+		if n.Updated.After(modified) {
+			modified = n.Updated
+		}
+	}
+	return modified, nil
+}
+
+// GetNotificationById returns notification of user by given ID.
+func GetNotificationById(uid, id int64) (*Notification, error) {
+	n := new(Notification)
+	has, err := orm.Where("id=? AND user_id=?", id, uid).Get(n)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrNotificationNotExist
+	}
+	return n, nil
+}
+
+// CountUnreadNotifications returns number of unread notifications of user.
+func CountUnreadNotifications(uid int64) int64 {
+	count, _ := orm.Where("user_id=? AND is_read=?", uid, false).Count(new(Notification))
+	return count
+}
+
+// MarkNotificationRead marks notification as read.
+func MarkNotificationRead(n *Notification) error {
+	n.IsRead = true
+	n.Updated = time.Now()
+	_, err := orm.Id(n.Id).Cols("is_read", "updated").Update(n)
+	return err
+}
+
+// MarkAllNotificationsRead marks all notifications of user as read.
+func MarkAllNotificationsRead(uid int64) error {
+	_, err := orm.Where("user_id=? AND is_read=?", uid, false).Cols("is_read", "updated").
+		Update(&Notification{IsRead: true, Updated: time.Now()})
+	return err
+}
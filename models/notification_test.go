@@ -0,0 +1,195 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetMentionedUsers(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2, u3 := newTestUser(t, "user1"), newTestUser(t, "user2"), newTestUser(t, "user3")
+	repo := newTestRepo(t, u1, "repo1")
+	if _, err := orm.Insert(&Access{UserName: "user2", RepoName: "user1/repo1", Mode: AU_READABLE}); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "@user2 @User2 @user3 @user1 @none please review"
+	tests := []struct {
+		isPrivate bool
+		expected  []int64
+	}{
+		{false, []int64{u2.Id, u3.Id}},
+		{true, []int64{u2.Id}},
+	}
+	for _, tt := range tests {
+		repo.IsPrivate = tt.isPrivate
+		users, err := GetMentionedUsers(u1, repo, content)
+		if err != nil {
+			t.Fatalf("GetMentionedUsers: %v", err)
+		}
+		ids := make([]int64, len(users))
+		for i, u := range users {
+			ids[i] = u.Id
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(tt.expected) {
+			t.Errorf("GetMentionedUsers(private %v) = %v, expected %v", tt.isPrivate, ids, tt.expected)
+		}
+	}
+
+	if users, err := GetMentionedUsers(u1, repo, "no mentions"); err != nil || len(users) != 0 {
+		t.Errorf("GetMentionedUsers(no mentions) = (%v, %v), expected none", users, err)
+	}
+}
+
+func TestMentionNotifications(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	repo := newTestRepo(t, u1, "repo1")
+
+	issues := make([]*Issue, 2)
+	for i := range issues {
+		issues[i] = &Issue{RepoId: repo.Id, Index: int64(i + 1), Name: fmt.Sprint("issue ", i+1), PosterId: u1.Id}
+		if err := NewIssue(issues[i]); err != nil {
+			t.Fatalf("NewIssue: %v", err)
+		}
+		if err := NewMentionNotifications(u1, repo, issues[i], 0, []*User{u2}); err != nil {
+			t.Fatalf("NewMentionNotifications: %v", err)
+		}
+	}
+	if err := NewMentionNotifications(u1, repo, issues[0], 7, []*User{u2}); err != nil {
+		t.Fatalf("NewMentionNotifications(comment): %v", err)
+	}
+
+	if n := CountUnreadNotifications(u2.Id); n != 3 {
+		t.Errorf("CountUnreadNotifications = %d, expected 3", n)
+	}
+	ns, err := GetNotifications(u2.Id, 1)
+	if err != nil {
+		t.Fatalf("GetNotifications: %v", err)
+	} else if len(ns) != 3 {
+		t.Fatalf("GetNotifications returns %d notifications, expected 3", len(ns))
+	}
+	if link := ns[0].Link(); link != "/user1/repo1/issues/1#issue-comment-7" {
+		t.Errorf("Link = %q, expected %q", link, "/user1/repo1/issues/1#issue-comment-7")
+	}
+
+	if _, err = GetNotificationById(u1.Id, ns[0].Id); err != ErrNotificationNotExist {
+		t.Errorf("GetNotificationById(other user) error = %v, expected %v", err, ErrNotificationNotExist)
+	}
+	if _, err = GetNotificationById(u2.Id, 0); err != ErrNotificationNotExist {
+		t.Errorf("GetNotificationById(zero ID) error = %v, expected %v", err, ErrNotificationNotExist)
+	}
+	n, err := GetNotificationById(u2.Id, ns[0].Id)
+	if err != nil {
+		t.Fatalf("GetNotificationById: %v", err)
+	} else if err = MarkNotificationRead(n); err != nil {
+		t.Fatalf("MarkNotificationRead: %v", err)
+	}
+
+	// Read notifications are listed last, and those of deleted issues are skipped.
+	if _, err = orm.Id(issues[1].Id).Delete(new(Issue)); err != nil {
+		t.Fatal(err)
+	}
+	if ns, err = GetNotifications(u2.Id, 1); err != nil {
+		t.Fatalf("GetNotifications: %v", err)
+	} else if len(ns) != 2 || ns[0].IsRead || ns[1].Id != n.Id {
+		t.Errorf("GetNotifications returns %d notifications, expected unread one first then %d", len(ns), n.Id)
+	}
+	if count := CountUnreadNotifications(u2.Id); count != 2 {
+		t.Errorf("CountUnreadNotifications = %d, expected 2", count)
+	}
+}
+
+func TestListNotifications(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	repo := newTestRepo(t, u1, "repo1")
+
+	for i := 1; i <= 3; i++ {
+		issue := &Issue{RepoId: repo.Id, Index: int64(i), Name: fmt.Sprint("issue ", i), PosterId: u1.Id}
+		if err := NewIssue(issue); err != nil {
+			t.Fatalf("NewIssue: %v", err)
+		}
+		if err := NewMentionNotifications(u1, repo, issue, 0, []*User{u2}); err != nil {
+			t.Fatalf("NewMentionNotifications: %v", err)
+		}
+	}
+	old := time.Now().Add(-time.Hour)
+	if _, err := orm.Exec("UPDATE notification SET created=?, updated=?", old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if modified, err := GetNotificationsModified(u1.Id); err != nil || !modified.IsZero() {
+		t.Errorf("GetNotificationsModified(no notification) = (%v, %v), expected zero time", modified, err)
+	}
+	before, err := GetNotificationsModified(u2.Id)
+	if err != nil || before.IsZero() {
+		t.Fatalf("GetNotificationsModified = (%v, %v), expected time of notifications", before, err)
+	}
+
+	list := func(opts *NotificationListOptions) ([]int64, int64) {
+		opts.UserId = u2.Id
+		if opts.PageSize == 0 {
+			opts.PageSize = 10
+		}
+		ns, total, err := ListNotifications(opts)
+		if err != nil {
+			t.Fatalf("ListNotifications: %v", err)
+		}
+		indexes := make([]int64, len(ns))
+		for i := range ns {
+			indexes[i] = ns[i].Issue.Index
+		}
+		return indexes, total
+	}
+
+	indexes, total := list(&NotificationListOptions{})
+	if !reflect.DeepEqual(indexes, []int64{3, 2, 1}) || total != 3 {
+		t.Fatalf("ListNotifications = (%v, %d), expected ([3 2 1], 3)", indexes, total)
+	}
+	ns, _, err := ListNotifications(&NotificationListOptions{UserId: u2.Id, PageSize: 1, Page: 3})
+	if err != nil || len(ns) != 1 {
+		t.Fatalf("ListNotifications(page 3) = (%d, %v), expected 1 notification", len(ns), err)
+	} else if err = MarkNotificationRead(ns[0]); err != nil {
+		t.Fatalf("MarkNotificationRead: %v", err)
+	}
+
+	since := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name     string
+		opts     *NotificationListOptions
+		expected []int64
+		total    int64
+	}{
+		{"unread", &NotificationListOptions{}, []int64{3, 2}, 2},
+		{"all", &NotificationListOptions{All: true}, []int64{3, 2, 1}, 3},
+		{"page", &NotificationListOptions{All: true, Page: 2, PageSize: 2}, []int64{1}, 3},
+		{"since", &NotificationListOptions{All: true, Since: since}, []int64{1}, 1},
+		{"unread since", &NotificationListOptions{Since: since}, []int64{}, 0},
+	}
+	for _, tt := range tests {
+		if indexes, total := list(tt.opts); !reflect.DeepEqual(indexes, tt.expected) || total != tt.total {
+			t.Errorf("ListNotifications(%s) = (%v, %d), expected (%v, %d)", tt.name, indexes, total, tt.expected, tt.total)
+		}
+	}
+
+	// Marking notifications as read changes last modified time.
+	if after, err := GetNotificationsModified(u2.Id); err != nil || !after.After(before) {
+		t.Errorf("GetNotificationsModified = (%v, %v), expected after %v", after, err, before)
+	}
+	if err = MarkAllNotificationsRead(u2.Id); err != nil {
+		t.Fatalf("MarkAllNotificationsRead: %v", err)
+	}
+	if indexes, total := list(&NotificationListOptions{All: true, Since: since}); len(indexes) != 3 || total != 3 {
+		t.Errorf("ListNotifications(since) = (%v, %d) after marking all read, expected 3 notifications", indexes, total)
+	}
+	if count := CountUnreadNotifications(u2.Id); count != 0 {
+		t.Errorf("CountUnreadNotifications = %d, expected 0", count)
+	}
+}
@@ -81,6 +81,20 @@ func DeleteOauth2ById(id int64) error {
 	return err
 }
 
+// DeleteUserOauth2ById deletes a oauth2 of given user by ID.
+func DeleteUserOauth2ById(uid, id int64) error {
+	if id <= 0 {
+		return ErrOauth2RecordNotExist
+	}
+	affected, err := orm.Where("id=? AND uid=?", id, uid).Delete(new(Oauth2))
+	if err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrOauth2RecordNotExist
+	}
+	return nil
+}
+
 // CleanUnbindOauth deletes all unbind OAuthes.
 func CleanUnbindOauth() error {
 	_, err := orm.Delete(&Oauth2{Uid: -1})
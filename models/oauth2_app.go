@@ -0,0 +1,230 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Unknwon/com"
+
+	"github.com/gogits/gogs/modules/base"
+)
+
+var (
+	ErrOauthAppNotExist     = errors.New("OAuth application does not exist")
+	ErrOauthCodeNotExist    = errors.New("OAuth authorization code does not exist")
+	ErrOauthRedirectInvalid = errors.New("OAuth redirect URI is not registered")
+	ErrOauthScopeInvalid    = errors.New("OAuth scope is invalid")
+)
+
+// Authorization code lives for 10 minutes.
+const OAUTH_CODE_EXPIRES = 10 * time.Minute
+
+// Access scopes that a token can be granted.
+const (
+	SCOPE_REPO_READ  = "repo:read"
+	SCOPE_REPO_WRITE = "repo:write"
+	SCOPE_USER       = "user"
+	SCOPE_ADMIN      = "admin"
+)
+
+var AccessScopes = []string{SCOPE_REPO_READ, SCOPE_REPO_WRITE, SCOPE_USER, SCOPE_ADMIN}
+
+// ParseScopes parses space or comma separated scopes, it returns
+// normalized scopes string or error if any of scopes is unknown.
+func ParseScopes(scope string) (string, error) {
+	fields := strings.FieldsFunc(scope, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+	scopes := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if !com.IsSliceContainsStr(AccessScopes, f) {
+			return "", ErrOauthScopeInvalid
+		}
+		if !com.IsSliceContainsStr(scopes, f) {
+			scopes = append(scopes, f)
+		}
+	}
+	return strings.Join(scopes, ","), nil
+}
+
+// OauthApplication represents an OAuth2 application registered by user,
+// that can request access tokens on behalf of other users.
+type OauthApplication struct {
+	Id           int64
+	Uid          int64  `xorm:"INDEX"`
+	Name         string `xorm:"NOT NULL"`
+	Website      string
+	ClientId     string    `xorm:"UNIQUE VARCHAR(40)"`
+	ClientSecret string    `xorm:"VARCHAR(40)"`
+	RedirectUris string    `xorm:"TEXT"` // One per line.
+	Created      time.Time `xorm:"CREATED"`
+	Updated      time.Time `xorm:"UPDATED"`
+}
+
+// IsValidRedirectUri returns true if given URI is registered by application.
+func (app *OauthApplication) IsValidRedirectUri(uri string) bool {
+	for _, u := range strings.Split(app.RedirectUris, "\n") {
+		if u = strings.TrimSpace(u); len(u) > 0 && u == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultRedirectUri returns the first registered redirect URI.
+func (app *OauthApplication) DefaultRedirectUri() string {
+	return strings.TrimSpace(strings.SplitN(app.RedirectUris, "\n", 2)[0])
+}
+
+// NewOauthApplication creates a new OAuth application with generated client ID and secret.
+func NewOauthApplication(app *OauthApplication) error {
+	app.ClientId = base.EncodeSha1(base.GetRandomString(40))[:20]
+	app.ClientSecret = base.EncodeSha1(base.GetRandomString(40))
+	_, err := orm.Insert(app)
+	return err
+}
+
+// GetOauthApplicationById returns OAuth application by given ID.
+func GetOauthApplicationById(id int64) (*OauthApplication, error) {
+	app := new(OauthApplication)
+	has, err := orm.Id(id).Get(app)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrOauthAppNotExist
+	}
+	return app, nil
+}
+
+// GetOauthApplicationByClientId returns OAuth application by given client ID.
+func GetOauthApplicationByClientId(clientId string) (*OauthApplication, error) {
+	if len(clientId) == 0 {
+		return nil, ErrOauthAppNotExist
+	}
+
+	app := &OauthApplication{ClientId: clientId}
+	has, err := orm.Get(app)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrOauthAppNotExist
+	}
+	return app, nil
+}
+
+// GetOauthApplicationsByUid returns all OAuth applications registered by given user.
+func GetOauthApplicationsByUid(uid int64) ([]*OauthApplication, error) {
+	apps := make([]*OauthApplication, 0, 5)
+	err := orm.Where("uid=?", uid).Find(&apps)
+	return apps, err
+}
+
+// UpdateOauthApplication updates information of OAuth application.
+func UpdateOauthApplication(app *OauthApplication) error {
+	_, err := orm.Id(app.Id).AllCols().Update(app)
+	return err
+}
+
+// DeleteOauthApplication deletes OAuth application of given user
+// and all access tokens issued by it.
+func DeleteOauthApplication(uid, id int64) error {
+	app, err := GetOauthApplicationById(id)
+	if err != nil {
+		return err
+	} else if app.Uid != uid {
+		return ErrOauthAppNotExist
+	}
+
+	sess := orm.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err = sess.Delete(&OauthCode{AppId: id}); err != nil {
+		sess.Rollback()
+		return err
+	} else if _, err = sess.Delete(&AccessToken{AppId: id}); err != nil {
+		sess.Rollback()
+		return err
+	} else if _, err = sess.Delete(&OauthApplication{Id: id}); err != nil {
+		sess.Rollback()
+		return err
+	}
+	return sess.Commit()
+}
+
+// OauthCode represents an authorization code granted by user to OAuth application.
+type OauthCode struct {
+	Id          int64
+	AppId       int64  `xorm:"INDEX"`
+	Uid         int64  `xorm:"INDEX"`
+	Code        string `xorm:"UNIQUE VARCHAR(40)"`
+	RedirectUri string `xorm:"TEXT"`
+	Scope       string
+	Created     time.Time `xorm:"CREATED"`
+}
+
+// IsExpired returns true if authorization code cannot be exchanged anymore.
+func (c *OauthCode) IsExpired() bool {
+	return c.Created.Add(OAUTH_CODE_EXPIRES).Before(time.Now())
+}
+
+// NewOauthCode creates a new authorization code of given user for OAuth application.
+func NewOauthCode(app *OauthApplication, uid int64, redirectUri, scope string) (*OauthCode, error) {
+	c := &OauthCode{
+		AppId:       app.Id,
+		Uid:         uid,
+		Code:        base.EncodeSha1(base.GetRandomString(40)),
+		RedirectUri: redirectUri,
+		Scope:       scope,
+	}
+	_, err := orm.Insert(c)
+	return c, err
+}
+
+// ExchangeOauthCode exchanges authorization code to an access token,
+// authorization code can be used only once.
+func ExchangeOauthCode(app *OauthApplication, code, redirectUri string) (*AccessToken, error) {
+	if len(code) == 0 {
+		return nil, ErrOauthCodeNotExist
+	}
+
+	c := &OauthCode{Code: code}
+	has, err := orm.Get(c)
+	if err != nil {
+		return nil, err
+	} else if !has || c.AppId != app.Id {
+		return nil, ErrOauthCodeNotExist
+	}
+
+	// Only the request that deletes the code can exchange it, concurrent ones fail.
+	affected, err := orm.Id(c.Id).Delete(new(OauthCode))
+	if err != nil {
+		return nil, err
+	} else if affected != 1 {
+		return nil, ErrOauthCodeNotExist
+	}
+
+	if c.IsExpired() {
+		return nil, ErrOauthCodeNotExist
+	} else if len(redirectUri) > 0 && redirectUri != c.RedirectUri {
+		return nil, ErrOauthRedirectInvalid
+	}
+
+	t := &AccessToken{
+		Uid:   c.Uid,
+		AppId: app.Id,
+		Name:  app.Name,
+		Scope: c.Scope,
+	}
+	if err = NewAccessToken(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
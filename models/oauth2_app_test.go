@@ -0,0 +1,114 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+var parseScopesTests = []struct {
+	scope, expected string
+	err             error
+}{
+	{"", "", nil},
+	{"user", "user", nil},
+	{"repo:read user", "repo:read,user", nil},
+	{"repo:read, admin", "repo:read,admin", nil},
+	{"user,user", "user", nil},
+	{"user repo", "", ErrOauthScopeInvalid},
+}
+
+func TestParseScopes(t *testing.T) {
+	for _, tt := range parseScopesTests {
+		scope, err := ParseScopes(tt.scope)
+		if scope != tt.expected || err != tt.err {
+			t.Errorf("ParseScopes(%q) = (%q, %v), expected (%q, %v)", tt.scope, scope, err, tt.expected, tt.err)
+		}
+	}
+}
+
+func TestIsValidRedirectUri(t *testing.T) {
+	app := &OauthApplication{RedirectUris: "https://example.com/callback\r\n  http://localhost:8080/cb \n\n"}
+	tests := []struct {
+		uri      string
+		expected bool
+	}{
+		{"https://example.com/callback", true},
+		{"http://localhost:8080/cb", true},
+		{"https://example.com/callback/", false},
+		{"https://example.com", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if ok := app.IsValidRedirectUri(tt.uri); ok != tt.expected {
+			t.Errorf("IsValidRedirectUri(%q) = %v, expected %v", tt.uri, ok, tt.expected)
+		}
+	}
+	if uri := app.DefaultRedirectUri(); uri != "https://example.com/callback" {
+		t.Errorf("DefaultRedirectUri() = %q, expected %q", uri, "https://example.com/callback")
+	}
+}
+
+func TestExchangeOauthCode(t *testing.T) {
+	defer prepareTestEnv(t)()
+	dev, u := newTestUser(t, "dev"), newTestUser(t, "user1")
+
+	app := &OauthApplication{Uid: dev.Id, Name: "CI", RedirectUris: "https://example.com/cb"}
+	other := &OauthApplication{Uid: dev.Id, Name: "Other", RedirectUris: "https://example.com/cb"}
+	for _, a := range []*OauthApplication{app, other} {
+		if err := NewOauthApplication(a); err != nil {
+			t.Fatalf("NewOauthApplication: %v", err)
+		}
+	}
+
+	c, err := NewOauthCode(app, u.Id, "https://example.com/cb", SCOPE_REPO_READ)
+	if err != nil {
+		t.Fatalf("NewOauthCode: %v", err)
+	}
+	if _, err = ExchangeOauthCode(other, c.Code, ""); err != ErrOauthCodeNotExist {
+		t.Errorf("ExchangeOauthCode(other application) error = %v, expected %v", err, ErrOauthCodeNotExist)
+	}
+
+	token, err := ExchangeOauthCode(app, c.Code, "https://example.com/cb")
+	if err != nil {
+		t.Fatalf("ExchangeOauthCode: %v", err)
+	} else if token.Uid != u.Id || token.AppId != app.Id || token.Scope != SCOPE_REPO_READ {
+		t.Errorf("token is issued to user %d, application %d with scope %q, expected %d, %d, %q",
+			token.Uid, token.AppId, token.Scope, u.Id, app.Id, SCOPE_REPO_READ)
+	}
+	if _, err = ExchangeOauthCode(app, c.Code, "https://example.com/cb"); err != ErrOauthCodeNotExist {
+		t.Errorf("ExchangeOauthCode(used code) error = %v, expected %v", err, ErrOauthCodeNotExist)
+	}
+
+	// Code is consumed even if redirect URI mismatches.
+	if c, err = NewOauthCode(app, u.Id, "https://example.com/cb", ""); err != nil {
+		t.Fatalf("NewOauthCode: %v", err)
+	}
+	if _, err = ExchangeOauthCode(app, c.Code, "https://example.com/evil"); err != ErrOauthRedirectInvalid {
+		t.Errorf("ExchangeOauthCode(wrong redirect URI) error = %v, expected %v", err, ErrOauthRedirectInvalid)
+	}
+	if _, err = ExchangeOauthCode(app, c.Code, ""); err != ErrOauthCodeNotExist {
+		t.Errorf("ExchangeOauthCode(consumed code) error = %v, expected %v", err, ErrOauthCodeNotExist)
+	}
+
+	if c, err = NewOauthCode(app, u.Id, "", ""); err != nil {
+		t.Fatalf("NewOauthCode: %v", err)
+	}
+	if _, err = orm.Exec("UPDATE oauth_code SET created=? WHERE id=?",
+		time.Now().Add(-OAUTH_CODE_EXPIRES-time.Minute), c.Id); err != nil {
+		t.Fatalf("expire code: %v", err)
+	}
+	if _, err = ExchangeOauthCode(app, c.Code, ""); err != ErrOauthCodeNotExist {
+		t.Errorf("ExchangeOauthCode(expired code) error = %v, expected %v", err, ErrOauthCodeNotExist)
+	}
+
+	tokens, err := ListOauthAccessTokens(u.Id)
+	if err != nil {
+		t.Fatalf("ListOauthAccessTokens: %v", err)
+	} else if len(tokens) != 1 {
+		t.Errorf("ListOauthAccessTokens returns %d tokens, expected 1", len(tokens))
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestDeleteUserOauth2ById(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+
+	oa1 := &Oauth2{Uid: u1.Id, Type: OT_GITHUB, Identity: "1001", Token: "{}"}
+	oa2 := &Oauth2{Uid: u1.Id, Type: OT_GOOGLE, Identity: "1002", Token: "{}"}
+	for _, oa := range []*Oauth2{oa1, oa2} {
+		if err := AddOauth2(oa); err != nil {
+			t.Fatalf("AddOauth2: %v", err)
+		}
+	}
+
+	if err := DeleteUserOauth2ById(u2.Id, oa1.Id); err != ErrOauth2RecordNotExist {
+		t.Errorf("DeleteUserOauth2ById(other user) error = %v, expected %v", err, ErrOauth2RecordNotExist)
+	}
+	if err := DeleteUserOauth2ById(u1.Id, 0); err != ErrOauth2RecordNotExist {
+		t.Errorf("DeleteUserOauth2ById(zero ID) error = %v, expected %v", err, ErrOauth2RecordNotExist)
+	}
+	if err := DeleteUserOauth2ById(u1.Id, oa1.Id); err != nil {
+		t.Fatalf("DeleteUserOauth2ById: %v", err)
+	}
+
+	oas, err := GetOauthByUserId(u1.Id)
+	if err != nil {
+		t.Fatalf("GetOauthByUserId: %v", err)
+	} else if len(oas) != 1 || oas[0].Id != oa2.Id {
+		t.Errorf("GetOauthByUserId returns %d records, expected only %d", len(oas), oa2.Id)
+	}
+
+	oa, err := GetOauth2("1002")
+	if err != nil || oa.User.Id != u1.Id {
+		t.Errorf("GetOauth2 = (%v, %v), expected bound to user %d", oa, err, u1.Id)
+	}
+}
@@ -0,0 +1,394 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrProjectNotExist       = errors.New("Project does not exist")
+	ErrProjectColumnNotExist = errors.New("Project column does not exist")
+	ErrProjectCardNotExist   = errors.New("Project card does not exist")
+	ErrProjectCardExist      = errors.New("Issue is already in project")
+)
+
+// States that issues of cards moved into a column are changed to.
+const (
+	PROJECT_STATE_NONE = iota
+	PROJECT_STATE_OPEN
+	PROJECT_STATE_CLOSED
+)
+
+// Project represents a kanban board of repository.
+type Project struct {
+	Id          int64
+	RepoId      int64 `xorm:"INDEX"`
+	Name        string
+	Description string           `xorm:"TEXT"`
+	IsClosed    bool             `xorm:"NOT NULL DEFAULT false"`
+	Columns     []*ProjectColumn `xorm:"-"`
+	NumCards    int              `xorm:"-"`
+	Created     time.Time        `xorm:"CREATED"`
+	Updated     time.Time        `xorm:"UPDATED"`
+}
+
+// ProjectColumn represents a column of project board.
+// Issues get label of column when their cards are moved into it, and issues
+// labeled with it are added to the column automatically.
+type ProjectColumn struct {
+	Id         int64
+	ProjectId  int64 `xorm:"INDEX"`
+	Name       string
+	Sorting    int
+	LabelId    int64
+	Label      *Label `xorm:"-"`
+	IssueState int
+	Cards      []*ProjectCard `xorm:"-"`
+}
+
+// ProjectCard represents a card of issue or a plain note in project column.
+type ProjectCard struct {
+	Id        int64
+	ProjectId int64  `xorm:"INDEX"`
+	ColumnId  int64  `xorm:"INDEX"`
+	IssueId   int64  `xorm:"INDEX"` // 0 for note card.
+	Issue     *Issue `xorm:"-"`
+	Note      string `xorm:"TEXT"`
+	Sorting   int
+	Created   time.Time `xorm:"CREATED"`
+}
+
+// NewProject creates new project with default columns.
+func NewProject(p *Project) (err error) {
+	sess := orm.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err = sess.Insert(p); err != nil {
+		sess.Rollback()
+		return err
+	}
+
+	columns := []*ProjectColumn{
+		{ProjectId: p.Id, Name: "To do", Sorting: 1, IssueState: PROJECT_STATE_NONE},
+		{ProjectId: p.Id, Name: "In progress", Sorting: 2, IssueState: PROJECT_STATE_OPEN},
+		{ProjectId: p.Id, Name: "Done", Sorting: 3, IssueState: PROJECT_STATE_CLOSED},
+	}
+	for _, c := range columns {
+		if _, err = sess.Insert(c); err != nil {
+			sess.Rollback()
+			return err
+		}
+	}
+	return sess.Commit()
+}
+
+// GetProjectById returns project of repository by given ID.
+func GetProjectById(repoId, id int64) (*Project, error) {
+	p := new(Project)
+	has, err := orm.Where("id=? AND repo_id=?", id, repoId).Get(p)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectNotExist
+	}
+	return p, nil
+}
+
+// GetProjects returns projects of repository by given status.
+func GetProjects(repoId int64, isClosed bool) ([]*Project, error) {
+	projects := make([]*Project, 0, 5)
+	if err := orm.Where("repo_id=?", repoId).And("is_closed=?", isClosed).Desc("id").Find(&projects); err != nil {
+		return nil, err
+	}
+	for _, p := range projects {
+		count, err := orm.Where("project_id=?", p.Id).Count(new(ProjectCard))
+		if err != nil {
+			return nil, err
+		}
+		p.NumCards = int(count)
+	}
+	return projects, nil
+}
+
+// CountProjects returns number of projects of repository by given status.
+func CountProjects(repoId int64, isClosed bool) int64 {
+	count, _ := orm.Where("repo_id=?", repoId).And("is_closed=?", isClosed).Count(new(Project))
+	return count
+}
+
+// UpdateProject updates information of given project.
+func UpdateProject(p *Project) error {
+	_, err := orm.Id(p.Id).AllCols().Update(p)
+	return err
+}
+
+// DeleteProject deletes project with all its columns and cards.
+func DeleteProject(p *Project) (err error) {
+	sess := orm.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err = sess.Delete(&ProjectCard{ProjectId: p.Id}); err != nil {
+		sess.Rollback()
+		return err
+	} else if _, err = sess.Delete(&ProjectColumn{ProjectId: p.Id}); err != nil {
+		sess.Rollback()
+		return err
+	} else if _, err = sess.Delete(&Project{Id: p.Id}); err != nil {
+		sess.Rollback()
+		return err
+	}
+	return sess.Commit()
+}
+
+// DeleteRepoProjects deletes all projects of repository.
+func DeleteRepoProjects(repoId int64) error {
+	projects := make([]*Project, 0, 5)
+	if err := orm.Where("repo_id=?", repoId).Find(&projects); err != nil {
+		return err
+	}
+	for _, p := range projects {
+		if err := DeleteProject(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetColumns loads columns of project with their cards in order,
+// cards of deleted issues are skipped.
+func (p *Project) GetColumns() error {
+	p.Columns = make([]*ProjectColumn, 0, 5)
+	if err := orm.Where("project_id=?", p.Id).Asc("sorting").Asc("id").Find(&p.Columns); err != nil {
+		return err
+	}
+
+	cards := make([]*ProjectCard, 0, 20)
+	if err := orm.Where("project_id=?", p.Id).Asc("sorting").Asc("id").Find(&cards); err != nil {
+		return err
+	}
+	columns := make(map[int64]*ProjectColumn, len(p.Columns))
+	for _, c := range p.Columns {
+		columns[c.Id] = c
+		if c.LabelId > 0 {
+			var err error
+			if c.Label, err = GetLabelById(c.LabelId); err != nil && err != ErrLabelNotExist {
+				return err
+			}
+		}
+	}
+
+	var err error
+	for _, card := range cards {
+		c, ok := columns[card.ColumnId]
+		if !ok {
+			continue
+		}
+		if card.IssueId > 0 {
+			if card.Issue, err = GetIssueById(card.IssueId); err != nil {
+				if err == ErrIssueNotExist {
+					continue
+				}
+				return err
+			} else if err = card.Issue.GetLabels(); err != nil {
+				return err
+			} else if err = card.Issue.GetAssignees(); err != nil {
+				return err
+			}
+		}
+		c.Cards = append(c.Cards, card)
+	}
+	return nil
+}
+
+// NewProjectColumn adds new column at the end of project.
+func NewProjectColumn(c *ProjectColumn) error {
+	last := new(ProjectColumn)
+	has, err := orm.Where("project_id=?", c.ProjectId).Desc("sorting").Get(last)
+	if err != nil {
+		return err
+	} else if has {
+		c.Sorting = last.Sorting + 1
+	}
+	_, err = orm.Insert(c)
+	return err
+}
+
+// GetProjectColumnById returns column of project by given ID.
+func GetProjectColumnById(projectId, id int64) (*ProjectColumn, error) {
+	c := new(ProjectColumn)
+	has, err := orm.Where("id=? AND project_id=?", id, projectId).Get(c)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectColumnNotExist
+	}
+	return c, nil
+}
+
+// UpdateProjectColumn updates information of given column.
+func UpdateProjectColumn(c *ProjectColumn) error {
+	_, err := orm.Id(c.Id).AllCols().Update(c)
+	return err
+}
+
+// DeleteProjectColumn deletes column with all its cards, issues are not affected.
+func DeleteProjectColumn(c *ProjectColumn) (err error) {
+	sess := orm.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err = sess.Delete(&ProjectCard{ColumnId: c.Id}); err != nil {
+		sess.Rollback()
+		return err
+	} else if _, err = sess.Delete(&ProjectColumn{Id: c.Id}); err != nil {
+		sess.Rollback()
+		return err
+	}
+	return sess.Commit()
+}
+
+// NewProjectCard adds new card at the end of column, an issue can only have one card in a project.
+func NewProjectCard(card *ProjectCard) error {
+	if card.IssueId > 0 {
+		has, err := orm.Get(&ProjectCard{ProjectId: card.ProjectId, IssueId: card.IssueId})
+		if err != nil {
+			return err
+		} else if has {
+			return ErrProjectCardExist
+		}
+	}
+
+	last := new(ProjectCard)
+	has, err := orm.Where("column_id=?", card.ColumnId).Desc("sorting").Get(last)
+	if err != nil {
+		return err
+	} else if has {
+		card.Sorting = last.Sorting + 1
+	}
+	_, err = orm.Insert(card)
+	return err
+}
+
+// GetProjectCardById returns card of project by given ID.
+func GetProjectCardById(projectId, id int64) (*ProjectCard, error) {
+	card := new(ProjectCard)
+	has, err := orm.Where("id=? AND project_id=?", id, projectId).Get(card)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectCardNotExist
+	}
+	return card, nil
+}
+
+// DeleteProjectCard deletes card from project, issue of card is not affected.
+func DeleteProjectCard(card *ProjectCard) error {
+	_, err := orm.Delete(&ProjectCard{Id: card.Id})
+	return err
+}
+
+// MoveProjectCard moves card into given column and saves order of cards in column
+// as given card IDs, then applies label and state rules of column to issue of card.
+func MoveProjectCard(doer *User, repo *Repository, card *ProjectCard, column *ProjectColumn, cardIds []int64) (err error) {
+	sess := orm.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	card.ColumnId = column.Id
+	if _, err = sess.Id(card.Id).Cols("column_id").Update(card); err != nil {
+		sess.Rollback()
+		return err
+	}
+	for i, id := range cardIds {
+		if _, err = sess.Where("id=? AND column_id=?", id, column.Id).Cols("sorting").
+			Update(&ProjectCard{Sorting: i + 1}); err != nil {
+			sess.Rollback()
+			return err
+		}
+	}
+	if err = sess.Commit(); err != nil {
+		return err
+	}
+
+	if card.IssueId == 0 {
+		return nil
+	}
+	issue, err := GetIssueById(card.IssueId)
+	if err != nil {
+		if err == ErrIssueNotExist {
+			return nil
+		}
+		return err
+	}
+
+	if column.LabelId > 0 {
+		label, err := GetRepoLabelById(repo.Id, column.LabelId)
+		if err == nil {
+			if !issue.HasLabel(label.Id) {
+				if err = ChangeIssueLabel(issue, label, true); err != nil {
+					return err
+				} else if err = CreateLabelEvent(doer, issue, label, true); err != nil {
+					return err
+				}
+			}
+		} else if err != ErrLabelNotExist {
+			return err
+		}
+	}
+
+	switch column.IssueState {
+	case PROJECT_STATE_OPEN:
+		if issue.IsClosed {
+			return ChangeIssueStatus(doer, issue, false)
+		}
+	case PROJECT_STATE_CLOSED:
+		if !issue.IsClosed {
+			if repo.BlockOnDependencies {
+				count, err := CountOpenBlockingIssues(issue.Id)
+				if err != nil {
+					return err
+				} else if count > 0 {
+					return nil
+				}
+			}
+			return ChangeIssueStatus(doer, issue, true)
+		}
+	}
+	return nil
+}
+
+// AddIssueToProjectsByLabel adds card of issue to every column of open projects that
+// collects issues with given label, unless issue is already in the project.
+func AddIssueToProjectsByLabel(issue *Issue, labelId int64) error {
+	columns := make([]*ProjectColumn, 0, 2)
+	if err := orm.Where("label_id=?", labelId).
+		And("project_id IN (SELECT id FROM project WHERE repo_id=? AND is_closed=?)", issue.RepoId, false).
+		Find(&columns); err != nil {
+		return err
+	}
+
+	for _, c := range columns {
+		if err := NewProjectCard(&ProjectCard{
+			ProjectId: c.ProjectId,
+			ColumnId:  c.Id,
+			IssueId:   issue.Id,
+		}); err != nil && err != ErrProjectCardExist {
+			return err
+		}
+	}
+	return nil
+}
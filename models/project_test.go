@@ -0,0 +1,133 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestProjects(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo, other := newTestRepo(t, u, "repo1"), newTestRepo(t, u, "other")
+
+	p := &Project{RepoId: repo.Id, Name: "Roadmap"}
+	if err := NewProject(p); err != nil {
+		t.Fatalf("NewProject: %v", err)
+	}
+	for _, ids := range [][2]int64{{other.Id, p.Id}, {repo.Id, 0}} {
+		if _, err := GetProjectById(ids[0], ids[1]); err != ErrProjectNotExist {
+			t.Errorf("GetProjectById(%d, %d) error = %v, expected %v", ids[0], ids[1], err, ErrProjectNotExist)
+		}
+	}
+	if err := p.GetColumns(); err != nil {
+		t.Fatalf("GetColumns: %v", err)
+	} else if len(p.Columns) != 3 {
+		t.Fatalf("new project has %d columns, expected 3", len(p.Columns))
+	}
+	todo, inProgress, done := p.Columns[0], p.Columns[1], p.Columns[2]
+
+	label := &Label{RepoId: repo.Id, Name: "review", Color: "#fbca04"}
+	if err := NewLabel(label); err != nil {
+		t.Fatalf("NewLabel: %v", err)
+	}
+	review := &ProjectColumn{ProjectId: p.Id, Name: "Review", LabelId: label.Id}
+	if err := NewProjectColumn(review); err != nil {
+		t.Fatalf("NewProjectColumn: %v", err)
+	} else if review.Sorting != 4 {
+		t.Errorf("new column has sorting %d, expected 4", review.Sorting)
+	}
+	if _, err := GetProjectColumnById(p.Id+1, review.Id); err != ErrProjectColumnNotExist {
+		t.Errorf("GetProjectColumnById(other project) error = %v, expected %v", err, ErrProjectColumnNotExist)
+	}
+
+	issues := make([]*Issue, 2)
+	for i := range issues {
+		issues[i] = &Issue{RepoId: repo.Id, Index: int64(i + 1), Name: "issue", PosterId: u.Id}
+		if err := NewIssue(issues[i]); err != nil {
+			t.Fatalf("NewIssue: %v", err)
+		}
+	}
+
+	card := &ProjectCard{ProjectId: p.Id, ColumnId: todo.Id, IssueId: issues[0].Id}
+	if err := NewProjectCard(card); err != nil {
+		t.Fatalf("NewProjectCard: %v", err)
+	}
+	note := &ProjectCard{ProjectId: p.Id, ColumnId: todo.Id, Note: "Write release notes"}
+	if err := NewProjectCard(note); err != nil {
+		t.Fatalf("NewProjectCard(note): %v", err)
+	} else if note.Sorting != card.Sorting+1 {
+		t.Errorf("new card has sorting %d, expected %d", note.Sorting, card.Sorting+1)
+	}
+	if err := NewProjectCard(&ProjectCard{ProjectId: p.Id, ColumnId: done.Id, IssueId: issues[0].Id}); err != ErrProjectCardExist {
+		t.Errorf("NewProjectCard(same issue) error = %v, expected %v", err, ErrProjectCardExist)
+	}
+	if _, err := GetProjectCardById(p.Id, 0); err != ErrProjectCardNotExist {
+		t.Errorf("GetProjectCardById(zero ID) error = %v, expected %v", err, ErrProjectCardNotExist)
+	}
+
+	issueOf := func(card *ProjectCard) *Issue {
+		issue, err := GetIssueById(card.IssueId)
+		if err != nil {
+			t.Fatalf("GetIssueById: %v", err)
+		}
+		return issue
+	}
+	if err := MoveProjectCard(u, repo, card, done, []int64{card.Id}); err != nil {
+		t.Fatalf("MoveProjectCard(done): %v", err)
+	} else if !issueOf(card).IsClosed {
+		t.Error("issue is not closed after moving card into done column")
+	}
+	if err := MoveProjectCard(u, repo, card, inProgress, []int64{card.Id}); err != nil {
+		t.Fatalf("MoveProjectCard(in progress): %v", err)
+	} else if issueOf(card).IsClosed {
+		t.Error("issue is not reopened after moving card into in progress column")
+	}
+	if err := MoveProjectCard(u, repo, card, review, []int64{card.Id}); err != nil {
+		t.Fatalf("MoveProjectCard(review): %v", err)
+	} else if !issueOf(card).HasLabel(label.Id) {
+		t.Error("issue does not get label of column after moving card into it")
+	}
+
+	// Issue labeled with label of column is added after existing cards once.
+	for i := 0; i < 2; i++ {
+		if err := AddIssueToProjectsByLabel(issues[1], label.Id); err != nil {
+			t.Fatalf("AddIssueToProjectsByLabel: %v", err)
+		}
+	}
+	if err := p.GetColumns(); err != nil {
+		t.Fatalf("GetColumns: %v", err)
+	}
+	expected := [][]int64{{note.Id}, nil, nil, {card.Id, 0}}
+	for i, c := range p.Columns {
+		if len(c.Cards) != len(expected[i]) {
+			t.Errorf("column %q has %d cards, expected %d", c.Name, len(c.Cards), len(expected[i]))
+			continue
+		}
+		for j, id := range expected[i] {
+			if id > 0 && c.Cards[j].Id != id {
+				t.Errorf("column %q card[%d] = %d, expected %d", c.Name, j, c.Cards[j].Id, id)
+			}
+		}
+	}
+	if projects, err := GetProjects(repo.Id, false); err != nil || len(projects) != 1 || projects[0].NumCards != 3 {
+		t.Errorf("GetProjects = (%v, %v), expected 1 project with 3 cards", projects, err)
+	}
+
+	if err := DeleteProjectColumn(todo); err != nil {
+		t.Fatalf("DeleteProjectColumn: %v", err)
+	} else if _, err = GetProjectCardById(p.Id, note.Id); err != ErrProjectCardNotExist {
+		t.Errorf("GetProjectCardById(card of deleted column) error = %v, expected %v", err, ErrProjectCardNotExist)
+	}
+	if err := DeleteRepoProjects(repo.Id); err != nil {
+		t.Fatalf("DeleteRepoProjects: %v", err)
+	}
+	if _, err := GetProjectById(repo.Id, p.Id); err != ErrProjectNotExist {
+		t.Errorf("GetProjectById(deleted) error = %v, expected %v", err, ErrProjectNotExist)
+	}
+	if count, err := orm.Count(new(ProjectCard)); err != nil || count != 0 {
+		t.Errorf("%d cards remain after DeleteRepoProjects (%v)", count, err)
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Unknwon/com"
+
+	"github.com/gogits/gogs/modules/base"
+)
+
+var (
+	ErrProtectedBranchNotExist     = errors.New("Protected branch does not exist")
+	ErrProtectedBranchAlreadyExist = errors.New("Branch is already protected")
+	ErrBranchForcePushBlocked      = errors.New("Force push to protected branch is not allowed")
+	ErrBranchDeletionBlocked       = errors.New("Protected branch cannot be deleted")
+	ErrBranchRequirePullRequest    = errors.New("Changes to protected branch must be made through pull request")
+	ErrBranchPushRestricted        = errors.New("You are not allowed to push to protected branch")
+)
+
+// ProtectedBranch represents protection rules of a branch in repository.
+type ProtectedBranch struct {
+	Id                 int64
+	RepoId             int64  `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	BranchName         string `xorm:"UNIQUE(s) NOT NULL"`
+	BlockForcePush     bool
+	BlockDeletion      bool
+	RequirePullRequest bool
+	EnableWhitelist    bool
+	WhitelistUserIds   string    `xorm:"TEXT"` // Comma separated list of user IDs.
+	WhitelistUsers     []*User   `xorm:"-"`
+	RequiredApprovals  int       // Number of approving reviews required to merge pull requests.
+	DismissStale       bool      // Approvals given before latest push to head branch do not count.
+	Created            time.Time `xorm:"CREATED"`
+	Updated            time.Time `xorm:"UPDATED"`
+}
+
+// SetWhitelistUsers saves given users as whitelist of protected branch.
+func (pb *ProtectedBranch) SetWhitelistUsers(uids []int64) {
+	ids := make([]string, len(uids))
+	for i := range uids {
+		ids[i] = base.ToStr(uids[i])
+	}
+	pb.WhitelistUserIds = strings.Join(ids, ",")
+}
+
+// GetWhitelistUsers loads users in whitelist of protected branch,
+// users that no longer exist are ignored.
+func (pb *ProtectedBranch) GetWhitelistUsers() error {
+	pb.WhitelistUsers = make([]*User, 0, 5)
+	for _, id := range strings.Split(pb.WhitelistUserIds, ",") {
+		uid, _ := base.StrTo(id).Int64()
+		if uid <= 0 {
+			continue
+		}
+		u, err := GetUserById(uid)
+		if err != nil {
+			if err == ErrUserNotExist {
+				continue
+			}
+			return err
+		}
+		pb.WhitelistUsers = append(pb.WhitelistUsers, u)
+	}
+	return nil
+}
+
+// CanPush returns true if given user is allowed to push to protected branch.
+func (pb *ProtectedBranch) CanPush(uid int64) bool {
+	if !pb.EnableWhitelist {
+		return true
+	}
+	return com.IsSliceContainsStr(strings.Split(pb.WhitelistUserIds, ","), base.ToStr(uid))
+}
+
+// AddProtectedBranch adds new protection rules of a branch.
+func AddProtectedBranch(pb *ProtectedBranch) error {
+	has, err := orm.Get(&ProtectedBranch{RepoId: pb.RepoId, BranchName: pb.BranchName})
+	if err != nil {
+		return err
+	} else if has {
+		return ErrProtectedBranchAlreadyExist
+	}
+	_, err = orm.Insert(pb)
+	return err
+}
+
+// GetProtectedBranchById returns protected branch of repository by given ID.
+func GetProtectedBranchById(repoId, id int64) (*ProtectedBranch, error) {
+	pb := new(ProtectedBranch)
+	has, err := orm.Where("id=? AND repo_id=?", id, repoId).Get(pb)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProtectedBranchNotExist
+	}
+	return pb, nil
+}
+
+// GetProtectedBranchByName returns protection rules of given branch.
+func GetProtectedBranchByName(repoId int64, branchName string) (*ProtectedBranch, error) {
+	pb := new(ProtectedBranch)
+	has, err := orm.Where("repo_id=? AND branch_name=?", repoId, branchName).Get(pb)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProtectedBranchNotExist
+	}
+	return pb, nil
+}
+
+// GetProtectedBranches returns all protected branches of repository.
+func GetProtectedBranches(repoId int64) ([]*ProtectedBranch, error) {
+	pbs := make([]*ProtectedBranch, 0, 5)
+	err := orm.Where("repo_id=?", repoId).Asc("branch_name").Find(&pbs)
+	return pbs, err
+}
+
+// UpdateProtectedBranch updates protection rules of a branch.
+func UpdateProtectedBranch(pb *ProtectedBranch) error {
+	_, err := orm.Id(pb.Id).AllCols().Update(pb)
+	return err
+}
+
+// DeleteProtectedBranch removes protection of a branch.
+func DeleteProtectedBranch(repoId, id int64) error {
+	if id <= 0 {
+		return ErrProtectedBranchNotExist
+	}
+	affected, err := orm.Where("id=? AND repo_id=?", id, repoId).Delete(new(ProtectedBranch))
+	if err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrProtectedBranchNotExist
+	}
+	return nil
+}
+
+// CheckBranchPush checks if given reference update is allowed by protection rules of branch
+// and repository is not archived, it is called for every reference before it is updated in pushing.
+func CheckBranchPush(repo *Repository, repoPath string, uid int64, refName, oldCommitId, newCommitId string) error {
+	if repo.IsArchived {
+		return ErrRepoArchived
+	} else if !strings.HasPrefix(refName, "refs/heads/") {
+		return nil
+	}
+
+	pb, err := GetProtectedBranchByName(repo.Id, strings.TrimPrefix(refName, "refs/heads/"))
+	if err != nil {
+		if err == ErrProtectedBranchNotExist {
+			return nil
+		}
+		return err
+	}
+
+	isNew := strings.HasPrefix(oldCommitId, "0000000")
+	isDel := strings.HasPrefix(newCommitId, "0000000")
+	switch {
+	case !pb.CanPush(uid):
+		return ErrBranchPushRestricted
+	case isDel && pb.BlockDeletion:
+		return ErrBranchDeletionBlocked
+	case pb.RequirePullRequest:
+		return ErrBranchRequirePullRequest
+	case !isNew && !isDel && pb.BlockForcePush:
+		// Force push rewrites history so old commit is no longer an ancestor of new one.
+		if _, _, err = com.ExecCmdDir(repoPath, "git", "merge-base", "--is-ancestor", oldCommitId, newCommitId); err != nil {
+			return ErrBranchForcePushBlocked
+		}
+	}
+	return nil
+}
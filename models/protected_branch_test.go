@@ -0,0 +1,70 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestCheckBranchPush(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u, other := newTestUser(t, "user1"), newTestUser(t, "user2")
+	repo := newTestRepo(t, u, "repo1")
+	repoPath := RepoPath(u.Name, repo.Name)
+
+	oldId, err := ResolveCommitId(repoPath, "master")
+	if err != nil {
+		t.Fatalf("ResolveCommitId: %v", err)
+	}
+	newId := testCommitFiles(t, repoPath, "master", "", map[string]string{"a.txt": "a\n"}, "Add a")
+	forcedId := testCommitFiles(t, repoPath, "rewritten", "", map[string]string{"b.txt": "b\n"}, "Rewrite history")
+	const emptyId = "0000000000000000000000000000000000000000"
+
+	pb := &ProtectedBranch{RepoId: repo.Id, BranchName: "master", BlockForcePush: true, BlockDeletion: true}
+	pb.SetWhitelistUsers([]int64{u.Id})
+	if err = AddProtectedBranch(pb); err != nil {
+		t.Fatalf("AddProtectedBranch: %v", err)
+	}
+	if err = AddProtectedBranch(&ProtectedBranch{RepoId: repo.Id, BranchName: "master"}); err != ErrProtectedBranchAlreadyExist {
+		t.Errorf("AddProtectedBranch(duplicate) error = %v, expected %v", err, ErrProtectedBranchAlreadyExist)
+	}
+	if _, err = GetProtectedBranchById(repo.Id, 0); err != ErrProtectedBranchNotExist {
+		t.Errorf("GetProtectedBranchById(zero ID) error = %v, expected %v", err, ErrProtectedBranchNotExist)
+	}
+	if _, err = GetProtectedBranchByName(repo.Id, ""); err != ErrProtectedBranchNotExist {
+		t.Errorf("GetProtectedBranchByName(empty) error = %v, expected %v", err, ErrProtectedBranchNotExist)
+	}
+
+	tests := []struct {
+		uid                     int64
+		refName, oldId, newId   string
+		whitelist, requirePulls bool
+		expected                error
+	}{
+		{u.Id, "refs/heads/master", oldId, newId, false, false, nil},
+		{u.Id, "refs/heads/master", newId, forcedId, false, false, ErrBranchForcePushBlocked},
+		{u.Id, "refs/heads/master", newId, emptyId, false, false, ErrBranchDeletionBlocked},
+		{u.Id, "refs/heads/feature", newId, forcedId, false, false, nil},
+		{u.Id, "refs/tags/v1", emptyId, newId, false, false, nil},
+		{other.Id, "refs/heads/master", oldId, newId, false, false, nil},
+		{other.Id, "refs/heads/master", oldId, newId, true, false, ErrBranchPushRestricted},
+		{u.Id, "refs/heads/master", oldId, newId, true, false, nil},
+		{u.Id, "refs/heads/master", oldId, newId, true, true, ErrBranchRequirePullRequest},
+	}
+	for i, tt := range tests {
+		pb.EnableWhitelist, pb.RequirePullRequest = tt.whitelist, tt.requirePulls
+		if err = UpdateProtectedBranch(pb); err != nil {
+			t.Fatalf("UpdateProtectedBranch: %v", err)
+		}
+		if err = CheckBranchPush(repo, repoPath, tt.uid, tt.refName, tt.oldId, tt.newId); err != tt.expected {
+			t.Errorf("#%d: CheckBranchPush(%s) error = %v, expected %v", i, tt.refName, err, tt.expected)
+		}
+	}
+
+	repo.IsArchived = true
+	if err = CheckBranchPush(repo, repoPath, u.Id, "refs/heads/feature", oldId, newId); err != ErrRepoArchived {
+		t.Errorf("CheckBranchPush(archived) error = %v, expected %v", err, ErrRepoArchived)
+	}
+}
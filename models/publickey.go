@@ -32,6 +32,7 @@ const (
 var (
 	ErrKeyAlreadyExist = errors.New("Public key already exist")
 	ErrKeyNotExist     = errors.New("Public key does not exist")
+	ErrKeyExpired      = errors.New("Public key has expired")
 )
 
 var sshOpLocker = sync.Mutex{}
@@ -82,6 +83,23 @@ type PublicKey struct {
 	Content     string    `xorm:"TEXT NOT NULL"`
 	Created     time.Time `xorm:"CREATED"`
 	Updated     time.Time `xorm:"UPDATED"`
+	LastUsed    time.Time // Zero value means never used.
+	Expires     time.Time // Zero value means never expires.
+}
+
+// HasUsed returns true if key has been used for any SSH operation.
+func (key PublicKey) HasUsed() bool {
+	return !key.LastUsed.IsZero()
+}
+
+// HasExpiration returns true if key has an expiration date.
+func (key PublicKey) HasExpiration() bool {
+	return !key.Expires.IsZero()
+}
+
+// IsExpired returns true if key is past its expiration date.
+func (key PublicKey) IsExpired() bool {
+	return key.HasExpiration() && time.Now().After(key.Expires)
 }
 
 // GetAuthorizedString generates and returns formatted public key string for authorized_keys file.
@@ -89,8 +107,8 @@ func (key *PublicKey) GetAuthorizedString() string {
 	return fmt.Sprintf(_TPL_PUBLICK_KEY, appPath, key.Id, key.Content)
 }
 
-// saveAuthorizedKeyFile writes SSH key content to authorized_keys file.
-func saveAuthorizedKeyFile(key *PublicKey) error {
+// saveAuthorizedKeyFile appends given line to authorized_keys file.
+func saveAuthorizedKeyFile(line string) error {
 	sshOpLocker.Lock()
 	defer sshOpLocker.Unlock()
 
@@ -101,13 +119,42 @@ func saveAuthorizedKeyFile(key *PublicKey) error {
 	}
 	defer f.Close()
 
-	_, err = f.WriteString(key.GetAuthorizedString())
+	_, err = f.WriteString(line)
 	return err
 }
 
+// calcFingerprint returns fingerprint of given SSH public key content.
+func calcFingerprint(content string) (string, error) {
+	tmpPath := strings.Replace(path.Join(os.TempDir(), fmt.Sprintf("%d", time.Now().Nanosecond()),
+		"id_rsa.pub"), "\\", "/", -1)
+	os.MkdirAll(path.Dir(tmpPath), os.ModePerm)
+	defer os.RemoveAll(path.Dir(tmpPath))
+	if err := ioutil.WriteFile(tmpPath, []byte(content), os.ModePerm); err != nil {
+		return "", err
+	}
+	stdout, stderr, err := com.ExecCmd("ssh-keygen", "-l", "-f", tmpPath)
+	if err != nil {
+		return "", errors.New("ssh-keygen -l -f: " + stderr)
+	} else if len(stdout) < 2 {
+		return "", errors.New("Not enough output for calculating fingerprint")
+	}
+	return strings.Split(stdout, " ")[1], nil
+}
+
+// isKeyFingerprintUsed returns true if a user key or deploy key
+// with given fingerprint exists, because SSH server can only use
+// the first one in authorized_keys file.
+func isKeyFingerprintUsed(fingerprint string) (bool, error) {
+	has, err := orm.Get(&PublicKey{Fingerprint: fingerprint})
+	if err != nil || has {
+		return has, err
+	}
+	return orm.Get(&DeployKey{Fingerprint: fingerprint})
+}
+
 // AddPublicKey adds new public key to database and authorized_keys file.
 func AddPublicKey(key *PublicKey) (err error) {
-	has, err := orm.Get(key)
+	has, err := orm.Get(&PublicKey{OwnerId: key.OwnerId, Name: key.Name})
 	if err != nil {
 		return err
 	} else if has {
@@ -115,24 +162,19 @@ func AddPublicKey(key *PublicKey) (err error) {
 	}
 
 	// Calculate fingerprint.
-	tmpPath := strings.Replace(path.Join(os.TempDir(), fmt.Sprintf("%d", time.Now().Nanosecond()),
-		"id_rsa.pub"), "\\", "/", -1)
-	os.MkdirAll(path.Dir(tmpPath), os.ModePerm)
-	if err = ioutil.WriteFile(tmpPath, []byte(key.Content), os.ModePerm); err != nil {
+	if key.Fingerprint, err = calcFingerprint(key.Content); err != nil {
 		return err
 	}
-	stdout, stderr, err := com.ExecCmd("ssh-keygen", "-l", "-f", tmpPath)
-	if err != nil {
-		return errors.New("ssh-keygen -l -f: " + stderr)
-	} else if len(stdout) < 2 {
-		return errors.New("Not enough output for calculating fingerprint")
+	if has, err = isKeyFingerprintUsed(key.Fingerprint); err != nil {
+		return err
+	} else if has {
+		return ErrKeyAlreadyExist
 	}
-	key.Fingerprint = strings.Split(stdout, " ")[1]
 
 	// Save SSH key.
 	if _, err = orm.Insert(key); err != nil {
 		return err
-	} else if err = saveAuthorizedKeyFile(key); err != nil {
+	} else if err = saveAuthorizedKeyFile(key.GetAuthorizedString()); err != nil {
 		// Roll back.
 		if _, err2 := orm.Delete(key); err2 != nil {
 			return err2
@@ -143,6 +185,25 @@ func AddPublicKey(key *PublicKey) (err error) {
 	return nil
 }
 
+// GetPublicKeyById returns public key by given ID.
+func GetPublicKeyById(id int64) (*PublicKey, error) {
+	key := new(PublicKey)
+	has, err := orm.Id(id).Get(key)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrKeyNotExist
+	}
+	return key, nil
+}
+
+// UpdatePublicKeyUsed records given public key has been used just now.
+func UpdatePublicKeyUsed(key *PublicKey) error {
+	key.LastUsed = time.Now()
+	_, err := orm.Id(key.Id).Cols("last_used").Update(key)
+	return err
+}
+
 // ListPublicKey returns a list of all public keys that user has.
 func ListPublicKey(uid int64) ([]PublicKey, error) {
 	keys := make([]PublicKey, 0, 5)
@@ -151,7 +212,7 @@ func ListPublicKey(uid int64) ([]PublicKey, error) {
 }
 
 // rewriteAuthorizedKeys finds and deletes corresponding line in authorized_keys file.
-func rewriteAuthorizedKeys(key *PublicKey, p, tmpP string) error {
+func rewriteAuthorizedKeys(keyword, content, p, tmpP string) error {
 	sshOpLocker.Lock()
 	defer sshOpLocker.Unlock()
 
@@ -168,7 +229,6 @@ func rewriteAuthorizedKeys(key *PublicKey, p, tmpP string) error {
 	defer fw.Close()
 
 	isFound := false
-	keyword := fmt.Sprintf("key-%d", key.Id)
 	buf := bufio.NewReader(fr)
 	for {
 		line, errRead := buf.ReadString('\n')
@@ -187,7 +247,7 @@ func rewriteAuthorizedKeys(key *PublicKey, p, tmpP string) error {
 		}
 
 		// Found the line and copy rest of file.
-		if !isFound && strings.Contains(line, keyword) && strings.Contains(line, key.Content) {
+		if !isFound && strings.Contains(line, keyword) && strings.Contains(line, content) {
 			isFound = true
 			continue
 		}
@@ -215,12 +275,17 @@ func DeletePublicKey(key *PublicKey) error {
 	if _, err = orm.Delete(key); err != nil {
 		return err
 	}
+	return removeAuthorizedKey(fmt.Sprintf("key-%d", key.Id), key.Content)
+}
 
+// removeAuthorizedKey deletes the line that contains both keyword and
+// key content from authorized_keys file.
+func removeAuthorizedKey(keyword, content string) error {
 	fpath := filepath.Join(sshPath, "authorized_keys")
 	tmpPath := filepath.Join(sshPath, "authorized_keys.tmp")
-	log.Trace("publickey.DeletePublicKey(authorized_keys): %s", fpath)
+	log.Trace("publickey.removeAuthorizedKey(authorized_keys): %s", fpath)
 
-	if err = rewriteAuthorizedKeys(key, fpath, tmpPath); err != nil {
+	if err := rewriteAuthorizedKeys(keyword, content, fpath, tmpPath); err != nil {
 		return err
 	} else if err = os.Remove(fpath); err != nil {
 		return err
@@ -0,0 +1,54 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPublicKeyIsExpired(t *testing.T) {
+	tests := []struct {
+		expires  time.Time
+		expected bool
+	}{
+		{time.Time{}, false},
+		{time.Now().Add(time.Hour), false},
+		{time.Now().Add(-time.Hour), true},
+	}
+	for _, tt := range tests {
+		if expired := (PublicKey{Expires: tt.expires}).IsExpired(); expired != tt.expected {
+			t.Errorf("PublicKey{Expires: %v}.IsExpired() = %v, expected %v", tt.expires, expired, tt.expected)
+		}
+	}
+}
+
+func TestUpdatePublicKeyUsed(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+
+	key := &PublicKey{OwnerId: u.Id, Name: "laptop", Content: testSSHKey1, Fingerprint: "-"}
+	if _, err := orm.Insert(key); err != nil {
+		t.Fatalf("insert key: %v", err)
+	}
+	if key, err := GetPublicKeyById(key.Id); err != nil {
+		t.Fatalf("GetPublicKeyById: %v", err)
+	} else if key.HasUsed() {
+		t.Error("new key has been used")
+	}
+
+	if err := UpdatePublicKeyUsed(key); err != nil {
+		t.Fatalf("UpdatePublicKeyUsed: %v", err)
+	}
+	if key, err := GetPublicKeyById(key.Id); err != nil {
+		t.Fatalf("GetPublicKeyById: %v", err)
+	} else if !key.HasUsed() || key.Name != "laptop" {
+		t.Errorf("key has used %v, name %q, expected true, %q", key.HasUsed(), key.Name, "laptop")
+	}
+
+	if _, err := GetPublicKeyById(key.Id + 1); err != ErrKeyNotExist {
+		t.Errorf("GetPublicKeyById(not exist) error = %v, expected %v", err, ErrKeyNotExist)
+	}
+}
@@ -0,0 +1,532 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Unknwon/com"
+
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+)
+
+var (
+	ErrPullRequestNotExist     = errors.New("Pull request does not exist")
+	ErrPullRequestAlreadyExist = errors.New("Pull request of given branches already exists")
+	ErrPullRequestNoChanges    = errors.New("There is nothing to compare")
+	ErrPullRequestNotMergeable = errors.New("Pull request cannot be merged automatically")
+	ErrPullRequestMerged       = errors.New("Pull request has already been merged")
+	ErrPullRequestNotMerged    = errors.New("Pull request has not been merged")
+	ErrHeadBranchChanged       = errors.New("Head branch has been changed since pull request was merged")
+	ErrHeadBranchNotRestorable = errors.New("Head branch cannot be restored")
+	ErrMergeStyleNotAllowed    = errors.New("Merge style is not allowed in this repository")
+	ErrPullRequestIsDraft      = errors.New("Draft pull request cannot be merged")
+	ErrPullRequestNotDraft     = errors.New("Pull request is not a draft")
+)
+
+// Merge styles of pull requests.
+const (
+	MERGE_STYLE_MERGE  = "merge"  // Merge commit of head branch.
+	MERGE_STYLE_SQUASH = "squash" // Single commit of all changes.
+	MERGE_STYLE_REBASE = "rebase" // Commits of head branch rebased onto base branch.
+)
+
+var mergeStyles = []string{MERGE_STYLE_MERGE, MERGE_STYLE_SQUASH, MERGE_STYLE_REBASE}
+
+// IsValidMergeStyle returns true if given name is a known merge style.
+func IsValidMergeStyle(style string) bool {
+	return com.IsSliceContainsStr(mergeStyles, style)
+}
+
+// AllowedMergeStyles returns merge styles allowed in repository.
+func (repo *Repository) AllowedMergeStyles() []string {
+	if len(repo.MergeStyles) == 0 {
+		return mergeStyles
+	}
+	styles := make([]string, 0, len(mergeStyles))
+	for _, style := range mergeStyles {
+		if repo.CanMergeWith(style) {
+			styles = append(styles, style)
+		}
+	}
+	return styles
+}
+
+// CanMergeWith returns true if pull requests can be merged with given style in repository.
+func (repo *Repository) CanMergeWith(style string) bool {
+	if !IsValidMergeStyle(style) {
+		return false
+	} else if len(repo.MergeStyles) == 0 {
+		return true
+	}
+	return com.IsSliceContainsStr(strings.Split(repo.MergeStyles, ","), style)
+}
+
+// GetDefaultMergeStyle returns default merge style of repository,
+// it falls back to the first allowed style if default is not allowed.
+func (repo *Repository) GetDefaultMergeStyle() string {
+	if repo.CanMergeWith(repo.DefaultMergeStyle) {
+		return repo.DefaultMergeStyle
+	}
+	return repo.AllowedMergeStyles()[0]
+}
+
+// MergeMessagePlaceholders can be used in merge message template of repository.
+var MergeMessagePlaceholders = []string{"$INDEX", "$TITLE", "$DESCRIPTION", "$AUTHOR", "$HEAD_REPO", "$HEAD_BRANCH", "$BASE_BRANCH"}
+
+const (
+	_DEFAULT_MERGE_MESSAGE  = "Merge pull request #$INDEX from $HEAD_REPO:$HEAD_BRANCH\n\n$TITLE"
+	_DEFAULT_SQUASH_MESSAGE = "$TITLE (#$INDEX)\n\n$DESCRIPTION"
+)
+
+// MergeMessage returns commit message of merging pull request with given style by
+// merge message template of repository, rebase does not create any commit.
+// Issue, head and base repositories of pull request must be loaded.
+func (pr *PullRequest) MergeMessage(style string) string {
+	tpl := pr.BaseRepo.MergeMessageTemplate
+	if len(tpl) == 0 {
+		switch style {
+		case MERGE_STYLE_MERGE:
+			tpl = _DEFAULT_MERGE_MESSAGE
+		case MERGE_STYLE_SQUASH:
+			tpl = _DEFAULT_SQUASH_MESSAGE
+		default:
+			return ""
+		}
+	}
+
+	author := ""
+	if pr.Issue.Poster != nil {
+		author = pr.Issue.Poster.Name
+	}
+	r := strings.NewReplacer(
+		"$INDEX", base.ToStr(pr.Issue.Index),
+		"$TITLE", pr.Issue.Name,
+		"$DESCRIPTION", pr.Issue.Content,
+		"$AUTHOR", author,
+		"$HEAD_REPO", pr.HeadRepo.Owner.Name+"/"+pr.HeadRepo.Name,
+		"$HEAD_BRANCH", pr.HeadBranch,
+		"$BASE_BRANCH", pr.BaseBranch)
+	return strings.TrimSpace(r.Replace(tpl))
+}
+
+// PullRequest represents the relation of a pull request issue and its branches.
+type PullRequest struct {
+	Id             int64
+	IssueId        int64       `xorm:"UNIQUE"`
+	Issue          *Issue      `xorm:"-"`
+	HeadRepoId     int64       `xorm:"INDEX"`
+	HeadRepo       *Repository `xorm:"-"`
+	BaseRepoId     int64       `xorm:"INDEX"`
+	BaseRepo       *Repository `xorm:"-"`
+	HeadBranch     string
+	IsHeadDeleted  bool // Head branch has been deleted after merge.
+	BaseBranch     string
+	MergeBase      string
+	HeadCommitId   string // Latest commit of head branch when merged.
+	IsDraft        bool   // Draft cannot be merged and sends no review requests.
+	Status         int    // Mergeability that is checked in background.
+	ConflictPaths  string `xorm:"TEXT"` // Newline separated paths of files that have conflicts.
+	HasMerged      bool
+	MergedCommitId string
+	MergeStyle     string
+	MergerId       int64
+	AutoMergeStyle string // Style to merge with once checks pass, empty if auto-merge is off.
+	AutoMergerId   int64
+	Merged         time.Time
+	Created        time.Time `xorm:"CREATED"`
+}
+
+// GetHeadRepo loads head repository and its owner.
+func (pr *PullRequest) GetHeadRepo() (err error) {
+	if pr.HeadRepo, err = GetRepositoryById(pr.HeadRepoId); err != nil {
+		return err
+	}
+	return pr.HeadRepo.GetOwner()
+}
+
+// GetBaseRepo loads base repository and its owner.
+func (pr *PullRequest) GetBaseRepo() (err error) {
+	if pr.BaseRepo, err = GetRepositoryById(pr.BaseRepoId); err != nil {
+		return err
+	}
+	return pr.BaseRepo.GetOwner()
+}
+
+// IsCrossRepo returns true if head branch of pull request belongs to a fork.
+func (pr *PullRequest) IsCrossRepo() bool {
+	return pr.HeadRepoId != pr.BaseRepoId
+}
+
+// FetchForkBranch fetches given branch of a fork into base repository so that
+// they can be compared, and returns latest commit ID of the branch.
+func FetchForkBranch(basePath, headPath string, headRepoId int64, branch string) (string, error) {
+	ref := fmt.Sprintf("refs/forks/%d/%s", headRepoId, branch)
+	if _, stderr, err := com.ExecCmdDir(basePath, "git", "fetch", "-q", headPath, "+refs/heads/"+branch+":"+ref); err != nil {
+		return "", errors.New("git fetch: " + stderr)
+	}
+
+	stdout, stderr, err := com.ExecCmdDir(basePath, "git", "rev-parse", ref)
+	if err != nil {
+		return "", errors.New("git rev-parse: " + stderr)
+	}
+	return strings.TrimSpace(stdout), nil
+}
+
+// FetchForkRef fetches given branch, tag or commit of a fork into base repository
+// so that they can be compared, and returns ID of commit it points to.
+func FetchForkRef(basePath, headPath string, headRepoId int64, refName string) (string, error) {
+	if _, _, err := com.ExecCmdDir(headPath, "git", "show-ref", "--verify", "-q", "refs/heads/"+refName); err == nil {
+		return FetchForkBranch(basePath, headPath, headRepoId, refName)
+	}
+
+	var refspec, rev string
+	if _, _, err := com.ExecCmdDir(headPath, "git", "show-ref", "--verify", "-q", "refs/tags/"+refName); err == nil {
+		rev = fmt.Sprintf("refs/forks/%d/tags/%s", headRepoId, refName)
+		refspec = "+refs/tags/" + refName + ":" + rev
+	} else {
+		// Commit can only be fetched through branches that contain it.
+		if _, err = ResolveCommitId(headPath, refName); err != nil {
+			return "", err
+		}
+		rev = refName
+		refspec = fmt.Sprintf("+refs/heads/*:refs/forks/%d/*", headRepoId)
+	}
+	if _, stderr, err := com.ExecCmdDir(basePath, "git", "fetch", "-q", "--no-tags", headPath, refspec); err != nil {
+		return "", errors.New("git fetch: " + stderr)
+	}
+	return ResolveCommitId(basePath, rev)
+}
+
+// NewPullRequest creates new pull request with its issue for repository.
+func NewPullRequest(repo *Repository, issue *Issue, pr *PullRequest) (err error) {
+	issue.IsPull = true
+
+	sess := orm.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err = sess.Insert(issue); err != nil {
+		sess.Rollback()
+		return err
+	}
+
+	rawSql := "UPDATE `repository` SET num_issues = num_issues + 1 WHERE id = ?"
+	if _, err = sess.Exec(rawSql, repo.Id); err != nil {
+		sess.Rollback()
+		return err
+	}
+
+	pr.IssueId = issue.Id
+	if _, err = sess.Insert(pr); err != nil {
+		sess.Rollback()
+		return err
+	}
+	return sess.Commit()
+}
+
+// GetPullRequestByIssueId returns pull request of given issue.
+func GetPullRequestByIssueId(issueId int64) (*PullRequest, error) {
+	pr := &PullRequest{IssueId: issueId}
+	has, err := orm.Get(pr)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPullRequestNotExist
+	}
+	return pr, nil
+}
+
+// GetUnmergedPullRequest returns the open pull request of given branches if exists.
+func GetUnmergedPullRequest(headRepoId, baseRepoId int64, headBranch, baseBranch string) (*PullRequest, error) {
+	pr := new(PullRequest)
+	has, err := orm.Join("INNER", "issue", "issue.id = pull_request.issue_id").
+		Where("pull_request.head_repo_id=?", headRepoId).
+		And("pull_request.base_repo_id=?", baseRepoId).
+		And("pull_request.head_branch=?", headBranch).
+		And("pull_request.base_branch=?", baseBranch).
+		And("pull_request.has_merged=?", false).
+		And("issue.is_closed=?", false).Get(pr)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPullRequestNotExist
+	}
+	return pr, nil
+}
+
+// GetPullRequests returns a list of pull request issues of repository,
+// draft can be "only" or "exclude" to filter draft pull requests.
+func GetPullRequests(repoId int64, isClosed bool, draft string, page int) ([]*Issue, error) {
+	if page <= 0 {
+		page = 1
+	}
+
+	issues := make([]*Issue, 0, 20)
+	sess := orm.Limit(20, (page-1)*20).Where("repo_id=?", repoId).And("is_pull=?", true).
+		And("is_closed=?", isClosed)
+	switch draft {
+	case "only":
+		sess.And("id IN (SELECT issue_id FROM pull_request WHERE is_draft=?)", true)
+	case "exclude":
+		sess.And("id IN (SELECT issue_id FROM pull_request WHERE is_draft=?)", false)
+	}
+	err := sess.Desc("created").Find(&issues)
+	return issues, err
+}
+
+// GetPullRequestCount returns number of open and closed pull requests of repository.
+func GetPullRequestCount(repoId int64) (open int64, closed int64) {
+	open, _ = orm.Where("repo_id=?", repoId).And("is_pull=?", true).And("is_closed=?", false).Count(new(Issue))
+	closed, _ = orm.Where("repo_id=?", repoId).And("is_pull=?", true).And("is_closed=?", true).Count(new(Issue))
+	return open, closed
+}
+
+// UpdatePullRequest updates information of pull request.
+func UpdatePullRequest(pr *PullRequest) error {
+	_, err := orm.Id(pr.Id).AllCols().Update(pr)
+	return err
+}
+
+// Merge merges head branch into base branch of pull request with given style through
+// a temporary clone, and closes the pull request on success. Empty message means
+// message by merge message template of repository.
+func (pr *PullRequest) Merge(doer *User, style, message string) (err error) {
+	if pr.HasMerged {
+		return ErrPullRequestMerged
+	} else if pr.IsDraft {
+		return ErrPullRequestIsDraft
+	}
+	if err = pr.GetBaseRepo(); err != nil {
+		return err
+	} else if pr.BaseRepo.IsArchived {
+		return ErrRepoArchived
+	} else if !pr.BaseRepo.CanMergeWith(style) {
+		return ErrMergeStyleNotAllowed
+	} else if err = pr.GetHeadRepo(); err != nil {
+		return err
+	} else if pr.Issue == nil {
+		if pr.Issue, err = GetIssueById(pr.IssueId); err != nil {
+			return err
+		}
+	}
+	if err = pr.Issue.GetPoster(); err != nil {
+		return err
+	}
+	if len(message) == 0 {
+		message = pr.MergeMessage(style)
+	}
+
+	basePath := RepoPath(pr.BaseRepo.Owner.Name, pr.BaseRepo.Name)
+	headPath := RepoPath(pr.HeadRepo.Owner.Name, pr.HeadRepo.Name)
+
+	tmpDir := filepath.Join(os.TempDir(), "gogs-merge-"+base.ToStr(time.Now().UnixNano()))
+	defer os.RemoveAll(tmpDir)
+
+	var stdout, stderr string
+	if _, stderr, err = com.ExecCmd("git", "clone", "-b", pr.BaseBranch, basePath, tmpDir); err != nil {
+		return errors.New("git clone: " + stderr)
+	}
+	if _, stderr, err = com.ExecCmdDir(tmpDir, "git", "fetch", headPath, pr.HeadBranch); err != nil {
+		return errors.New("git fetch: " + stderr)
+	}
+	if stdout, stderr, err = com.ExecCmdDir(tmpDir, "git", "rev-parse", "FETCH_HEAD"); err != nil {
+		return errors.New("git rev-parse: " + stderr)
+	}
+	pr.HeadCommitId = strings.TrimSpace(stdout)
+	if stdout, stderr, err = com.ExecCmdDir(tmpDir, "git", "merge-base", "HEAD", "FETCH_HEAD"); err != nil {
+		return errors.New("git merge-base: " + stderr)
+	}
+	pr.MergeBase = strings.TrimSpace(stdout)
+
+	if err = pr.CheckApprovals(pr.HeadCommitId); err != nil {
+		return err
+	}
+
+	sig := doer.NewGitSig()
+	gitArgs := []string{"-c", "user.name=" + sig.Name, "-c", "user.email=" + sig.Email}
+	switch style {
+	case MERGE_STYLE_MERGE:
+		if _, stderr, err = com.ExecCmdDir(tmpDir, "git", append(gitArgs,
+			"merge", "--no-ff", "--no-edit", "-m", message, "FETCH_HEAD")...); err != nil {
+			return ErrPullRequestNotMergeable
+		}
+	case MERGE_STYLE_SQUASH:
+		// Squashed commit is authored by poster of pull request.
+		if _, stderr, err = com.ExecCmdDir(tmpDir, "git", append(gitArgs,
+			"merge", "--squash", "FETCH_HEAD")...); err != nil {
+			return ErrPullRequestNotMergeable
+		}
+		author := pr.Issue.Poster.NewGitSig()
+		if _, stderr, err = com.ExecCmdDir(tmpDir, "git", append(gitArgs, "commit",
+			"--author", author.Name+" <"+author.Email+">", "-m", message)...); err != nil {
+			return errors.New("git commit: " + stderr)
+		}
+	case MERGE_STYLE_REBASE:
+		if _, stderr, err = com.ExecCmdDir(tmpDir, "git", "checkout", "-q", "-b", "_gogs_rebase", "FETCH_HEAD"); err != nil {
+			return errors.New("git checkout: " + stderr)
+		}
+		if _, stderr, err = com.ExecCmdDir(tmpDir, "git", append(gitArgs,
+			"rebase", "-q", pr.BaseBranch)...); err != nil {
+			return ErrPullRequestNotMergeable
+		}
+		if _, stderr, err = com.ExecCmdDir(tmpDir, "git", "checkout", "-q", pr.BaseBranch); err != nil {
+			return errors.New("git checkout: " + stderr)
+		} else if _, stderr, err = com.ExecCmdDir(tmpDir, "git", "merge", "--ff-only", "_gogs_rebase"); err != nil {
+			return errors.New("git merge: " + stderr)
+		}
+	}
+	if stdout, stderr, err = com.ExecCmdDir(tmpDir, "git", "rev-parse", "HEAD"); err != nil {
+		return errors.New("git rev-parse: " + stderr)
+	}
+	pr.MergedCommitId = strings.TrimSpace(stdout)
+
+	SetRepoEnvs(doer.Id, doer.Name, pr.BaseRepo.Name, pr.BaseRepo.Owner.Name)
+	if _, stderr, err = com.ExecCmdDir(tmpDir, "git", "push", "origin", pr.BaseBranch); err != nil {
+		return errors.New("git push: " + stderr)
+	}
+
+	if err = ClearRepoArchives(basePath); err != nil {
+		log.Error("models.Merge(ClearRepoArchives): %v", err)
+	}
+	if err = MarkPushMirrorsPending(pr.BaseRepoId); err != nil {
+		log.Error("models.Merge(MarkPushMirrorsPending): %v", err)
+	}
+
+	pr.HasMerged = true
+	pr.MergeStyle = style
+	pr.MergerId = doer.Id
+	pr.Merged = time.Now()
+	if err = UpdatePullRequest(pr); err != nil {
+		return err
+	}
+
+	pr.Issue.IsClosed = true
+	if err = UpdateIssue(pr.Issue); err != nil {
+		return err
+	} else if err = UpdateIssueUserPairsByStatus(pr.Issue.Id, true); err != nil {
+		return err
+	}
+	if _, err = CreateComment(doer.Id, pr.BaseRepoId, pr.Issue.Id, 0, 0, IT_CLOSE, ""); err != nil {
+		return err
+	}
+
+	// Closing keywords in pull request description take effect once it is merged into default branch,
+	// commits of pull request itself are handled by the push.
+	if pr.BaseBranch == pr.BaseRepo.DefaultBranch {
+		if err = UpdateIssuesByCommit(doer, pr.BaseRepo, pr.MergedCommitId, pr.Issue.Name+"\n\n"+pr.Issue.Content, true); err != nil {
+			log.Error("models.Merge(UpdateIssuesByCommit): %v", err)
+		}
+	}
+	return nil
+}
+
+// MarkReadyForReview marks draft pull request as ready for review and requests
+// reviews from given users, it returns users who have been newly requested.
+func (pr *PullRequest) MarkReadyForReview(doer *User, repo *Repository, reviewers []*User) ([]*User, error) {
+	if !pr.IsDraft {
+		return nil, ErrPullRequestNotDraft
+	}
+	pr.IsDraft = false
+	if _, err := orm.Id(pr.Id).Cols("is_draft").Update(pr); err != nil {
+		return nil, err
+	} else if _, err = CreateComment(doer.Id, repo.Id, pr.IssueId, 0, 0, IT_CHANGE, "marked this pull request as ready for review"); err != nil {
+		return nil, err
+	}
+	return RequestReviews(doer, repo, pr.Issue, reviewers)
+}
+
+// DeleteHeadBranch deletes head branch of merged pull request. Branch that has been changed
+// since merge is not deleted, and deletion is subject to same protection rules as pushes.
+func (pr *PullRequest) DeleteHeadBranch(doer *User) error {
+	if !pr.HasMerged {
+		return ErrPullRequestNotMerged
+	}
+	if pr.HeadRepo == nil {
+		if err := pr.GetHeadRepo(); err != nil {
+			return err
+		}
+	}
+	if pr.HeadRepo.IsArchived {
+		return ErrRepoArchived
+	} else if pr.HeadBranch == pr.HeadRepo.DefaultBranch {
+		return ErrDeleteDefaultBranch
+	}
+
+	repoPath := RepoPath(pr.HeadRepo.Owner.Name, pr.HeadRepo.Name)
+	refName := "refs/heads/" + pr.HeadBranch
+	commitId, err := execGitCmd(repoPath, nil, nil, "rev-parse", "--verify", "-q", refName)
+	if err != nil {
+		return ErrBranchNotExist
+	} else if commitId != pr.HeadCommitId {
+		return ErrHeadBranchChanged
+	}
+
+	if err = CheckBranchPush(pr.HeadRepo, repoPath, doer.Id, refName, commitId, _EMPTY_COMMIT_ID); err != nil {
+		return err
+	}
+	if _, err = execGitCmd(repoPath, nil, nil, "update-ref", "-d", refName, commitId); err != nil {
+		return err
+	}
+
+	pr.IsHeadDeleted = true
+	if err = UpdatePullRequest(pr); err != nil {
+		return err
+	}
+	return Update(refName, commitId, _EMPTY_COMMIT_ID, doer.Name, pr.HeadRepo.Owner.Name, pr.HeadRepo.Name, doer.Id)
+}
+
+// IsHeadBranchRestorable returns true if head branch has been deleted after merge,
+// no other branch has taken its name and its latest commit still exists in head repository.
+func (pr *PullRequest) IsHeadBranchRestorable() bool {
+	if !pr.IsHeadDeleted || pr.HeadRepo == nil {
+		return false
+	}
+	repoPath := RepoPath(pr.HeadRepo.Owner.Name, pr.HeadRepo.Name)
+	if _, err := execGitCmd(repoPath, nil, nil, "rev-parse", "--verify", "-q", "refs/heads/"+pr.HeadBranch); err == nil {
+		return false
+	}
+	_, err := execGitCmd(repoPath, nil, nil, "cat-file", "-e", pr.HeadCommitId+"^{commit}")
+	return err == nil
+}
+
+// RestoreHeadBranch recreates head branch that has been deleted after merge
+// on the commit it pointed to when pull request was merged.
+func (pr *PullRequest) RestoreHeadBranch(doer *User) error {
+	if pr.HeadRepo == nil {
+		if err := pr.GetHeadRepo(); err != nil {
+			return err
+		}
+	}
+	if pr.HeadRepo.IsArchived {
+		return ErrRepoArchived
+	} else if !pr.IsHeadBranchRestorable() {
+		return ErrHeadBranchNotRestorable
+	}
+
+	repoPath := RepoPath(pr.HeadRepo.Owner.Name, pr.HeadRepo.Name)
+	refName := "refs/heads/" + pr.HeadBranch
+	if err := CheckBranchPush(pr.HeadRepo, repoPath, doer.Id, refName, _EMPTY_COMMIT_ID, pr.HeadCommitId); err != nil {
+		return err
+	}
+	// Old value of all zeros makes sure branch has not been created in the meantime.
+	if _, err := execGitCmd(repoPath, nil, nil, "update-ref", refName, pr.HeadCommitId, _EMPTY_COMMIT_ID); err != nil {
+		return err
+	}
+
+	pr.IsHeadDeleted = false
+	if err := UpdatePullRequest(pr); err != nil {
+		return err
+	}
+	return Update(refName, _EMPTY_COMMIT_ID, pr.HeadCommitId, doer.Name, pr.HeadRepo.Owner.Name, pr.HeadRepo.Name, doer.Id)
+}
@@ -0,0 +1,131 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrPullRequestNotApproved = errors.New("Pull request does not have enough approving reviews")
+	ErrPullApprovalOwnRequest = errors.New("You cannot approve or request changes on your own pull request")
+)
+
+// PullApproval represents an approving review of pull request by a collaborator.
+type PullApproval struct {
+	Id         int64
+	IssueId    int64     `xorm:"UNIQUE(s) INDEX"`
+	ReviewerId int64     `xorm:"UNIQUE(s)"`
+	Reviewer   *User     `xorm:"-"`
+	CommitId   string    // Head commit of pull request when approved.
+	IsStale    bool      `xorm:"-"`
+	Created    time.Time `xorm:"CREATED"`
+}
+
+// ApprovePullRequest saves approval of pull request by given reviewer at given head commit,
+// approval of the same reviewer is updated to the new commit.
+func ApprovePullRequest(pr *PullRequest, reviewer *User, commitId string) error {
+	if pr.Issue.PosterId == reviewer.Id {
+		return ErrPullApprovalOwnRequest
+	}
+
+	a := &PullApproval{IssueId: pr.IssueId, ReviewerId: reviewer.Id}
+	has, err := orm.Where("issue_id=? AND reviewer_id=?", pr.IssueId, reviewer.Id).Get(a)
+	if err != nil {
+		return err
+	}
+	a.CommitId = commitId
+	if has {
+		a.Created = time.Now()
+		_, err = orm.Id(a.Id).Cols("commit_id", "created").Update(a)
+		return err
+	}
+	_, err = orm.Insert(a)
+	return err
+}
+
+// DeletePullApproval withdraws approval of pull request by given reviewer.
+func DeletePullApproval(issueId, reviewerId int64) error {
+	_, err := orm.Where("issue_id=? AND reviewer_id=?", issueId, reviewerId).Delete(new(PullApproval))
+	return err
+}
+
+// GetPullApprovals returns approvals of pull request with their reviewers,
+// approvals of reviewers that no longer exist are skipped.
+func GetPullApprovals(issueId int64) ([]*PullApproval, error) {
+	approvals := make([]*PullApproval, 0, 3)
+	if err := orm.Where("issue_id=?", issueId).Asc("created").Find(&approvals); err != nil {
+		return nil, err
+	}
+
+	var err error
+	valid := approvals[:0]
+	for _, a := range approvals {
+		if a.Reviewer, err = GetUserById(a.ReviewerId); err != nil {
+			if err == ErrUserNotExist {
+				continue
+			}
+			return nil, err
+		}
+		valid = append(valid, a)
+	}
+	return valid, nil
+}
+
+// ApprovalRule returns protection rules of base branch when it requires approvals
+// before merge, or nil otherwise. Base repository must have been loaded.
+func (pr *PullRequest) ApprovalRule() (*ProtectedBranch, error) {
+	pb, err := GetProtectedBranchByName(pr.BaseRepoId, pr.BaseBranch)
+	if err != nil {
+		if err == ErrProtectedBranchNotExist {
+			return nil, nil
+		}
+		return nil, err
+	} else if pb.RequiredApprovals <= 0 {
+		return nil, nil
+	}
+	return pb, nil
+}
+
+// CountApprovals marks approvals that do not count towards given protection rules as stale
+// and returns number of valid ones. Only approvals of users who can still write to base
+// repository count, and if stale approvals are dismissed, only those of given head commit.
+func (pr *PullRequest) CountApprovals(pb *ProtectedBranch, approvals []*PullApproval, headCommitId string) (int, error) {
+	repoName := pr.BaseRepo.Owner.Name + "/" + pr.BaseRepo.Name
+	count := 0
+	for _, a := range approvals {
+		canWrite, err := HasAccess(a.Reviewer.Name, repoName, AU_WRITABLE)
+		if err != nil {
+			return 0, err
+		}
+		a.IsStale = !canWrite || (pb.DismissStale && a.CommitId != headCommitId)
+		if !a.IsStale {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CheckApprovals returns ErrPullRequestNotApproved if pull request does not have
+// enough approvals required by protection rules of base branch.
+func (pr *PullRequest) CheckApprovals(headCommitId string) error {
+	pb, err := pr.ApprovalRule()
+	if err != nil || pb == nil {
+		return err
+	}
+
+	approvals, err := GetPullApprovals(pr.IssueId)
+	if err != nil {
+		return err
+	}
+	count, err := pr.CountApprovals(pb, approvals, headCommitId)
+	if err != nil {
+		return err
+	} else if count < pb.RequiredApprovals {
+		return ErrPullRequestNotApproved
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestPullApprovals(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	writer, reader := newTestUser(t, "writer"), newTestUser(t, "reader")
+	repo := newTestRepo(t, u1, "repo1")
+	repoPath := RepoPath(u1.Name, repo.Name)
+	for _, a := range []*Access{
+		{UserName: "writer", RepoName: "user1/repo1", Mode: AU_WRITABLE},
+		{UserName: "reader", RepoName: "user1/repo1", Mode: AU_READABLE},
+	} {
+		if _, err := orm.Insert(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	oldHeadId := testCommitFiles(t, repoPath, "feature", "master", map[string]string{"a.txt": "a\n"}, "Add a")
+	pr := newTestPullRequest(t, repo, u2, "feature", "master")
+	pr.BaseRepo = repo
+	if err := ApprovePullRequest(pr, u2, oldHeadId); err != ErrPullApprovalOwnRequest {
+		t.Errorf("ApprovePullRequest(own) error = %v, expected %v", err, ErrPullApprovalOwnRequest)
+	}
+	for _, u := range []*User{writer, reader} {
+		if err := ApprovePullRequest(pr, u, oldHeadId); err != nil {
+			t.Fatalf("ApprovePullRequest(%s): %v", u.Name, err)
+		}
+	}
+
+	// No rule means no approval is required.
+	if err := pr.CheckApprovals(oldHeadId); err != nil {
+		t.Errorf("CheckApprovals(unprotected): %v", err)
+	}
+	pb := &ProtectedBranch{RepoId: repo.Id, BranchName: "master", RequiredApprovals: 2, DismissStale: true}
+	if err := AddProtectedBranch(pb); err != nil {
+		t.Fatalf("AddProtectedBranch: %v", err)
+	}
+	// Approval of user without write access does not count.
+	if err := pr.CheckApprovals(oldHeadId); err != ErrPullRequestNotApproved {
+		t.Errorf("CheckApprovals(2 required) error = %v, expected %v", err, ErrPullRequestNotApproved)
+	}
+	if err := pr.Merge(u1, MERGE_STYLE_MERGE, ""); err != ErrPullRequestNotApproved {
+		t.Errorf("Merge(not approved) error = %v, expected %v", err, ErrPullRequestNotApproved)
+	}
+
+	pb.RequiredApprovals = 1
+	if err := UpdateProtectedBranch(pb); err != nil {
+		t.Fatalf("UpdateProtectedBranch: %v", err)
+	}
+	if err := pr.CheckApprovals(oldHeadId); err != nil {
+		t.Errorf("CheckApprovals(1 required): %v", err)
+	}
+	headId := testCommitFiles(t, repoPath, "feature", "", map[string]string{"b.txt": "b\n"}, "Add b")
+	if err := pr.CheckApprovals(headId); err != ErrPullRequestNotApproved {
+		t.Errorf("CheckApprovals(stale) error = %v, expected %v", err, ErrPullRequestNotApproved)
+	}
+
+	// Approving again updates the existing approval.
+	if err := ApprovePullRequest(pr, writer, headId); err != nil {
+		t.Fatalf("ApprovePullRequest(again): %v", err)
+	}
+	approvals, err := GetPullApprovals(pr.IssueId)
+	if err != nil {
+		t.Fatalf("GetPullApprovals: %v", err)
+	} else if len(approvals) != 2 {
+		t.Errorf("GetPullApprovals returns %d approvals, expected 2", len(approvals))
+	}
+	if err = pr.CheckApprovals(headId); err != nil {
+		t.Errorf("CheckApprovals(approved again): %v", err)
+	}
+
+	if err = DeletePullApproval(pr.IssueId, 0); err != nil {
+		t.Fatalf("DeletePullApproval(zero ID): %v", err)
+	} else if approvals, _ = GetPullApprovals(pr.IssueId); len(approvals) != 2 {
+		t.Errorf("DeletePullApproval(zero ID) leaves %d approvals, expected 2", len(approvals))
+	}
+	if err = DeletePullApproval(pr.IssueId, writer.Id); err != nil {
+		t.Fatalf("DeletePullApproval: %v", err)
+	} else if err = pr.CheckApprovals(headId); err != ErrPullRequestNotApproved {
+		t.Errorf("CheckApprovals(withdrawn) error = %v, expected %v", err, ErrPullRequestNotApproved)
+	}
+}
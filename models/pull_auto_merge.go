@@ -0,0 +1,183 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/gogits/gogs/modules/log"
+)
+
+var (
+	ErrAutoMergeNotEnabled = errors.New("Auto-merge is not enabled for pull request")
+)
+
+// IsAutoMerge returns true if pull request is merged automatically once checks pass.
+func (pr *PullRequest) IsAutoMerge() bool {
+	return len(pr.AutoMergeStyle) > 0
+}
+
+// EnableAutoMerge makes pull request be merged by doer with given style as soon as
+// it has no conflicts, its checks pass and it has enough approvals.
+func (pr *PullRequest) EnableAutoMerge(doer *User, style string) (err error) {
+	if pr.HasMerged {
+		return ErrPullRequestMerged
+	} else if pr.IsDraft {
+		return ErrPullRequestIsDraft
+	}
+	if pr.BaseRepo == nil {
+		if err = pr.GetBaseRepo(); err != nil {
+			return err
+		}
+	}
+	if !pr.BaseRepo.CanMergeWith(style) {
+		return ErrMergeStyleNotAllowed
+	}
+
+	pr.AutoMergeStyle = style
+	pr.AutoMergerId = doer.Id
+	if _, err = orm.Id(pr.Id).Cols("auto_merge_style", "auto_merger_id").Update(pr); err != nil {
+		return err
+	}
+	_, err = CreateComment(doer.Id, pr.BaseRepoId, pr.IssueId, 0, 0, IT_CHANGE, "enabled auto-merge")
+	return err
+}
+
+// DisableAutoMerge turns off auto-merge of pull request, reason is recorded
+// in timeline when it is cancelled automatically.
+func (pr *PullRequest) DisableAutoMerge(doer *User, reason string) (err error) {
+	if !pr.IsAutoMerge() {
+		return ErrAutoMergeNotEnabled
+	}
+
+	if err = pr.clearAutoMerge(); err != nil {
+		return err
+	}
+	content := "disabled auto-merge"
+	if len(reason) > 0 {
+		content = "auto-merge was cancelled: " + reason
+	}
+	_, err = CreateComment(doer.Id, pr.BaseRepoId, pr.IssueId, 0, 0, IT_CHANGE, content)
+	return err
+}
+
+func (pr *PullRequest) clearAutoMerge() error {
+	pr.AutoMergeStyle = ""
+	pr.AutoMergerId = 0
+	_, err := orm.Id(pr.Id).Cols("auto_merge_style", "auto_merger_id").Update(pr)
+	return err
+}
+
+// TryAutoMerge merges pull request if auto-merge is enabled and all conditions are met.
+// Auto-merge keeps waiting while checks are pending or approvals are missing, but is
+// cancelled when pull request has conflicts, checks fail or merge is rejected.
+func (pr *PullRequest) TryAutoMerge() (merged bool, err error) {
+	if !pr.IsAutoMerge() || pr.HasMerged {
+		return false, nil
+	}
+	if pr.Issue == nil {
+		if pr.Issue, err = GetIssueById(pr.IssueId); err != nil {
+			return false, err
+		}
+	}
+	merger, err := GetUserById(pr.AutoMergerId)
+	if err != nil {
+		if err == ErrUserNotExist {
+			return false, pr.clearAutoMerge()
+		}
+		return false, err
+	}
+	if pr.Issue.IsClosed {
+		return false, pr.DisableAutoMerge(merger, "pull request has been closed")
+	} else if pr.IsDraft || pr.Status == PULL_STATUS_CHECKING {
+		return false, nil
+	} else if pr.Status == PULL_STATUS_CONFLICT {
+		return false, pr.DisableAutoMerge(merger, "pull request has conflicts")
+	}
+
+	if err = pr.GetBaseRepo(); err != nil {
+		return false, err
+	} else if err = pr.GetHeadRepo(); err != nil {
+		if err == ErrRepoNotExist {
+			return false, pr.DisableAutoMerge(merger, "head repository no longer exists")
+		}
+		return false, err
+	}
+	// User may have lost permission to merge since it was enabled.
+	if pr.BaseRepo.OwnerId != merger.Id && !merger.IsAdmin {
+		has, err := HasAccess(merger.Name, pr.BaseRepo.Owner.Name+"/"+pr.BaseRepo.Name, AU_WRITABLE)
+		if err != nil {
+			return false, err
+		} else if !has {
+			return false, pr.DisableAutoMerge(merger, merger.Name+" can no longer merge")
+		}
+	}
+
+	headCommitId, err := execGitCmd(RepoPath(pr.HeadRepo.Owner.Name, pr.HeadRepo.Name), nil, nil,
+		"rev-parse", "--verify", "refs/heads/"+pr.HeadBranch)
+	if err != nil {
+		return false, pr.DisableAutoMerge(merger, "head branch no longer exists")
+	}
+	statuses, err := GetLatestCommitStatuses(pr.BaseRepoId, headCommitId)
+	if err != nil {
+		return false, err
+	}
+	switch CombineCommitStatuses(statuses) {
+	case COMMIT_STATUS_PENDING:
+		return false, nil
+	case COMMIT_STATUS_ERROR, COMMIT_STATUS_FAILURE:
+		return false, pr.DisableAutoMerge(merger, "checks have failed")
+	}
+	if err = pr.CheckApprovals(headCommitId); err == ErrPullRequestNotApproved {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	switch err = pr.Merge(merger, pr.AutoMergeStyle, ""); err {
+	case nil:
+	case ErrPullRequestNotMergeable, ErrMergeStyleNotAllowed, ErrPullRequestNotApproved, ErrRepoArchived:
+		return false, pr.DisableAutoMerge(merger, err.Error())
+	default:
+		return false, err
+	}
+
+	return true, pr.clearAutoMerge()
+}
+
+var (
+	autoMergeLock    sync.Mutex
+	autoMergeRunning bool
+)
+
+// AutoMergeUpdate merges pull requests that have auto-merge enabled and meet all conditions.
+func AutoMergeUpdate() {
+	// Skip if previous round is still running.
+	autoMergeLock.Lock()
+	if autoMergeRunning {
+		autoMergeLock.Unlock()
+		return
+	}
+	autoMergeRunning = true
+	autoMergeLock.Unlock()
+	defer func() {
+		autoMergeLock.Lock()
+		autoMergeRunning = false
+		autoMergeLock.Unlock()
+	}()
+
+	prs := make([]*PullRequest, 0, 10)
+	if err := orm.Where("auto_merge_style<>? AND has_merged=?", "", false).Find(&prs); err != nil {
+		log.Error("models.AutoMergeUpdate: %v", err)
+		return
+	}
+
+	for _, pr := range prs {
+		if _, err := pr.TryAutoMerge(); err != nil {
+			log.Error("models.AutoMergeUpdate(%d): %v", pr.Id, err)
+		}
+	}
+}
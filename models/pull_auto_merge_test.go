@@ -0,0 +1,112 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestAutoMerge(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	writer := newTestUser(t, "writer")
+	repo := newTestRepo(t, u1, "repo1")
+	repoPath := RepoPath(u1.Name, repo.Name)
+	if _, err := orm.Insert(&Access{UserName: "writer", RepoName: "user1/repo1", Mode: AU_WRITABLE}); err != nil {
+		t.Fatal(err)
+	}
+
+	// newPull opens a mergeable pull request from a new branch with one commit.
+	newPull := func(branch string) (*PullRequest, string) {
+		headId := testCommitFiles(t, repoPath, branch, "master", map[string]string{branch + ".txt": branch + "\n"}, "Add "+branch)
+		pr := newTestPullRequest(t, repo, u2, branch, "master")
+		pr.Status = PULL_STATUS_MERGEABLE
+		if _, err := orm.Id(pr.Id).Cols("status").Update(pr); err != nil {
+			t.Fatal(err)
+		}
+		return pr, headId
+	}
+	// tryAutoMerge expects result of auto-merge and whether it is still enabled.
+	tryAutoMerge := func(pr *PullRequest, merged, enabled bool) {
+		if ok, err := pr.TryAutoMerge(); err != nil {
+			t.Fatalf("TryAutoMerge(%s): %v", pr.HeadBranch, err)
+		} else if ok != merged {
+			t.Errorf("TryAutoMerge(%s) = %v, expected %v", pr.HeadBranch, ok, merged)
+		}
+		saved, err := GetPullRequestByIssueId(pr.IssueId)
+		if err != nil {
+			t.Fatalf("GetPullRequestByIssueId: %v", err)
+		} else if saved.HasMerged != merged || saved.IsAutoMerge() != enabled {
+			t.Errorf("pull request from %s is (merged %v, auto-merge %v), expected (%v, %v)",
+				pr.HeadBranch, saved.HasMerged, saved.IsAutoMerge(), merged, enabled)
+		}
+	}
+	status := func(sha, state string) {
+		if err := NewCommitStatus(repo, u1, sha, &CommitStatus{State: state, Context: "ci"}); err != nil {
+			t.Fatalf("NewCommitStatus(%s): %v", state, err)
+		}
+	}
+
+	pr, headId := newPull("feature")
+	if err := pr.DisableAutoMerge(u1, ""); err != ErrAutoMergeNotEnabled {
+		t.Errorf("DisableAutoMerge(not enabled) error = %v, expected %v", err, ErrAutoMergeNotEnabled)
+	}
+	if err := pr.EnableAutoMerge(u1, "fast-forward"); err != ErrMergeStyleNotAllowed {
+		t.Errorf("EnableAutoMerge(invalid style) error = %v, expected %v", err, ErrMergeStyleNotAllowed)
+	}
+	if err := pr.EnableAutoMerge(u1, MERGE_STYLE_MERGE); err != nil {
+		t.Fatalf("EnableAutoMerge: %v", err)
+	}
+
+	// Auto-merge waits for mergeability check and pending checks.
+	pr.Status = PULL_STATUS_CHECKING
+	tryAutoMerge(pr, false, true)
+	pr.Status = PULL_STATUS_MERGEABLE
+	status(headId, COMMIT_STATUS_PENDING)
+	tryAutoMerge(pr, false, true)
+	status(headId, COMMIT_STATUS_SUCCESS)
+	tryAutoMerge(pr, true, false)
+	if err := pr.EnableAutoMerge(u1, MERGE_STYLE_MERGE); err != ErrPullRequestMerged {
+		t.Errorf("EnableAutoMerge(merged) error = %v, expected %v", err, ErrPullRequestMerged)
+	}
+
+	// Auto-merge is cancelled with a reason in timeline.
+	conflictPr, _ := newPull("conflict")
+	failPr, failId := newPull("fail")
+	writerPr, _ := newPull("writer")
+	for _, p := range []*PullRequest{conflictPr, failPr} {
+		if err := p.EnableAutoMerge(u1, MERGE_STYLE_MERGE); err != nil {
+			t.Fatalf("EnableAutoMerge(%s): %v", p.HeadBranch, err)
+		}
+	}
+	if err := writerPr.EnableAutoMerge(writer, MERGE_STYLE_MERGE); err != nil {
+		t.Fatalf("EnableAutoMerge(writer): %v", err)
+	}
+	conflictPr.Status = PULL_STATUS_CONFLICT
+	status(failId, COMMIT_STATUS_FAILURE)
+	if _, err := orm.Delete(&Access{UserName: "writer", RepoName: "user1/repo1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		pr     *PullRequest
+		reason string
+	}{
+		{conflictPr, "pull request has conflicts"},
+		{failPr, "checks have failed"},
+		{writerPr, "writer can no longer merge"},
+	}
+	for _, tt := range tests {
+		tryAutoMerge(tt.pr, false, false)
+		comments, err := GetIssueComments(tt.pr.IssueId)
+		if err != nil {
+			t.Fatalf("GetIssueComments: %v", err)
+		}
+		expected := "auto-merge was cancelled: " + tt.reason
+		if n := len(comments); n == 0 || comments[n-1].Content != expected {
+			t.Errorf("last comment of pull request from %s is not %q", tt.pr.HeadBranch, expected)
+		}
+	}
+}
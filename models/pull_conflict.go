@@ -0,0 +1,279 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Unknwon/com"
+
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+)
+
+var (
+	ErrPullRequestNoConflict = errors.New("Pull request has no conflicts")
+	ErrConflictBinaryFile    = errors.New("Conflicts of binary files cannot be resolved in browser")
+	ErrConflictNotResolved   = errors.New("Conflict markers still remain in resolved files")
+)
+
+// Mergeability status of pull requests, new and updated pull requests are checked in background.
+const (
+	PULL_STATUS_CHECKING = iota
+	PULL_STATUS_MERGEABLE
+	PULL_STATUS_CONFLICT
+)
+
+// ConflictFile represents a file that has conflicts when base branch is merged into
+// head branch of pull request, its content has conflict markers.
+type ConflictFile struct {
+	Name     string
+	Content  string
+	IsBinary bool
+}
+
+// GetConflictPaths returns paths of files that have conflicts.
+func (pr *PullRequest) GetConflictPaths() []string {
+	if len(pr.ConflictPaths) == 0 {
+		return nil
+	}
+	return strings.Split(pr.ConflictPaths, "\n")
+}
+
+// mergeBaseIntoHead clones head branch of pull request into given directory and merges base
+// branch into it without committing. It returns latest commit of head branch and paths
+// of files that have conflicts.
+func (pr *PullRequest) mergeBaseIntoHead(tmpDir string) (headCommitId string, conflicts []string, err error) {
+	if pr.HeadRepo == nil {
+		if err = pr.GetHeadRepo(); err != nil {
+			return "", nil, err
+		}
+	}
+	if pr.BaseRepo == nil {
+		if err = pr.GetBaseRepo(); err != nil {
+			return "", nil, err
+		}
+	}
+	headPath := RepoPath(pr.HeadRepo.Owner.Name, pr.HeadRepo.Name)
+	basePath := RepoPath(pr.BaseRepo.Owner.Name, pr.BaseRepo.Name)
+
+	var stdout, stderr string
+	if _, stderr, err = com.ExecCmd("git", "clone", "-b", pr.HeadBranch, headPath, tmpDir); err != nil {
+		return "", nil, errors.New("git clone: " + stderr)
+	}
+	if stdout, stderr, err = com.ExecCmdDir(tmpDir, "git", "rev-parse", "HEAD"); err != nil {
+		return "", nil, errors.New("git rev-parse: " + stderr)
+	}
+	headCommitId = strings.TrimSpace(stdout)
+	if _, stderr, err = com.ExecCmdDir(tmpDir, "git", "fetch", basePath, pr.BaseBranch); err != nil {
+		return "", nil, errors.New("git fetch: " + stderr)
+	}
+
+	if _, stderr, err = com.ExecCmdDir(tmpDir, "git", "-c", "user.name=Gogs", "-c", "user.email=gogs@localhost",
+		"merge", "--no-ff", "--no-commit", "FETCH_HEAD"); err != nil {
+		stdout, _, _ = com.ExecCmdDir(tmpDir, "git", "diff", "--name-only", "--diff-filter=U")
+		if stdout = strings.TrimSpace(stdout); len(stdout) == 0 {
+			return "", nil, errors.New("git merge: " + stderr)
+		}
+		conflicts = strings.Split(stdout, "\n")
+	}
+	return headCommitId, conflicts, nil
+}
+
+// CheckMergeable checks whether pull request can be merged without conflicts and saves result.
+func (pr *PullRequest) CheckMergeable() error {
+	tmpDir := filepath.Join(os.TempDir(), "gogs-check-"+base.ToStr(time.Now().UnixNano()))
+	defer os.RemoveAll(tmpDir)
+
+	_, conflicts, err := pr.mergeBaseIntoHead(tmpDir)
+	if err != nil {
+		return err
+	}
+	pr.Status = PULL_STATUS_MERGEABLE
+	pr.ConflictPaths = ""
+	if len(conflicts) > 0 {
+		pr.Status = PULL_STATUS_CONFLICT
+		pr.ConflictPaths = strings.Join(conflicts, "\n")
+	}
+	_, err = orm.Id(pr.Id).Cols("status", "conflict_paths").Update(pr)
+	return err
+}
+
+// MarkPullRequestsChecking marks open pull requests from or into given branch
+// to be checked again, it is called after branch has been changed.
+func MarkPullRequestsChecking(repoId int64, branch string) error {
+	_, err := orm.Where("has_merged=?", false).
+		And("((head_repo_id=? AND head_branch=?) OR (base_repo_id=? AND base_branch=?))", repoId, branch, repoId, branch).
+		Cols("status").Update(&PullRequest{Status: PULL_STATUS_CHECKING})
+	return err
+}
+
+var (
+	pullCheckLock    sync.Mutex
+	pullCheckRunning bool
+)
+
+// PullRequestCheckUpdate checks mergeability of open pull requests that are pending.
+func PullRequestCheckUpdate() {
+	// Skip if previous round is still running.
+	pullCheckLock.Lock()
+	if pullCheckRunning {
+		pullCheckLock.Unlock()
+		return
+	}
+	pullCheckRunning = true
+	pullCheckLock.Unlock()
+	defer func() {
+		pullCheckLock.Lock()
+		pullCheckRunning = false
+		pullCheckLock.Unlock()
+	}()
+
+	prs := make([]*PullRequest, 0, 10)
+	if err := orm.Where("status=? AND has_merged=?", PULL_STATUS_CHECKING, false).
+		And("issue_id IN (SELECT id FROM issue WHERE is_closed=?)", false).Find(&prs); err != nil {
+		log.Error("models.PullRequestCheckUpdate: %v", err)
+		return
+	}
+
+	for _, pr := range prs {
+		if err := pr.CheckMergeable(); err != nil {
+			// Branches may have been deleted, it is checked again once they are pushed.
+			log.Error("models.PullRequestCheckUpdate(%d): %v", pr.Id, err)
+		}
+	}
+}
+
+// GetConflictFiles returns latest commit of head branch and files that have conflicts
+// when base branch is merged into head branch, with conflict markers in their content.
+func (pr *PullRequest) GetConflictFiles() (string, []*ConflictFile, error) {
+	tmpDir := filepath.Join(os.TempDir(), "gogs-conflict-"+base.ToStr(time.Now().UnixNano()))
+	defer os.RemoveAll(tmpDir)
+
+	headCommitId, conflicts, err := pr.mergeBaseIntoHead(tmpDir)
+	if err != nil {
+		return "", nil, err
+	} else if len(conflicts) == 0 {
+		return headCommitId, nil, ErrPullRequestNoConflict
+	}
+
+	files := make([]*ConflictFile, 0, len(conflicts))
+	for _, name := range conflicts {
+		f := &ConflictFile{Name: name}
+		data, err := ioutil.ReadFile(filepath.Join(tmpDir, name))
+		if err != nil && !os.IsNotExist(err) {
+			return "", nil, err
+		}
+		// File deleted on one side has no markers, its content is resolved as a whole.
+		if _, isText := base.IsTextFile(data); isText {
+			f.Content = string(data)
+		} else {
+			f.IsBinary = true
+		}
+		files = append(files, f)
+	}
+	return headCommitId, files, nil
+}
+
+// hasConflictMarkers returns true if any line of content starts a conflict marker.
+func hasConflictMarkers(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "<<<<<<< ") || strings.HasPrefix(line, ">>>>>>> ") {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveConflicts merges base branch into head branch of pull request with given content
+// of every conflicted file, and pushes the merge commit to head branch. Head branch must
+// still be on given commit that conflicts were resolved against.
+func (pr *PullRequest) ResolveConflicts(doer *User, headCommitId string, contents map[string]string) (err error) {
+	if pr.HasMerged {
+		return ErrPullRequestMerged
+	}
+	if pr.HeadRepo == nil {
+		if err = pr.GetHeadRepo(); err != nil {
+			return err
+		}
+	}
+	if pr.HeadRepo.IsArchived {
+		return ErrRepoArchived
+	}
+
+	tmpDir := filepath.Join(os.TempDir(), "gogs-conflict-"+base.ToStr(time.Now().UnixNano()))
+	defer os.RemoveAll(tmpDir)
+
+	commitId, conflicts, err := pr.mergeBaseIntoHead(tmpDir)
+	if err != nil {
+		return err
+	} else if commitId != headCommitId {
+		return ErrRepoFileChanged
+	} else if len(conflicts) == 0 {
+		return ErrPullRequestNoConflict
+	}
+
+	for _, name := range conflicts {
+		content, ok := contents[name]
+		if !ok || hasConflictMarkers(content) {
+			return ErrConflictNotResolved
+		}
+		fpath := filepath.Join(tmpDir, name)
+		data, err := ioutil.ReadFile(fpath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		} else if _, isText := base.IsTextFile(data); !isText {
+			return ErrConflictBinaryFile
+		}
+		// Browsers submit text with CRLF line endings.
+		if !strings.Contains(string(data), "\r\n") {
+			content = strings.Replace(content, "\r\n", "\n", -1)
+		}
+		// Empty content keeps file deleted if it has been deleted on one side.
+		if data == nil && len(content) == 0 {
+			continue
+		}
+		if err = os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+			return err
+		} else if err = ioutil.WriteFile(fpath, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+
+	var stdout, stderr string
+	if _, stderr, err = com.ExecCmdDir(tmpDir, "git", "add", "--all"); err != nil {
+		return errors.New("git add: " + stderr)
+	}
+	sig := doer.NewGitSig()
+	if _, stderr, err = com.ExecCmdDir(tmpDir, "git", "-c", "user.name="+sig.Name, "-c", "user.email="+sig.Email,
+		"commit", "--no-edit", "-m", fmt.Sprintf("Merge branch '%s' into %s", pr.BaseBranch, pr.HeadBranch)); err != nil {
+		return errors.New("git commit: " + stderr)
+	}
+	if stdout, stderr, err = com.ExecCmdDir(tmpDir, "git", "rev-parse", "HEAD"); err != nil {
+		return errors.New("git rev-parse: " + stderr)
+	}
+	newCommitId := strings.TrimSpace(stdout)
+
+	// Merge commit always fast-forwards the branch, so protection rules can be checked
+	// before it is pushed.
+	refName := "refs/heads/" + pr.HeadBranch
+	headPath := RepoPath(pr.HeadRepo.Owner.Name, pr.HeadRepo.Name)
+	if err = CheckBranchPush(pr.HeadRepo, headPath, doer.Id, refName, headCommitId, headCommitId); err != nil {
+		return err
+	}
+	SetRepoEnvs(doer.Id, doer.Name, pr.HeadRepo.Name, pr.HeadRepo.Owner.Name)
+	if _, stderr, err = com.ExecCmdDir(tmpDir, "git", "push", "origin", "HEAD:"+refName); err != nil {
+		return errors.New("git push: " + stderr)
+	}
+
+	return Update(refName, headCommitId, newCommitId, doer.Name, pr.HeadRepo.Owner.Name, pr.HeadRepo.Name, doer.Id)
+}
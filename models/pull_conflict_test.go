@@ -0,0 +1,150 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHasConflictMarkers(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected bool
+	}{
+		{"<<<<<<< HEAD\na\n=======\nb\n>>>>>>> FETCH_HEAD\n", true},
+		{"a\n>>>>>>> FETCH_HEAD", true},
+		{"a\n=======\nb\n", false},
+		{"a <<<<<<< b\n", false},
+		{"<<<<<<<\n", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if has := hasConflictMarkers(tt.content); has != tt.expected {
+			t.Errorf("hasConflictMarkers(%q) = %v, expected %v", tt.content, has, tt.expected)
+		}
+	}
+}
+
+func TestGetConflictPaths(t *testing.T) {
+	tests := []struct {
+		paths    string
+		expected []string
+	}{
+		{"", nil},
+		{"a.txt", []string{"a.txt"}},
+		{"a.txt\ndocs/b.md", []string{"a.txt", "docs/b.md"}},
+	}
+	for _, tt := range tests {
+		pr := &PullRequest{ConflictPaths: tt.paths}
+		if paths := pr.GetConflictPaths(); !reflect.DeepEqual(paths, tt.expected) {
+			t.Errorf("GetConflictPaths(%q) = %v, expected %v", tt.paths, paths, tt.expected)
+		}
+	}
+}
+
+func TestResolveConflicts(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	repo := newTestRepo(t, u1, "repo1")
+	repoPath := RepoPath(u1.Name, repo.Name)
+
+	testCommitFiles(t, repoPath, "master", "", map[string]string{"a.txt": "base\n", "logo.bin": "\x00base"}, "Add files")
+	headId := testCommitFiles(t, repoPath, "feature", "master", map[string]string{"a.txt": "feature\n"}, "Change a")
+	testCommitFiles(t, repoPath, "binary", "master", map[string]string{"logo.bin": "\x00binary"}, "Change logo")
+	testCommitFiles(t, repoPath, "done", "master", map[string]string{"c.txt": "c\n"}, "Add c")
+	testCommitFiles(t, repoPath, "master", "", map[string]string{"a.txt": "master\n", "logo.bin": "\x00master"}, "Change both")
+	pr := newTestPullRequest(t, repo, u2, "feature", "master")
+	binPr := newTestPullRequest(t, repo, u2, "binary", "master")
+	donePr := newTestPullRequest(t, repo, u2, "done", "master")
+
+	if err := pr.CheckMergeable(); err != nil {
+		t.Fatalf("CheckMergeable: %v", err)
+	} else if pr.Status != PULL_STATUS_CONFLICT || pr.ConflictPaths != "a.txt" {
+		t.Errorf("CheckMergeable = (%d, %q), expected (%d, %q)", pr.Status, pr.ConflictPaths, PULL_STATUS_CONFLICT, "a.txt")
+	}
+	commitId, files, err := pr.GetConflictFiles()
+	if err != nil {
+		t.Fatalf("GetConflictFiles: %v", err)
+	} else if commitId != headId {
+		t.Errorf("GetConflictFiles returns head commit %s, expected %s", commitId, headId)
+	}
+	if len(files) != 1 || files[0].Name != "a.txt" || files[0].IsBinary || !hasConflictMarkers(files[0].Content) {
+		t.Fatalf("GetConflictFiles returns %d files, expected a.txt with conflict markers", len(files))
+	}
+
+	if _, binFiles, err := binPr.GetConflictFiles(); err != nil {
+		t.Fatalf("GetConflictFiles(binary): %v", err)
+	} else if len(binFiles) != 1 || !binFiles[0].IsBinary {
+		t.Errorf("GetConflictFiles(binary) returns %d files, expected a binary file", len(binFiles))
+	}
+	binHeadId, _ := ResolveCommitId(repoPath, "binary")
+	if err = binPr.ResolveConflicts(u2, binHeadId, map[string]string{"logo.bin": "logo"}); err != ErrConflictBinaryFile {
+		t.Errorf("ResolveConflicts(binary) error = %v, expected %v", err, ErrConflictBinaryFile)
+	}
+
+	tests := []struct {
+		commitId string
+		contents map[string]string
+		expected error
+	}{
+		{pr.MergeBase, map[string]string{"a.txt": "resolved\n"}, ErrRepoFileChanged},
+		{headId, map[string]string{"b.txt": "resolved\n"}, ErrConflictNotResolved},
+		{headId, map[string]string{"a.txt": files[0].Content}, ErrConflictNotResolved},
+	}
+	for i, tt := range tests {
+		if err = pr.ResolveConflicts(u2, tt.commitId, tt.contents); err != tt.expected {
+			t.Errorf("#%d: ResolveConflicts error = %v, expected %v", i, err, tt.expected)
+		}
+	}
+
+	binPr.Status = PULL_STATUS_MERGEABLE
+	donePr.HasMerged, donePr.Status = true, PULL_STATUS_MERGEABLE
+	for _, p := range []*PullRequest{binPr, donePr} {
+		if _, err = orm.Id(p.Id).Cols("has_merged", "status").Update(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// checkStatus reports mismatch of saved status of pull requests.
+	checkStatus := func(expected map[*PullRequest]int) {
+		for p, status := range expected {
+			saved, err := GetPullRequestByIssueId(p.IssueId)
+			if err != nil {
+				t.Fatalf("GetPullRequestByIssueId: %v", err)
+			} else if saved.Status != status {
+				t.Errorf("status of pull request from %s is %d, expected %d", p.HeadBranch, saved.Status, status)
+			}
+		}
+	}
+
+	// Browser submits CRLF line endings.
+	if err = pr.ResolveConflicts(u2, headId, map[string]string{"a.txt": "resolved\r\n"}); err != nil {
+		t.Fatalf("ResolveConflicts: %v", err)
+	}
+	if content, err := execGitCmd(repoPath, nil, nil, "cat-file", "-p", "feature:a.txt"); err != nil || content != "resolved" {
+		t.Errorf("resolved content is (%q, %v), expected %q", content, err, "resolved")
+	}
+	if parents, err := execGitCmd(repoPath, nil, nil, "log", "-1", "--format=%P", "feature"); err != nil || len(strings.Fields(parents)) != 2 {
+		t.Errorf("resolution has parents (%q, %v), expected 2", parents, err)
+	}
+
+	// Pushing the resolution only marks pull requests of feature branch for checking.
+	checkStatus(map[*PullRequest]int{pr: PULL_STATUS_CHECKING, binPr: PULL_STATUS_MERGEABLE, donePr: PULL_STATUS_MERGEABLE})
+	// Merged pull request into the same base branch is not checked again.
+	if err = MarkPullRequestsChecking(repo.Id, "master"); err != nil {
+		t.Fatalf("MarkPullRequestsChecking: %v", err)
+	}
+	checkStatus(map[*PullRequest]int{binPr: PULL_STATUS_CHECKING, donePr: PULL_STATUS_MERGEABLE})
+
+	if err = pr.CheckMergeable(); err != nil {
+		t.Fatalf("CheckMergeable(resolved): %v", err)
+	} else if pr.Status != PULL_STATUS_MERGEABLE || len(pr.ConflictPaths) != 0 {
+		t.Errorf("CheckMergeable(resolved) = (%d, %q), expected (%d, %q)", pr.Status, pr.ConflictPaths, PULL_STATUS_MERGEABLE, "")
+	}
+	if _, _, err = pr.GetConflictFiles(); err != ErrPullRequestNoConflict {
+		t.Errorf("GetConflictFiles(resolved) error = %v, expected %v", err, ErrPullRequestNoConflict)
+	}
+}
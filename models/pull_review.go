@@ -0,0 +1,511 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Unknwon/com"
+	"github.com/go-xorm/xorm"
+)
+
+var (
+	ErrReviewThreadNotExist  = errors.New("Review thread does not exist")
+	ErrReviewLineNotExist    = errors.New("Line does not exist in diff of pull request")
+	ErrPullReviewNotExist    = errors.New("Review does not exist")
+	ErrPullReviewEmpty       = errors.New("Review needs a summary or comments")
+	ErrReviewCommentNotExist = errors.New("Review comment does not exist")
+	ErrSuggestionNotExist    = errors.New("Comment does not suggest a change")
+	ErrSuggestionApplied     = errors.New("Suggested change has already been applied")
+	ErrSuggestionOutdated    = errors.New("Line of suggested change has been changed in head branch")
+)
+
+// Review types, pending review and its comments are only visible to reviewer until submitted.
+const (
+	REVIEW_PENDING = iota
+	REVIEW_COMMENT
+	REVIEW_APPROVE
+	REVIEW_REQUEST_CHANGES
+)
+
+// PullReview represents a review of pull request that batches review comments
+// with a summary and verdict.
+type PullReview struct {
+	Id              int64
+	IssueId         int64 `xorm:"INDEX"`
+	ReviewerId      int64 `xorm:"INDEX"`
+	Reviewer        *User `xorm:"-"`
+	Type            int
+	Content         string `xorm:"TEXT"`
+	RenderedContent string `xorm:"-"`
+	CommitId        string // Head commit when submitted.
+	NumComments     int
+	Created         time.Time `xorm:"CREATED"`
+	Submitted       time.Time
+}
+
+// GetPendingReview returns pending review of user on pull request.
+func GetPendingReview(issueId, uid int64) (*PullReview, error) {
+	r := new(PullReview)
+	has, err := orm.Where("issue_id=? AND reviewer_id=? AND type=?", issueId, uid, REVIEW_PENDING).Get(r)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPullReviewNotExist
+	}
+	return r, nil
+}
+
+// GetOrCreatePendingReview returns pending review of user on pull request,
+// a new one is started if user has none.
+func GetOrCreatePendingReview(issueId, uid int64) (*PullReview, error) {
+	r, err := GetPendingReview(issueId, uid)
+	if err != ErrPullReviewNotExist {
+		return r, err
+	}
+	r = &PullReview{IssueId: issueId, ReviewerId: uid, Type: REVIEW_PENDING}
+	_, err = orm.Insert(r)
+	return r, err
+}
+
+// SubmitPullReview submits pending review of reviewer, or a new one without comments,
+// with given verdict and summary. Approving counts towards required approvals of pull
+// request and requesting changes withdraws previous approval of reviewer.
+func SubmitPullReview(pr *PullRequest, reviewer *User, reviewType int, content, commitId string) (*PullReview, error) {
+	if reviewType != REVIEW_COMMENT && pr.Issue.PosterId == reviewer.Id {
+		return nil, ErrPullApprovalOwnRequest
+	}
+
+	r, err := GetPendingReview(pr.IssueId, reviewer.Id)
+	if err == ErrPullReviewNotExist {
+		r = &PullReview{IssueId: pr.IssueId, ReviewerId: reviewer.Id}
+	} else if err != nil {
+		return nil, err
+	}
+	if reviewType == REVIEW_COMMENT && len(content) == 0 && r.NumComments == 0 {
+		return nil, ErrPullReviewEmpty
+	}
+
+	r.Type = reviewType
+	r.Content = content
+	r.CommitId = commitId
+	r.Submitted = time.Now()
+	if r.Id > 0 {
+		_, err = orm.Id(r.Id).AllCols().Update(r)
+	} else {
+		_, err = orm.Insert(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = DeleteReviewRequest(pr.IssueId, reviewer.Id); err != nil {
+		return nil, err
+	}
+
+	switch reviewType {
+	case REVIEW_APPROVE:
+		err = ApprovePullRequest(pr, reviewer, commitId)
+	case REVIEW_REQUEST_CHANGES:
+		err = DeletePullApproval(pr.IssueId, reviewer.Id)
+	}
+	return r, err
+}
+
+// GetPullReviews returns submitted reviews of pull request with their reviewers,
+// reviews of reviewers that no longer exist are skipped.
+func GetPullReviews(issueId int64) ([]*PullReview, error) {
+	reviews := make([]*PullReview, 0, 5)
+	if err := orm.Where("issue_id=? AND type>?", issueId, REVIEW_PENDING).Asc("submitted").Find(&reviews); err != nil {
+		return nil, err
+	}
+
+	var err error
+	valid := reviews[:0]
+	for _, r := range reviews {
+		if r.Reviewer, err = GetUserById(r.ReviewerId); err != nil {
+			if err == ErrUserNotExist {
+				continue
+			}
+			return nil, err
+		}
+		valid = append(valid, r)
+	}
+	return valid, nil
+}
+
+// ReviewThread represents a thread of review comments on a line of pull request diff.
+type ReviewThread struct {
+	Id          int64
+	IssueId     int64 `xorm:"INDEX"`
+	PosterId    int64
+	TreePath    string
+	Line        int    // Line number in the side of diff that has been commented.
+	IsOldSide   bool   // Comment is on a deleted line, so line number refers to base version.
+	LineContent string `xorm:"TEXT"` // Used to find the line again after head branch changes.
+	CommitId    string // Head commit the line number refers to.
+	IsResolved  bool
+	ResolverId  int64
+	Resolver    *User            `xorm:"-"`
+	IsOutdated  bool             `xorm:"-"` // Line cannot be found in current diff.
+	Comments    []*ReviewComment `xorm:"-"`
+	Created     time.Time        `xorm:"CREATED"`
+	Updated     time.Time        `xorm:"UPDATED"`
+}
+
+// ReviewComment represents a comment in review thread.
+type ReviewComment struct {
+	Id              int64
+	ThreadId        int64 `xorm:"INDEX"`
+	ReviewId        int64 `xorm:"INDEX"` // 0 if comment is not part of a review.
+	PosterId        int64
+	Poster          *User     `xorm:"-"`
+	Content         string    `xorm:"TEXT"`
+	RenderedContent string    `xorm:"-"`
+	IsPending       bool      `xorm:"-"`
+	IsApplied       bool      // Suggested change has been committed to head branch.
+	CanApply        bool      `xorm:"-"`
+	Created         time.Time `xorm:"CREATED"`
+}
+
+// suggestionPattern matches fenced code block with "suggestion" as language,
+// its content replaces the commented line.
+var suggestionPattern = regexp.MustCompile("(?s)```suggestion[ \\t]*\\r?\\n(.*?)\\r?\\n?```")
+
+// Suggestion returns suggested content of commented line in comment, if any.
+func (c *ReviewComment) Suggestion() (string, bool) {
+	m := suggestionPattern.FindStringSubmatch(c.Content)
+	if m == nil {
+		return "", false
+	}
+	return strings.Replace(m[1], "\r\n", "\n", -1), true
+}
+
+// addReviewComment inserts comment into thread, and counts it in review it belongs to.
+func addReviewComment(sess *xorm.Session, c *ReviewComment) error {
+	if _, err := sess.Insert(c); err != nil {
+		return err
+	} else if c.ReviewId > 0 {
+		if _, err = sess.Exec("UPDATE `pull_review` SET num_comments = num_comments + 1 WHERE id = ?", c.ReviewId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lineContent returns content of diff line without the leading change mark.
+func lineContent(line *DiffLine) string {
+	if len(line.Content) == 0 {
+		return ""
+	}
+	return line.Content[1:]
+}
+
+// lineIndex returns line number of diff line in given side of diff, or 0 if line
+// does not exist in that side.
+func lineIndex(line *DiffLine, isOldSide bool) int {
+	if isOldSide {
+		return line.LeftIdx
+	}
+	return line.RightIdx
+}
+
+// findDiffLine returns line of file in diff by line number in given side.
+func findDiffLine(diff *Diff, treePath string, line int, isOldSide bool) *DiffLine {
+	for _, f := range diff.Files {
+		if f.Name != treePath {
+			continue
+		}
+		for _, sec := range f.Sections {
+			for _, l := range sec.Lines {
+				if l.Type != DIFF_LINE_SECTION && lineIndex(l, isOldSide) == line {
+					return l
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// NewReviewThread starts a review thread with its first comment on given line of
+// pull request diff at given head commit, comment is added to given pending review if any.
+func NewReviewThread(diff *Diff, t *ReviewThread, reviewId int64, content string) (err error) {
+	line := findDiffLine(diff, t.TreePath, t.Line, t.IsOldSide)
+	if line == nil {
+		return ErrReviewLineNotExist
+	}
+	t.LineContent = lineContent(line)
+
+	sess := orm.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err = sess.Insert(t); err != nil {
+		sess.Rollback()
+		return err
+	} else if err = addReviewComment(sess, &ReviewComment{
+		ThreadId: t.Id,
+		ReviewId: reviewId,
+		PosterId: t.PosterId,
+		Content:  content,
+	}); err != nil {
+		sess.Rollback()
+		return err
+	}
+	return sess.Commit()
+}
+
+// GetReviewThreadById returns review thread of pull request by given ID.
+func GetReviewThreadById(issueId, id int64) (*ReviewThread, error) {
+	t := new(ReviewThread)
+	has, err := orm.Where("id=? AND issue_id=?", id, issueId).Get(t)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrReviewThreadNotExist
+	}
+	return t, nil
+}
+
+// GetReviewThreads returns review threads of pull request with their comments visible to
+// given user, comments of pending reviews are only visible to their reviewers.
+func GetReviewThreads(issueId, viewerId int64) ([]*ReviewThread, error) {
+	pendings := make([]*PullReview, 0, 2)
+	if err := orm.Where("issue_id=? AND type=?", issueId, REVIEW_PENDING).Find(&pendings); err != nil {
+		return nil, err
+	}
+	isOwnPending := make(map[int64]bool, len(pendings))
+	for _, r := range pendings {
+		isOwnPending[r.Id] = r.ReviewerId == viewerId
+	}
+
+	threads := make([]*ReviewThread, 0, 5)
+	if err := orm.Where("issue_id=?", issueId).Asc("created").Find(&threads); err != nil {
+		return nil, err
+	}
+
+	var err error
+	valid := threads[:0]
+	for _, t := range threads {
+		if t.IsResolved && t.ResolverId > 0 {
+			if t.Resolver, err = GetUserById(t.ResolverId); err != nil && err != ErrUserNotExist {
+				return nil, err
+			}
+		}
+		comments := make([]*ReviewComment, 0, 3)
+		if err = orm.Where("thread_id=?", t.Id).Asc("created").Find(&comments); err != nil {
+			return nil, err
+		}
+		t.Comments = comments[:0]
+		for _, c := range comments {
+			if isOwn, ok := isOwnPending[c.ReviewId]; ok {
+				if !isOwn {
+					continue
+				}
+				c.IsPending = true
+			}
+			if c.Poster, err = GetUserById(c.PosterId); err != nil {
+				if err != ErrUserNotExist {
+					return nil, err
+				}
+				c.Poster = &User{Name: "Ghost"}
+			}
+			t.Comments = append(t.Comments, c)
+		}
+		if len(t.Comments) > 0 {
+			valid = append(valid, t)
+		}
+	}
+	return valid, nil
+}
+
+// NewReviewComment adds a reply to review thread, reply is added to given pending review if any.
+func NewReviewComment(t *ReviewThread, posterId, reviewId int64, content string) (err error) {
+	sess := orm.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if err = addReviewComment(sess, &ReviewComment{
+		ThreadId: t.Id,
+		ReviewId: reviewId,
+		PosterId: posterId,
+		Content:  content,
+	}); err != nil {
+		sess.Rollback()
+		return err
+	} else if _, err = sess.Id(t.Id).Cols("updated").Update(t); err != nil {
+		sess.Rollback()
+		return err
+	}
+	return sess.Commit()
+}
+
+// GetReviewCommentById returns comment of review thread by given ID.
+func GetReviewCommentById(threadId, id int64) (*ReviewComment, error) {
+	c := new(ReviewComment)
+	has, err := orm.Where("id=? AND thread_id=?", id, threadId).Get(c)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrReviewCommentNotExist
+	}
+	return c, nil
+}
+
+// ResolveReviewThread marks review thread as resolved or unresolved by given user.
+func ResolveReviewThread(t *ReviewThread, doer *User, isResolved bool) error {
+	t.IsResolved = isResolved
+	t.ResolverId = 0
+	if isResolved {
+		t.ResolverId = doer.Id
+	}
+	_, err := orm.Id(t.Id).Cols("is_resolved", "resolver_id").Update(t)
+	return err
+}
+
+// AttachReviewThreads attaches review threads to lines of current diff of pull request.
+// Threads started on an older head commit are mapped to the line of same file and content
+// closest to their previous position, so they survive force pushes. New positions are saved,
+// and threads whose line no longer exists are marked as outdated.
+func AttachReviewThreads(diff *Diff, threads []*ReviewThread, headCommitId string) error {
+	for _, t := range threads {
+		// Content of file may be collapsed or on another page of diff.
+		if f := diff.GetFile(t.TreePath); f != nil && !diff.IsFileShown(f) {
+			continue
+		}
+
+		var line *DiffLine
+		if t.CommitId == headCommitId {
+			line = findDiffLine(diff, t.TreePath, t.Line, t.IsOldSide)
+		} else {
+			line = locateReviewLine(diff, t)
+		}
+		if line == nil || lineContent(line) != t.LineContent {
+			t.IsOutdated = true
+			continue
+		}
+		line.Threads = append(line.Threads, t)
+
+		if t.CommitId != headCommitId {
+			t.Line = lineIndex(line, t.IsOldSide)
+			t.CommitId = headCommitId
+			if _, err := orm.Id(t.Id).Cols("line", "commit_id").Update(t); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// locateReviewLine returns line of same file and content as review thread in diff
+// that is closest to previous position of thread.
+func locateReviewLine(diff *Diff, t *ReviewThread) *DiffLine {
+	var found *DiffLine
+	distance := -1
+	for _, f := range diff.Files {
+		if f.Name != t.TreePath {
+			continue
+		}
+		for _, sec := range f.Sections {
+			for _, l := range sec.Lines {
+				idx := lineIndex(l, t.IsOldSide)
+				if l.Type == DIFF_LINE_SECTION || idx == 0 || lineContent(l) != t.LineContent {
+					continue
+				}
+				d := idx - t.Line
+				if d < 0 {
+					d = -d
+				}
+				if distance == -1 || d < distance {
+					found, distance = l, d
+				}
+			}
+		}
+	}
+	return found
+}
+
+// ApplyReviewSuggestion commits suggested change of review comment to head branch of pull request,
+// authored by poster of comment, and resolves the thread. Suggestions only apply to lines
+// of head branch that have not been changed since the comment.
+func ApplyReviewSuggestion(doer *User, pr *PullRequest, t *ReviewThread, c *ReviewComment) (err error) {
+	if c.IsApplied {
+		return ErrSuggestionApplied
+	}
+	suggestion, ok := c.Suggestion()
+	if !ok || t.IsOldSide {
+		return ErrSuggestionNotExist
+	}
+	// Comments of pending reviews are not visible to others yet.
+	if c.ReviewId > 0 {
+		has, err := orm.Where("id=? AND type=?", c.ReviewId, REVIEW_PENDING).Get(new(PullReview))
+		if err != nil {
+			return err
+		} else if has {
+			return ErrSuggestionNotExist
+		}
+	}
+
+	if pr.HeadRepo == nil {
+		if err = pr.GetHeadRepo(); err != nil {
+			return err
+		}
+	}
+	if pr.HeadRepo.IsArchived {
+		return ErrRepoArchived
+	}
+	repoPath := RepoPath(pr.HeadRepo.Owner.Name, pr.HeadRepo.Name)
+	commitId, err := execGitCmd(repoPath, nil, nil, "rev-parse", "--verify", "refs/heads/"+pr.HeadBranch)
+	if err != nil {
+		return ErrBranchNotExist
+	}
+	content, _, err := com.ExecCmdDir(repoPath, "git", "show", commitId+":"+t.TreePath)
+	if err != nil {
+		return ErrSuggestionOutdated
+	}
+	lines := strings.Split(content, "\n")
+	idx := t.Line - 1
+	if idx < 0 || idx >= len(lines) || lines[idx] != t.LineContent {
+		return ErrSuggestionOutdated
+	}
+
+	newLines := make([]string, 0, len(lines)+5)
+	newLines = append(newLines, lines[:idx]...)
+	if len(suggestion) > 0 {
+		newLines = append(newLines, strings.Split(suggestion, "\n")...)
+	}
+	newLines = append(newLines, lines[idx+1:]...)
+
+	poster, err := GetUserById(c.PosterId)
+	if err != nil {
+		return err
+	}
+	if err = CommitRepoFileChange(doer, pr.HeadRepo, &RepoFileChange{
+		OldBranch:    pr.HeadBranch,
+		NewBranch:    pr.HeadBranch,
+		LastCommitId: commitId,
+		OldTreeName:  t.TreePath,
+		NewTreeName:  t.TreePath,
+		Content:      strings.Join(newLines, "\n"),
+		Message:      fmt.Sprintf("Apply suggestion to %s from code review", t.TreePath),
+		Author:       poster,
+	}); err != nil {
+		return err
+	}
+
+	c.IsApplied = true
+	if _, err = orm.Id(c.Id).Cols("is_applied").Update(c); err != nil {
+		return err
+	}
+	return ResolveReviewThread(t, doer, true)
+}
@@ -0,0 +1,348 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestLocateReviewLine(t *testing.T) {
+	diff := &Diff{Files: []*DiffFile{
+		{Name: "b.txt", Sections: []*DiffSection{{Lines: []*DiffLine{
+			{LeftIdx: 1, RightIdx: 1, Type: DIFF_LINE_PLAIN, Content: " same"},
+		}}}},
+		{Name: "a.txt", Sections: []*DiffSection{{Lines: []*DiffLine{
+			{Type: DIFF_LINE_SECTION, Content: "@@ -1,4 +1,6 @@"},
+			{LeftIdx: 1, RightIdx: 1, Type: DIFF_LINE_PLAIN, Content: " same"},
+			{LeftIdx: 2, Type: DIFF_LINE_DEL, Content: "-old"},
+			{RightIdx: 2, Type: DIFF_LINE_ADD, Content: "+new"},
+			{LeftIdx: 3, RightIdx: 3, Type: DIFF_LINE_PLAIN, Content: " same"},
+			{RightIdx: 4, Type: DIFF_LINE_ADD, Content: "+same"},
+			{LeftIdx: 4, RightIdx: 5, Type: DIFF_LINE_PLAIN, Content: " end"},
+		}}}},
+	}}
+
+	tests := []struct {
+		treePath, content string
+		line              int
+		isOldSide         bool
+		expected          int // Line number in the same side, 0 if not found.
+	}{
+		{"a.txt", "same", 1, false, 1},
+		{"a.txt", "same", 3, false, 3},
+		{"a.txt", "same", 5, false, 4},
+		{"a.txt", "same", 9, true, 3},
+		{"a.txt", "new", 7, false, 2},
+		{"a.txt", "new", 2, true, 0},
+		{"a.txt", "old", 1, true, 2},
+		{"a.txt", "gone", 1, false, 0},
+		{"c.txt", "same", 1, false, 0},
+	}
+	for _, tt := range tests {
+		th := &ReviewThread{TreePath: tt.treePath, LineContent: tt.content, Line: tt.line, IsOldSide: tt.isOldSide}
+		idx := 0
+		if l := locateReviewLine(diff, th); l != nil {
+			idx = lineIndex(l, tt.isOldSide)
+		}
+		if idx != tt.expected {
+			t.Errorf("locateReviewLine(%s:%d %q, old side %v) = line %d, expected %d", tt.treePath, tt.line, tt.content, tt.isOldSide, idx, tt.expected)
+		}
+	}
+}
+
+func TestReviewThreads(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	repo := newTestRepo(t, u1, "repo1")
+	repoPath := RepoPath(u1.Name, repo.Name)
+
+	headId := testCommitFiles(t, repoPath, "feature", "master", map[string]string{"a.txt": "one\ntwo\nthree\n"}, "Add a")
+	pr := newTestPullRequest(t, repo, u2, "feature", "master")
+	diff, err := GetDiffRange(repoPath, pr.MergeBase, headId)
+	if err != nil {
+		t.Fatalf("GetDiffRange: %v", err)
+	}
+
+	th := &ReviewThread{IssueId: pr.IssueId, PosterId: u1.Id, TreePath: "a.txt", Line: 9, CommitId: headId}
+	if err = NewReviewThread(diff, th, 0, "Nowhere"); err != ErrReviewLineNotExist {
+		t.Errorf("NewReviewThread(line 9) error = %v, expected %v", err, ErrReviewLineNotExist)
+	}
+	th.Line = 2
+	if err = NewReviewThread(diff, th, 0, "Why two?"); err != nil {
+		t.Fatalf("NewReviewThread: %v", err)
+	} else if th.LineContent != "two" {
+		t.Errorf("thread remembers line content %q, expected %q", th.LineContent, "two")
+	}
+	if err = NewReviewComment(th, u2.Id, 0, "Because"); err != nil {
+		t.Fatalf("NewReviewComment: %v", err)
+	}
+
+	if _, err = GetReviewThreadById(pr.IssueId+1, th.Id); err != ErrReviewThreadNotExist {
+		t.Errorf("GetReviewThreadById(other issue) error = %v, expected %v", err, ErrReviewThreadNotExist)
+	}
+	if _, err = GetReviewThreadById(0, th.Id); err != ErrReviewThreadNotExist {
+		t.Errorf("GetReviewThreadById(zero issue) error = %v, expected %v", err, ErrReviewThreadNotExist)
+	}
+	if th, err = GetReviewThreadById(pr.IssueId, th.Id); err != nil {
+		t.Fatalf("GetReviewThreadById: %v", err)
+	}
+	if err = ResolveReviewThread(th, u2, true); err != nil {
+		t.Fatalf("ResolveReviewThread: %v", err)
+	}
+
+	threads, err := GetReviewThreads(pr.IssueId, u1.Id)
+	if err != nil {
+		t.Fatalf("GetReviewThreads: %v", err)
+	} else if len(threads) != 1 {
+		t.Fatalf("GetReviewThreads returns %d threads, expected 1", len(threads))
+	}
+	if n := len(threads[0].Comments); n != 2 {
+		t.Errorf("thread has %d comments, expected 2", n)
+	} else if threads[0].Comments[1].Poster.Name != u2.Name {
+		t.Errorf("reply is posted by %q, expected %q", threads[0].Comments[1].Poster.Name, u2.Name)
+	}
+	if !threads[0].IsResolved || threads[0].Resolver == nil || threads[0].Resolver.Id != u2.Id {
+		t.Errorf("thread is not resolved by %s", u2.Name)
+	}
+
+	// Inserting a line above moves thread down with its line.
+	newHeadId := testCommitFiles(t, repoPath, "feature", "", map[string]string{"a.txt": "zero\none\ntwo\nthree\n"}, "Add zero")
+	if diff, err = GetDiffRange(repoPath, pr.MergeBase, newHeadId); err != nil {
+		t.Fatalf("GetDiffRange(line inserted): %v", err)
+	}
+	if err = AttachReviewThreads(diff, threads, newHeadId); err != nil {
+		t.Fatalf("AttachReviewThreads: %v", err)
+	} else if threads[0].IsOutdated {
+		t.Fatal("thread is outdated after line is inserted")
+	}
+	if th, err = GetReviewThreadById(pr.IssueId, th.Id); err != nil {
+		t.Fatalf("GetReviewThreadById(moved): %v", err)
+	} else if th.Line != 3 || th.CommitId != newHeadId {
+		t.Errorf("thread is on line %d of %s, expected line 3 of %s", th.Line, th.CommitId, newHeadId)
+	}
+	if l := findDiffLine(diff, "a.txt", 3, false); l == nil || len(l.Threads) != 1 {
+		t.Error("thread is not attached to line 3 of diff")
+	}
+
+	// Thread whose line is removed is outdated.
+	lastId := testCommitFiles(t, repoPath, "feature", "", map[string]string{"a.txt": "zero\none\nthree\n"}, "Remove two")
+	if diff, err = GetDiffRange(repoPath, pr.MergeBase, lastId); err != nil {
+		t.Fatalf("GetDiffRange(line removed): %v", err)
+	}
+	threads[0].IsOutdated = false
+	if err = AttachReviewThreads(diff, threads, lastId); err != nil {
+		t.Fatalf("AttachReviewThreads(line removed): %v", err)
+	} else if !threads[0].IsOutdated {
+		t.Error("thread of removed line is not outdated")
+	}
+}
+
+func TestPullReviews(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	writer := newTestUser(t, "writer")
+	repo := newTestRepo(t, u1, "repo1")
+	repoPath := RepoPath(u1.Name, repo.Name)
+	if _, err := orm.Insert(&Access{UserName: "writer", RepoName: "user1/repo1", Mode: AU_WRITABLE}); err != nil {
+		t.Fatal(err)
+	}
+
+	headId := testCommitFiles(t, repoPath, "feature", "master", map[string]string{"a.txt": "one\ntwo\n"}, "Add a")
+	pr := newTestPullRequest(t, repo, u2, "feature", "master")
+	pr.BaseRepo = repo
+	diff, err := GetDiffRange(repoPath, pr.MergeBase, headId)
+	if err != nil {
+		t.Fatalf("GetDiffRange: %v", err)
+	}
+
+	if _, err = GetPendingReview(pr.IssueId, writer.Id); err != ErrPullReviewNotExist {
+		t.Errorf("GetPendingReview(none) error = %v, expected %v", err, ErrPullReviewNotExist)
+	}
+	r, err := GetOrCreatePendingReview(pr.IssueId, writer.Id)
+	if err != nil {
+		t.Fatalf("GetOrCreatePendingReview: %v", err)
+	}
+	if again, err := GetOrCreatePendingReview(pr.IssueId, writer.Id); err != nil {
+		t.Fatalf("GetOrCreatePendingReview(again): %v", err)
+	} else if again.Id != r.Id {
+		t.Errorf("GetOrCreatePendingReview(again) = review %d, expected %d", again.Id, r.Id)
+	}
+	if _, err = GetPendingReview(pr.IssueId, 0); err != ErrPullReviewNotExist {
+		t.Errorf("GetPendingReview(zero ID) error = %v, expected %v", err, ErrPullReviewNotExist)
+	}
+
+	th := &ReviewThread{IssueId: pr.IssueId, PosterId: writer.Id, TreePath: "a.txt", Line: 1, CommitId: headId}
+	if err = NewReviewThread(diff, th, r.Id, "Pending"); err != nil {
+		t.Fatalf("NewReviewThread: %v", err)
+	}
+	// Comments of pending review are only visible to reviewer.
+	for _, tt := range []struct {
+		viewer  *User
+		threads int
+	}{
+		{writer, 1},
+		{u2, 0},
+	} {
+		threads, err := GetReviewThreads(pr.IssueId, tt.viewer.Id)
+		if err != nil {
+			t.Fatalf("GetReviewThreads(%s): %v", tt.viewer.Name, err)
+		} else if len(threads) != tt.threads {
+			t.Errorf("GetReviewThreads(%s) returns %d threads, expected %d", tt.viewer.Name, len(threads), tt.threads)
+		} else if len(threads) > 0 && !threads[0].Comments[0].IsPending {
+			t.Errorf("comment of pending review is not pending for %s", tt.viewer.Name)
+		}
+	}
+
+	if _, err = SubmitPullReview(pr, u2, REVIEW_APPROVE, "", headId); err != ErrPullApprovalOwnRequest {
+		t.Errorf("SubmitPullReview(own) error = %v, expected %v", err, ErrPullApprovalOwnRequest)
+	}
+	if _, err = SubmitPullReview(pr, u1, REVIEW_COMMENT, "", headId); err != ErrPullReviewEmpty {
+		t.Errorf("SubmitPullReview(empty) error = %v, expected %v", err, ErrPullReviewEmpty)
+	}
+	if _, err = SubmitPullReview(pr, u2, REVIEW_COMMENT, "Thanks", headId); err != nil {
+		t.Errorf("SubmitPullReview(own comment): %v", err)
+	}
+
+	// Submitting pending review publishes its comments and approves.
+	submitted, err := SubmitPullReview(pr, writer, REVIEW_APPROVE, "", headId)
+	if err != nil {
+		t.Fatalf("SubmitPullReview(approve): %v", err)
+	} else if submitted.Id != r.Id || submitted.NumComments != 1 {
+		t.Errorf("SubmitPullReview(approve) = review %d with %d comments, expected review %d with 1", submitted.Id, submitted.NumComments, r.Id)
+	}
+	if threads, err := GetReviewThreads(pr.IssueId, u2.Id); err != nil {
+		t.Fatalf("GetReviewThreads(submitted): %v", err)
+	} else if len(threads) != 1 || threads[0].Comments[0].IsPending {
+		t.Error("comment of submitted review is not visible to others")
+	}
+	if approvals, err := GetPullApprovals(pr.IssueId); err != nil {
+		t.Fatalf("GetPullApprovals: %v", err)
+	} else if len(approvals) != 1 || approvals[0].ReviewerId != writer.Id {
+		t.Errorf("GetPullApprovals returns %d approvals, expected approval of %s", len(approvals), writer.Name)
+	}
+
+	if _, err = SubmitPullReview(pr, writer, REVIEW_REQUEST_CHANGES, "Please fix", headId); err != nil {
+		t.Fatalf("SubmitPullReview(request changes): %v", err)
+	}
+	if approvals, _ := GetPullApprovals(pr.IssueId); len(approvals) != 0 {
+		t.Errorf("requesting changes leaves %d approvals, expected 0", len(approvals))
+	}
+
+	reviews, err := GetPullReviews(pr.IssueId)
+	if err != nil {
+		t.Fatalf("GetPullReviews: %v", err)
+	}
+	// Reviews may be submitted within the same second, so order is not checked.
+	types := make(map[int]int, len(reviews))
+	for _, r := range reviews {
+		types[r.Type]++
+	}
+	if len(reviews) != 3 || types[REVIEW_COMMENT] != 1 || types[REVIEW_APPROVE] != 1 || types[REVIEW_REQUEST_CHANGES] != 1 {
+		t.Errorf("GetPullReviews returns review types %v, expected one of each verdict", types)
+	}
+}
+
+func TestReviewCommentSuggestion(t *testing.T) {
+	tests := []struct {
+		content, suggestion string
+		ok                  bool
+	}{
+		{"```suggestion\nTWO\n```", "TWO", true},
+		{"Try:\n```suggestion \r\nA\r\nB\r\n```\nThanks", "A\nB", true},
+		{"```suggestion\n```", "", true},
+		{"```suggestion```", "", false},
+		{"```go\nx\n```", "", false},
+		{"No suggestion", "", false},
+	}
+	for _, tt := range tests {
+		c := &ReviewComment{Content: tt.content}
+		if suggestion, ok := c.Suggestion(); suggestion != tt.suggestion || ok != tt.ok {
+			t.Errorf("Suggestion(%q) = (%q, %v), expected (%q, %v)", tt.content, suggestion, ok, tt.suggestion, tt.ok)
+		}
+	}
+}
+
+func TestApplyReviewSuggestion(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	repo := newTestRepo(t, u1, "repo1")
+	repoPath := RepoPath(u1.Name, repo.Name)
+
+	headId := testCommitFiles(t, repoPath, "feature", "master", map[string]string{"a.txt": "one\ntwo\nthree\n"}, "Add a")
+	pr := newTestPullRequest(t, repo, u2, "feature", "master")
+	diff, err := GetDiffRange(repoPath, pr.MergeBase, headId)
+	if err != nil {
+		t.Fatalf("GetDiffRange: %v", err)
+	}
+	pending, err := GetOrCreatePendingReview(pr.IssueId, u1.Id)
+	if err != nil {
+		t.Fatalf("GetOrCreatePendingReview: %v", err)
+	}
+
+	// newThread starts a thread and returns it with its comment.
+	newThread := func(line int, reviewId int64, content string) (*ReviewThread, *ReviewComment) {
+		th := &ReviewThread{IssueId: pr.IssueId, PosterId: u1.Id, TreePath: "a.txt", Line: line, CommitId: headId}
+		if err := NewReviewThread(diff, th, reviewId, content); err != nil {
+			t.Fatalf("NewReviewThread(line %d): %v", line, err)
+		}
+		c := new(ReviewComment)
+		if has, err := orm.Where("thread_id=?", th.Id).Get(c); err != nil || !has {
+			t.Fatalf("comment of thread on line %d is not found: %v", line, err)
+		}
+		return th, c
+	}
+	th, c := newThread(2, 0, "```suggestion\nTWO\n2\n```")
+	outdatedTh, outdatedC := newThread(3, 0, "```suggestion\nTHREE\n```")
+	pendingTh, pendingC := newThread(1, pending.Id, "```suggestion\nONE\n```")
+	plainTh, plainC := newThread(1, 0, "Fine")
+
+	if _, err = GetReviewCommentById(0, c.Id); err != ErrReviewCommentNotExist {
+		t.Errorf("GetReviewCommentById(zero thread) error = %v, expected %v", err, ErrReviewCommentNotExist)
+	}
+	if c, err = GetReviewCommentById(th.Id, c.Id); err != nil {
+		t.Fatalf("GetReviewCommentById: %v", err)
+	}
+
+	for _, tt := range []struct {
+		th *ReviewThread
+		c  *ReviewComment
+	}{
+		{pendingTh, pendingC},
+		{plainTh, plainC},
+	} {
+		if err = ApplyReviewSuggestion(u2, pr, tt.th, tt.c); err != ErrSuggestionNotExist {
+			t.Errorf("ApplyReviewSuggestion(%q) error = %v, expected %v", tt.c.Content, err, ErrSuggestionNotExist)
+		}
+	}
+
+	if err = ApplyReviewSuggestion(u2, pr, th, c); err != nil {
+		t.Fatalf("ApplyReviewSuggestion: %v", err)
+	}
+	if content, err := execGitCmd(repoPath, nil, nil, "cat-file", "blob", "feature:a.txt"); err != nil || content != "one\nTWO\n2\nthree" {
+		t.Errorf("content of head branch is (%q, %v), expected %q", content, err, "one\nTWO\n2\nthree")
+	}
+	// Poster of comment is author and user applying it is committer.
+	if sigs, err := execGitCmd(repoPath, nil, nil, "log", "-1", "--format=%ae %ce", "feature"); err != nil || sigs != "user1@gogs.io user2@gogs.io" {
+		t.Errorf("author and committer of suggestion are (%q, %v), expected %q", sigs, err, "user1@gogs.io user2@gogs.io")
+	}
+	if c, err = GetReviewCommentById(th.Id, c.Id); err != nil {
+		t.Fatalf("GetReviewCommentById(applied): %v", err)
+	} else if !c.IsApplied {
+		t.Error("suggestion is not marked as applied")
+	}
+	if th, err = GetReviewThreadById(pr.IssueId, th.Id); err != nil {
+		t.Fatalf("GetReviewThreadById: %v", err)
+	} else if !th.IsResolved || th.ResolverId != u2.Id {
+		t.Errorf("thread is not resolved by %s", u2.Name)
+	}
+	if err = ApplyReviewSuggestion(u2, pr, th, c); err != ErrSuggestionApplied {
+		t.Errorf("ApplyReviewSuggestion(again) error = %v, expected %v", err, ErrSuggestionApplied)
+	}
+
+	// Line 3 is no longer "three" after the first suggestion.
+	if err = ApplyReviewSuggestion(u2, pr, outdatedTh, outdatedC); err != ErrSuggestionOutdated {
+		t.Errorf("ApplyReviewSuggestion(outdated) error = %v, expected %v", err, ErrSuggestionOutdated)
+	}
+}
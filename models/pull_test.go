@@ -0,0 +1,414 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// newTestPullRequest creates an open pull request from head branch to base branch of repository.
+func newTestPullRequest(t *testing.T, repo *Repository, poster *User, headBranch, baseBranch string) *PullRequest {
+	repoPath := RepoPath(repo.Owner.Name, repo.Name)
+	mergeBase, err := GetMergeBase(repoPath, baseBranch, headBranch)
+	if err != nil {
+		t.Fatalf("GetMergeBase: %v", err)
+	}
+
+	issue := &Issue{
+		RepoId:   repo.Id,
+		Index:    int64(repo.NumIssues) + 1,
+		Name:     "Merge " + headBranch + " into " + baseBranch,
+		PosterId: poster.Id,
+	}
+	pr := &PullRequest{
+		HeadRepoId: repo.Id,
+		BaseRepoId: repo.Id,
+		HeadBranch: headBranch,
+		BaseBranch: baseBranch,
+		MergeBase:  mergeBase,
+	}
+	if err = NewPullRequest(repo, issue, pr); err != nil {
+		t.Fatalf("NewPullRequest: %v", err)
+	}
+	repo.NumIssues++
+	pr.Issue = issue
+	return pr
+}
+
+func TestGetUnmergedPullRequest(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo := newTestRepo(t, u, "repo1")
+	repoPath := RepoPath(u.Name, repo.Name)
+
+	initId, err := ResolveCommitId(repoPath, "master")
+	if err != nil {
+		t.Fatalf("ResolveCommitId: %v", err)
+	}
+	testCommitFiles(t, repoPath, "feature", "master", map[string]string{"a.txt": "a\n"}, "Add a")
+	headId := testCommitFiles(t, repoPath, "feature", "", map[string]string{"b.txt": "b\n"}, "Add b")
+	testCommitFiles(t, repoPath, "master", "", map[string]string{"c.txt": "c\n"}, "Add c")
+
+	pr := newTestPullRequest(t, repo, u, "feature", "master")
+	if pr.MergeBase != initId {
+		t.Errorf("merge base is %s, expected %s", pr.MergeBase, initId)
+	}
+	commits, err := GetCommitsRange(repoPath, pr.MergeBase, headId)
+	if err != nil {
+		t.Fatalf("GetCommitsRange: %v", err)
+	} else if commits.Len() != 2 {
+		t.Errorf("GetCommitsRange returns %d commits, expected 2", commits.Len())
+	}
+	diff, err := GetDiffRange(repoPath, pr.MergeBase, headId)
+	if err != nil {
+		t.Fatalf("GetDiffRange: %v", err)
+	} else if diff.NumFiles() != 2 || diff.GetFile("c.txt") != nil {
+		t.Errorf("GetDiffRange returns %d files, expected a.txt and b.txt only", diff.NumFiles())
+	}
+
+	got, err := GetUnmergedPullRequest(repo.Id, repo.Id, "feature", "master")
+	if err != nil || got.Id != pr.Id {
+		t.Fatalf("GetUnmergedPullRequest = (%v, %v), expected pull request %d", got, err, pr.Id)
+	}
+	if _, err = GetUnmergedPullRequest(repo.Id, repo.Id, "master", "feature"); err != ErrPullRequestNotExist {
+		t.Errorf("GetUnmergedPullRequest(reversed) error = %v, expected %v", err, ErrPullRequestNotExist)
+	}
+	if open, closed := GetPullRequestCount(repo.Id); open != 1 || closed != 0 {
+		t.Errorf("GetPullRequestCount = (%d, %d), expected (1, 0)", open, closed)
+	}
+
+	pr.Issue.IsClosed = true
+	if _, err = orm.Id(pr.IssueId).Cols("is_closed").Update(pr.Issue); err != nil {
+		t.Fatalf("close issue: %v", err)
+	}
+	if _, err = GetUnmergedPullRequest(repo.Id, repo.Id, "feature", "master"); err != ErrPullRequestNotExist {
+		t.Errorf("GetUnmergedPullRequest(closed) error = %v, expected %v", err, ErrPullRequestNotExist)
+	}
+}
+
+func TestFetchForkRef(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	basePath := RepoPath(u.Name, newTestRepo(t, u, "base").Name)
+	head := newTestRepo(t, u, "head")
+	headPath := RepoPath(u.Name, head.Name)
+
+	branchId := testCommitFiles(t, headPath, "feature", "master", map[string]string{"a.txt": "a\n"}, "Add a")
+	commitId := testCommitFiles(t, headPath, "feature", "", map[string]string{"b.txt": "b\n"}, "Add b")
+	if _, err := execGitCmd(headPath, nil, nil, "tag", "v1.0", branchId); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, rev := range []string{"", "-h", "none", commitId[:7] + "^^^^"} {
+		if _, err := ResolveCommitId(headPath, rev); err != ErrRevisionNotExist {
+			t.Errorf("ResolveCommitId(%q) error = %v, expected %v", rev, err, ErrRevisionNotExist)
+		}
+	}
+
+	tests := []struct {
+		refName, expected string
+	}{
+		{"feature", commitId},
+		{"v1.0", branchId},
+		{branchId[:10], branchId},
+	}
+	for _, tt := range tests {
+		if id, err := FetchForkRef(basePath, headPath, head.Id, tt.refName); err != nil || id != tt.expected {
+			t.Errorf("FetchForkRef(%s) = (%q, %v), expected %q", tt.refName, id, err, tt.expected)
+		}
+	}
+	if _, err := FetchForkRef(basePath, headPath, head.Id, "none"); err != ErrRevisionNotExist {
+		t.Errorf("FetchForkRef(none) error = %v, expected %v", err, ErrRevisionNotExist)
+	}
+}
+
+func TestDeleteHeadBranch(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo := newTestRepo(t, u, "repo1")
+	repoPath := RepoPath(u.Name, repo.Name)
+
+	headId := testCommitFiles(t, repoPath, "feature", "master", map[string]string{"a.txt": "a\n"}, "Add a")
+	pr := newTestPullRequest(t, repo, u, "feature", "master")
+	if err := pr.DeleteHeadBranch(u); err != ErrPullRequestNotMerged {
+		t.Errorf("DeleteHeadBranch(unmerged) error = %v, expected %v", err, ErrPullRequestNotMerged)
+	}
+	pr.HasMerged, pr.HeadCommitId = true, headId
+	if err := UpdatePullRequest(pr); err != nil {
+		t.Fatalf("UpdatePullRequest: %v", err)
+	}
+	if err := pr.RestoreHeadBranch(u); err != ErrHeadBranchNotRestorable {
+		t.Errorf("RestoreHeadBranch(not deleted) error = %v, expected %v", err, ErrHeadBranchNotRestorable)
+	}
+
+	// Commits pushed after merge are not thrown away.
+	testCommitFiles(t, repoPath, "feature", "", map[string]string{"b.txt": "b\n"}, "Add b")
+	if err := pr.DeleteHeadBranch(u); err != ErrHeadBranchChanged {
+		t.Errorf("DeleteHeadBranch(changed) error = %v, expected %v", err, ErrHeadBranchChanged)
+	}
+	if _, err := execGitCmd(repoPath, nil, nil, "update-ref", "refs/heads/feature", headId); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pr.DeleteHeadBranch(u); err != nil {
+		t.Fatalf("DeleteHeadBranch: %v", err)
+	}
+	if _, err := execGitCmd(repoPath, nil, nil, "rev-parse", "--verify", "-q", "refs/heads/feature"); err == nil {
+		t.Error("head branch still exists after DeleteHeadBranch")
+	}
+	if saved, err := GetPullRequestByIssueId(pr.IssueId); err != nil || !saved.IsHeadDeleted {
+		t.Errorf("GetPullRequestByIssueId = (%+v, %v), expected head deleted", saved, err)
+	}
+	if err := pr.DeleteHeadBranch(u); err != ErrBranchNotExist {
+		t.Errorf("DeleteHeadBranch(deleted) error = %v, expected %v", err, ErrBranchNotExist)
+	}
+
+	// Branch of same name that has been created since is not overwritten.
+	if _, err := execGitCmd(repoPath, nil, nil, "branch", "feature", "master"); err != nil {
+		t.Fatal(err)
+	}
+	if pr.IsHeadBranchRestorable() {
+		t.Error("IsHeadBranchRestorable = true, expected false when branch name is taken")
+	}
+	if err := pr.RestoreHeadBranch(u); err != ErrHeadBranchNotRestorable {
+		t.Errorf("RestoreHeadBranch(name taken) error = %v, expected %v", err, ErrHeadBranchNotRestorable)
+	}
+	if _, err := execGitCmd(repoPath, nil, nil, "branch", "-D", "feature"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !pr.IsHeadBranchRestorable() {
+		t.Error("IsHeadBranchRestorable = false, expected true")
+	}
+	if err := pr.RestoreHeadBranch(u); err != nil {
+		t.Fatalf("RestoreHeadBranch: %v", err)
+	}
+	if id, _ := execGitCmd(repoPath, nil, nil, "rev-parse", "refs/heads/feature"); id != headId {
+		t.Errorf("restored head branch is at %q, expected %q", id, headId)
+	}
+	if saved, err := GetPullRequestByIssueId(pr.IssueId); err != nil || saved.IsHeadDeleted {
+		t.Errorf("GetPullRequestByIssueId = (%+v, %v), expected head not deleted", saved, err)
+	}
+
+	pr.HeadRepo.IsArchived = true
+	if err := pr.DeleteHeadBranch(u); err != ErrRepoArchived {
+		t.Errorf("DeleteHeadBranch(archived) error = %v, expected %v", err, ErrRepoArchived)
+	}
+	pr.HeadRepo.IsArchived = false
+
+	defaultPr := &PullRequest{HasMerged: true, HeadRepo: pr.HeadRepo, HeadBranch: "master", HeadCommitId: headId}
+	if err := defaultPr.DeleteHeadBranch(u); err != ErrDeleteDefaultBranch {
+		t.Errorf("DeleteHeadBranch(default branch) error = %v, expected %v", err, ErrDeleteDefaultBranch)
+	}
+}
+
+func TestMergeStyles(t *testing.T) {
+	tests := []struct {
+		styles, defaultStyle string
+		allowed              []string
+		expectedDefault      string
+	}{
+		{"", "", mergeStyles, MERGE_STYLE_MERGE},
+		{"", MERGE_STYLE_REBASE, mergeStyles, MERGE_STYLE_REBASE},
+		{"rebase,squash", "", []string{MERGE_STYLE_SQUASH, MERGE_STYLE_REBASE}, MERGE_STYLE_SQUASH},
+		{"squash,unknown", MERGE_STYLE_MERGE, []string{MERGE_STYLE_SQUASH}, MERGE_STYLE_SQUASH},
+	}
+	for _, tt := range tests {
+		repo := &Repository{MergeStyles: tt.styles, DefaultMergeStyle: tt.defaultStyle}
+		if allowed := repo.AllowedMergeStyles(); strings.Join(allowed, ",") != strings.Join(tt.allowed, ",") {
+			t.Errorf("Repository{MergeStyles: %q}.AllowedMergeStyles() = %v, expected %v", tt.styles, allowed, tt.allowed)
+		}
+		if style := repo.GetDefaultMergeStyle(); style != tt.expectedDefault {
+			t.Errorf("Repository{MergeStyles: %q, DefaultMergeStyle: %q}.GetDefaultMergeStyle() = %q, expected %q",
+				tt.styles, tt.defaultStyle, style, tt.expectedDefault)
+		}
+		if repo.CanMergeWith("unknown") {
+			t.Errorf("Repository{MergeStyles: %q}.CanMergeWith(unknown) = true, expected false", tt.styles)
+		}
+	}
+}
+
+func TestMergeMessage(t *testing.T) {
+	owner := &User{Name: "user1"}
+	pr := &PullRequest{
+		Issue:      &Issue{Index: 3, Name: "Add feature", Content: "Details", Poster: &User{Name: "user2"}},
+		HeadRepo:   &Repository{Name: "fork", Owner: &User{Name: "user2"}},
+		BaseRepo:   &Repository{Name: "repo1", Owner: owner},
+		HeadBranch: "feature",
+		BaseBranch: "master",
+	}
+
+	tests := []struct {
+		tpl, style, expected string
+	}{
+		{"", MERGE_STYLE_MERGE, "Merge pull request #3 from user2/fork:feature\n\nAdd feature"},
+		{"", MERGE_STYLE_SQUASH, "Add feature (#3)\n\nDetails"},
+		{"", MERGE_STYLE_REBASE, ""},
+		{"$TITLE by $AUTHOR into $BASE_BRANCH\n\n$UNKNOWN\n", MERGE_STYLE_MERGE, "Add feature by user2 into master\n\n$UNKNOWN"},
+	}
+	for _, tt := range tests {
+		pr.BaseRepo.MergeMessageTemplate = tt.tpl
+		if msg := pr.MergeMessage(tt.style); msg != tt.expected {
+			t.Errorf("MergeMessage(%q, %s) = %q, expected %q", tt.tpl, tt.style, msg, tt.expected)
+		}
+	}
+}
+
+func TestMergePullRequest(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	repo := newTestRepo(t, u1, "repo1")
+	repoPath := RepoPath(u1.Name, repo.Name)
+
+	git := func(args ...string) string {
+		stdout, err := execGitCmd(repoPath, nil, nil, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stdout
+	}
+
+	for _, style := range mergeStyles {
+		testCommitFiles(t, repoPath, style, "master", map[string]string{style + "-1.txt": "1\n"}, "Add "+style+" 1")
+		testCommitFiles(t, repoPath, style, "", map[string]string{style + "-2.txt": "2\n"}, "Add "+style+" 2")
+	}
+	// Base branch moves on so that head branches cannot be fast-forwarded.
+	testCommitFiles(t, repoPath, "master", "", map[string]string{"base.txt": "base\n"}, "Add base")
+
+	repo.MergeStyles = MERGE_STYLE_MERGE + "," + MERGE_STYLE_REBASE
+	if _, err := orm.Id(repo.Id).Cols("merge_styles").Update(repo); err != nil {
+		t.Fatal(err)
+	}
+	pr := newTestPullRequest(t, repo, u2, MERGE_STYLE_SQUASH, "master")
+	if err := pr.Merge(u1, MERGE_STYLE_SQUASH, ""); err != ErrMergeStyleNotAllowed {
+		t.Errorf("Merge(disallowed style) error = %v, expected %v", err, ErrMergeStyleNotAllowed)
+	}
+	repo.MergeStyles = ""
+	if _, err := orm.Id(repo.Id).Cols("merge_styles").Update(repo); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		style      string
+		numCommits int // Number of commits added to base branch.
+		numParents int // Number of parents of merged commit.
+	}{
+		{MERGE_STYLE_MERGE, 3, 2},
+		{MERGE_STYLE_SQUASH, 1, 1},
+		{MERGE_STYLE_REBASE, 2, 1},
+	}
+	for _, tt := range tests {
+		oldCommitId := git("rev-parse", "master")
+		if tt.style != MERGE_STYLE_SQUASH {
+			pr = newTestPullRequest(t, repo, u2, tt.style, "master")
+		}
+		if err := pr.Merge(u1, tt.style, ""); err != nil {
+			t.Fatalf("Merge(%s): %v", tt.style, err)
+		}
+
+		if id := git("rev-parse", "master"); id != pr.MergedCommitId {
+			t.Errorf("Merge(%s): master is at %s, expected merged commit %s", tt.style, id, pr.MergedCommitId)
+		}
+		if n := len(strings.Fields(git("rev-list", oldCommitId+"..master"))); n != tt.numCommits {
+			t.Errorf("Merge(%s) adds %d commits, expected %d", tt.style, n, tt.numCommits)
+		}
+		if n := len(strings.Fields(git("rev-list", "--parents", "-n", "1", "master"))) - 1; n != tt.numParents {
+			t.Errorf("Merge(%s): merged commit has %d parents, expected %d", tt.style, n, tt.numParents)
+		}
+		// Squashed commit is authored by poster and committed by merger.
+		if tt.style == MERGE_STYLE_SQUASH {
+			if names := git("log", "-1", "--format=%an %cn", "master"); names != "user2 user1" {
+				t.Errorf("Merge(squash): author and committer are %q, expected %q", names, "user2 user1")
+			}
+		}
+
+		saved, err := GetPullRequestByIssueId(pr.IssueId)
+		if err != nil {
+			t.Fatalf("GetPullRequestByIssueId: %v", err)
+		} else if !saved.HasMerged || saved.MergeStyle != tt.style || saved.MergerId != u1.Id {
+			t.Errorf("Merge(%s): pull request is saved as merged %v with style %q by %d",
+				tt.style, saved.HasMerged, saved.MergeStyle, saved.MergerId)
+		}
+		if issue, err := GetIssueById(pr.IssueId); err != nil || !issue.IsClosed {
+			t.Errorf("Merge(%s): GetIssueById = (%+v, %v), expected closed", tt.style, issue, err)
+		}
+	}
+
+	if err := pr.Merge(u1, MERGE_STYLE_MERGE, ""); err != ErrPullRequestMerged {
+		t.Errorf("Merge(merged) error = %v, expected %v", err, ErrPullRequestMerged)
+	}
+}
+
+func TestDraftPullRequest(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	repo := newTestRepo(t, u1, "repo1")
+	repoPath := RepoPath(u1.Name, repo.Name)
+
+	testCommitFiles(t, repoPath, "draft", "master", map[string]string{"a.txt": "a\n"}, "Add a")
+	testCommitFiles(t, repoPath, "ready", "master", map[string]string{"b.txt": "b\n"}, "Add b")
+	draft := newTestPullRequest(t, repo, u2, "draft", "master")
+	ready := newTestPullRequest(t, repo, u2, "ready", "master")
+	draft.IsDraft = true
+	if _, err := orm.Id(draft.Id).Cols("is_draft").Update(draft); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		draft    string
+		expected []int64
+	}{
+		{"", []int64{draft.IssueId, ready.IssueId}},
+		{"only", []int64{draft.IssueId}},
+		{"exclude", []int64{ready.IssueId}},
+	}
+	for _, tt := range tests {
+		issues, err := GetPullRequests(repo.Id, false, tt.draft, 1)
+		if err != nil {
+			t.Fatalf("GetPullRequests(%q): %v", tt.draft, err)
+		}
+		ids := make([]int64, len(issues))
+		for i := range issues {
+			ids[i] = issues[i].Id
+		}
+		sort.Sort(int64Slice(ids))
+		if !reflect.DeepEqual(ids, tt.expected) {
+			t.Errorf("GetPullRequests(%q) = %v, expected %v", tt.draft, ids, tt.expected)
+		}
+	}
+
+	if err := draft.Merge(u1, MERGE_STYLE_MERGE, ""); err != ErrPullRequestIsDraft {
+		t.Errorf("Merge(draft) error = %v, expected %v", err, ErrPullRequestIsDraft)
+	}
+	if _, err := ready.MarkReadyForReview(u2, repo, []*User{u1}); err != ErrPullRequestNotDraft {
+		t.Errorf("MarkReadyForReview(not draft) error = %v, expected %v", err, ErrPullRequestNotDraft)
+	}
+
+	// Reviewers are only requested once draft is ready.
+	if ns, err := GetNotifications(u1.Id, 1); err != nil {
+		t.Fatalf("GetNotifications: %v", err)
+	} else if len(ns) != 0 {
+		t.Errorf("reviewer has %d notifications before draft is ready, expected 0", len(ns))
+	}
+	if _, err := draft.MarkReadyForReview(u2, repo, []*User{u1}); err != nil {
+		t.Fatalf("MarkReadyForReview: %v", err)
+	}
+	if pr, err := GetPullRequestByIssueId(draft.IssueId); err != nil {
+		t.Fatalf("GetPullRequestByIssueId: %v", err)
+	} else if pr.IsDraft {
+		t.Error("pull request is still a draft")
+	}
+	if draft.Issue.AssigneeId != u1.Id {
+		t.Errorf("pull request is assigned to %d, expected %d", draft.Issue.AssigneeId, u1.Id)
+	}
+	if ns, err := GetNotifications(u1.Id, 1); err != nil {
+		t.Fatalf("GetNotifications(ready): %v", err)
+	} else if len(ns) != 1 || ns[0].Type != NOTIFY_REVIEW_REQUEST || ns[0].IssueId != draft.IssueId {
+		t.Errorf("reviewer has %d notifications, expected a review request", len(ns))
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Unknwon/com"
+
+	"github.com/gogits/gogs/modules/log"
+)
+
+var (
+	ErrPushMirrorNotExist = errors.New("Push mirror does not exist")
+)
+
+// Maximum delay before retrying a failed push.
+const _PUSH_MIRROR_MAX_BACKOFF = 24 * time.Hour
+
+// PushMirror represents a remote that repository is pushed to after every change.
+type PushMirror struct {
+	Id           int64
+	RepoId       int64  `xorm:"INDEX NOT NULL"`
+	Address      string `xorm:"TEXT"` // Remote URL without credentials.
+	AuthUserName string
+	AuthPasswd   string `xorm:"TEXT"`
+	IsPending    bool   // Repository has changes that are not pushed yet.
+	NumFailures  int    // Number of failures since last successful push.
+	NextRetry    time.Time
+	LastPush     time.Time
+	LastError    string    `xorm:"TEXT"`
+	IsSyncing    bool      `xorm:"-"`
+	Created      time.Time `xorm:"CREATED"`
+}
+
+// FullAddress returns remote URL with credentials for pushing.
+func (m *PushMirror) FullAddress() string {
+	return buildRemoteAddress(m.Address, m.AuthUserName, m.AuthPasswd)
+}
+
+// AddPushMirror adds new push mirror and pushes repository to it soon.
+func AddPushMirror(m *PushMirror) error {
+	m.IsPending = true
+	m.NextRetry = time.Now()
+	_, err := orm.Insert(m)
+	return err
+}
+
+// GetPushMirrorById returns push mirror of repository by given ID.
+func GetPushMirrorById(repoId, id int64) (*PushMirror, error) {
+	m := new(PushMirror)
+	has, err := orm.Where("id=? AND repo_id=?", id, repoId).Get(m)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPushMirrorNotExist
+	}
+	m.IsSyncing = isPushMirrorSyncing(m.Id)
+	return m, nil
+}
+
+// GetPushMirrors returns all push mirrors of repository.
+func GetPushMirrors(repoId int64) ([]*PushMirror, error) {
+	mirrors := make([]*PushMirror, 0, 2)
+	if err := orm.Where("repo_id=?", repoId).Asc("id").Find(&mirrors); err != nil {
+		return nil, err
+	}
+	for _, m := range mirrors {
+		m.IsSyncing = isPushMirrorSyncing(m.Id)
+	}
+	return mirrors, nil
+}
+
+// UpdatePushMirror updates information of push mirror.
+func UpdatePushMirror(m *PushMirror) error {
+	_, err := orm.Id(m.Id).AllCols().Update(m)
+	return err
+}
+
+// DeletePushMirror deletes push mirror of repository.
+func DeletePushMirror(repoId, id int64) error {
+	if id <= 0 {
+		return ErrPushMirrorNotExist
+	}
+	affected, err := orm.Where("id=? AND repo_id=?", id, repoId).Delete(new(PushMirror))
+	if err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrPushMirrorNotExist
+	}
+	return nil
+}
+
+// MarkPushMirrorsPending marks all push mirrors of repository to be pushed,
+// it is called after repository has been changed.
+func MarkPushMirrorsPending(repoId int64) error {
+	_, err := orm.Where("repo_id=?", repoId).Cols("is_pending", "num_failures", "next_retry").
+		Update(&PushMirror{IsPending: true, NextRetry: time.Now()})
+	return err
+}
+
+var (
+	pushMirrorSyncLock sync.Mutex
+	syncingPushMirrors = make(map[int64]bool)
+)
+
+func isPushMirrorSyncing(id int64) bool {
+	pushMirrorSyncLock.Lock()
+	defer pushMirrorSyncLock.Unlock()
+	return syncingPushMirrors[id]
+}
+
+// SyncPushMirror pushes all branches and tags of repository to push mirror,
+// and records result of pushing. Failed pushes are retried with growing delay.
+func SyncPushMirror(m *PushMirror) error {
+	pushMirrorSyncLock.Lock()
+	if syncingPushMirrors[m.Id] {
+		pushMirrorSyncLock.Unlock()
+		return ErrMirrorSyncing
+	}
+	syncingPushMirrors[m.Id] = true
+	pushMirrorSyncLock.Unlock()
+	defer func() {
+		pushMirrorSyncLock.Lock()
+		delete(syncingPushMirrors, m.Id)
+		pushMirrorSyncLock.Unlock()
+	}()
+
+	repo, err := GetRepositoryById(m.RepoId)
+	if err != nil {
+		return err
+	} else if err = repo.GetOwner(); err != nil {
+		return err
+	}
+
+	// References only exist in this server(e.g. fetched fork branches) are not pushed.
+	var pushErr error
+	if _, stderr, err := com.ExecCmdDir(RepoPath(repo.Owner.Name, repo.Name), "git", "push", "--prune",
+		m.FullAddress(), "+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*"); err != nil {
+		// Do not leak credentials through error message.
+		pushErr = errors.New("git push: " + strings.Replace(stderr, m.FullAddress(), m.Address, -1))
+	}
+
+	if pushErr != nil {
+		m.NumFailures++
+		backoff := time.Minute << uint(m.NumFailures-1)
+		if m.NumFailures > 12 || backoff > _PUSH_MIRROR_MAX_BACKOFF {
+			backoff = _PUSH_MIRROR_MAX_BACKOFF
+		}
+		m.NextRetry = time.Now().Add(backoff)
+		m.LastError = pushErr.Error()
+		log.Error("models.SyncPushMirror(%d): %v", m.Id, pushErr)
+	} else {
+		m.IsPending = false
+		m.NumFailures = 0
+		m.LastPush = time.Now()
+		m.LastError = ""
+	}
+	if err = UpdatePushMirror(m); err != nil {
+		return err
+	}
+	return pushErr
+}
+
+// PushMirrorUpdate pushes repositories to push mirrors that are pending and due.
+func PushMirrorUpdate() {
+	mirrors := make([]*PushMirror, 0, 10)
+	if err := orm.Where("is_pending=? AND next_retry<=?", true, time.Now()).Find(&mirrors); err != nil {
+		log.Error("models.PushMirrorUpdate: %v", err)
+		return
+	}
+
+	for _, m := range mirrors {
+		// Errors are recorded in push mirror.
+		SyncPushMirror(m)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gogits/gogs/modules/setting"
+)
+
+func TestSyncPushMirror(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo := newTestRepo(t, u, "repo1")
+	other := newTestRepo(t, u, "repo2")
+
+	remotePath := filepath.Join(setting.RepoRootPath, "remote.git")
+	if err := os.MkdirAll(remotePath, os.ModePerm); err != nil {
+		t.Fatal(err)
+	} else if _, err = execGitCmd(remotePath, nil, nil, "init", "-q", "--bare"); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &PushMirror{RepoId: repo.Id, Address: remotePath}
+	if err := AddPushMirror(m); err != nil {
+		t.Fatalf("AddPushMirror: %v", err)
+	}
+	if _, err := GetPushMirrorById(other.Id, m.Id); err != ErrPushMirrorNotExist {
+		t.Errorf("GetPushMirrorById(other repository) error = %v, expected %v", err, ErrPushMirrorNotExist)
+	}
+	if _, err := GetPushMirrorById(repo.Id, 0); err != ErrPushMirrorNotExist {
+		t.Errorf("GetPushMirrorById(zero ID) error = %v, expected %v", err, ErrPushMirrorNotExist)
+	}
+
+	if err := SyncPushMirror(m); err != nil {
+		t.Fatalf("SyncPushMirror: %v", err)
+	}
+	commitId, _ := execGitCmd(RepoPath(u.Name, repo.Name), nil, nil, "rev-parse", "refs/heads/master")
+	if id, err := execGitCmd(remotePath, nil, nil, "rev-parse", "refs/heads/master"); err != nil || id != commitId {
+		t.Errorf("pushed branch is (%q, %v), expected %q", id, err, commitId)
+	}
+
+	// Failed pushes are retried later with growing delay.
+	m.Address = remotePath + ".missing"
+	for i := 1; i <= 2; i++ {
+		if err := SyncPushMirror(m); err == nil {
+			t.Fatal("SyncPushMirror(missing remote) returns no error")
+		}
+		m, _ = GetPushMirrorById(repo.Id, m.Id)
+		if m.NumFailures != i || len(m.LastError) == 0 || !m.NextRetry.After(time.Now()) {
+			t.Errorf("#%d: push mirror has %d failures, last error %q, next retry %v", i, m.NumFailures, m.LastError, m.NextRetry)
+		}
+	}
+
+	if err := MarkPushMirrorsPending(repo.Id); err != nil {
+		t.Fatalf("MarkPushMirrorsPending: %v", err)
+	}
+	if m, _ = GetPushMirrorById(repo.Id, m.Id); !m.IsPending || m.NumFailures != 0 {
+		t.Errorf("push mirror has pending %v, %d failures, expected pending with no failure", m.IsPending, m.NumFailures)
+	}
+
+	if err := DeletePushMirror(other.Id, m.Id); err != ErrPushMirrorNotExist {
+		t.Errorf("DeletePushMirror(other repository) error = %v, expected %v", err, ErrPushMirrorNotExist)
+	}
+	if err := DeletePushMirror(repo.Id, m.Id); err != nil {
+		t.Errorf("DeletePushMirror: %v", err)
+	}
+}
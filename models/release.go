@@ -15,6 +15,7 @@ import (
 
 var (
 	ErrReleaseAlreadyExist = errors.New("Release already exist")
+	ErrReleaseNotExist     = errors.New("Release does not exist")
 )
 
 // Release represents a release of repository.
@@ -31,7 +32,10 @@ type Release struct {
 	NumCommitsBehind int    `xorm:"-"`
 	Note             string `xorm:"TEXT"`
 	IsPrerelease     bool
-	Created          time.Time `xorm:"created"`
+	IsDraft          bool                // Draft does not create tag until it is published.
+	Attachments      []*Attachment       `xorm:"-"`
+	Verification     *CommitVerification `xorm:"-"` // Nil if tag is not signed.
+	Created          time.Time           `xorm:"created"`
 }
 
 // GetReleasesByRepoId returns a list of releases of repository.
@@ -49,17 +53,16 @@ func IsReleaseExist(repoId int64, tagName string) (bool, error) {
 	return orm.Get(&Release{RepoId: repoId, LowerTagName: strings.ToLower(tagName)})
 }
 
-// CreateRelease creates a new release of repository.
-func CreateRelease(gitRepo *git.Repository, rel *Release) error {
-	isExist, err := IsReleaseExist(rel.RepoId, rel.TagName)
-	if err != nil {
-		return err
-	} else if isExist {
-		return ErrReleaseAlreadyExist
-	}
+// isValidTagName returns true if given name can be used as tag name of release,
+// names starting with dash would be taken as options of git tag.
+func isValidTagName(repoPath, name string) bool {
+	return !strings.HasPrefix(name, "-") && isBranchOrTagRef(repoPath, "refs/tags/"+name)
+}
 
+// createTag creates tag of release if it does not exist yet.
+func createTag(gitRepo *git.Repository, rel *Release) error {
 	if !gitRepo.IsTagExist(rel.TagName) {
-		_, stderr, err := com.ExecCmdDir(gitRepo.Path, "git", "tag", rel.TagName, "-m", rel.Title)
+		_, stderr, err := com.ExecCmdDir(gitRepo.Path, "git", "tag", rel.TagName, rel.SHA1, "-m", rel.Title)
 		if err != nil {
 			return err
 		} else if strings.Contains(stderr, "fatal:") {
@@ -71,13 +74,91 @@ func CreateRelease(gitRepo *git.Repository, rel *Release) error {
 			return err
 		}
 
+		rel.SHA1 = commit.Id.String()
 		rel.NumCommits, err = commit.CommitsCount()
 		if err != nil {
 			return err
 		}
 	}
+	return nil
+}
+
+// CreateRelease creates a new release of repository.
+func CreateRelease(gitRepo *git.Repository, rel *Release) error {
+	if !isValidTagName(gitRepo.Path, rel.TagName) {
+		return ErrRefNameIllegal
+	}
+	isExist, err := IsReleaseExist(rel.RepoId, rel.TagName)
+	if err != nil {
+		return err
+	} else if isExist {
+		return ErrReleaseAlreadyExist
+	}
+
+	if !rel.IsDraft {
+		if err = createTag(gitRepo, rel); err != nil {
+			return err
+		}
+	}
 
 	rel.LowerTagName = strings.ToLower(rel.TagName)
 	_, err = orm.InsertOne(rel)
 	return err
 }
+
+// GetReleaseById returns release by given ID.
+func GetReleaseById(id int64) (*Release, error) {
+	rel := new(Release)
+	has, err := orm.Id(id).Get(rel)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrReleaseNotExist
+	}
+	return rel, nil
+}
+
+// UpdateRelease updates information of release,
+// tag is created when release is no longer a draft.
+func UpdateRelease(gitRepo *git.Repository, rel *Release) (err error) {
+	if !isValidTagName(gitRepo.Path, rel.TagName) {
+		return ErrRefNameIllegal
+	}
+	has, err := orm.Where("id!=?", rel.Id).Get(&Release{RepoId: rel.RepoId, LowerTagName: strings.ToLower(rel.TagName)})
+	if err != nil {
+		return err
+	} else if has {
+		return ErrReleaseAlreadyExist
+	}
+
+	if !rel.IsDraft {
+		if err = createTag(gitRepo, rel); err != nil {
+			return err
+		}
+	}
+
+	rel.LowerTagName = strings.ToLower(rel.TagName)
+	_, err = orm.Id(rel.Id).AllCols().Update(rel)
+	return err
+}
+
+// GetReleaseByTagName returns release of repository by given tag name.
+func GetReleaseByTagName(repoId int64, tagName string) (*Release, error) {
+	rel := &Release{RepoId: repoId, LowerTagName: strings.ToLower(tagName)}
+	has, err := orm.Get(rel)
+	if err != nil {
+		return nil, err
+	} else if !has || len(tagName) == 0 {
+		return nil, ErrReleaseNotExist
+	}
+	return rel, nil
+}
+
+// DeleteRelease deletes release and its attachments, tag of release is kept.
+func DeleteRelease(rel *Release) error {
+	if err := DeleteReleaseAttachments(rel.Id); err != nil {
+		return err
+	}
+	_, err := orm.Id(rel.Id).Delete(new(Release))
+	return err
+}
@@ -0,0 +1,104 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gogits/git"
+
+	"github.com/gogits/gogs/modules/setting"
+)
+
+func TestReleases(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo, other := newTestRepo(t, u, "repo1"), newTestRepo(t, u, "repo2")
+	repoPath := RepoPath(u.Name, repo.Name)
+	commitId := testCommitFiles(t, repoPath, "master", "", map[string]string{"a.txt": "a\n"}, "Add a")
+	gitRepo, err := git.OpenRepository(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Tag names must be valid and cannot be taken as options of git tag.
+	for _, name := range []string{"", "-f", "--delete", "a..b", "v1 0"} {
+		if err = CreateRelease(gitRepo, &Release{RepoId: repo.Id, TagName: name, SHA1: commitId}); err != ErrRefNameIllegal {
+			t.Errorf("CreateRelease(%q) error = %v, expected %v", name, err, ErrRefNameIllegal)
+		}
+	}
+
+	rel := &Release{RepoId: repo.Id, PublisherId: u.Id, Title: "First", TagName: "v1.0", SHA1: commitId}
+	if err = CreateRelease(gitRepo, rel); err != nil {
+		t.Fatalf("CreateRelease: %v", err)
+	} else if !gitRepo.IsTagExist("v1.0") {
+		t.Errorf("tag of release is not created")
+	}
+	if err = CreateRelease(gitRepo, &Release{RepoId: repo.Id, TagName: "V1.0", SHA1: commitId}); err != ErrReleaseAlreadyExist {
+		t.Errorf("CreateRelease(existing tag) error = %v, expected %v", err, ErrReleaseAlreadyExist)
+	}
+
+	tests := []struct {
+		repoId  int64
+		tagName string
+		err     error
+	}{
+		{repo.Id, "V1.0", nil},
+		{repo.Id, "", ErrReleaseNotExist},
+		{repo.Id, "v2.0", ErrReleaseNotExist},
+		{other.Id, "v1.0", ErrReleaseNotExist},
+	}
+	for _, tt := range tests {
+		got, err := GetReleaseByTagName(tt.repoId, tt.tagName)
+		if err != tt.err {
+			t.Errorf("GetReleaseByTagName(%d, %q) error = %v, expected %v", tt.repoId, tt.tagName, err, tt.err)
+		} else if err == nil && got.Id != rel.Id {
+			t.Errorf("GetReleaseByTagName(%d, %q) returns release %d, expected %d", tt.repoId, tt.tagName, got.Id, rel.Id)
+		}
+	}
+
+	// Drafts do not create tags until they are published.
+	draft := &Release{RepoId: repo.Id, PublisherId: u.Id, Title: "Second", TagName: "v2.0", SHA1: commitId, IsDraft: true}
+	if err = CreateRelease(gitRepo, draft); err != nil {
+		t.Fatalf("CreateRelease(draft): %v", err)
+	} else if gitRepo.IsTagExist("v2.0") {
+		t.Errorf("tag of draft is created")
+	}
+	for name, expected := range map[string]error{"-d": ErrRefNameIllegal, "V1.0": ErrReleaseAlreadyExist} {
+		draft.TagName = name
+		if err = UpdateRelease(gitRepo, draft); err != expected {
+			t.Errorf("UpdateRelease(%q) error = %v, expected %v", name, err, expected)
+		}
+	}
+	draft.TagName, draft.IsDraft = "v2.0", false
+	if err = UpdateRelease(gitRepo, draft); err != nil {
+		t.Fatalf("UpdateRelease: %v", err)
+	} else if !gitRepo.IsTagExist("v2.0") {
+		t.Errorf("tag of published release is not created")
+	}
+
+	// Deleting release removes its attachments but keeps its tag.
+	defer func(maxSize int64) { setting.AttachmentMaxSize = maxSize }(setting.AttachmentMaxSize)
+	setting.AttachmentMaxSize = 1
+	if _, err = NewAttachment(rel.Id, "gogs.zip", bytes.NewReader([]byte("gogs"))); err != nil {
+		t.Fatalf("NewAttachment: %v", err)
+	}
+	if err = DeleteRelease(rel); err != nil {
+		t.Fatalf("DeleteRelease: %v", err)
+	}
+	if _, err = GetReleaseById(rel.Id); err != ErrReleaseNotExist {
+		t.Errorf("GetReleaseById(deleted) error = %v, expected %v", err, ErrReleaseNotExist)
+	}
+	if attachments, err := GetAttachmentsByReleaseId(rel.Id); err != nil || len(attachments) != 0 {
+		t.Errorf("GetAttachmentsByReleaseId(deleted) = (%d attachments, %v), expected none", len(attachments), err)
+	}
+	if !gitRepo.IsTagExist("v1.0") {
+		t.Errorf("tag of deleted release is removed")
+	}
+	if _, err = GetReleaseById(draft.Id); err != nil {
+		t.Errorf("GetReleaseById(other release): %v", err)
+	}
+}
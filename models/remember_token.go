@@ -0,0 +1,115 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/setting"
+)
+
+var (
+	ErrRememberTokenNotExist = errors.New("Remember token does not exist")
+	ErrRememberTokenTheft    = errors.New("Remember token has been used by someone else")
+)
+
+// RememberToken represents a persistent login of user, which is identified
+// by series and authorized by token that changes every time it is used.
+// Only SHA1 of token is stored.
+type RememberToken struct {
+	Id      int64
+	Uid     int64     `xorm:"INDEX"`
+	Series  string    `xorm:"UNIQUE VARCHAR(40)"`
+	Sha1    string    `xorm:"VARCHAR(40)"`
+	Expires time.Time `xorm:"INDEX"`
+	Created time.Time `xorm:"CREATED"`
+	Updated time.Time `xorm:"UPDATED"`
+}
+
+func newRememberTokenValue() string {
+	return base.GetRandomString(40)
+}
+
+// NewRememberToken creates a new persistent login of user,
+// it returns series and token to be stored in cookie.
+func NewRememberToken(uid int64) (series, token string, err error) {
+	series = newRememberTokenValue()
+	token = newRememberTokenValue()
+	_, err = orm.Insert(&RememberToken{
+		Uid:     uid,
+		Series:  series,
+		Sha1:    base.EncodeSha1(token),
+		Expires: time.Now().Add(time.Duration(setting.LogInRememberDays) * 24 * time.Hour),
+	})
+	return series, token, err
+}
+
+// UseRememberToken verifies given series and token, it returns the owner
+// and a new token that replaces the used one. Mismatched token of a known
+// series means the cookie has been stolen and replayed, all persistent
+// logins of the user are invalidated in such case.
+func UseRememberToken(series, token string) (*User, string, error) {
+	if len(series) == 0 {
+		return nil, "", ErrRememberTokenNotExist
+	}
+
+	t := &RememberToken{Series: series}
+	has, err := orm.Get(t)
+	if err != nil {
+		return nil, "", err
+	} else if !has {
+		return nil, "", ErrRememberTokenNotExist
+	} else if time.Now().After(t.Expires) {
+		DeleteRememberToken(series)
+		return nil, "", ErrRememberTokenNotExist
+	}
+
+	if subtle.ConstantTimeCompare([]byte(t.Sha1), []byte(base.EncodeSha1(token))) != 1 {
+		if err = DeleteUserRememberTokens(t.Uid); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrRememberTokenTheft
+	}
+
+	u, err := GetUserById(t.Uid)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Condition makes sure concurrent requests cannot both replace the same token.
+	oldSha := t.Sha1
+	token = newRememberTokenValue()
+	t.Sha1 = base.EncodeSha1(token)
+	affected, err := orm.Where("id=? AND sha1=?", t.Id, oldSha).Cols("sha1").Update(t)
+	if err != nil {
+		return nil, "", err
+	} else if affected != 1 {
+		return nil, "", ErrRememberTokenNotExist
+	}
+	return u, token, nil
+}
+
+// DeleteRememberToken deletes persistent login by given series.
+func DeleteRememberToken(series string) error {
+	_, err := orm.Where("series=?", series).Delete(new(RememberToken))
+	return err
+}
+
+// DeleteUserRememberTokens deletes all persistent logins of given user.
+func DeleteUserRememberTokens(uid int64) error {
+	_, err := orm.Where("uid=?", uid).Delete(new(RememberToken))
+	return err
+}
+
+// DeleteExpiredRememberTokens deletes all expired persistent logins.
+func DeleteExpiredRememberTokens() {
+	if _, err := orm.Where("expires<?", time.Now()).Delete(new(RememberToken)); err != nil {
+		log.Error("DeleteExpiredRememberTokens: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gogits/gogs/modules/setting"
+)
+
+func TestUseRememberToken(t *testing.T) {
+	defer prepareTestEnv(t)()
+	defer func(days int) { setting.LogInRememberDays = days }(setting.LogInRememberDays)
+	setting.LogInRememberDays = 7
+
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	series, token, err := NewRememberToken(u1.Id)
+	if err != nil {
+		t.Fatalf("NewRememberToken: %v", err)
+	}
+	otherSeries, otherToken, err := NewRememberToken(u2.Id)
+	if err != nil {
+		t.Fatalf("NewRememberToken: %v", err)
+	}
+
+	if _, _, err = UseRememberToken("", ""); err != ErrRememberTokenNotExist {
+		t.Errorf("UseRememberToken(empty series) error = %v, expected %v", err, ErrRememberTokenNotExist)
+	}
+
+	// Token rotates every time it is used.
+	u, newToken, err := UseRememberToken(series, token)
+	if err != nil {
+		t.Fatalf("UseRememberToken: %v", err)
+	} else if u.Id != u1.Id || newToken == token {
+		t.Errorf("UseRememberToken returns user %d, token rotated %v, expected %d, true", u.Id, newToken != token, u1.Id)
+	}
+
+	// Replaying the old token means the cookie has been stolen.
+	if _, _, err = UseRememberToken(series, token); err != ErrRememberTokenTheft {
+		t.Errorf("UseRememberToken(replayed token) error = %v, expected %v", err, ErrRememberTokenTheft)
+	}
+	if _, _, err = UseRememberToken(series, newToken); err != ErrRememberTokenNotExist {
+		t.Errorf("UseRememberToken(after theft) error = %v, expected %v", err, ErrRememberTokenNotExist)
+	}
+	if u, _, err = UseRememberToken(otherSeries, otherToken); err != nil || u.Id != u2.Id {
+		t.Errorf("UseRememberToken(other user) = (%v, %v), expected user %d", u, err, u2.Id)
+	}
+
+	if series, token, err = NewRememberToken(u1.Id); err != nil {
+		t.Fatalf("NewRememberToken: %v", err)
+	}
+	if _, err = orm.Where("series=?", series).Cols("expires").
+		Update(&RememberToken{Expires: time.Now().Add(-time.Minute)}); err != nil {
+		t.Fatalf("expire token: %v", err)
+	}
+	if _, _, err = UseRememberToken(series, token); err != ErrRememberTokenNotExist {
+		t.Errorf("UseRememberToken(expired) error = %v, expected %v", err, ErrRememberTokenNotExist)
+	}
+
+	if err = DeleteRememberToken(""); err != nil {
+		t.Fatalf("DeleteRememberToken(empty series): %v", err)
+	}
+	if count, err := orm.Count(new(RememberToken)); err != nil || count != 1 {
+		t.Errorf("%d remember tokens left, expected 1", count)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 	"unicode/utf8"
 
@@ -35,6 +36,8 @@ var (
 	ErrRepoNameIllegal   = errors.New("Repository name contains illegal characters")
 	ErrRepoFileNotLoaded = errors.New("Repository file not loaded")
 	ErrMirrorNotExist    = errors.New("Mirror does not exist")
+	ErrMirrorSyncing     = errors.New("Mirror is being synchronized")
+	ErrRepoArchived      = errors.New("Repository is archived and read-only")
 )
 
 var (
@@ -111,9 +114,11 @@ type Repository struct {
 	Id                  int64
 	OwnerId             int64 `xorm:"unique(s)"`
 	Owner               *User `xorm:"-"`
+	IsFork              bool  `xorm:"NOT NULL DEFAULT false"`
 	ForkId              int64
-	LowerName           string `xorm:"unique(s) index not null"`
-	Name                string `xorm:"index not null"`
+	BaseRepo            *Repository `xorm:"-"`
+	LowerName           string      `xorm:"unique(s) index not null"`
+	Name                string      `xorm:"index not null"`
 	Description         string
 	Website             string
 	NumWatches          int
@@ -130,9 +135,20 @@ type Repository struct {
 	IsMirror            bool
 	IsBare              bool
 	IsGoget             bool
+	IsTemplate          bool
+	IsArchived          bool  // Archived repository is read-only.
+	DeleteMergedHead    bool  // Default of deleting head branch when pull request is merged.
+	BlockOnDependencies bool  `xorm:"NOT NULL DEFAULT false"` // Issues cannot be closed while blocked by open issues.
+	Size                int64 // In bytes, updated after every push.
+	SizeLimit           int64 // In megabytes, 0 means default limit, -1 means unlimited.
 	DefaultBranch       string
-	Created             time.Time `xorm:"created"`
-	Updated             time.Time `xorm:"updated"`
+	// Comma separated merge styles allowed for pull requests, empty means all.
+	MergeStyles          string
+	DefaultMergeStyle    string
+	MergeMessageTemplate string    `xorm:"TEXT"` // Empty means default message of merge style.
+	Topics               []string  `xorm:"-"`
+	Created              time.Time `xorm:"created"`
+	Updated              time.Time `xorm:"updated"`
 }
 
 func (repo *Repository) GetOwner() (err error) {
@@ -140,6 +156,18 @@ func (repo *Repository) GetOwner() (err error) {
 	return err
 }
 
+// GetBaseRepo loads the repository that this one was forked from and its owner.
+func (repo *Repository) GetBaseRepo() (err error) {
+	if !repo.IsFork {
+		return nil
+	}
+
+	if repo.BaseRepo, err = GetRepositoryById(repo.ForkId); err != nil {
+		return err
+	}
+	return repo.BaseRepo.GetOwner()
+}
+
 // IsRepositoryExist returns true if the repository with given name under user has already existed.
 func IsRepositoryExist(u *User, repoName string) (bool, error) {
 	repo := Repository{OwnerId: u.Id}
@@ -154,7 +182,7 @@ func IsRepositoryExist(u *User, repoName string) (bool, error) {
 }
 
 var (
-	illegalEquals  = []string{"raw", "install", "api", "avatar", "user", "help", "stars", "issues", "pulls", "commits", "repo", "template", "admin"}
+	illegalEquals  = []string{"raw", "install", "api", "avatar", "user", "help", "stars", "issues", "pulls", "commits", "repo", "template", "admin", "explore", "search"}
 	illegalSuffixs = []string{".git"}
 )
 
@@ -176,12 +204,32 @@ func IsLegalName(repoName string) bool {
 
 // Mirror represents a mirror information of repository.
 type Mirror struct {
-	Id         int64
-	RepoId     int64
-	RepoName   string    // <user name>/<repo name>
-	Interval   int       // Hour.
-	Updated    time.Time `xorm:"UPDATED"`
-	NextUpdate time.Time
+	Id           int64
+	RepoId       int64
+	RepoName     string    // <user name>/<repo name>
+	Address      string    `xorm:"TEXT"` // Upstream URL without credentials.
+	AuthUserName string    // Credentials of upstream, if any.
+	AuthPasswd   string    `xorm:"TEXT"`
+	Interval     int       // Hour.
+	Updated      time.Time `xorm:"UPDATED"`
+	NextUpdate   time.Time
+	LastSync     time.Time
+	LastError    string `xorm:"TEXT"`
+	IsSyncing    bool   `xorm:"-"`
+}
+
+// FullAddress returns upstream URL with credentials for fetching.
+func (m *Mirror) FullAddress() string {
+	return buildRemoteAddress(m.Address, m.AuthUserName, m.AuthPasswd)
+}
+
+// buildRemoteAddress puts credentials into remote URL.
+func buildRemoteAddress(url, userName, passwd string) string {
+	if len(userName) == 0 && len(passwd) == 0 {
+		return url
+	}
+	authStr := strings.Replace(fmt.Sprintf("://%s:%s", userName, passwd), "@", "%40", -1)
+	return strings.Replace(url, "://", authStr+"@", 1)
 }
 
 func GetMirror(repoId int64) (*Mirror, error) {
@@ -192,50 +240,118 @@ func GetMirror(repoId int64) (*Mirror, error) {
 	} else if !has {
 		return nil, ErrMirrorNotExist
 	}
+	m.IsSyncing = IsMirrorSyncing(m.Id)
 	return m, nil
 }
 
 func UpdateMirror(m *Mirror) error {
-	_, err := orm.Id(m.Id).Update(m)
+	_, err := orm.Id(m.Id).AllCols().Update(m)
 	return err
 }
 
-// MirrorUpdate checks and updates mirror repositories.
-func MirrorUpdate() {
-	if err := orm.Iterate(new(Mirror), func(idx int, bean interface{}) error {
-		m := bean.(*Mirror)
-		if m.NextUpdate.After(time.Now()) {
-			return nil
-		}
+var (
+	mirrorSyncLock sync.Mutex
+	syncingMirrors = make(map[int64]bool)
+)
 
-		repoPath := filepath.Join(setting.RepoRootPath, m.RepoName+".git")
-		_, stderr, err := com.ExecCmdDir(repoPath, "git", "remote", "update")
-		if err != nil {
-			return errors.New("git remote update: " + stderr)
-		} else if err = git.UnpackRefs(repoPath); err != nil {
+// IsMirrorSyncing returns true if given mirror is being synchronized.
+func IsMirrorSyncing(id int64) bool {
+	mirrorSyncLock.Lock()
+	defer mirrorSyncLock.Unlock()
+	return syncingMirrors[id]
+}
+
+// SyncMirror fetches all references from upstream of mirror repository,
+// and records result of synchronization.
+func SyncMirror(m *Mirror) error {
+	mirrorSyncLock.Lock()
+	if syncingMirrors[m.Id] {
+		mirrorSyncLock.Unlock()
+		return ErrMirrorSyncing
+	}
+	syncingMirrors[m.Id] = true
+	mirrorSyncLock.Unlock()
+	defer func() {
+		mirrorSyncLock.Lock()
+		delete(syncingMirrors, m.Id)
+		mirrorSyncLock.Unlock()
+	}()
+
+	repoPath := filepath.Join(setting.RepoRootPath, m.RepoName+".git")
+	// Mirrors created before address was saved still use URL in git config.
+	args := []string{"fetch", "--prune", "origin"}
+	if len(m.Address) > 0 {
+		args = []string{"fetch", "--prune", m.FullAddress(), "+refs/*:refs/*"}
+	}
+
+	var syncErr error
+	if _, stderr, err := com.ExecCmdDir(repoPath, "git", args...); err != nil {
+		// Do not leak credentials through error message.
+		syncErr = errors.New("git fetch: " + strings.Replace(stderr, m.FullAddress(), m.Address, -1))
+	} else if err = git.UnpackRefs(repoPath); err != nil {
+		syncErr = err
+	} else if _, stderr, err = com.ExecCmdDir(repoPath, "git", "update-server-info"); err != nil {
+		syncErr = errors.New("git update-server-info: " + stderr)
+	}
+
+	m.LastSync = time.Now()
+	m.NextUpdate = m.LastSync.Add(time.Duration(m.Interval) * time.Hour)
+	m.LastError = ""
+	if syncErr != nil {
+		m.LastError = syncErr.Error()
+		log.Error("repo.SyncMirror(%s): %v", m.RepoName, syncErr)
+	}
+	if err := UpdateMirror(m); err != nil {
+		return err
+	} else if syncErr == nil {
+		if err = MarkRepoIndexerPending(m.RepoId); err != nil {
+			return err
+		} else if err = MarkRepoStatsPending(m.RepoId); err != nil {
+			return err
+		} else if err = UpdateRepoSize(&Repository{Id: m.RepoId}, repoPath); err != nil {
 			return err
 		}
+	}
+	return syncErr
+}
 
-		m.NextUpdate = time.Now().Add(time.Duration(m.Interval) * time.Hour)
-		return UpdateMirror(m)
-	}); err != nil {
+// MirrorUpdate checks and updates mirror repositories.
+func MirrorUpdate() {
+	mirrors := make([]*Mirror, 0, 10)
+	if err := orm.Where("next_update<=?", time.Now()).Find(&mirrors); err != nil {
 		log.Error("repo.MirrorUpdate: %v", err)
+		return
+	}
+
+	for _, m := range mirrors {
+		// Errors are recorded in mirror.
+		SyncMirror(m)
 	}
 }
 
 // MirrorRepository creates a mirror repository from source.
-func MirrorRepository(repoId int64, userName, repoName, repoPath, url string) error {
-	_, stderr, err := com.ExecCmd("git", "clone", "--mirror", url, repoPath)
+func MirrorRepository(repoId int64, userName, repoName, repoPath, url, authUserName, authPasswd string) error {
+	m := &Mirror{
+		RepoId:       repoId,
+		RepoName:     strings.ToLower(userName + "/" + repoName),
+		Address:      url,
+		AuthUserName: authUserName,
+		AuthPasswd:   authPasswd,
+		Interval:     24,
+	}
+
+	_, stderr, err := com.ExecCmd("git", "clone", "--mirror", m.FullAddress(), repoPath)
 	if err != nil {
-		return errors.New("git clone --mirror: " + stderr)
+		return errors.New("git clone --mirror: " + strings.Replace(stderr, m.FullAddress(), m.Address, -1))
+	}
+	// Credentials are kept in database instead of git config.
+	if _, stderr, err = com.ExecCmdDir(repoPath, "git", "remote", "set-url", "origin", url); err != nil {
+		return errors.New("git remote set-url: " + stderr)
 	}
 
-	if _, err = orm.InsertOne(&Mirror{
-		RepoId:     repoId,
-		RepoName:   strings.ToLower(userName + "/" + repoName),
-		Interval:   24,
-		NextUpdate: time.Now().Add(24 * time.Hour),
-	}); err != nil {
+	m.LastSync = time.Now()
+	m.NextUpdate = m.LastSync.Add(time.Duration(m.Interval) * time.Hour)
+	if _, err = orm.InsertOne(m); err != nil {
 		return err
 	}
 
@@ -243,7 +359,7 @@ func MirrorRepository(repoId int64, userName, repoName, repoPath, url string) er
 }
 
 // MigrateRepository migrates a existing repository from other project hosting.
-func MigrateRepository(user *User, name, desc string, private, mirror bool, url string) (*Repository, error) {
+func MigrateRepository(user *User, name, desc string, private, mirror bool, url, authUserName, authPasswd string) (*Repository, error) {
 	repo, err := CreateRepository(user, name, desc, "", "", private, mirror, false)
 	if err != nil {
 		return nil, err
@@ -257,10 +373,15 @@ func MigrateRepository(user *User, name, desc string, private, mirror bool, url
 
 	repo.IsBare = false
 	if mirror {
-		if err = MirrorRepository(repo.Id, user.Name, repo.Name, repoPath, url); err != nil {
+		if err = MirrorRepository(repo.Id, user.Name, repo.Name, repoPath, url, authUserName, authPasswd); err != nil {
 			return repo, err
 		}
 		repo.IsMirror = true
+		if err = MarkRepoIndexerPending(repo.Id); err != nil {
+			return repo, err
+		} else if err = UpdateRepoSize(repo, repoPath); err != nil {
+			return repo, err
+		}
 		return repo, UpdateRepository(repo)
 	}
 
@@ -271,7 +392,7 @@ func MigrateRepository(user *User, name, desc string, private, mirror bool, url
 	}
 
 	// Pull data from source.
-	_, stderr, err = com.ExecCmdDir(tmpDir, "git", "pull", url)
+	_, stderr, err = com.ExecCmdDir(tmpDir, "git", "pull", buildRemoteAddress(url, authUserName, authPasswd))
 	if err != nil {
 		return repo, errors.New("git pull: " + stderr)
 	}
@@ -280,6 +401,9 @@ func MigrateRepository(user *User, name, desc string, private, mirror bool, url
 	if _, stderr, err = com.ExecCmdDir(tmpDir, "git", "push", "origin", "master"); err != nil {
 		return repo, errors.New("git push: " + stderr)
 	}
+	if err = UpdateRepoSize(repo, repoPath); err != nil {
+		return repo, err
+	}
 
 	return repo, UpdateRepository(repo)
 }
@@ -569,6 +693,13 @@ func TransferOwnership(user *User, newOwner string, repo *Repository) (err error
 		return err
 	}
 
+	isExist, err := IsRepositoryExist(newUser, repo.Name)
+	if err != nil {
+		return err
+	} else if isExist {
+		return ErrRepoAlreadyExist
+	}
+
 	// Update accesses.
 	accesses := make([]Access, 0, 10)
 	if err = orm.Find(&accesses, &Access{RepoName: user.LowerName + "/" + repo.LowerName}); err != nil {
@@ -582,6 +713,15 @@ func TransferOwnership(user *User, newOwner string, repo *Repository) (err error
 	}
 
 	for i := range accesses {
+		// New owner gets access of previous owner, so access as collaborator is useless.
+		if accesses[i].UserName == newUser.LowerName {
+			if _, err = sess.Delete(&Access{Id: accesses[i].Id}); err != nil {
+				sess.Rollback()
+				return err
+			}
+			continue
+		}
+
 		accesses[i].RepoName = newUser.LowerName + "/" + repo.LowerName
 		if accesses[i].UserName == user.LowerName {
 			accesses[i].UserName = newUser.LowerName
@@ -610,26 +750,50 @@ func TransferOwnership(user *User, newOwner string, repo *Repository) (err error
 		return err
 	}
 
-	// Add watch of new owner to repository.
-	if !IsWatching(newUser.Id, repo.Id) {
-		if err = WatchRepo(newUser.Id, repo.Id, true); err != nil {
-			sess.Rollback()
-			return err
-		}
+	if _, err = sess.Delete(&RepoTransfer{RepoId: repo.Id}); err != nil {
+		sess.Rollback()
+		return err
 	}
 
-	if err = TransferRepoAction(user, newUser, repo); err != nil {
+	// Redirect previous location to new one, which is not a redirect anymore.
+	if _, err = sess.Delete(&RepoRedirect{OwnerId: newUser.Id, LowerName: repo.LowerName}); err != nil {
+		sess.Rollback()
+		return err
+	} else if _, err = sess.Delete(&RepoRedirect{OwnerId: user.Id, LowerName: repo.LowerName}); err != nil {
+		sess.Rollback()
+		return err
+	} else if _, err = sess.Insert(&RepoRedirect{OwnerId: user.Id, LowerName: repo.LowerName, RepoId: repo.Id}); err != nil {
 		sess.Rollback()
 		return err
 	}
 
 	// Change repository directory name.
+	if err = os.MkdirAll(UserPath(newUser.Name), os.ModePerm); err != nil {
+		sess.Rollback()
+		return err
+	}
 	if err = os.Rename(RepoPath(user.Name, repo.Name), RepoPath(newUser.Name, repo.Name)); err != nil {
 		sess.Rollback()
 		return err
 	}
+	if HasWiki(user.Name, repo.Name) {
+		if err = os.Rename(WikiPath(user.Name, repo.Name), WikiPath(newUser.Name, repo.Name)); err != nil {
+			sess.Rollback()
+			return err
+		}
+	}
 
-	return sess.Commit()
+	if err = sess.Commit(); err != nil {
+		return err
+	}
+
+	// Watch and action are not saved by session, so they are recorded after commit.
+	if !IsWatching(newUser.Id, repo.Id) {
+		if err = WatchRepo(newUser.Id, repo.Id, true); err != nil {
+			return err
+		}
+	}
+	return TransferRepoAction(user, newUser, repo)
 }
 
 // ChangeRepositoryName changes all corresponding setting from old repository name to new one.
@@ -658,6 +822,12 @@ func ChangeRepositoryName(userName, oldRepoName, newRepoName string) (err error)
 		sess.Rollback()
 		return err
 	}
+	if HasWiki(userName, oldRepoName) {
+		if err = os.Rename(WikiPath(userName, oldRepoName), WikiPath(userName, newRepoName)); err != nil {
+			sess.Rollback()
+			return err
+		}
+	}
 
 	return sess.Commit()
 }
@@ -685,6 +855,11 @@ func DeleteRepository(userId, repoId int64, userName string) (err error) {
 		return ErrRepoNotExist
 	}
 
+	rels, err := GetReleasesByRepoId(repoId)
+	if err != nil {
+		return err
+	}
+
 	sess := orm.NewSession()
 	defer sess.Close()
 	if err = sess.Begin(); err != nil {
@@ -718,17 +893,43 @@ func DeleteRepository(userId, repoId int64, userName string) (err error) {
 		sess.Rollback()
 		return err
 	}
+	if _, err = sess.Delete(&IssueFilter{RepoId: repoId}); err != nil {
+		sess.Rollback()
+		return err
+	}
 	if _, err = sess.Delete(&Release{RepoId: repoId}); err != nil {
 		sess.Rollback()
 		return err
 	}
 
-	// Delete comments.
+	// Delete comments, dependencies, approvals and reviews, issues only depend on issues of same repository.
 	if err = orm.Iterate(&Issue{RepoId: repoId}, func(idx int, bean interface{}) error {
 		issue := bean.(*Issue)
 		if _, err = sess.Delete(&Comment{IssueId: issue.Id}); err != nil {
 			sess.Rollback()
 			return err
+		} else if _, err = sess.Delete(&IssueDependency{IssueId: issue.Id}); err != nil {
+			sess.Rollback()
+			return err
+		} else if _, err = sess.Delete(&PullApproval{IssueId: issue.Id}); err != nil {
+			sess.Rollback()
+			return err
+		} else if _, err = sess.Exec("DELETE FROM `review_comment` WHERE thread_id IN "+
+			"(SELECT id FROM `review_thread` WHERE issue_id = ?)", issue.Id); err != nil {
+			sess.Rollback()
+			return err
+		} else if _, err = sess.Delete(&ReviewThread{IssueId: issue.Id}); err != nil {
+			sess.Rollback()
+			return err
+		} else if _, err = sess.Delete(&PullReview{IssueId: issue.Id}); err != nil {
+			sess.Rollback()
+			return err
+		} else if _, err = sess.Delete(&ReviewRequest{IssueId: issue.Id}); err != nil {
+			sess.Rollback()
+			return err
+		} else if _, err = sess.Delete(&IssueSubscription{IssueId: issue.Id}); err != nil {
+			sess.Rollback()
+			return err
 		}
 		return nil
 	}); err != nil {
@@ -741,20 +942,94 @@ func DeleteRepository(userId, repoId int64, userName string) (err error) {
 		return err
 	}
 
+	if _, err = sess.Delete(&PullRequest{BaseRepoId: repoId}); err != nil {
+		sess.Rollback()
+		return err
+	}
+	if _, err = sess.Delete(&ProtectedBranch{RepoId: repoId}); err != nil {
+		sess.Rollback()
+		return err
+	}
+	if _, err = sess.Delete(&PushMirror{RepoId: repoId}); err != nil {
+		sess.Rollback()
+		return err
+	}
+	if _, err = sess.Delete(&Migration{RepoId: repoId}); err != nil {
+		sess.Rollback()
+		return err
+	}
+	if _, err = sess.Delete(&RepoTransfer{RepoId: repoId}); err != nil {
+		sess.Rollback()
+		return err
+	}
+	if _, err = sess.Delete(&RepoRedirect{RepoId: repoId}); err != nil {
+		sess.Rollback()
+		return err
+	}
+	if _, err = sess.Delete(&Topic{RepoId: repoId}); err != nil {
+		sess.Rollback()
+		return err
+	}
+	if _, err = sess.Delete(&CommitStatus{RepoId: repoId}); err != nil {
+		sess.Rollback()
+		return err
+	}
+	if _, err = sess.Delete(&CommitComment{RepoId: repoId}); err != nil {
+		sess.Rollback()
+		return err
+	}
+	if _, err = sess.Delete(&RepoStats{RepoId: repoId}); err != nil {
+		sess.Rollback()
+		return err
+	}
+
 	rawSql := "UPDATE `user` SET num_repos = num_repos - 1 WHERE id = ?"
 	if _, err = sess.Exec(rawSql, userId); err != nil {
 		sess.Rollback()
 		return err
 	}
+
+	// Forks of this repository become standalone repositories.
+	if repo.IsFork {
+		if _, err = sess.Exec("UPDATE `repository` SET num_forks = num_forks - 1 WHERE id = ?", repo.ForkId); err != nil {
+			sess.Rollback()
+			return err
+		}
+	}
+	if _, err = sess.Exec("UPDATE `repository` SET fork_id = 0, is_fork = ? WHERE fork_id = ?", false, repoId); err != nil {
+		sess.Rollback()
+		return err
+	}
 	if err = sess.Commit(); err != nil {
 		sess.Rollback()
 		return err
 	}
+	for _, rel := range rels {
+		if err = DeleteReleaseAttachments(rel.Id); err != nil {
+			log.Error("delete attachments of release %d failed: %v", rel.Id, err)
+		}
+	}
+	if err = DeleteRepoIssueAttachments(repoId); err != nil {
+		log.Error("delete issue attachments of repo %s/%s failed: %v", userName, repo.Name, err)
+	}
+	if err = DeleteRepoProjects(repoId); err != nil {
+		log.Error("delete projects of repo %s/%s failed: %v", userName, repo.Name, err)
+	}
+	if err = DeleteRepoDeployKeys(repoId); err != nil {
+		log.Error("delete deploy keys of repo %s/%s failed: %v", userName, repo.Name, err)
+	}
+	if err = DeleteRepoIndex(repoId); err != nil {
+		log.Error("delete index of repo %s/%s failed: %v", userName, repo.Name, err)
+	}
 	if err = os.RemoveAll(RepoPath(userName, repo.Name)); err != nil {
 		// TODO: log and delete manully
 		log.Error("delete repo %s/%s failed: %v", userName, repo.Name, err)
 		return err
 	}
+	if err = os.RemoveAll(WikiPath(userName, repo.Name)); err != nil {
+		log.Error("delete wiki of repo %s/%s failed: %v", userName, repo.Name, err)
+		return err
+	}
 	return nil
 }
 
@@ -869,6 +1144,24 @@ func GetCollaborators(repoName string) (us []*User, err error) {
 	return us, nil
 }
 
+// GetRepoCollaborators returns a list of collaborators of repository with their access types.
+func GetRepoCollaborators(repoName string) ([]*Collaborator, error) {
+	accesses := make([]*Access, 0, 10)
+	if err := orm.Find(&accesses, &Access{RepoName: strings.ToLower(repoName)}); err != nil {
+		return nil, err
+	}
+
+	collaborators := make([]*Collaborator, len(accesses))
+	for i := range accesses {
+		u, err := GetUserByName(accesses[i].UserName)
+		if err != nil {
+			return nil, err
+		}
+		collaborators[i] = &Collaborator{u, accesses[i].Mode}
+	}
+	return collaborators, nil
+}
+
 // Watch is connection request for receiving repository notifycation.
 type Watch struct {
 	Id     int64
@@ -936,6 +1229,102 @@ func IsWatching(uid, rid int64) bool {
 	return has
 }
 
-func ForkRepository(repoName string, uid int64) {
+// HasForkedRepo checks if given user has already forked given repository,
+// and returns the fork if so.
+func HasForkedRepo(ownerId, repoId int64) (*Repository, bool) {
+	repo := new(Repository)
+	has, _ := orm.Where("owner_id=?", ownerId).And("fork_id=?", repoId).And("is_fork=?", true).Get(repo)
+	return repo, has
+}
+
+// ForkRepository creates a copy of given repository under user,
+// and keeps track of where it is forked from.
+func ForkRepository(u *User, oldRepo *Repository, name, desc string) (_ *Repository, err error) {
+	if !IsLegalName(name) {
+		return nil, ErrRepoNameIllegal
+	}
+
+	isExist, err := IsRepositoryExist(u, name)
+	if err != nil {
+		return nil, err
+	} else if isExist {
+		return nil, ErrRepoAlreadyExist
+	}
+
+	repo := &Repository{
+		OwnerId:       u.Id,
+		Owner:         u,
+		Name:          name,
+		LowerName:     strings.ToLower(name),
+		Description:   desc,
+		IsPrivate:     oldRepo.IsPrivate,
+		IsBare:        oldRepo.IsBare,
+		IsFork:        true,
+		ForkId:        oldRepo.Id,
+		DefaultBranch: oldRepo.DefaultBranch,
+	}
+
+	sess := orm.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	if _, err = sess.Insert(repo); err != nil {
+		sess.Rollback()
+		return nil, err
+	}
+	access := &Access{
+		UserName: u.LowerName,
+		RepoName: strings.ToLower(path.Join(u.Name, repo.Name)),
+		Mode:     AU_WRITABLE,
+	}
+	if _, err = sess.Insert(access); err != nil {
+		sess.Rollback()
+		return nil, err
+	}
+	if _, err = sess.Exec("UPDATE `user` SET num_repos = num_repos + 1 WHERE id = ?", u.Id); err != nil {
+		sess.Rollback()
+		return nil, err
+	}
+	if _, err = sess.Exec("UPDATE `repository` SET num_forks = num_forks + 1 WHERE id = ?", oldRepo.Id); err != nil {
+		sess.Rollback()
+		return nil, err
+	}
+
+	repoPath := RepoPath(u.Name, repo.Name)
+	if _, stderr, err := com.ExecCmd("git", "clone", "--bare", RepoPath(oldRepo.Owner.Name, oldRepo.Name), repoPath); err != nil {
+		sess.Rollback()
+		os.RemoveAll(repoPath)
+		return nil, errors.New("git clone: " + stderr)
+	}
+
+	rp := strings.NewReplacer("\\", "/", " ", "\\ ")
+	if err = createHookUpdate(filepath.Join(repoPath, "hooks", "update"),
+		fmt.Sprintf("#!/usr/bin/env %s\n%s update $1 $2 $3\n", setting.ScriptType,
+			rp.Replace(appPath))); err != nil {
+		sess.Rollback()
+		os.RemoveAll(repoPath)
+		return nil, err
+	}
+
+	if err = sess.Commit(); err != nil {
+		os.RemoveAll(repoPath)
+		return nil, err
+	}
+
+	if err = WatchRepo(u.Id, repo.Id, true); err != nil {
+		log.Error("repo.ForkRepository(WatchRepo): %v", err)
+	}
+	if _, stderr, err := com.ExecCmdDir(repoPath, "git", "update-server-info"); err != nil {
+		log.Error("repo.ForkRepository(update-server-info): %s", stderr)
+	}
+	return repo, nil
+}
 
+// GetForks returns all forks of given repository.
+func GetForks(repo *Repository) ([]*Repository, error) {
+	forks := make([]*Repository, 0, repo.NumForks)
+	err := orm.Where("fork_id=?", repo.Id).And("is_fork=?", true).Asc("id").Find(&forks)
+	return forks, err
 }
@@ -0,0 +1,68 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/gogits/gogs/modules/base"
+)
+
+const (
+	ARCHIVE_ZIP   = "zip"
+	ARCHIVE_TARGZ = "tar.gz"
+)
+
+// repoArchivesPath returns directory that caches archives of repository.
+func repoArchivesPath(repoPath string) string {
+	return filepath.Join(repoPath, "archives")
+}
+
+// RepoArchivePath returns path of cached archive of given commit,
+// name is used as both file name and top directory in archive.
+func RepoArchivePath(repoPath, commitId, name, format string) string {
+	return filepath.Join(repoArchivesPath(repoPath), commitId, name+"."+format)
+}
+
+// WriteRepoArchive writes archive of given commit to w while saving it to cache,
+// so later requests can be served from disk.
+func WriteRepoArchive(w io.Writer, repoPath, commitId, name, format string) error {
+	archivePath := RepoArchivePath(repoPath, commitId, name, format)
+	if err := os.MkdirAll(filepath.Dir(archivePath), os.ModePerm); err != nil {
+		return err
+	}
+
+	// Write to temporary file first, so an incomplete archive is never served from cache.
+	tmpPath := archivePath + "." + base.ToStr(time.Now().UnixNano())
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+
+	stderr := new(bytes.Buffer)
+	cmd := exec.Command("git", "archive", "--format="+format, "--prefix="+name+"/", commitId)
+	cmd.Dir = repoPath
+	cmd.Stdout = io.MultiWriter(w, f)
+	cmd.Stderr = stderr
+	err = cmd.Run()
+	f.Close()
+	if err != nil {
+		return errors.New("git archive: " + stderr.String())
+	}
+	return os.Rename(tmpPath, archivePath)
+}
+
+// ClearRepoArchives removes all cached archives of repository,
+// it is called after references of repository have been changed.
+func ClearRepoArchives(repoPath string) error {
+	return os.RemoveAll(repoArchivesPath(repoPath))
+}
@@ -0,0 +1,57 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestWriteRepoArchive(t *testing.T) {
+	repoPath, err := ioutil.TempDir("", "gogs-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoPath)
+	if _, err = execGitCmd(repoPath, nil, nil, "init", "-q", "--bare"); err != nil {
+		t.Fatal(err)
+	}
+	commitId := testCommitFiles(t, repoPath, "master", "", map[string]string{"README.md": "# repo1\n"}, "Initial commit")
+
+	buf := new(bytes.Buffer)
+	if err = WriteRepoArchive(buf, repoPath, commitId, "repo1-master", ARCHIVE_ZIP); err != nil {
+		t.Fatalf("WriteRepoArchive: %v", err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader: %v", err)
+	}
+	names := make(map[string]bool)
+	for _, f := range r.File {
+		names[f.Name] = true
+	}
+	if !names["repo1-master/README.md"] {
+		t.Errorf("archive has files %v, expected repo1-master/README.md", names)
+	}
+
+	// Same archive is cached on disk.
+	archivePath := RepoArchivePath(repoPath, commitId, "repo1-master", ARCHIVE_ZIP)
+	if data, err := ioutil.ReadFile(archivePath); err != nil || !bytes.Equal(data, buf.Bytes()) {
+		t.Errorf("cached archive is different from written one: %v", err)
+	}
+
+	if err = WriteRepoArchive(ioutil.Discard, repoPath, "0000000000000000000000000000000000000000", "none", ARCHIVE_ZIP); err == nil {
+		t.Error("WriteRepoArchive(missing commit) returns no error")
+	}
+
+	if err = ClearRepoArchives(repoPath); err != nil {
+		t.Fatalf("ClearRepoArchives: %v", err)
+	} else if _, err = os.Stat(archivePath); !os.IsNotExist(err) {
+		t.Errorf("cached archive is not removed: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Unknwon/com"
+
+	"github.com/gogits/gogs/modules/base"
+)
+
+var (
+	ErrCherryPickConflict = errors.New("Changes cannot be applied cleanly because of conflicts")
+	ErrCherryPickEmpty    = errors.New("Changes have already been applied to the branch")
+)
+
+// CherryPickCommit applies changes of given commit, or reverts them, on top of target branch,
+// and pushes result to new branch, which is the target branch itself when it is empty.
+// It returns ID of created commit.
+func CherryPickCommit(doer *User, repo *Repository, commitId, targetBranch, newBranch string, revert bool) (_ string, err error) {
+	if err = repo.GetOwner(); err != nil {
+		return "", err
+	}
+	repoPath := RepoPath(repo.Owner.Name, repo.Name)
+	if len(newBranch) == 0 {
+		newBranch = targetBranch
+	}
+
+	oldCommitId, err := execGitCmd(repoPath, nil, nil, "rev-parse", "--verify", "refs/heads/"+targetBranch)
+	if err != nil {
+		return "", ErrBranchNotExist
+	}
+	refName := "refs/heads/" + newBranch
+	expectCommitId := oldCommitId
+	if newBranch != targetBranch {
+		if _, err = execGitCmd(repoPath, nil, nil, "rev-parse", "--verify", refName); err == nil {
+			return "", ErrBranchAlreadyExist
+		}
+		expectCommitId = _EMPTY_COMMIT_ID
+	}
+	// New commit always fast-forwards the branch, so protection rules can be checked
+	// before it is created.
+	if err = CheckBranchPush(repo, repoPath, doer.Id, refName, expectCommitId, oldCommitId); err != nil {
+		return "", err
+	}
+
+	// Merge commit is applied against its first parent.
+	stdout, err := execGitCmd(repoPath, nil, nil, "rev-list", "--parents", "-n", "1", commitId)
+	if err != nil {
+		return "", ErrRevisionNotExist
+	}
+	args := []string{"cherry-pick", "-x"}
+	if revert {
+		args = []string{"revert", "--no-edit"}
+	}
+	if len(strings.Fields(stdout)) > 2 {
+		args = append(args, "-m", "1")
+	}
+	args = append(args, commitId)
+
+	tmpDir := filepath.Join(os.TempDir(), "gogs-cherry-pick-"+base.ToStr(time.Now().UnixNano()))
+	defer os.RemoveAll(tmpDir)
+
+	var stderr string
+	if _, stderr, err = com.ExecCmd("git", "clone", "-b", targetBranch, repoPath, tmpDir); err != nil {
+		return "", errors.New("git clone: " + stderr)
+	}
+
+	sig := doer.NewGitSig()
+	if _, stderr, err = com.ExecCmdDir(tmpDir, "git", append([]string{"-c", "user.name=" + sig.Name,
+		"-c", "user.email=" + sig.Email}, args...)...); err != nil {
+		if conflicts, _, _ := com.ExecCmdDir(tmpDir, "git", "diff", "--name-only", "--diff-filter=U"); len(strings.TrimSpace(conflicts)) > 0 {
+			return "", ErrCherryPickConflict
+		} else if status, _, _ := com.ExecCmdDir(tmpDir, "git", "status", "--porcelain"); len(strings.TrimSpace(status)) == 0 {
+			return "", ErrCherryPickEmpty
+		}
+		return "", errors.New("git " + args[0] + ": " + stderr)
+	}
+
+	newCommitId, err := execGitCmd(tmpDir, nil, nil, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	SetRepoEnvs(doer.Id, doer.Name, repo.Name, repo.Owner.Name)
+	if _, stderr, err = com.ExecCmdDir(tmpDir, "git", "push", "origin", "HEAD:"+refName); err != nil {
+		return "", errors.New("git push: " + stderr)
+	}
+
+	if err = Update(refName, expectCommitId, newCommitId, doer.Name, repo.Owner.Name, repo.Name, doer.Id); err != nil {
+		return "", err
+	}
+	return newCommitId, nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestCherryPickCommit(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo := newTestRepo(t, u, "repo1")
+	repoPath := RepoPath(u.Name, repo.Name)
+
+	addId := testCommitFiles(t, repoPath, "feature", "master", map[string]string{"a.txt": "a\n"}, "Add a")
+	conflictId := testCommitFiles(t, repoPath, "feature", "", map[string]string{"README.md": "feature\n"}, "Change README")
+	testCommitFiles(t, repoPath, "master", "", map[string]string{"README.md": "master\n"}, "Change README")
+
+	tests := []struct {
+		commitId, target, newBranch string
+		expected                    error
+	}{
+		{addId, "none", "", ErrBranchNotExist},
+		{addId, "master", "feature", ErrBranchAlreadyExist},
+		{"none", "master", "", ErrRevisionNotExist},
+		{conflictId, "master", "", ErrCherryPickConflict},
+	}
+	for _, tt := range tests {
+		if _, err := CherryPickCommit(u, repo, tt.commitId, tt.target, tt.newBranch, false); err != tt.expected {
+			t.Errorf("CherryPickCommit(%s, %s, %s) error = %v, expected %v", tt.commitId, tt.target, tt.newBranch, err, tt.expected)
+		}
+	}
+
+	newId, err := CherryPickCommit(u, repo, addId, "master", "", false)
+	if err != nil {
+		t.Fatalf("CherryPickCommit: %v", err)
+	}
+	if id, _ := execGitCmd(repoPath, nil, nil, "rev-parse", "master"); id != newId {
+		t.Errorf("master is at %s, expected %s", id, newId)
+	}
+	if content, err := execGitCmd(repoPath, nil, nil, "cat-file", "blob", "master:a.txt"); err != nil || content != "a" {
+		t.Errorf("content of a.txt is (%q, %v), expected %q", content, err, "a")
+	}
+	if _, err = CherryPickCommit(u, repo, addId, "master", "", false); err != ErrCherryPickEmpty {
+		t.Errorf("CherryPickCommit(applied) error = %v, expected %v", err, ErrCherryPickEmpty)
+	}
+
+	// Revert is pushed to new branch, target branch is not changed.
+	if _, err = CherryPickCommit(u, repo, newId, "master", "revert-a", true); err != nil {
+		t.Fatalf("CherryPickCommit(revert): %v", err)
+	}
+	if _, err = execGitCmd(repoPath, nil, nil, "cat-file", "-e", "revert-a:a.txt"); err == nil {
+		t.Error("a.txt exists after revert")
+	}
+	if id, _ := execGitCmd(repoPath, nil, nil, "rev-parse", "master"); id != newId {
+		t.Errorf("master is at %s after revert to new branch, expected %s", id, newId)
+	}
+}
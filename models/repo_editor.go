@@ -0,0 +1,180 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gogits/gogs/modules/base"
+)
+
+var (
+	ErrRepoFileAlreadyExist = errors.New("File already exists")
+	ErrRepoFileNameIllegal  = errors.New("File name contains illegal characters")
+	ErrRepoFileChanged      = errors.New("File has been changed since you started editing")
+	ErrBranchAlreadyExist   = errors.New("Branch already exists")
+	ErrBranchNotExist       = errors.New("Branch does not exist")
+)
+
+const _EMPTY_COMMIT_ID = "0000000000000000000000000000000000000000"
+
+// RepoFileChange represents a change to a single file that is committed from web.
+type RepoFileChange struct {
+	OldBranch    string
+	NewBranch    string
+	LastCommitId string // Commit that the change is based on.
+	OldTreeName  string // Empty when it is a new file.
+	NewTreeName  string // Empty when file is deleted.
+	Content      string
+	Message      string
+	Author       *User // Author of commit if it is not the committer.
+}
+
+// CleanTreeName returns cleaned relative path of file in repository,
+// or empty string if it is not a legal one.
+func CleanTreeName(name string) string {
+	name = strings.Trim(path.Clean("/"+strings.Replace(name, "\\", "/", -1)), "/")
+	if len(name) == 0 {
+		return ""
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".git" {
+			return ""
+		}
+	}
+	return name
+}
+
+// execGitCmd executes git command in given directory with extra environment variables and input.
+func execGitCmd(dir string, env []string, stdin io.Reader, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = stdin
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %s", args[0], stderr.String())
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// CommitRepoFileChange commits given change to branch directly in bare repository
+// through a temporary index file, so no working copy is needed.
+func CommitRepoFileChange(doer *User, repo *Repository, change *RepoFileChange) (err error) {
+	if err = repo.GetOwner(); err != nil {
+		return err
+	}
+	repoPath := RepoPath(repo.Owner.Name, repo.Name)
+
+	oldCommitId, err := execGitCmd(repoPath, nil, nil, "rev-parse", "--verify", "refs/heads/"+change.OldBranch)
+	if err != nil {
+		return ErrBranchNotExist
+	}
+	if change.NewBranch != change.OldBranch {
+		if _, err = execGitCmd(repoPath, nil, nil, "rev-parse", "--verify", "refs/heads/"+change.NewBranch); err == nil {
+			return ErrBranchAlreadyExist
+		}
+	}
+
+	// blobId returns ID of file in given commit, or empty string if it does not exist.
+	blobId := func(commitId, treeName string) string {
+		id, _ := execGitCmd(repoPath, nil, nil, "rev-parse", "--verify", "-q", commitId+":"+treeName)
+		return id
+	}
+
+	if len(change.OldTreeName) > 0 {
+		if len(blobId(oldCommitId, change.OldTreeName)) == 0 {
+			return ErrRepoFileNotExist
+		}
+		// Someone else may have changed the file after editing started.
+		if len(change.LastCommitId) > 0 && change.LastCommitId != oldCommitId &&
+			blobId(change.LastCommitId, change.OldTreeName) != blobId(oldCommitId, change.OldTreeName) {
+			return ErrRepoFileChanged
+		}
+	}
+	if len(change.NewTreeName) > 0 && change.NewTreeName != change.OldTreeName {
+		if len(blobId(oldCommitId, change.NewTreeName)) > 0 {
+			return ErrRepoFileAlreadyExist
+		}
+		// File cannot be created where a file is used as a directory.
+		for dir := path.Dir(change.NewTreeName); dir != "."; dir = path.Dir(dir) {
+			if typ, _ := execGitCmd(repoPath, nil, nil, "cat-file", "-t", oldCommitId+":"+dir); typ == "blob" {
+				return ErrRepoFileNameIllegal
+			}
+		}
+	}
+
+	indexFile := filepath.Join(os.TempDir(), "gogs-index-"+base.ToStr(time.Now().UnixNano()))
+	defer os.Remove(indexFile)
+	env := []string{"GIT_INDEX_FILE=" + indexFile}
+
+	if _, err = execGitCmd(repoPath, env, nil, "read-tree", oldCommitId); err != nil {
+		return err
+	}
+
+	mode := "100644"
+	if len(change.OldTreeName) > 0 {
+		// Keep mode of file, e.g. executable.
+		if stdout, err := execGitCmd(repoPath, nil, nil, "ls-tree", oldCommitId, "--", change.OldTreeName); err == nil {
+			if fields := strings.Fields(stdout); len(fields) > 0 {
+				mode = fields[0]
+			}
+		}
+		if _, err = execGitCmd(repoPath, env, nil, "update-index", "--force-remove", "--", change.OldTreeName); err != nil {
+			return err
+		}
+	}
+	if len(change.NewTreeName) > 0 {
+		id, err := execGitCmd(repoPath, nil, strings.NewReader(change.Content), "hash-object", "-w", "--stdin")
+		if err != nil {
+			return err
+		}
+		if _, err = execGitCmd(repoPath, env, nil, "update-index", "--add", "--cacheinfo", mode, id, change.NewTreeName); err != nil {
+			return err
+		}
+	}
+
+	treeId, err := execGitCmd(repoPath, env, nil, "write-tree")
+	if err != nil {
+		return err
+	}
+
+	sig, author := doer.NewGitSig(), doer.NewGitSig()
+	if change.Author != nil {
+		author = change.Author.NewGitSig()
+	}
+	sigEnv := []string{"GIT_AUTHOR_NAME=" + author.Name, "GIT_AUTHOR_EMAIL=" + author.Email,
+		"GIT_COMMITTER_NAME=" + sig.Name, "GIT_COMMITTER_EMAIL=" + sig.Email}
+	newCommitId, err := execGitCmd(repoPath, sigEnv, strings.NewReader(change.Message), "commit-tree", treeId, "-p", oldCommitId)
+	if err != nil {
+		return err
+	}
+
+	// Changes from web are subject to same protection rules as pushes.
+	refName := "refs/heads/" + change.NewBranch
+	expectCommitId := oldCommitId
+	if change.NewBranch != change.OldBranch {
+		expectCommitId = _EMPTY_COMMIT_ID
+	}
+	if err = CheckBranchPush(repo, repoPath, doer.Id, refName, expectCommitId, newCommitId); err != nil {
+		return err
+	}
+	if _, err = execGitCmd(repoPath, nil, nil, "update-ref", refName, newCommitId, expectCommitId); err != nil {
+		return err
+	}
+
+	return Update(refName, expectCommitId, newCommitId, doer.Name, repo.Owner.Name, repo.Name, doer.Id)
+}
@@ -0,0 +1,79 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+)
+
+var cleanTreeNameTests = []struct {
+	name, expected string
+}{
+	{"", ""},
+	{".", ""},
+	{"README.md", "README.md"},
+	{"/docs//guide/", "docs/guide"},
+	{"../README.md", "README.md"},
+	{"docs/../../README.md", "README.md"},
+	{"docs\\guide.md", "docs/guide.md"},
+	{".git/config", ""},
+	{"docs/.git", ""},
+	{"docs/.gitignore", "docs/.gitignore"},
+}
+
+func TestCleanTreeName(t *testing.T) {
+	for _, tt := range cleanTreeNameTests {
+		if name := CleanTreeName(tt.name); name != tt.expected {
+			t.Errorf("CleanTreeName(%q) = %q, expected %q", tt.name, name, tt.expected)
+		}
+	}
+}
+
+func TestCommitRepoFileChange(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo := newTestRepo(t, u, "repo1")
+	repoPath := RepoPath(u.Name, repo.Name)
+
+	baseId := testCommitFiles(t, repoPath, "master", "", map[string]string{"docs/guide.md": "guide\n"}, "Add guide")
+	testCommitFiles(t, repoPath, "master", "", map[string]string{"docs/guide.md": "new guide\n"}, "Update guide")
+
+	tests := []struct {
+		change   *RepoFileChange
+		expected error
+	}{
+		{&RepoFileChange{OldBranch: "dev", NewBranch: "dev", NewTreeName: "a.txt"}, ErrBranchNotExist},
+		{&RepoFileChange{OldBranch: "master", NewBranch: "master", OldTreeName: "none.md", NewTreeName: "none.md"}, ErrRepoFileNotExist},
+		{&RepoFileChange{OldBranch: "master", NewBranch: "master", LastCommitId: baseId,
+			OldTreeName: "docs/guide.md", NewTreeName: "docs/guide.md"}, ErrRepoFileChanged},
+		{&RepoFileChange{OldBranch: "master", NewBranch: "master", NewTreeName: "README.md"}, ErrRepoFileAlreadyExist},
+		{&RepoFileChange{OldBranch: "master", NewBranch: "master", NewTreeName: "README.md/a.txt"}, ErrRepoFileNameIllegal},
+	}
+	for i, tt := range tests {
+		if err := CommitRepoFileChange(u, repo, tt.change); err != tt.expected {
+			t.Errorf("#%d: CommitRepoFileChange error = %v, expected %v", i, err, tt.expected)
+		}
+	}
+
+	if err := AddProtectedBranch(&ProtectedBranch{RepoId: repo.Id, BranchName: "master", RequirePullRequest: true}); err != nil {
+		t.Fatalf("AddProtectedBranch: %v", err)
+	}
+	change := &RepoFileChange{OldBranch: "master", NewBranch: "master", NewTreeName: "docs/new.md", Content: "new\n", Message: "Add new"}
+	if err := CommitRepoFileChange(u, repo, change); err != ErrBranchRequirePullRequest {
+		t.Errorf("CommitRepoFileChange(protected branch) error = %v, expected %v", err, ErrBranchRequirePullRequest)
+	}
+
+	// Change can still be committed to a new branch for pull request.
+	change.NewBranch = "patch-1"
+	if err := CommitRepoFileChange(u, repo, change); err != nil {
+		t.Fatalf("CommitRepoFileChange(new branch): %v", err)
+	}
+	if content, err := execGitCmd(repoPath, nil, nil, "cat-file", "blob", "patch-1:docs/new.md"); err != nil || content != "new" {
+		t.Errorf("content of committed file is (%q, %v), expected %q", content, err, "new")
+	}
+	if err := CommitRepoFileChange(u, repo, change); err != ErrBranchAlreadyExist {
+		t.Errorf("CommitRepoFileChange(existing new branch) error = %v, expected %v", err, ErrBranchAlreadyExist)
+	}
+}
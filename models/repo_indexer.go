@@ -0,0 +1,634 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Unknwon/com"
+	"github.com/blevesearch/bleve"
+
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/setting"
+)
+
+var (
+	ErrRepoIndexerDisabled = errors.New("Repository indexer is disabled")
+)
+
+const (
+	_REPO_INDEXER_BATCH_SIZE = 50
+	// Maximum number of matched lines shown for every file.
+	_CODE_SEARCH_MAX_LINES = 5
+)
+
+// RepoIndexerStatus represents progress of indexing default branch of a repository.
+// Changes are recorded by update hook and indexed by web server later,
+// because only one process can open the index.
+type RepoIndexerStatus struct {
+	Id        int64
+	RepoId    int64     `xorm:"UNIQUE NOT NULL"`
+	CommitId  string    `xorm:"VARCHAR(40)"` // Last indexed commit, empty if nothing is indexed.
+	IsPending bool      `xorm:"INDEX"`
+	Updated   time.Time `xorm:"UPDATED"`
+}
+
+// codeIndexerData represents a file in index.
+type codeIndexerData struct {
+	RepoId   string
+	CommitId string
+	Path     string
+	Language string
+	Content  string
+}
+
+var (
+	repoIndexer        bleve.Index
+	repoIndexerLock    sync.Mutex
+	repoIndexerRunning bool
+)
+
+// Languages recognized by file extension, used for filtering search results.
+var codeLanguages = map[string]string{
+	".bash":     "Shell",
+	".c":        "C",
+	".cc":       "C++",
+	".cpp":      "C++",
+	".cs":       "C#",
+	".css":      "CSS",
+	".go":       "Go",
+	".h":        "C",
+	".hpp":      "C++",
+	".htm":      "HTML",
+	".html":     "HTML",
+	".java":     "Java",
+	".js":       "JavaScript",
+	".json":     "JSON",
+	".less":     "Less",
+	".lua":      "Lua",
+	".m":        "Objective-C",
+	".markdown": "Markdown",
+	".md":       "Markdown",
+	".php":      "PHP",
+	".pl":       "Perl",
+	".py":       "Python",
+	".rb":       "Ruby",
+	".rs":       "Rust",
+	".scala":    "Scala",
+	".sh":       "Shell",
+	".sql":      "SQL",
+	".swift":    "Swift",
+	".tmpl":     "HTML",
+	".xml":      "XML",
+	".yaml":     "YAML",
+	".yml":      "YAML",
+}
+
+// codeLanguage returns language of file by its extension.
+func codeLanguage(treePath string) string {
+	return codeLanguages[strings.ToLower(path.Ext(treePath))]
+}
+
+// CodeLanguages returns sorted names of all recognized languages.
+func CodeLanguages() []string {
+	seen := make(map[string]bool)
+	langs := make([]string, 0, len(codeLanguages))
+	for _, lang := range codeLanguages {
+		if !seen[lang] {
+			seen[lang] = true
+			langs = append(langs, lang)
+		}
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// NewRepoIndexer opens index of repositories, a new index is created and
+// filled with all repositories if it does not exist.
+func NewRepoIndexer() error {
+	if !setting.RepoIndexerEnabled {
+		return nil
+	}
+
+	var err error
+	if com.IsExist(setting.RepoIndexerPath) {
+		repoIndexer, err = bleve.Open(setting.RepoIndexerPath)
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(setting.RepoIndexerPath), os.ModePerm); err != nil {
+		return err
+	}
+
+	keywordMapping := bleve.NewTextFieldMapping()
+	keywordMapping.Analyzer = "keyword"
+	keywordMapping.IncludeInAll = false
+	contentMapping := bleve.NewTextFieldMapping()
+	contentMapping.Store = false
+	contentMapping.IncludeInAll = false
+
+	docMapping := bleve.NewDocumentMapping()
+	docMapping.AddFieldMappingsAt("RepoId", keywordMapping)
+	docMapping.AddFieldMappingsAt("CommitId", keywordMapping)
+	docMapping.AddFieldMappingsAt("Path", keywordMapping)
+	docMapping.AddFieldMappingsAt("Language", keywordMapping)
+	docMapping.AddFieldMappingsAt("Content", contentMapping)
+	mapping := bleve.NewIndexMapping()
+	mapping.DefaultMapping = docMapping
+
+	if repoIndexer, err = bleve.New(setting.RepoIndexerPath, mapping); err != nil {
+		return err
+	}
+
+	// Everything has to be indexed again for a new index.
+	if _, err = orm.Where("id>0").Cols("commit_id", "is_pending").
+		Update(&RepoIndexerStatus{CommitId: "", IsPending: true}); err != nil {
+		return err
+	}
+	for start := 0; ; start += 50 {
+		repos := make([]*Repository, 0, 50)
+		if err = orm.Limit(50, start).Asc("id").Find(&repos); err != nil {
+			return err
+		} else if len(repos) == 0 {
+			break
+		}
+		for _, repo := range repos {
+			if err = MarkRepoIndexerPending(repo.Id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// MarkRepoIndexerPending marks repository to be indexed again,
+// it is called after default branch of repository has been changed.
+func MarkRepoIndexerPending(repoId int64) error {
+	if !setting.RepoIndexerEnabled {
+		return nil
+	}
+
+	affected, err := orm.Where("repo_id=?", repoId).Cols("is_pending").
+		Update(&RepoIndexerStatus{IsPending: true})
+	if err != nil {
+		return err
+	} else if affected == 0 {
+		_, err = orm.Insert(&RepoIndexerStatus{RepoId: repoId, IsPending: true})
+	}
+	return err
+}
+
+func repoIndexerDocId(repoId int64, treePath string) string {
+	return base.ToStr(repoId) + "_" + treePath
+}
+
+// deleteRepoFromIndexer removes all files of repository from index.
+func deleteRepoFromIndexer(repoId int64) error {
+	query := bleve.NewTermQuery(base.ToStr(repoId))
+	query.SetField("RepoId")
+	for {
+		result, err := repoIndexer.Search(bleve.NewSearchRequestOptions(query, _REPO_INDEXER_BATCH_SIZE*10, 0, false))
+		if err != nil {
+			return err
+		} else if len(result.Hits) == 0 {
+			return nil
+		}
+
+		batch := repoIndexer.NewBatch()
+		for _, hit := range result.Hits {
+			batch.Delete(hit.ID)
+		}
+		if err = repoIndexer.Batch(batch); err != nil {
+			return err
+		}
+	}
+}
+
+// indexedBlob represents a file of tree that can be indexed.
+type indexedBlob struct {
+	id   string
+	size int64
+}
+
+// listIndexedBlobs returns all files of commit with their blob IDs and sizes.
+func listIndexedBlobs(repoPath, commitId string) (map[string]*indexedBlob, error) {
+	stdout, stderr, err := com.ExecCmdDirBytes(repoPath, "git", "ls-tree", "-r", "-l", "-z", commitId)
+	if err != nil {
+		return nil, errors.New("git ls-tree: " + string(stderr))
+	}
+
+	blobs := make(map[string]*indexedBlob)
+	for _, entry := range bytes.Split(stdout, []byte{0}) {
+		// Format: "<mode> <type> <object> <size>\t<path>".
+		tab := bytes.IndexByte(entry, '\t')
+		if tab == -1 {
+			continue
+		}
+		fields := strings.Fields(string(entry[:tab]))
+		if len(fields) != 4 || fields[1] != "blob" {
+			continue
+		}
+		size, _ := base.StrTo(fields[3]).Int64()
+		blobs[string(entry[tab+1:])] = &indexedBlob{fields[2], size}
+	}
+	return blobs, nil
+}
+
+// listChangedFiles returns paths of files that have been changed between two commits.
+func listChangedFiles(repoPath, oldCommitId, newCommitId string) ([]string, error) {
+	stdout, stderr, err := com.ExecCmdDirBytes(repoPath, "git", "diff", "--name-only", "--no-renames", "-z",
+		oldCommitId, newCommitId)
+	if err != nil {
+		return nil, errors.New("git diff: " + string(stderr))
+	}
+
+	paths := make([]string, 0, 10)
+	for _, p := range bytes.Split(stdout, []byte{0}) {
+		if len(p) > 0 {
+			paths = append(paths, string(p))
+		}
+	}
+	return paths, nil
+}
+
+// updateRepoIndex indexes changes of default branch since last indexed commit,
+// the whole tree is indexed again when history has been rewritten.
+func updateRepoIndex(status *RepoIndexerStatus) error {
+	repo, err := GetRepositoryById(status.RepoId)
+	if err != nil {
+		if err == ErrRepoNotExist {
+			_, err = orm.Delete(&RepoIndexerStatus{RepoId: status.RepoId})
+		}
+		return err
+	} else if err = repo.GetOwner(); err != nil {
+		return err
+	}
+
+	repoPath := RepoPath(repo.Owner.Name, repo.Name)
+	commitId := ""
+	if !repo.IsBare && len(repo.DefaultBranch) > 0 {
+		// Default branch may not exist yet, then nothing is indexed.
+		commitId, _ = execGitCmd(repoPath, nil, nil, "rev-parse", "--verify", "-q", "refs/heads/"+repo.DefaultBranch)
+	}
+
+	var paths []string
+	if len(status.CommitId) > 0 && len(commitId) > 0 {
+		if _, err = execGitCmd(repoPath, nil, nil, "merge-base", "--is-ancestor", status.CommitId, commitId); err == nil {
+			if paths, err = listChangedFiles(repoPath, status.CommitId, commitId); err != nil {
+				return err
+			}
+		}
+	}
+	if paths == nil {
+		if err = deleteRepoFromIndexer(repo.Id); err != nil {
+			return err
+		}
+	}
+
+	if len(commitId) > 0 {
+		blobs, err := listIndexedBlobs(repoPath, commitId)
+		if err != nil {
+			return err
+		}
+		if paths == nil {
+			paths = make([]string, 0, len(blobs))
+			for p := range blobs {
+				paths = append(paths, p)
+			}
+		}
+
+		batch := repoIndexer.NewBatch()
+		for _, p := range paths {
+			docId := repoIndexerDocId(repo.Id, p)
+			blob := blobs[p]
+			if blob == nil || blob.size > setting.RepoIndexerMaxFileSize*1024 {
+				batch.Delete(docId)
+				continue
+			}
+
+			content, stderr, err := com.ExecCmdDirBytes(repoPath, "git", "cat-file", "blob", blob.id)
+			if err != nil {
+				return errors.New("git cat-file: " + string(stderr))
+			} else if _, isText := base.IsTextFile(content); !isText {
+				batch.Delete(docId)
+				continue
+			}
+
+			if err = batch.Index(docId, &codeIndexerData{
+				RepoId:   base.ToStr(repo.Id),
+				CommitId: commitId,
+				Path:     p,
+				Language: codeLanguage(p),
+				Content:  string(content),
+			}); err != nil {
+				return err
+			}
+			if batch.Size() >= _REPO_INDEXER_BATCH_SIZE {
+				if err = repoIndexer.Batch(batch); err != nil {
+					return err
+				}
+				batch = repoIndexer.NewBatch()
+			}
+		}
+		if err = repoIndexer.Batch(batch); err != nil {
+			return err
+		}
+	}
+
+	status.CommitId = commitId
+	status.IsPending = false
+	_, err = orm.Id(status.Id).Cols("commit_id", "is_pending").Update(status)
+	return err
+}
+
+// RepoIndexerUpdate indexes repositories that have pending changes.
+func RepoIndexerUpdate() {
+	if repoIndexer == nil {
+		return
+	}
+
+	// Skip if previous round is still running.
+	repoIndexerLock.Lock()
+	if repoIndexerRunning {
+		repoIndexerLock.Unlock()
+		return
+	}
+	repoIndexerRunning = true
+	repoIndexerLock.Unlock()
+	defer func() {
+		repoIndexerLock.Lock()
+		repoIndexerRunning = false
+		repoIndexerLock.Unlock()
+	}()
+
+	statuses := make([]*RepoIndexerStatus, 0, 10)
+	if err := orm.Where("is_pending=?", true).Find(&statuses); err != nil {
+		log.Error("models.RepoIndexerUpdate: %v", err)
+		return
+	}
+
+	for _, status := range statuses {
+		if err := updateRepoIndex(status); err != nil {
+			log.Error("models.RepoIndexerUpdate(%d): %v", status.RepoId, err)
+		}
+	}
+}
+
+// DeleteRepoIndex removes repository from index, it is called after repository has been deleted.
+func DeleteRepoIndex(repoId int64) error {
+	if _, err := orm.Delete(&RepoIndexerStatus{RepoId: repoId}); err != nil {
+		return err
+	} else if repoIndexer == nil {
+		return nil
+	}
+	return deleteRepoFromIndexer(repoId)
+}
+
+// CodeSearchLine represents a line of file that matches keyword.
+type CodeSearchLine struct {
+	Num     int
+	Content string
+}
+
+// CodeSearchResult represents a file that matches keyword.
+type CodeSearchResult struct {
+	Repo     *Repository
+	CommitId string
+	Path     string
+	Language string
+	Lines    []*CodeSearchLine
+}
+
+// SearchCodeOptions represents conditions of searching code.
+type SearchCodeOptions struct {
+	Keyword  string
+	Language string
+	RepoIds  []int64 // Repositories to search in, nil means all repositories.
+	Page     int     // Starts from 1.
+	PageSize int
+}
+
+// hitField returns value of stored string field of search hit.
+func hitField(fields map[string]interface{}, name string) string {
+	value, _ := fields[name].(string)
+	return value
+}
+
+// matchLines returns lines of content that contain any of keywords.
+func matchLines(content string, keywords []string) []*CodeSearchLine {
+	lines := make([]*CodeSearchLine, 0, _CODE_SEARCH_MAX_LINES)
+	for i, line := range strings.Split(content, "\n") {
+		lower := strings.ToLower(line)
+		for _, kw := range keywords {
+			if strings.Contains(lower, kw) {
+				lines = append(lines, &CodeSearchLine{i + 1, line})
+				break
+			}
+		}
+		if len(lines) == _CODE_SEARCH_MAX_LINES {
+			break
+		}
+	}
+	return lines
+}
+
+// SearchCode returns files that match keyword in indexed repositories and total number of them.
+func SearchCode(opts *SearchCodeOptions) ([]*CodeSearchResult, int, error) {
+	if repoIndexer == nil {
+		return nil, 0, ErrRepoIndexerDisabled
+	} else if opts.RepoIds != nil && len(opts.RepoIds) == 0 {
+		return nil, 0, nil
+	}
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+
+	contentQuery := bleve.NewMatchQuery(opts.Keyword)
+	contentQuery.SetField("Content")
+	query := bleve.NewConjunctionQuery(contentQuery)
+	if opts.RepoIds != nil {
+		repoQuery := bleve.NewDisjunctionQuery()
+		for _, id := range opts.RepoIds {
+			q := bleve.NewTermQuery(base.ToStr(id))
+			q.SetField("RepoId")
+			repoQuery.AddQuery(q)
+		}
+		query.AddQuery(repoQuery)
+	}
+	if len(opts.Language) > 0 {
+		langQuery := bleve.NewTermQuery(opts.Language)
+		langQuery.SetField("Language")
+		query.AddQuery(langQuery)
+	}
+
+	req := bleve.NewSearchRequestOptions(query, opts.PageSize, (opts.Page-1)*opts.PageSize, false)
+	req.Fields = []string{"RepoId", "CommitId", "Path", "Language"}
+	result, err := repoIndexer.Search(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	keywords := strings.Fields(strings.ToLower(opts.Keyword))
+	repos := make(map[int64]*Repository)
+	results := make([]*CodeSearchResult, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		repoId, _ := base.StrTo(hitField(hit.Fields, "RepoId")).Int64()
+		repo, ok := repos[repoId]
+		if !ok {
+			if repo, err = GetRepositoryById(repoId); err != nil {
+				if err == ErrRepoNotExist {
+					continue
+				}
+				return nil, 0, err
+			} else if err = repo.GetOwner(); err != nil {
+				return nil, 0, err
+			}
+			repos[repoId] = repo
+		}
+
+		r := &CodeSearchResult{
+			Repo:     repo,
+			CommitId: hitField(hit.Fields, "CommitId"),
+			Path:     hitField(hit.Fields, "Path"),
+			Language: hitField(hit.Fields, "Language"),
+		}
+		// Content is not stored in index, lines are matched from repository.
+		content, _, err := com.ExecCmdDir(RepoPath(repo.Owner.Name, repo.Name),
+			"git", "cat-file", "blob", r.CommitId+":"+r.Path)
+		if err == nil {
+			r.Lines = matchLines(content, keywords)
+		}
+		results = append(results, r)
+	}
+	return results, int(result.Total), nil
+}
+
+// GetAccessibleRepoIds returns IDs of all repositories that user can read,
+// nil means user can read all repositories.
+func GetAccessibleRepoIds(u *User) ([]int64, error) {
+	if u != nil && u.IsAdmin {
+		return nil, nil
+	}
+
+	repos := make([]*Repository, 0, 50)
+	if err := orm.Where("is_private=?", false).Cols("id").Find(&repos); err != nil {
+		return nil, err
+	}
+	if u != nil {
+		owned, err := GetRepositories(u.Id, true)
+		if err != nil {
+			return nil, err
+		}
+		collaborative, err := GetCollaborativeRepos(u.Name)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(append(repos, owned...), collaborative...)
+	}
+
+	seen := make(map[int64]bool, len(repos))
+	ids := make([]int64, 0, len(repos))
+	for _, repo := range repos {
+		if !seen[repo.Id] {
+			seen[repo.Id] = true
+			ids = append(ids, repo.Id)
+		}
+	}
+	return ids, nil
+}
+
+// GetSearchableRepoIds returns IDs of repositories that user can search code in,
+// it is limited to given repository("<owner>/<name>") if repoLink is not empty.
+func GetSearchableRepoIds(u *User, repoLink string) ([]int64, error) {
+	ids, err := GetAccessibleRepoIds(u)
+	if err != nil || len(repoLink) == 0 {
+		return ids, err
+	}
+
+	infos := strings.SplitN(repoLink, "/", 2)
+	if len(infos) != 2 {
+		return nil, ErrRepoNotExist
+	}
+	owner, err := GetUserByName(infos[0])
+	if err != nil {
+		if err == ErrUserNotExist {
+			return nil, ErrRepoNotExist
+		}
+		return nil, err
+	}
+	repo, err := GetRepositoryByName(owner.Id, infos[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if ids == nil {
+		return []int64{repo.Id}, nil
+	}
+	for _, id := range ids {
+		if id == repo.Id {
+			return []int64{repo.Id}, nil
+		}
+	}
+	// Do not expose existence of private repository.
+	return nil, ErrRepoNotExist
+}
+
+// FilterRepoIdsByOwner returns given repository IDs that belong to owner,
+// nil IDs mean all repositories.
+func FilterRepoIdsByOwner(ids []int64, ownerId int64) ([]int64, error) {
+	repos, err := GetRepositories(ownerId, true)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		allowed[id] = true
+	}
+	owned := make([]int64, 0, len(repos))
+	for _, repo := range repos {
+		if ids == nil || allowed[repo.Id] {
+			owned = append(owned, repo.Id)
+		}
+	}
+	return owned, nil
+}
+
+// GetRepoIdsByLanguage returns IDs of repositories that have indexed files
+// of given language in their default branches.
+func GetRepoIdsByLanguage(lang string) ([]int64, error) {
+	if repoIndexer == nil {
+		return nil, ErrRepoIndexerDisabled
+	}
+	numRepos, err := orm.Count(new(Repository))
+	if err != nil {
+		return nil, err
+	}
+
+	query := bleve.NewTermQuery(lang)
+	query.SetField("Language")
+	req := bleve.NewSearchRequestOptions(query, 0, 0, false)
+	req.AddFacet("repos", bleve.NewFacetRequest("RepoId", int(numRepos)))
+	result, err := repoIndexer.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, 10)
+	for _, term := range result.Facets["repos"].Terms {
+		id, _ := base.StrTo(term.Term).Int64()
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
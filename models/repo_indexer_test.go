@@ -0,0 +1,200 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gogits/gogs/modules/setting"
+)
+
+func TestMatchLines(t *testing.T) {
+	content := "package main\n\nfunc Main() {\n\tmain()\n}\n"
+	lines := matchLines(content, []string{"main", "none"})
+	if len(lines) != 3 || lines[0].Num != 1 || lines[1].Num != 3 || lines[2].Num != 4 || lines[2].Content != "\tmain()" {
+		t.Errorf("matchLines returns %v, expected lines 1, 3 and 4", lines)
+	}
+
+	content = "a\na\na\na\na\na\na\n"
+	if lines = matchLines(content, []string{"a"}); len(lines) != _CODE_SEARCH_MAX_LINES {
+		t.Errorf("matchLines returns %d lines, expected %d", len(lines), _CODE_SEARCH_MAX_LINES)
+	}
+}
+
+// prepareTestRepoIndexer creates a new repository index in temporary directory of test.
+func prepareTestRepoIndexer(t *testing.T) func() {
+	oldEnabled, oldPath, oldMaxFileSize := setting.RepoIndexerEnabled, setting.RepoIndexerPath, setting.RepoIndexerMaxFileSize
+	setting.RepoIndexerEnabled = true
+	setting.RepoIndexerPath = filepath.Join(setting.RepoRootPath, "..", "indexers", "repos.bleve")
+	setting.RepoIndexerMaxFileSize = 1
+	if err := NewRepoIndexer(); err != nil {
+		t.Fatalf("NewRepoIndexer: %v", err)
+	}
+	return func() {
+		repoIndexer.Close()
+		repoIndexer = nil
+		setting.RepoIndexerEnabled, setting.RepoIndexerPath, setting.RepoIndexerMaxFileSize = oldEnabled, oldPath, oldMaxFileSize
+	}
+}
+
+func TestRepoIndexer(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo1, repo2 := newTestRepo(t, u, "repo1"), newTestRepo(t, u, "repo2")
+	defer prepareTestRepoIndexer(t)()
+
+	big := make([]byte, 2048)
+	for i := range big {
+		big[i] = 'x'
+	}
+	repoPath := RepoPath(u.Name, repo1.Name)
+	testCommitFiles(t, repoPath, "master", "", map[string]string{
+		"main.go":  "package main\n\nfunc main() {\n\tgopher()\n}\n",
+		"old.md":   "gopher\n",
+		"big.txt":  "gopher " + string(big),
+		"data.bin": "gopher\x00\x01",
+	}, "Add files")
+	testCommitFiles(t, RepoPath(u.Name, repo2.Name), "master", "", map[string]string{"gopher.py": "gopher = 1\n"}, "Add gopher")
+	RepoIndexerUpdate()
+
+	search := func(opts *SearchCodeOptions) map[string]bool {
+		opts.Keyword, opts.PageSize = "gopher", 10
+		results, _, err := SearchCode(opts)
+		if err != nil {
+			t.Fatalf("SearchCode: %v", err)
+		}
+		paths := make(map[string]bool)
+		for _, r := range results {
+			paths[r.Repo.Name+"/"+r.Path] = true
+		}
+		return paths
+	}
+
+	// Large and binary files are not indexed.
+	if paths := search(&SearchCodeOptions{}); len(paths) != 3 || !paths["repo1/main.go"] || !paths["repo1/old.md"] || !paths["repo2/gopher.py"] {
+		t.Errorf("SearchCode returns %v, expected main.go, old.md of repo1 and gopher.py of repo2", paths)
+	}
+	if paths := search(&SearchCodeOptions{Language: "Go"}); len(paths) != 1 || !paths["repo1/main.go"] {
+		t.Errorf("SearchCode(Go) returns %v, expected main.go of repo1", paths)
+	}
+	if paths := search(&SearchCodeOptions{RepoIds: []int64{repo2.Id}}); len(paths) != 1 || !paths["repo2/gopher.py"] {
+		t.Errorf("SearchCode(repo2) returns %v, expected gopher.py of repo2", paths)
+	}
+	if paths := search(&SearchCodeOptions{RepoIds: []int64{}}); len(paths) != 0 {
+		t.Errorf("SearchCode(no repository) returns %v, expected nothing", paths)
+	}
+
+	// Changes of default branch are indexed incrementally.
+	testCommitFiles(t, repoPath, "master", "", map[string]string{"old.md": ""}, "Remove old")
+	if err := MarkRepoIndexerPending(repo1.Id); err != nil {
+		t.Fatalf("MarkRepoIndexerPending: %v", err)
+	}
+	RepoIndexerUpdate()
+	if paths := search(&SearchCodeOptions{RepoIds: []int64{repo1.Id}}); len(paths) != 1 || !paths["repo1/main.go"] {
+		t.Errorf("SearchCode(repo1) returns %v after removal, expected main.go", paths)
+	}
+
+	if err := DeleteRepoIndex(repo2.Id); err != nil {
+		t.Fatalf("DeleteRepoIndex: %v", err)
+	}
+	if paths := search(&SearchCodeOptions{}); len(paths) != 1 || !paths["repo1/main.go"] {
+		t.Errorf("SearchCode returns %v after deleting repo2, expected main.go of repo1", paths)
+	}
+}
+
+func TestGetSearchableRepoIds(t *testing.T) {
+	defer prepareTestEnv(t)()
+	owner, u := newTestUser(t, "owner"), newTestUser(t, "user1")
+	public, private := newTestRepo(t, owner, "public"), newTestRepo(t, owner, "private")
+	private.IsPrivate = true
+	if err := UpdateRepository(private); err != nil {
+		t.Fatal(err)
+	}
+
+	if ids, err := GetSearchableRepoIds(nil, ""); err != nil || len(ids) != 1 || ids[0] != public.Id {
+		t.Errorf("GetSearchableRepoIds(anonymous) = (%v, %v), expected [%d]", ids, err, public.Id)
+	}
+	if _, err := GetSearchableRepoIds(u, "owner/private"); err != ErrRepoNotExist {
+		t.Errorf("GetSearchableRepoIds(private repository) error = %v, expected %v", err, ErrRepoNotExist)
+	}
+
+	if err := AddAccess(&Access{UserName: u.LowerName, RepoName: "owner/private", Mode: AU_READABLE}); err != nil {
+		t.Fatal(err)
+	}
+	if ids, err := GetSearchableRepoIds(u, "owner/private"); err != nil || len(ids) != 1 || ids[0] != private.Id {
+		t.Errorf("GetSearchableRepoIds(collaborator) = (%v, %v), expected [%d]", ids, err, private.Id)
+	}
+	if ids, err := GetSearchableRepoIds(owner, ""); err != nil || len(ids) != 2 {
+		t.Errorf("GetSearchableRepoIds(owner) = (%v, %v), expected 2 repositories", ids, err)
+	}
+}
+
+func TestGetRepoIdsByLanguage(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo1, repo2 := newTestRepo(t, u, "repo1"), newTestRepo(t, u, "repo2")
+
+	if _, err := GetRepoIdsByLanguage("Go"); err != ErrRepoIndexerDisabled {
+		t.Errorf("GetRepoIdsByLanguage without indexer error = %v, expected %v", err, ErrRepoIndexerDisabled)
+	}
+
+	defer prepareTestRepoIndexer(t)()
+	testCommitFiles(t, RepoPath(u.Name, repo1.Name), "master", "", map[string]string{"main.go": "package main\n"}, "Add main.go")
+	testCommitFiles(t, RepoPath(u.Name, repo2.Name), "master", "", map[string]string{"main.go": "package main\n", "main.py": "pass\n"}, "Add files")
+	RepoIndexerUpdate()
+
+	tests := []struct {
+		lang     string
+		expected []int64
+	}{
+		{"Go", []int64{repo1.Id, repo2.Id}},
+		{"Python", []int64{repo2.Id}},
+		{"Ruby", []int64{}},
+	}
+	for _, tt := range tests {
+		ids, err := GetRepoIdsByLanguage(tt.lang)
+		if err != nil {
+			t.Fatalf("GetRepoIdsByLanguage(%s): %v", tt.lang, err)
+		}
+		sort.Sort(int64Slice(ids))
+		if !reflect.DeepEqual(ids, tt.expected) {
+			t.Errorf("GetRepoIdsByLanguage(%s) = %v, expected %v", tt.lang, ids, tt.expected)
+		}
+	}
+}
+
+func TestFilterRepoIdsByOwner(t *testing.T) {
+	defer prepareTestEnv(t)()
+	owner, u := newTestUser(t, "owner"), newTestUser(t, "user1")
+	public, private := newTestRepo(t, owner, "public"), newTestRepo(t, owner, "private")
+	other := newTestRepo(t, u, "other")
+	private.IsPrivate = true
+	if err := UpdateRepository(private); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ids      []int64
+		expected []int64
+	}{
+		{nil, []int64{public.Id, private.Id}},
+		{[]int64{public.Id, other.Id}, []int64{public.Id}},
+		{[]int64{other.Id}, []int64{}},
+		{[]int64{}, []int64{}},
+	}
+	for _, tt := range tests {
+		ids, err := FilterRepoIdsByOwner(tt.ids, owner.Id)
+		if err != nil {
+			t.Fatalf("FilterRepoIdsByOwner(%v): %v", tt.ids, err)
+		}
+		sort.Sort(int64Slice(ids))
+		if !reflect.DeepEqual(ids, tt.expected) {
+			t.Errorf("FilterRepoIdsByOwner(%v) = %v, expected %v", tt.ids, ids, tt.expected)
+		}
+	}
+}
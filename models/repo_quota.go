@@ -0,0 +1,158 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/gogits/gogs/modules/setting"
+)
+
+var (
+	ErrRepoSizeExceeded  = errors.New("Repository size exceeds its limit")
+	ErrUserQuotaExceeded = errors.New("Total size of repositories exceeds quota of owner")
+)
+
+// SizeLimitBytes returns max size of repository in bytes, 0 means unlimited.
+func (repo *Repository) SizeLimitBytes() int64 {
+	switch {
+	case repo.SizeLimit < 0:
+		return 0
+	case repo.SizeLimit > 0:
+		return repo.SizeLimit << 20
+	}
+	return setting.RepoMaxSize << 20
+}
+
+// DiskQuotaBytes returns max total size of repositories of user in bytes, 0 means unlimited.
+func (u *User) DiskQuotaBytes() int64 {
+	switch {
+	case u.DiskQuota < 0:
+		return 0
+	case u.DiskQuota > 0:
+		return u.DiskQuota << 20
+	}
+	return setting.UserMaxSize << 20
+}
+
+// getRepoDirSize returns size of all files in repository directory,
+// cached archives are not counted.
+func getRepoDirSize(repoPath string) (int64, error) {
+	archivesPath := repoArchivesPath(repoPath)
+	var size int64
+	err := filepath.Walk(repoPath, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			// Files may be removed by git during walking.
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if fi.IsDir() {
+			if path == archivesPath {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		size += fi.Size()
+		return nil
+	})
+	return size, err
+}
+
+// UpdateRepoSize calculates and saves size of repository.
+func UpdateRepoSize(repo *Repository, repoPath string) (err error) {
+	if repo.Size, err = getRepoDirSize(repoPath); err != nil {
+		return err
+	}
+	_, err = orm.Id(repo.Id).Cols("size").Update(repo)
+	return err
+}
+
+// GetUserDiskUsage returns total size of repositories of user.
+func GetUserDiskUsage(uid int64) (int64, error) {
+	repos := make([]*Repository, 0, 10)
+	if err := orm.Where("owner_id=?", uid).Cols("id", "size").Find(&repos); err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, repo := range repos {
+		total += repo.Size
+	}
+	return total, nil
+}
+
+// QuotaUsage represents disk usage of repository and its owner, limits are 0 if unlimited.
+type QuotaUsage struct {
+	RepoSize  int64
+	RepoLimit int64
+	UserSize  int64
+	UserLimit int64
+}
+
+// RepoPercent returns percentage of repository size relative to its limit.
+func (q *QuotaUsage) RepoPercent() int64 {
+	if q.RepoLimit == 0 {
+		return 0
+	}
+	return q.RepoSize * 100 / q.RepoLimit
+}
+
+// UserPercent returns percentage of owner usage relative to its quota.
+func (q *QuotaUsage) UserPercent() int64 {
+	if q.UserLimit == 0 {
+		return 0
+	}
+	return q.UserSize * 100 / q.UserLimit
+}
+
+// GetQuotaUsage returns recorded disk usage of repository and its owner,
+// owner of repository must be loaded.
+func GetQuotaUsage(repo *Repository) (*QuotaUsage, error) {
+	userSize, err := GetUserDiskUsage(repo.OwnerId)
+	if err != nil {
+		return nil, err
+	}
+	return &QuotaUsage{
+		RepoSize:  repo.Size,
+		RepoLimit: repo.SizeLimitBytes(),
+		UserSize:  userSize,
+		UserLimit: repo.Owner.DiskQuotaBytes(),
+	}, nil
+}
+
+// CheckRepoQuota checks if current size of repository, which includes objects being pushed,
+// is within limit of repository and quota of its owner.
+func CheckRepoQuota(repo *Repository, repoPath string) (*QuotaUsage, error) {
+	if repo.Owner == nil {
+		if err := repo.GetOwner(); err != nil {
+			return nil, err
+		}
+	}
+	q, err := GetQuotaUsage(repo)
+	if err != nil {
+		return nil, err
+	}
+	if q.RepoLimit == 0 && q.UserLimit == 0 {
+		return q, nil
+	}
+
+	size, err := getRepoDirSize(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	q.UserSize += size - q.RepoSize
+	q.RepoSize = size
+
+	if q.RepoLimit > 0 && q.RepoSize > q.RepoLimit {
+		return q, ErrRepoSizeExceeded
+	} else if q.UserLimit > 0 && q.UserSize > q.UserLimit {
+		return q, ErrUserQuotaExceeded
+	}
+	return q, nil
+}
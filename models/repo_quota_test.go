@@ -0,0 +1,92 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gogits/gogs/modules/setting"
+)
+
+func TestSizeLimitBytes(t *testing.T) {
+	oldRepoMaxSize, oldUserMaxSize := setting.RepoMaxSize, setting.UserMaxSize
+	defer func() {
+		setting.RepoMaxSize, setting.UserMaxSize = oldRepoMaxSize, oldUserMaxSize
+	}()
+	setting.RepoMaxSize, setting.UserMaxSize = 10, 100
+
+	tests := []struct {
+		limit, repoExpected, userExpected int64
+	}{
+		{0, 10 << 20, 100 << 20},
+		{-1, 0, 0},
+		{5, 5 << 20, 5 << 20},
+	}
+	for _, tt := range tests {
+		if limit := (&Repository{SizeLimit: tt.limit}).SizeLimitBytes(); limit != tt.repoExpected {
+			t.Errorf("Repository{SizeLimit: %d}.SizeLimitBytes() = %d, expected %d", tt.limit, limit, tt.repoExpected)
+		}
+		if quota := (&User{DiskQuota: tt.limit}).DiskQuotaBytes(); quota != tt.userExpected {
+			t.Errorf("User{DiskQuota: %d}.DiskQuotaBytes() = %d, expected %d", tt.limit, quota, tt.userExpected)
+		}
+	}
+}
+
+func TestCheckRepoQuota(t *testing.T) {
+	defer prepareTestEnv(t)()
+	oldRepoMaxSize, oldUserMaxSize := setting.RepoMaxSize, setting.UserMaxSize
+	defer func() {
+		setting.RepoMaxSize, setting.UserMaxSize = oldRepoMaxSize, oldUserMaxSize
+	}()
+	setting.RepoMaxSize, setting.UserMaxSize = 0, 0
+
+	u := newTestUser(t, "user1")
+	repo1, repo2 := newTestRepo(t, u, "repo1"), newTestRepo(t, u, "repo2")
+	repoPath := RepoPath(u.Name, repo1.Name)
+	for _, repo := range []*Repository{repo1, repo2} {
+		if err := UpdateRepoSize(repo, RepoPath(u.Name, repo.Name)); err != nil {
+			t.Fatalf("UpdateRepoSize: %v", err)
+		}
+	}
+	if total, err := GetUserDiskUsage(u.Id); err != nil || total != repo1.Size+repo2.Size || repo1.Size == 0 {
+		t.Errorf("GetUserDiskUsage = (%d, %v), expected %d", total, err, repo1.Size+repo2.Size)
+	}
+
+	// Cached archives are not counted.
+	archivePath := RepoArchivePath(repoPath, "0000000000000000000000000000000000000000", "repo1", ARCHIVE_ZIP)
+	if err := os.MkdirAll(filepath.Dir(archivePath), os.ModePerm); err != nil {
+		t.Fatal(err)
+	} else if err = ioutil.WriteFile(archivePath, make([]byte, 2<<20), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Objects being pushed are counted.
+	if err := ioutil.WriteFile(filepath.Join(repoPath, "objects", "incoming"), make([]byte, 1<<20), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		repoLimit, userQuota int64
+		expected             error
+	}{
+		{-1, -1, nil},
+		{2, -1, nil},
+		{1, -1, ErrRepoSizeExceeded},
+		{2, 2, nil},
+		{-1, 1, ErrUserQuotaExceeded},
+	}
+	for _, tt := range tests {
+		repo1.SizeLimit, u.DiskQuota = tt.repoLimit, tt.userQuota
+		repo1.Owner = u
+		q, err := CheckRepoQuota(repo1, repoPath)
+		if err != tt.expected {
+			t.Errorf("CheckRepoQuota(%d, %d) error = %v, expected %v", tt.repoLimit, tt.userQuota, err, tt.expected)
+		} else if tt.repoLimit > 0 && q.RepoSize != repo1.Size+1<<20 {
+			t.Errorf("CheckRepoQuota(%d, %d) returns repository size %d, expected %d", tt.repoLimit, tt.userQuota, q.RepoSize, repo1.Size+1<<20)
+		}
+	}
+}
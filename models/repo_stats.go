@@ -0,0 +1,259 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+)
+
+var (
+	ErrRepoStatsNotReady = errors.New("Repository statistics are being computed")
+)
+
+const _WEEK_SECONDS = 7 * 24 * 3600
+
+// RepoStats represents cached statistics of default branch of a repository.
+// Statistics are computed by web server in background when they are first requested,
+// and recomputed after default branch has been changed.
+type RepoStats struct {
+	Id        int64
+	RepoId    int64     `xorm:"UNIQUE NOT NULL"`
+	CommitId  string    `xorm:"VARCHAR(40)"` // Commit that statistics are computed at.
+	Data      string    `xorm:"TEXT"`        // JSON of RepoStatsData.
+	IsPending bool      `xorm:"INDEX"`
+	Updated   time.Time `xorm:"UPDATED"`
+}
+
+// WeekStats represents activity in a week, Week is Unix time of start of the week.
+type WeekStats struct {
+	Week      int64 `json:"week"`
+	Commits   int   `json:"commits"`
+	Additions int   `json:"additions"`
+	Deletions int   `json:"deletions"`
+}
+
+// ContributorStats represents total activity of an author.
+type ContributorStats struct {
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Commits   int    `json:"commits"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
+
+// RepoStatsData represents statistics computed from history of default branch.
+type RepoStatsData struct {
+	Contributors    []*ContributorStats `json:"contributors"`     // Ordered by number of commits.
+	CommitFrequency []*WeekStats        `json:"commit_frequency"` // Last 52 weeks.
+	CodeFrequency   []*WeekStats        `json:"code_frequency"`   // All weeks since first commit.
+}
+
+type contributorList []*ContributorStats
+
+func (cl contributorList) Len() int           { return len(cl) }
+func (cl contributorList) Less(i, j int) bool { return cl[i].Commits > cl[j].Commits }
+func (cl contributorList) Swap(i, j int)      { cl[i], cl[j] = cl[j], cl[i] }
+
+// weekStart returns Unix time of start of the week that given time is in, weeks start on Sunday.
+func weekStart(unix int64) int64 {
+	t := time.Unix(unix, 0).UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -int(day.Weekday())).Unix()
+}
+
+// parseStatsLog parses output of "git log --numstat" with format "%x00%an%x00%ae%x00%at"
+// into statistics, now is used to decide range of commit frequency.
+func parseStatsLog(output string, now int64) *RepoStatsData {
+	contributors := make(map[string]*ContributorStats)
+	weeks := make(map[int64]*WeekStats)
+
+	var author *ContributorStats
+	var week *WeekStats
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) == 0 {
+			continue
+		}
+
+		// Header line of every commit.
+		if line[0] == 0 {
+			fields := strings.Split(line[1:], "\x00")
+			if len(fields) != 3 {
+				author, week = nil, nil
+				continue
+			}
+			email := strings.ToLower(fields[1])
+			if author = contributors[email]; author == nil {
+				author = &ContributorStats{Name: fields[0], Email: email}
+				contributors[email] = author
+			}
+			author.Commits++
+
+			unix, _ := base.StrTo(fields[2]).Int64()
+			start := weekStart(unix)
+			if week = weeks[start]; week == nil {
+				week = &WeekStats{Week: start}
+				weeks[start] = week
+			}
+			week.Commits++
+			continue
+		} else if author == nil {
+			continue
+		}
+
+		// Format: "<additions>\t<deletions>\t<path>", binary files have "-".
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		add, _ := base.StrTo(fields[0]).Int()
+		del, _ := base.StrTo(fields[1]).Int()
+		author.Additions += add
+		author.Deletions += del
+		week.Additions += add
+		week.Deletions += del
+	}
+
+	data := &RepoStatsData{
+		Contributors:    make([]*ContributorStats, 0, len(contributors)),
+		CommitFrequency: make([]*WeekStats, 0, 52),
+		CodeFrequency:   make([]*WeekStats, 0, len(weeks)),
+	}
+	for _, c := range contributors {
+		data.Contributors = append(data.Contributors, c)
+	}
+	sort.Sort(contributorList(data.Contributors))
+
+	// Fill weeks without any commit.
+	first, last := weekStart(now), weekStart(now)
+	for start := range weeks {
+		if start < first {
+			first = start
+		}
+	}
+	for start := first; start <= last; start += _WEEK_SECONDS {
+		w := weeks[start]
+		if w == nil {
+			w = &WeekStats{Week: start}
+		}
+		data.CodeFrequency = append(data.CodeFrequency, w)
+		if start > last-52*_WEEK_SECONDS {
+			data.CommitFrequency = append(data.CommitFrequency, w)
+		}
+	}
+	return data
+}
+
+// MarkRepoStatsPending marks statistics of repository to be recomputed if they have been requested.
+func MarkRepoStatsPending(repoId int64) error {
+	_, err := orm.Where("repo_id=?", repoId).Cols("is_pending").Update(&RepoStats{IsPending: true})
+	return err
+}
+
+// GetRepoStats returns statistics of repository, it returns ErrRepoStatsNotReady
+// if statistics have not been computed yet and schedules computing.
+func GetRepoStats(repoId int64) (*RepoStatsData, error) {
+	stats := &RepoStats{RepoId: repoId}
+	has, err := orm.Get(stats)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		if _, err = orm.Insert(&RepoStats{RepoId: repoId, IsPending: true}); err != nil {
+			return nil, err
+		}
+		return nil, ErrRepoStatsNotReady
+	} else if len(stats.Data) == 0 {
+		return nil, ErrRepoStatsNotReady
+	}
+
+	// Outdated statistics are still returned while being recomputed.
+	data := new(RepoStatsData)
+	if err = json.Unmarshal([]byte(stats.Data), data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// updateRepoStats computes statistics of default branch of repository.
+func updateRepoStats(stats *RepoStats) error {
+	repo, err := GetRepositoryById(stats.RepoId)
+	if err != nil {
+		if err == ErrRepoNotExist {
+			_, err = orm.Delete(&RepoStats{RepoId: stats.RepoId})
+		}
+		return err
+	} else if err = repo.GetOwner(); err != nil {
+		return err
+	}
+	repoPath := RepoPath(repo.Owner.Name, repo.Name)
+
+	data := &RepoStatsData{}
+	commitId := ""
+	if !repo.IsBare {
+		if commitId, err = ResolveCommitId(repoPath, repo.DefaultBranch); err != nil {
+			return err
+		}
+		stdout, err := execGitCmd(repoPath, nil, nil, "log", "--no-merges", "--numstat",
+			"--format=%x00%an%x00%ae%x00%at", commitId)
+		if err != nil {
+			return err
+		}
+		data = parseStatsLog(stdout, time.Now().Unix())
+	}
+
+	content, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	stats.CommitId = commitId
+	stats.Data = string(content)
+	stats.IsPending = false
+	_, err = orm.Id(stats.Id).AllCols().Update(stats)
+	return err
+}
+
+var (
+	repoStatsLock    sync.Mutex
+	repoStatsRunning bool
+)
+
+// RepoStatsUpdate computes pending statistics of repositories.
+func RepoStatsUpdate() {
+	// Skip if previous round is still running.
+	repoStatsLock.Lock()
+	if repoStatsRunning {
+		repoStatsLock.Unlock()
+		return
+	}
+	repoStatsRunning = true
+	repoStatsLock.Unlock()
+	defer func() {
+		repoStatsLock.Lock()
+		repoStatsRunning = false
+		repoStatsLock.Unlock()
+	}()
+
+	stats := make([]*RepoStats, 0, 10)
+	if err := orm.Where("is_pending=?", true).Find(&stats); err != nil {
+		log.Error("models.RepoStatsUpdate: %v", err)
+		return
+	}
+
+	for _, s := range stats {
+		if err := updateRepoStats(s); err != nil {
+			log.Error("models.RepoStatsUpdate(%d): %v", s.RepoId, err)
+		}
+	}
+}
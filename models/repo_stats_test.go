@@ -0,0 +1,97 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWeekStart(t *testing.T) {
+	sunday := time.Date(2014, 10, 12, 0, 0, 0, 0, time.UTC).Unix()
+	for _, d := range []time.Time{
+		time.Date(2014, 10, 12, 0, 0, 0, 0, time.UTC),
+		time.Date(2014, 10, 15, 12, 30, 0, 0, time.UTC),
+		time.Date(2014, 10, 18, 23, 59, 59, 0, time.UTC),
+	} {
+		if start := weekStart(d.Unix()); start != sunday {
+			t.Errorf("weekStart(%v) = %v, expected %v", d, time.Unix(start, 0).UTC(), time.Unix(sunday, 0).UTC())
+		}
+	}
+}
+
+func TestParseStatsLog(t *testing.T) {
+	now := time.Date(2014, 10, 15, 12, 0, 0, 0, time.UTC).Unix()
+	twoWeeksAgo := now - 2*_WEEK_SECONDS
+	output := fmt.Sprintf("\x00Alice\x00alice@example.com\x00%d\n\n3\t1\tmain.go\n-\t-\tlogo.png\n"+
+		"\x00Bob\x00bob@example.com\x00%d\n\n10\t0\tREADME.md\n"+
+		"\x00Alice\x00Alice@Example.com\x00%d\n\n1\t2\tmain.go\n", now, twoWeeksAgo, twoWeeksAgo)
+	data := parseStatsLog(output, now)
+
+	if len(data.Contributors) != 2 {
+		t.Fatalf("parseStatsLog returns %d contributors, expected 2", len(data.Contributors))
+	}
+	if c := data.Contributors[0]; c.Name != "Alice" || c.Commits != 2 || c.Additions != 4 || c.Deletions != 3 {
+		t.Errorf("first contributor is %+v, expected Alice with 2 commits, 4 additions and 3 deletions", c)
+	}
+	if c := data.Contributors[1]; c.Name != "Bob" || c.Commits != 1 || c.Additions != 10 {
+		t.Errorf("second contributor is %+v, expected Bob with 1 commit and 10 additions", c)
+	}
+
+	// Weeks without commits are filled in.
+	if len(data.CodeFrequency) != 3 {
+		t.Fatalf("parseStatsLog returns %d weeks of code frequency, expected 3", len(data.CodeFrequency))
+	}
+	expected := []WeekStats{
+		{weekStart(twoWeeksAgo), 2, 11, 2},
+		{weekStart(twoWeeksAgo) + _WEEK_SECONDS, 0, 0, 0},
+		{weekStart(now), 1, 3, 1},
+	}
+	for i, w := range data.CodeFrequency {
+		if *w != expected[i] {
+			t.Errorf("week #%d is %+v, expected %+v", i, *w, expected[i])
+		}
+	}
+	if len(data.CommitFrequency) != 3 {
+		t.Errorf("parseStatsLog returns %d weeks of commit frequency, expected 3", len(data.CommitFrequency))
+	}
+
+	// Commit frequency only covers last 52 weeks.
+	output = fmt.Sprintf("\x00Alice\x00alice@example.com\x00%d\n", now-100*_WEEK_SECONDS)
+	if data = parseStatsLog(output, now); len(data.CodeFrequency) != 101 || len(data.CommitFrequency) != 52 {
+		t.Errorf("parseStatsLog returns %d weeks of code frequency and %d weeks of commit frequency, expected 101 and 52",
+			len(data.CodeFrequency), len(data.CommitFrequency))
+	}
+}
+
+func TestRepoStatsUpdate(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo := newTestRepo(t, u, "repo1")
+
+	if _, err := GetRepoStats(repo.Id); err != ErrRepoStatsNotReady {
+		t.Fatalf("GetRepoStats(first request) error = %v, expected %v", err, ErrRepoStatsNotReady)
+	}
+	RepoStatsUpdate()
+	data, err := GetRepoStats(repo.Id)
+	if err != nil {
+		t.Fatalf("GetRepoStats: %v", err)
+	} else if len(data.Contributors) != 1 || data.Contributors[0].Commits != 1 {
+		t.Errorf("GetRepoStats returns contributors %v, expected 1 with 1 commit", data.Contributors)
+	}
+
+	// Outdated statistics are returned until recomputed.
+	testCommitFiles(t, RepoPath(u.Name, repo.Name), "master", "", map[string]string{"a.txt": "a\n"}, "Add a")
+	if err = MarkRepoStatsPending(repo.Id); err != nil {
+		t.Fatalf("MarkRepoStatsPending: %v", err)
+	} else if data, err = GetRepoStats(repo.Id); err != nil || data.Contributors[0].Commits != 1 {
+		t.Errorf("GetRepoStats(pending) = (%v, %v), expected outdated statistics", data, err)
+	}
+	RepoStatsUpdate()
+	if data, err = GetRepoStats(repo.Id); err != nil || data.Contributors[0].Commits != 2 {
+		t.Errorf("GetRepoStats(recomputed) = (%v, %v), expected 2 commits", data, err)
+	}
+}
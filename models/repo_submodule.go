@@ -0,0 +1,157 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bufio"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gogits/gogs/modules/setting"
+)
+
+// Submodule represents a submodule entry in repository tree.
+type Submodule struct {
+	Name     string
+	Path     string
+	Url      string // As written in .gitmodules.
+	CommitId string
+}
+
+// parseGitmodules parses content of .gitmodules into submodules keyed by path.
+func parseGitmodules(content string) map[string]*Submodule {
+	subs := make(map[string]*Submodule)
+	var cur *Submodule
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			cur = nil
+			line = strings.TrimSpace(strings.Trim(line, "[]"))
+			if strings.HasPrefix(line, "submodule") {
+				cur = &Submodule{Name: strings.Trim(strings.TrimSpace(line[len("submodule"):]), `"`)}
+			}
+			continue
+		} else if cur == nil {
+			continue
+		}
+
+		i := strings.Index(line, "=")
+		if i == -1 {
+			continue
+		}
+		key, value := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		switch key {
+		case "path":
+			cur.Path = strings.Trim(value, "/")
+			subs[cur.Path] = cur
+		case "url":
+			cur.Url = value
+		}
+	}
+	return subs
+}
+
+// GetSubmodules returns submodules in given directory of commit keyed by their names in directory,
+// commit IDs of submodules are loaded from tree.
+func GetSubmodules(repoPath, commitId, treePath string) (map[string]*Submodule, error) {
+	content, err := execGitCmd(repoPath, nil, nil, "cat-file", "blob", commitId+":.gitmodules")
+	if err != nil {
+		// Repository has no submodule.
+		return map[string]*Submodule{}, nil
+	}
+	subs := parseGitmodules(content)
+
+	treePath = strings.Trim(treePath, "/")
+	args := []string{"ls-tree", commitId}
+	if len(treePath) > 0 {
+		args = append(args, "--", treePath+"/")
+	}
+	stdout, err := execGitCmd(repoPath, nil, nil, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]*Submodule)
+	for _, line := range strings.Split(stdout, "\n") {
+		// Format: "<mode> <type> <object>\t<path>".
+		i := strings.Index(line, "\t")
+		if i == -1 {
+			continue
+		}
+		fields := strings.Fields(line[:i])
+		if len(fields) != 3 || fields[1] != "commit" {
+			continue
+		}
+		p := line[i+1:]
+		sub := subs[p]
+		if sub == nil {
+			sub = &Submodule{Name: p, Path: p}
+		}
+		sub.CommitId = fields[2]
+		entries[path.Base(p)] = sub
+	}
+	return entries, nil
+}
+
+// RepoLink returns web link of submodule repository,
+// relative URLs are resolved against given link of current repository.
+// It returns empty string if URL cannot be recognized.
+func (s *Submodule) RepoLink(repoLink string) string {
+	rawUrl := strings.TrimSuffix(s.Url, "/")
+	rawUrl = strings.TrimSuffix(rawUrl, ".git")
+	switch {
+	case len(rawUrl) == 0:
+		return ""
+	case strings.HasPrefix(rawUrl, "./") || strings.HasPrefix(rawUrl, "../"):
+		return path.Join(repoLink, rawUrl)
+	case strings.Contains(rawUrl, "://"):
+		u, err := url.Parse(rawUrl)
+		if err != nil {
+			return ""
+		}
+		switch u.Scheme {
+		case "http", "https":
+		case "git", "ssh":
+			u.Scheme = "https"
+		default:
+			return ""
+		}
+		u.User = nil
+		if u.Host == setting.Domain || strings.HasPrefix(setting.AppUrl, u.Scheme+"://"+u.Host+"/") {
+			return strings.TrimSuffix(setting.AppUrl, "/") + u.Path
+		}
+		return u.String()
+	}
+
+	// SCP-like syntax: "user@host:owner/repo".
+	i := strings.Index(rawUrl, ":")
+	if i == -1 {
+		return ""
+	}
+	host := rawUrl[:i]
+	if j := strings.Index(host, "@"); j > -1 {
+		host = host[j+1:]
+	}
+	repoPath := strings.TrimPrefix(rawUrl[i+1:], "/")
+	if host == setting.Domain {
+		return strings.TrimSuffix(setting.AppUrl, "/") + "/" + repoPath
+	}
+	return "https://" + host + "/" + repoPath
+}
+
+// CommitLink returns web link of referenced commit in submodule repository.
+func (s *Submodule) CommitLink(repoLink string) string {
+	link := s.RepoLink(repoLink)
+	if len(link) == 0 {
+		return ""
+	}
+	return link + "/commit/" + s.CommitId
+}
@@ -0,0 +1,134 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Unknwon/com"
+
+	"github.com/gogits/gogs/modules/base"
+)
+
+// GetRepoTemplates returns template repositories that user can create repositories from,
+// which are templates owned by user and public templates.
+func GetRepoTemplates(u *User) ([]*Repository, error) {
+	repos := make([]*Repository, 0, 5)
+	if err := orm.Where("is_template=? AND (owner_id=? OR is_private=?)", true, u.Id, false).
+		Asc("lower_name").Find(&repos); err != nil {
+		return nil, err
+	}
+	for _, repo := range repos {
+		if err := repo.GetOwner(); err != nil {
+			return nil, err
+		}
+	}
+	return repos, nil
+}
+
+// GetRepoTemplateById returns template repository by given ID if user can read it.
+func GetRepoTemplateById(u *User, id int64) (*Repository, error) {
+	repo, err := GetRepositoryById(id)
+	if err != nil {
+		return nil, err
+	} else if !repo.IsTemplate {
+		return nil, ErrRepoNotExist
+	} else if err = repo.GetOwner(); err != nil {
+		return nil, err
+	}
+
+	if repo.IsPrivate && repo.OwnerId != u.Id {
+		has, err := HasAccess(u.Name, repo.Owner.Name+"/"+repo.Name, AU_READABLE)
+		if err != nil {
+			return nil, err
+		} else if !has {
+			return nil, ErrRepoNotExist
+		}
+	}
+	return repo, nil
+}
+
+// generateRepoContent commits files of default branch of template to new repository
+// as its first commit, history of template is not copied.
+func generateRepoContent(u *User, tpl, repo *Repository) error {
+	repoPath := RepoPath(u.Name, repo.Name)
+	tmpDir := filepath.Join(os.TempDir(), "gogs-template-"+base.ToStr(time.Now().Nanosecond()))
+	if err := os.MkdirAll(tmpDir, os.ModePerm); err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if _, stderr, err := com.ExecCmd("git", "clone", repoPath, tmpDir); err != nil {
+		return errors.New("git clone: " + stderr)
+	}
+	if _, stderr, err := com.ExecCmdDir(tmpDir, "git", "fetch", RepoPath(tpl.Owner.Name, tpl.Name),
+		"refs/heads/"+tpl.DefaultBranch); err != nil {
+		return errors.New("git fetch: " + stderr)
+	}
+	if _, stderr, err := com.ExecCmdDir(tmpDir, "git", "checkout", "FETCH_HEAD", "--", "."); err != nil {
+		return errors.New("git checkout: " + stderr)
+	}
+
+	SetRepoEnvs(u.Id, u.Name, repo.Name, u.Name)
+	return initRepoCommit(tmpDir, u.NewGitSig())
+}
+
+// CreateRepositoryFromTemplate creates a repository for user with content of template,
+// labels and webhooks of template are copied if asked.
+func CreateRepositoryFromTemplate(u *User, tpl *Repository, name, desc string, private, copyLabels, copyHooks bool) (*Repository, error) {
+	repo, err := CreateRepository(u, name, desc, "", "", private, false, false)
+	if err != nil {
+		return repo, err
+	}
+
+	if !tpl.IsBare && len(tpl.DefaultBranch) > 0 {
+		if err = generateRepoContent(u, tpl, repo); err != nil {
+			return repo, err
+		}
+		repo.IsBare = false
+		repo.DefaultBranch = "master"
+		if err = UpdateRepository(repo); err != nil {
+			return repo, err
+		} else if err = UpdateRepoSize(repo, RepoPath(u.Name, repo.Name)); err != nil {
+			return repo, err
+		}
+	}
+
+	if copyLabels {
+		labels, err := GetLabels(tpl.Id)
+		if err != nil {
+			return repo, err
+		}
+		for _, l := range labels {
+			if err = NewLabel(&Label{RepoId: repo.Id, Name: l.Name, Color: l.Color, Description: l.Description}); err != nil {
+				return repo, err
+			}
+		}
+	}
+
+	if copyHooks {
+		hooks, err := GetWebhooksByRepoId(tpl.Id)
+		if err != nil {
+			return repo, err
+		}
+		for _, w := range hooks {
+			if err = CreateWebhook(&Webhook{
+				RepoId:      repo.Id,
+				Url:         w.Url,
+				ContentType: w.ContentType,
+				Secret:      w.Secret,
+				Events:      w.Events,
+				IsSsl:       w.IsSsl,
+				IsActive:    w.IsActive,
+			}); err != nil {
+				return repo, err
+			}
+		}
+	}
+	return repo, nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestGetRepoTemplates(t *testing.T) {
+	defer prepareTestEnv(t)()
+	owner, u := newTestUser(t, "owner"), newTestUser(t, "user1")
+	public, private := newTestRepo(t, owner, "public"), newTestRepo(t, owner, "private")
+	normal := newTestRepo(t, owner, "normal")
+	public.IsTemplate = true
+	private.IsTemplate, private.IsPrivate = true, true
+	for _, repo := range []*Repository{public, private} {
+		if err := UpdateRepository(repo); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		u        *User
+		expected int
+	}{
+		{owner, 2},
+		{u, 1},
+	} {
+		if repos, err := GetRepoTemplates(tt.u); err != nil || len(repos) != tt.expected {
+			t.Errorf("GetRepoTemplates(%s) = (%d, %v), expected %d templates", tt.u.Name, len(repos), err, tt.expected)
+		}
+	}
+
+	tests := []struct {
+		u        *User
+		id       int64
+		expected error
+	}{
+		{u, public.Id, nil},
+		{u, private.Id, ErrRepoNotExist},
+		{u, normal.Id, ErrRepoNotExist},
+		{owner, private.Id, nil},
+	}
+	for _, tt := range tests {
+		if _, err := GetRepoTemplateById(tt.u, tt.id); err != tt.expected {
+			t.Errorf("GetRepoTemplateById(%s, %d) error = %v, expected %v", tt.u.Name, tt.id, err, tt.expected)
+		}
+	}
+
+	// Collaborator can use private template.
+	if err := AddAccess(&Access{UserName: u.LowerName, RepoName: "owner/private", Mode: AU_READABLE}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetRepoTemplateById(u, private.Id); err != nil {
+		t.Errorf("GetRepoTemplateById(collaborator): %v", err)
+	}
+}
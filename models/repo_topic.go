@@ -0,0 +1,204 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrTopicNameIllegal = errors.New("Topic name contains illegal characters or is too long")
+	ErrTooManyTopics    = errors.New("Repository has too many topics")
+)
+
+const (
+	_MAX_TOPIC_NAME_LENGTH = 35
+	_MAX_REPO_TOPICS       = 20
+)
+
+var topicPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9\-\.\+#]*$`)
+
+// Topic represents a free-form tag of repository.
+type Topic struct {
+	Id     int64
+	RepoId int64  `xorm:"UNIQUE(s) NOT NULL"`
+	Name   string `xorm:"UNIQUE(s) INDEX NOT NULL"`
+}
+
+// ParseTopics returns unique lower-cased topic names that are separated by commas or spaces.
+func ParseTopics(s string) ([]string, error) {
+	names := make([]string, 0, 5)
+	seen := make(map[string]bool)
+	for _, name := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	}) {
+		if seen[name] {
+			continue
+		} else if len(name) > _MAX_TOPIC_NAME_LENGTH || !topicPattern.MatchString(name) {
+			return nil, ErrTopicNameIllegal
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	if len(names) > _MAX_REPO_TOPICS {
+		return nil, ErrTooManyTopics
+	}
+	return names, nil
+}
+
+// GetTopics loads topic names of repository.
+func (repo *Repository) GetTopics() error {
+	topics := make([]*Topic, 0, 5)
+	if err := orm.Where("repo_id=?", repo.Id).Asc("name").Find(&topics); err != nil {
+		return err
+	}
+	repo.Topics = make([]string, len(topics))
+	for i := range topics {
+		repo.Topics[i] = topics[i].Name
+	}
+	return nil
+}
+
+// HasTopic returns true if repository has given topic, topics must be loaded.
+func (repo *Repository) HasTopic(name string) bool {
+	for _, t := range repo.Topics {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveRepoTopics replaces all topics of repository with given names.
+func SaveRepoTopics(repo *Repository, names []string) (err error) {
+	sess := orm.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err = sess.Delete(&Topic{RepoId: repo.Id}); err != nil {
+		sess.Rollback()
+		return err
+	}
+	for _, name := range names {
+		if _, err = sess.Insert(&Topic{RepoId: repo.Id, Name: name}); err != nil {
+			sess.Rollback()
+			return err
+		}
+	}
+	if err = sess.Commit(); err != nil {
+		return err
+	}
+	repo.Topics = names
+	return nil
+}
+
+// GetPopularTopics returns names of topics that are used by most public repositories.
+func GetPopularTopics(limit int) ([]string, error) {
+	results, err := orm.Query("SELECT topic.name, COUNT(*) AS num FROM topic "+
+		"INNER JOIN repository ON repository.id = topic.repo_id WHERE repository.is_private = ? "+
+		"GROUP BY topic.name ORDER BY num DESC, topic.name ASC LIMIT ?", false, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(results))
+	for i := range results {
+		names[i] = string(results[i]["name"])
+	}
+	return names, nil
+}
+
+// SearchRepoOptions represents conditions of searching repositories.
+type SearchRepoOptions struct {
+	Keyword  string // Matches name or description.
+	Topic    string
+	OwnerId  int64   // Only repositories of the owner when it is positive.
+	RepoIds  []int64 // Only these repositories when it is not nil.
+	Viewer   *User   // Private repositories are included when viewer can read them, nil means public ones only.
+	Page     int     // Starts from 1.
+	PageSize int
+}
+
+// idsCond returns SQL condition that column is one of given IDs, and its arguments.
+func idsCond(column string, ids []int64) (string, []interface{}) {
+	if len(ids) == 0 {
+		return "1=0", nil
+	}
+	args := make([]interface{}, len(ids))
+	for i := range ids {
+		args[i] = ids[i]
+	}
+	return column + " IN (?" + strings.Repeat(",?", len(ids)-1) + ")", args
+}
+
+// SearchRepositories returns repositories that match given conditions
+// ordered by last update, and total number of them.
+func SearchRepositories(opts *SearchRepoOptions) ([]*Repository, int64, error) {
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+
+	var cond string
+	var args []interface{}
+	switch {
+	case opts.Viewer == nil:
+		cond = "is_private=?"
+		args = []interface{}{false}
+	case opts.Viewer.IsAdmin:
+		cond = "1=1"
+	default:
+		collaborative, err := GetCollaborativeRepos(opts.Viewer.Name)
+		if err != nil {
+			return nil, 0, err
+		}
+		ids := make([]int64, len(collaborative))
+		for i := range collaborative {
+			ids[i] = collaborative[i].Id
+		}
+		idCond, idArgs := idsCond("id", ids)
+		cond = "(is_private=? OR owner_id=? OR " + idCond + ")"
+		args = append([]interface{}{false, opts.Viewer.Id}, idArgs...)
+	}
+	if opts.OwnerId > 0 {
+		cond += " AND owner_id=?"
+		args = append(args, opts.OwnerId)
+	}
+	if opts.RepoIds != nil {
+		idCond, idArgs := idsCond("id", opts.RepoIds)
+		cond += " AND " + idCond
+		args = append(args, idArgs...)
+	}
+	if len(opts.Keyword) > 0 {
+		cond += " AND (lower_name LIKE ? OR description LIKE ?)"
+		args = append(args, "%"+strings.ToLower(opts.Keyword)+"%", "%"+opts.Keyword+"%")
+	}
+	if len(opts.Topic) > 0 {
+		cond += " AND id IN (SELECT repo_id FROM topic WHERE name=?)"
+		args = append(args, strings.ToLower(opts.Topic))
+	}
+
+	total, err := orm.Where(cond, args...).Count(new(Repository))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	repos := make([]*Repository, 0, opts.PageSize)
+	if err = orm.Where(cond, args...).Desc("updated").
+		Limit(opts.PageSize, (opts.Page-1)*opts.PageSize).Find(&repos); err != nil {
+		return nil, 0, err
+	}
+	for _, repo := range repos {
+		if err = repo.GetOwner(); err != nil {
+			return nil, 0, err
+		} else if err = repo.GetTopics(); err != nil {
+			return nil, 0, err
+		}
+	}
+	return repos, total, nil
+}
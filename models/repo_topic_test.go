@@ -0,0 +1,136 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestParseTopics(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected string
+		err      error
+	}{
+		{"", "", nil},
+		{"Go, git\tC++  go", "go|git|c++", nil},
+		{"c# .net", "", ErrTopicNameIllegal},
+		{"-go", "", ErrTopicNameIllegal},
+		{"中文", "", ErrTopicNameIllegal},
+		{strings.Repeat("a", 36), "", ErrTopicNameIllegal},
+		{strings.Repeat("a ", 30), "a", nil},
+		{"a b c d e f g h i j k l m n o p q r s t u", "", ErrTooManyTopics},
+	}
+	for _, tt := range tests {
+		names, err := ParseTopics(tt.s)
+		if err != tt.err || strings.Join(names, "|") != tt.expected {
+			t.Errorf("ParseTopics(%q) = (%q, %v), expected (%q, %v)", tt.s, names, err, tt.expected, tt.err)
+		}
+	}
+}
+
+func TestSearchRepositoriesByTopic(t *testing.T) {
+	defer prepareTestEnv(t)()
+	owner, u := newTestUser(t, "owner"), newTestUser(t, "user1")
+	repo1, repo2, private := newTestRepo(t, owner, "repo1"), newTestRepo(t, owner, "repo2"), newTestRepo(t, owner, "private")
+	private.IsPrivate = true
+	if err := UpdateRepository(private); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, repo := range []*Repository{repo1, repo2, private} {
+		if err := SaveRepoTopics(repo, []string{"go", "git"}); err != nil {
+			t.Fatalf("SaveRepoTopics: %v", err)
+		}
+	}
+	// Topics are replaced.
+	if err := SaveRepoTopics(repo2, []string{"go"}); err != nil {
+		t.Fatalf("SaveRepoTopics: %v", err)
+	} else if err = repo2.GetTopics(); err != nil || !repo2.HasTopic("go") || repo2.HasTopic("git") {
+		t.Errorf("repository has topics %v (%v), expected [go]", repo2.Topics, err)
+	}
+
+	if names, err := GetPopularTopics(10); err != nil || strings.Join(names, "|") != "go|git" {
+		t.Errorf("GetPopularTopics = (%q, %v), expected go and git", names, err)
+	}
+
+	search := func(viewer *User, topic string) []int64 {
+		repos, total, err := SearchRepositories(&SearchRepoOptions{Topic: topic, Viewer: viewer, PageSize: 10})
+		if err != nil {
+			t.Fatalf("SearchRepositories: %v", err)
+		} else if int(total) != len(repos) {
+			t.Errorf("SearchRepositories returns %d repositories of total %d", len(repos), total)
+		}
+		ids := make([]int64, len(repos))
+		for i := range repos {
+			ids[i] = repos[i].Id
+		}
+		return ids
+	}
+	if ids := search(nil, "GIT"); len(ids) != 1 || ids[0] != repo1.Id {
+		t.Errorf("SearchRepositories(anonymous, git) returns %v, expected [%d]", ids, repo1.Id)
+	}
+	if ids := search(u, "git"); len(ids) != 1 {
+		t.Errorf("SearchRepositories(user, git) returns %v, expected 1 repository", ids)
+	}
+	if ids := search(owner, "git"); len(ids) != 2 {
+		t.Errorf("SearchRepositories(owner, git) returns %v, expected 2 repositories", ids)
+	}
+	if ids := search(u, "none"); len(ids) != 0 {
+		t.Errorf("SearchRepositories(none) returns %v, expected nothing", ids)
+	}
+}
+
+func TestSearchRepositories(t *testing.T) {
+	defer prepareTestEnv(t)()
+	owner, u, admin := newTestUser(t, "owner"), newTestUser(t, "user1"), newTestUser(t, "admin")
+	admin.IsAdmin = true
+	if err := UpdateUser(admin); err != nil {
+		t.Fatal(err)
+	}
+	public, private, secret := newTestRepo(t, owner, "gopher"), newTestRepo(t, owner, "private"), newTestRepo(t, owner, "secret")
+	own := newTestRepo(t, u, "mine")
+	for _, repo := range []*Repository{private, secret} {
+		repo.IsPrivate = true
+		if err := UpdateRepository(repo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := AddAccess(&Access{UserName: u.LowerName, RepoName: "owner/private", Mode: AU_READABLE}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     *SearchRepoOptions
+		expected []int64
+	}{
+		{"anonymous", &SearchRepoOptions{}, []int64{public.Id, own.Id}},
+		{"collaborator", &SearchRepoOptions{Viewer: u}, []int64{public.Id, private.Id, own.Id}},
+		{"admin", &SearchRepoOptions{Viewer: admin}, []int64{public.Id, private.Id, secret.Id, own.Id}},
+		{"owner", &SearchRepoOptions{Viewer: u, OwnerId: owner.Id}, []int64{public.Id, private.Id}},
+		{"IDs", &SearchRepoOptions{Viewer: admin, RepoIds: []int64{secret.Id, own.Id}}, []int64{secret.Id, own.Id}},
+		{"no IDs", &SearchRepoOptions{Viewer: admin, RepoIds: []int64{}}, []int64{}},
+		{"keyword", &SearchRepoOptions{Viewer: admin, Keyword: "GOPHER"}, []int64{public.Id}},
+	}
+	for _, tt := range tests {
+		tt.opts.PageSize = 10
+		repos, total, err := SearchRepositories(tt.opts)
+		if err != nil {
+			t.Fatalf("SearchRepositories(%s): %v", tt.name, err)
+		}
+		ids := make([]int64, len(repos))
+		for i := range repos {
+			ids[i] = repos[i].Id
+		}
+		sort.Sort(int64Slice(ids))
+		if !reflect.DeepEqual(ids, tt.expected) || int(total) != len(tt.expected) {
+			t.Errorf("SearchRepositories(%s) = (%v, %d), expected %v", tt.name, ids, total, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrRepoTransferNotExist = errors.New("Repository transfer does not exist")
+	ErrRepoTransferToOwner  = errors.New("Repository cannot be transferred to its owner")
+)
+
+// RepoTransfer represents a transfer of repository that waits for confirmation of recipient.
+type RepoTransfer struct {
+	Id          int64
+	RepoId      int64       `xorm:"UNIQUE NOT NULL"`
+	Repo        *Repository `xorm:"-"`
+	DoerId      int64
+	Doer        *User     `xorm:"-"`
+	RecipientId int64     `xorm:"INDEX NOT NULL"`
+	Recipient   *User     `xorm:"-"`
+	Created     time.Time `xorm:"CREATED"`
+}
+
+// GetAttributes loads repository, doer and recipient of transfer.
+func (t *RepoTransfer) GetAttributes() (err error) {
+	if t.Repo, err = GetRepositoryById(t.RepoId); err != nil {
+		return err
+	} else if err = t.Repo.GetOwner(); err != nil {
+		return err
+	} else if t.Doer, err = GetUserById(t.DoerId); err != nil {
+		return err
+	}
+	t.Recipient, err = GetUserById(t.RecipientId)
+	return err
+}
+
+// RepoRedirect represents a previous location of a transferred repository.
+type RepoRedirect struct {
+	Id        int64
+	OwnerId   int64  `xorm:"UNIQUE(s)"`
+	LowerName string `xorm:"UNIQUE(s)"`
+	RepoId    int64  `xorm:"INDEX"`
+}
+
+// StartRepoTransfer creates a transfer of repository to recipient,
+// it replaces the previous transfer that has not been confirmed.
+func StartRepoTransfer(doer, recipient *User, repo *Repository) error {
+	if recipient.Id == repo.OwnerId {
+		return ErrRepoTransferToOwner
+	}
+	isExist, err := IsRepositoryExist(recipient, repo.Name)
+	if err != nil {
+		return err
+	} else if isExist {
+		return ErrRepoAlreadyExist
+	}
+
+	sess := orm.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err = sess.Delete(&RepoTransfer{RepoId: repo.Id}); err != nil {
+		sess.Rollback()
+		return err
+	}
+	t := &RepoTransfer{RepoId: repo.Id, DoerId: doer.Id, RecipientId: recipient.Id}
+	if _, err = sess.Insert(t); err != nil {
+		sess.Rollback()
+		return err
+	}
+	return sess.Commit()
+}
+
+// GetRepoTransfer returns pending transfer of given repository.
+func GetRepoTransfer(repoId int64) (*RepoTransfer, error) {
+	t := &RepoTransfer{RepoId: repoId}
+	has, err := orm.Get(t)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrRepoTransferNotExist
+	}
+	return t, t.GetAttributes()
+}
+
+// GetRepoTransferById returns pending transfer by given ID that is sent to recipient.
+func GetRepoTransferById(recipientId, id int64) (*RepoTransfer, error) {
+	t := new(RepoTransfer)
+	has, err := orm.Where("id=? AND recipient_id=?", id, recipientId).Get(t)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrRepoTransferNotExist
+	}
+	return t, t.GetAttributes()
+}
+
+// GetIncomingRepoTransfers returns all pending transfers that are sent to recipient.
+func GetIncomingRepoTransfers(recipientId int64) ([]*RepoTransfer, error) {
+	ts := make([]*RepoTransfer, 0, 2)
+	if err := orm.Where("recipient_id=?", recipientId).Asc("id").Find(&ts); err != nil {
+		return nil, err
+	}
+	for _, t := range ts {
+		if err := t.GetAttributes(); err != nil {
+			return nil, err
+		}
+	}
+	return ts, nil
+}
+
+// CountIncomingRepoTransfers returns number of pending transfers that are sent to recipient.
+func CountIncomingRepoTransfers(recipientId int64) (int64, error) {
+	return orm.Where("recipient_id=?", recipientId).Count(new(RepoTransfer))
+}
+
+// CancelRepoTransfer deletes pending transfer of given repository.
+func CancelRepoTransfer(repoId int64) error {
+	_, err := orm.Delete(&RepoTransfer{RepoId: repoId})
+	return err
+}
+
+// AcceptRepoTransfer transfers repository to recipient of given transfer.
+func AcceptRepoTransfer(t *RepoTransfer) error {
+	return TransferOwnership(t.Repo.Owner, t.Recipient.Name, t.Repo)
+}
+
+// GetRedirectedRepository returns repository that was located at given owner and name
+// before it was transferred.
+func GetRedirectedRepository(ownerId int64, repoName string) (*Repository, error) {
+	r := &RepoRedirect{OwnerId: ownerId, LowerName: strings.ToLower(repoName)}
+	has, err := orm.Get(r)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrRepoNotExist
+	}
+
+	repo, err := GetRepositoryById(r.RepoId)
+	if err != nil {
+		return nil, err
+	}
+	return repo, repo.GetOwner()
+}
@@ -0,0 +1,69 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/Unknwon/com"
+)
+
+func TestRepoTransfer(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2, u3 := newTestUser(t, "user1"), newTestUser(t, "user2"), newTestUser(t, "user3")
+	repo := newTestRepo(t, u1, "repo1")
+
+	if err := StartRepoTransfer(u1, u1, repo); err != ErrRepoTransferToOwner {
+		t.Errorf("StartRepoTransfer(owner) error = %v, expected %v", err, ErrRepoTransferToOwner)
+	}
+	newTestRepo(t, u3, "repo1")
+	if err := StartRepoTransfer(u1, u3, repo); err != ErrRepoAlreadyExist {
+		t.Errorf("StartRepoTransfer(existing name) error = %v, expected %v", err, ErrRepoAlreadyExist)
+	}
+	if err := StartRepoTransfer(u1, u2, repo); err != nil {
+		t.Fatalf("StartRepoTransfer: %v", err)
+	}
+
+	tr, err := GetRepoTransfer(repo.Id)
+	if err != nil {
+		t.Fatalf("GetRepoTransfer: %v", err)
+	}
+	if _, err = GetRepoTransferById(u3.Id, tr.Id); err != ErrRepoTransferNotExist {
+		t.Errorf("GetRepoTransferById(other recipient) error = %v, expected %v", err, ErrRepoTransferNotExist)
+	}
+	if _, err = GetRepoTransferById(u2.Id, 0); err != ErrRepoTransferNotExist {
+		t.Errorf("GetRepoTransferById(zero ID) error = %v, expected %v", err, ErrRepoTransferNotExist)
+	}
+	if n, err := CountIncomingRepoTransfers(u2.Id); err != nil || n != 1 {
+		t.Errorf("CountIncomingRepoTransfers = (%d, %v), expected 1", n, err)
+	}
+
+	if tr, err = GetRepoTransferById(u2.Id, tr.Id); err != nil {
+		t.Fatalf("GetRepoTransferById: %v", err)
+	} else if err = AcceptRepoTransfer(tr); err != nil {
+		t.Fatalf("AcceptRepoTransfer: %v", err)
+	}
+
+	if repo, err = GetRepositoryById(repo.Id); err != nil || repo.OwnerId != u2.Id {
+		t.Errorf("GetRepositoryById = (%v, %v), expected owned by %d", repo, err, u2.Id)
+	}
+	if !com.IsDir(RepoPath(u2.Name, "repo1")) || com.IsExist(RepoPath(u1.Name, "repo1")) {
+		t.Error("repository directory is not moved to new owner")
+	}
+	if !IsWatching(u2.Id, repo.Id) {
+		t.Error("new owner is not watching repository")
+	}
+	if _, err = GetRepoTransfer(repo.Id); err != ErrRepoTransferNotExist {
+		t.Errorf("GetRepoTransfer(accepted) error = %v, expected %v", err, ErrRepoTransferNotExist)
+	}
+
+	// Previous location redirects to transferred repository.
+	if r, err := GetRedirectedRepository(u1.Id, "Repo1"); err != nil || r.Id != repo.Id {
+		t.Errorf("GetRedirectedRepository = (%v, %v), expected %d", r, err, repo.Id)
+	}
+	if _, err = GetRedirectedRepository(u2.Id, "repo1"); err != ErrRepoNotExist {
+		t.Errorf("GetRedirectedRepository(current location) error = %v, expected %v", err, ErrRepoNotExist)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrReviewRequestOwnPull  = errors.New("Author of pull request cannot be requested to review it")
+	ErrReviewRequestNoAccess = errors.New("User has no access to repository")
+)
+
+// ReviewRequest represents a pending request for user to review a pull request,
+// it is removed once user submits a review.
+type ReviewRequest struct {
+	Id          int64
+	IssueId     int64 `xorm:"UNIQUE(s) INDEX"`
+	ReviewerId  int64 `xorm:"UNIQUE(s) INDEX"`
+	Reviewer    *User `xorm:"-"`
+	RequesterId int64
+	Created     time.Time `xorm:"CREATED"`
+}
+
+// RequestReviews requests given users to review pull request, the first one is assigned
+// if nobody is assigned yet. It returns users who have not been requested before.
+func RequestReviews(doer *User, repo *Repository, issue *Issue, reviewers []*User) ([]*User, error) {
+	requested := make([]*User, 0, len(reviewers))
+	for _, u := range reviewers {
+		if u.Id == issue.PosterId {
+			continue
+		}
+		has, err := orm.Where("issue_id=? AND reviewer_id=?", issue.Id, u.Id).Get(new(ReviewRequest))
+		if err != nil {
+			return nil, err
+		} else if has {
+			continue
+		}
+		if _, err = orm.Insert(&ReviewRequest{
+			IssueId:     issue.Id,
+			ReviewerId:  u.Id,
+			RequesterId: doer.Id,
+		}); err != nil {
+			return nil, err
+		}
+		requested = append(requested, u)
+	}
+	if len(requested) == 0 {
+		return requested, nil
+	}
+
+	if issue.AssigneeId == 0 {
+		if err := ChangeIssueAssignee(issue, requested[0].Id, true); err != nil {
+			return nil, err
+		} else if err = CreateAssigneeEvent(doer, issue, requested[0], true); err != nil {
+			return nil, err
+		}
+	}
+	return requested, NewReviewRequestNotifications(doer, repo, issue, requested)
+}
+
+// RequestReview requests user to review pull request of given repository,
+// user must be able to read the repository.
+func RequestReview(doer *User, repo *Repository, issue *Issue, u *User) ([]*User, error) {
+	if u.Id == issue.PosterId {
+		return nil, ErrReviewRequestOwnPull
+	}
+	if repo.IsPrivate && repo.OwnerId != u.Id {
+		if err := repo.GetOwner(); err != nil {
+			return nil, err
+		}
+		has, err := HasAccess(u.Name, repo.Owner.Name+"/"+repo.Name, AU_READABLE)
+		if err != nil {
+			return nil, err
+		} else if !has {
+			return nil, ErrReviewRequestNoAccess
+		}
+	}
+	return RequestReviews(doer, repo, issue, []*User{u})
+}
+
+// DeleteReviewRequest removes pending review request of user on pull request.
+func DeleteReviewRequest(issueId, reviewerId int64) error {
+	_, err := orm.Where("issue_id=? AND reviewer_id=?", issueId, reviewerId).Delete(new(ReviewRequest))
+	return err
+}
+
+// GetReviewRequests returns pending review requests of pull request with their reviewers.
+func GetReviewRequests(issueId int64) ([]*ReviewRequest, error) {
+	reqs := make([]*ReviewRequest, 0, 3)
+	if err := orm.Where("issue_id=?", issueId).Asc("id").Find(&reqs); err != nil {
+		return nil, err
+	}
+
+	var err error
+	valid := reqs[:0]
+	for _, r := range reqs {
+		if r.Reviewer, err = GetUserById(r.ReviewerId); err != nil {
+			if err == ErrUserNotExist {
+				continue
+			}
+			return nil, err
+		}
+		valid = append(valid, r)
+	}
+	return valid, nil
+}
+
+// GetRequestedReviewPulls returns open pull requests that user is requested to review,
+// pull requests of repositories user has no longer access to are skipped.
+func GetRequestedReviewPulls(u *User) ([]*Issue, error) {
+	reqs := make([]*ReviewRequest, 0, 5)
+	if err := orm.Where("reviewer_id=?", u.Id).Desc("id").Find(&reqs); err != nil {
+		return nil, err
+	}
+
+	issues := make([]*Issue, 0, len(reqs))
+	for _, r := range reqs {
+		issue, err := GetIssueById(r.IssueId)
+		if err != nil {
+			if err == ErrIssueNotExist {
+				continue
+			}
+			return nil, err
+		} else if issue.IsClosed {
+			continue
+		}
+
+		if issue.Repo, err = GetRepositoryById(issue.RepoId); err != nil {
+			if err == ErrRepoNotExist {
+				continue
+			}
+			return nil, err
+		} else if err = issue.Repo.GetOwner(); err != nil {
+			return nil, err
+		}
+		if issue.Repo.IsPrivate && issue.Repo.OwnerId != u.Id {
+			has, err := HasAccess(u.Name, issue.Repo.Owner.Name+"/"+issue.Repo.Name, AU_READABLE)
+			if err != nil {
+				return nil, err
+			} else if !has {
+				continue
+			}
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
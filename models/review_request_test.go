@@ -0,0 +1,86 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+)
+
+func TestReviewRequests(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	reader, stranger := newTestUser(t, "reader"), newTestUser(t, "stranger")
+	repo := newTestRepo(t, u1, "repo1")
+	repoPath := RepoPath(u1.Name, repo.Name)
+	repo.IsPrivate = true
+	if _, err := orm.Id(repo.Id).Cols("is_private").Update(repo); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := orm.Insert(&Access{UserName: "reader", RepoName: "user1/repo1", Mode: AU_READABLE}); err != nil {
+		t.Fatal(err)
+	}
+
+	testCommitFiles(t, repoPath, "feature", "master", map[string]string{"a.txt": "a\n"}, "Add a")
+	pr := newTestPullRequest(t, repo, u2, "feature", "master")
+
+	if _, err := RequestReview(u2, repo, pr.Issue, u2); err != ErrReviewRequestOwnPull {
+		t.Errorf("RequestReview(author) error = %v, expected %v", err, ErrReviewRequestOwnPull)
+	}
+	if _, err := RequestReview(u2, repo, pr.Issue, stranger); err != ErrReviewRequestNoAccess {
+		t.Errorf("RequestReview(no access) error = %v, expected %v", err, ErrReviewRequestNoAccess)
+	}
+	for _, u := range []*User{reader, u1} {
+		if requested, err := RequestReview(u2, repo, pr.Issue, u); err != nil {
+			t.Fatalf("RequestReview(%s): %v", u.Name, err)
+		} else if len(requested) != 1 {
+			t.Errorf("RequestReview(%s) requests %d users, expected 1", u.Name, len(requested))
+		}
+	}
+	// Users are not requested twice, and author is skipped.
+	if requested, err := RequestReviews(u2, repo, pr.Issue, []*User{u1, u2}); err != nil {
+		t.Fatalf("RequestReviews(again): %v", err)
+	} else if len(requested) != 0 {
+		t.Errorf("RequestReviews(again) requests %d users, expected 0", len(requested))
+	}
+	if pr.Issue.AssigneeId != reader.Id {
+		t.Errorf("pull request is assigned to %d, expected first reviewer %d", pr.Issue.AssigneeId, reader.Id)
+	}
+
+	reqs, err := GetReviewRequests(pr.IssueId)
+	if err != nil {
+		t.Fatalf("GetReviewRequests: %v", err)
+	} else if len(reqs) != 2 || reqs[0].Reviewer.Id != reader.Id || reqs[1].Reviewer.Id != u1.Id {
+		t.Errorf("GetReviewRequests returns %d requests, expected requests of %s and %s", len(reqs), reader.Name, u1.Name)
+	}
+	for _, u := range []*User{reader, u1} {
+		if issues, err := GetRequestedReviewPulls(u); err != nil {
+			t.Fatalf("GetRequestedReviewPulls(%s): %v", u.Name, err)
+		} else if len(issues) != 1 || issues[0].Id != pr.IssueId {
+			t.Errorf("GetRequestedReviewPulls(%s) returns %d pull requests, expected 1", u.Name, len(issues))
+		}
+	}
+
+	// Reader loses access to private repository.
+	if _, err = orm.Delete(&Access{UserName: "reader", RepoName: "user1/repo1"}); err != nil {
+		t.Fatal(err)
+	}
+	if issues, err := GetRequestedReviewPulls(reader); err != nil {
+		t.Fatalf("GetRequestedReviewPulls(no access): %v", err)
+	} else if len(issues) != 0 {
+		t.Errorf("GetRequestedReviewPulls(no access) returns %d pull requests, expected 0", len(issues))
+	}
+
+	if err = DeleteReviewRequest(pr.IssueId, 0); err != nil {
+		t.Fatalf("DeleteReviewRequest(zero ID): %v", err)
+	} else if reqs, _ = GetReviewRequests(pr.IssueId); len(reqs) != 2 {
+		t.Errorf("DeleteReviewRequest(zero ID) leaves %d requests, expected 2", len(reqs))
+	}
+	// Submitting a review fulfils the request.
+	if _, err = SubmitPullReview(pr, u1, REVIEW_COMMENT, "Looks fine", ""); err != nil {
+		t.Fatalf("SubmitPullReview: %v", err)
+	} else if reqs, _ = GetReviewRequests(pr.IssueId); len(reqs) != 1 || reqs[0].ReviewerId != reader.Id {
+		t.Errorf("SubmitPullReview leaves %d requests, expected request of %s", len(reqs), reader.Name)
+	}
+}
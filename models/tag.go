@@ -0,0 +1,115 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gogits/git"
+)
+
+var (
+	ErrTagAlreadyExist  = errors.New("Tag already exists")
+	ErrTagNotExist      = errors.New("Tag does not exist")
+	ErrTagNameIllegal   = errors.New("Tag name is not valid")
+	ErrTagTargetIllegal = errors.New("Target of tag is not a branch, tag or commit")
+)
+
+// Tag represents a tag of repository.
+type Tag struct {
+	Name        string
+	IsAnnotated bool
+	Message     string // Subject of annotation, empty for lightweight tag.
+	Commit      *git.Commit
+	// Verification is nil if tag is not signed.
+	Verification *CommitVerification
+}
+
+// GetTags returns all tags of repository, newest first.
+func GetTags(gitRepo *git.Repository) ([]*Tag, error) {
+	stdout, err := execGitCmd(gitRepo.Path, nil, nil, "for-each-ref", "--sort=-creatordate",
+		"--format=%(refname:short)%00%(objecttype)%00%(contents:subject)", "refs/tags")
+	if err != nil {
+		return nil, err
+	} else if len(stdout) == 0 {
+		return []*Tag{}, nil
+	}
+
+	verifications, err := GetTagsVerification(gitRepo.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(stdout, "\n")
+	tags := make([]*Tag, 0, len(lines))
+	for _, line := range lines {
+		fields := strings.SplitN(line, "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		t := &Tag{Name: fields[0], IsAnnotated: fields[1] == "tag", Message: fields[2], Verification: verifications[fields[0]]}
+		if t.Commit, err = gitRepo.GetCommitOfTag(t.Name); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, nil
+}
+
+// CreateTag creates an annotated tag on commit that target points to,
+// hooks are triggered in the same way as tag is pushed.
+func CreateTag(doer *User, repo *Repository, tagName, target, message string) error {
+	if repo.IsArchived {
+		return ErrRepoArchived
+	}
+	repoPath := RepoPath(repo.Owner.Name, repo.Name)
+	refName := "refs/tags/" + tagName
+	if _, err := execGitCmd(repoPath, nil, nil, "check-ref-format", refName); err != nil {
+		return ErrTagNameIllegal
+	} else if _, err = execGitCmd(repoPath, nil, nil, "rev-parse", "--verify", "-q", refName); err == nil {
+		return ErrTagAlreadyExist
+	}
+
+	commitId, err := execGitCmd(repoPath, nil, nil, "rev-parse", "--verify", "-q", target+"^{commit}")
+	if err != nil {
+		return ErrTagTargetIllegal
+	}
+
+	sig := doer.NewGitSig()
+	sigEnv := []string{"GIT_COMMITTER_NAME=" + sig.Name, "GIT_COMMITTER_EMAIL=" + sig.Email}
+	if _, err = execGitCmd(repoPath, sigEnv, strings.NewReader(message), "tag", "-a", "-F", "-", tagName, commitId); err != nil {
+		return err
+	}
+	tagId, err := execGitCmd(repoPath, nil, nil, "rev-parse", refName)
+	if err != nil {
+		return err
+	}
+
+	return Update(refName, _EMPTY_COMMIT_ID, tagId, doer.Name, repo.Owner.Name, repo.Name, doer.Id)
+}
+
+// DeleteTag deletes tag of repository, release of the tag becomes draft.
+func DeleteTag(doer *User, repo *Repository, tagName string) error {
+	if repo.IsArchived {
+		return ErrRepoArchived
+	}
+	repoPath := RepoPath(repo.Owner.Name, repo.Name)
+	refName := "refs/tags/" + tagName
+	tagId, err := execGitCmd(repoPath, nil, nil, "rev-parse", "--verify", "-q", refName)
+	if err != nil {
+		return ErrTagNotExist
+	}
+	if _, err = execGitCmd(repoPath, nil, nil, "update-ref", "-d", refName, tagId); err != nil {
+		return err
+	}
+
+	if _, err = orm.Where("repo_id=? AND lower_tag_name=?", repo.Id, strings.ToLower(tagName)).
+		Cols("is_draft").Update(&Release{IsDraft: true}); err != nil {
+		return err
+	}
+
+	return Update(refName, tagId, _EMPTY_COMMIT_ID, doer.Name, repo.Owner.Name, repo.Name, doer.Id)
+}
@@ -0,0 +1,66 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/gogits/git"
+)
+
+func TestCreateTag(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo := newTestRepo(t, u, "repo1")
+	repoPath := RepoPath(u.Name, repo.Name)
+
+	tests := []struct {
+		name, target string
+		expected     error
+	}{
+		{"v1..0", "master", ErrTagNameIllegal},
+		{"v1.0", "none", ErrTagTargetIllegal},
+		{"v1.0", "master", nil},
+		{"v1.0", "master", ErrTagAlreadyExist},
+		{"v1.0-copy", "v1.0", nil},
+	}
+	for _, tt := range tests {
+		if err := CreateTag(u, repo, tt.name, tt.target, "Release "+tt.name); err != tt.expected {
+			t.Errorf("CreateTag(%s, %s) error = %v, expected %v", tt.name, tt.target, err, tt.expected)
+		}
+	}
+
+	gitRepo, err := git.OpenRepository(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags, err := GetTags(gitRepo)
+	if err != nil {
+		t.Fatalf("GetTags: %v", err)
+	} else if len(tags) != 2 {
+		t.Fatalf("GetTags returns %d tags, expected 2", len(tags))
+	}
+	masterId, _ := execGitCmd(repoPath, nil, nil, "rev-parse", "master")
+	for _, tag := range tags {
+		if !tag.IsAnnotated || tag.Message != "Release "+tag.Name || tag.Commit.Id.String() != masterId {
+			t.Errorf("tag %s is annotated %v with message %q on %s, expected on %s",
+				tag.Name, tag.IsAnnotated, tag.Message, tag.Commit.Id, masterId)
+		}
+	}
+
+	// Release of deleted tag becomes draft.
+	rel := &Release{RepoId: repo.Id, PublisherId: u.Id, Title: "v1.0", TagName: "v1.0", LowerTagName: "v1.0"}
+	if _, err = orm.Insert(rel); err != nil {
+		t.Fatal(err)
+	}
+	if err = DeleteTag(u, repo, "v1.0"); err != nil {
+		t.Fatalf("DeleteTag: %v", err)
+	} else if err = DeleteTag(u, repo, "v1.0"); err != ErrTagNotExist {
+		t.Errorf("DeleteTag(deleted) error = %v, expected %v", err, ErrTagNotExist)
+	}
+	if rel, err = GetReleaseById(rel.Id); err != nil || !rel.IsDraft {
+		t.Errorf("GetReleaseById = (%v, %v), expected draft", rel, err)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+
+	"github.com/gogits/gogs/modules/log"
+)
+
+// tagVerificationCache caches verification results of signed tags by ID of tag object.
+// Tag objects never change, so results only become stale when GPG keys are changed.
+var tagVerificationCache = struct {
+	sync.RWMutex
+	results map[string]*CommitVerification
+}{results: make(map[string]*CommitVerification)}
+
+// resetTagVerificationCache removes all cached verification results of tags.
+func resetTagVerificationCache() {
+	tagVerificationCache.Lock()
+	tagVerificationCache.results = make(map[string]*CommitVerification)
+	tagVerificationCache.Unlock()
+}
+
+// splitTagSignature separates armored signature that is appended to message
+// of raw tag object, the rest is the payload that was signed.
+func splitTagSignature(raw []byte) (payload, sig []byte) {
+	if bytes.HasPrefix(raw, []byte("-----BEGIN PGP SIGNATURE-----")) {
+		return nil, raw
+	}
+	idx := bytes.Index(raw, []byte("\n-----BEGIN PGP SIGNATURE-----"))
+	if idx == -1 {
+		return raw, nil
+	}
+	return raw[:idx+1], raw[idx+1:]
+}
+
+// tagTaggerEmail returns e-mail address of tagger in raw tag object.
+func tagTaggerEmail(raw []byte) string {
+	for _, line := range strings.Split(string(raw), "\n") {
+		if len(line) == 0 {
+			break
+		} else if !strings.HasPrefix(line, "tagger ") {
+			continue
+		}
+		start, end := strings.Index(line, "<"), strings.Index(line, ">")
+		if start == -1 || end < start {
+			return ""
+		}
+		return line[start+1 : end]
+	}
+	return ""
+}
+
+// GetTagsVerification returns signature verification results of signed tags of repository
+// by tag name, tags that are lightweight or not signed are not included.
+func GetTagsVerification(repoPath string) (map[string]*CommitVerification, error) {
+	stdout, err := execGitCmd(repoPath, nil, nil, "for-each-ref",
+		"--format=%(refname:short)%00%(objectname)%00%(objecttype)", "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]*CommitVerification)
+	names := make(map[string][]string) // Tag object ID -> tag names.
+	ids := make([]string, 0, 10)
+	tagVerificationCache.RLock()
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 3 || fields[2] != "tag" {
+			continue
+		}
+		if v, ok := tagVerificationCache.results[fields[1]]; ok {
+			if v != nil {
+				results[fields[0]] = v
+			}
+			continue
+		}
+		if _, ok := names[fields[1]]; !ok {
+			ids = append(ids, fields[1])
+		}
+		names[fields[1]] = append(names[fields[1]], fields[0])
+	}
+	tagVerificationCache.RUnlock()
+	if len(ids) == 0 {
+		return results, nil
+	}
+
+	raws, err := readRawCommits(repoPath, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string][]*GPGKey)
+	verified := make(map[string]*CommitVerification, len(ids))
+	for _, id := range ids {
+		raw, ok := raws[id]
+		if !ok {
+			log.Error("GetTagsVerification: tag object %s is missing", id)
+			continue
+		}
+		var v *CommitVerification
+		if payload, sig := splitTagSignature(raw); len(sig) > 0 {
+			v = verifySignature(payload, sig, tagTaggerEmail(raw), "Tagger", keys)
+		}
+		// Unsigned tags are cached as well to avoid reading them again.
+		verified[id] = v
+		if v != nil {
+			for _, name := range names[id] {
+				results[name] = v
+			}
+		}
+	}
+
+	tagVerificationCache.Lock()
+	for id, v := range verified {
+		tagVerificationCache.results[id] = v
+	}
+	tagVerificationCache.Unlock()
+	return results, nil
+}
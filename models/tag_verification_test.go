@@ -0,0 +1,116 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+const testSignedTag = `object 4b825dc642cb6eb9a060e54bf8d69288fbee4904
+type commit
+tag v1.0
+tagger Gogs <gogs@example.com> 1400000000 +0800
+
+Release v1.0
+-----BEGIN PGP SIGNATURE-----
+
+iQEcBAABAgAGBQJTcHYAAAoJEP
+=abcd
+-----END PGP SIGNATURE-----
+`
+
+func TestSplitTagSignature(t *testing.T) {
+	tests := []struct {
+		raw, payload, sig string
+	}{
+		{testSignedTag, testSignedTag[:strings.Index(testSignedTag, "-----BEGIN")], testSignedTag[strings.Index(testSignedTag, "-----BEGIN"):]},
+		{"object 4b82\ntype commit\n\nRelease\n", "object 4b82\ntype commit\n\nRelease\n", ""},
+		{"-----BEGIN PGP SIGNATURE-----\n", "", "-----BEGIN PGP SIGNATURE-----\n"},
+		{"message with -----BEGIN PGP SIGNATURE----- inline\n", "message with -----BEGIN PGP SIGNATURE----- inline\n", ""},
+	}
+	for _, tt := range tests {
+		payload, sig := splitTagSignature([]byte(tt.raw))
+		if string(payload) != tt.payload || string(sig) != tt.sig {
+			t.Errorf("splitTagSignature(%q) = (%q, %q), expected (%q, %q)", tt.raw, payload, sig, tt.payload, tt.sig)
+		}
+	}
+}
+
+func TestTagTaggerEmail(t *testing.T) {
+	tests := []struct {
+		raw, expected string
+	}{
+		{testSignedTag, "gogs@example.com"},
+		{"object 4b82\ntype commit\ntag v1.0\n\nRelease\n", ""},
+		{"object 4b82\n\ntagger Gogs <gogs@example.com> 1400000000 +0800\n", ""},
+		{"tagger Gogs >gogs@example.com< 1400000000 +0800\n", ""},
+		{"tagger Gogs 1400000000 +0800\n", ""},
+	}
+	for _, tt := range tests {
+		if email := tagTaggerEmail([]byte(tt.raw)); email != tt.expected {
+			t.Errorf("tagTaggerEmail(%q) = %q, expected %q", tt.raw, email, tt.expected)
+		}
+	}
+}
+
+func TestGetTagsVerification(t *testing.T) {
+	repoPath, err := ioutil.TempDir("", "gogs-tag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoPath)
+	defer resetTagVerificationCache()
+
+	if _, err = execGitCmd(repoPath, nil, nil, "init", "-q", "--bare"); err != nil {
+		t.Fatal(err)
+	}
+	commitId := testCommitFiles(t, repoPath, "master", "", map[string]string{"README.md": "# tag\n"}, "Initial commit")
+
+	git := func(stdin string, args ...string) string {
+		stdout, err := execGitCmd(repoPath, testGitEnv, strings.NewReader(stdin), args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stdout
+	}
+	git("", "tag", "v0.1", "master")
+	git("", "tag", "-a", "-m", "Unsigned", "v0.2", "master")
+	// Signature that cannot be parsed is reported without looking up any key.
+	signed := strings.Replace(testSignedTag, "4b825dc642cb6eb9a060e54bf8d69288fbee4904", commitId, 1)
+	tagId := git(signed, "mktag")
+	git("", "update-ref", "refs/tags/v1.0", tagId)
+	git("", "update-ref", "refs/tags/v1.0-rc", tagId)
+
+	for i := 0; i < 2; i++ {
+		results, err := GetTagsVerification(repoPath)
+		if err != nil {
+			t.Fatalf("GetTagsVerification: %v", err)
+		} else if len(results) != 2 {
+			t.Fatalf("GetTagsVerification returns %d results, expected 2", len(results))
+		}
+		for _, name := range []string{"v1.0", "v1.0-rc"} {
+			if v := results[name]; v == nil || v.Verified || v.Reason != "Invalid signature" {
+				t.Errorf("GetTagsVerification[%s] = %+v, expected invalid signature", name, v)
+			}
+		}
+	}
+
+	tagVerificationCache.RLock()
+	v, ok := tagVerificationCache.results[tagId]
+	tagVerificationCache.RUnlock()
+	if !ok || v == nil {
+		t.Errorf("verification of tag %s is not cached", tagId)
+	}
+	resetTagVerificationCache()
+	tagVerificationCache.RLock()
+	n := len(tagVerificationCache.results)
+	tagVerificationCache.RUnlock()
+	if n != 0 {
+		t.Errorf("resetTagVerificationCache leaves %d results", n)
+	}
+}
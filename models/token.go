@@ -0,0 +1,118 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-xorm/xorm"
+
+	"github.com/gogits/gogs/modules/base"
+)
+
+var (
+	ErrAccessTokenNotExist = errors.New("Access token does not exist")
+)
+
+// AccessToken represents a personal access token.
+type AccessToken struct {
+	Id      int64
+	Uid     int64     `xorm:"INDEX"`
+	Name    string    `xorm:"NOT NULL"`
+	AppId   int64     `xorm:"INDEX"` // OAuth application that token is issued to, 0 for personal.
+	Scope   string    // Comma separated scopes, empty for full access of personal token.
+	Sha1    string    `xorm:"UNIQUE VARCHAR(40)"`
+	Created time.Time `xorm:"CREATED"`
+	Updated time.Time `xorm:"UPDATED"`
+
+	HasRecentActivity bool `xorm:"-"`
+	HasUsed           bool `xorm:"-"`
+}
+
+// HasScope returns true if token is granted given scope, personal token without
+// any scope has full access but token issued to OAuth application has none.
+func (t *AccessToken) HasScope(scope string) bool {
+	if len(t.Scope) == 0 {
+		return t.AppId == 0
+	}
+
+	for _, s := range strings.Split(t.Scope, ",") {
+		if s == scope || (s == SCOPE_REPO_WRITE && scope == SCOPE_REPO_READ) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewAccessToken creates new access token.
+func NewAccessToken(t *AccessToken) error {
+	t.Sha1 = base.EncodeSha1(base.GetRandomString(40))
+	_, err := orm.Insert(t)
+	return err
+}
+
+// GetAccessTokenBySha returns access token by given sha1.
+func GetAccessTokenBySha(sha string) (*AccessToken, error) {
+	if len(sha) != 40 {
+		return nil, ErrAccessTokenNotExist
+	}
+
+	t := &AccessToken{Sha1: sha}
+	has, err := orm.Get(t)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrAccessTokenNotExist
+	}
+	return t, nil
+}
+
+// ListAccessTokens returns a list of personal access tokens belongs to given user.
+func ListAccessTokens(uid int64) ([]*AccessToken, error) {
+	return listAccessTokens(orm.Where("uid=?", uid).And("app_id=0"))
+}
+
+// ListOauthAccessTokens returns a list of access tokens that given user
+// has authorized OAuth applications with.
+func ListOauthAccessTokens(uid int64) ([]*AccessToken, error) {
+	return listAccessTokens(orm.Where("uid=?", uid).And("app_id>0"))
+}
+
+func listAccessTokens(sess *xorm.Session) ([]*AccessToken, error) {
+	tokens := make([]*AccessToken, 0, 5)
+	err := sess.Desc("id").Find(&tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range tokens {
+		t.HasUsed = t.Updated.After(t.Created)
+		t.HasRecentActivity = t.Updated.Add(7 * 24 * time.Hour).After(time.Now())
+	}
+	return tokens, nil
+}
+
+// UpdateAccessToken updates information of access token.
+func UpdateAccessToken(t *AccessToken) error {
+	_, err := orm.Id(t.Id).AllCols().Update(t)
+	return err
+}
+
+// DeleteAccessTokenOfUserById deletes access token of given user by given ID.
+func DeleteAccessTokenOfUserById(uid, id int64) error {
+	// Zero ID must not be left to xorm, which ignores zero value conditions.
+	if id <= 0 {
+		return ErrAccessTokenNotExist
+	}
+	affected, err := orm.Where("id=? AND uid=?", id, uid).Delete(new(AccessToken))
+	if err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrAccessTokenNotExist
+	}
+	return nil
+}
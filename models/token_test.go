@@ -0,0 +1,65 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+)
+
+var hasScopeTests = []struct {
+	token    *AccessToken
+	scope    string
+	expected bool
+}{
+	{&AccessToken{}, SCOPE_ADMIN, true},
+	{&AccessToken{AppId: 1}, SCOPE_REPO_READ, false},
+	{&AccessToken{Scope: SCOPE_REPO_READ}, SCOPE_REPO_READ, true},
+	{&AccessToken{Scope: SCOPE_REPO_READ}, SCOPE_REPO_WRITE, false},
+	{&AccessToken{Scope: SCOPE_REPO_WRITE}, SCOPE_REPO_READ, true},
+	{&AccessToken{Scope: "user,admin"}, SCOPE_ADMIN, true},
+	{&AccessToken{Scope: "user,admin"}, SCOPE_REPO_READ, false},
+}
+
+func TestHasScope(t *testing.T) {
+	for _, tt := range hasScopeTests {
+		if has := tt.token.HasScope(tt.scope); has != tt.expected {
+			t.Errorf("AccessToken{AppId: %d, Scope: %q}.HasScope(%q) = %v, expected %v",
+				tt.token.AppId, tt.token.Scope, tt.scope, has, tt.expected)
+		}
+	}
+}
+
+func TestAccessTokenOwnership(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+
+	token := &AccessToken{Uid: u1.Id, Name: "ci"}
+	if err := NewAccessToken(token); err != nil {
+		t.Fatalf("NewAccessToken: %v", err)
+	}
+	if got, err := GetAccessTokenBySha(token.Sha1); err != nil || got.Id != token.Id {
+		t.Fatalf("GetAccessTokenBySha = (%v, %v), expected token %d", got, err, token.Id)
+	}
+	if _, err := GetAccessTokenBySha(token.Sha1[:39]); err != ErrAccessTokenNotExist {
+		t.Errorf("GetAccessTokenBySha(short) error = %v, expected %v", err, ErrAccessTokenNotExist)
+	}
+
+	if err := DeleteAccessTokenOfUserById(u2.Id, token.Id); err != ErrAccessTokenNotExist {
+		t.Errorf("DeleteAccessTokenOfUserById(other user) error = %v, expected %v", err, ErrAccessTokenNotExist)
+	}
+	if err := DeleteAccessTokenOfUserById(u1.Id, 0); err != ErrAccessTokenNotExist {
+		t.Errorf("DeleteAccessTokenOfUserById(zero ID) error = %v, expected %v", err, ErrAccessTokenNotExist)
+	}
+	if tokens, err := ListAccessTokens(u1.Id); err != nil || len(tokens) != 1 {
+		t.Fatalf("ListAccessTokens = (%d tokens, %v), expected 1 token", len(tokens), err)
+	}
+
+	if err := DeleteAccessTokenOfUserById(u1.Id, token.Id); err != nil {
+		t.Fatalf("DeleteAccessTokenOfUserById: %v", err)
+	}
+	if _, err := GetAccessTokenBySha(token.Sha1); err != ErrAccessTokenNotExist {
+		t.Errorf("GetAccessTokenBySha(deleted) error = %v, expected %v", err, ErrAccessTokenNotExist)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gogits/gogs/modules/base"
+)
+
+var (
+	ErrTwoFactorNotEnrolled  = errors.New("Two-factor authentication is not enrolled")
+	ErrTwoFactorNotConfirmed = errors.New("Password or passcode is incorrect")
+)
+
+// TwoFactor represents a two-factor authentication token of a user.
+type TwoFactor struct {
+	Id           int64
+	Uid          int64  `xorm:"UNIQUE"`
+	Secret       string `xorm:"NOT NULL"`
+	ScratchToken string
+	LastTotpStep int64     // Time step of last accepted passcode, which cannot be used again.
+	Created      time.Time `xorm:"CREATED"`
+	Updated      time.Time `xorm:"UPDATED"`
+}
+
+// GenerateScratchToken regenerates the scratch token of two-factor authentication.
+func (t *TwoFactor) GenerateScratchToken() string {
+	t.ScratchToken = base.GetRandomString(10, []byte("0123456789abcdefghijklmnopqrstuvwxyz")...)
+	return t.ScratchToken
+}
+
+// UseTotp returns true if given passcode is valid for current time and has not been
+// used, its time step is saved so neither it nor an earlier passcode can be replayed.
+func (t *TwoFactor) UseTotp(passcode string) (bool, error) {
+	step := base.MatchTotp(t.Secret, passcode, time.Now())
+	if step <= t.LastTotpStep {
+		return false, nil
+	}
+
+	// Condition makes sure concurrent requests cannot both use the same passcode.
+	affected, err := orm.Where("id=? AND last_totp_step<?", t.Id, step).
+		Cols("last_totp_step").Update(&TwoFactor{LastTotpStep: step})
+	if err != nil {
+		return false, err
+	}
+	t.LastTotpStep = step
+	return affected == 1, nil
+}
+
+// ValidateScratchToken returns true if given token matches the scratch token.
+func (t *TwoFactor) ValidateScratchToken(token string) bool {
+	token = strings.ToLower(strings.TrimSpace(token))
+	return len(t.ScratchToken) > 0 &&
+		subtle.ConstantTimeCompare([]byte(t.ScratchToken), []byte(token)) == 1
+}
+
+// NewTwoFactor creates a new two-factor authentication token.
+func NewTwoFactor(t *TwoFactor) error {
+	t.GenerateScratchToken()
+	_, err := orm.Insert(t)
+	return err
+}
+
+// UpdateTwoFactor updates information of two-factor authentication token.
+func UpdateTwoFactor(t *TwoFactor) error {
+	_, err := orm.Id(t.Id).AllCols().Update(t)
+	return err
+}
+
+// GetTwoFactorByUid returns two-factor authentication token of given user.
+func GetTwoFactorByUid(uid int64) (*TwoFactor, error) {
+	t := &TwoFactor{Uid: uid}
+	has, err := orm.Get(t)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	return t, nil
+}
+
+// DeleteTwoFactorByUid deletes two-factor authentication token of given user.
+func DeleteTwoFactorByUid(uid int64) error {
+	if uid <= 0 {
+		return ErrTwoFactorNotEnrolled
+	}
+	_, err := orm.Where("uid=?", uid).Delete(new(TwoFactor))
+	return err
+}
+
+// DisableTwoFactor disables two-factor authentication of user once it is confirmed by
+// current password or a valid passcode, so that signed in session alone is not enough.
+func DisableTwoFactor(u *User, passwd, passcode string) error {
+	if len(passwd) > 0 && u.ValidatePassword(passwd) {
+		return DeleteTwoFactorByUid(u.Id)
+	} else if len(passcode) == 0 {
+		return ErrTwoFactorNotConfirmed
+	}
+
+	t, err := GetTwoFactorByUid(u.Id)
+	if err != nil {
+		return err
+	}
+	if ok, err := t.UseTotp(passcode); err != nil {
+		return err
+	} else if !ok {
+		return ErrTwoFactorNotConfirmed
+	}
+	return DeleteTwoFactorByUid(u.Id)
+}
@@ -0,0 +1,59 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gogits/gogs/modules/base"
+)
+
+func TestDisableTwoFactorNotConfirmed(t *testing.T) {
+	// User has zero ID, so that matched password is never re-encoded.
+	u := &User{Passwd: encodePbkdf2Passwd("gogs-passwd", "salt"), Salt: "salt"}
+
+	tests := []struct {
+		passwd, passcode string
+	}{
+		{"", ""},
+		{"gogs-passwd2", ""},
+		{"gogs-passwd ", ""},
+	}
+	for _, tt := range tests {
+		if err := DisableTwoFactor(u, tt.passwd, tt.passcode); err != ErrTwoFactorNotConfirmed {
+			t.Errorf("DisableTwoFactor(%q, %q) = %v, expected %v", tt.passwd, tt.passcode, err, ErrTwoFactorNotConfirmed)
+		}
+	}
+}
+
+func TestUseTotpReplay(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+
+	tf := &TwoFactor{Uid: u.Id, Secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"}
+	if err := NewTwoFactor(tf); err != nil {
+		t.Fatalf("NewTwoFactor: %v", err)
+	}
+	// Loaded before passcode is used, as a concurrent request would have.
+	stale, err := GetTwoFactorByUid(u.Id)
+	if err != nil {
+		t.Fatalf("GetTwoFactorByUid: %v", err)
+	}
+
+	passcode, err := base.ComputeTotp(tf.Secret, uint64(time.Now().Unix()/base.TOTP_PERIOD))
+	if err != nil {
+		t.Fatalf("ComputeTotp: %v", err)
+	}
+	if ok, err := tf.UseTotp(passcode); err != nil || !ok {
+		t.Fatalf("UseTotp = (%v, %v), expected true", ok, err)
+	}
+	if ok, err := tf.UseTotp(passcode); err != nil || ok {
+		t.Errorf("UseTotp(used passcode) = (%v, %v), expected false", ok, err)
+	}
+	if ok, err := stale.UseTotp(passcode); err != nil || ok {
+		t.Errorf("UseTotp(used passcode, stale token) = (%v, %v), expected false", ok, err)
+	}
+}
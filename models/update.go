@@ -6,6 +6,8 @@ package models
 
 import (
 	"container/list"
+	"errors"
+	"fmt"
 	"os/exec"
 	"strings"
 
@@ -16,11 +18,48 @@ import (
 	"github.com/gogits/gogs/modules/base"
 )
 
-func Update(refName, oldCommitId, newCommitId, userName, repoUserName, repoName string, userId int64) {
+// updateRepoStates refreshes or marks states that depend on content of repository
+// after reference has been updated, failures are logged as they do not affect the update itself.
+func updateRepoStates(repo *Repository, repoPath, refName string) {
+	var err error
+	if err = ClearRepoArchives(repoPath); err != nil {
+		qlog.Errorf("updateRepoStates.ClearRepoArchives: %v", err)
+	}
+	if err = UpdateRepoSize(repo, repoPath); err != nil {
+		qlog.Errorf("updateRepoStates.UpdateRepoSize: %v", err)
+	}
+
+	// Push mirrors are synchronized by web server later.
+	if err = MarkPushMirrorsPending(repo.Id); err != nil {
+		qlog.Errorf("updateRepoStates.MarkPushMirrorsPending: %v", err)
+	}
+
+	// Pull requests from or into the branch are checked for conflicts by web server later.
+	if strings.HasPrefix(refName, "refs/heads/") {
+		if err = MarkPullRequestsChecking(repo.Id, strings.TrimPrefix(refName, "refs/heads/")); err != nil {
+			qlog.Errorf("updateRepoStates.MarkPullRequestsChecking: %v", err)
+		}
+	}
+
+	// Only default branch is indexed for code search and statistics, first push of bare repository sets it.
+	if repo.IsBare || refName == "refs/heads/"+repo.DefaultBranch {
+		if err = MarkRepoIndexerPending(repo.Id); err != nil {
+			qlog.Errorf("updateRepoStates.MarkRepoIndexerPending: %v", err)
+		}
+		if err = MarkRepoStatsPending(repo.Id); err != nil {
+			qlog.Errorf("updateRepoStates.MarkRepoStatsPending: %v", err)
+		}
+	}
+}
+
+// Update records reference update of repository made by given user, i.e. push action,
+// and marks states depending on repository content to be refreshed.
+// It is called by both update hook and web server, so it must not exit on error.
+func Update(refName, oldCommitId, newCommitId, userName, repoUserName, repoName string, userId int64) error {
 	isNew := strings.HasPrefix(oldCommitId, "0000000")
 	if isNew &&
 		strings.HasPrefix(newCommitId, "0000000") {
-		qlog.Fatal("old rev and new rev both 000000")
+		return errors.New("old rev and new rev both 000000")
 	}
 
 	f := RepoPath(repoUserName, repoName)
@@ -29,52 +68,51 @@ func Update(refName, oldCommitId, newCommitId, userName, repoUserName, repoName
 	gitUpdate.Dir = f
 	gitUpdate.Run()
 
+	ru, err := GetUserByName(repoUserName)
+	if err != nil {
+		return fmt.Errorf("GetUserByName: %v", err)
+	}
+	repos, err := GetRepositoryByName(ru.Id, repoName)
+	if err != nil {
+		return fmt.Errorf("GetRepositoryByName: %v", err)
+	}
+	updateRepoStates(repos, f, refName)
+
 	isDel := strings.HasPrefix(newCommitId, "0000000")
 	if isDel {
 		qlog.Info("del rev", refName, "from", userName+"/"+repoName+".git", "by", userId)
-		return
+		return nil
 	}
 
 	repo, err := git.OpenRepository(f)
 	if err != nil {
-		qlog.Fatalf("runUpdate.Open repoId: %v", err)
+		return fmt.Errorf("OpenRepository: %v", err)
+	}
+
+	// Annotated tag points to tag object instead of commit.
+	if strings.HasPrefix(refName, "refs/tags/") {
+		if newCommitId, err = execGitCmd(f, nil, nil, "rev-parse", newCommitId+"^{commit}"); err != nil {
+			return fmt.Errorf("peel tag: %v", err)
+		}
 	}
 
 	newCommit, err := repo.GetCommit(newCommitId)
 	if err != nil {
-		qlog.Fatalf("runUpdate GetCommit of newCommitId: %v", err)
-		return
+		return fmt.Errorf("GetCommit(%s): %v", newCommitId, err)
 	}
 
 	var l *list.List
 	// if a new branch
 	if isNew {
-		l, err = newCommit.CommitsBefore()
-		if err != nil {
-			qlog.Fatalf("Find CommitsBefore erro: %v", err)
+		if l, err = newCommit.CommitsBefore(); err != nil {
+			return fmt.Errorf("CommitsBefore: %v", err)
 		}
 	} else {
-		l, err = newCommit.CommitsBeforeUntil(oldCommitId)
-		if err != nil {
-			qlog.Fatalf("Find CommitsBeforeUntil erro: %v", err)
-			return
+		if l, err = newCommit.CommitsBeforeUntil(oldCommitId); err != nil {
+			return fmt.Errorf("CommitsBeforeUntil: %v", err)
 		}
 	}
 
-	if err != nil {
-		qlog.Fatalf("runUpdate.Commit repoId: %v", err)
-	}
-
-	ru, err := GetUserByName(repoUserName)
-	if err != nil {
-		qlog.Fatalf("runUpdate.GetUserByName: %v", err)
-	}
-
-	repos, err := GetRepositoryByName(ru.Id, repoName)
-	if err != nil {
-		qlog.Fatalf("runUpdate.GetRepositoryByName userId: %v", err)
-	}
-
 	commits := make([]*base.PushCommit, 0)
 	var maxCommits = 3
 	var actEmail string
@@ -96,6 +134,23 @@ func Update(refName, oldCommitId, newCommitId, userName, repoUserName, repoName
 	//commits = append(commits, []string{lastCommit.Id().String(), lastCommit.Message()})
 	if err = CommitRepoAction(userId, ru.Id, userName, actEmail,
 		repos.Id, repoUserName, repoName, refName, &base.PushCommits{l.Len(), commits}); err != nil {
-		qlog.Fatalf("runUpdate.models.CommitRepoAction: %s/%s:%v", repoUserName, repoName, err)
+		return fmt.Errorf("CommitRepoAction(%s/%s): %v", repoUserName, repoName, err)
+	}
+
+	// Commits pushed to any branch are referenced on issues, but only those
+	// pushed to default branch close them.
+	if strings.HasPrefix(refName, "refs/heads/") {
+		doer, err := GetUserById(userId)
+		if err != nil {
+			return fmt.Errorf("GetUserById: %v", err)
+		}
+		canClose := refName == "refs/heads/"+repos.DefaultBranch
+		for e := l.Front(); e != nil; e = e.Next() {
+			commit := e.Value.(*git.Commit)
+			if err = UpdateIssuesByCommit(doer, repos, commit.Id.String(), commit.Message(), canClose); err != nil {
+				qlog.Errorf("runUpdate.UpdateIssuesByCommit(%s): %v", commit.Id, err)
+			}
+		}
 	}
+	return nil
 }
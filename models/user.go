@@ -6,6 +6,7 @@ package models
 
 import (
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -14,6 +15,8 @@ import (
 	"strings"
 	"time"
 
+	"code.google.com/p/go.crypto/bcrypt"
+
 	"github.com/gogits/git"
 
 	"github.com/gogits/gogs/modules/base"
@@ -25,6 +28,7 @@ import (
 const (
 	UT_INDIVIDUAL = iota + 1
 	UT_ORGANIZATION
+	UT_BOT // Machine account that only authenticates by access token or SSH key.
 )
 
 var (
@@ -34,6 +38,7 @@ var (
 	ErrUserNotKeyOwner       = errors.New("User does not the owner of public key")
 	ErrEmailAlreadyUsed      = errors.New("E-mail already used")
 	ErrUserNameIllegal       = errors.New("User name contains illegal characters")
+	ErrUserSuspended         = errors.New("User has been suspended")
 	ErrLoginSourceNotExist   = errors.New("Login source does not exist")
 	ErrLoginSourceNotActived = errors.New("Login source is not actived")
 	ErrUnsupportedLoginType  = errors.New("Login source is unknown")
@@ -61,12 +66,19 @@ type User struct {
 	Website       string
 	IsActive      bool
 	IsAdmin       bool
+	IsSuspended   bool      // Suspended user cannot sign in or access anything by any means.
+	DiskQuota     int64     // Total size of repositories in megabytes, 0 means default quota, -1 means unlimited.
 	Rands         string    `xorm:"VARCHAR(10)"`
 	Salt          string    `xorm:"VARCHAR(10)"`
 	Created       time.Time `xorm:"created"`
 	Updated       time.Time `xorm:"updated"`
 }
 
+// IsBot returns true if user is a machine account.
+func (user *User) IsBot() bool {
+	return user.Type == UT_BOT
+}
+
 // HomeLink returns the user home page link.
 func (user *User) HomeLink() string {
 	return "/user/" + user.Name
@@ -91,10 +103,62 @@ func (user *User) NewGitSig() *git.Signature {
 	}
 }
 
+// Password hash schemes, stored as prefix of encoded password.
+// Passwords without known prefix are encoded by PBKDF2.
+const (
+	PASSWD_SCHEME_BCRYPT = "bcrypt$"
+)
+
+func encodePbkdf2Passwd(passwd, salt string) string {
+	return fmt.Sprintf("%x", base.PBKDF2([]byte(passwd), []byte(salt), 10000, 50, sha256.New))
+}
+
 // EncodePasswd encodes password to safe format.
 func (user *User) EncodePasswd() {
-	newPasswd := base.PBKDF2([]byte(user.Passwd), []byte(user.Salt), 10000, 50, sha256.New)
-	user.Passwd = fmt.Sprintf("%x", newPasswd)
+	hash, err := bcrypt.GenerateFromPassword([]byte(user.Passwd), setting.BcryptCost)
+	if err != nil {
+		// Only happens when cost is out of range, which has been checked in settings.
+		log.Error("user.EncodePasswd: %v", err)
+		user.Passwd = encodePbkdf2Passwd(user.Passwd, user.Salt)
+		return
+	}
+	user.Passwd = PASSWD_SCHEME_BCRYPT + string(hash)
+}
+
+// passwdNeedsRehash returns true if password of user is not encoded by
+// current scheme or parameters.
+func (user *User) passwdNeedsRehash() bool {
+	if !strings.HasPrefix(user.Passwd, PASSWD_SCHEME_BCRYPT) {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(strings.TrimPrefix(user.Passwd, PASSWD_SCHEME_BCRYPT)))
+	return err != nil || cost != setting.BcryptCost
+}
+
+// ValidatePassword checks if given password matches the one of user,
+// password encoded by outdated scheme is re-encoded when it matches.
+func (user *User) ValidatePassword(passwd string) bool {
+	// Bot has no password.
+	if user.IsBot() || len(user.Passwd) == 0 {
+		return false
+	}
+
+	if strings.HasPrefix(user.Passwd, PASSWD_SCHEME_BCRYPT) {
+		if bcrypt.CompareHashAndPassword([]byte(strings.TrimPrefix(user.Passwd, PASSWD_SCHEME_BCRYPT)), []byte(passwd)) != nil {
+			return false
+		}
+	} else if subtle.ConstantTimeCompare([]byte(user.Passwd), []byte(encodePbkdf2Passwd(passwd, user.Salt))) != 1 {
+		return false
+	}
+
+	if user.Id > 0 && user.passwdNeedsRehash() {
+		user.Passwd = passwd
+		user.EncodePasswd()
+		if _, err := orm.Id(user.Id).Cols("passwd").Update(user); err != nil {
+			log.Error("user.ValidatePassword(rehash): %v", err)
+		}
+	}
+	return true
 }
 
 // Member represents user is member of organization.
@@ -152,7 +216,11 @@ func RegisterUser(user *User) (*User, error) {
 	user.AvatarEmail = user.Email
 	user.Rands = GetUserSalt()
 	user.Salt = GetUserSalt()
-	user.EncodePasswd()
+	if user.IsBot() {
+		user.Passwd = ""
+	} else {
+		user.EncodePasswd()
+	}
 	if _, err = orm.Insert(user); err != nil {
 		return nil, err
 	} else if err = os.MkdirAll(UserPath(user.Name), os.ModePerm); err != nil {
@@ -278,8 +346,24 @@ func UpdateUser(u *User) (err error) {
 		u.Website = u.Website[:255]
 	}
 
-	_, err = orm.Id(u.Id).AllCols().Update(u)
-	return err
+	if _, err = orm.Id(u.Id).AllCols().Update(u); err != nil {
+		return err
+	}
+	// Verification results of tags depend on e-mail of key owners.
+	resetTagVerificationCache()
+	return nil
+}
+
+// SuspendUser suspends or unsuspends user, all signed in sessions
+// of the user are revoked when it is suspended.
+func SuspendUser(u *User, suspended bool) error {
+	u.IsSuspended = suspended
+	if err := UpdateUser(u); err != nil {
+		return err
+	} else if suspended {
+		return DeleteUserSessions(u.Id)
+	}
+	return nil
 }
 
 // DeleteUser completely deletes everything of the user.
@@ -304,6 +388,48 @@ func DeleteUser(user *User) error {
 		return err
 	}
 
+	// Delete two-factor authentication.
+	if err = DeleteTwoFactorByUid(user.Id); err != nil {
+		return err
+	}
+
+	// Delete all OAuth applications.
+	apps, err := GetOauthApplicationsByUid(user.Id)
+	if err != nil {
+		return err
+	}
+	for _, app := range apps {
+		if err = DeleteOauthApplication(user.Id, app.Id); err != nil {
+			return err
+		}
+	}
+
+	// Delete all access tokens.
+	if _, err = orm.Delete(&AccessToken{Uid: user.Id}); err != nil {
+		return err
+	}
+
+	// Delete all GPG keys.
+	if _, err = orm.Delete(&GPGKey{OwnerId: user.Id}); err != nil {
+		return err
+	}
+	resetTagVerificationCache()
+
+	// Delete all signed in sessions.
+	if err = DeleteUserSessions(user.Id); err != nil {
+		return err
+	}
+
+	// Delete all persistent logins.
+	if err = DeleteUserRememberTokens(user.Id); err != nil {
+		return err
+	}
+
+	// Delete all activation codes.
+	if _, err = orm.Delete(&EmailActivation{Uid: user.Id}); err != nil {
+		return err
+	}
+
 	// Delete all feeds.
 	if _, err = orm.Delete(&Action{UserId: user.Id}); err != nil {
 		return err
@@ -314,6 +440,16 @@ func DeleteUser(user *User) error {
 		return err
 	}
 
+	// Delete all issue subscriptions.
+	if _, err = orm.Delete(&IssueSubscription{UserId: user.Id}); err != nil {
+		return err
+	}
+
+	// Delete all saved issue filters.
+	if _, err = orm.Delete(&IssueFilter{UserId: user.Id}); err != nil {
+		return err
+	}
+
 	// Delete all accesses.
 	if _, err = orm.Delete(&Access{UserName: user.LowerName}); err != nil {
 		return err
@@ -388,7 +524,7 @@ func GetUserEmailsByNames(names []string) []string {
 	mails := make([]string, 0, len(names))
 	for _, name := range names {
 		u, err := GetUserByName(name)
-		if err != nil {
+		if err != nil || u.IsBot() {
 			continue
 		}
 		mails = append(mails, u.Email)
@@ -426,7 +562,6 @@ func GetUserByEmail(email string) (*User, error) {
 
 // SearchUserByName returns given number of users whose name contains keyword.
 func SearchUserByName(key string, limit int) (us []*User, err error) {
-	// Prevent SQL inject.
 	key = strings.TrimSpace(key)
 	if len(key) == 0 {
 		return us, nil
@@ -439,10 +574,42 @@ func SearchUserByName(key string, limit int) (us []*User, err error) {
 	key = strings.ToLower(key)
 
 	us = make([]*User, 0, limit)
-	err = orm.Limit(limit).Where("lower_name like '%" + key + "%'").Find(&us)
+	err = orm.Limit(limit).Where("lower_name LIKE ?", "%"+key+"%").Find(&us)
 	return us, err
 }
 
+// SearchUserOptions represents conditions of searching users.
+type SearchUserOptions struct {
+	Keyword  string // Matches user name or full name.
+	Page     int    // Starts from 1.
+	PageSize int
+}
+
+// SearchUsers returns individual users that match given conditions ordered by
+// name, and total number of them. Suspended users are not included.
+func SearchUsers(opts *SearchUserOptions) ([]*User, int64, error) {
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+
+	cond := "type=? AND is_suspended=?"
+	args := []interface{}{UT_INDIVIDUAL, false}
+	if key := strings.TrimSpace(opts.Keyword); len(key) > 0 {
+		cond += " AND (lower_name LIKE ? OR full_name LIKE ?)"
+		args = append(args, "%"+strings.ToLower(key)+"%", "%"+key+"%")
+	}
+
+	total, err := orm.Where(cond, args...).Count(new(User))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	us := make([]*User, 0, opts.PageSize)
+	err = orm.Where(cond, args...).Asc("lower_name").
+		Limit(opts.PageSize, (opts.Page-1)*opts.PageSize).Find(&us)
+	return us, total, err
+}
+
 // Follow is connection request for receiving user notifycation.
 type Follow struct {
 	Id       int64
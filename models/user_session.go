@@ -0,0 +1,138 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/setting"
+)
+
+var (
+	ErrUserSessionNotExist = errors.New("User session does not exist")
+)
+
+// UserSession represents a signed in session of user,
+// only SHA1 of session ID is stored.
+type UserSession struct {
+	Id        int64
+	Uid       int64  `xorm:"INDEX"`
+	Sha1      string `xorm:"UNIQUE VARCHAR(40)"`
+	Ip        string `xorm:"VARCHAR(50)"`
+	UserAgent string
+	Created   time.Time `xorm:"CREATED"`
+	Updated   time.Time `xorm:"INDEX"` // Last seen time.
+	IsCurrent bool      `xorm:"-"`
+}
+
+func userSessionExpireTime() time.Time {
+	return time.Now().Add(-time.Duration(setting.SessionConfig.SessionLifeTime) * time.Second)
+}
+
+// NewUserSession records a new signed in session of user.
+func NewUserSession(uid int64, sid, ip, agent string) error {
+	sha := base.EncodeSha1(sid)
+
+	sess := orm.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	// Session ID could be reused by another user.
+	if _, err := sess.Where("sha1=?", sha).Delete(new(UserSession)); err != nil {
+		sess.Rollback()
+		return err
+	}
+	if _, err := sess.Insert(&UserSession{
+		Uid:       uid,
+		Sha1:      sha,
+		Ip:        ip,
+		UserAgent: agent,
+		Updated:   time.Now(),
+	}); err != nil {
+		sess.Rollback()
+		return err
+	}
+	return sess.Commit()
+}
+
+// GetUserSession returns signed in session by given session ID.
+func GetUserSession(sid string) (*UserSession, error) {
+	s := &UserSession{Sha1: base.EncodeSha1(sid)}
+	has, err := orm.Get(s)
+	if err != nil {
+		return nil, err
+	} else if !has || s.Updated.Before(userSessionExpireTime()) {
+		return nil, ErrUserSessionNotExist
+	}
+	return s, nil
+}
+
+// TouchUserSession updates last seen information of session,
+// it only writes to database once a minute.
+func TouchUserSession(s *UserSession, ip, agent string) error {
+	if time.Since(s.Updated) < time.Minute && s.Ip == ip {
+		return nil
+	}
+	s.Ip = ip
+	s.UserAgent = agent
+	s.Updated = time.Now()
+	_, err := orm.Id(s.Id).Cols("ip", "user_agent", "updated").Update(s)
+	return err
+}
+
+// GetUserSessions returns all signed in sessions of given user,
+// the current one is marked by given session ID.
+func GetUserSessions(uid int64, sid string) ([]*UserSession, error) {
+	sessions := make([]*UserSession, 0, 5)
+	if err := orm.Where("uid=?", uid).And("updated>?", userSessionExpireTime()).
+		Desc("updated").Find(&sessions); err != nil {
+		return nil, err
+	}
+
+	sha := base.EncodeSha1(sid)
+	for _, s := range sessions {
+		s.IsCurrent = s.Sha1 == sha
+	}
+	return sessions, nil
+}
+
+// DeleteUserSession revokes a signed in session of given user by ID.
+func DeleteUserSession(uid, id int64) error {
+	if id <= 0 {
+		return ErrUserSessionNotExist
+	}
+	affected, err := orm.Where("id=? AND uid=?", id, uid).Delete(new(UserSession))
+	if err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrUserSessionNotExist
+	}
+	return nil
+}
+
+// DeleteUserSessionBySid revokes signed in session by given session ID.
+func DeleteUserSessionBySid(sid string) error {
+	_, err := orm.Where("sha1=?", base.EncodeSha1(sid)).Delete(new(UserSession))
+	return err
+}
+
+// DeleteUserSessions revokes all signed in sessions of given user.
+func DeleteUserSessions(uid int64) error {
+	_, err := orm.Where("uid=?", uid).Delete(new(UserSession))
+	return err
+}
+
+// DeleteExpiredUserSessions deletes sessions that have not been seen
+// within session life time.
+func DeleteExpiredUserSessions() {
+	if _, err := orm.Where("updated<?", userSessionExpireTime()).Delete(new(UserSession)); err != nil {
+		log.Error("DeleteExpiredUserSessions: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gogits/gogs/modules/setting"
+)
+
+func TestUserSessions(t *testing.T) {
+	defer prepareTestEnv(t)()
+	defer func(lifeTime int64) { setting.SessionConfig.SessionLifeTime = lifeTime }(setting.SessionConfig.SessionLifeTime)
+	setting.SessionConfig.SessionLifeTime = 3600
+
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	for _, s := range []struct {
+		uid int64
+		sid string
+	}{{u1.Id, "sid1"}, {u1.Id, "sid2"}, {u2.Id, "sid3"}} {
+		if err := NewUserSession(s.uid, s.sid, "127.0.0.1", "test"); err != nil {
+			t.Fatalf("NewUserSession: %v", err)
+		}
+	}
+
+	sessions, err := GetUserSessions(u1.Id, "sid1")
+	if err != nil {
+		t.Fatalf("GetUserSessions: %v", err)
+	} else if len(sessions) != 2 {
+		t.Fatalf("GetUserSessions returns %d sessions, expected 2", len(sessions))
+	}
+	var other *UserSession
+	for _, s := range sessions {
+		if !s.IsCurrent {
+			other = s
+		}
+	}
+	if other == nil {
+		t.Fatal("all sessions are marked as current")
+	}
+
+	if err = DeleteUserSession(u2.Id, other.Id); err != ErrUserSessionNotExist {
+		t.Errorf("DeleteUserSession(other user) error = %v, expected %v", err, ErrUserSessionNotExist)
+	}
+	if err = DeleteUserSession(u1.Id, other.Id); err != nil {
+		t.Fatalf("DeleteUserSession: %v", err)
+	}
+	if _, err = GetUserSession("sid2"); err != ErrUserSessionNotExist {
+		t.Errorf("GetUserSession(revoked) error = %v, expected %v", err, ErrUserSessionNotExist)
+	}
+
+	if err = DeleteUserSessions(0); err != nil {
+		t.Fatalf("DeleteUserSessions(zero ID): %v", err)
+	}
+	if err = DeleteUserSessions(u1.Id); err != nil {
+		t.Fatalf("DeleteUserSessions: %v", err)
+	}
+	if _, err = GetUserSession("sid1"); err != ErrUserSessionNotExist {
+		t.Errorf("GetUserSession(revoked) error = %v, expected %v", err, ErrUserSessionNotExist)
+	}
+
+	s, err := GetUserSession("sid3")
+	if err != nil {
+		t.Fatalf("GetUserSession(other user): %v", err)
+	}
+	s.Updated = time.Now().Add(-2 * time.Hour)
+	if _, err = orm.Id(s.Id).Cols("updated").Update(s); err != nil {
+		t.Fatalf("expire session: %v", err)
+	}
+	if _, err = GetUserSession("sid3"); err != ErrUserSessionNotExist {
+		t.Errorf("GetUserSession(expired) error = %v, expected %v", err, ErrUserSessionNotExist)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"code.google.com/p/go.crypto/bcrypt"
+
+	"github.com/gogits/gogs/modules/setting"
+)
+
+func TestValidatePassword(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("gogs-passwd"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Users have zero ID, so that matched password is never re-encoded.
+	tests := []struct {
+		user     *User
+		passwd   string
+		expected bool
+	}{
+		{&User{Passwd: PASSWD_SCHEME_BCRYPT + string(hash)}, "gogs-passwd", true},
+		{&User{Passwd: PASSWD_SCHEME_BCRYPT + string(hash)}, "gogs-passwd2", false},
+		{&User{Passwd: encodePbkdf2Passwd("gogs-passwd", "salt"), Salt: "salt"}, "gogs-passwd", true},
+		{&User{Passwd: encodePbkdf2Passwd("gogs-passwd", "salt"), Salt: "salt"}, "", false},
+		{&User{Passwd: encodePbkdf2Passwd("gogs-passwd", "salt"), Salt: "salt2"}, "gogs-passwd", false},
+		{&User{Passwd: PASSWD_SCHEME_BCRYPT + string(hash), Type: UT_BOT}, "gogs-passwd", false},
+		{&User{}, "", false},
+	}
+	for i, tt := range tests {
+		if ok := tt.user.ValidatePassword(tt.passwd); ok != tt.expected {
+			t.Errorf("#%d: ValidatePassword(%q) = %v, expected %v", i, tt.passwd, ok, tt.expected)
+		}
+	}
+}
+
+func TestValidatePasswordRehash(t *testing.T) {
+	defer prepareTestEnv(t)()
+	defer func(cost int) { setting.BcryptCost = cost }(setting.BcryptCost)
+	setting.BcryptCost = bcrypt.MinCost
+
+	u := newTestUser(t, "user1")
+	u.Salt = "salt"
+	u.Passwd = encodePbkdf2Passwd("gogs-passwd", u.Salt)
+	if err := UpdateUser(u); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+
+	if u.ValidatePassword("gogs-passwd2") {
+		t.Fatal("ValidatePassword(wrong password) = true, expected false")
+	}
+	if u, err := GetUserById(u.Id); err != nil {
+		t.Fatalf("GetUserById: %v", err)
+	} else if strings.HasPrefix(u.Passwd, PASSWD_SCHEME_BCRYPT) {
+		t.Error("password is re-encoded by wrong password")
+	}
+
+	if !u.ValidatePassword("gogs-passwd") {
+		t.Fatal("ValidatePassword = false, expected true")
+	}
+	u, err := GetUserById(u.Id)
+	if err != nil {
+		t.Fatalf("GetUserById: %v", err)
+	} else if !strings.HasPrefix(u.Passwd, PASSWD_SCHEME_BCRYPT) {
+		t.Errorf("password is not re-encoded by bcrypt: %q", u.Passwd)
+	} else if !u.ValidatePassword("gogs-passwd") {
+		t.Error("ValidatePassword(re-encoded) = false, expected true")
+	}
+}
+
+func TestSuspendUser(t *testing.T) {
+	defer prepareTestEnv(t)()
+	defer func(lifeTime int64) { setting.SessionConfig.SessionLifeTime = lifeTime }(setting.SessionConfig.SessionLifeTime)
+	setting.SessionConfig.SessionLifeTime = 3600
+
+	u1, u2 := newTestUser(t, "user1"), newTestUser(t, "user2")
+	for i, u := range []*User{u1, u2} {
+		if err := NewUserSession(u.Id, fmt.Sprint("sid", i), "127.0.0.1", "test"); err != nil {
+			t.Fatalf("NewUserSession: %v", err)
+		}
+	}
+
+	tests := []struct {
+		suspended bool
+		sessions  int // Remaining sessions of user1.
+	}{
+		{true, 0},
+		{false, 0},
+	}
+	for _, tt := range tests {
+		if err := SuspendUser(u1, tt.suspended); err != nil {
+			t.Fatalf("SuspendUser(%v): %v", tt.suspended, err)
+		}
+		u, err := GetUserById(u1.Id)
+		if err != nil {
+			t.Fatalf("GetUserById: %v", err)
+		} else if u.IsSuspended != tt.suspended {
+			t.Errorf("SuspendUser(%v): user is suspended %v", tt.suspended, u.IsSuspended)
+		}
+		if sessions, err := GetUserSessions(u1.Id, ""); err != nil {
+			t.Fatalf("GetUserSessions: %v", err)
+		} else if len(sessions) != tt.sessions {
+			t.Errorf("SuspendUser(%v): user has %d sessions, expected %d", tt.suspended, len(sessions), tt.sessions)
+		}
+	}
+
+	// Sessions of other users are kept.
+	if sessions, err := GetUserSessions(u2.Id, ""); err != nil || len(sessions) != 1 {
+		t.Errorf("other user has (%d sessions, %v), expected 1", len(sessions), err)
+	}
+}
+
+func TestSearchUsers(t *testing.T) {
+	defer prepareTestEnv(t)()
+	gopher, alice := newTestUser(t, "gopher"), newTestUser(t, "alice")
+	bot, suspended := newTestUser(t, "gopherbot"), newTestUser(t, "gopher2")
+	alice.FullName = "Alice Gopher"
+	bot.Type = UT_BOT
+	for _, u := range []*User{alice, bot} {
+		if err := UpdateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SuspendUser(suspended, true); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		keyword        string
+		page, pageSize int
+		expected       []string
+		total          int64
+	}{
+		{"Gopher", 1, 10, []string{alice.Name, gopher.Name}, 2},
+		{"gopher", 2, 1, []string{gopher.Name}, 2},
+		{" ", 1, 1, []string{alice.Name}, 2},
+		{"none", 1, 10, []string{}, 0},
+	}
+	for _, tt := range tests {
+		us, total, err := SearchUsers(&SearchUserOptions{Keyword: tt.keyword, Page: tt.page, PageSize: tt.pageSize})
+		if err != nil {
+			t.Fatalf("SearchUsers(%q): %v", tt.keyword, err)
+		}
+		names := make([]string, len(us))
+		for i := range us {
+			names[i] = us[i].Name
+		}
+		if strings.Join(names, "|") != strings.Join(tt.expected, "|") || total != tt.total {
+			t.Errorf("SearchUsers(%q, page %d) = (%v, %d), expected (%v, %d)", tt.keyword, tt.page, names, total, tt.expected, tt.total)
+		}
+	}
+
+	// Keyword is passed as an argument instead of being part of SQL.
+	if us, err := SearchUserByName("' OR '1'='1", 10); err != nil || len(us) != 0 {
+		t.Errorf("SearchUserByName(injection) = (%d users, %v), expected nothing", len(us), err)
+	}
+}
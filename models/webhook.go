@@ -65,7 +65,7 @@ func CreateWebhook(w *Webhook) error {
 
 // UpdateWebhook updates information of webhook.
 func UpdateWebhook(w *Webhook) error {
-	_, err := orm.AllCols().Update(w)
+	_, err := orm.Id(w.Id).AllCols().Update(w)
 	return err
 }
 
@@ -93,8 +93,11 @@ func GetWebhooksByRepoId(repoId int64) (ws []*Webhook, err error) {
 	return ws, err
 }
 
-// DeleteWebhook deletes webhook of repository.
+// DeleteWebhook deletes webhook of repository and its deliveries.
 func DeleteWebhook(hookId int64) error {
-	_, err := orm.Delete(&Webhook{Id: hookId})
+	if _, err := orm.Id(hookId).Delete(new(Webhook)); err != nil {
+		return err
+	}
+	_, err := orm.Where("hook_id=?", hookId).Delete(new(HookDelivery))
 	return err
 }
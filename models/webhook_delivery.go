@@ -0,0 +1,166 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gogits/gogs/modules/hooks"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/setting"
+)
+
+var (
+	ErrHookDeliveryNotExist = errors.New("Webhook delivery does not exist")
+)
+
+// MAX_HOOK_DELIVERIES is number of recent deliveries that are kept for each webhook.
+const MAX_HOOK_DELIVERIES = 50
+
+// HookDelivery represents a delivery of payload to webhook and its response.
+type HookDelivery struct {
+	Id              int64
+	HookId          int64 `xorm:"INDEX"`
+	Event           string
+	PayloadContent  string `xorm:"TEXT"`
+	IsDelivered     bool   // Set when request has finished, successful or not.
+	IsSucceed       bool
+	ResponseStatus  int
+	ResponseContent string    `xorm:"TEXT"`
+	Duration        int64     // In milliseconds.
+	Created         time.Time `xorm:"CREATED"`
+}
+
+// deliverHookPayload records a delivery of encoded payload to webhook and queues it,
+// result of the delivery is saved when it is done.
+func deliverHookPayload(w *Webhook, event string, data []byte) (*HookDelivery, error) {
+	d := &HookDelivery{
+		HookId:         w.Id,
+		Event:          event,
+		PayloadContent: string(data),
+	}
+	if _, err := orm.Insert(d); err != nil {
+		return nil, err
+	}
+
+	// Only keep recent deliveries.
+	old := make([]*HookDelivery, 0, 1)
+	if err := orm.Where("hook_id=?", w.Id).Desc("id").Limit(1, MAX_HOOK_DELIVERIES).
+		Cols("id").Find(&old); err != nil {
+		return nil, err
+	} else if len(old) > 0 {
+		if _, err = orm.Where("hook_id=? AND id<=?", w.Id, old[0].Id).Delete(new(HookDelivery)); err != nil {
+			return nil, err
+		}
+	}
+
+	hooks.AddHookTask(&hooks.HookTask{
+		Type:        hooks.HTT_WEBHOOK,
+		Url:         w.Url,
+		ContentType: w.ContentType,
+		IsSsl:       w.IsSsl,
+		Data:        data,
+		OnDelivered: func(result *hooks.Delivery) {
+			d.IsDelivered = true
+			d.IsSucceed = result.Err == nil && result.StatusCode >= 200 && result.StatusCode < 300
+			d.ResponseStatus = result.StatusCode
+			d.ResponseContent = result.Response
+			if result.Err != nil {
+				d.ResponseContent = result.Err.Error()
+			}
+			d.Duration = int64(result.Duration / time.Millisecond)
+			if _, err := orm.Id(d.Id).AllCols().Update(d); err != nil {
+				log.Error("webhook.deliverHookPayload(update delivery %d): %v", d.Id, err)
+			}
+		},
+	})
+	return d, nil
+}
+
+// deliverWebhook delivers payload of event to webhook with its secret.
+func deliverWebhook(w *Webhook, event string, p *hooks.Payload) (*HookDelivery, error) {
+	p.Secret = w.Secret
+	data, err := json.MarshalIndent(p, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return deliverHookPayload(w, event, data)
+}
+
+// TestWebhook delivers a push event of latest commit of default branch to webhook.
+func TestWebhook(repo *Repository, w *Webhook) (*HookDelivery, error) {
+	if err := repo.GetOwner(); err != nil {
+		return nil, err
+	}
+	repoPath := RepoPath(repo.Owner.Name, repo.Name)
+	stdout, err := execGitCmd(repoPath, nil, nil, "log", "-1", "--format=%H%x00%an%x00%ae%x00%B",
+		"refs/heads/"+repo.DefaultBranch, "--")
+	if err != nil {
+		return nil, ErrBranchNotExist
+	}
+	infos := strings.SplitN(stdout, "\x00", 4)
+	if len(infos) != 4 {
+		return nil, fmt.Errorf("unexpected output of git log: %s", stdout)
+	}
+
+	repoLink := fmt.Sprintf("%s%s/%s", setting.AppUrl, repo.Owner.Name, repo.Name)
+	return deliverWebhook(w, "push", &hooks.Payload{
+		Ref: "refs/heads/" + repo.DefaultBranch,
+		Commits: []*hooks.PayloadCommit{{
+			Id:      infos[0],
+			Message: infos[3],
+			Url:     fmt.Sprintf("%s/commit/%s", repoLink, infos[0]),
+			Author: &hooks.PayloadAuthor{
+				Name:  infos[1],
+				Email: infos[2],
+			},
+		}},
+		Repo: &hooks.PayloadRepo{
+			Id:          repo.Id,
+			Name:        repo.LowerName,
+			Url:         repoLink,
+			Description: repo.Description,
+			Website:     repo.Website,
+			Watchers:    repo.NumWatches,
+			Owner: &hooks.PayloadAuthor{
+				Name:  repo.Owner.Name,
+				Email: repo.Owner.Email,
+			},
+			Private: repo.IsPrivate,
+		},
+		Pusher: &hooks.PayloadAuthor{
+			Name:  repo.Owner.LowerName,
+			Email: repo.Owner.Email,
+		},
+	})
+}
+
+// RedeliverHookDelivery delivers payload of given delivery again to current URL of webhook.
+func RedeliverHookDelivery(w *Webhook, d *HookDelivery) (*HookDelivery, error) {
+	return deliverHookPayload(w, d.Event, []byte(d.PayloadContent))
+}
+
+// GetHookDeliveries returns recent deliveries of webhook, newest first.
+func GetHookDeliveries(hookId int64) ([]*HookDelivery, error) {
+	ds := make([]*HookDelivery, 0, 10)
+	err := orm.Where("hook_id=?", hookId).Desc("id").Find(&ds)
+	return ds, err
+}
+
+// GetHookDeliveryById returns delivery of webhook by given ID.
+func GetHookDeliveryById(hookId, id int64) (*HookDelivery, error) {
+	d := new(HookDelivery)
+	has, err := orm.Where("id=? AND hook_id=?", id, hookId).Get(d)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrHookDeliveryNotExist
+	}
+	return d, nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitHookDelivery waits for delivery to finish and returns its saved result.
+func waitHookDelivery(t *testing.T, d *HookDelivery) *HookDelivery {
+	for i := 0; i < 200; i++ {
+		saved, err := GetHookDeliveryById(d.HookId, d.Id)
+		if err != nil {
+			t.Fatalf("GetHookDeliveryById: %v", err)
+		} else if saved.IsDelivered {
+			return saved
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("delivery %d is not done", d.Id)
+	return nil
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	defer prepareTestEnv(t)()
+	u := newTestUser(t, "user1")
+	repo := newTestRepo(t, u, "repo1")
+
+	bodies := make(chan string, 10)
+	okServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		bodies <- string(data)
+		w.WriteHeader(201)
+		w.Write([]byte("created"))
+	}))
+	defer okServer.Close()
+	failServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", 500)
+	}))
+	defer failServer.Close()
+
+	w1 := &Webhook{RepoId: repo.Id, Url: okServer.URL, ContentType: CT_JSON, Secret: "s3cret", IsActive: true}
+	w2 := &Webhook{RepoId: repo.Id, Url: failServer.URL, ContentType: CT_JSON, IsActive: true}
+	for _, w := range []*Webhook{w1, w2} {
+		if err := CreateWebhook(w); err != nil {
+			t.Fatalf("CreateWebhook: %v", err)
+		}
+	}
+
+	d, err := TestWebhook(repo, w1)
+	if err != nil {
+		t.Fatalf("TestWebhook: %v", err)
+	}
+	saved := waitHookDelivery(t, d)
+	if !saved.IsSucceed || saved.ResponseStatus != 201 || saved.ResponseContent != "created" || saved.Event != "push" {
+		t.Errorf("delivery is (succeed %v, status %d, response %q, event %q), expected successful push with 201 created",
+			saved.IsSucceed, saved.ResponseStatus, saved.ResponseContent, saved.Event)
+	}
+	commitId, _ := ResolveCommitId(RepoPath(u.Name, repo.Name), "master")
+	body := <-bodies
+	if body != saved.PayloadContent || !strings.Contains(body, commitId) || !strings.Contains(body, "s3cret") {
+		t.Errorf("delivered payload %q does not have latest commit and secret", body)
+	}
+
+	d, err = TestWebhook(repo, w2)
+	if err != nil {
+		t.Fatalf("TestWebhook(failing): %v", err)
+	}
+	if saved = waitHookDelivery(t, d); saved.IsSucceed || saved.ResponseStatus != 500 {
+		t.Errorf("delivery to failing webhook is (succeed %v, status %d), expected failure with 500", saved.IsSucceed, saved.ResponseStatus)
+	}
+	for _, hookId := range []int64{0, w1.Id} {
+		if _, err = GetHookDeliveryById(hookId, d.Id); err != ErrHookDeliveryNotExist {
+			t.Errorf("GetHookDeliveryById(hook %d) error = %v, expected %v", hookId, err, ErrHookDeliveryNotExist)
+		}
+	}
+
+	// Redelivery keeps payload and only recent deliveries are kept.
+	first := d
+	for i := 0; i < MAX_HOOK_DELIVERIES; i++ {
+		if _, err = orm.Insert(&HookDelivery{HookId: w2.Id, Event: "push", IsDelivered: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d, err = RedeliverHookDelivery(w2, saved); err != nil {
+		t.Fatalf("RedeliverHookDelivery: %v", err)
+	} else if d.PayloadContent != saved.PayloadContent {
+		t.Error("redelivery has different payload")
+	}
+	waitHookDelivery(t, d)
+	ds, err := GetHookDeliveries(w2.Id)
+	if err != nil {
+		t.Fatalf("GetHookDeliveries: %v", err)
+	} else if len(ds) != MAX_HOOK_DELIVERIES || ds[0].Id != d.Id {
+		t.Errorf("GetHookDeliveries returns %d deliveries, expected %d newest first", len(ds), MAX_HOOK_DELIVERIES)
+	}
+	if _, err = GetHookDeliveryById(w2.Id, first.Id); err != ErrHookDeliveryNotExist {
+		t.Errorf("GetHookDeliveryById(oldest) error = %v, expected %v", err, ErrHookDeliveryNotExist)
+	}
+
+	// Updating or deleting a webhook does not affect others.
+	w2.Url = okServer.URL + "/new"
+	if err = UpdateWebhook(w2); err != nil {
+		t.Fatalf("UpdateWebhook: %v", err)
+	}
+	if w, err := GetWebhookById(w1.Id); err != nil {
+		t.Fatalf("GetWebhookById: %v", err)
+	} else if w.Url != okServer.URL {
+		t.Errorf("URL of other webhook is %q, expected %q", w.Url, okServer.URL)
+	}
+	if err = DeleteWebhook(0); err != nil {
+		t.Fatalf("DeleteWebhook(zero ID): %v", err)
+	} else if ws, _ := GetWebhooksByRepoId(repo.Id); len(ws) != 2 {
+		t.Errorf("DeleteWebhook(zero ID) leaves %d webhooks, expected 2", len(ws))
+	}
+	if err = DeleteWebhook(w2.Id); err != nil {
+		t.Fatalf("DeleteWebhook: %v", err)
+	} else if _, err = GetWebhookById(w2.Id); err != ErrWebhookNotExist {
+		t.Errorf("GetWebhookById(deleted) error = %v, expected %v", err, ErrWebhookNotExist)
+	}
+	if ds, _ = GetHookDeliveries(w2.Id); len(ds) != 0 {
+		t.Errorf("deleted webhook has %d deliveries, expected 0", len(ds))
+	} else if ds, _ = GetHookDeliveries(w1.Id); len(ds) != 1 {
+		t.Errorf("other webhook has %d deliveries, expected 1", len(ds))
+	}
+}
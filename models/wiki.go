@@ -0,0 +1,322 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Unknwon/com"
+
+	"github.com/gogits/git"
+
+	"github.com/gogits/gogs/modules/base"
+)
+
+var (
+	ErrWikiPageNotExist     = errors.New("Wiki page does not exist")
+	ErrWikiPageAlreadyExist = errors.New("Wiki page already exists")
+	ErrWikiPageNameIllegal  = errors.New("Wiki page name contains illegal characters")
+)
+
+const (
+	WIKI_HOME_PAGE      = "Home"
+	WIKI_DEFAULT_BRANCH = "master"
+)
+
+// wikiWorkingLock prevents concurrent changes to wiki repositories
+// from interfering with each other.
+var wikiWorkingLock sync.Mutex
+
+// WikiPage represents a page of repository wiki.
+type WikiPage struct {
+	Name    string // Name used in URL, e.g. "Getting-Started".
+	Title   string
+	Content []byte
+	Updated time.Time
+}
+
+// WikiPath returns wiki repository path by given user and repository name.
+func WikiPath(userName, repoName string) string {
+	return filepath.Join(UserPath(userName), strings.ToLower(repoName)+".wiki.git")
+}
+
+// HasWiki returns true if repository has wiki repository.
+func HasWiki(userName, repoName string) bool {
+	return com.IsDir(WikiPath(userName, repoName))
+}
+
+// WikiPageName converts given page title to the name used in URL and file name.
+func WikiPageName(title string) string {
+	return strings.Replace(strings.TrimSpace(title), " ", "-", -1)
+}
+
+// WikiPageTitle converts given page name back to human readable title.
+func WikiPageTitle(name string) string {
+	return strings.Replace(name, "-", " ", -1)
+}
+
+// IsValidWikiPageName returns false if given page name cannot be used as a file
+// or conflicts with reserved routes.
+func IsValidWikiPageName(name string) bool {
+	if len(name) == 0 || name[0] == '_' || name[0] == '.' {
+		return false
+	}
+	return !strings.ContainsAny(name, "/\\?#%")
+}
+
+func wikiFileName(name string) string {
+	return name + ".md"
+}
+
+// wikiBranch returns branch that HEAD of wiki repository points to, which may not be
+// the default one when wiki has been pushed over SSH or HTTP.
+func wikiBranch(userName, repoName string) string {
+	branch, err := execGitCmd(WikiPath(userName, repoName), nil, nil, "symbolic-ref", "--short", "HEAD")
+	if err != nil || len(branch) == 0 {
+		return WIKI_DEFAULT_BRANCH
+	}
+	return branch
+}
+
+// openWiki opens wiki repository and returns latest commit,
+// commit is nil when wiki has no page yet.
+func openWiki(userName, repoName string) (*git.Repository, *git.Commit, error) {
+	if !HasWiki(userName, repoName) {
+		return nil, nil, nil
+	}
+
+	repo, err := git.OpenRepository(WikiPath(userName, repoName))
+	if err != nil {
+		return nil, nil, err
+	}
+	branch := wikiBranch(userName, repoName)
+	if !repo.IsBranchExist(branch) {
+		return repo, nil, nil
+	}
+
+	commit, err := repo.GetCommitOfBranch(branch)
+	if err != nil {
+		return nil, nil, err
+	}
+	return repo, commit, nil
+}
+
+// GetWikiPage returns wiki page by given name.
+func GetWikiPage(userName, repoName, name string) (*WikiPage, error) {
+	if !IsValidWikiPageName(name) {
+		return nil, ErrWikiPageNotExist
+	}
+
+	_, commit, err := openWiki(userName, repoName)
+	if err != nil {
+		return nil, err
+	} else if commit == nil {
+		return nil, ErrWikiPageNotExist
+	}
+
+	blob, err := commit.GetBlobByPath(wikiFileName(name))
+	if err != nil {
+		if err == git.ErrNotExist {
+			return nil, ErrWikiPageNotExist
+		}
+		return nil, err
+	}
+	dataRc, err := blob.Data()
+	if err != nil {
+		return nil, err
+	}
+	content, err := ioutil.ReadAll(dataRc)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &WikiPage{
+		Name:    name,
+		Title:   WikiPageTitle(name),
+		Content: content,
+	}
+	if c, err := commit.GetCommitOfRelPath(wikiFileName(name)); err == nil {
+		page.Updated = c.Author.When
+	}
+	return page, nil
+}
+
+// GetWikiPages returns all pages of repository wiki without content.
+func GetWikiPages(userName, repoName string) ([]*WikiPage, error) {
+	_, commit, err := openWiki(userName, repoName)
+	if err != nil {
+		return nil, err
+	} else if commit == nil {
+		return []*WikiPage{}, nil
+	}
+
+	tree, err := commit.SubTree("")
+	if err != nil {
+		return nil, err
+	}
+	entries := tree.ListEntries()
+	entries.Sort()
+
+	pages := make([]*WikiPage, 0, len(entries))
+	for _, te := range entries {
+		if te.IsDir() || !strings.HasSuffix(te.Name(), ".md") {
+			continue
+		}
+		name := strings.TrimSuffix(te.Name(), ".md")
+		page := &WikiPage{Name: name, Title: WikiPageTitle(name)}
+		if c, err := commit.GetCommitOfRelPath(te.Name()); err == nil {
+			page.Updated = c.Author.When
+		}
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
+
+// GetWikiPageHistory returns number of commits and given page of commits of wiki page.
+func GetWikiPageHistory(userName, repoName, name string, page int) (int, *list.List, error) {
+	repo, commit, err := openWiki(userName, repoName)
+	if err != nil {
+		return 0, nil, err
+	} else if commit == nil {
+		return 0, nil, ErrWikiPageNotExist
+	}
+
+	branch := wikiBranch(userName, repoName)
+	count, err := repo.FileCommitsCount(branch, wikiFileName(name))
+	if err != nil {
+		return 0, nil, err
+	} else if count == 0 {
+		return 0, nil, ErrWikiPageNotExist
+	}
+
+	commits, err := repo.CommitsByFileAndRange(branch, wikiFileName(name), page)
+	if err != nil {
+		return 0, nil, err
+	}
+	return count, commits, nil
+}
+
+// initWiki creates bare wiki repository if it does not exist yet.
+func initWiki(userName, repoName string) error {
+	if HasWiki(userName, repoName) {
+		return nil
+	}
+	return extractGitBareZip(WikiPath(userName, repoName))
+}
+
+// commitWikiChanges commits all changes in temporary working directory and pushes to given branch of wiki repository.
+func commitWikiChanges(tmpDir, branch string, doer *User, message string) error {
+	sig := doer.NewGitSig()
+	var stderr string
+	var err error
+	if _, stderr, err = com.ExecCmdDir(tmpDir, "git", "add", "--all"); err != nil {
+		return errors.New("git add: " + stderr)
+	}
+	if _, stderr, err = com.ExecCmdDir(tmpDir, "git", "-c", "user.name="+sig.Name, "-c", "user.email="+sig.Email,
+		"commit", fmt.Sprintf("--author=%s <%s>", sig.Name, sig.Email), "-m", message); err != nil {
+		return errors.New("git commit: " + stderr)
+	}
+	if _, stderr, err = com.ExecCmdDir(tmpDir, "git", "push", "origin", "HEAD:refs/heads/"+branch); err != nil {
+		return errors.New("git push: " + stderr)
+	}
+	return nil
+}
+
+// updateWikiPage adds or edits a wiki page through a temporary clone of wiki repository.
+func updateWikiPage(doer *User, userName, repoName, oldName, name, content, message string, isNew bool) (err error) {
+	if !IsValidWikiPageName(name) {
+		return ErrWikiPageNameIllegal
+	} else if !isNew && !IsValidWikiPageName(oldName) {
+		// Old name comes from URL, it must not point outside of wiki.
+		return ErrWikiPageNotExist
+	}
+
+	wikiWorkingLock.Lock()
+	defer wikiWorkingLock.Unlock()
+
+	if err = initWiki(userName, repoName); err != nil {
+		return fmt.Errorf("initWiki: %v", err)
+	}
+
+	tmpDir := filepath.Join(os.TempDir(), "gogs-wiki-"+base.ToStr(time.Now().UnixNano()))
+	defer os.RemoveAll(tmpDir)
+
+	if _, stderr, err := com.ExecCmd("git", "clone", WikiPath(userName, repoName), tmpDir); err != nil {
+		return errors.New("git clone: " + stderr)
+	}
+
+	filePath := filepath.Join(tmpDir, wikiFileName(name))
+	if isNew || oldName != name {
+		if com.IsExist(filePath) {
+			return ErrWikiPageAlreadyExist
+		}
+	}
+	if !isNew {
+		oldPath := filepath.Join(tmpDir, wikiFileName(oldName))
+		if !com.IsFile(oldPath) {
+			return ErrWikiPageNotExist
+		} else if oldName != name {
+			if err = os.Remove(oldPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = ioutil.WriteFile(filePath, []byte(content), 0644); err != nil {
+		return err
+	}
+
+	if len(message) == 0 {
+		if isNew {
+			message = "Create page '" + WikiPageTitle(name) + "'"
+		} else {
+			message = "Update page '" + WikiPageTitle(name) + "'"
+		}
+	}
+	return commitWikiChanges(tmpDir, wikiBranch(userName, repoName), doer, message)
+}
+
+// AddWikiPage creates new wiki page and initializes wiki repository if needed.
+func AddWikiPage(doer *User, userName, repoName, name, content, message string) error {
+	return updateWikiPage(doer, userName, repoName, "", name, content, message, true)
+}
+
+// EditWikiPage updates content of wiki page, page is renamed when name changes.
+func EditWikiPage(doer *User, userName, repoName, oldName, name, content, message string) error {
+	return updateWikiPage(doer, userName, repoName, oldName, name, content, message, false)
+}
+
+// DeleteWikiPage deletes wiki page by given name.
+func DeleteWikiPage(doer *User, userName, repoName, name string) error {
+	wikiWorkingLock.Lock()
+	defer wikiWorkingLock.Unlock()
+
+	if !HasWiki(userName, repoName) {
+		return ErrWikiPageNotExist
+	}
+
+	tmpDir := filepath.Join(os.TempDir(), "gogs-wiki-"+base.ToStr(time.Now().UnixNano()))
+	defer os.RemoveAll(tmpDir)
+
+	if _, stderr, err := com.ExecCmd("git", "clone", WikiPath(userName, repoName), tmpDir); err != nil {
+		return errors.New("git clone: " + stderr)
+	}
+
+	filePath := filepath.Join(tmpDir, wikiFileName(name))
+	if !IsValidWikiPageName(name) || !com.IsFile(filePath) {
+		return ErrWikiPageNotExist
+	} else if err := os.Remove(filePath); err != nil {
+		return err
+	}
+	return commitWikiChanges(tmpDir, wikiBranch(userName, repoName), doer, "Delete page '"+WikiPageTitle(name)+"'")
+}
@@ -0,0 +1,68 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/gogits/gogs/modules/setting"
+)
+
+var isValidWikiPageNameTests = []struct {
+	name     string
+	expected bool
+}{
+	{"Home", true},
+	{"Getting-Started", true},
+	{"", false},
+	{"_pages", false},
+	{".git", false},
+	{"..", false},
+	{"../Home", false},
+	{"docs/Home", false},
+	{"docs\\Home", false},
+	{"Home?raw", false},
+	{"Home%2F", false},
+}
+
+func TestIsValidWikiPageName(t *testing.T) {
+	for _, tt := range isValidWikiPageNameTests {
+		if ok := IsValidWikiPageName(tt.name); ok != tt.expected {
+			t.Errorf("IsValidWikiPageName(%q) = %v, expected %v", tt.name, ok, tt.expected)
+		}
+	}
+}
+
+func TestWikiBranch(t *testing.T) {
+	root, err := ioutil.TempDir("", "gogs-wiki-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	oldRoot := setting.RepoRootPath
+	setting.RepoRootPath = root
+	defer func() { setting.RepoRootPath = oldRoot }()
+
+	// Wiki that does not exist falls back to default branch.
+	if branch := wikiBranch("user", "repo"); branch != WIKI_DEFAULT_BRANCH {
+		t.Errorf("wikiBranch of missing wiki = %q, expected %q", branch, WIKI_DEFAULT_BRANCH)
+	}
+
+	wikiPath := WikiPath("user", "repo")
+	if err = os.MkdirAll(wikiPath, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = execGitCmd(wikiPath, nil, nil, "init", "--bare"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = execGitCmd(wikiPath, nil, nil, "symbolic-ref", "HEAD", "refs/heads/pages"); err != nil {
+		t.Fatal(err)
+	}
+	if branch := wikiBranch("user", "repo"); branch != "pages" {
+		t.Errorf("wikiBranch = %q, expected %q", branch, "pages")
+	}
+}
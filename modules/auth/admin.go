@@ -22,6 +22,7 @@ type AdminEditUserForm struct {
 	Active    bool   `form:"active"`
 	Admin     bool   `form:"admin"`
 	LoginType int    `form:"login_type"`
+	DiskQuota int64  `form:"disk_quota"`
 }
 
 func (f *AdminEditUserForm) Name(field string) string {
@@ -38,3 +39,38 @@ func (f *AdminEditUserForm) Validate(errors *binding.Errors, req *http.Request,
 	data := context.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
 	validate(errors, data, f)
 }
+
+type NewBotForm struct {
+	UserName string `form:"username" binding:"Required;AlphaDashDot;MaxSize(30)"`
+	Email    string `form:"email" binding:"Required;Email;MaxSize(50)"`
+}
+
+func (f *NewBotForm) Name(field string) string {
+	names := map[string]string{
+		"UserName": "Username",
+		"Email":    "E-mail address",
+	}
+	return names[field]
+}
+
+func (f *NewBotForm) Validate(errors *binding.Errors, req *http.Request, context martini.Context) {
+	data := context.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validate(errors, data, f)
+}
+
+type EmailDomainForm struct {
+	Domain    string `form:"domain" binding:"Required;MaxSize(255)"`
+	IsAllowed bool   `form:"is_allowed"`
+}
+
+func (f *EmailDomainForm) Name(field string) string {
+	names := map[string]string{
+		"Domain": "E-mail domain",
+	}
+	return names[field]
+}
+
+func (f *EmailDomainForm) Validate(errors *binding.Errors, req *http.Request, context martini.Context) {
+	data := context.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validate(errors, data, f)
+}
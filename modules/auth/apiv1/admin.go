@@ -0,0 +1,27 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package apiv1
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/middleware/binding"
+)
+
+type CreateUserForm struct {
+	UserName string `form:"username" json:"username" binding:"Required;AlphaDashDot;MaxSize(30)"`
+	Email    string `form:"email" json:"email" binding:"Required;Email;MaxSize(50)"`
+	Password string `form:"password" json:"password" binding:"Required;MaxSize(30)"`
+	FullName string `form:"full_name" json:"full_name" binding:"MaxSize(40)"`
+}
+
+func (f *CreateUserForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
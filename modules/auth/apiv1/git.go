@@ -0,0 +1,52 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package apiv1
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/middleware/binding"
+)
+
+type CreateRefForm struct {
+	Ref string `form:"ref" json:"ref" binding:"Required"`
+	Sha string `form:"sha" json:"sha" binding:"Required"`
+}
+
+func (f *CreateRefForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
+
+// UpdateFileForm creates or updates a file, content is encoded in Base64 and
+// sha of current blob must be given when file exists.
+type UpdateFileForm struct {
+	Message   string `form:"message" json:"message"`
+	Content   string `form:"content" json:"content"`
+	Sha       string `form:"sha" json:"sha"`
+	Branch    string `form:"branch" json:"branch"`
+	NewBranch string `form:"new_branch" json:"new_branch"`
+}
+
+func (f *UpdateFileForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
+
+type DeleteFileForm struct {
+	Message   string `form:"message" json:"message"`
+	Sha       string `form:"sha" json:"sha" binding:"Required"`
+	Branch    string `form:"branch" json:"branch"`
+	NewBranch string `form:"new_branch" json:"new_branch"`
+}
+
+func (f *DeleteFileForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
@@ -0,0 +1,42 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package apiv1
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/middleware/binding"
+)
+
+type CreateHookForm struct {
+	Url         string `form:"url" json:"url" binding:"Required;Url"`
+	ContentType string `form:"content_type" json:"content_type"`
+	Secret      string `form:"secret" json:"secret"`
+	PushOnly    bool   `form:"push_only" json:"push_only"`
+	Active      bool   `form:"active" json:"active"`
+}
+
+func (f *CreateHookForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
+
+// EditHookForm is given as JSON, fields that are not given keep their current values.
+type EditHookForm struct {
+	Url         *string `json:"url"`
+	ContentType *string `json:"content_type"`
+	Secret      *string `json:"secret"`
+	PushOnly    *bool   `json:"push_only"`
+	Active      *bool   `json:"active"`
+}
+
+func (f *EditHookForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
@@ -0,0 +1,90 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package apiv1
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/middleware/binding"
+)
+
+type CreateIssueForm struct {
+	Title     string   `form:"title" json:"title" binding:"Required;MaxSize(255)"`
+	Body      string   `form:"body" json:"body"`
+	Assignee  string   `form:"assignee" json:"assignee"`
+	Milestone int64    `form:"milestone" json:"milestone"`
+	Labels    []string `form:"labels" json:"labels"`
+	DueDate   string   `form:"due_date" json:"due_date"`
+}
+
+func (f *CreateIssueForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
+
+// EditIssueForm is given as JSON, fields that are not given keep their current values.
+// Labels and assignees replace current ones, zero milestone removes the milestone.
+type EditIssueForm struct {
+	Title     *string   `json:"title"`
+	Body      *string   `json:"body"`
+	State     *string   `json:"state"`
+	Assignees *[]string `json:"assignees"`
+	Milestone *int64    `json:"milestone"`
+	Labels    *[]string `json:"labels"`
+	DueDate   *string   `json:"due_date"`
+}
+
+func (f *EditIssueForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
+
+type CreateCommentForm struct {
+	Body string `form:"body" json:"body" binding:"Required"`
+}
+
+func (f *CreateCommentForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
+
+type CreateLabelForm struct {
+	Name        string `form:"name" json:"name" binding:"Required;MaxSize(50)"`
+	Color       string `form:"color" json:"color" binding:"Required"`
+	Description string `form:"description" json:"description" binding:"MaxSize(255)"`
+}
+
+func (f *CreateLabelForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
+
+type CreateMilestoneForm struct {
+	Title       string `form:"title" json:"title" binding:"Required;MaxSize(50)"`
+	Description string `form:"description" json:"description"`
+	DueDate     string `form:"due_date" json:"due_date"`
+}
+
+func (f *CreateMilestoneForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
+
+// EditMilestoneForm is given as JSON, fields that are not given keep their current values.
+type EditMilestoneForm struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	State       *string `json:"state"`
+	DueDate     *string `json:"due_date"`
+}
+
+func (f *EditMilestoneForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
@@ -17,9 +17,9 @@ import (
 )
 
 type MarkdownForm struct {
-	Text    string `form:"text" binding:"Required"`
-	Mode    string `form:"mode"`
-	Context string `form:"context"`
+	Text    string `form:"text" json:"text" binding:"Required"`
+	Mode    string `form:"mode" json:"mode"`
+	Context string `form:"context" json:"context"`
 }
 
 func (f *MarkdownForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
@@ -0,0 +1,47 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package apiv1
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/middleware/binding"
+)
+
+// CreateReleaseForm creates a release, tag is created from target commitish,
+// which is default branch by default, unless release is a draft or tag exists.
+type CreateReleaseForm struct {
+	TagName         string `form:"tag_name" json:"tag_name" binding:"Required"`
+	TargetCommitish string `form:"target_commitish" json:"target_commitish"`
+	Name            string `form:"name" json:"name" binding:"Required"`
+	Body            string `form:"body" json:"body"`
+	Draft           bool   `form:"draft" json:"draft"`
+	Prerelease      bool   `form:"prerelease" json:"prerelease"`
+}
+
+func (f *CreateReleaseForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
+
+// EditReleaseForm is given as JSON, fields that are not given keep their current values.
+// Tag name and target commitish can only be changed before release is published.
+type EditReleaseForm struct {
+	TagName         *string `json:"tag_name"`
+	TargetCommitish *string `json:"target_commitish"`
+	Name            *string `json:"name"`
+	Body            *string `json:"body"`
+	Draft           *bool   `json:"draft"`
+	Prerelease      *bool   `json:"prerelease"`
+}
+
+func (f *EditReleaseForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
@@ -0,0 +1,76 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package apiv1
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/middleware/binding"
+)
+
+type CreateCommitStatusForm struct {
+	State       string `form:"state" binding:"Required"`
+	TargetUrl   string `form:"target_url" binding:"Url;MaxSize(255)"`
+	Description string `form:"description" binding:"MaxSize(255)"`
+	Context     string `form:"context" binding:"MaxSize(255)"`
+}
+
+func (f *CreateCommitStatusForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
+
+type AddCollaboratorForm struct {
+	Permission string `form:"permission"`
+}
+
+func (f *AddCollaboratorForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
+
+type CreateRepoForm struct {
+	Name        string `form:"name" json:"name" binding:"Required;AlphaDashDot;MaxSize(100)"`
+	Description string `form:"description" json:"description" binding:"MaxSize(255)"`
+	Private     bool   `form:"private" json:"private"`
+	AutoInit    bool   `form:"auto_init" json:"auto_init"`
+	Gitignore   string `form:"gitignore" json:"gitignore"`
+	License     string `form:"license" json:"license"`
+}
+
+func (f *CreateRepoForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
+
+// EditRepoForm is given as JSON, fields that are not given keep their current values.
+type EditRepoForm struct {
+	Name          *string `json:"name"`
+	Description   *string `json:"description"`
+	Website       *string `json:"website"`
+	Private       *bool   `json:"private"`
+	DefaultBranch *string `json:"default_branch"`
+	Archived      *bool   `json:"archived"`
+}
+
+func (f *EditRepoForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
+
+type AddDeployKeyForm struct {
+	Title      string `form:"title" json:"title" binding:"Required;MaxSize(50)"`
+	Key        string `form:"key" json:"key" binding:"Required"`
+	IsWritable bool   `form:"is_writable" json:"is_writable"`
+}
+
+func (f *AddDeployKeyForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
@@ -0,0 +1,39 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package apiv1
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/middleware/binding"
+)
+
+// EditUserForm is given as JSON, fields that are not given keep their current values.
+type EditUserForm struct {
+	FullName *string `json:"full_name"`
+	Email    *string `json:"email"`
+	Website  *string `json:"website"`
+	Location *string `json:"location"`
+}
+
+func (f *EditUserForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
+
+type AddPublicKeyForm struct {
+	Title   string `form:"title" json:"title" binding:"Required"`
+	Key     string `form:"key" json:"key" binding:"Required"`
+	Expires string `form:"expires" json:"expires"`
+}
+
+func (f *AddPublicKeyForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validateApiReq(errs, data, f)
+}
@@ -11,11 +11,15 @@ import (
 
 	"github.com/go-martini/martini"
 
+	"github.com/gogits/gogs/models"
 	"github.com/gogits/gogs/modules/base"
 	"github.com/gogits/gogs/modules/log"
 	"github.com/gogits/gogs/modules/middleware/binding"
 )
 
+// ERR_EMAIL_DOMAIN is the error of e-mail address that is not allowed to sign up.
+const ERR_EMAIL_DOMAIN = "EmailDomainNotAllowed"
+
 // Web form interface.
 type Form interface {
 	Name(field string) string
@@ -42,6 +46,14 @@ func (f *RegisterForm) Name(field string) string {
 
 func (f *RegisterForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
 	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	// Password of user from external login source is not used.
+	if len(f.LoginType) == 0 || strings.HasPrefix(f.LoginType, "0-") {
+		validatePasswd(errs, "Password", f.Password)
+	}
+	// Accounts created by admin are not restricted by e-mail domain rules.
+	if !strings.HasPrefix(req.URL.Path, "/admin/") {
+		validateEmailDomain(errs, "Email", f.Email)
+	}
 	validate(errs, data, f)
 }
 
@@ -64,6 +76,21 @@ func (f *LogInForm) Validate(errs *binding.Errors, req *http.Request, ctx martin
 	validate(errs, data, f)
 }
 
+// validateEmailDomain checks e-mail field against registration rules of e-mail domain
+// unless it has already failed basic validation.
+func validateEmailDomain(errs *binding.Errors, field, email string) {
+	if _, ok := errs.Fields[field]; ok {
+		return
+	}
+	isAllowed, err := models.IsEmailDomainAllowed(email)
+	if err != nil {
+		log.Error("auth.validateEmailDomain(IsEmailDomainAllowed): %v", err)
+		return
+	} else if !isAllowed {
+		errs.Fields[field] = ERR_EMAIL_DOMAIN
+	}
+}
+
 func GetMinMaxSize(field reflect.StructField) string {
 	for _, rule := range strings.Split(field.Tag.Get("binding"), ";") {
 		if strings.HasPrefix(rule, "MinSize(") || strings.HasPrefix(rule, "MaxSize(") {
@@ -120,6 +147,10 @@ func validate(errs *binding.Errors, data base.TmplData, f Form) {
 				data["ErrorMsg"] = f.Name(field.Name) + " is not a valid e-mail address"
 			case binding.BindingUrlError:
 				data["ErrorMsg"] = f.Name(field.Name) + " is not a valid URL"
+			case ERR_EMAIL_DOMAIN:
+				data["ErrorMsg"] = f.Name(field.Name) + " is not allowed to sign up"
+			case ERR_PASSWD_TOO_SHORT, ERR_PASSWD_COMPLEXITY, ERR_PASSWD_COMMON:
+				data["ErrorMsg"] = PasswdPolicyErrorMsg(f.Name(field.Name), err)
 			default:
 				data["ErrorMsg"] = "Unknown error: " + err
 			}
@@ -22,7 +22,11 @@ type AuthenticationForm struct {
 	Host              string `form:"host"`
 	Port              int    `form:"port"`
 	UseSSL            bool   `form:"usessl"`
+	BindDN            string `form:"bind_dn"`
+	BindPassword      string `form:"bind_password"`
 	BaseDN            string `form:"base_dn"`
+	AttributeUsername string `form:"attribute_username"`
+	AttributeName     string `form:"attribute_name"`
 	Attributes        string `form:"attributes"`
 	Filter            string `form:"filter"`
 	MsAdSA            string `form:"ms_ad_sa"`
@@ -31,20 +35,26 @@ type AuthenticationForm struct {
 	SmtpHost          string `form:"smtphost"`
 	SmtpPort          int    `form:"smtpport"`
 	Tls               bool   `form:"tls"`
+	AllowedDomains    string `form:"allowed_domains"`
+	PAMServiceName    string `form:"pam_service_name"`
 	AllowAutoRegister bool   `form:"allowautoregister"`
 }
 
 func (f *AuthenticationForm) Name(field string) string {
 	names := map[string]string{
-		"AuthName":   "Authentication's name",
-		"Domain":     "Domain name",
-		"Host":       "Host address",
-		"Port":       "Port Number",
-		"UseSSL":     "Use SSL",
-		"BaseDN":     "Base DN",
-		"Attributes": "Search attributes",
-		"Filter":     "Search filter",
-		"MsAdSA":     "Ms Ad SA",
+		"AuthName":          "Authentication's name",
+		"Domain":            "Domain name",
+		"Host":              "Host address",
+		"Port":              "Port Number",
+		"UseSSL":            "Use SSL",
+		"BindDN":            "Bind DN",
+		"BindPassword":      "Bind password",
+		"BaseDN":            "Base DN",
+		"AttributeUsername": "Username attribute",
+		"AttributeName":     "Full name attribute",
+		"Attributes":        "E-mail attribute",
+		"Filter":            "Search filter",
+		"MsAdSA":            "Ms Ad SA",
 	}
 	return names[field]
 }
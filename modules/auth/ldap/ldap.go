@@ -15,15 +15,19 @@ import (
 
 // Basic LDAP authentication service
 type Ldapsource struct {
-	Name         string // canonical name (ie. corporate.ad)
-	Host         string // LDAP host
-	Port         int    // port number
-	UseSSL       bool   // Use SSL
-	BaseDN       string // Base DN
-	Attributes   string // Attribut to search
-	Filter       string // Query filter to validate entry
-	MsAdSAFormat string // in the case of MS AD Simple Authen, the format to use (see: http://msdn.microsoft.com/en-us/library/cc223499.aspx)
-	Enabled      bool   // if this source is disabled
+	Name              string // canonical name (ie. corporate.ad)
+	Host              string // LDAP host
+	Port              int    // port number
+	UseSSL            bool   // Use SSL
+	BindDN            string // DN to bind with to search user, empty to bind as user directly
+	BindPassword      string // Bind DN password
+	BaseDN            string // Base DN
+	AttributeUsername string // Username attribute, empty to use login name
+	AttributeName     string // Full name attribute
+	Attributes        string // E-mail attribute
+	Filter            string // Query filter to validate entry
+	MsAdSAFormat      string // in the case of MS AD Simple Authen, the format to use (see: http://msdn.microsoft.com/en-us/library/cc223499.aspx)
+	Enabled           bool   // if this source is disabled
 }
 
 //Global LDAP directory pool
@@ -33,7 +37,17 @@ var (
 
 // Add a new source (LDAP directory) to the global pool
 func AddSource(name string, host string, port int, usessl bool, basedn string, attributes string, filter string, msadsaformat string) {
-	ldaphost := Ldapsource{name, host, port, usessl, basedn, attributes, filter, msadsaformat, true}
+	ldaphost := Ldapsource{
+		Name:         name,
+		Host:         host,
+		Port:         port,
+		UseSSL:       usessl,
+		BaseDN:       basedn,
+		Attributes:   attributes,
+		Filter:       filter,
+		MsAdSAFormat: msadsaformat,
+		Enabled:      true,
+	}
 	Authensource = append(Authensource, ldaphost)
 }
 
@@ -43,7 +57,7 @@ func AddSource(name string, host string, port int, usessl bool, basedn string, a
 func LoginUser(name, passwd string) (a string, r bool) {
 	r = false
 	for _, ls := range Authensource {
-		a, r = ls.SearchEntry(name, passwd)
+		_, _, a, r = ls.SearchEntry(name, passwd)
 		if r {
 			return
 		}
@@ -51,40 +65,83 @@ func LoginUser(name, passwd string) (a string, r bool) {
 	return
 }
 
-// searchEntry : search an LDAP source if an entry (name, passwd) is valide and in the specific filter
-func (ls Ldapsource) SearchEntry(name, passwd string) (string, bool) {
+// SearchEntry : search an LDAP source if an entry (name, passwd) is valide and in the specific filter,
+// returns username, full name and e-mail of the entry.
+func (ls Ldapsource) SearchEntry(name, passwd string) (string, string, string, bool) {
 	l, err := ldapDial(ls)
 	if err != nil {
 		log.Error("LDAP Connect error, %s:%v", ls.Host, err)
 		ls.Enabled = false
-		return "", false
+		return "", "", "", false
 	}
 	defer l.Close()
 
-	nx := fmt.Sprintf(ls.MsAdSAFormat, name)
-	err = l.Bind(nx, passwd)
+	// With a bind DN, find the user entry first and then bind as it,
+	// otherwise bind as user directly.
+	userDN := fmt.Sprintf(ls.MsAdSAFormat, name)
+	if len(ls.BindDN) > 0 {
+		if err = l.Bind(ls.BindDN, ls.BindPassword); err != nil {
+			log.Error("LDAP Bind failed for %s, reason: %s", ls.BindDN, err.Error())
+			return "", "", "", false
+		}
+
+		sr, err := l.Search(goldap.NewSearchRequest(
+			ls.BaseDN,
+			goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 0, 0, false,
+			fmt.Sprintf(ls.Filter, EscapeFilter(name)),
+			[]string{"dn"},
+			nil))
+		if err != nil || len(sr.Entries) != 1 {
+			log.Debug("LDAP user not found or not unique: %s", name)
+			return "", "", "", false
+		}
+		userDN = sr.Entries[0].DN
+	}
+
+	err = l.Bind(userDN, passwd)
 	if err != nil {
-		log.Debug("LDAP Authan failed for %s, reason: %s", nx, err.Error())
-		return "", false
+		log.Debug("LDAP Authan failed for %s, reason: %s", userDN, err.Error())
+		return "", "", "", false
 	}
 
 	search := goldap.NewSearchRequest(
 		ls.BaseDN,
 		goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 0, 0, false,
-		fmt.Sprintf(ls.Filter, name),
-		[]string{ls.Attributes},
+		fmt.Sprintf(ls.Filter, EscapeFilter(name)),
+		[]string{ls.AttributeUsername, ls.AttributeName, ls.Attributes},
 		nil)
 	sr, err := l.Search(search)
 	if err != nil {
 		log.Debug("LDAP Authen OK but not in filter %s", name)
-		return "", false
+		return "", "", "", false
 	}
 	log.Debug("LDAP Authen OK: %s", name)
 	if len(sr.Entries) > 0 {
-		r := sr.Entries[0].GetAttributeValue(ls.Attributes)
-		return r, true
+		entry := sr.Entries[0]
+		uname := name
+		if len(ls.AttributeUsername) > 0 {
+			if v := entry.GetAttributeValue(ls.AttributeUsername); len(v) > 0 {
+				uname = v
+			}
+		}
+		return uname, entry.GetAttributeValue(ls.AttributeName), entry.GetAttributeValue(ls.Attributes), true
+	}
+	return name, "", "", true
+}
+
+// EscapeFilter escapes characters that have special meaning in LDAP search filter (RFC 4515),
+// so that login name is always matched as a literal value.
+func EscapeFilter(filter string) string {
+	buf := make([]byte, 0, len(filter))
+	for i := 0; i < len(filter); i++ {
+		switch c := filter[i]; c {
+		case '\\', '*', '(', ')', 0:
+			buf = append(buf, fmt.Sprintf("\\%02x", c)...)
+		default:
+			buf = append(buf, c)
+		}
 	}
-	return "", true
+	return string(buf)
 }
 
 func ldapDial(ls Ldapsource) (*goldap.Conn, error) {
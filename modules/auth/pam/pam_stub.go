@@ -0,0 +1,20 @@
+// +build !pam
+
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pam
+
+import (
+	"errors"
+)
+
+// Supported indicates whether PAM authentication is built in.
+const Supported = false
+
+// PAMAuth always returns an error because PAM support is not built in,
+// build with tag "pam" to enable it.
+func PAMAuth(serviceName, userName, passwd string) error {
+	return errors.New("PAM not supported")
+}
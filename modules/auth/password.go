@@ -0,0 +1,107 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gogits/gogs/modules/middleware/binding"
+	"github.com/gogits/gogs/modules/setting"
+)
+
+// Password policy errors.
+const (
+	ERR_PASSWD_TOO_SHORT  = "PasswdTooShort"
+	ERR_PASSWD_COMPLEXITY = "PasswdComplexity"
+	ERR_PASSWD_COMMON     = "PasswdCommon"
+)
+
+// passwdClasses contains character classes that can be required by password policy.
+var passwdClasses = map[string]struct {
+	desc  string
+	match func(rune) bool
+}{
+	"lower": {"lowercase letters", unicode.IsLower},
+	"upper": {"uppercase letters", unicode.IsUpper},
+	"digit": {"digits", unicode.IsDigit},
+	"spec": {"special characters", func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r)
+	}},
+}
+
+// commonPasswds is a list of passwords that are too common to be used.
+var commonPasswds = map[string]bool{
+	"123456": true, "1234567": true, "12345678": true, "123456789": true,
+	"1234567890": true, "0123456789": true, "987654321": true, "654321": true,
+	"111111": true, "000000": true, "666666": true, "888888": true,
+	"121212": true, "123123": true, "112233": true, "123321": true,
+	"password": true, "password1": true, "password123": true, "passw0rd": true,
+	"p@ssw0rd": true, "qwerty": true, "qwerty123": true, "qwertyuiop": true,
+	"asdfgh": true, "asdfghjkl": true, "zxcvbnm": true, "1q2w3e4r": true,
+	"1qaz2wsx": true, "qazwsx": true, "abc123": true, "abcdef": true,
+	"abcd1234": true, "iloveyou": true, "letmein": true, "welcome": true,
+	"welcome1": true, "monkey": true, "dragon": true, "master": true,
+	"sunshine": true, "princess": true, "football": true, "baseball": true,
+	"superman": true, "trustno1": true, "starwars": true, "shadow": true,
+	"michael": true, "jennifer": true, "whatever": true, "changeme": true,
+	"secret": true, "admin123": true, "administrator": true, "root123": true,
+	"gogsgogs": true, "github": true,
+}
+
+// CheckPasswdPolicy checks given password against password policy,
+// it returns corresponding error or empty string if it is acceptable.
+func CheckPasswdPolicy(passwd string) string {
+	if utf8.RuneCountInString(passwd) < setting.PasswdMinLength {
+		return ERR_PASSWD_TOO_SHORT
+	}
+
+	for _, class := range setting.PasswdComplexity {
+		c, ok := passwdClasses[class]
+		if !ok {
+			continue
+		}
+		if strings.IndexFunc(passwd, c.match) == -1 {
+			return ERR_PASSWD_COMPLEXITY
+		}
+	}
+
+	if setting.PasswdCheckCommon && commonPasswds[strings.ToLower(passwd)] {
+		return ERR_PASSWD_COMMON
+	}
+	return ""
+}
+
+// PasswdPolicyErrorMsg returns human readable message of password policy error.
+func PasswdPolicyErrorMsg(name, err string) string {
+	switch err {
+	case ERR_PASSWD_TOO_SHORT:
+		return fmt.Sprintf("%s must contain at least %d characters", name, setting.PasswdMinLength)
+	case ERR_PASSWD_COMPLEXITY:
+		descs := make([]string, 0, len(setting.PasswdComplexity))
+		for _, class := range setting.PasswdComplexity {
+			if c, ok := passwdClasses[class]; ok {
+				descs = append(descs, c.desc)
+			}
+		}
+		return name + " must contain " + strings.Join(descs, ", ")
+	case ERR_PASSWD_COMMON:
+		return name + " is too common, please choose another one"
+	}
+	return ""
+}
+
+// validatePasswd checks password field against password policy
+// unless it has already failed basic validation.
+func validatePasswd(errs *binding.Errors, field, passwd string) {
+	if _, ok := errs.Fields[field]; ok {
+		return
+	}
+	if err := CheckPasswdPolicy(passwd); len(err) > 0 {
+		errs.Fields[field] = err
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/gogits/gogs/modules/setting"
+)
+
+var checkPasswdPolicyTests = []struct {
+	passwd, expected string
+}{
+	{"Ab1!", ERR_PASSWD_TOO_SHORT},
+	{"Ab1!密", ERR_PASSWD_TOO_SHORT},
+	{"Ab1!密码", ""},
+	{"abcdefg1!", ERR_PASSWD_COMPLEXITY},
+	{"ABCDEFG1!", ERR_PASSWD_COMPLEXITY},
+	{"Abcdefgh!", ERR_PASSWD_COMPLEXITY},
+	{"Abcdefgh1", ERR_PASSWD_COMPLEXITY},
+	{"Abcdefg 1", ERR_PASSWD_COMPLEXITY},
+	{"P@ssw0rd", ERR_PASSWD_COMMON},
+	{"Gogs-2014", ""},
+}
+
+func TestCheckPasswdPolicy(t *testing.T) {
+	defer func(minLength int, complexity []string, checkCommon bool) {
+		setting.PasswdMinLength, setting.PasswdComplexity, setting.PasswdCheckCommon =
+			minLength, complexity, checkCommon
+	}(setting.PasswdMinLength, setting.PasswdComplexity, setting.PasswdCheckCommon)
+	setting.PasswdMinLength = 6
+	setting.PasswdComplexity = []string{"lower", "upper", "digit", "spec"}
+	setting.PasswdCheckCommon = true
+
+	for _, tt := range checkPasswdPolicyTests {
+		if err := CheckPasswdPolicy(tt.passwd); err != tt.expected {
+			t.Errorf("CheckPasswdPolicy(%q) = %q, expected %q", tt.passwd, err, tt.expected)
+		}
+	}
+
+	setting.PasswdComplexity = nil
+	setting.PasswdCheckCommon = false
+	if err := CheckPasswdPolicy("password"); err != "" {
+		t.Errorf("CheckPasswdPolicy(%q) without complexity and common check = %q, expected none", "password", err)
+	}
+}
@@ -17,12 +17,14 @@ import (
 type AddSSHKeyForm struct {
 	KeyName    string `form:"keyname" binding:"Required"`
 	KeyContent string `form:"key_content" binding:"Required"`
+	Expires    string `form:"expires"`
 }
 
 func (f *AddSSHKeyForm) Name(field string) string {
 	names := map[string]string{
 		"KeyName":    "SSH key name",
 		"KeyContent": "SSH key content",
+		"Expires":    "Expiration date",
 	}
 	return names[field]
 }
@@ -31,3 +33,19 @@ func (f *AddSSHKeyForm) Validate(errors *binding.Errors, req *http.Request, cont
 	data := context.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
 	validate(errors, data, f)
 }
+
+type AddGPGKeyForm struct {
+	Content string `form:"content" binding:"Required"`
+}
+
+func (f *AddGPGKeyForm) Name(field string) string {
+	names := map[string]string{
+		"Content": "GPG key content",
+	}
+	return names[field]
+}
+
+func (f *AddGPGKeyForm) Validate(errors *binding.Errors, req *http.Request, context martini.Context) {
+	data := context.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validate(errors, data, f)
+}
@@ -28,6 +28,9 @@ type CreateRepoForm struct {
 	Language    string `form:"language"`
 	License     string `form:"license"`
 	InitReadme  bool   `form:"initReadme"`
+	Template    int64  `form:"template"`
+	CopyLabels  bool   `form:"copy_labels"`
+	CopyHooks   bool   `form:"copy_hooks"`
 }
 
 func (f *CreateRepoForm) Name(field string) string {
@@ -47,6 +50,9 @@ type MigrateRepoForm struct {
 	Url          string `form:"url" binding:"Url"`
 	AuthUserName string `form:"auth_username"`
 	AuthPasswd   string `form:"auth_password"`
+	Service      string `form:"service"`
+	AuthToken    string `form:"auth_token"`
+	Metadata     bool   `form:"metadata"`
 	RepoName     string `form:"repo" binding:"Required;AlphaDash;MaxSize(100)"`
 	Mirror       bool   `form:"mirror"`
 	Private      bool   `form:"private"`
@@ -67,21 +73,49 @@ func (f *MigrateRepoForm) Validate(errors *binding.Errors, req *http.Request, co
 	validate(errors, data, f)
 }
 
-type RepoSettingForm struct {
-	RepoName    string `form:"name" binding:"Required;AlphaDash;MaxSize(100)"`
+type ForkRepoForm struct {
+	RepoName    string `form:"repo" binding:"Required;AlphaDash;MaxSize(100)"`
 	Description string `form:"desc" binding:"MaxSize(100)"`
-	Website     string `form:"site" binding:"Url;MaxSize(100)"`
-	Branch      string `form:"branch"`
-	Interval    int    `form:"interval"`
-	Private     bool   `form:"private"`
-	GoGet       bool   `form:"goget"`
 }
 
-func (f *RepoSettingForm) Name(field string) string {
+func (f *ForkRepoForm) Name(field string) string {
 	names := map[string]string{
 		"RepoName":    "Repository name",
 		"Description": "Description",
-		"Website":     "Website address",
+	}
+	return names[field]
+}
+
+func (f *ForkRepoForm) Validate(errors *binding.Errors, req *http.Request, context martini.Context) {
+	data := context.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validate(errors, data, f)
+}
+
+type RepoSettingForm struct {
+	RepoName            string `form:"name" binding:"Required;AlphaDash;MaxSize(100)"`
+	Description         string `form:"desc" binding:"MaxSize(100)"`
+	Website             string `form:"site" binding:"Url;MaxSize(100)"`
+	Topics              string `form:"topics" binding:"MaxSize(500)"`
+	Branch              string `form:"branch"`
+	Private             bool   `form:"private"`
+	GoGet               bool   `form:"goget"`
+	IsTemplate          bool   `form:"is_template"`
+	DeleteMergedHead    bool   `form:"delete_merged_head"`
+	BlockOnDependencies bool   `form:"block_on_dependencies"`
+	MergeStyleMerge     bool   `form:"merge_style_merge"`
+	MergeStyleSquash    bool   `form:"merge_style_squash"`
+	MergeStyleRebase    bool   `form:"merge_style_rebase"`
+	DefaultMergeStyle   string `form:"default_merge_style"`
+	MergeMessage        string `form:"merge_message_template" binding:"MaxSize(1000)"`
+}
+
+func (f *RepoSettingForm) Name(field string) string {
+	names := map[string]string{
+		"RepoName":     "Repository name",
+		"Description":  "Description",
+		"Website":      "Website address",
+		"Topics":       "Topics",
+		"MergeMessage": "Merge message template",
 	}
 	return names[field]
 }
@@ -119,6 +153,130 @@ func (f *NewWebhookForm) Validate(errors *binding.Errors, req *http.Request, con
 	validate(errors, data, f)
 }
 
+type AddDeployKeyForm struct {
+	Title      string `form:"title" binding:"Required;MaxSize(50)"`
+	Content    string `form:"content" binding:"Required"`
+	IsWritable bool   `form:"is_writable"`
+}
+
+func (f *AddDeployKeyForm) Name(field string) string {
+	names := map[string]string{
+		"Title":   "Title",
+		"Content": "Key",
+	}
+	return names[field]
+}
+
+func (f *AddDeployKeyForm) Validate(errors *binding.Errors, req *http.Request, context martini.Context) {
+	data := context.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validate(errors, data, f)
+}
+
+type ProtectedBranchForm struct {
+	BranchName         string `form:"branch" binding:"Required;MaxSize(255)"`
+	BlockForcePush     bool   `form:"block_force_push"`
+	BlockDeletion      bool   `form:"block_deletion"`
+	RequirePullRequest bool   `form:"require_pull_request"`
+	EnableWhitelist    bool   `form:"enable_whitelist"`
+	Whitelist          string `form:"whitelist"`
+	RequiredApprovals  int    `form:"required_approvals"`
+	DismissStale       bool   `form:"dismiss_stale"`
+}
+
+func (f *ProtectedBranchForm) Name(field string) string {
+	names := map[string]string{
+		"BranchName": "Branch",
+	}
+	return names[field]
+}
+
+func (f *ProtectedBranchForm) Validate(errors *binding.Errors, req *http.Request, context martini.Context) {
+	data := context.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validate(errors, data, f)
+}
+
+type MirrorSettingForm struct {
+	Address      string `form:"address" binding:"Required;Url"`
+	AuthUserName string `form:"auth_username"`
+	AuthPasswd   string `form:"auth_password"`
+	Interval     int    `form:"interval" binding:"Required"`
+}
+
+func (f *MirrorSettingForm) Name(field string) string {
+	names := map[string]string{
+		"Address":  "Mirror address",
+		"Interval": "Mirror interval",
+	}
+	return names[field]
+}
+
+func (f *MirrorSettingForm) Validate(errors *binding.Errors, req *http.Request, context martini.Context) {
+	data := context.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validate(errors, data, f)
+}
+
+type PushMirrorForm struct {
+	Address      string `form:"address" binding:"Required;Url"`
+	AuthUserName string `form:"auth_username"`
+	AuthPasswd   string `form:"auth_password"`
+}
+
+func (f *PushMirrorForm) Name(field string) string {
+	names := map[string]string{
+		"Address": "Remote address",
+	}
+	return names[field]
+}
+
+func (f *PushMirrorForm) Validate(errors *binding.Errors, req *http.Request, context martini.Context) {
+	data := context.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validate(errors, data, f)
+}
+
+type EditRepoFileForm struct {
+	TreeName      string `form:"tree_name" binding:"Required;MaxSize(500)"`
+	Content       string `form:"content"`
+	CommitSummary string `form:"commit_summary" binding:"MaxSize(100)"`
+	CommitMessage string `form:"commit_message"`
+	CommitChoice  string `form:"commit_choice" binding:"Required;MaxSize(50)"`
+	NewBranchName string `form:"new_branch_name" binding:"AlphaDashDot;MaxSize(100)"`
+	LastCommit    string `form:"last_commit"`
+}
+
+func (f *EditRepoFileForm) Name(field string) string {
+	names := map[string]string{
+		"TreeName":      "File name",
+		"CommitSummary": "Commit summary",
+		"NewBranchName": "Branch name",
+	}
+	return names[field]
+}
+
+func (f *EditRepoFileForm) Validate(errors *binding.Errors, req *http.Request, context martini.Context) {
+	data := context.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validate(errors, data, f)
+}
+
+type DeleteRepoFileForm struct {
+	CommitSummary string `form:"commit_summary" binding:"MaxSize(100)"`
+	CommitMessage string `form:"commit_message"`
+	CommitChoice  string `form:"commit_choice" binding:"Required;MaxSize(50)"`
+	NewBranchName string `form:"new_branch_name" binding:"AlphaDashDot;MaxSize(100)"`
+}
+
+func (f *DeleteRepoFileForm) Name(field string) string {
+	names := map[string]string{
+		"CommitSummary": "Commit summary",
+		"NewBranchName": "Branch name",
+	}
+	return names[field]
+}
+
+func (f *DeleteRepoFileForm) Validate(errors *binding.Errors, req *http.Request, context martini.Context) {
+	data := context.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validate(errors, data, f)
+}
+
 // .___
 // |   | ______ ________ __   ____
 // |   |/  ___//  ___/  |  \_/ __ \
@@ -132,6 +290,7 @@ type CreateIssueForm struct {
 	AssigneeId  int64  `form:"assigneeid"`
 	Labels      string `form:"labels"`
 	Content     string `form:"content"`
+	Deadline    string `form:"due_date"`
 }
 
 func (f *CreateIssueForm) Name(field string) string {
@@ -146,6 +305,41 @@ func (f *CreateIssueForm) Validate(errors *binding.Errors, req *http.Request, co
 	validate(errors, data, f)
 }
 
+type SaveIssueFilterForm struct {
+	Title string `form:"name" binding:"Required;MaxSize(50)"`
+	Query string `form:"query"`
+}
+
+func (f *SaveIssueFilterForm) Name(field string) string {
+	names := map[string]string{
+		"Title": "Filter name",
+	}
+	return names[field]
+}
+
+func (f *SaveIssueFilterForm) Validate(errors *binding.Errors, req *http.Request, context martini.Context) {
+	data := context.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validate(errors, data, f)
+}
+
+type CreatePullRequestForm struct {
+	Title   string `form:"title" binding:"Required;MaxSize(50)"`
+	Content string `form:"content"`
+	IsDraft bool   `form:"draft"`
+}
+
+func (f *CreatePullRequestForm) Name(field string) string {
+	names := map[string]string{
+		"Title": "Pull request title",
+	}
+	return names[field]
+}
+
+func (f *CreatePullRequestForm) Validate(errors *binding.Errors, req *http.Request, context martini.Context) {
+	data := context.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validate(errors, data, f)
+}
+
 //    _____  .__.__                   __
 //   /     \ |__|  |   ____   _______/  |_  ____   ____   ____
 //  /  \ /  \|  |  | _/ __ \ /  ___/\   __\/  _ \ /    \_/ __ \
@@ -179,14 +373,16 @@ func (f *CreateMilestoneForm) Validate(errors *binding.Errors, req *http.Request
 //         \/    \/    \/     \/
 
 type CreateLabelForm struct {
-	Title string `form:"title" binding:"Required;MaxSize(50)"`
-	Color string `form:"color" binding:"Required;Size(7)"`
+	Title       string `form:"title" binding:"Required;MaxSize(50)"`
+	Color       string `form:"color" binding:"Required;Size(7)"`
+	Description string `form:"desc" binding:"MaxSize(255)"`
 }
 
 func (f *CreateLabelForm) Name(field string) string {
 	names := map[string]string{
-		"Title": "Label name",
-		"Color": "Label color",
+		"Title":       "Label name",
+		"Color":       "Label color",
+		"Description": "Label description",
 	}
 	return names[field]
 }
@@ -196,6 +392,41 @@ func (f *CreateLabelForm) Validate(errors *binding.Errors, req *http.Request, co
 	validate(errors, data, f)
 }
 
+type CreateProjectForm struct {
+	Title       string `form:"name" binding:"Required;MaxSize(50)"`
+	Description string `form:"desc"`
+}
+
+func (f *CreateProjectForm) Name(field string) string {
+	names := map[string]string{
+		"Title": "Project name",
+	}
+	return names[field]
+}
+
+func (f *CreateProjectForm) Validate(errors *binding.Errors, req *http.Request, context martini.Context) {
+	data := context.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validate(errors, data, f)
+}
+
+type ProjectColumnForm struct {
+	Title      string `form:"name" binding:"Required;MaxSize(50)"`
+	LabelId    int64  `form:"label"`
+	IssueState int    `form:"state"`
+}
+
+func (f *ProjectColumnForm) Name(field string) string {
+	names := map[string]string{
+		"Title": "Column name",
+	}
+	return names[field]
+}
+
+func (f *ProjectColumnForm) Validate(errors *binding.Errors, req *http.Request, context martini.Context) {
+	data := context.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validate(errors, data, f)
+}
+
 // __________       .__
 // \______   \ ____ |  |   ____ _____    ______ ____
 //  |       _// __ \|  | _/ __ \\__  \  /  ___// __ \
@@ -208,6 +439,7 @@ type NewReleaseForm struct {
 	Title      string `form:"title" binding:"Required"`
 	Content    string `form:"content" binding:"Required"`
 	Prerelease bool   `form:"prerelease"`
+	Draft      string `form:"draft"`
 }
 
 func (f *NewReleaseForm) Name(field string) string {
@@ -223,3 +455,43 @@ func (f *NewReleaseForm) Validate(errors *binding.Errors, req *http.Request, con
 	data := context.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
 	validate(errors, data, f)
 }
+
+type NewTagForm struct {
+	TagName string `form:"tag_name" binding:"Required;MaxSize(100)"`
+	Target  string `form:"target" binding:"Required"`
+	Message string `form:"message" binding:"Required"`
+}
+
+func (f *NewTagForm) Name(field string) string {
+	names := map[string]string{
+		"TagName": "Tag name",
+		"Target":  "Target",
+		"Message": "Tag message",
+	}
+	return names[field]
+}
+
+func (f *NewTagForm) Validate(errors *binding.Errors, req *http.Request, context martini.Context) {
+	data := context.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validate(errors, data, f)
+}
+
+type WikiPageForm struct {
+	Title   string `form:"title" binding:"Required;MaxSize(100)"`
+	Content string `form:"content" binding:"Required"`
+	Message string `form:"message" binding:"MaxSize(255)"`
+}
+
+func (f *WikiPageForm) Name(field string) string {
+	names := map[string]string{
+		"Title":   "Page title",
+		"Content": "Page content",
+		"Message": "Edit message",
+	}
+	return names[field]
+}
+
+func (f *WikiPageForm) Validate(errors *binding.Errors, req *http.Request, context martini.Context) {
+	data := context.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validate(errors, data, f)
+}
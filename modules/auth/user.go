@@ -5,8 +5,10 @@
 package auth
 
 import (
+	"net"
 	"net/http"
 	"reflect"
+	"strings"
 
 	"github.com/go-martini/martini"
 
@@ -16,19 +18,107 @@ import (
 	"github.com/gogits/gogs/modules/base"
 	"github.com/gogits/gogs/modules/log"
 	"github.com/gogits/gogs/modules/middleware/binding"
+	"github.com/gogits/gogs/modules/setting"
 )
 
+// IsApiPath returns true if given URL path is an API call.
+func IsApiPath(url string) bool {
+	return strings.HasPrefix(url, "/api/")
+}
+
+// RemoteAddr returns IP address of client, headers set by reverse proxy
+// are only respected when request comes from local.
+func RemoteAddr(req *http.Request) string {
+	addr := req.RemoteAddr
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+
+	if ip := net.ParseIP(addr); ip != nil && ip.IsLoopback() {
+		if realIp := req.Header.Get("X-Real-IP"); len(realIp) > 0 {
+			return strings.TrimSpace(realIp)
+		} else if fwd := req.Header.Get("X-Forwarded-For"); len(fwd) > 0 {
+			return strings.TrimSpace(strings.Split(fwd, ",")[0])
+		}
+	}
+	return addr
+}
+
+// RequestAccessToken returns the access token that given request carries
+// by "Authorization: token|Bearer <sha1>" header, password of basic
+// authentication or "token" query parameter.
+func RequestAccessToken(req *http.Request) *models.AccessToken {
+	tokenSha := req.URL.Query().Get("token")
+	if len(tokenSha) == 0 {
+		auths := strings.Fields(req.Header.Get("Authorization"))
+		if len(auths) == 2 {
+			switch auths[0] {
+			case "token", "Bearer", "bearer":
+				tokenSha = auths[1]
+			case "Basic":
+				_, tokenSha, _ = base.BasicAuthDecode(auths[1])
+			}
+		}
+	}
+	if len(tokenSha) == 0 {
+		return nil
+	}
+
+	t, err := models.GetAccessTokenBySha(tokenSha)
+	if err != nil {
+		if err != models.ErrAccessTokenNotExist {
+			log.Error("auth.RequestAccessToken(GetAccessTokenBySha): %v", err)
+		}
+		return nil
+	}
+	return t
+}
+
+// AccessTokenUid returns the id of user who owns the access token
+// that given request carries.
+func AccessTokenUid(req *http.Request) int64 {
+	t := RequestAccessToken(req)
+	if t == nil {
+		return 0
+	}
+	if err := models.UpdateAccessToken(t); err != nil {
+		log.Error("auth.AccessTokenUid(UpdateAccessToken): %v", err)
+	}
+	return t.Uid
+}
+
 // SignedInId returns the id of signed in user.
-func SignedInId(sess session.SessionStore) int64 {
+func SignedInId(req *http.Request, sess session.SessionStore) int64 {
 	if !models.HasEngine {
 		return 0
 	}
 
+	// API calls can be authorized by access token.
+	if IsApiPath(req.URL.Path) {
+		if uid := AccessTokenUid(req); uid > 0 {
+			return uid
+		}
+	}
+
 	uid := sess.Get("userId")
 	if uid == nil {
 		return 0
 	}
 	if id, ok := uid.(int64); ok {
+		// Session could have been revoked from elsewhere.
+		s, err := models.GetUserSession(sess.SessionID())
+		if err != nil || s.Uid != id {
+			if err != nil && err != models.ErrUserSessionNotExist {
+				log.Error("auth.SignedInId(GetUserSession): %v", err)
+			}
+			sess.Delete("userId")
+			sess.Delete("userName")
+			return 0
+		}
+		if err = models.TouchUserSession(s, RemoteAddr(req), req.UserAgent()); err != nil {
+			log.Error("auth.SignedInId(TouchUserSession): %v", err)
+		}
+
 		if _, err := models.GetUserById(id); err != nil {
 			return 0
 		}
@@ -49,9 +139,55 @@ func SignedInName(sess session.SessionStore) string {
 	return ""
 }
 
+// reverseProxyUser returns the user whose name is set in header by
+// fronting SSO proxy, the user is created if auto-registration is enabled.
+func reverseProxyUser(req *http.Request) *models.User {
+	uname := req.Header.Get(setting.ReverseProxyAuthUser)
+	if len(uname) == 0 {
+		return nil
+	}
+
+	u, err := models.GetUserByName(uname)
+	if err == nil {
+		if u.IsBot() || u.IsSuspended {
+			return nil
+		}
+		return u
+	} else if err != models.ErrUserNotExist {
+		log.Error("auth.reverseProxyUser(GetUserByName): %v", err)
+		return nil
+	} else if !setting.Service.EnableReverseProxyAutoRegister {
+		return nil
+	}
+
+	email := req.Header.Get(setting.ReverseProxyAuthEmail)
+	if len(email) == 0 {
+		email = uname + "@localhost"
+	}
+	u, err = models.RegisterUser(&models.User{
+		Name:      uname,
+		Email:     email,
+		Passwd:    base.GetRandomString(30),
+		IsActive:  true,
+		LoginType: models.LT_PLAIN,
+	})
+	if err != nil {
+		log.Error("auth.reverseProxyUser(RegisterUser): %v", err)
+		return nil
+	}
+	log.Trace("User auto-registered by reverse proxy: %s", u.Name)
+	return u
+}
+
 // SignedInUser returns the user object of signed user.
-func SignedInUser(sess session.SessionStore) *models.User {
-	uid := SignedInId(sess)
+func SignedInUser(req *http.Request, sess session.SessionStore) *models.User {
+	if models.HasEngine && setting.Service.EnableReverseProxyAuth {
+		if u := reverseProxyUser(req); u != nil {
+			return u
+		}
+	}
+
+	uid := SignedInId(req, sess)
 	if uid <= 0 {
 		return nil
 	}
@@ -60,13 +196,15 @@ func SignedInUser(sess session.SessionStore) *models.User {
 	if err != nil {
 		log.Error("user.SignedInUser: %v", err)
 		return nil
+	} else if u.IsSuspended {
+		return nil
 	}
 	return u
 }
 
 // IsSignedIn check if any user has signed in.
-func IsSignedIn(sess session.SessionStore) bool {
-	return SignedInId(sess) > 0
+func IsSignedIn(req *http.Request, sess session.SessionStore) bool {
+	return SignedInId(req, sess) > 0
 }
 
 type FeedsForm struct {
@@ -115,6 +253,76 @@ func (f *UpdatePasswdForm) Name(field string) string {
 }
 
 func (f *UpdatePasswdForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validatePasswd(errs, "NewPasswd", f.NewPasswd)
+	validate(errs, data, f)
+}
+
+type TwoFactorAuthForm struct {
+	Passcode string `form:"passcode" binding:"Required;MinSize(6);MaxSize(6)"`
+}
+
+func (f *TwoFactorAuthForm) Name(field string) string {
+	names := map[string]string{
+		"Passcode": "Passcode",
+	}
+	return names[field]
+}
+
+func (f *TwoFactorAuthForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validate(errs, data, f)
+}
+
+type TwoFactorScratchAuthForm struct {
+	Token string `form:"token" binding:"Required"`
+}
+
+func (f *TwoFactorScratchAuthForm) Name(field string) string {
+	names := map[string]string{
+		"Token": "Scratch token",
+	}
+	return names[field]
+}
+
+func (f *TwoFactorScratchAuthForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validate(errs, data, f)
+}
+
+type NewAccessTokenForm struct {
+	TokenName string   `form:"name" binding:"Required;MaxSize(50)"`
+	Scopes    []string `form:"scopes"`
+}
+
+func (f *NewAccessTokenForm) Name(field string) string {
+	names := map[string]string{
+		"TokenName": "Token name",
+	}
+	return names[field]
+}
+
+func (f *NewAccessTokenForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
+	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
+	validate(errs, data, f)
+}
+
+type NewOauthApplicationForm struct {
+	AppName      string `form:"name" binding:"Required;MaxSize(50)"`
+	Website      string `form:"website" binding:"Url;MaxSize(100)"`
+	RedirectUris string `form:"redirect_uris" binding:"Required"`
+}
+
+func (f *NewOauthApplicationForm) Name(field string) string {
+	names := map[string]string{
+		"AppName":      "Application name",
+		"Website":      "Website",
+		"RedirectUris": "Redirect URIs",
+	}
+	return names[field]
+}
+
+func (f *NewOauthApplicationForm) Validate(errs *binding.Errors, req *http.Request, ctx martini.Context) {
 	data := ctx.Get(reflect.TypeOf(base.TmplData{})).Interface().(base.TmplData)
 	validate(errs, data, f)
 }
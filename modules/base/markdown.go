@@ -7,6 +7,7 @@ package base
 import (
 	"bytes"
 	"fmt"
+	"html/template"
 	"net/http"
 	"path"
 	"path/filepath"
@@ -89,11 +90,33 @@ func (options *CustomRender) Link(out *bytes.Buffer, link []byte, title []byte,
 	options.Renderer.Link(out, link, title, content)
 }
 
+// Image renders images uploaded as issue attachments as thumbnails linked to the original.
+func (options *CustomRender) Image(out *bytes.Buffer, link []byte, title []byte, alt []byte) {
+	if bytes.HasPrefix(link, []byte("issues/attachments/")) {
+		link = []byte(path.Join(options.urlPrefix, string(link)))
+	}
+
+	if !issueAttachmentPattern.Match(link) {
+		options.Renderer.Image(out, link, title, alt)
+		return
+	}
+
+	out.WriteString(`<a class="attachment-thumbnail" href="`)
+	out.Write(link)
+	out.WriteString(`">`)
+	options.Renderer.Image(out, []byte(string(link)+"/thumbnail"), title, alt)
+	out.WriteString("</a>")
+}
+
 var (
 	MentionPattern    = regexp.MustCompile(`@[0-9a-zA-Z_]{1,}`)
 	commitPattern     = regexp.MustCompile(`(\s|^)https?.*commit/[0-9a-zA-Z]+(#+[0-9a-zA-Z-]*)?`)
 	issueFullPattern  = regexp.MustCompile(`(\s|^)https?.*issues/[0-9]+(#+[0-9a-zA-Z-]*)?`)
-	issueIndexPattern = regexp.MustCompile(`#[0-9]+`)
+	issueIndexPattern = regexp.MustCompile(`(^|[\s(\[])#([0-9]+)\b`)
+	crossIssuePattern = regexp.MustCompile(`(^|[\s(\[])([0-9a-zA-Z_.\-]+/[0-9a-zA-Z_.\-]+)#([0-9]+)\b`)
+	shaPattern        = regexp.MustCompile(`(^|[\s(\[])([0-9a-f]{7,40})\b`)
+
+	issueAttachmentPattern = regexp.MustCompile(`^/[0-9a-zA-Z_.\-/]+/issues/attachments/[0-9a-f]{40}$`)
 )
 
 func RenderSpecialLink(rawBytes []byte, urlPrefix string) []byte {
@@ -143,12 +166,45 @@ func RenderSpecialLink(rawBytes []byte, urlPrefix string) []byte {
 		rawBytes = bytes.Replace(rawBytes, m, []byte(fmt.Sprintf(
 			` <a href="%s">#%s</a>`, m, ShortSha(string(m[i+7:j])))), -1)
 	}
-	ms = issueIndexPattern.FindAll(rawBytes, -1)
-	for _, m := range ms {
-		rawBytes = bytes.Replace(rawBytes, m, []byte(fmt.Sprintf(
-			`<a href="%s/issues/%s">%s</a>`, urlPrefix, m[1:], m)), -1)
+
+	buf = bytes.NewBufferString("")
+	inCodeBlock = false
+	for _, line := range bytes.Split(rawBytes, lineBreak) {
+		if bytes.HasPrefix(line, codeBlockPrefix) {
+			inCodeBlock = !inCodeBlock
+		}
+		if !inCodeBlock && !bytes.HasPrefix(line, tab) {
+			line = RenderIssueRefs(line, urlPrefix)
+		}
+		buf.Write(line)
+		buf.Write(lineBreak)
 	}
-	return rawBytes
+	return buf.Bytes()
+}
+
+// isCommitSha returns true if hex string looks like a commit SHA rather than a word or number.
+func isCommitSha(s []byte) bool {
+	return bytes.IndexAny(s, "0123456789") > -1 && bytes.IndexAny(s, "abcdef") > -1
+}
+
+// RenderIssueRefs renders references to issues like "#123" and "owner/repo#123",
+// and commit SHAs in given text as links.
+func RenderIssueRefs(raw []byte, urlPrefix string) []byte {
+	raw = crossIssuePattern.ReplaceAll(raw, []byte(`$1<a href="/$2/issues/$3">$2#$3</a>`))
+	raw = issueIndexPattern.ReplaceAll(raw, []byte(`$1<a href="`+urlPrefix+`/issues/$2">#$2</a>`))
+	return shaPattern.ReplaceAllFunc(raw, func(m []byte) []byte {
+		sm := shaPattern.FindSubmatch(m)
+		if !isCommitSha(sm[2]) {
+			return m
+		}
+		return []byte(fmt.Sprintf(`%s<a href="%s/commit/%s"><code>%s</code></a>`,
+			sm[1], urlPrefix, sm[2], ShortSha(string(sm[2]))))
+	})
+}
+
+// RenderCommitMessage escapes commit message and renders references in it as links.
+func RenderCommitMessage(msg, urlPrefix string) template.HTML {
+	return template.HTML(RenderIssueRefs([]byte(template.HTMLEscapeString(msg)), urlPrefix))
 }
 
 func RenderRawMarkdown(body []byte, urlPrefix string) []byte {
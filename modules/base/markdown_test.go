@@ -0,0 +1,39 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"testing"
+)
+
+var renderIssueRefsTests = []struct {
+	raw, expected string
+}{
+	{"See #12.", `See <a href="/user1/repo1/issues/12">#12</a>.`},
+	{"(#3)", `(<a href="/user1/repo1/issues/3">#3</a>)`},
+	{"a#1 and #x", "a#1 and #x"},
+	{"Ref gogits/gogs#45", `Ref <a href="/gogits/gogs/issues/45">gogits/gogs#45</a>`},
+	{"Fixed in 1a2b3c4d", `Fixed in <a href="/user1/repo1/commit/1a2b3c4d"><code>1a2b3c4d</code></a>`},
+	{"1a2b3c4d5e6f7a8b9c0d1a2b3c4d5e6f7a8b9c0d", `<a href="/user1/repo1/commit/1a2b3c4d5e6f7a8b9c0d1a2b3c4d5e6f7a8b9c0d"><code>1a2b3c4d5e</code></a>`},
+	// Numbers and words made of hex letters are not commits.
+	{"Number 12345678 and word deadbeef", "Number 12345678 and word deadbeef"},
+	{"1a2b3c", "1a2b3c"},
+}
+
+func TestRenderIssueRefs(t *testing.T) {
+	for _, tt := range renderIssueRefsTests {
+		if html := string(RenderIssueRefs([]byte(tt.raw), "/user1/repo1")); html != tt.expected {
+			t.Errorf("RenderIssueRefs(%q) = %q, expected %q", tt.raw, html, tt.expected)
+		}
+	}
+}
+
+func TestRenderCommitMessage(t *testing.T) {
+	msg := "Fix <script> in #1"
+	expected := `Fix &lt;script&gt; in <a href="/user1/repo1/issues/1">#1</a>`
+	if html := string(RenderCommitMessage(msg, "/user1/repo1")); html != expected {
+		t.Errorf("RenderCommitMessage(%q) = %q, expected %q", msg, html, expected)
+	}
+}
@@ -95,11 +95,12 @@ var TemplateFuncs template.FuncMap = map[string]interface{}{
 	"SubStr": func(str string, start, length int) string {
 		return str[start : start+length]
 	},
-	"DiffTypeToStr":     DiffTypeToStr,
-	"DiffLineTypeToStr": DiffLineTypeToStr,
-	"ShortSha":          ShortSha,
-	"Oauth2Icon":        Oauth2Icon,
-	"Oauth2Name":        Oauth2Name,
+	"DiffTypeToStr":       DiffTypeToStr,
+	"DiffLineTypeToStr":   DiffLineTypeToStr,
+	"ShortSha":            ShortSha,
+	"RenderCommitMessage": RenderCommitMessage,
+	"Oauth2Icon":          Oauth2Icon,
+	"Oauth2Name":          Oauth2Name,
 }
 
 type Actioner interface {
@@ -122,10 +123,14 @@ func ActionIcon(opType int) string {
 		return "arrow-circle-o-right"
 	case 6: // Create issue.
 		return "exclamation-circle"
+	case 7: // Pull request.
+		return "code-fork"
 	case 8: // Transfer repository.
 		return "share"
 	case 10: // Comment issue.
 		return "comment"
+	case 11: // Publish release.
+		return "tag"
 	default:
 		return "invalid type"
 	}
@@ -141,6 +146,9 @@ const (
 	TPL_PUSH_TAG      = `<a href="/user/%s">%s</a> pushed tag <a href="/%s/src/%s" rel="nofollow">%s</a> at <a href="/%s">%s</a>`
 	TPL_COMMENT_ISSUE = `<a href="/user/%s">%s</a> commented on issue <a href="/%s/issues/%s">%s#%s</a>
 <div><img src="%s?s=16" alt="user-avatar"/> %s</div>`
+	TPL_PULL_REQUEST = `<a href="/user/%s">%s</a> opened pull request <a href="/%s/pulls/%s">%s#%s</a>
+<div><img src="%s?s=16" alt="user-avatar"/> %s</div>`
+	TPL_PUBLISH_RELEASE = `<a href="/user/%s">%s</a> published release <a href="/%s/releases">%s</a> at <a href="/%s">%s</a>`
 )
 
 type PushCommit struct {
@@ -186,6 +194,10 @@ func ActionDesc(act Actioner) string {
 		infos := strings.SplitN(content, "|", 2)
 		return fmt.Sprintf(TPL_CREATE_ISSUE, actUserName, actUserName, repoLink, infos[0], repoLink, infos[0],
 			AvatarLink(email), infos[1])
+	case 7: // Pull request.
+		infos := strings.SplitN(content, "|", 2)
+		return fmt.Sprintf(TPL_PULL_REQUEST, actUserName, actUserName, repoLink, infos[0], repoLink, infos[0],
+			AvatarLink(email), infos[1])
 	case 8: // Transfer repository.
 		newRepoLink := content + "/" + repoName
 		return fmt.Sprintf(TPL_TRANSFER_REPO, actUserName, actUserName, repoLink, newRepoLink, newRepoLink)
@@ -195,6 +207,36 @@ func ActionDesc(act Actioner) string {
 		infos := strings.SplitN(content, "|", 2)
 		return fmt.Sprintf(TPL_COMMENT_ISSUE, actUserName, actUserName, repoLink, infos[0], repoLink, infos[0],
 			AvatarLink(email), infos[1])
+	case 11: // Publish release.
+		infos := strings.SplitN(content, "|", 2)
+		return fmt.Sprintf(TPL_PUBLISH_RELEASE, actUserName, actUserName, repoLink, infos[0], repoLink, repoLink)
+	default:
+		return "invalid type"
+	}
+}
+
+// ActionTitle returns one line plain text description of action.
+func ActionTitle(act Actioner) string {
+	actUserName := act.GetActUserName()
+	repoLink := act.GetRepoUserName() + "/" + act.GetRepoName()
+	content := act.GetContent()
+	switch act.GetOpType() {
+	case 1: // Create repository.
+		return fmt.Sprintf("%s created repository %s", actUserName, repoLink)
+	case 5: // Commit repository.
+		return fmt.Sprintf("%s pushed to %s at %s", actUserName, act.GetBranch(), repoLink)
+	case 6: // Create issue.
+		return fmt.Sprintf("%s opened issue %s#%s", actUserName, repoLink, strings.SplitN(content, "|", 2)[0])
+	case 7: // Pull request.
+		return fmt.Sprintf("%s opened pull request %s#%s", actUserName, repoLink, strings.SplitN(content, "|", 2)[0])
+	case 8: // Transfer repository.
+		return fmt.Sprintf("%s transfered repository %s to %s/%s", actUserName, repoLink, content, act.GetRepoName())
+	case 9: // Push tag.
+		return fmt.Sprintf("%s pushed tag %s at %s", actUserName, act.GetBranch(), repoLink)
+	case 10: // Comment issue.
+		return fmt.Sprintf("%s commented on issue %s#%s", actUserName, repoLink, strings.SplitN(content, "|", 2)[0])
+	case 11: // Publish release.
+		return fmt.Sprintf("%s published release %s at %s", actUserName, strings.SplitN(content, "|", 2)[0], repoLink)
 	default:
 		return "invalid type"
 	}
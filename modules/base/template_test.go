@@ -0,0 +1,44 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"testing"
+)
+
+type testAction struct {
+	opType                     int
+	repoUserName, branch, text string
+}
+
+func (a testAction) GetOpType() int          { return a.opType }
+func (a testAction) GetActUserName() string  { return "user1" }
+func (a testAction) GetActEmail() string     { return "user1@gogs.io" }
+func (a testAction) GetRepoUserName() string { return a.repoUserName }
+func (a testAction) GetRepoName() string     { return "repo1" }
+func (a testAction) GetBranch() string       { return a.branch }
+func (a testAction) GetContent() string      { return a.text }
+
+func TestActionTitle(t *testing.T) {
+	tests := []struct {
+		act      testAction
+		expected string
+	}{
+		{testAction{1, "user1", "", ""}, "user1 created repository user1/repo1"},
+		{testAction{5, "owner", "master", ""}, "user1 pushed to master at owner/repo1"},
+		{testAction{6, "owner", "", "3|Bug"}, "user1 opened issue owner/repo1#3"},
+		{testAction{7, "owner", "", "4|Fix|bug"}, "user1 opened pull request owner/repo1#4"},
+		{testAction{8, "user1", "", "owner"}, "user1 transfered repository user1/repo1 to owner/repo1"},
+		{testAction{9, "owner", "v1.0", ""}, "user1 pushed tag v1.0 at owner/repo1"},
+		{testAction{10, "owner", "", "3|Comment"}, "user1 commented on issue owner/repo1#3"},
+		{testAction{11, "owner", "v1.0", "v1.0|First"}, "user1 published release v1.0 at owner/repo1"},
+		{testAction{2, "owner", "", ""}, "invalid type"},
+	}
+	for _, tt := range tests {
+		if title := ActionTitle(tt.act); title != tt.expected {
+			t.Errorf("ActionTitle(%+v) = %q, expected %q", tt.act, title, tt.expected)
+		}
+	}
+}
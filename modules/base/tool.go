@@ -9,7 +9,9 @@ import (
 	"crypto/md5"
 	"crypto/rand"
 	"crypto/sha1"
+	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"hash"
 	"math"
@@ -27,6 +29,35 @@ func EncodeMd5(str string) string {
 	return hex.EncodeToString(m.Sum(nil))
 }
 
+// Encode string to sha1 hex value.
+func EncodeSha1(str string) string {
+	h := sha1.New()
+	h.Write([]byte(str))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// BasicAuthDecode decodes username and password of HTTP basic authentication.
+func BasicAuthDecode(encoded string) (user string, passwd string, err error) {
+	var s []byte
+	s, err = base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return
+	}
+
+	a := strings.SplitN(string(s), ":", 2)
+	if len(a) == 2 {
+		user, passwd = a[0], a[1]
+	} else {
+		err = errors.New("decode failed")
+	}
+	return
+}
+
+// BasicAuthEncode encodes username and password for HTTP basic authentication.
+func BasicAuthEncode(username, passwd string) string {
+	return base64.StdEncoding.EncodeToString([]byte(username + ":" + passwd))
+}
+
 // GetRandomString generate random string by specify chars.
 func GetRandomString(n int, alphabets ...byte) string {
 	const alphanum = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
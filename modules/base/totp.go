@@ -0,0 +1,82 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	TOTP_PERIOD = 30 // seconds
+	TOTP_DIGITS = 6
+	TOTP_SKEW   = 1 // periods allowed before and after current one
+)
+
+// NewTotpSecret generates a random base32 encoded TOTP secret.
+func NewTotpSecret() string {
+	b := make([]byte, 10)
+	rand.Read(b)
+	return base32.StdEncoding.EncodeToString(b)
+}
+
+// TotpUri returns the key URI that authenticator applications understand.
+// https://code.google.com/p/google-authenticator/wiki/KeyUriFormat
+func TotpUri(issuer, account, secret string) string {
+	label := strings.Replace(url.QueryEscape(issuer+":"+account), "+", "%20", -1)
+	return fmt.Sprintf("otpauth://totp/%s?secret=%s&issuer=%s", label, secret, url.QueryEscape(issuer))
+}
+
+// ComputeTotp computes passcode of given secret at given counter (RFC 4226 and 6238).
+func ComputeTotp(secret string, counter uint64) (string, error) {
+	key, err := base32.StdEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(buf[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0xf
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", code%1000000), nil
+}
+
+// MatchTotp returns counter of time step whose passcode of given secret is given passcode,
+// steps around given time within allowed skew are tried. It returns -1 if none matches.
+func MatchTotp(secret, passcode string, now time.Time) int64 {
+	passcode = strings.TrimSpace(passcode)
+	if len(passcode) != TOTP_DIGITS {
+		return -1
+	}
+
+	counter := now.Unix() / TOTP_PERIOD
+	for i := -TOTP_SKEW; i <= TOTP_SKEW; i++ {
+		code, err := ComputeTotp(secret, uint64(counter+int64(i)))
+		if err != nil {
+			return -1
+		}
+		if subtle.ConstantTimeCompare([]byte(code), []byte(passcode)) == 1 {
+			return counter + int64(i)
+		}
+	}
+	return -1
+}
+
+// ValidateTotp returns true if passcode matches given secret at current time.
+func ValidateTotp(secret, passcode string) bool {
+	return MatchTotp(secret, passcode, time.Now()) >= 0
+}
@@ -0,0 +1,57 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"testing"
+	"time"
+)
+
+// Secret of test vectors in RFC 6238, i.e. "12345678901234567890" in base32.
+const testTotpSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+var computeTotpTests = []struct {
+	unix     int64
+	expected string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+}
+
+func TestComputeTotp(t *testing.T) {
+	for _, tt := range computeTotpTests {
+		code, err := ComputeTotp(testTotpSecret, uint64(tt.unix/TOTP_PERIOD))
+		if err != nil {
+			t.Errorf("ComputeTotp(%d): %v", tt.unix, err)
+		} else if code != tt.expected {
+			t.Errorf("ComputeTotp(%d) = %q, expected %q", tt.unix, code, tt.expected)
+		}
+	}
+}
+
+var matchTotpTests = []struct {
+	secret, passcode string
+	unix, expected   int64
+}{
+	{testTotpSecret, "287082", 59, 1},
+	{testTotpSecret, " 287082 ", 59, 1},
+	{testTotpSecret, "287082", 89, 1},
+	{testTotpSecret, "287082", 29, 1},
+	{testTotpSecret, "287082", 150, -1},
+	{testTotpSecret, "287083", 59, -1},
+	{testTotpSecret, "28708", 59, -1},
+	{"not base32!", "287082", 59, -1},
+}
+
+func TestMatchTotp(t *testing.T) {
+	for _, tt := range matchTotpTests {
+		if step := MatchTotp(tt.secret, tt.passcode, time.Unix(tt.unix, 0)); step != tt.expected {
+			t.Errorf("MatchTotp(%q, %q, %d) = %d, expected %d", tt.secret, tt.passcode, tt.unix, step, tt.expected)
+		}
+	}
+}
@@ -27,226 +27,369 @@ func bindata_read(data []byte, name string) ([]byte, error) {
 
 func conf_app_ini() ([]byte, error) {
 	return bindata_read([]byte{
-		0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x00, 0xff, 0xb4, 0x58,
-		0xed, 0x72, 0xdb, 0xb8, 0xd5, 0xfe, 0xcf, 0xab, 0x40, 0xf4, 0xee, 0xbe,
-		0x9b, 0x74, 0x6c, 0x49, 0x76, 0x1a, 0x27, 0x6b, 0x6f, 0x66, 0x56, 0x96,
-		0x28, 0x9b, 0x8d, 0xf5, 0x11, 0x92, 0x76, 0x9a, 0x66, 0x3c, 0x1c, 0x9a,
-		0x84, 0x24, 0xd4, 0x24, 0x41, 0x13, 0x90, 0x15, 0xf5, 0x5f, 0x6f, 0xa1,
-		0xd3, 0xab, 0xe9, 0xf5, 0xf4, 0x47, 0x2f, 0xa3, 0xcf, 0x01, 0x49, 0x99,
-		0x52, 0xb4, 0xd9, 0xf4, 0x6b, 0x76, 0x27, 0x16, 0x81, 0x83, 0x83, 0x73,
-		0x9e, 0xf3, 0x8d, 0x33, 0xd6, 0xcb, 0x73, 0x96, 0x85, 0x29, 0x67, 0x7a,
-		0x11, 0x6a, 0xa6, 0x16, 0x72, 0xa5, 0x98, 0xcc, 0x18, 0x7f, 0xe4, 0xc5,
-		0x9a, 0xe5, 0xe1, 0x1c, 0x1b, 0x42, 0x27, 0xdc, 0xea, 0x4d, 0xa7, 0xc1,
-		0xb8, 0x37, 0xb2, 0xd9, 0x5b, 0x76, 0x21, 0xe7, 0xea, 0x14, 0xff, 0xb2,
-		0x0b, 0xa1, 0x99, 0xc7, 0x8b, 0x47, 0x11, 0x95, 0xfb, 0x57, 0x93, 0x8b,
-		0x09, 0xf6, 0x45, 0x3a, 0xef, 0xcc, 0x42, 0xac, 0xca, 0xac, 0x9d, 0x67,
-		0x73, 0xeb, 0x8c, 0xf5, 0x17, 0x61, 0x06, 0x4e, 0x20, 0x17, 0x33, 0xb6,
-		0x96, 0x4b, 0x56, 0x2c, 0x33, 0x96, 0xc8, 0x28, 0x4c, 0x92, 0xb5, 0xe5,
-		0x5e, 0x8f, 0x83, 0x6b, 0xcf, 0x76, 0x71, 0x72, 0x2e, 0x34, 0xa8, 0x6d,
-		0xa1, 0x17, 0xbc, 0x60, 0xad, 0x98, 0x3f, 0xb6, 0x0e, 0x58, 0x2b, 0x2f,
-		0x64, 0xdc, 0x62, 0x12, 0x0b, 0x9a, 0x2b, 0x8d, 0x95, 0x98, 0xcf, 0xc2,
-		0x65, 0x02, 0x5e, 0xaa, 0xa4, 0x31, 0x1c, 0x46, 0x93, 0x01, 0xc9, 0x86,
-		0x6f, 0xcb, 0xfa, 0x54, 0xf0, 0x5c, 0x2a, 0xa1, 0x65, 0xb1, 0xbe, 0xb5,
-		0xdc, 0xc9, 0xc4, 0xc7, 0x86, 0xe5, 0xf5, 0x5d, 0x67, 0xea, 0x07, 0xfe,
-		0xc7, 0x29, 0xd1, 0xdd, 0x85, 0x6a, 0x01, 0x42, 0x05, 0xe9, 0x79, 0x71,
-		0x6b, 0x4d, 0xdd, 0x89, 0x3f, 0xe9, 0x4f, 0xae, 0xb0, 0xb3, 0xd0, 0x3a,
-		0xb7, 0x06, 0x93, 0x51, 0xcf, 0x19, 0xe3, 0xcb, 0x08, 0xb9, 0x90, 0x4a,
-		0x1b, 0x3e, 0xc1, 0xb5, 0x4b, 0x24, 0xdf, 0x3f, 0xaf, 0xe9, 0x5f, 0xa8,
-		0xd3, 0x4e, 0xe7, 0xfb, 0xe7, 0x25, 0x39, 0x3e, 0xbe, 0x7f, 0x7e, 0xe9,
-		0xfb, 0xd3, 0x60, 0x3a, 0x71, 0xfd, 0x17, 0xaa, 0x63, 0x99, 0x8f, 0xde,
-		0x60, 0x40, 0xba, 0x59, 0x9b, 0x1d, 0x7c, 0xbc, 0xec, 0x76, 0xbb, 0x96,
-		0xe7, 0x5d, 0xd6, 0xdf, 0xc7, 0xc7, 0xd0, 0x7b, 0x20, 0x54, 0x78, 0x97,
-		0x70, 0xd6, 0x1f, 0x8c, 0x09, 0xff, 0x8c, 0x89, 0xac, 0xd6, 0x3e, 0x95,
-		0x31, 0xb7, 0x26, 0xc3, 0xe1, 0x95, 0x33, 0xb6, 0x6b, 0x55, 0x67, 0x61,
-		0xa2, 0xb8, 0x35, 0x70, 0xbc, 0xde, 0xf9, 0x95, 0x1d, 0xb8, 0x93, 0x6b,
-		0xdf, 0x76, 0xc9, 0x04, 0x9b, 0xad, 0x33, 0x76, 0xc1, 0x33, 0x5e, 0x84,
-		0x9a, 0x33, 0xa5, 0x79, 0xae, 0x4e, 0xb1, 0xf2, 0x1d, 0x8b, 0x62, 0x98,
-		0x55, 0x2f, 0x3a, 0x5a, 0x76, 0xe6, 0x30, 0x64, 0x27, 0x5a, 0x2a, 0x2d,
-		0xd3, 0x0e, 0xa9, 0xad, 0x0c, 0xc1, 0x5c, 0x1a, 0xf3, 0x7c, 0x77, 0x31,
-		0x21, 0x95, 0x3b, 0xaa, 0x88, 0x3a, 0xf9, 0xfd, 0xbc, 0x13, 0x15, 0xeb,
-		0x1c, 0x67, 0x74, 0xa2, 0x3a, 0xf3, 0x8a, 0x6d, 0x10, 0xf1, 0x42, 0xb7,
-		0x41, 0x7f, 0x18, 0x85, 0x6f, 0x75, 0xb1, 0xe4, 0xec, 0x30, 0x5e, 0x62,
-		0x43, 0xc8, 0xec, 0xed, 0x9b, 0xd7, 0x27, 0xdd, 0x45, 0x37, 0xed, 0x2a,
-		0x76, 0x48, 0xf0, 0xbd, 0x4d, 0xd7, 0xf4, 0xa7, 0xcd, 0x3f, 0x87, 0x69,
-		0x9e, 0xf0, 0x76, 0x24, 0x53, 0xab, 0x6f, 0xbb, 0x7e, 0x30, 0x74, 0xae,
-		0x48, 0x99, 0xa6, 0x14, 0x1d, 0xc3, 0x36, 0xe7, 0xa9, 0xf5, 0xce, 0xfe,
-		0xb8, 0x97, 0xe0, 0x9e, 0xaf, 0xcd, 0xfe, 0x19, 0xbb, 0xce, 0x73, 0xb8,
-		0x4a, 0x02, 0xb8, 0x12, 0x26, 0x67, 0x4c, 0x73, 0x70, 0x27, 0x85, 0xc3,
-		0x2c, 0x86, 0xd2, 0x10, 0x25, 0x62, 0x33, 0x01, 0x4c, 0x49, 0x65, 0x90,
-		0x37, 0x5c, 0x07, 0x3e, 0x66, 0x56, 0xd9, 0x0a, 0xce, 0xc6, 0x8d, 0x53,
-		0xd3, 0x32, 0xff, 0xcc, 0xa3, 0xa5, 0xe6, 0xb1, 0xe5, 0xf9, 0x3d, 0xdf,
-		0xe9, 0x07, 0xc6, 0xec, 0xd3, 0x9e, 0x7f, 0x49, 0x26, 0xb4, 0x3e, 0xc5,
-		0xa1, 0x0e, 0xe1, 0x3b, 0xfc, 0xb6, 0xe1, 0xa7, 0xe9, 0x5a, 0x3d, 0x24,
-		0xc6, 0x53, 0xa1, 0xe1, 0xbc, 0xe0, 0xaa, 0xf4, 0x56, 0x2c, 0x0a, 0xcd,
-		0x5f, 0x62, 0x43, 0xe8, 0x1f, 0x14, 0xb9, 0x7d, 0xc1, 0xa2, 0x85, 0xa4,
-		0x60, 0x19, 0x9c, 0xd7, 0x7e, 0x68, 0xce, 0x5a, 0x97, 0x13, 0x8f, 0xbc,
-		0xe0, 0xe8, 0xf8, 0x75, 0xbb, 0x8b, 0xff, 0x8e, 0x4e, 0x5f, 0xbe, 0xec,
-		0x9e, 0x58, 0x55, 0xb8, 0x91, 0x95, 0xac, 0x2a, 0x40, 0x0a, 0x29, 0xb5,
-		0x35, 0xed, 0x79, 0xde, 0x87, 0x01, 0x7b, 0x0b, 0x11, 0x86, 0x74, 0x51,
-		0xe3, 0xda, 0x2c, 0x59, 0x1f, 0x30, 0x5e, 0xc7, 0x4f, 0xe9, 0x4f, 0x24,
-		0x59, 0xc1, 0x1f, 0x96, 0xa2, 0xe0, 0xa5, 0x60, 0xf0, 0x78, 0x31, 0x5b,
-		0x1f, 0xce, 0x96, 0x49, 0xd2, 0x82, 0x13, 0x5e, 0x6d, 0x62, 0xa7, 0xa4,
-		0xaf, 0xd9, 0xd6, 0xf2, 0x1b, 0xae, 0x56, 0x05, 0x01, 0xe9, 0x6f, 0xfc,
-		0xa6, 0x1d, 0xdf, 0x01, 0x8e, 0x30, 0x4e, 0x45, 0x76, 0x6b, 0x02, 0x29,
-		0x5a, 0x16, 0x42, 0x23, 0xde, 0x9c, 0x31, 0x90, 0xbb, 0xba, 0x82, 0x27,
-		0xf6, 0xdf, 0x35, 0x5c, 0xf1, 0xd9, 0xb3, 0xfe, 0x65, 0x6f, 0x7c, 0x61,
-		0x33, 0xff, 0xd2, 0xf1, 0x98, 0x3f, 0x61, 0xef, 0x6c, 0x7b, 0xca, 0x3e,
-		0x4e, 0xae, 0x5d, 0x66, 0x74, 0x1b, 0xf4, 0xfc, 0x1e, 0xf3, 0x7a, 0x43,
-		0xfb, 0xd9, 0x33, 0xcb, 0xb3, 0xfb, 0xae, 0xed, 0x07, 0xb0, 0x3e, 0x18,
-		0x3c, 0xfb, 0xbf, 0x9f, 0x87, 0x03, 0xfb, 0x83, 0x8b, 0xff, 0xff, 0xff,
-		0x37, 0xcf, 0xc1, 0xa9, 0xb7, 0xd4, 0xf2, 0x30, 0x91, 0x73, 0x44, 0x47,
-		0xc1, 0x53, 0x9e, 0xde, 0x41, 0xd7, 0x38, 0x5c, 0x2b, 0x0b, 0xbe, 0xef,
-		0x8c, 0x03, 0xd7, 0x1e, 0xd9, 0xa3, 0x73, 0x84, 0xc2, 0xa0, 0xf7, 0xd1,
-		0xc3, 0xf9, 0xd7, 0x56, 0x7f, 0x32, 0x79, 0xe7, 0xd8, 0x26, 0xc7, 0x34,
-		0x20, 0x0d, 0xc2, 0x15, 0x57, 0x32, 0xe5, 0xf5, 0xf6, 0xe6, 0x5c, 0x93,
-		0x46, 0x64, 0x51, 0xc1, 0x63, 0x41, 0xa8, 0x94, 0xc9, 0x02, 0xd6, 0xbb,
-		0xb5, 0x7a, 0x7d, 0xdf, 0xb9, 0xb1, 0x83, 0x3e, 0x60, 0x0b, 0xae, 0xe8,
-		0xd7, 0xc8, 0x19, 0x23, 0xfa, 0xe8, 0xb6, 0xa3, 0x37, 0x5d, 0xcb, 0xb5,
-		0x3d, 0x9b, 0x7c, 0x86, 0xac, 0xf4, 0x8b, 0x44, 0x70, 0x5d, 0xf0, 0x63,
-		0x19, 0xe7, 0x31, 0xd3, 0x92, 0x21, 0x57, 0xce, 0x44, 0x91, 0x32, 0x7e,
-		0x98, 0x86, 0x22, 0x61, 0x33, 0x18, 0xa0, 0xe0, 0x73, 0xa1, 0x74, 0x19,
-		0x4e, 0xe0, 0x79, 0xe1, 0x78, 0x14, 0xe0, 0x36, 0x32, 0xcd, 0x15, 0xb8,
-		0x8e, 0x87, 0x8e, 0x3b, 0x6a, 0xe0, 0x3b, 0x90, 0x5c, 0xb1, 0x4c, 0x6a,
-		0x86, 0x9c, 0x2a, 0x57, 0xd5, 0x61, 0x5c, 0x40, 0x81, 0x60, 0xac, 0xc4,
-		0xa0, 0x89, 0x89, 0x8c, 0x28, 0x92, 0xcb, 0x4c, 0x97, 0x56, 0xdd, 0x64,
-		0x0f, 0xc3, 0xde, 0x85, 0xc7, 0x4f, 0xc6, 0x0d, 0xa6, 0x46, 0xc4, 0x14,
-		0x91, 0xc7, 0x94, 0x98, 0x9b, 0x7c, 0x04, 0x51, 0x1f, 0x05, 0x5f, 0x81,
-		0xed, 0x5a, 0x2f, 0x44, 0x36, 0x6f, 0x43, 0xb2, 0xf7, 0xd7, 0x8e, 0x6b,
-		0x07, 0x9e, 0x73, 0x31, 0x06, 0xfc, 0x37, 0x8e, 0xfd, 0xa1, 0xc1, 0xa1,
-		0x1f, 0x46, 0x88, 0xb3, 0xf0, 0x11, 0x6e, 0x03, 0x59, 0x14, 0xcb, 0x45,
-		0xa4, 0x97, 0x05, 0xb7, 0xec, 0xb1, 0xb9, 0xb7, 0xdf, 0xeb, 0x5f, 0xda,
-		0x41, 0xef, 0x06, 0xc6, 0x77, 0x1b, 0xa7, 0x46, 0x84, 0x01, 0x94, 0x11,
-		0x33, 0x11, 0x95, 0xfa, 0x57, 0xf4, 0xe3, 0x89, 0xef, 0x0c, 0x3f, 0x06,
-		0x84, 0xc1, 0x86, 0xdc, 0xfa, 0x44, 0x90, 0x51, 0x16, 0x2f, 0x89, 0x06,
-		0x0d, 0x46, 0xe7, 0xcb, 0xd9, 0xcc, 0xe4, 0x87, 0x6c, 0x8e, 0x48, 0x47,
-		0x82, 0x88, 0x50, 0x89, 0x32, 0x9e, 0x1c, 0xb0, 0x7b, 0xce, 0x73, 0x2a,
-		0x48, 0x90, 0x49, 0x98, 0x7c, 0x50, 0x55, 0xa6, 0x58, 0x66, 0x3f, 0x68,
-		0x76, 0x9f, 0x01, 0xc3, 0x15, 0x55, 0x44, 0xb3, 0xd9, 0x86, 0x4b, 0x8e,
-		0x07, 0xc1, 0xf9, 0xf5, 0x70, 0x48, 0x39, 0xd6, 0x26, 0x8c, 0x8e, 0xc8,
-		0x86, 0x63, 0xaa, 0x9c, 0x88, 0x1b, 0x24, 0x9d, 0x35, 0x0c, 0x09, 0x80,
-		0x8c, 0xf9, 0xca, 0x92, 0xe9, 0x5d, 0x9f, 0xff, 0xce, 0xee, 0xfb, 0xa6,
-		0x60, 0xd4, 0xe5, 0xf3, 0x85, 0xaa, 0xd5, 0x2b, 0x4b, 0x0f, 0x25, 0x69,
-		0x3a, 0x72, 0xca, 0x54, 0xaa, 0xf3, 0xf6, 0x9c, 0x7e, 0x53, 0x72, 0x3c,
-		0x7d, 0xf5, 0xe6, 0x35, 0xf6, 0xde, 0xbf, 0xaf, 0x36, 0x1e, 0x1e, 0xcc,
-		0xea, 0xf1, 0xab, 0x3a, 0x57, 0xd4, 0x6c, 0x66, 0x85, 0x4c, 0x61, 0xe0,
-		0x18, 0xf1, 0xaf, 0xac, 0xa1, 0x3b, 0x19, 0x3d, 0xed, 0x41, 0xf1, 0xa5,
-		0xf1, 0x31, 0x12, 0x92, 0xfc, 0x20, 0x0f, 0x95, 0x5a, 0xc9, 0x22, 0xae,
-		0xb3, 0xc9, 0x26, 0x93, 0x50, 0x66, 0x93, 0xe1, 0x52, 0x2f, 0xbe, 0xc4,
-		0xb0, 0xda, 0x68, 0xa3, 0x34, 0x2f, 0x96, 0x77, 0x5f, 0xee, 0xf7, 0xaf,
-		0x1c, 0x7b, 0xec, 0x07, 0x8e, 0xe1, 0x52, 0x7d, 0x94, 0xf1, 0x5b, 0x16,
-		0xdd, 0xc9, 0xd4, 0xb8, 0xbc, 0xc9, 0xdb, 0xa8, 0x95, 0x61, 0x2e, 0x2a,
-		0x56, 0xa4, 0x4f, 0x87, 0xe4, 0xb3, 0x7a, 0xd7, 0xfe, 0x65, 0x55, 0x59,
-		0x6b, 0xb2, 0x06, 0x89, 0x89, 0xf4, 0x8e, 0x11, 0xa2, 0x43, 0xff, 0xc8,
-		0x42, 0xfc, 0x89, 0x5b, 0xfe, 0xe4, 0x9d, 0x3d, 0xfe, 0xc6, 0x43, 0x51,
-		0x04, 0x6c, 0x02, 0x2d, 0xef, 0x79, 0x66, 0x99, 0xa2, 0xa8, 0x59, 0x94,
-		0x08, 0x8e, 0x18, 0x10, 0x71, 0x59, 0x28, 0x38, 0x62, 0x43, 0x1b, 0x28,
-		0xb1, 0x5f, 0xb3, 0x43, 0x48, 0x2a, 0x89, 0x52, 0x15, 0x53, 0x71, 0x91,
-		0x28, 0x33, 0x0a, 0xa5, 0x4e, 0xce, 0xcb, 0xe2, 0xd5, 0x41, 0x5d, 0xfe,
-		0x23, 0x8f, 0xf4, 0x06, 0x1e, 0xb3, 0xf3, 0x1f, 0xc3, 0xb3, 0x5a, 0xad,
-		0x2a, 0x56, 0x00, 0x4a, 0x99, 0x8b, 0x8c, 0x0e, 0x84, 0x93, 0xc8, 0x66,
-		0xb2, 0xcd, 0x8d, 0x7f, 0x7d, 0x33, 0x39, 0xa4, 0xa4, 0xf2, 0xb7, 0x0f,
-		0xe2, 0x2a, 0x0f, 0x6c, 0x29, 0x25, 0x4b, 0xc8, 0x8e, 0x0d, 0x97, 0xbd,
-		0x18, 0x7f, 0xf5, 0x54, 0x05, 0x71, 0x05, 0xc9, 0xc3, 0xc3, 0xbf, 0x0d,
-		0x07, 0x72, 0x98, 0x71, 0x7e, 0xf6, 0xf7, 0xbf, 0xfd, 0xe5, 0x1f, 0x7f,
-		0xfe, 0x2b, 0x25, 0xfd, 0x3d, 0x3e, 0x52, 0x84, 0xf9, 0xa2, 0x0a, 0x8c,
-		0x4a, 0x82, 0x76, 0xb7, 0xe1, 0x22, 0x67, 0x6c, 0xaf, 0x93, 0xec, 0x3d,
-		0x55, 0x4a, 0x8e, 0x13, 0x3c, 0x8b, 0xc8, 0x31, 0x56, 0x5c, 0xdc, 0xc9,
-		0x7d, 0xa8, 0xc1, 0x0f, 0xb2, 0xb6, 0xae, 0xcf, 0x47, 0x73, 0x71, 0x78,
-		0x57, 0x3b, 0xda, 0xf1, 0xaf, 0xb8, 0xe7, 0xd7, 0x8f, 0x6e, 0x39, 0x69,
-		0x85, 0xa0, 0x5e, 0x09, 0xad, 0xf7, 0x25, 0xb6, 0x7f, 0x01, 0xc6, 0x7d,
-		0x96, 0x47, 0x0c, 0x56, 0xac, 0x9f, 0x50, 0xf8, 0x15, 0xe1, 0x7f, 0xe1,
-		0xcc, 0x3e, 0xa9, 0x0d, 0x76, 0xff, 0x0b, 0x99, 0x0d, 0xe3, 0x86, 0xdd,
-		0xbe, 0x41, 0xe4, 0x2f, 0x8f, 0x6c, 0x4b, 0x1c, 0x51, 0x79, 0xda, 0xea,
-		0xe5, 0x78, 0x8a, 0xa9, 0xa1, 0x6c, 0x99, 0x90, 0xd7, 0xf1, 0x43, 0x96,
-		0xab, 0x86, 0x72, 0x67, 0xf8, 0xa8, 0x88, 0xad, 0xde, 0xa0, 0x37, 0xf5,
-		0x4d, 0x46, 0x2d, 0x57, 0xea, 0x0e, 0xaa, 0xda, 0xaf, 0xda, 0xb2, 0x8b,
-		0x3e, 0xea, 0x03, 0xf0, 0x7b, 0x0c, 0x13, 0x2a, 0x14, 0x48, 0x3a, 0x32,
-		0x8b, 0xd5, 0x16, 0xc7, 0x93, 0x2e, 0xda, 0x27, 0x70, 0xba, 0xe9, 0x91,
-		0x22, 0x27, 0xdd, 0x9a, 0x51, 0x29, 0x8b, 0xc9, 0x55, 0x4d, 0x59, 0xc0,
-		0x20, 0x43, 0x0e, 0x42, 0x7d, 0x64, 0xd4, 0x5c, 0x6f, 0xca, 0xc0, 0x19,
-		0x33, 0x07, 0x4e, 0x59, 0xeb, 0xf4, 0xa4, 0xfb, 0xf2, 0xc7, 0x16, 0x16,
-		0xea, 0x53, 0x58, 0x7b, 0xea, 0x32, 0x8f, 0x8e, 0x8e, 0x8f, 0x8e, 0x5a,
-		0x55, 0x45, 0x31, 0x0d, 0x8e, 0x52, 0x60, 0xb6, 0x1f, 0x0f, 0xca, 0x23,
-		0x4f, 0xb8, 0x94, 0xb0, 0x54, 0x8d, 0xef, 0x3e, 0x4c, 0x30, 0x21, 0xdd,
-		0x38, 0x03, 0x03, 0x8a, 0xc9, 0x40, 0x67, 0x6c, 0x5a, 0xc8, 0x47, 0x11,
-		0x83, 0xa9, 0xe9, 0x75, 0xe6, 0x4c, 0xe6, 0x24, 0xb9, 0x2a, 0x85, 0xc3,
-		0x99, 0x53, 0xd3, 0xbe, 0x2c, 0xc2, 0x47, 0x2a, 0x56, 0xeb, 0x9a, 0x6a,
-		0xcd, 0x69, 0x24, 0x24, 0x16, 0xa8, 0x84, 0xa5, 0x7c, 0x4f, 0x1d, 0x3d,
-		0x7a, 0xdd, 0xf6, 0xbc, 0x8d, 0x4e, 0x97, 0xba, 0xd2, 0x6a, 0x57, 0xb5,
-		0x9e, 0xf4, 0xaf, 0x78, 0x24, 0xe2, 0x9e, 0x97, 0x4b, 0x55, 0xd5, 0x35,
-		0x48, 0x1d, 0xb0, 0x5c, 0xca, 0xc4, 0x83, 0xfb, 0x1c, 0x6c, 0x2a, 0x63,
-		0xcd, 0xf0, 0x09, 0xa3, 0x93, 0x97, 0xaf, 0x7f, 0x3c, 0x38, 0xea, 0x76,
-		0x0f, 0x42, 0x8c, 0x13, 0x9f, 0x05, 0x37, 0x60, 0x92, 0xde, 0xa7, 0xe8,
-		0x10, 0x0f, 0xf1, 0xf7, 0x30, 0x2e, 0x04, 0x58, 0x76, 0xcc, 0x22, 0x8b,
-		0x55, 0x56, 0xdf, 0x8a, 0xde, 0x0d, 0x0d, 0x52, 0xcd, 0x91, 0x3a, 0xf7,
-		0xd3, 0xfa, 0x9a, 0x9f, 0x6b, 0x61, 0x03, 0x6d, 0x3a, 0xf4, 0x0d, 0x5a,
-		0x65, 0x63, 0x77, 0x51, 0x37, 0xda, 0xb5, 0x4a, 0xb8, 0xd3, 0xab, 0x74,
-		0x8f, 0xa4, 0xbc, 0x17, 0xdc, 0xd4, 0xf4, 0xba, 0x73, 0xad, 0x1a, 0x56,
-		0x11, 0x90, 0x9e, 0x01, 0xfa, 0x56, 0xa1, 0xe9, 0x84, 0x53, 0x36, 0x34,
-		0xa8, 0x05, 0x1b, 0xe0, 0xe0, 0x76, 0x26, 0x3a, 0x2a, 0x8f, 0x6c, 0xd8,
-		0xad, 0x8a, 0xd1, 0x92, 0x21, 0xc2, 0xf2, 0xda, 0xb5, 0x1b, 0x6d, 0x94,
-		0x9d, 0x99, 0xc1, 0x54, 0x51, 0xe5, 0x34, 0xf7, 0x6f, 0x9d, 0xa5, 0xc9,
-		0xaf, 0x6e, 0xd0, 0xa8, 0xf3, 0x2d, 0xb9, 0xe0, 0xb8, 0xd9, 0x78, 0x12,
-		0x1d, 0x01, 0xa0, 0x05, 0x5a, 0x91, 0x3a, 0x0a, 0xb6, 0x98, 0xbc, 0x39,
-		0xf9, 0x2d, 0x46, 0xe2, 0x8b, 0x7e, 0x50, 0x07, 0x40, 0xe0, 0x3b, 0x46,
-		0xad, 0x72, 0xe3, 0x89, 0x4b, 0x22, 0x66, 0xdc, 0xf0, 0xd9, 0x73, 0xdc,
-		0xb3, 0x3d, 0x0f, 0x1d, 0x2c, 0xfa, 0xed, 0xa1, 0xbd, 0x7b, 0x7e, 0x83,
-		0x41, 0x0c, 0x1f, 0x53, 0x0b, 0x36, 0x5b, 0x66, 0xd1, 0xc1, 0xc6, 0xcf,
-		0xd5, 0x22, 0x3c, 0x22, 0xef, 0xc6, 0xdf, 0xe3, 0x57, 0x27, 0x95, 0x7b,
-		0xc7, 0xaf, 0x5a, 0xcd, 0x3b, 0x88, 0x66, 0x73, 0x85, 0x33, 0x08, 0x2e,
-		0x7b, 0xde, 0xe5, 0xf0, 0x7a, 0xdc, 0xc7, 0x25, 0x66, 0xeb, 0x49, 0x46,
-		0x73, 0x01, 0x86, 0xd4, 0x2d, 0x11, 0xc9, 0x10, 0x05, 0x42, 0x18, 0xfd,
-		0x5a, 0xe9, 0x1a, 0xbb, 0xbc, 0xcc, 0xbc, 0x83, 0x30, 0xac, 0x7a, 0x64,
-		0x0a, 0x43, 0x9f, 0x86, 0xd4, 0x24, 0x8c, 0x38, 0x35, 0xde, 0xd5, 0xba,
-		0x71, 0x8d, 0xa7, 0x29, 0xaf, 0xf4, 0xe8, 0x52, 0xe2, 0x07, 0x91, 0x89,
-		0xe5, 0x4e, 0x40, 0x56, 0xfb, 0xb8, 0xcc, 0xbd, 0x71, 0xfa, 0x84, 0x48,
-		0xd5, 0x79, 0xd6, 0xbd, 0xff, 0x85, 0xbb, 0xd3, 0x7f, 0x5b, 0x9f, 0xd0,
-		0x3e, 0x95, 0x0f, 0x27, 0xd5, 0xe4, 0xdb, 0x48, 0x08, 0x55, 0x57, 0xd4,
-		0xcc, 0x08, 0x94, 0x86, 0x0c, 0x76, 0x68, 0x54, 0x4b, 0x39, 0xea, 0x29,
-		0x79, 0x47, 0x94, 0xfa, 0x6c, 0x39, 0x59, 0xc0, 0x95, 0xd2, 0x34, 0x24,
-		0xc5, 0x14, 0xcf, 0x43, 0xf3, 0x4c, 0x91, 0x82, 0x52, 0xe4, 0xf0, 0x34,
-		0x7a, 0xef, 0x50, 0x75, 0xe8, 0x54, 0xc7, 0x0e, 0x4c, 0xdc, 0xb7, 0xac,
-		0x6a, 0x5a, 0xad, 0x56, 0xff, 0x9b, 0x4d, 0xfe, 0x4e, 0x7f, 0xdf, 0x35,
-		0x7e, 0x53, 0x2b, 0xee, 0x17, 0x30, 0x03, 0xa9, 0x39, 0xe0, 0x77, 0xcb,
-		0x39, 0xfd, 0x70, 0xd0, 0x61, 0xd1, 0xdf, 0x0f, 0x61, 0x61, 0xf4, 0xb7,
-		0x8b, 0x42, 0x16, 0xf4, 0xa3, 0x8f, 0x49, 0x18, 0x83, 0xcb, 0x6e, 0x6a,
-		0x2c, 0x39, 0x58, 0x57, 0xf6, 0x8d, 0x4d, 0xe9, 0xdd, 0x7c, 0x5a, 0x75,
-		0x8a, 0xaf, 0xb1, 0x31, 0xaa, 0x97, 0xc3, 0x19, 0x99, 0xa1, 0x5d, 0xad,
-		0xdf, 0x6e, 0x8e, 0x6d, 0x4e, 0x18, 0x34, 0x76, 0xc9, 0x69, 0xb1, 0x41,
-		0x4b, 0x8f, 0x27, 0x75, 0x7e, 0xc0, 0x76, 0x39, 0xb9, 0xe3, 0x87, 0x71,
-		0x2d, 0x7a, 0xed, 0x30, 0x81, 0xad, 0x18, 0x8a, 0xa3, 0x4c, 0x61, 0x81,
-		0x98, 0xa8, 0x58, 0x21, 0x35, 0x7e, 0x3f, 0x57, 0xa8, 0xf7, 0x91, 0x01,
-		0x74, 0x26, 0x69, 0xa8, 0x84, 0xcb, 0xd6, 0x49, 0xfb, 0xc5, 0x97, 0x09,
-		0x00, 0xd3, 0x77, 0xe0, 0x4e, 0xfc, 0x9e, 0xdf, 0x88, 0xfc, 0x51, 0xf8,
-		0x19, 0xf1, 0x9a, 0x21, 0x5d, 0x2d, 0xcd, 0x98, 0x0e, 0x56, 0x0a, 0x5c,
-		0x60, 0x60, 0x92, 0x73, 0x8b, 0x87, 0x81, 0x1b, 0x80, 0x8f, 0x7a, 0xbf,
-		0x0f, 0xe8, 0x95, 0xcb, 0xab, 0x4d, 0x60, 0x8c, 0x40, 0x8c, 0x14, 0x32,
-		0x35, 0x02, 0x4d, 0xcc, 0xf4, 0xd7, 0xf8, 0x1c, 0xbf, 0x41, 0x39, 0x09,
-		0x33, 0x30, 0x64, 0x3f, 0xfd, 0x84, 0xaf, 0x03, 0x86, 0x78, 0x1e, 0x9d,
-		0x1b, 0xbe, 0x9e, 0xf3, 0x07, 0x64, 0xa8, 0x4b, 0x67, 0x68, 0x9e, 0xdc,
-		0xde, 0x98, 0x80, 0x9d, 0xa7, 0xd4, 0xef, 0x91, 0xd6, 0x31, 0x3a, 0xeb,
-		0xf5, 0x97, 0x7a, 0x0d, 0x30, 0x6b, 0x7e, 0xfc, 0x42, 0x33, 0xfb, 0x73,
-		0x2e, 0x50, 0x51, 0xcc, 0xc3, 0x03, 0x89, 0x43, 0x0c, 0x48, 0x96, 0xe7,
-		0x31, 0x4f, 0x38, 0x4d, 0xd9, 0x33, 0x1a, 0xbe, 0x53, 0x88, 0x4d, 0x14,
-		0xdb, 0x70, 0xbd, 0x36, 0xc2, 0x6c, 0x9e, 0x27, 0x1a, 0x1e, 0x90, 0xed,
-		0x33, 0x7f, 0xd6, 0xb0, 0xe7, 0x19, 0x73, 0x79, 0x55, 0xf5, 0xcb, 0x92,
-		0x4f, 0x0f, 0x05, 0xe5, 0x5b, 0x6d, 0x05, 0x48, 0x8a, 0x14, 0x14, 0xce,
-		0xf9, 0x9e, 0xe4, 0xee, 0xda, 0x28, 0x2e, 0x63, 0x0c, 0xa4, 0x01, 0x52,
-		0xce, 0xc8, 0x6b, 0xbe, 0x13, 0xfa, 0x38, 0x8f, 0x38, 0x2c, 0x36, 0xbc,
-		0x57, 0x0b, 0x9e, 0x35, 0xdb, 0x0b, 0x30, 0x49, 0x70, 0xdd, 0xd7, 0xb8,
-		0x36, 0xcb, 0x45, 0x15, 0x32, 0x3a, 0xca, 0x29, 0x1c, 0x96, 0x99, 0xf8,
-		0x5c, 0xe6, 0x85, 0x65, 0x9c, 0xef, 0xc4, 0x04, 0x91, 0x34, 0x5f, 0x5f,
-		0xf1, 0x0d, 0x06, 0x97, 0xcd, 0x6e, 0xa6, 0x7e, 0x3f, 0xdd, 0xbc, 0x4b,
-		0x99, 0x34, 0xb3, 0x83, 0x13, 0x2d, 0x6e, 0xe1, 0xf4, 0xb5, 0xc9, 0x7c,
-		0x5b, 0x84, 0x81, 0x08, 0xe7, 0x19, 0x2e, 0x14, 0x51, 0x0d, 0x5e, 0x39,
-		0x54, 0x9b, 0x34, 0xd9, 0x6a, 0x4c, 0xf1, 0x5f, 0x25, 0xdc, 0x19, 0xeb,
-		0xb7, 0xa7, 0xf4, 0x6f, 0x9f, 0xc4, 0x4b, 0x0b, 0x73, 0xea, 0x28, 0x90,
-		0xff, 0xa2, 0x30, 0x63, 0x77, 0xa4, 0x26, 0x27, 0xf8, 0xd0, 0x24, 0xf1,
-		0x2a, 0x27, 0x7e, 0x6a, 0x1d, 0xfd, 0xdc, 0x78, 0x4a, 0x6d, 0x1d, 0xb4,
-		0x8e, 0xb7, 0xbe, 0x6f, 0xc9, 0x2e, 0xb6, 0x73, 0x63, 0xbb, 0x5e, 0x13,
-		0xba, 0x4d, 0x5e, 0xde, 0x85, 0xef, 0xe9, 0x59, 0x73, 0x03, 0xe1, 0xc0,
-		0xa5, 0xe3, 0xa6, 0x59, 0x87, 0x81, 0xe9, 0xef, 0x3f, 0x03, 0x00, 0x00,
-		0xff, 0xff, 0x77, 0x1f, 0xe2, 0xa5, 0x2d, 0x18, 0x00, 0x00,
+		0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x00, 0xff, 0xb5, 0x5a,
+		0xeb, 0x72, 0xdb, 0xc8, 0x95, 0xfe, 0x8f, 0xa7, 0x68, 0x6b, 0x33, 0x89,
+		0xbd, 0xc5, 0x9b, 0xe4, 0xb1, 0xec, 0x91, 0xe3, 0xaa, 0xa1, 0x48, 0x48,
+		0x42, 0xcc, 0xdb, 0x90, 0x94, 0x6d, 0xed, 0x94, 0x8b, 0x05, 0x01, 0x4d,
+		0x12, 0x11, 0x89, 0x86, 0xd1, 0x80, 0x25, 0x4e, 0xcd, 0x9f, 0xbc, 0xc2,
+		0xd6, 0x3e, 0xcd, 0x3e, 0xcf, 0xfe, 0xd8, 0xc7, 0xd8, 0xef, 0x9c, 0x6e,
+		0x80, 0x20, 0x45, 0x5f, 0x92, 0x4d, 0x6a, 0xa6, 0x2c, 0x12, 0xe8, 0x3e,
+		0x7d, 0xae, 0xdf, 0xb9, 0x34, 0x5f, 0x8b, 0x76, 0x92, 0x88, 0xd8, 0x5f,
+		0x4b, 0x91, 0x2d, 0xfd, 0x4c, 0xe8, 0xa5, 0xba, 0xd7, 0x42, 0xc5, 0x42,
+		0x7e, 0x96, 0xe9, 0x46, 0x24, 0xfe, 0x02, 0x2f, 0xa2, 0x6c, 0x25, 0x9d,
+		0xf6, 0x68, 0x34, 0x1b, 0xb4, 0xfb, 0xae, 0x78, 0x23, 0x2e, 0xd5, 0x42,
+		0x9f, 0xe1, 0x5f, 0x71, 0x19, 0x65, 0x62, 0x22, 0xd3, 0xcf, 0x51, 0x60,
+		0xde, 0xf7, 0x86, 0x97, 0x43, 0xbc, 0x8f, 0xd6, 0x8b, 0xe6, 0xdc, 0xc7,
+		0x53, 0x15, 0x37, 0x92, 0x78, 0xe1, 0xbc, 0x16, 0x9d, 0xa5, 0x1f, 0x83,
+		0x12, 0x96, 0x47, 0x73, 0xb1, 0x51, 0xb9, 0x48, 0xf3, 0x58, 0xac, 0x54,
+		0xe0, 0xaf, 0x56, 0x1b, 0x67, 0x7c, 0x3d, 0x98, 0x5d, 0x4f, 0xdc, 0x31,
+		0x76, 0x2e, 0xa2, 0x0c, 0xab, 0xdd, 0x28, 0x5b, 0xca, 0x54, 0x1c, 0x85,
+		0xf2, 0xf3, 0x51, 0x4d, 0x1c, 0x25, 0xa9, 0x0a, 0x8f, 0x84, 0xc2, 0x83,
+		0x4c, 0xea, 0x0c, 0x4f, 0x42, 0x39, 0xf7, 0xf3, 0x15, 0x68, 0x69, 0xb3,
+		0x86, 0x29, 0xf4, 0x87, 0x5d, 0xe2, 0x0d, 0xdf, 0x1d, 0xe7, 0xd7, 0x54,
+		0x26, 0x4a, 0x47, 0x99, 0x4a, 0x37, 0x1f, 0x9d, 0xf1, 0x70, 0x38, 0xc5,
+		0x0b, 0x67, 0xd2, 0x19, 0x7b, 0xa3, 0xe9, 0x6c, 0x7a, 0x33, 0xa2, 0x75,
+		0xb7, 0xbe, 0x5e, 0xe2, 0xa8, 0xae, 0x25, 0xb5, 0xf6, 0x1f, 0x84, 0x8e,
+		0x7e, 0x93, 0x42, 0xcd, 0xad, 0xe8, 0x5b, 0x12, 0x22, 0x8a, 0xc5, 0x5a,
+		0x2e, 0xfc, 0xdb, 0x0d, 0xce, 0xaf, 0x89, 0x16, 0xbe, 0xf8, 0xb1, 0x16,
+		0x79, 0xbc, 0x8a, 0xd6, 0x51, 0x26, 0xc3, 0x06, 0xe8, 0x8c, 0x72, 0xbd,
+		0x94, 0xda, 0xe8, 0x70, 0xed, 0xdf, 0xc9, 0xea, 0xf6, 0x95, 0x9f, 0x2e,
+		0x20, 0x0e, 0xde, 0x41, 0x66, 0xda, 0x22, 0xfc, 0x94, 0x16, 0xfc, 0x55,
+		0x06, 0xd8, 0xed, 0xf4, 0xdb, 0x1f, 0x66, 0x13, 0xef, 0x3f, 0x88, 0xa9,
+		0xd6, 0x1e, 0x47, 0x99, 0xca, 0xfc, 0x55, 0xc9, 0x57, 0x49, 0x32, 0xc2,
+		0x49, 0x25, 0x9f, 0xb9, 0x06, 0x6d, 0x45, 0xff, 0x2f, 0xfc, 0x38, 0xfa,
+		0xcd, 0xcf, 0x22, 0x58, 0xef, 0x1b, 0x1c, 0x3b, 0xa4, 0xee, 0xd9, 0xfe,
+		0xc1, 0x91, 0x86, 0x39, 0xd4, 0x3d, 0xce, 0xcb, 0xa4, 0xf0, 0xc3, 0x75,
+		0x14, 0x47, 0x3a, 0x4b, 0x7d, 0x1c, 0x08, 0xc1, 0x94, 0x90, 0x21, 0x38,
+		0x0f, 0x72, 0x9d, 0xa9, 0xb5, 0xc0, 0x99, 0x38, 0xbc, 0xae, 0xa3, 0x50,
+		0x92, 0xcd, 0xc4, 0x52, 0xa9, 0x3b, 0xbd, 0xcf, 0x63, 0x0d, 0x44, 0xe9,
+		0x85, 0xd0, 0x41, 0x1a, 0x25, 0x99, 0x36, 0x62, 0xc3, 0xf0, 0xb7, 0x1b,
+		0x28, 0x43, 0x1a, 0xce, 0x59, 0x63, 0x78, 0xa8, 0xd9, 0xab, 0x84, 0x56,
+		0x22, 0x56, 0xf0, 0x3f, 0x29, 0xe4, 0x4a, 0x4b, 0x11, 0xf8, 0xc6, 0x13,
+		0xcd, 0xe1, 0xd8, 0xb4, 0x76, 0xba, 0xde, 0xa4, 0x7d, 0xde, 0x73, 0x67,
+		0x97, 0xde, 0x74, 0x76, 0x35, 0x1c, 0xbe, 0x9d, 0x80, 0xfd, 0xb9, 0x8f,
+		0xc5, 0x30, 0xbb, 0x61, 0xeb, 0xa3, 0x33, 0x1a, 0x0f, 0xa7, 0xc3, 0xce,
+		0xb0, 0x87, 0x57, 0xcb, 0x2c, 0x4b, 0x9c, 0xee, 0xb0, 0xdf, 0xf6, 0x06,
+		0xf8, 0xc6, 0x2e, 0xb7, 0x54, 0x3a, 0x63, 0xaf, 0x98, 0x5d, 0x8f, 0x69,
+		0xc9, 0x0f, 0x4f, 0x8b, 0xf5, 0xcf, 0xf4, 0x59, 0xb3, 0xf9, 0xc3, 0x53,
+		0xb3, 0x1c, 0x5f, 0x7e, 0x78, 0x7a, 0x35, 0x9d, 0x8e, 0x66, 0xa3, 0xe1,
+		0x78, 0xfa, 0x4c, 0x37, 0x1d, 0xfe, 0xd2, 0xee, 0x76, 0xc9, 0x53, 0x9d,
+		0xf2, 0x0d, 0xbe, 0x3c, 0x6f, 0xb5, 0x5a, 0xce, 0x64, 0x72, 0x55, 0x7c,
+		0x3f, 0x39, 0xb1, 0xfa, 0xbc, 0x5d, 0x49, 0xd1, 0xe9, 0x0e, 0x48, 0x06,
+		0x36, 0x8a, 0xf5, 0xe5, 0xb5, 0x0a, 0xa5, 0x33, 0xbc, 0xb8, 0xe8, 0x79,
+		0x03, 0xb7, 0x70, 0x5c, 0x23, 0x43, 0x21, 0xdd, 0x78, 0x78, 0x3d, 0x85,
+		0x89, 0x10, 0x50, 0xe5, 0xab, 0xd7, 0xe2, 0x52, 0xc6, 0x12, 0xf6, 0x90,
+		0x42, 0x67, 0x32, 0xd1, 0x67, 0x78, 0xf2, 0x07, 0x11, 0x84, 0x08, 0xd2,
+		0x6c, 0xd9, 0xcc, 0x54, 0x73, 0x01, 0x05, 0x36, 0x8d, 0x81, 0x9a, 0x24,
+		0xb6, 0xe6, 0x05, 0x0b, 0xc5, 0x3a, 0xff, 0xc3, 0xe5, 0x90, 0x44, 0x6e,
+		0xea, 0x34, 0x68, 0x26, 0x77, 0x8b, 0x66, 0x90, 0x6e, 0x12, 0xec, 0xc9,
+		0x56, 0xba, 0xb9, 0xb0, 0x64, 0x67, 0x81, 0x4c, 0xb3, 0x06, 0xd6, 0xd7,
+		0x03, 0xff, 0x4d, 0x96, 0xe6, 0x52, 0xd4, 0xc3, 0x3c, 0x65, 0x87, 0x7a,
+		0xf3, 0xea, 0xe5, 0x69, 0x6b, 0xd9, 0x5a, 0xb7, 0xb4, 0xa8, 0x93, 0xfa,
+		0xde, 0xac, 0x37, 0xf4, 0xa7, 0x21, 0x1f, 0xfc, 0x75, 0xb2, 0x92, 0x8d,
+		0x40, 0xad, 0x9d, 0x8e, 0x3b, 0x9e, 0xce, 0x2e, 0xbc, 0x1e, 0x09, 0x53,
+		0xe5, 0xa2, 0xc9, 0x64, 0x13, 0x98, 0xee, 0xad, 0x7b, 0x73, 0x70, 0xc1,
+		0x9d, 0xdc, 0xf0, 0xfb, 0xd7, 0xe2, 0x3a, 0x49, 0x60, 0xec, 0x15, 0xd4,
+		0xb5, 0x22, 0x6f, 0xca, 0x24, 0xa8, 0x93, 0xc0, 0x7e, 0x1c, 0x42, 0x68,
+		0xb0, 0x12, 0x88, 0x79, 0x04, 0x9d, 0x92, 0xc8, 0x58, 0x5e, 0x01, 0x02,
+		0x72, 0x28, 0x7a, 0x2a, 0xee, 0x01, 0x1d, 0xd2, 0x38, 0x13, 0x1e, 0xcb,
+		0x07, 0x19, 0xe4, 0xe4, 0xf1, 0x93, 0x69, 0x7b, 0xea, 0x75, 0x66, 0x6c,
+		0xf6, 0x51, 0x7b, 0x7a, 0x45, 0x26, 0x74, 0x7e, 0x0d, 0xfd, 0xcc, 0x07,
+		0x12, 0xc8, 0x8f, 0x15, 0xd4, 0x59, 0x6f, 0xf4, 0xa7, 0x15, 0xe3, 0x0e,
+		0x24, 0x5c, 0xa4, 0x52, 0x1b, 0xec, 0xc1, 0x43, 0xc4, 0xc5, 0x73, 0xbc,
+		0x88, 0xb2, 0x3f, 0x69, 0x02, 0xb1, 0x54, 0x04, 0x4b, 0x45, 0xd0, 0xd7,
+		0x3d, 0x2f, 0x50, 0x85, 0xf7, 0x3a, 0x57, 0xc3, 0x09, 0x79, 0xc1, 0xf1,
+		0xc9, 0xcb, 0x46, 0x0b, 0xff, 0x1d, 0x9f, 0x3d, 0x7f, 0xde, 0x3a, 0x75,
+		0x2c, 0x78, 0x92, 0x95, 0x1c, 0x0b, 0x77, 0xa9, 0x52, 0x99, 0x33, 0x6a,
+		0x4f, 0x26, 0xef, 0xbb, 0xe2, 0x0d, 0x58, 0xb8, 0xa0, 0x83, 0x2a, 0xc7,
+		0xc6, 0xab, 0x4d, 0x4d, 0xc8, 0x02, 0x0d, 0x8d, 0x3f, 0x11, 0x67, 0xa9,
+		0xfc, 0x94, 0x47, 0xa9, 0x34, 0x8c, 0xc1, 0xe3, 0xa3, 0xf9, 0xa6, 0x3e,
+		0xcf, 0x57, 0xab, 0x23, 0x38, 0x61, 0xaf, 0x44, 0x42, 0xb3, 0xbe, 0x20,
+		0x5b, 0xf0, 0xcf, 0x54, 0x1d, 0xab, 0x02, 0x92, 0x9f, 0xfd, 0xa6, 0x11,
+		0xde, 0x42, 0x1d, 0x1c, 0xf4, 0x1f, 0x39, 0x90, 0x82, 0x3c, 0x8d, 0x32,
+		0xa0, 0xa7, 0x37, 0x80, 0xe6, 0x7a, 0x3d, 0x78, 0x62, 0xe7, 0x6d, 0xc5,
+		0x15, 0x9f, 0x3c, 0xe9, 0x5c, 0xb5, 0x07, 0x97, 0xae, 0x98, 0x5e, 0x79,
+		0x13, 0x31, 0x1d, 0x8a, 0xb7, 0xae, 0x3b, 0x12, 0x37, 0xc3, 0xeb, 0xb1,
+		0x60, 0xd9, 0xba, 0xed, 0x69, 0x5b, 0x4c, 0xda, 0x17, 0xee, 0x93, 0x27,
+		0xce, 0xc4, 0xed, 0x8c, 0xdd, 0xe9, 0x0c, 0xd6, 0x07, 0x81, 0x27, 0xff,
+		0xf6, 0xf3, 0x45, 0xd7, 0x7d, 0x3f, 0xc6, 0xff, 0x7f, 0xfc, 0xf7, 0xa7,
+		0xa0, 0xd4, 0xce, 0x33, 0x55, 0x5f, 0xa9, 0x05, 0xa2, 0x23, 0x95, 0x6b,
+		0xb9, 0xbe, 0x85, 0xac, 0xa1, 0xbf, 0xd1, 0x0e, 0x7c, 0xdf, 0x1b, 0xcc,
+		0xc6, 0x6e, 0xdf, 0xed, 0x9f, 0x23, 0x14, 0xba, 0xed, 0x1b, 0x0a, 0xf5,
+		0x97, 0x4e, 0x07, 0x41, 0xef, 0xb9, 0x9c, 0x31, 0x2a, 0x2a, 0x9d, 0xf9,
+		0xf7, 0x52, 0xab, 0xb5, 0x2c, 0x5e, 0x97, 0xfb, 0xaa, 0x6b, 0xa2, 0x38,
+		0x48, 0x81, 0x24, 0x46, 0x2b, 0x1d, 0xe8, 0x99, 0x9c, 0xec, 0x96, 0x43,
+		0x01, 0xce, 0xa3, 0xf5, 0xbd, 0x4a, 0x43, 0xb1, 0x44, 0x66, 0x88, 0xe2,
+		0x45, 0x4d, 0xdc, 0xca, 0xec, 0x5e, 0x22, 0x6e, 0x7f, 0x64, 0xf7, 0x7b,
+		0x7e, 0xec, 0x9c, 0x77, 0xc6, 0x37, 0xc8, 0x20, 0x1d, 0x6b, 0x5e, 0x02,
+		0xcd, 0x9e, 0x0a, 0xee, 0x84, 0x1f, 0x04, 0x2a, 0x8f, 0x01, 0xeb, 0xf3,
+		0x8c, 0x11, 0x0d, 0x7e, 0x17, 0xe7, 0x2c, 0x08, 0xc8, 0xcf, 0x7d, 0x38,
+		0x6c, 0x28, 0x58, 0x42, 0x2d, 0xee, 0x61, 0x4c, 0x48, 0x4a, 0xde, 0x7a,
+		0x1f, 0xc5, 0xa1, 0xba, 0x27, 0x74, 0x06, 0xb8, 0x16, 0xc6, 0x32, 0x42,
+		0x13, 0x32, 0x5f, 0xb4, 0xbd, 0xde, 0xf5, 0xd8, 0x25, 0x99, 0x5f, 0xe0,
+		0xa0, 0xf3, 0x15, 0x9d, 0xe4, 0x8d, 0x00, 0xcc, 0x21, 0x9c, 0x43, 0xff,
+		0x6b, 0x0e, 0x9b, 0x8d, 0xa0, 0x31, 0x1c, 0x02, 0x08, 0x23, 0xe9, 0xde,
+		0xf3, 0x2e, 0x22, 0xcc, 0x02, 0x42, 0x2b, 0x7b, 0x27, 0x50, 0x9e, 0x89,
+		0xe2, 0x9c, 0xb3, 0x0c, 0x31, 0x88, 0x37, 0x85, 0x32, 0x22, 0xce, 0x37,
+		0xf6, 0x19, 0xf3, 0x8a, 0xdc, 0x6e, 0x4e, 0xb4, 0xa7, 0xcd, 0xde, 0x7b,
+		0x83, 0xee, 0xf0, 0x3d, 0xa9, 0x92, 0x24, 0xbc, 0x92, 0x7e, 0x88, 0x55,
+		0x5c, 0x8b, 0xe0, 0x44, 0xce, 0x0e, 0xfc, 0x85, 0xb4, 0x2f, 0xeb, 0x6b,
+		0x1c, 0x5c, 0x0a, 0xaf, 0x65, 0x46, 0x59, 0x24, 0xa5, 0xdc, 0x80, 0x34,
+		0x01, 0x54, 0x7d, 0xd8, 0x08, 0x3f, 0x87, 0xa4, 0xe0, 0x32, 0x60, 0xc8,
+		0x72, 0xc6, 0xee, 0x3b, 0x77, 0x3c, 0x71, 0x67, 0x00, 0xf7, 0x0f, 0x37,
+		0xb3, 0xf6, 0xf5, 0xf4, 0xca, 0x1d, 0x00, 0x02, 0x00, 0x03, 0xc3, 0xb2,
+		0xde, 0xf8, 0x50, 0x7f, 0xef, 0x9e, 0xd3, 0xab, 0x3a, 0x3d, 0xf8, 0xfa,
+		0x16, 0x17, 0x49, 0xa1, 0xb7, 0xb3, 0x87, 0x9f, 0x80, 0xf3, 0x3e, 0x32,
+		0xe5, 0x3a, 0x5f, 0x03, 0xb7, 0xe2, 0x05, 0x10, 0x08, 0xcc, 0x17, 0xce,
+		0x54, 0xa3, 0x0c, 0x16, 0x2b, 0x30, 0x2b, 0xf1, 0x56, 0x6b, 0x53, 0x04,
+		0x9c, 0x9a, 0x98, 0x1f, 0x8e, 0xbb, 0xb3, 0x3e, 0xd4, 0xd1, 0x73, 0x07,
+		0x97, 0x1c, 0x8d, 0xa7, 0xa6, 0x52, 0x4a, 0xfd, 0x80, 0xd4, 0x15, 0xac,
+		0x40, 0xa4, 0xa8, 0x29, 0x4a, 0xef, 0x5c, 0x03, 0x38, 0x61, 0x8d, 0x38,
+		0xf3, 0xa3, 0xb8, 0x06, 0x3d, 0x24, 0x3e, 0x41, 0x77, 0x48, 0xda, 0x00,
+		0x08, 0xaf, 0x7d, 0x4a, 0xba, 0xfe, 0x67, 0xe8, 0x8a, 0x2c, 0x7c, 0x06,
+		0xa3, 0xdc, 0xcb, 0xb4, 0x26, 0x72, 0xc2, 0x55, 0x14, 0x4e, 0x11, 0x92,
+		0x35, 0x76, 0x25, 0x32, 0xd8, 0xb2, 0xd0, 0x19, 0xf6, 0x47, 0x3d, 0xf7,
+		0x83, 0x37, 0xbd, 0x61, 0x0c, 0x1a, 0x73, 0x5d, 0x52, 0x1e, 0x68, 0xcf,
+		0xa7, 0xd4, 0x6d, 0x5d, 0x69, 0x15, 0x99, 0xb8, 0xa1, 0xe3, 0x50, 0x6a,
+		0x94, 0x0b, 0x2b, 0x14, 0xaf, 0xdc, 0xce, 0x5b, 0xa2, 0xdb, 0x1f, 0x52,
+		0xd2, 0xa5, 0x54, 0x02, 0xc2, 0x03, 0x84, 0x93, 0x4a, 0xef, 0xd8, 0x69,
+		0x3a, 0x1e, 0x92, 0x28, 0xf4, 0x62, 0xaa, 0x95, 0x03, 0x52, 0x08, 0xd9,
+		0x58, 0x34, 0x10, 0x62, 0x0c, 0xa0, 0xad, 0xe6, 0xab, 0x9a, 0x38, 0xfe,
+		0xe9, 0xa4, 0x71, 0x7c, 0xfa, 0xaa, 0x71, 0xdc, 0xe0, 0xc0, 0x6b, 0x13,
+		0x54, 0xe1, 0xf0, 0x18, 0x99, 0x22, 0xd2, 0x0c, 0x6a, 0xe4, 0x7a, 0xd0,
+		0x31, 0x85, 0xb7, 0x98, 0xa7, 0xa8, 0x52, 0xb8, 0x9c, 0x01, 0xd5, 0xd8,
+		0x9e, 0x0c, 0xaa, 0xeb, 0x24, 0xdb, 0x50, 0x08, 0x98, 0x4a, 0x07, 0xff,
+		0x3a, 0xed, 0x2e, 0xd9, 0x00, 0x10, 0x37, 0x7c, 0x3f, 0x1b, 0xb8, 0x53,
+		0x08, 0x40, 0x25, 0x85, 0x7d, 0xdc, 0x75, 0x07, 0x37, 0xd5, 0xa7, 0xaf,
+		0xc5, 0x24, 0x5a, 0xc4, 0x14, 0x07, 0x46, 0xfb, 0x38, 0x4d, 0x21, 0x0c,
+		0xc8, 0x43, 0xcd, 0xe1, 0xa0, 0x7d, 0x27, 0xf1, 0xe4, 0x29, 0xa0, 0x66,
+		0x95, 0x87, 0xb4, 0x92, 0x8a, 0x64, 0x45, 0x35, 0x0c, 0x55, 0x0c, 0xcf,
+		0x58, 0x93, 0xcc, 0xed, 0x52, 0xc5, 0x0a, 0x68, 0xf4, 0xf7, 0xb0, 0x0a,
+		0xa7, 0x3b, 0xc0, 0x29, 0x3d, 0xdd, 0x67, 0xd4, 0xd4, 0x42, 0x48, 0x4e,
+		0x1f, 0x9d, 0x76, 0x67, 0xea, 0xbd, 0x73, 0x61, 0x8e, 0xae, 0x3b, 0xeb,
+		0xd1, 0x27, 0x08, 0x86, 0xe2, 0x82, 0x80, 0xe5, 0xf8, 0x55, 0x0b, 0x3e,
+		0x3f, 0x71, 0x29, 0x25, 0x52, 0x12, 0xfa, 0xe2, 0x22, 0x64, 0x66, 0x0e,
+		0x47, 0x09, 0x16, 0xc1, 0x12, 0xfc, 0x6f, 0x1e, 0xa5, 0xeb, 0x22, 0x2a,
+		0xe7, 0xc8, 0x2f, 0xa9, 0x5c, 0x98, 0x6a, 0xd1, 0x84, 0xde, 0xa5, 0x37,
+		0xa1, 0xfa, 0x85, 0x23, 0x04, 0x54, 0x07, 0x17, 0xde, 0xb8, 0x5f, 0x49,
+		0x1f, 0x5d, 0x05, 0xc7, 0xa6, 0xb8, 0x30, 0xc2, 0x99, 0xcd, 0x38, 0x80,
+		0x15, 0xc9, 0x96, 0x05, 0x50, 0x73, 0xe2, 0xb7, 0x68, 0xc2, 0x49, 0xab,
+		0x2c, 0x8e, 0x98, 0xfc, 0x98, 0xc3, 0xb2, 0x42, 0x94, 0x59, 0xe4, 0xf8,
+		0xd0, 0x30, 0x12, 0xbb, 0xab, 0x12, 0x9f, 0x23, 0x09, 0xe5, 0xc5, 0x1b,
+		0x42, 0xc2, 0x45, 0x03, 0x9c, 0xfd, 0x72, 0xed, 0x01, 0x7a, 0x26, 0xde,
+		0xe5, 0x00, 0xf6, 0x7d, 0xe7, 0xb9, 0xef, 0x2b, 0x14, 0x3a, 0x7e, 0x00,
+		0x07, 0x47, 0xf8, 0x64, 0x3e, 0x78, 0xd1, 0x22, 0x89, 0x82, 0x2c, 0x4f,
+		0xa5, 0xe3, 0x0e, 0xf8, 0xdc, 0x4e, 0x1b, 0x9e, 0x3d, 0x6b, 0xbf, 0x43,
+		0x6e, 0x1b, 0x57, 0x76, 0x4d, 0x53, 0x3a, 0x73, 0x8b, 0x57, 0x38, 0x78,
+		0x69, 0xb0, 0xcc, 0xc2, 0x14, 0x2c, 0x6c, 0xc0, 0x73, 0x32, 0x19, 0x1a,
+		0xac, 0x42, 0x72, 0xc9, 0xa3, 0x55, 0x56, 0x8f, 0x62, 0x83, 0xa4, 0xa4,
+		0xc2, 0x35, 0xf9, 0xb1, 0x85, 0x66, 0xee, 0x29, 0xfa, 0xd4, 0x47, 0xe8,
+		0xbc, 0x52, 0xcd, 0xec, 0x7b, 0x79, 0xb6, 0x4c, 0x55, 0xbe, 0x58, 0x9a,
+		0xe2, 0x87, 0x31, 0x10, 0xd5, 0x4f, 0xcc, 0x6d, 0x16, 0x4a, 0x9f, 0x98,
+		0x29, 0x3d, 0x29, 0xb8, 0xff, 0x1a, 0xb8, 0x55, 0x75, 0x60, 0x14, 0xcf,
+		0xe2, 0x00, 0x51, 0xd5, 0x9a, 0xaa, 0x2e, 0xea, 0xd3, 0x4a, 0xda, 0x61,
+		0x61, 0x3c, 0xf9, 0x40, 0x50, 0xb0, 0x91, 0xd9, 0x17, 0x8f, 0x18, 0x7e,
+		0xc9, 0x56, 0x7d, 0xf2, 0x1b, 0xd0, 0x88, 0xe6, 0x05, 0x5c, 0x5b, 0x12,
+		0x83, 0xe1, 0xd4, 0xbb, 0xb8, 0x99, 0x59, 0xac, 0x2d, 0x0a, 0x7b, 0x72,
+		0x33, 0x2a, 0xec, 0xcd, 0xa2, 0x6e, 0x85, 0xd0, 0x79, 0x3e, 0x9f, 0x73,
+		0xc9, 0x58, 0x40, 0x6f, 0x00, 0x84, 0x05, 0x28, 0xd4, 0xc4, 0x9d, 0x94,
+		0x09, 0xb1, 0x0b, 0x3b, 0x1a, 0x85, 0xd8, 0xd6, 0x33, 0x54, 0xf1, 0x9f,
+		0x32, 0x71, 0x17, 0xc3, 0xef, 0xee, 0x09, 0xda, 0xf8, 0x65, 0x03, 0x55,
+		0xca, 0xa0, 0x3b, 0x3b, 0xbf, 0xbe, 0xb8, 0xa0, 0xb2, 0xdb, 0x1d, 0x14,
+		0x09, 0x7e, 0x40, 0x16, 0x85, 0x55, 0x50, 0x87, 0x6e, 0xe0, 0xfc, 0x94,
+		0xf0, 0x88, 0x75, 0xd3, 0x13, 0x4f, 0xae, 0xcf, 0xff, 0xe2, 0x76, 0xa6,
+		0xdc, 0x43, 0x14, 0xfd, 0xf1, 0x33, 0x5d, 0x88, 0x67, 0xba, 0x11, 0xaa,
+		0xdb, 0x69, 0xcb, 0x99, 0xd0, 0xeb, 0x2c, 0x69, 0x2c, 0xe8, 0x33, 0xd5,
+		0xcb, 0x67, 0x2f, 0x5e, 0xbd, 0xc4, 0xbb, 0x5f, 0x7e, 0xb1, 0x2f, 0x3e,
+		0x7d, 0xe2, 0xa7, 0x27, 0x2f, 0x8a, 0xf2, 0xb1, 0x20, 0x63, 0xf0, 0xc1,
+		0x24, 0x3e, 0xe7, 0x62, 0x3c, 0xec, 0x6f, 0xdf, 0x41, 0xf0, 0xdd, 0x34,
+		0x59, 0x80, 0x71, 0x51, 0x60, 0x96, 0xc5, 0x25, 0x15, 0xbb, 0x8a, 0x12,
+		0xe4, 0x63, 0x1d, 0xda, 0x17, 0x0d, 0xa4, 0x86, 0x65, 0x7e, 0xfb, 0xf8,
+		0x7d, 0xa7, 0xe7, 0xc1, 0x51, 0x66, 0x1e, 0x53, 0xb1, 0x5f, 0x4c, 0x49,
+		0x67, 0xba, 0xea, 0xe1, 0x88, 0x61, 0x82, 0x4b, 0x79, 0xb4, 0x4f, 0x7e,
+		0x12, 0x59, 0x52, 0x24, 0x4f, 0x93, 0xf8, 0x33, 0x10, 0x65, 0x9a, 0xad,
+		0x62, 0x59, 0x65, 0x09, 0x47, 0x40, 0x93, 0x99, 0x68, 0xd2, 0x3f, 0x68,
+		0x1e, 0x7f, 0x93, 0xce, 0x74, 0xf8, 0xd6, 0x1d, 0x7c, 0xe7, 0x26, 0x8e,
+		0x88, 0x19, 0x43, 0xaf, 0xc3, 0x7d, 0x12, 0x52, 0xe4, 0x2a, 0x92, 0x54,
+		0x85, 0x84, 0xa6, 0x77, 0x90, 0xc0, 0x93, 0x8c, 0x55, 0x49, 0x4d, 0xa9,
+		0x25, 0x07, 0x18, 0xd3, 0x0a, 0xdd, 0x4b, 0x48, 0xfd, 0x86, 0x42, 0x86,
+		0xd4, 0xe8, 0x7e, 0xd4, 0xc2, 0xf4, 0x33, 0x4d, 0x04, 0x14, 0x65, 0xc2,
+		0x52, 0x3d, 0xfc, 0xe6, 0xff, 0xad, 0x9e, 0xfb, 0xfb, 0x7b, 0x4b, 0x0a,
+		0x8a, 0xd2, 0x7c, 0x10, 0xcb, 0x40, 0x7a, 0x8a, 0xe2, 0xb9, 0x6a, 0x48,
+		0xf6, 0xaf, 0xef, 0x5e, 0x0e, 0x2e, 0xa9, 0x23, 0x3a, 0xa4, 0x62, 0x8b,
+		0x9d, 0x3b, 0x42, 0x29, 0xa3, 0xb2, 0x13, 0xa6, 0x72, 0x50, 0xc7, 0x5f,
+		0xdd, 0x65, 0x55, 0x6c, 0x55, 0xf2, 0xe9, 0xd3, 0x3f, 0xac, 0x0e, 0x4a,
+		0x67, 0xe4, 0xfc, 0xe2, 0x7f, 0xfe, 0xfb, 0x3f, 0xff, 0xf7, 0x6f, 0xff,
+		0x45, 0x09, 0xfd, 0x80, 0x8f, 0xa4, 0x7e, 0xb2, 0xb4, 0x81, 0x61, 0x39,
+		0x40, 0x2d, 0xb0, 0x75, 0x11, 0x40, 0xef, 0x21, 0x27, 0x39, 0xb8, 0xcb,
+		0x70, 0x8e, 0x1d, 0x32, 0x0e, 0xc8, 0x31, 0xee, 0x65, 0x74, 0xab, 0x0e,
+		0x69, 0x0d, 0x7e, 0x10, 0x37, 0xb2, 0x62, 0x7f, 0xb0, 0x88, 0xea, 0xb7,
+		0x85, 0xa3, 0x9d, 0x7c, 0xc3, 0x3d, 0xbf, 0xbe, 0x75, 0xc7, 0x49, 0xad,
+		0x06, 0x33, 0x94, 0xe8, 0xd9, 0x21, 0x60, 0xfb, 0x3b, 0xd4, 0x78, 0xc8,
+		0xf2, 0x88, 0x41, 0x4b, 0x7a, 0xab, 0x85, 0x6f, 0x30, 0xff, 0x85, 0x3d,
+		0x87, 0xb8, 0x66, 0xdd, 0xfd, 0x2b, 0x78, 0x66, 0xc2, 0x15, 0xbb, 0x7d,
+		0x07, 0xcb, 0x8f, 0xb7, 0xec, 0x72, 0x1c, 0x50, 0x4a, 0xdf, 0x69, 0xef,
+		0xe5, 0x5a, 0xa5, 0x1b, 0xd3, 0x45, 0x03, 0xd7, 0xf1, 0x41, 0x99, 0xa7,
+		0xbc, 0x72, 0x6f, 0xba, 0x68, 0x17, 0xa3, 0x1a, 0x6c, 0x8f, 0xa6, 0x8c,
+		0xa8, 0xe6, 0x49, 0xd1, 0x54, 0xdb, 0xf7, 0xb6, 0x53, 0xbf, 0xec, 0x20,
+		0x3f, 0x40, 0x7f, 0x9f, 0xfd, 0x15, 0x25, 0x0a, 0x80, 0x8e, 0x8a, 0x43,
+		0xbd, 0x43, 0xf1, 0xb4, 0x85, 0x8e, 0x1a, 0x94, 0xde, 0xb5, 0x49, 0x90,
+		0xd3, 0x56, 0x41, 0xc8, 0xf0, 0xc2, 0x58, 0x55, 0xe5, 0x05, 0x04, 0x62,
+		0x60, 0x10, 0x15, 0xc9, 0x34, 0x6f, 0x29, 0xd3, 0xc0, 0x6b, 0xc1, 0x1b,
+		0xce, 0xc4, 0xd1, 0xd9, 0x69, 0xeb, 0xf9, 0x4f, 0x47, 0x78, 0x50, 0xec,
+		0xc2, 0xb3, 0xed, 0xe0, 0xe1, 0xf8, 0xf8, 0xe4, 0xf8, 0xf8, 0xc8, 0x66,
+		0x14, 0x2e, 0x0a, 0xb9, 0x62, 0x3d, 0xac, 0x0f, 0xc2, 0x91, 0xad, 0x5e,
+		0x8c, 0x5a, 0xec, 0x2c, 0xe4, 0x90, 0x4e, 0x90, 0xe4, 0xdf, 0x79, 0x5d,
+		0x56, 0x0a, 0x23, 0xd0, 0x6b, 0x31, 0x4a, 0xd5, 0xe7, 0x88, 0xea, 0x1e,
+		0xae, 0x0f, 0x17, 0x42, 0x25, 0xc4, 0xb9, 0x36, 0xcc, 0x61, 0xcf, 0x19,
+		0x57, 0x0d, 0x4b, 0xff, 0x33, 0x25, 0xab, 0x4d, 0xb1, 0x8a, 0xea, 0x87,
+		0xd7, 0x4c, 0xe2, 0xac, 0xa8, 0xa8, 0xb7, 0x43, 0x1e, 0xdb, 0x08, 0x1c,
+		0xf1, 0xa0, 0xa2, 0xa8, 0xb7, 0x8f, 0xb6, 0xf2, 0x5b, 0x1a, 0xab, 0x88,
+		0x47, 0xae, 0x78, 0x64, 0xb3, 0x2e, 0x6b, 0xaa, 0x26, 0x12, 0xa5, 0x56,
+		0x13, 0xb8, 0x4f, 0xad, 0xd2, 0x91, 0x19, 0x82, 0x5b, 0x1d, 0x9d, 0x3e,
+		0x7f, 0xf9, 0x53, 0xed, 0xb8, 0xd5, 0xaa, 0xf9, 0x3a, 0xf3, 0x1f, 0x22,
+		0xc9, 0xca, 0x24, 0xb9, 0xcf, 0xc4, 0x42, 0xd5, 0xf1, 0xb7, 0x1e, 0xa6,
+		0x11, 0x48, 0x36, 0xf9, 0xa1, 0x08, 0x75, 0x5c, 0x9c, 0x8a, 0x7a, 0x97,
+		0x07, 0x05, 0x86, 0x22, 0x0d, 0x73, 0xce, 0x8a, 0x63, 0x7e, 0x2e, 0x98,
+		0x9d, 0x65, 0x3c, 0xb4, 0x29, 0xb5, 0x65, 0x8a, 0xe1, 0xcb, 0x62, 0xf6,
+		0x52, 0x88, 0x44, 0x9d, 0x85, 0x95, 0x3d, 0x50, 0xea, 0x2e, 0x92, 0x9c,
+		0xd3, 0x8b, 0x61, 0x86, 0x9d, 0x61, 0x44, 0x33, 0x92, 0x73, 0xb6, 0x40,
+		0xea, 0xcb, 0x68, 0x87, 0x67, 0x0a, 0x1a, 0xe4, 0x82, 0x52, 0x71, 0x54,
+		0x7b, 0x52, 0x74, 0x58, 0x8f, 0xac, 0xd8, 0xcd, 0xc6, 0xa8, 0x21, 0x88,
+		0xb0, 0x44, 0x03, 0x5e, 0x29, 0xa3, 0x5c, 0x2e, 0x16, 0xb9, 0x60, 0x35,
+		0xe7, 0xef, 0xec, 0xe5, 0x0e, 0xce, 0x16, 0x68, 0xd4, 0x2d, 0x18, 0x2a,
+		0xdb, 0xd6, 0xae, 0x60, 0x1d, 0x01, 0x90, 0x45, 0x5c, 0x01, 0x9b, 0x28,
+		0xd8, 0x21, 0xf2, 0xea, 0xf4, 0xc7, 0x56, 0xcb, 0xb9, 0xec, 0xcc, 0x8a,
+		0x00, 0x98, 0x4d, 0x3d, 0x16, 0xcb, 0xbc, 0xd8, 0x52, 0x59, 0x45, 0x73,
+		0xc9, 0x74, 0x0e, 0x6c, 0x9f, 0xb8, 0x93, 0x09, 0x35, 0xe3, 0x3d, 0xef,
+		0xc2, 0xdd, 0xdf, 0x5f, 0xea, 0xc0, 0xcc, 0x70, 0xc4, 0x3c, 0x8f, 0x83,
+		0x5a, 0xe9, 0xe7, 0x7a, 0xe9, 0x1f, 0x93, 0x77, 0xe3, 0xef, 0xc9, 0x8b,
+		0x53, 0xeb, 0xde, 0xe1, 0x8b, 0xa3, 0xea, 0x19, 0xb4, 0xa6, 0x3c, 0xc2,
+		0xeb, 0xce, 0xae, 0xda, 0x93, 0xab, 0x8b, 0xeb, 0x41, 0x07, 0x87, 0xf0,
+		0xab, 0x2d, 0x8f, 0x7c, 0xc0, 0x9d, 0xdc, 0x55, 0x31, 0x19, 0x22, 0x45,
+		0x08, 0xd3, 0x80, 0x9c, 0x5d, 0x63, 0x9f, 0x16, 0x8f, 0xc0, 0x10, 0x86,
+		0xb6, 0xaf, 0xa0, 0x30, 0x9c, 0x52, 0xe9, 0xbe, 0xf2, 0x03, 0x49, 0xcd,
+		0x8a, 0x7d, 0xce, 0xae, 0xb1, 0x1d, 0xfc, 0x19, 0x8f, 0x36, 0x1c, 0x7f,
+		0x8a, 0xe2, 0x28, 0xdf, 0x0b, 0x48, 0xfb, 0x1e, 0x87, 0x8d, 0xdf, 0x79,
+		0x1d, 0xd2, 0x88, 0xad, 0x3c, 0xcb, 0x51, 0xf9, 0x78, 0xaf, 0x67, 0xa1,
+		0x0b, 0x12, 0x54, 0x14, 0x5a, 0x36, 0xfc, 0x2c, 0x03, 0x68, 0xac, 0x91,
+		0x11, 0x89, 0x99, 0xf7, 0x4b, 0xc9, 0x47, 0x22, 0x6d, 0xf9, 0xe9, 0x86,
+		0xa3, 0x50, 0xf3, 0x0c, 0xfe, 0x16, 0x11, 0xcb, 0x2b, 0x4d, 0xff, 0x67,
+		0x77, 0xeb, 0x4a, 0x0a, 0xb0, 0x9e, 0x30, 0xa2, 0x11, 0x2c, 0x56, 0xe8,
+		0x0c, 0xbd, 0xad, 0xd8, 0x52, 0x37, 0xb4, 0x6a, 0xb4, 0x13, 0xe5, 0xfe,
+		0x67, 0x13, 0xdc, 0xe6, 0x46, 0x20, 0x8f, 0x09, 0x32, 0xa8, 0xeb, 0x45,
+		0x9d, 0x9d, 0x02, 0xed, 0x08, 0x5f, 0xab, 0xf3, 0xc9, 0x2d, 0x15, 0x53,
+		0x5a, 0x57, 0x6e, 0x6a, 0xf0, 0xbc, 0x7a, 0x48, 0xf5, 0xda, 0xa3, 0x7a,
+		0xaf, 0xf2, 0xfc, 0xc4, 0x6e, 0xdc, 0x4e, 0xca, 0x2a, 0x44, 0xcd, 0x84,
+		0xc3, 0x8a, 0x99, 0x27, 0x2b, 0x85, 0xde, 0x0d, 0x65, 0x23, 0x35, 0x9d,
+		0x81, 0x64, 0x32, 0x34, 0xb4, 0x9e, 0x98, 0x8e, 0xc0, 0xf9, 0x35, 0xd2,
+		0x3a, 0x97, 0x55, 0x6d, 0x91, 0xb8, 0x12, 0xc0, 0x1d, 0xa2, 0x70, 0xa3,
+		0x99, 0x14, 0xda, 0x57, 0x92, 0x88, 0x0b, 0x39, 0xbe, 0x39, 0x01, 0x26,
+		0xa0, 0x21, 0x95, 0xe6, 0xd6, 0x86, 0xb7, 0x57, 0xa6, 0x2a, 0x61, 0x8e,
+		0xb0, 0x53, 0x2a, 0xa6, 0xc1, 0x0d, 0x8d, 0x7e, 0x0d, 0x7b, 0xbc, 0x8a,
+		0xac, 0x6b, 0xc9, 0x85, 0xa6, 0xfb, 0x8a, 0xd5, 0xad, 0x0a, 0x37, 0xf4,
+		0xdc, 0xd2, 0x0c, 0x8b, 0xb0, 0xec, 0x5e, 0xf3, 0xcc, 0xd3, 0x1b, 0x58,
+		0x44, 0xb6, 0x71, 0x3d, 0x28, 0x05, 0xa6, 0x99, 0x2a, 0x04, 0x9c, 0x2b,
+		0x7b, 0x66, 0x48, 0x5d, 0x1e, 0x5b, 0xca, 0x4f, 0x33, 0x16, 0x80, 0x3a,
+		0xd4, 0x82, 0x7b, 0xed, 0x54, 0x09, 0x16, 0xc3, 0xd7, 0xe3, 0x42, 0xfc,
+		0x2f, 0xf8, 0xcd, 0x17, 0x1d, 0xc6, 0x0a, 0x4d, 0xe9, 0x8d, 0xe6, 0x3b,
+		0xa4, 0xf5, 0x1a, 0x75, 0xad, 0x1b, 0x56, 0x01, 0x3b, 0x4b, 0x68, 0x1d,
+		0x81, 0x2d, 0xcf, 0x37, 0x49, 0xec, 0x63, 0x55, 0x43, 0x3d, 0x76, 0xb7,
+		0xb6, 0x1d, 0x9b, 0xf4, 0x09, 0x09, 0xb2, 0x4d, 0x62, 0x54, 0x5c, 0xb5,
+		0xed, 0xce, 0x6c, 0xe9, 0xe8, 0x77, 0x0e, 0x9d, 0x8c, 0x2f, 0xde, 0x4c,
+		0x63, 0x45, 0xa3, 0x34, 0x72, 0x1d, 0xba, 0x6d, 0x40, 0x28, 0x9a, 0x5a,
+		0x9a, 0x46, 0x2c, 0x6e, 0x97, 0x87, 0xfa, 0x13, 0xbe, 0xcf, 0xf4, 0x17,
+		0xb2, 0xf9, 0xd7, 0x44, 0x2e, 0x7e, 0x37, 0x1f, 0x93, 0xb8, 0xf8, 0xb4,
+		0x88, 0xe6, 0xbf, 0xfb, 0x49, 0xb2, 0xb2, 0x5d, 0x6c, 0x33, 0x09, 0x77,
+		0xbf, 0xff, 0x16, 0x25, 0xbf, 0x67, 0xf2, 0x21, 0x6b, 0x22, 0xc6, 0xa3,
+		0xf8, 0x35, 0x75, 0xa7, 0x29, 0x40, 0xf6, 0x4d, 0x9e, 0xcd, 0xeb, 0xaf,
+		0xfe, 0x41, 0x6f, 0xfe, 0xf1, 0x9f, 0xe3, 0xcc, 0x2f, 0xc8, 0x98, 0x50,
+		0xf9, 0x03, 0xd5, 0x9f, 0x5b, 0x1b, 0x06, 0x2a, 0x7c, 0x7c, 0xdb, 0x48,
+		0xdd, 0x33, 0x2f, 0x0d, 0x79, 0xd8, 0xa3, 0xa5, 0x9f, 0x06, 0x34, 0x50,
+		0x71, 0xc6, 0xee, 0x68, 0x38, 0x23, 0x1f, 0xf9, 0x40, 0xd3, 0x9e, 0x47,
+		0xdd, 0xf9, 0x2e, 0x1c, 0x30, 0x09, 0x0b, 0x6e, 0xdf, 0x0b, 0x04, 0x3b,
+		0x07, 0x54, 0x51, 0xc1, 0xb2, 0xae, 0x9b, 0xcc, 0x68, 0xe3, 0x96, 0x2e,
+		0x8d, 0xa8, 0x9a, 0x62, 0x17, 0xac, 0x5e, 0xb9, 0xf2, 0x88, 0x9c, 0x75,
+		0x0c, 0x85, 0xde, 0x45, 0x2b, 0xc5, 0x0a, 0xe5, 0x13, 0xa9, 0x1c, 0xb1,
+		0x72, 0x95, 0xaa, 0x29, 0xb4, 0xfc, 0xe2, 0xf8, 0x84, 0x6e, 0x44, 0x92,
+		0xe8, 0xe3, 0x23, 0x75, 0xb7, 0x47, 0x9e, 0xa0, 0x2b, 0x18, 0xa9, 0xa1,
+		0x6f, 0xba, 0xb0, 0x5a, 0xd2, 0x65, 0x50, 0x69, 0xc1, 0xca, 0x20, 0x90,
+		0x30, 0xdb, 0xc4, 0x29, 0x1d, 0x4e, 0x4d, 0xdb, 0xa1, 0xdb, 0xd8, 0x71,
+		0x7b, 0x4a, 0x53, 0xb7, 0xbe, 0x47, 0x85, 0xf2, 0x8b, 0x16, 0x27, 0xb3,
+		0x3d, 0x03, 0xc7, 0x2a, 0xde, 0xac, 0x55, 0xae, 0xbf, 0x71, 0xf6, 0xf6,
+		0x7e, 0xe0, 0xeb, 0xe7, 0xcc, 0xda, 0x83, 0xe1, 0xe0, 0xa6, 0x3f, 0xbc,
+		0x9e, 0x98, 0x2a, 0x94, 0x2a, 0x80, 0x07, 0x40, 0x4f, 0x8c, 0xca, 0x35,
+		0x95, 0x64, 0x07, 0x68, 0x96, 0xa8, 0xae, 0xfd, 0xf4, 0x2e, 0x4f, 0x0a,
+		0xf4, 0xd6, 0xb6, 0x16, 0x35, 0x63, 0x2e, 0xaa, 0x05, 0xf9, 0x75, 0xe3,
+		0x08, 0x5e, 0x61, 0x23, 0x11, 0x51, 0x56, 0x4c, 0xf0, 0x69, 0xa6, 0xe5,
+		0x67, 0x0d, 0x9e, 0x29, 0x1b, 0x92, 0x9c, 0x63, 0xef, 0xa2, 0x24, 0x21,
+		0x7d, 0x00, 0xe4, 0x20, 0x02, 0x8f, 0x7b, 0x09, 0x16, 0xca, 0x39, 0xf9,
+		0x1c, 0xed, 0x67, 0xd8, 0xa0, 0x99, 0x0f, 0xd3, 0xf6, 0x75, 0x10, 0x45,
+		0xa1, 0x0a, 0x3e, 0x3e, 0x8e, 0xfe, 0x0e, 0xed, 0xad, 0x44, 0x78, 0x31,
+		0x99, 0xe6, 0xea, 0x11, 0x41, 0x27, 0x63, 0x33, 0x9e, 0x2d, 0xb1, 0xd6,
+		0x8a, 0x16, 0x9a, 0x1b, 0x68, 0x46, 0x57, 0xc3, 0x98, 0xc3, 0x96, 0x77,
+		0x3f, 0x4c, 0xdd, 0x01, 0xe5, 0x6b, 0xd2, 0x4a, 0xc3, 0xc7, 0xa1, 0xb5,
+		0xf2, 0xf8, 0xe2, 0x38, 0xb0, 0x9a, 0x4a, 0x3f, 0xd4, 0x25, 0x70, 0x30,
+		0x8a, 0xe8, 0x0c, 0xe8, 0xc9, 0xf0, 0x76, 0x9f, 0x46, 0xe4, 0x5d, 0x57,
+		0xd3, 0x7e, 0xcf, 0x38, 0x7e, 0xa8, 0xf2, 0x0c, 0x5e, 0x3c, 0x30, 0xb5,
+		0x5f, 0xbf, 0xdf, 0x1e, 0x90, 0x04, 0x05, 0x59, 0xf8, 0xb8, 0xa8, 0xd7,
+		0x63, 0x55, 0x37, 0x13, 0xc3, 0xfa, 0x1c, 0x45, 0xa4, 0xa4, 0x47, 0xda,
+		0x47, 0x11, 0x54, 0xaf, 0x63, 0x73, 0x9d, 0xc4, 0x79, 0x53, 0x17, 0x75,
+		0x8e, 0x27, 0x78, 0x16, 0x5f, 0x73, 0x92, 0x53, 0xf0, 0xfd, 0xa8, 0x4a,
+		0x8b, 0x54, 0x6d, 0x43, 0xdf, 0xdc, 0x0d, 0x6c, 0x19, 0xe4, 0x79, 0xad,
+		0xe1, 0xdc, 0xa7, 0xc8, 0xa0, 0x36, 0x22, 0x5d, 0xe4, 0x16, 0x62, 0x90,
+		0x70, 0xfc, 0x90, 0xa8, 0xb1, 0x08, 0x8e, 0x37, 0x41, 0xb0, 0x8d, 0xae,
+		0xcb, 0x8b, 0xdc, 0xed, 0x00, 0x8e, 0x8d, 0x01, 0xcf, 0x82, 0xee, 0xb9,
+		0x48, 0x09, 0x09, 0xd6, 0x1e, 0x19, 0xe5, 0x80, 0x1e, 0x53, 0x7d, 0x40,
+		0x7e, 0x3c, 0x3c, 0x59, 0x66, 0xeb, 0x95, 0x11, 0x3e, 0xf5, 0xef, 0xcd,
+		0x07, 0x12, 0xa3, 0x0e, 0xa6, 0x64, 0xca, 0x33, 0xc1, 0x6f, 0x70, 0xa3,
+		0xd2, 0x05, 0xdd, 0xa4, 0x7f, 0x0f, 0x13, 0x58, 0xfa, 0x98, 0x89, 0x84,
+		0x6a, 0xb5, 0x00, 0x27, 0x93, 0x0d, 0xdf, 0x60, 0x09, 0x3e, 0x66, 0xea,
+		0x0d, 0xf1, 0xf5, 0xc5, 0xa3, 0x57, 0x6a, 0x61, 0x7e, 0x52, 0x62, 0xc1,
+		0xa8, 0xd2, 0x49, 0xd9, 0x71, 0x52, 0xb5, 0x95, 0xa2, 0xfe, 0x8d, 0x8b,
+		0xce, 0x75, 0x96, 0x98, 0x02, 0xae, 0xb8, 0x71, 0xde, 0xab, 0xe1, 0x8a,
+		0xbd, 0x66, 0x8c, 0x6d, 0x0c, 0x66, 0x0a, 0x0b, 0xe3, 0xdc, 0x62, 0x8d,
+		0x95, 0x51, 0x02, 0x23, 0x93, 0xc4, 0xba, 0xe8, 0x39, 0xec, 0xb6, 0x1a,
+		0xdb, 0xff, 0xc8, 0xb1, 0x37, 0xbf, 0xf6, 0xe9, 0x3f, 0x73, 0x3a, 0xba,
+		0x37, 0x18, 0x35, 0x18, 0x55, 0x08, 0x3e, 0x4d, 0x51, 0xbf, 0x92, 0x98,
+		0x5d, 0x79, 0x9b, 0x2f, 0xe8, 0x83, 0x17, 0xcf, 0x15, 0xfd, 0x7d, 0xef,
+		0xa7, 0x2c, 0xbf, 0x9b, 0xa6, 0x2a, 0xa5, 0x0f, 0x1d, 0x84, 0x07, 0x8d,
+		0x93, 0xf7, 0xc4, 0x37, 0x14, 0x9c, 0x9e, 0xfb, 0xce, 0xa5, 0xbe, 0x98,
+		0xbf, 0x3a, 0x45, 0x6f, 0x5c, 0xe8, 0x86, 0x45, 0x37, 0x37, 0x01, 0x64,
+		0x86, 0x86, 0x7d, 0xfe, 0xb1, 0xdc, 0x56, 0xee, 0x60, 0x6d, 0xec, 0x2f,
+		0xa7, 0x87, 0x95, 0xb5, 0xec, 0x24, 0xb6, 0xb1, 0xc2, 0x6b, 0x73, 0x0b,
+		0x8e, 0x0f, 0x5c, 0x93, 0x97, 0xe3, 0x73, 0x5d, 0xcc, 0xc0, 0xcd, 0x9d,
+		0xa7, 0x48, 0xe9, 0xce, 0x4a, 0x3e, 0xd5, 0xf7, 0x51, 0x16, 0x2c, 0x0d,
+		0xc8, 0x11, 0xf0, 0x51, 0xe9, 0x64, 0xbb, 0xdd, 0x67, 0x8f, 0x3b, 0xa7,
+		0xde, 0xf0, 0x72, 0x36, 0x1e, 0x4e, 0x81, 0xbe, 0x5b, 0xec, 0x22, 0x7c,
+		0x5f, 0x45, 0xb1, 0xac, 0x80, 0xbc, 0x06, 0x95, 0x95, 0x29, 0x41, 0x76,
+		0x68, 0xb0, 0xba, 0xa1, 0x70, 0xca, 0x50, 0xf4, 0x8b, 0x91, 0x49, 0x61,
+		0x82, 0x32, 0x51, 0x70, 0x76, 0xd3, 0xcb, 0x68, 0x9e, 0x7d, 0x8d, 0xce,
+		0xc9, 0x2b, 0x9b, 0x11, 0x8e, 0xc5, 0x9f, 0xff, 0x8c, 0x6f, 0x35, 0x81,
+		0x46, 0xa8, 0x7f, 0x5e, 0x96, 0x16, 0xb3, 0xc9, 0x95, 0x77, 0xc1, 0x3f,
+		0x5f, 0x79, 0xc5, 0x9d, 0xce, 0x82, 0x21, 0x82, 0xa4, 0xe6, 0xca, 0xf6,
+		0xb1, 0x5c, 0xdd, 0xb6, 0xd7, 0xbb, 0x79, 0x24, 0x99, 0xfb, 0x90, 0x44,
+		0x84, 0xb1, 0x5c, 0x70, 0x82, 0x1d, 0x22, 0x40, 0xbc, 0x3c, 0x0d, 0x51,
+		0xd1, 0xd1, 0x95, 0x0e, 0x5f, 0x06, 0xd3, 0xcf, 0x9b, 0x68, 0xc5, 0xae,
+		0xba, 0x5e, 0x32, 0x33, 0xe5, 0x55, 0x7f, 0xc5, 0x03, 0xe2, 0x43, 0xe6,
+		0x8f, 0x2b, 0xf6, 0xa4, 0x2c, 0x63, 0xc7, 0x25, 0x66, 0x56, 0x42, 0x85,
+		0x8a, 0xf9, 0x89, 0x94, 0x55, 0xc8, 0x1a, 0x69, 0x11, 0x35, 0xdb, 0x81,
+		0xae, 0x78, 0xec, 0xa2, 0x2b, 0x1f, 0xb8, 0x9d, 0xe9, 0x0c, 0xbd, 0x5a,
+		0x7f, 0x72, 0xb9, 0x73, 0xb9, 0xb3, 0x31, 0x1d, 0x4f, 0x41, 0x9b, 0xeb,
+		0xf0, 0xca, 0x5c, 0x06, 0x44, 0x56, 0x38, 0xee, 0x6b, 0x54, 0xab, 0x7d,
+		0xb6, 0x0d, 0x99, 0x2c, 0x48, 0x28, 0x1c, 0xf2, 0x38, 0x7a, 0x30, 0xb8,
+		0x90, 0x87, 0xc9, 0x5e, 0x4c, 0xd0, 0x92, 0xea, 0x2f, 0x99, 0xf0, 0x9d,
+		0x6e, 0xc9, 0xab, 0x63, 0xa0, 0xe2, 0xb7, 0x48, 0xe5, 0x6f, 0x3c, 0x18,
+		0x66, 0xf6, 0xf4, 0x44, 0x0f, 0x77, 0xf4, 0xf4, 0xb5, 0x2b, 0x8d, 0x5d,
+		0x16, 0xba, 0x91, 0xbf, 0x88, 0x71, 0x60, 0x14, 0x14, 0xca, 0xb3, 0xe9,
+		0x8e, 0xfa, 0xcb, 0xa3, 0xca, 0xf5, 0xc7, 0x57, 0x17, 0xee, 0xdd, 0x87,
+		0xec, 0x5e, 0x6f, 0x7c, 0xff, 0x15, 0x86, 0xb1, 0xb0, 0xa4, 0x51, 0x8c,
+		0xae, 0x15, 0x15, 0x2e, 0xfd, 0x52, 0x0c, 0x92, 0xaf, 0x51, 0x5e, 0x5a,
+		0x4c, 0xfc, 0xf5, 0xe8, 0xf8, 0xe7, 0xca, 0xcf, 0x92, 0x8e, 0x6a, 0x47,
+		0x27, 0x3b, 0xdf, 0x3f, 0x92, 0x5d, 0x5c, 0x8f, 0x2e, 0xaa, 0xaa, 0xaa,
+		0x2b, 0x71, 0x79, 0x5f, 0x7d, 0xdb, 0x9f, 0x08, 0x95, 0x2a, 0xec, 0x8e,
+		0x69, 0x3b, 0x4f, 0x39, 0x61, 0x60, 0xfa, 0xfb, 0x7f, 0x3f, 0x88, 0x3f,
+		0xa3, 0x47, 0x29, 0x00, 0x00,
 		},
 		"conf/app.ini",
 	)
@@ -8,10 +8,21 @@ import (
 	"github.com/robfig/cron"
 
 	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/mailer"
 )
 
 func NewCronContext() {
 	c := cron.New()
 	c.AddFunc("@every 1h", models.MirrorUpdate)
+	c.AddFunc("@every 1m", models.PushMirrorUpdate)
+	c.AddFunc("@every 1m", models.RepoIndexerUpdate)
+	c.AddFunc("@every 1m", models.RepoStatsUpdate)
+	c.AddFunc("@every 1m", models.PullRequestCheckUpdate)
+	c.AddFunc("@every 1m", models.AutoMergeUpdate)
+	c.AddFunc("@every 1h", models.DeleteExpiredLoginAttempts)
+	c.AddFunc("@every 1h", models.DeleteExpiredEmailActivations)
+	c.AddFunc("@every 1h", models.DeleteExpiredUserSessions)
+	c.AddFunc("@every 1h", models.DeleteExpiredRememberTokens)
+	c.AddFunc("@daily", mailer.SendIssueDueReminders)
 	c.Start()
 }
@@ -6,6 +6,8 @@ package hooks
 
 import (
 	"encoding/json"
+	"io"
+	"io/ioutil"
 	"time"
 
 	"github.com/gogits/gogs/modules/httplib"
@@ -57,6 +59,21 @@ type HookTask struct {
 	*Payload
 	ContentType int
 	IsSsl       bool
+	// Encoded payload, which is sent instead of Payload when it is given.
+	Data []byte
+	// Called with result after task has been delivered or failed, can be nil.
+	OnDelivered func(*Delivery)
+}
+
+// MAX_RESPONSE_SIZE is max size of response body in bytes that is kept in delivery.
+const MAX_RESPONSE_SIZE = 4096
+
+// Delivery represents result of delivering a hook task.
+type Delivery struct {
+	StatusCode int
+	Response   string
+	Duration   time.Duration
+	Err        error
 }
 
 var (
@@ -77,19 +94,47 @@ func handleQueue() {
 		select {
 		case t := <-taskQueue:
 			// Only support JSON now.
-			data, err := json.MarshalIndent(t.Payload, "", "\t")
-			if err != nil {
-				log.Error("hooks.handleQueue(json): %v", err)
-				continue
+			data := t.Data
+			if data == nil {
+				var err error
+				if data, err = json.MarshalIndent(t.Payload, "", "\t"); err != nil {
+					log.Error("hooks.handleQueue(json): %v", err)
+					continue
+				}
 			}
 
-			_, err = httplib.Post(t.Url).SetTimeout(5*time.Second, 5*time.Second).
-				Body(data).Response()
-			if err != nil {
-				log.Error("hooks.handleQueue: Fail to deliver hook: %v", err)
+			d := deliver(t.Url, data)
+			if t.OnDelivered != nil {
+				t.OnDelivered(d)
+			}
+			if d.Err != nil {
+				log.Error("hooks.handleQueue: Fail to deliver hook: %v", d.Err)
 				continue
 			}
 			log.Info("Hook delivered: %s", string(data))
 		}
 	}
 }
+
+// deliver posts data to given URL and returns result of delivery.
+func deliver(url string, data []byte) *Delivery {
+	d := new(Delivery)
+	start := time.Now()
+	resp, err := httplib.Post(url).SetTimeout(5*time.Second, 5*time.Second).
+		Body(data).Response()
+	d.Duration = time.Since(start)
+	if err != nil {
+		d.Err = err
+		return d
+	}
+	defer resp.Body.Close()
+
+	d.StatusCode = resp.StatusCode
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+	if err != nil {
+		d.Err = err
+		return d
+	}
+	d.Response = string(body)
+	return d
+}
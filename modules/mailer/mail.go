@@ -56,7 +56,11 @@ func CreateUserActiveCode(u *models.User, startInf interface{}) string {
 
 // Send user register mail with active code
 func SendRegisterMail(r *middleware.Render, u *models.User) {
-	code := CreateUserActiveCode(u, nil)
+	code, err := models.NewEmailActivation(u)
+	if err != nil {
+		log.Error("mail.SendRegisterMail(NewEmailActivation): %v", err)
+		return
+	}
 	subject := "Register success, Welcome"
 
 	data := GetMailTmplData(u)
@@ -75,7 +79,11 @@ func SendRegisterMail(r *middleware.Render, u *models.User) {
 
 // Send email verify active email.
 func SendActiveMail(r *middleware.Render, u *models.User) {
-	code := CreateUserActiveCode(u, nil)
+	code, err := models.NewEmailActivation(u)
+	if err != nil {
+		log.Error("mail.SendActiveMail(NewEmailActivation): %v", err)
+		return
+	}
 
 	subject := "Verify your e-mail address"
 
@@ -113,24 +121,19 @@ func SendResetPasswdMail(r *middleware.Render, u *models.User) {
 	SendAsync(&msg)
 }
 
-// SendIssueNotifyMail sends mail notification of all watchers of repository.
+// SendIssueNotifyMail sends mail notification of all subscribers of issue.
 func SendIssueNotifyMail(u, owner *models.User, repo *models.Repository, issue *models.Issue) ([]string, error) {
-	ws, err := models.GetWatchers(repo.Id)
+	subscribers, err := models.GetIssueSubscribers(repo, issue)
 	if err != nil {
-		return nil, errors.New("mail.NotifyWatchers(GetWatchers): " + err.Error())
+		return nil, errors.New("mail.SendIssueNotifyMail(GetIssueSubscribers): " + err.Error())
 	}
 
-	tos := make([]string, 0, len(ws))
-	for i := range ws {
-		uid := ws[i].UserId
-		if u.Id == uid {
+	tos := make([]string, 0, len(subscribers))
+	for _, s := range subscribers {
+		if s.Id == u.Id || s.IsBot() {
 			continue
 		}
-		u, err := models.GetUserById(uid)
-		if err != nil {
-			return nil, errors.New("mail.NotifyWatchers(GetUserById): " + err.Error())
-		}
-		tos = append(tos, u.Email)
+		tos = append(tos, s.Email)
 	}
 
 	if len(tos) == 0 {
@@ -172,9 +175,122 @@ func SendIssueMentionMail(r *middleware.Render, u, owner *models.User,
 	return nil
 }
 
+// SendReviewRequestMail sends mail notification to users who are requested to review pull request.
+func SendReviewRequestMail(r *middleware.Render, u, owner *models.User,
+	repo *models.Repository, issue *models.Issue, reviewers []*models.User) error {
+
+	tos := make([]string, 0, len(reviewers))
+	for _, reviewer := range reviewers {
+		if !reviewer.IsBot() {
+			tos = append(tos, reviewer.Email)
+		}
+	}
+	if len(tos) == 0 {
+		return nil
+	}
+
+	subject := fmt.Sprintf("[%s] %s(#%d)", repo.Name, issue.Name, issue.Index)
+
+	data := GetMailTmplData(nil)
+	data["ActUserName"] = u.Name
+	data["IssueLink"] = fmt.Sprintf("%s/%s/pulls/%d", owner.Name, repo.Name, issue.Index)
+	data["Subject"] = subject
+
+	body, err := r.HTMLString("mail/notify/review_request", data)
+	if err != nil {
+		return fmt.Errorf("mail.SendReviewRequestMail(fail to render): %v", err)
+	}
+
+	msg := NewMailMessageFrom(tos, u.Email, subject, body)
+	msg.Info = fmt.Sprintf("Subject: %s, send review request emails", subject)
+	SendAsync(&msg)
+	return nil
+}
+
+// SendCommitCommentMail sends mail notification to author of commit about comment on it.
+func SendCommitCommentMail(r *middleware.Render, u, owner *models.User,
+	repo *models.Repository, c *models.CommitComment, author *models.User) error {
+
+	subject := fmt.Sprintf("[%s] Comment on commit %s", repo.Name, base.ShortSha(c.CommitId))
+
+	data := GetMailTmplData(nil)
+	data["ActUserName"] = u.Name
+	data["CommitLink"] = fmt.Sprintf("%s/%s/commit/%s#commit-comment-%d", owner.Name, repo.Name, c.CommitId, c.Id)
+	data["Content"] = c.Content
+	data["Subject"] = subject
+
+	body, err := r.HTMLString("mail/notify/commit_comment", data)
+	if err != nil {
+		return fmt.Errorf("mail.SendCommitCommentMail(fail to render): %v", err)
+	}
+
+	msg := NewMailMessageFrom([]string{author.Email}, u.Email, subject, body)
+	msg.Info = fmt.Sprintf("Subject: %s, send commit comment email", subject)
+	SendAsync(&msg)
+	return nil
+}
+
+// SendIssueDueReminders sends reminder mails of open issues that are due soon to their assignees,
+// or posters when nobody is assigned.
+func SendIssueDueReminders() {
+	if !setting.IssueDueReminderEnabled || !setting.Service.NotifyMail {
+		return
+	}
+
+	issues, err := models.GetIssuesDueWithin(setting.IssueDueReminderDays)
+	if err != nil {
+		log.Error("mail.SendIssueDueReminders(GetIssuesDueWithin): %v", err)
+		return
+	}
+	for _, issue := range issues {
+		repo, err := models.GetRepositoryById(issue.RepoId)
+		if err != nil {
+			log.Error("mail.SendIssueDueReminders(GetRepositoryById): %v", err)
+			continue
+		} else if err = repo.GetOwner(); err != nil {
+			log.Error("mail.SendIssueDueReminders(GetOwner): %v", err)
+			continue
+		} else if err = issue.GetAssignees(); err != nil {
+			log.Error("mail.SendIssueDueReminders(GetAssignees): %v", err)
+			continue
+		}
+
+		users := issue.Assignees
+		if len(users) == 0 {
+			if err = issue.GetPoster(); err != nil {
+				log.Error("mail.SendIssueDueReminders(GetPoster): %v", err)
+				continue
+			} else if issue.Poster.Id == 0 {
+				continue
+			}
+			users = []*models.User{issue.Poster}
+		}
+		tos := make([]string, 0, len(users))
+		for _, u := range users {
+			if !u.IsBot() {
+				tos = append(tos, u.Email)
+			}
+		}
+		if len(tos) == 0 {
+			continue
+		}
+
+		subject := fmt.Sprintf("[%s] %s(#%d) is due on %s", repo.Name, issue.Name, issue.Index,
+			issue.Deadline.Format("Jan 02, 2006"))
+		content := fmt.Sprintf("%s<br>-<br> <a href=\"%s%s/%s/issues/%d\">View it on Gogs</a>.",
+			subject, setting.AppUrl, repo.Owner.Name, repo.Name, issue.Index)
+		msg := NewMailMessage(tos, subject, content)
+		msg.Info = fmt.Sprintf("Subject: %s, send issue due reminder emails", subject)
+		SendAsync(&msg)
+	}
+}
+
 // SendCollaboratorMail sends mail notification to new collaborator.
 func SendCollaboratorMail(r *middleware.Render, u, owner *models.User,
 	repo *models.Repository) error {
+	if u.IsBot() {
+		return nil
+	}
 
 	subject := fmt.Sprintf("%s added you to %s", owner.Name, repo.Name)
 
@@ -0,0 +1,84 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package markup
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gogits/gogs/modules/setting"
+)
+
+// externalRenderer renders content by command in configuration.
+type externalRenderer struct {
+	*setting.MarkupRenderer
+}
+
+func (r *externalRenderer) Name() string {
+	return r.MarkupRenderer.Name
+}
+
+func (r *externalRenderer) Extensions() []string {
+	return r.FileExtensions
+}
+
+var (
+	bodyStartTag = []byte("<body")
+	bodyEndTag   = []byte("</body>")
+)
+
+// extractBody returns content of body element if output is a complete HTML page.
+func extractBody(html []byte) []byte {
+	lower := bytes.ToLower(html)
+	start := bytes.Index(lower, bodyStartTag)
+	if start == -1 {
+		return html
+	}
+	start += bytes.IndexByte(lower[start:], '>') + 1
+	end := bytes.LastIndex(lower, bodyEndTag)
+	if end < start {
+		return html[start:]
+	}
+	return html[start:end]
+}
+
+func (r *externalRenderer) Render(rawBytes []byte, urlPrefix string) ([]byte, error) {
+	fields := strings.Fields(r.Command)
+	args := fields[1:]
+
+	var stdin *bytes.Reader
+	if r.IsInputFile {
+		f, err := ioutil.TempFile("", "gogs-markup-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(f.Name())
+		if _, err = f.Write(rawBytes); err != nil {
+			f.Close()
+			return nil, err
+		}
+		f.Close()
+		args = append(args, f.Name())
+	} else {
+		stdin = bytes.NewReader(rawBytes)
+	}
+
+	cmd := exec.Command(fields[0], args...)
+	cmd.Env = append(os.Environ(), "GOGS_PREFIX_URL="+urlPrefix)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.New(r.MarkupRenderer.Name + ": " + stderr.String())
+	}
+	return extractBody(stdout.Bytes()), nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package markup
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/setting"
+)
+
+var ErrNoRenderer = errors.New("No renderer for this file type")
+
+// Renderer renders content of markup files to HTML.
+type Renderer interface {
+	Name() string
+	// Extensions returns lower-cased file extensions with leading dot.
+	Extensions() []string
+	// Render returns HTML of content, urlPrefix is used to resolve relative links.
+	Render(rawBytes []byte, urlPrefix string) ([]byte, error)
+}
+
+var (
+	renderersLock sync.RWMutex
+	renderers     = make(map[string]Renderer)
+)
+
+// Register registers renderer for its file extensions,
+// renderer registered later replaces previous one of same extension.
+func Register(r Renderer) {
+	renderersLock.Lock()
+	defer renderersLock.Unlock()
+	for _, ext := range r.Extensions() {
+		renderers[strings.ToLower(ext)] = r
+	}
+}
+
+// GetRenderer returns renderer of file by its extension, it returns nil if file is not a markup file.
+func GetRenderer(name string) Renderer {
+	renderersLock.RLock()
+	defer renderersLock.RUnlock()
+	return renderers[strings.ToLower(filepath.Ext(name))]
+}
+
+// IsMarkupFile returns true if file can be rendered by a registered renderer.
+func IsMarkupFile(name string) bool {
+	return GetRenderer(name) != nil
+}
+
+// Render renders content of file by renderer of its extension.
+func Render(name string, rawBytes []byte, urlPrefix string) ([]byte, error) {
+	r := GetRenderer(name)
+	if r == nil {
+		return nil, ErrNoRenderer
+	}
+	return r.Render(rawBytes, urlPrefix)
+}
+
+type markdownRenderer struct{}
+
+func (markdownRenderer) Name() string {
+	return "markdown"
+}
+
+func (markdownRenderer) Extensions() []string {
+	return []string{".md", ".markdown", ".mdown"}
+}
+
+func (markdownRenderer) Render(rawBytes []byte, urlPrefix string) ([]byte, error) {
+	return base.RenderMarkdown(rawBytes, urlPrefix), nil
+}
+
+func init() {
+	Register(markdownRenderer{})
+}
+
+// NewRenderers registers external renderers in configuration.
+func NewRenderers() {
+	for _, cfg := range setting.MarkupRenderers {
+		Register(&externalRenderer{cfg})
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package markup
+
+import (
+	"testing"
+
+	"github.com/gogits/gogs/modules/setting"
+)
+
+func TestExtractBody(t *testing.T) {
+	tests := []struct {
+		html, expected string
+	}{
+		{"<p>text</p>", "<p>text</p>"},
+		{"<html><BODY class=\"doc\"><p>text</p></BODY></html>", "<p>text</p>"},
+		{"<html><body><p>text</p>", "<p>text</p>"},
+		{"<body><p>&lt;/body&gt;</p></body></html>", "<p>&lt;/body&gt;</p>"},
+	}
+	for _, tt := range tests {
+		if body := string(extractBody([]byte(tt.html))); body != tt.expected {
+			t.Errorf("extractBody(%q) = %q, expected %q", tt.html, body, tt.expected)
+		}
+	}
+}
+
+func TestExternalRenderer(t *testing.T) {
+	defer func() {
+		renderersLock.Lock()
+		delete(renderers, ".txt")
+		delete(renderers, ".text")
+		renderersLock.Unlock()
+	}()
+
+	for _, isInputFile := range []bool{false, true} {
+		Register(&externalRenderer{&setting.MarkupRenderer{
+			Name:           "text",
+			FileExtensions: []string{".txt", ".text"},
+			Command:        "cat",
+			IsInputFile:    isInputFile,
+		}})
+
+		if !IsMarkupFile("docs/README.TXT") || IsMarkupFile("main.go") {
+			t.Errorf("IsMarkupFile does not match registered extensions")
+		}
+		html, err := Render("a.text", []byte("<html><body>text</body></html>"), "/user1/repo1")
+		if err != nil {
+			t.Fatalf("Render(input file %v): %v", isInputFile, err)
+		} else if string(html) != "text" {
+			t.Errorf("Render(input file %v) = %q, expected %q", isInputFile, html, "text")
+		}
+	}
+
+	if _, err := Render("main.go", nil, ""); err != ErrNoRenderer {
+		t.Errorf("Render(main.go) error = %v, expected %v", err, ErrNoRenderer)
+	}
+	if r := GetRenderer("README.md"); r == nil || r.Name() != "markdown" {
+		t.Errorf("GetRenderer(README.md) = %v, expected markdown renderer", r)
+	}
+}
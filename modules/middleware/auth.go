@@ -10,13 +10,17 @@ import (
 
 	"github.com/go-martini/martini"
 
+	"github.com/gogits/gogs/modules/base"
 	"github.com/gogits/gogs/modules/setting"
 )
 
+const API_DOC_URL = "http://gogs.io/docs"
+
 type ToggleOptions struct {
 	SignInRequire  bool
 	SignOutRequire bool
 	AdminRequire   bool
+	ActiveRequire  bool // Requires user to have confirmed e-mail address.
 	DisableCsrf    bool
 }
 
@@ -34,8 +38,7 @@ func Toggle(options *ToggleOptions) martini.Handler {
 			return
 		}
 
-		if !options.DisableCsrf && ctx.Req.Method == "POST" && !ctx.CsrfTokenValid() {
-			ctx.Error(403, "CSRF token does not match")
+		if !options.DisableCsrf && !checkCsrf(ctx) {
 			return
 		}
 
@@ -48,7 +51,7 @@ func Toggle(options *ToggleOptions) martini.Handler {
 				ctx.SetCookie("redirect_to", "/"+url.QueryEscape(ctx.Req.RequestURI))
 				ctx.Redirect("/user/login")
 				return
-			} else if !ctx.User.IsActive && setting.Service.RegisterEmailConfirm {
+			} else if options.ActiveRequire && !ctx.User.IsActive && setting.Service.RegisterEmailConfirm {
 				ctx.Data["Title"] = "Activate Your Account"
 				ctx.HTML(200, "user/activate")
 				return
@@ -64,3 +67,55 @@ func Toggle(options *ToggleOptions) martini.Handler {
 		}
 	}
 }
+
+// ApiReqScope requires access token that authorizes current API request
+// to be granted given scope, requests authorized by session are not affected.
+func ApiReqScope(scope string) martini.Handler {
+	return func(ctx *Context) {
+		if ctx.AccessToken != nil && !ctx.AccessToken.HasScope(scope) {
+			ctx.JSON(403, &base.ApiJsonErr{"access token requires scope: " + scope, API_DOC_URL})
+			return
+		}
+	}
+}
+
+// ApiReqSignIn requires API request to be authorized by session or access token.
+func ApiReqSignIn() martini.Handler {
+	return func(ctx *Context) {
+		if !ctx.IsSigned {
+			ctx.JSON(401, &base.ApiJsonErr{"authentication required", API_DOC_URL})
+			return
+		}
+	}
+}
+
+// ApiReqActive requires API request to be authorized as a user who has confirmed
+// e-mail address, when registration requires confirmation.
+func ApiReqActive() martini.Handler {
+	return func(ctx *Context) {
+		if !ctx.IsSigned {
+			ctx.JSON(401, &base.ApiJsonErr{"authentication required", API_DOC_URL})
+			return
+		} else if !ctx.User.IsActive && setting.Service.RegisterEmailConfirm {
+			ctx.JSON(403, &base.ApiJsonErr{"account is not activated", API_DOC_URL})
+			return
+		}
+	}
+}
+
+// ApiReqAdmin requires API request to be authorized as a site administrator
+// from network that is allowed to access admin panel.
+func ApiReqAdmin() martini.Handler {
+	return func(ctx *Context) {
+		if !ctx.IsSigned {
+			ctx.JSON(401, &base.ApiJsonErr{"authentication required", API_DOC_URL})
+			return
+		} else if !ctx.User.IsAdmin {
+			ctx.JSON(403, &base.ApiJsonErr{"site administrator required", API_DOC_URL})
+			return
+		} else if !IsIpAllowed(ctx.RemoteAddr(), setting.AdminAllowNets, setting.AdminDenyNets) {
+			ctx.JSON(403, &base.ApiJsonErr{"network is not allowed to access admin API", API_DOC_URL})
+			return
+		}
+	}
+}
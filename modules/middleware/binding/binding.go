@@ -116,20 +116,23 @@ func MultipartForm(formStruct interface{}, ifacePtr ...interface{}) martini.Hand
 		formStruct := reflect.New(reflect.TypeOf(formStruct))
 		errors := newErrors()
 
-		// Workaround for multipart forms returning nil instead of an error
-		// when content is not multipart
-		// https://code.google.com/p/go/issues/detail?id=6334
-		multipartReader, err := req.MultipartReader()
-		if err != nil {
-			errors.Overall[BindingDeserializationError] = err.Error()
-		} else {
-			form, parseErr := multipartReader.ReadForm(MaxMemory)
+		// Form may have already been parsed by previous handlers, e.g. CSRF check.
+		if req.MultipartForm == nil {
+			// Workaround for multipart forms returning nil instead of an error
+			// when content is not multipart
+			// https://code.google.com/p/go/issues/detail?id=6334
+			multipartReader, err := req.MultipartReader()
+			if err != nil {
+				errors.Overall[BindingDeserializationError] = err.Error()
+			} else {
+				form, parseErr := multipartReader.ReadForm(MaxMemory)
 
-			if parseErr != nil {
-				errors.Overall[BindingDeserializationError] = parseErr.Error()
-			}
+				if parseErr != nil {
+					errors.Overall[BindingDeserializationError] = parseErr.Error()
+				}
 
-			req.MultipartForm = form
+				req.MultipartForm = form
+			}
 		}
 
 		mapForm(formStruct, req.MultipartForm.Value, errors)
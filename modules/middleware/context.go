@@ -9,7 +9,6 @@ import (
 	"crypto/sha1"
 	"encoding/base64"
 	"fmt"
-	"html/template"
 	"io"
 	"net/http"
 	"net/url"
@@ -26,7 +25,6 @@ import (
 
 	"github.com/gogits/gogs/models"
 	"github.com/gogits/gogs/modules/auth"
-	"github.com/gogits/gogs/modules/base"
 	"github.com/gogits/gogs/modules/log"
 	"github.com/gogits/gogs/modules/setting"
 )
@@ -44,10 +42,16 @@ type Context struct {
 	User     *models.User
 	IsSigned bool
 
+	// Access token that authorizes current API request, nil if
+	// request is authorized by session.
+	AccessToken *models.AccessToken
+
 	csrfToken string
 
 	Repo struct {
-		IsOwner    bool
+		IsOwner    bool // Has write access.
+		IsAdmin    bool // Can manage settings.
+		AccessMode int
 		IsWatching bool
 		IsBranch   bool
 		IsTag      bool
@@ -69,6 +73,12 @@ type Context struct {
 	}
 }
 
+// RemoteAddr returns IP address of client, headers set by reverse proxy
+// are only respected when request comes from local.
+func (ctx *Context) RemoteAddr() string {
+	return auth.RemoteAddr(ctx.Req)
+}
+
 // Query querys form parameter.
 func (ctx *Context) Query(name string) string {
 	ctx.Req.ParseForm()
@@ -103,6 +113,13 @@ func (ctx *Context) HasError() bool {
 	return hasErr.(bool)
 }
 
+// ShowSuccess sets success message of page rendered for current request, unlike
+// Flash.Success it is not stored in cookie so it can contain secrets such as new tokens.
+func (ctx *Context) ShowSuccess(msg string) {
+	ctx.Flash.SuccessMsg = msg
+	ctx.Data["Flash"] = ctx.Flash
+}
+
 // HTML calls render.HTML underlying but reduce one argument.
 func (ctx *Context) HTML(status int, name string, htmlOpt ...HTMLOptions) {
 	ctx.Render.HTML(status, name, ctx.Data, htmlOpt...)
@@ -240,33 +257,6 @@ func (ctx *Context) SetSecureCookie(Secret, name, value string, others ...interf
 	ctx.SetCookie(name, cookie, others...)
 }
 
-func (ctx *Context) CsrfToken() string {
-	if len(ctx.csrfToken) > 0 {
-		return ctx.csrfToken
-	}
-
-	token := ctx.GetCookie("_csrf")
-	if len(token) == 0 {
-		token = base.GetRandomString(30)
-		ctx.SetCookie("_csrf", token)
-	}
-	ctx.csrfToken = token
-	return token
-}
-
-func (ctx *Context) CsrfTokenValid() bool {
-	token := ctx.Query("_csrf")
-	if token == "" {
-		token = ctx.Req.Header.Get("X-Csrf-Token")
-	}
-	if token == "" {
-		return false
-	} else if ctx.csrfToken != token {
-		return false
-	}
-	return true
-}
-
 func (ctx *Context) ServeFile(file string, names ...string) {
 	var name string
 	if len(names) > 0 {
@@ -357,24 +347,31 @@ func InitContext() martini.Handler {
 			}
 		})
 
-		// Get user from session if logined.
-		user := auth.SignedInUser(ctx.Session)
+		// Get user from session if logined, signed in state of session or access token
+		// is not honored from networks that are not allowed to sign in.
+		user := auth.SignedInUser(ctx.Req, ctx.Session)
+		if user != nil && !IsIpAllowed(ctx.RemoteAddr(), setting.AuthAllowNets, setting.AuthDenyNets) {
+			user = nil
+		}
 		ctx.User = user
 		ctx.IsSigned = user != nil
 
 		ctx.Data["IsSigned"] = ctx.IsSigned
 
+		if user != nil && auth.IsApiPath(ctx.Req.URL.Path) {
+			if t := auth.RequestAccessToken(ctx.Req); t != nil && t.Uid == user.Id {
+				ctx.AccessToken = t
+			}
+		}
+
 		if user != nil {
 			ctx.Data["SignedUser"] = user
 			ctx.Data["SignedUserId"] = user.Id
 			ctx.Data["SignedUserName"] = user.Name
 			ctx.Data["IsAdmin"] = ctx.User.IsAdmin
+			ctx.Data["NotificationCount"] = models.CountUnreadNotifications(user.Id)
 		}
 
-		// get or create csrf token
-		ctx.Data["CsrfToken"] = ctx.CsrfToken()
-		ctx.Data["CsrfTokenHtml"] = template.HTML(`<input type="hidden" name="_csrf" value="` + ctx.csrfToken + `">`)
-
 		c.Map(ctx)
 
 		c.Next()
@@ -0,0 +1,96 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"crypto/subtle"
+	"html/template"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/modules/base"
+)
+
+const CSRF_SESSION_KEY = "_csrf"
+
+// CsrfToken returns CSRF token of current session,
+// a new one is generated if session does not have one yet.
+func (ctx *Context) CsrfToken() string {
+	if len(ctx.csrfToken) > 0 {
+		return ctx.csrfToken
+	}
+
+	token, _ := ctx.Session.Get(CSRF_SESSION_KEY).(string)
+	if len(token) == 0 {
+		token = base.GetRandomString(30)
+		ctx.Session.Set(CSRF_SESSION_KEY, token)
+	}
+	ctx.csrfToken = token
+	return token
+}
+
+// CsrfTokenValid returns true if request carries CSRF token of current session,
+// by "_csrf" form value or "X-Csrf-Token" header.
+func (ctx *Context) CsrfTokenValid() bool {
+	token := ctx.Query("_csrf")
+	if len(token) == 0 {
+		token = ctx.Req.Header.Get("X-Csrf-Token")
+	}
+	if len(token) == 0 {
+		return false
+	}
+
+	// Session may have expired since the form was rendered.
+	expected, _ := ctx.Session.Get(CSRF_SESSION_KEY).(string)
+	if len(expected) == 0 {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+// isSafeMethod returns true if request method is not supposed to change anything.
+func isSafeMethod(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS":
+		return true
+	}
+	return false
+}
+
+// checkCsrf rejects request that is not safe and has no valid CSRF token,
+// it returns false when response has been written.
+func checkCsrf(ctx *Context) bool {
+	if !isSafeMethod(ctx.Req.Method) && !ctx.CsrfTokenValid() {
+		ctx.Error(403, "CSRF token does not match")
+		return false
+	}
+	return true
+}
+
+// Csrf injects CSRF token of current session into template data.
+func Csrf() martini.Handler {
+	return func(ctx *Context) {
+		ctx.Data["CsrfToken"] = ctx.CsrfToken()
+		ctx.Data["CsrfTokenHtml"] = template.HTML(`<input type="hidden" name="_csrf" value="` + ctx.CsrfToken() + `">`)
+	}
+}
+
+// ValidateCsrf validates CSRF token for routes that do not use Toggle.
+func ValidateCsrf() martini.Handler {
+	return func(ctx *Context) {
+		checkCsrf(ctx)
+	}
+}
+
+// ApiCsrf validates CSRF token of API requests that are not safe and authorized by session,
+// since browser sends session cookie with requests that other sites make as well.
+// Requests authorized by access token are not affected.
+func ApiCsrf() martini.Handler {
+	return func(ctx *Context) {
+		if ctx.IsSigned && ctx.AccessToken == nil && !isSafeMethod(ctx.Req.Method) && !ctx.CsrfTokenValid() {
+			ctx.JSON(403, &base.ApiJsonErr{"CSRF token does not match, use an access token instead", API_DOC_URL})
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"testing"
+)
+
+var isSafeMethodTests = []struct {
+	method   string
+	expected bool
+}{
+	{"GET", true},
+	{"HEAD", true},
+	{"OPTIONS", true},
+	{"POST", false},
+	{"PUT", false},
+	{"PATCH", false},
+	{"DELETE", false},
+	{"get", false},
+}
+
+func TestIsSafeMethod(t *testing.T) {
+	for _, tt := range isSafeMethodTests {
+		if ok := isSafeMethod(tt.method); ok != tt.expected {
+			t.Errorf("isSafeMethod(%q) = %v, expected %v", tt.method, ok, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/modules/log"
+)
+
+func containsIp(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsIpAllowed returns true if given IP address is not in deny list,
+// and is in allow list when allow list is not empty.
+func IsIpAllowed(addr string, allow, deny []*net.IPNet) bool {
+	if len(allow) == 0 && len(deny) == 0 {
+		return true
+	}
+
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	} else if containsIp(deny, ip) {
+		return false
+	}
+	return len(allow) == 0 || containsIp(allow, ip)
+}
+
+// IpFilter returns a middleware that blocks clients whose IP address
+// is not allowed by given network rules.
+func IpFilter(allow, deny []*net.IPNet) martini.Handler {
+	return func(ctx *Context) {
+		addr := ctx.RemoteAddr()
+		if IsIpAllowed(addr, allow, deny) {
+			return
+		}
+
+		log.Trace("Request blocked by network rules: %s %s", addr, ctx.Req.RequestURI)
+		ctx.Data["Title"] = "Access Denied"
+		ctx.Data["RemoteAddr"] = addr
+		ctx.HTML(403, "status/403")
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net"
+	"testing"
+)
+
+func parseNets(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, nets[i], _ = net.ParseCIDR(cidr)
+	}
+	return nets
+}
+
+var isIpAllowedTests = []struct {
+	addr        string
+	allow, deny []*net.IPNet
+	expected    bool
+}{
+	{"10.0.0.1", nil, nil, true},
+	{"invalid", nil, nil, true},
+	{"10.0.0.1", parseNets("10.0.0.0/8"), nil, true},
+	{"192.168.1.1", parseNets("10.0.0.0/8"), nil, false},
+	{"192.168.1.1", parseNets("10.0.0.0/8", "192.168.0.0/16"), nil, true},
+	{"10.0.0.5", parseNets("10.0.0.0/8"), parseNets("10.0.0.5/32"), false},
+	{"10.0.0.6", parseNets("10.0.0.0/8"), parseNets("10.0.0.5/32"), true},
+	{"10.0.0.5", nil, parseNets("10.0.0.5/32"), false},
+	{"10.0.0.6", nil, parseNets("10.0.0.5/32"), true},
+	{"::1", parseNets("::1/128"), nil, true},
+	{"::2", parseNets("::1/128"), nil, false},
+	{"invalid", parseNets("10.0.0.0/8"), nil, false},
+}
+
+func TestIsIpAllowed(t *testing.T) {
+	for i, tt := range isIpAllowedTests {
+		if ok := IsIpAllowed(tt.addr, tt.allow, tt.deny); ok != tt.expected {
+			t.Errorf("#%d: IsIpAllowed(%q) = %v, expected %v", i, tt.addr, ok, tt.expected)
+		}
+	}
+}
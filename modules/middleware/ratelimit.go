@@ -0,0 +1,104 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/setting"
+)
+
+const RATE_LIMIT_WINDOW = time.Hour
+
+type rateLimitWindow struct {
+	reset time.Time
+	count int
+}
+
+var rateLimiter = struct {
+	sync.Mutex
+	windows   map[string]*rateLimitWindow
+	lastSweep time.Time
+}{windows: make(map[string]*rateLimitWindow)}
+
+// takeRateLimit counts a request of given key, it returns number of remaining requests
+// and time when the limit resets, and false if the limit has been exceeded.
+func takeRateLimit(key string, limit int) (int, time.Time, bool) {
+	rateLimiter.Lock()
+	defer rateLimiter.Unlock()
+
+	now := time.Now()
+	// Forget windows that have expired so that memory does not keep growing.
+	if now.Sub(rateLimiter.lastSweep) > RATE_LIMIT_WINDOW {
+		for k, w := range rateLimiter.windows {
+			if now.After(w.reset) {
+				delete(rateLimiter.windows, k)
+			}
+		}
+		rateLimiter.lastSweep = now
+	}
+
+	w, ok := rateLimiter.windows[key]
+	if !ok || now.After(w.reset) {
+		w = &rateLimitWindow{reset: now.Add(RATE_LIMIT_WINDOW)}
+		rateLimiter.windows[key] = w
+	}
+	if w.count >= limit {
+		return 0, w.reset, false
+	}
+	w.count++
+	return limit - w.count, w.reset, true
+}
+
+// apiRateLimitKey returns key and limit of rate limiting that request falls into.
+func apiRateLimitKey(ctx *Context) (string, int) {
+	if ctx.AccessToken != nil {
+		return "token:" + base.ToStr(ctx.AccessToken.Id), setting.ApiRateLimit
+	} else if ctx.IsSigned {
+		return "user:" + base.ToStr(ctx.User.Id), setting.ApiRateLimit
+	}
+	return "ip:" + ctx.RemoteAddr(), setting.ApiRateLimitAnonymous
+}
+
+// RefundApiRateLimit gives back the request counted against rate limit,
+// it must be called before response is written.
+func RefundApiRateLimit(ctx *Context) {
+	key, limit := apiRateLimitKey(ctx)
+	if limit <= 0 {
+		return
+	}
+
+	rateLimiter.Lock()
+	defer rateLimiter.Unlock()
+	if w, ok := rateLimiter.windows[key]; ok && w.count > 0 {
+		w.count--
+		ctx.Res.Header().Set("X-RateLimit-Remaining", base.ToStr(limit-w.count))
+	}
+}
+
+// ApiRateLimit limits number of API requests per hour of each access token,
+// signed in user or IP address of anonymous requests.
+func ApiRateLimit() martini.Handler {
+	return func(ctx *Context) {
+		key, limit := apiRateLimitKey(ctx)
+		if limit <= 0 {
+			return
+		}
+
+		remaining, reset, ok := takeRateLimit(key, limit)
+		ctx.Res.Header().Set("X-RateLimit-Limit", base.ToStr(limit))
+		ctx.Res.Header().Set("X-RateLimit-Remaining", base.ToStr(remaining))
+		ctx.Res.Header().Set("X-RateLimit-Reset", base.ToStr(reset.Unix()))
+		if !ok {
+			ctx.Res.Header().Set("Retry-After", base.ToStr(int64(reset.Sub(time.Now())/time.Second)+1))
+			ctx.JSON(429, &base.ApiJsonErr{"API rate limit exceeded", API_DOC_URL})
+			return
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTakeRateLimit(t *testing.T) {
+	defer func() {
+		rateLimiter.Lock()
+		delete(rateLimiter.windows, "test:1")
+		delete(rateLimiter.windows, "test:2")
+		rateLimiter.Unlock()
+	}()
+
+	tests := []struct {
+		key       string
+		remaining int
+		ok        bool
+	}{
+		{"test:1", 1, true},
+		{"test:1", 0, true},
+		{"test:1", 0, false},
+		{"test:2", 1, true},
+	}
+	for _, tt := range tests {
+		remaining, reset, ok := takeRateLimit(tt.key, 2)
+		if remaining != tt.remaining || ok != tt.ok {
+			t.Errorf("takeRateLimit(%s) = (%d, %v), expected (%d, %v)", tt.key, remaining, ok, tt.remaining, tt.ok)
+		} else if reset.Before(time.Now()) {
+			t.Errorf("takeRateLimit(%s) resets at %v, expected in the future", tt.key, reset)
+		}
+	}
+
+	// Limit resets once window has expired.
+	rateLimiter.Lock()
+	rateLimiter.windows["test:1"].reset = time.Now().Add(-time.Second)
+	rateLimiter.Unlock()
+	if remaining, _, ok := takeRateLimit("test:1", 2); remaining != 1 || !ok {
+		t.Errorf("takeRateLimit(expired) = (%d, %v), expected (1, true)", remaining, ok)
+	}
+}
@@ -19,6 +19,45 @@ import (
 	"github.com/gogits/gogs/modules/setting"
 )
 
+// redirectTransferredRepo redirects request to new location of repository
+// that has been transferred, or responses 404 if there is none.
+func redirectTransferredRepo(ctx *Context, owner *models.User, repoName string) {
+	repo, err := models.GetRedirectedRepository(owner.Id, repoName)
+	if err != nil {
+		if err == models.ErrRepoNotExist {
+			ctx.Handle(404, "RepoAssignment", err)
+		} else {
+			ctx.Handle(500, "RepoAssignment(GetRedirectedRepository)", err)
+		}
+		return
+	}
+
+	// Do not reveal new location of private repository.
+	if repo.IsPrivate {
+		hasAccess := false
+		if ctx.IsSigned {
+			hasAccess, err = models.HasAccess(ctx.User.Name, repo.Owner.Name+"/"+repo.Name, models.AU_READABLE)
+			if err != nil {
+				ctx.Handle(500, "RepoAssignment(HasAccess)", err)
+				return
+			}
+		}
+		if !hasAccess {
+			ctx.Handle(404, "RepoAssignment", models.ErrRepoNotExist)
+			return
+		}
+	}
+
+	link := "/" + repo.Owner.Name + "/" + repo.Name
+	if prefix := "/" + owner.Name + "/" + repoName; len(ctx.Req.URL.Path) >= len(prefix) {
+		link += ctx.Req.URL.Path[len(prefix):]
+	}
+	if len(ctx.Req.URL.RawQuery) > 0 {
+		link += "?" + ctx.Req.URL.RawQuery
+	}
+	ctx.Redirect(link)
+}
+
 func RepoAssignment(redirect bool, args ...bool) martini.Handler {
 	return func(ctx *Context, params martini.Params) {
 		log.Trace(fmt.Sprint(args))
@@ -50,11 +89,12 @@ func RepoAssignment(redirect bool, args ...bool) martini.Handler {
 
 		// Collaborators who have write access can be seen as owners.
 		if ctx.IsSigned {
-			ctx.Repo.IsOwner, err = models.HasAccess(ctx.User.Name, userName+"/"+repoName, models.AU_WRITABLE)
+			ctx.Repo.AccessMode, err = models.GetAccessMode(ctx.User.Name, userName+"/"+repoName)
 			if err != nil {
-				ctx.Handle(500, "RepoAssignment(HasAccess)", err)
+				ctx.Handle(500, "RepoAssignment(GetAccessMode)", err)
 				return
 			}
+			ctx.Repo.IsOwner = ctx.Repo.AccessMode >= models.AU_WRITABLE
 			isTrueOwner = ctx.User.LowerName == strings.ToLower(userName)
 		}
 
@@ -89,7 +129,7 @@ func RepoAssignment(redirect bool, args ...bool) martini.Handler {
 		repo, err := models.GetRepositoryByName(user.Id, repoName)
 		if err != nil {
 			if err == models.ErrRepoNotExist {
-				ctx.Handle(404, "RepoAssignment", err)
+				redirectTransferredRepo(ctx, user, repoName)
 				return
 			} else if redirect {
 				ctx.Redirect("/")
@@ -103,9 +143,12 @@ func RepoAssignment(redirect bool, args ...bool) martini.Handler {
 		if ctx.IsSigned && !ctx.Repo.IsOwner && repo.OwnerId == ctx.User.Id {
 			ctx.Repo.IsOwner = true
 		}
+		// Owner and site administrators can always manage repository.
+		ctx.Repo.IsAdmin = ctx.IsSigned && (repo.OwnerId == ctx.User.Id || ctx.User.IsAdmin ||
+			ctx.Repo.AccessMode >= models.AU_ADMIN)
 
 		// Check access.
-		if repo.IsPrivate && !ctx.Repo.IsOwner {
+		if repo.IsPrivate && !ctx.Repo.IsOwner && !ctx.Repo.IsAdmin {
 			if ctx.User == nil {
 				ctx.Handle(404, "RepoAssignment(HasAccess)", nil)
 				return
@@ -130,10 +173,19 @@ func RepoAssignment(redirect bool, args ...bool) martini.Handler {
 				return
 			}
 			ctx.Data["MirrorInterval"] = ctx.Repo.Mirror.Interval
+			ctx.Data["Mirror"] = ctx.Repo.Mirror
+		}
+
+		if repo.IsFork {
+			if err = repo.GetBaseRepo(); err != nil {
+				ctx.Handle(500, "RepoAssignment(GetBaseRepo)", err)
+				return
+			}
 		}
 
 		repo.NumOpenIssues = repo.NumIssues - repo.NumClosedIssues
 		repo.NumOpenMilestones = repo.NumMilestones - repo.NumClosedMilestones
+		repo.Owner = user
 		ctx.Repo.Repository = repo
 		ctx.Data["IsBareRepo"] = ctx.Repo.Repository.IsBare
 
@@ -157,6 +209,10 @@ func RepoAssignment(redirect bool, args ...bool) martini.Handler {
 		ctx.Data["Owner"] = user
 		ctx.Data["RepoLink"] = ctx.Repo.RepoLink
 		ctx.Data["IsRepositoryOwner"] = ctx.Repo.IsOwner
+		ctx.Data["IsRepositoryAdmin"] = ctx.Repo.IsAdmin
+		// Hook scripts are run on server as the user that runs Gogs,
+		// so only site administrators can edit them.
+		ctx.Data["CanEditGitHooks"] = !setting.DisableGitHooks && ctx.IsSigned && ctx.User.IsAdmin
 		ctx.Data["BranchName"] = ""
 
 		if setting.SshPort != 22 {
@@ -270,3 +326,29 @@ func RequireOwner() martini.Handler {
 		}
 	}
 }
+
+// RequireAdmin requires user to be able to manage settings of repository.
+func RequireAdmin() martini.Handler {
+	return func(ctx *Context) {
+		if !ctx.Repo.IsAdmin {
+			if !ctx.IsSigned {
+				ctx.SetCookie("redirect_to", "/"+url.QueryEscape(ctx.Req.RequestURI))
+				ctx.Redirect("/user/login")
+				return
+			}
+			ctx.Handle(404, ctx.Req.RequestURI, nil)
+			return
+		}
+	}
+}
+
+// RequireUnarchived redirects to repository home page if repository is archived.
+func RequireUnarchived() martini.Handler {
+	return func(ctx *Context) {
+		if ctx.Repo.Repository != nil && ctx.Repo.Repository.IsArchived {
+			ctx.Flash.Error("This repository is archived and read-only.")
+			ctx.Redirect(ctx.Repo.RepoLink)
+			return
+		}
+	}
+}
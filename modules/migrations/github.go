@@ -0,0 +1,183 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+	"time"
+)
+
+const _GITHUB_API_URL = "https://api.github.com"
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubLabel struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type githubMilestone struct {
+	Number      int64      `json:"number"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	State       string     `json:"state"`
+	DueOn       *time.Time `json:"due_on"`
+}
+
+type githubIssue struct {
+	Number      int64            `json:"number"`
+	Title       string           `json:"title"`
+	Body        string           `json:"body"`
+	User        githubUser       `json:"user"`
+	Labels      []githubLabel    `json:"labels"`
+	Milestone   *githubMilestone `json:"milestone"`
+	State       string           `json:"state"`
+	PullRequest *struct{}        `json:"pull_request"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+type githubComment struct {
+	Body      string     `json:"body"`
+	User      githubUser `json:"user"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type githubRelease struct {
+	TagName    string `json:"tag_name"`
+	Name       string `json:"name"`
+	Body       string `json:"body"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+// githubDownloader downloads metadata through GitHub API v3.
+type githubDownloader struct {
+	repoPath string
+	headers  map[string]string
+}
+
+func newGithubDownloader(repoPath, token string) *githubDownloader {
+	d := &githubDownloader{
+		repoPath: repoPath,
+		headers:  map[string]string{"Accept": "application/vnd.github.v3+json"},
+	}
+	if len(token) > 0 {
+		d.headers["Authorization"] = "token " + token
+	}
+	return d
+}
+
+// getPages requests all pages of given API path of repository.
+func (d *githubDownloader) getPages(path string, newPage func() interface{}, appendPage func(interface{}) int) error {
+	return getPages(_GITHUB_API_URL+"/repos/"+d.repoPath+path, d.headers, newPage, appendPage)
+}
+
+func (d *githubDownloader) GetLabels() ([]*Label, error) {
+	labels := make([]*Label, 0, 10)
+	err := d.getPages("/labels", func() interface{} { return &[]githubLabel{} }, func(v interface{}) int {
+		page := *v.(*[]githubLabel)
+		for _, l := range page {
+			labels = append(labels, &Label{Name: l.Name, Color: "#" + l.Color})
+		}
+		return len(page)
+	})
+	return labels, err
+}
+
+func (d *githubDownloader) GetMilestones() ([]*Milestone, error) {
+	miles := make([]*Milestone, 0, 10)
+	err := d.getPages("/milestones?state=all&sort=due_date&direction=asc", func() interface{} { return &[]githubMilestone{} }, func(v interface{}) int {
+		page := *v.(*[]githubMilestone)
+		for _, m := range page {
+			mile := &Milestone{
+				Number:   m.Number,
+				Title:    m.Title,
+				Content:  m.Description,
+				IsClosed: m.State == "closed",
+			}
+			if m.DueOn != nil {
+				mile.Deadline = *m.DueOn
+			}
+			miles = append(miles, mile)
+		}
+		return len(page)
+	})
+	return miles, err
+}
+
+func (d *githubDownloader) GetIssues() ([]*Issue, error) {
+	issues := make([]*Issue, 0, 50)
+	err := d.getPages("/issues?state=all&sort=created&direction=asc", func() interface{} { return &[]githubIssue{} }, func(v interface{}) int {
+		page := *v.(*[]githubIssue)
+		for _, i := range page {
+			// Pull requests are also listed as issues by GitHub.
+			if i.PullRequest != nil {
+				continue
+			}
+			issue := &Issue{
+				Number:   i.Number,
+				Title:    i.Title,
+				Content:  i.Body,
+				Poster:   i.User.Login,
+				Labels:   make([]string, len(i.Labels)),
+				IsClosed: i.State == "closed",
+				Created:  i.CreatedAt,
+			}
+			for j := range i.Labels {
+				issue.Labels[j] = i.Labels[j].Name
+			}
+			if i.Milestone != nil {
+				issue.MilestoneId = i.Milestone.Number
+			}
+			issues = append(issues, issue)
+		}
+		return len(page)
+	})
+	return issues, err
+}
+
+func (d *githubDownloader) GetComments(issueNumber int64) ([]*Comment, error) {
+	comments := make([]*Comment, 0, 10)
+	err := d.getPages(fmt.Sprintf("/issues/%d/comments", issueNumber), func() interface{} { return &[]githubComment{} }, func(v interface{}) int {
+		page := *v.(*[]githubComment)
+		for _, c := range page {
+			comments = append(comments, &Comment{Poster: c.User.Login, Content: c.Body, Created: c.CreatedAt})
+		}
+		return len(page)
+	})
+	return comments, err
+}
+
+func (d *githubDownloader) GetReleases() ([]*Release, error) {
+	rels := make([]*Release, 0, 10)
+	err := d.getPages("/releases", func() interface{} { return &[]githubRelease{} }, func(v interface{}) int {
+		page := *v.(*[]githubRelease)
+		for _, r := range page {
+			rels = append(rels, &Release{
+				TagName:      r.TagName,
+				Title:        r.Name,
+				Note:         r.Body,
+				IsDraft:      r.Draft,
+				IsPrerelease: r.Prerelease,
+			})
+		}
+		return len(page)
+	})
+	return rels, err
+}
+
+func (d *githubDownloader) GetCollaborators() ([]string, error) {
+	names := make([]string, 0, 5)
+	err := d.getPages("/collaborators", func() interface{} { return &[]githubUser{} }, func(v interface{}) int {
+		page := *v.(*[]githubUser)
+		for _, u := range page {
+			names = append(names, u.Login)
+		}
+		return len(page)
+	})
+	return names, err
+}
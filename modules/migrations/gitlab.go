@@ -0,0 +1,194 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// Minimum access level of GitLab member who can push, which is developer.
+const _GITLAB_DEVELOPER = 30
+
+type gitlabUser struct {
+	Username    string `json:"username"`
+	AccessLevel int    `json:"access_level"`
+}
+
+type gitlabLabel struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type gitlabMilestone struct {
+	Id          int64  `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	State       string `json:"state"`
+	DueDate     string `json:"due_date"`
+}
+
+type gitlabIssue struct {
+	Id          int64            `json:"id"`
+	Iid         int64            `json:"iid"`
+	Title       string           `json:"title"`
+	Description string           `json:"description"`
+	Author      gitlabUser       `json:"author"`
+	Labels      []string         `json:"labels"`
+	Milestone   *gitlabMilestone `json:"milestone"`
+	State       string           `json:"state"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+type gitlabNote struct {
+	Body      string     `json:"body"`
+	Author    gitlabUser `json:"author"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type gitlabTag struct {
+	Name    string `json:"name"`
+	Release *struct {
+		Description string `json:"description"`
+	} `json:"release"`
+}
+
+type gitlabIssues []*Issue
+
+func (is gitlabIssues) Len() int           { return len(is) }
+func (is gitlabIssues) Less(i, j int) bool { return is[i].Number < is[j].Number }
+func (is gitlabIssues) Swap(i, j int)      { is[i], is[j] = is[j], is[i] }
+
+// gitlabDownloader downloads metadata through GitLab API v3.
+type gitlabDownloader struct {
+	projectUrl string
+	headers    map[string]string
+	issueIds   map[int64]int64 // Number of issue -> ID of issue, notes are requested by ID.
+}
+
+func newGitlabDownloader(baseUrl, repoPath, token string) *gitlabDownloader {
+	d := &gitlabDownloader{
+		projectUrl: baseUrl + "/api/v3/projects/" + url.QueryEscape(repoPath),
+		headers:    map[string]string{},
+		issueIds:   make(map[int64]int64),
+	}
+	if len(token) > 0 {
+		d.headers["PRIVATE-TOKEN"] = token
+	}
+	return d
+}
+
+// getPages requests all pages of given API path of project.
+func (d *gitlabDownloader) getPages(path string, newPage func() interface{}, appendPage func(interface{}) int) error {
+	return getPages(d.projectUrl+path, d.headers, newPage, appendPage)
+}
+
+func (d *gitlabDownloader) GetLabels() ([]*Label, error) {
+	labels := make([]*Label, 0, 10)
+	err := d.getPages("/labels", func() interface{} { return &[]gitlabLabel{} }, func(v interface{}) int {
+		page := *v.(*[]gitlabLabel)
+		for _, l := range page {
+			labels = append(labels, &Label{Name: l.Name, Color: l.Color})
+		}
+		return len(page)
+	})
+	return labels, err
+}
+
+func (d *gitlabDownloader) GetMilestones() ([]*Milestone, error) {
+	miles := make([]*Milestone, 0, 10)
+	err := d.getPages("/milestones", func() interface{} { return &[]gitlabMilestone{} }, func(v interface{}) int {
+		page := *v.(*[]gitlabMilestone)
+		for _, m := range page {
+			mile := &Milestone{
+				Number:   m.Id,
+				Title:    m.Title,
+				Content:  m.Description,
+				IsClosed: m.State == "closed",
+			}
+			if len(m.DueDate) > 0 {
+				mile.Deadline, _ = time.Parse("2006-01-02", m.DueDate)
+			}
+			miles = append(miles, mile)
+		}
+		return len(page)
+	})
+	return miles, err
+}
+
+func (d *gitlabDownloader) GetIssues() ([]*Issue, error) {
+	issues := make([]*Issue, 0, 50)
+	err := d.getPages("/issues", func() interface{} { return &[]gitlabIssue{} }, func(v interface{}) int {
+		page := *v.(*[]gitlabIssue)
+		for _, i := range page {
+			issue := &Issue{
+				Number:   i.Iid,
+				Title:    i.Title,
+				Content:  i.Description,
+				Poster:   i.Author.Username,
+				Labels:   i.Labels,
+				IsClosed: i.State == "closed",
+				Created:  i.CreatedAt,
+			}
+			if i.Milestone != nil {
+				issue.MilestoneId = i.Milestone.Id
+			}
+			d.issueIds[i.Iid] = i.Id
+			issues = append(issues, issue)
+		}
+		return len(page)
+	})
+	sort.Sort(gitlabIssues(issues))
+	return issues, err
+}
+
+func (d *gitlabDownloader) GetComments(issueNumber int64) ([]*Comment, error) {
+	id, ok := d.issueIds[issueNumber]
+	if !ok {
+		return nil, fmt.Errorf("issue #%d has not been downloaded", issueNumber)
+	}
+
+	comments := make([]*Comment, 0, 10)
+	err := d.getPages(fmt.Sprintf("/issues/%d/notes", id), func() interface{} { return &[]gitlabNote{} }, func(v interface{}) int {
+		page := *v.(*[]gitlabNote)
+		for _, n := range page {
+			comments = append(comments, &Comment{Poster: n.Author.Username, Content: n.Body, Created: n.CreatedAt})
+		}
+		return len(page)
+	})
+	return comments, err
+}
+
+// GetReleases returns tags that have release notes, GitLab has no separate releases.
+func (d *gitlabDownloader) GetReleases() ([]*Release, error) {
+	rels := make([]*Release, 0, 10)
+	err := d.getPages("/repository/tags", func() interface{} { return &[]gitlabTag{} }, func(v interface{}) int {
+		page := *v.(*[]gitlabTag)
+		for _, t := range page {
+			if t.Release == nil {
+				continue
+			}
+			rels = append(rels, &Release{TagName: t.Name, Title: t.Name, Note: t.Release.Description})
+		}
+		return len(page)
+	})
+	return rels, err
+}
+
+func (d *gitlabDownloader) GetCollaborators() ([]string, error) {
+	names := make([]string, 0, 5)
+	err := d.getPages("/members", func() interface{} { return &[]gitlabUser{} }, func(v interface{}) int {
+		page := *v.(*[]gitlabUser)
+		for _, u := range page {
+			if u.AccessLevel >= _GITLAB_DEVELOPER {
+				names = append(names, u.Username)
+			}
+		}
+		return len(page)
+	})
+	return names, err
+}
@@ -0,0 +1,173 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package migrations downloads metadata of repository from other project hosting services.
+package migrations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gogits/gogs/modules/httplib"
+)
+
+const (
+	SERVICE_GIT    = "git" // Plain Git repository, only data of Git is migrated.
+	SERVICE_GITHUB = "github"
+	SERVICE_GITLAB = "gitlab"
+)
+
+var (
+	ErrServiceNotSupported = errors.New("Migration service is not supported")
+	ErrInvalidRepoUrl      = errors.New("Repository URL does not point to a repository of migration service")
+)
+
+// Label represents a label of remote repository.
+type Label struct {
+	Name  string
+	Color string // In form of "#rrggbb".
+}
+
+// Milestone represents a milestone of remote repository.
+type Milestone struct {
+	Number   int64
+	Title    string
+	Content  string
+	IsClosed bool
+	Deadline time.Time
+}
+
+// Issue represents an issue of remote repository.
+type Issue struct {
+	Number      int64
+	Title       string
+	Content     string
+	Poster      string
+	Labels      []string
+	MilestoneId int64 // Number of milestone, 0 when there is none.
+	IsClosed    bool
+	Created     time.Time
+}
+
+// Comment represents a comment of issue in remote repository.
+type Comment struct {
+	Poster  string
+	Content string
+	Created time.Time
+}
+
+// Release represents a release of remote repository.
+type Release struct {
+	TagName      string
+	Title        string
+	Note         string
+	IsDraft      bool
+	IsPrerelease bool
+}
+
+// Downloader fetches metadata of a remote repository.
+type Downloader interface {
+	GetLabels() ([]*Label, error)
+	GetMilestones() ([]*Milestone, error)
+	// GetIssues returns issues in ascending order of number, pull requests are excluded.
+	GetIssues() ([]*Issue, error)
+	GetComments(issueNumber int64) ([]*Comment, error)
+	GetReleases() ([]*Release, error)
+	// GetCollaborators returns user names of people who can push to repository.
+	GetCollaborators() ([]string, error)
+}
+
+// DetectService returns migration service by host of repository URL,
+// it returns SERVICE_GIT if it cannot be told.
+func DetectService(repoUrl string) string {
+	u, err := url.Parse(repoUrl)
+	if err != nil {
+		return SERVICE_GIT
+	}
+	switch strings.ToLower(u.Host) {
+	case "github.com", "www.github.com":
+		return SERVICE_GITHUB
+	case "gitlab.com", "www.gitlab.com":
+		return SERVICE_GITLAB
+	}
+	return SERVICE_GIT
+}
+
+// parseRepoUrl returns base URL of service and path of repository in form of "<owner>/<name>".
+func parseRepoUrl(repoUrl string) (string, string, error) {
+	u, err := url.Parse(repoUrl)
+	if err != nil {
+		return "", "", err
+	}
+	repoPath := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if strings.Count(repoPath, "/") != 1 {
+		return "", "", ErrInvalidRepoUrl
+	}
+	return u.Scheme + "://" + u.Host, repoPath, nil
+}
+
+// NewDownloader returns downloader of given service for repository,
+// token is used to access private data if it is not empty.
+func NewDownloader(service, repoUrl, token string) (Downloader, error) {
+	baseUrl, repoPath, err := parseRepoUrl(repoUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	switch service {
+	case SERVICE_GITHUB:
+		return newGithubDownloader(repoPath, token), nil
+	case SERVICE_GITLAB:
+		return newGitlabDownloader(baseUrl, repoPath, token), nil
+	}
+	return nil, ErrServiceNotSupported
+}
+
+// Number of items requested in every page of API.
+const _PAGE_SIZE = 100
+
+// getPages requests all pages of given URL until a page is not full, newPage returns
+// pointer to a new slice for every page and appendPage collects items of that page
+// and returns number of them.
+func getPages(pageUrl string, headers map[string]string, newPage func() interface{}, appendPage func(interface{}) int) error {
+	sep := "?"
+	if strings.Contains(pageUrl, "?") {
+		sep = "&"
+	}
+	for page := 1; ; page++ {
+		v := newPage()
+		if err := getJson(fmt.Sprintf("%s%sper_page=%d&page=%d", pageUrl, sep, _PAGE_SIZE, page), headers, v); err != nil {
+			return err
+		}
+		if appendPage(v) < _PAGE_SIZE {
+			return nil
+		}
+	}
+}
+
+// getJson requests given URL with headers and decodes JSON response into v.
+func getJson(reqUrl string, headers map[string]string, v interface{}) error {
+	req := httplib.Get(reqUrl).SetTimeout(10*time.Second, 30*time.Second)
+	for key, value := range headers {
+		req.Header(key, value)
+	}
+	resp, err := req.Response()
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	} else if resp.StatusCode != 200 {
+		return fmt.Errorf("GET %s: %s", reqUrl, resp.Status)
+	}
+	return json.Unmarshal(data, v)
+}
@@ -3,7 +3,9 @@
 // Use of this source code is governed by a MIT-style
 // license that can be found in the LICENSE file.
 
-package social
+// Package oauth2 implements authorization code flow of OAuth2 providers
+// that users can sign in with or link to their accounts.
+package oauth2
 
 import (
 	"encoding/json"
@@ -35,7 +37,7 @@ type SocialConnector interface {
 }
 
 var (
-	SocialBaseUrl = "/user/login"
+	SocialBaseUrl = "/user/login/oauth2/"
 	SocialMap     = make(map[string]SocialConnector)
 )
 
@@ -6,6 +6,7 @@ package setting
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
 	"path"
@@ -52,15 +53,65 @@ var (
 	LogInRememberDays  int
 	CookieUserName     string
 	CookieRememberName string
+	BcryptCost         int
+
+	// Login throttling settings.
+	LoginMaxFailures      int
+	LoginMaxFailuresPerIp int
+	LoginFailureWindow    int
+
+	// Reverse proxy authentication settings.
+	ReverseProxyAuthUser  string
+	ReverseProxyAuthEmail string
+
+	// Password policy settings.
+	PasswdMinLength   int
+	PasswdComplexity  []string
+	PasswdCheckCommon bool
+
+	// Network access rules, empty allow list means all networks are allowed.
+	AdminAllowNets []*net.IPNet
+	AdminDenyNets  []*net.IPNet
+	AuthAllowNets  []*net.IPNet
+	AuthDenyNets   []*net.IPNet
 
 	// Repository settings.
 	RepoRootPath string
 	ScriptType   string
+	RepoMaxSize  int64 // In megabytes, 0 means unlimited.
+	UserMaxSize  int64 // Total size of repositories of every user in megabytes, 0 means unlimited.
+
+	DisableGitHooks bool
 
 	// Picture settings.
 	PictureService  string
 	DisableGravatar bool
 
+	// Release attachment settings.
+	AttachmentEnabled  bool
+	AttachmentPath     string
+	AttachmentMaxSize  int64 // In megabytes.
+	AttachmentMaxFiles int
+
+	// Issue attachment settings, files are stored along with release attachments.
+	IssueAttachmentEnabled      bool
+	IssueAttachmentAllowedTypes []string
+	IssueAttachmentMaxSize      int64 // In megabytes.
+	IssueAttachmentMaxFiles     int
+
+	// Issue due date settings.
+	IssueDueReminderEnabled bool
+	IssueDueReminderDays    int
+
+	// Repository indexer settings.
+	RepoIndexerEnabled     bool
+	RepoIndexerPath        string
+	RepoIndexerMaxFileSize int64 // In kilobytes.
+
+	// API settings, max numbers of requests per hour, 0 means unlimited.
+	ApiRateLimit          int // For each access token or signed in user.
+	ApiRateLimitAnonymous int // For each IP address of anonymous requests.
+
 	// Log settings.
 	LogRootPath string
 	LogModes    []string
@@ -99,6 +150,32 @@ func WorkDir() (string, error) {
 	return path.Dir(strings.Replace(p, "\\", "/", -1)), nil
 }
 
+// parseNetworks parses comma separated CIDR list of given key in security section,
+// single IP address is treated as a network that only contains itself.
+func parseNetworks(key string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, 5)
+	for _, cidr := range strings.Split(Cfg.MustValue("security", key), ",") {
+		cidr = strings.TrimSpace(cidr)
+		if len(cidr) == 0 {
+			continue
+		}
+
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			log.Fatal("Invalid network '%s' in %s: %v", cidr, key, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets
+}
+
 // NewConfigContext initializes configuration context.
 // NOTE: do not print any log except error.
 func NewConfigContext() {
@@ -154,6 +231,31 @@ func NewConfigContext() {
 	LogInRememberDays = Cfg.MustInt("security", "LOGIN_REMEMBER_DAYS")
 	CookieUserName = Cfg.MustValue("security", "COOKIE_USERNAME")
 	CookieRememberName = Cfg.MustValue("security", "COOKIE_REMEMBER_NAME")
+	BcryptCost = Cfg.MustInt("security", "BCRYPT_COST", 10)
+	if BcryptCost < 4 || BcryptCost > 31 {
+		log.Warn("Invalid BCRYPT_COST(%d), use default value", BcryptCost)
+		BcryptCost = 10
+	}
+	LoginMaxFailures = Cfg.MustInt("security", "LOGIN_MAX_FAILURES", 5)
+	LoginMaxFailuresPerIp = Cfg.MustInt("security", "LOGIN_MAX_FAILURES_PER_IP", 20)
+	LoginFailureWindow = Cfg.MustInt("security", "LOGIN_FAILURE_WINDOW", 15)
+	ReverseProxyAuthUser = Cfg.MustValue("security", "REVERSE_PROXY_AUTHENTICATION_USER", "X-WEBAUTH-USER")
+	ReverseProxyAuthEmail = Cfg.MustValue("security", "REVERSE_PROXY_AUTHENTICATION_EMAIL", "X-WEBAUTH-EMAIL")
+	PasswdMinLength = Cfg.MustInt("security", "PASSWORD_MIN_LENGTH", 6)
+	if PasswdMinLength < 6 {
+		PasswdMinLength = 6
+	}
+	PasswdComplexity = PasswdComplexity[:0]
+	for _, class := range strings.Split(Cfg.MustValue("security", "PASSWORD_COMPLEXITY"), ",") {
+		if class = strings.ToLower(strings.TrimSpace(class)); len(class) > 0 {
+			PasswdComplexity = append(PasswdComplexity, class)
+		}
+	}
+	PasswdCheckCommon = Cfg.MustBool("security", "PASSWORD_CHECK_COMMON", true)
+	AdminAllowNets = parseNetworks("ADMIN_ALLOW_NETWORKS")
+	AdminDenyNets = parseNetworks("ADMIN_DENY_NETWORKS")
+	AuthAllowNets = parseNetworks("AUTH_ALLOW_NETWORKS")
+	AuthDenyNets = parseNetworks("AUTH_DENY_NETWORKS")
 
 	RunUser = Cfg.MustValue("", "RUN_USER")
 	curUser := os.Getenv("USER")
@@ -175,10 +277,44 @@ func NewConfigContext() {
 		log.Fatal("Fail to create repository root path(%s): %v", RepoRootPath, err)
 	}
 	ScriptType = Cfg.MustValue("repository", "SCRIPT_TYPE", "bash")
+	RepoMaxSize = int64(Cfg.MustInt("repository", "MAX_SIZE", 0))
+	UserMaxSize = int64(Cfg.MustInt("repository", "USER_MAX_SIZE", 0))
+	DisableGitHooks = Cfg.MustBool("repository", "DISABLE_GIT_HOOKS")
 
 	PictureService = Cfg.MustValueRange("picture", "SERVICE", "server",
 		[]string{"server"})
 	DisableGravatar = Cfg.MustBool("picture", "DISABLE_GRAVATAR")
+
+	AttachmentEnabled = Cfg.MustBool("release.attachment", "ENABLED", true)
+	AttachmentPath = Cfg.MustValue("release.attachment", "PATH", "data/attachments")
+	if !filepath.IsAbs(AttachmentPath) {
+		AttachmentPath = filepath.Join(workDir, AttachmentPath)
+	}
+	AttachmentMaxSize = int64(Cfg.MustInt("release.attachment", "MAX_SIZE", 32))
+	AttachmentMaxFiles = Cfg.MustInt("release.attachment", "MAX_FILES", 10)
+
+	IssueAttachmentEnabled = Cfg.MustBool("issue.attachment", "ENABLED", true)
+	IssueAttachmentAllowedTypes = make([]string, 0, 5)
+	for _, typ := range strings.Split(Cfg.MustValue("issue.attachment", "ALLOWED_TYPES", "image/jpeg|image/png|image/gif|application/pdf|application/zip|text/plain; charset=utf-8"), "|") {
+		if typ = strings.TrimSpace(typ); len(typ) > 0 {
+			IssueAttachmentAllowedTypes = append(IssueAttachmentAllowedTypes, typ)
+		}
+	}
+	IssueAttachmentMaxSize = int64(Cfg.MustInt("issue.attachment", "MAX_SIZE", 4))
+	IssueAttachmentMaxFiles = Cfg.MustInt("issue.attachment", "MAX_FILES", 5)
+
+	IssueDueReminderEnabled = Cfg.MustBool("issue", "ENABLE_DUE_REMINDER")
+	IssueDueReminderDays = Cfg.MustInt("issue", "DUE_REMINDER_DAYS", 1)
+
+	RepoIndexerEnabled = Cfg.MustBool("indexer", "REPO_INDEXER_ENABLED")
+	RepoIndexerPath = Cfg.MustValue("indexer", "REPO_INDEXER_PATH", "data/indexers/repos.bleve")
+	if !filepath.IsAbs(RepoIndexerPath) {
+		RepoIndexerPath = filepath.Join(workDir, RepoIndexerPath)
+	}
+	RepoIndexerMaxFileSize = int64(Cfg.MustInt("indexer", "MAX_FILE_SIZE", 512))
+
+	ApiRateLimit = Cfg.MustInt("api", "RATE_LIMIT", 5000)
+	ApiRateLimitAnonymous = Cfg.MustInt("api", "RATE_LIMIT_ANONYMOUS", 60)
 }
 
 var Service struct {
@@ -190,6 +326,9 @@ var Service struct {
 	ActiveCodeLives      int
 	ResetPwdCodeLives    int
 	LdapAuth             bool
+
+	EnableReverseProxyAuth         bool
+	EnableReverseProxyAutoRegister bool
 }
 
 func newService() {
@@ -198,6 +337,8 @@ func newService() {
 	Service.DisableRegistration = Cfg.MustBool("service", "DISABLE_REGISTRATION")
 	Service.RequireSignInView = Cfg.MustBool("service", "REQUIRE_SIGNIN_VIEW")
 	Service.EnableCacheAvatar = Cfg.MustBool("service", "ENABLE_CACHE_AVATAR")
+	Service.EnableReverseProxyAuth = Cfg.MustBool("service", "ENABLE_REVERSE_PROXY_AUTHENTICATION")
+	Service.EnableReverseProxyAutoRegister = Cfg.MustBool("service", "ENABLE_REVERSE_PROXY_AUTO_REGISTRATION")
 }
 
 var logLevels = map[string]string{
@@ -352,6 +493,52 @@ var (
 	OauthService *Oauther
 )
 
+// MarkupRenderer represents an external command that renders markup files to HTML.
+type MarkupRenderer struct {
+	Name           string
+	FileExtensions []string // Lower-cased with leading dot.
+	Command        string
+	IsInputFile    bool // Passes content as path of temporary file instead of stdin.
+}
+
+var MarkupRenderers []*MarkupRenderer
+
+func newMarkupService() {
+	MarkupRenderers = make([]*MarkupRenderer, 0, 3)
+	for _, sec := range Cfg.GetSectionList() {
+		if !strings.HasPrefix(sec, "markup.") || !Cfg.MustBool(sec, "ENABLED") {
+			continue
+		}
+
+		name := strings.TrimPrefix(sec, "markup.")
+		exts := make([]string, 0, 2)
+		for _, ext := range strings.Split(Cfg.MustValue(sec, "FILE_EXTENSIONS"), ",") {
+			if ext = strings.ToLower(strings.TrimSpace(ext)); len(ext) == 0 {
+				continue
+			} else if ext[0] != '.' {
+				ext = "." + ext
+			}
+			exts = append(exts, ext)
+		}
+		command := strings.TrimSpace(Cfg.MustValue(sec, "RENDER_COMMAND"))
+		if len(exts) == 0 || len(command) == 0 {
+			log.Warn("Markup Renderer(%s): no file extension or render command", name)
+			continue
+		} else if _, err := exec.LookPath(strings.Fields(command)[0]); err != nil {
+			log.Warn("Markup Renderer(%s): render command is not available: %v", name, err)
+			continue
+		}
+
+		MarkupRenderers = append(MarkupRenderers, &MarkupRenderer{
+			Name:           name,
+			FileExtensions: exts,
+			Command:        command,
+			IsInputFile:    Cfg.MustBool(sec, "IS_INPUT_FILE"),
+		})
+		log.Info("Markup Renderer(%s) Enabled", name)
+	}
+}
+
 func newMailService() {
 	// Check mailer setting.
 	if !Cfg.MustBool("mailer", "ENABLED") {
@@ -398,4 +585,5 @@ func NewServices() {
 	newMailService()
 	newRegisterMailService()
 	newNotifyMailService()
+	newMarkupService()
 }
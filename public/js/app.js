@@ -331,6 +331,16 @@ function initCore() {
     Gogits.initDropDown();
     Gogits.renderMarkdown();
     Gogits.renderCodeView();
+
+    // Search scope of navbar.
+    $('#nav-search-form .dropdown-menu a').on('click', function (e) {
+        e.preventDefault();
+        var $form = $('#nav-search-form');
+        var $repo = $form.find('input[name=repo]');
+        var all = $(this).text() == 'All Repositories';
+        $repo.prop('disabled', all);
+        $form.find('.dropdown-toggle').html($(this).text() + ' <span class="caret"></span>');
+    });
 }
 
 function initUserSetting() {
@@ -498,6 +508,86 @@ function initIssue() {
         });
     }());
 
+    // merge style of pull request
+    $('#pull-merge-style').on('change', function () {
+        var $option = $(this).find('option:selected');
+        $('#pull-merge-message').toggleClass('hidden', $option.val() == 'rebase')
+            .find('textarea').val($option.data('message'));
+    });
+
+    // load diffs of collapsed files
+    $('.diff-file-box').on('click', '.diff-load', function () {
+        var $btn = $(this).addClass('disabled');
+        $.get($btn.data('url'), function (html) {
+            $btn.closest('.diff-file-box').replaceWith(html);
+        }).fail(function () {
+            $btn.removeClass('disabled').text('Failed to load diff, try again');
+        });
+    });
+
+    // review comments on lines of pull request diff, and comments on lines of commit diff
+    (function () {
+        var $form = $('#review-new-form, #commit-line-comment-form');
+        if (!$form.length) {
+            return;
+        }
+        // Diffs of collapsed files are loaded later, so handler is delegated.
+        $(document).on('click', '.diff-file-box .code-diff .lines-num', function () {
+            var $tr = $(this).parent();
+            if (!$tr.data('old') && !$tr.data('new')) {
+                return;
+            }
+            var isOld = $tr.hasClass('del-code');
+            $form.find('input[name=path]').val($tr.closest('.diff-file-box').data('path'));
+            $form.find('input[name=line]').val(isOld ? $tr.data('old') : $tr.data('new'));
+            $form.find('input[name=side]').val(isOld ? 'old' : 'new');
+            var $row = $('<tr class="review-form-row"><td colspan="3"></td></tr>');
+            $('.review-form-row').remove();
+            $row.find('td').append($form.removeClass('hidden'));
+            $tr.after($row);
+            $form.find('textarea').focus();
+        });
+        $form.find('.review-cancel').on('click', function () {
+            $('body').append($form.addClass('hidden'));
+            $('.review-form-row').remove();
+        });
+    }());
+
+    // bulk edit of issues in list
+    (function () {
+        var $form = $('#issue-bulk-form');
+        if (!$form.length) {
+            return;
+        }
+        var $checks = $('.issue-check');
+
+        function update() {
+            var n = $checks.filter(':checked').length;
+            $form.find('.issue-bulk-count').text(n ? n + ' selected' : 'Select all');
+            $form.find('button.issue-bulk-action, .issue-bulk-toggle').prop('disabled', n == 0);
+        }
+
+        $form.find('.issue-check-all').on('change', function () {
+            $checks.prop('checked', this.checked);
+            update();
+        });
+        $checks.on('change', update);
+        $form.find('.issue-bulk-action').on('click', function (e) {
+            e.preventDefault();
+            var indexes = [];
+            $checks.filter(':checked').each(function () {
+                indexes.push($(this).val());
+            });
+            if (!indexes.length) {
+                return;
+            }
+            $form.find('input[name=issues]').val(indexes.join(','));
+            $form.find('input[name=action]').val($(this).data('action'));
+            $form.find('input[name=value]').val($(this).data('value') || 0);
+            $form.submit();
+        });
+    }());
+
     // issue edit mode
     (function () {
         $("#issue-edit-btn").on("click", function () {
@@ -510,6 +600,94 @@ function initIssue() {
         })
     }());
 
+    // comment edit and delete
+    (function () {
+        $('.issue-comment-edit').on("click", function (e) {
+            e.preventDefault();
+            var $cnt = $(this).parents('.issue-content');
+            $cnt.children('.markdown').toggleHide();
+            $cnt.children('.issue-comment-form').toggleShow();
+        });
+        $('.issue-comment-cancel').on("click", function () {
+            var $cnt = $(this).parents('.issue-content');
+            $cnt.children('.issue-comment-form').toggleHide();
+            $cnt.children('.markdown').toggleShow();
+        });
+        $('.issue-comment-del').on("submit", function () {
+            return confirm("Are you sure you want to delete this comment?");
+        });
+    }());
+
+    // attachments
+    (function () {
+        $('.attachment-drop').each(function () {
+            var $drop = $(this);
+            var $form = $drop.parents('form');
+            var $cnt = $form.find('textarea[name=content]').first();
+            var $files = $drop.find('.attachment-files');
+            var maxFiles = $drop.data('max-files');
+
+            function upload(file) {
+                if ($form.find('input[name=files]').length >= maxFiles) {
+                    $('<li class="text-danger"/>').text('Cannot attach more than ' + maxFiles + ' files.').appendTo($files);
+                    return;
+                }
+                var $item = $('<li/>').text(file.name + ' uploading...').appendTo($files);
+                var data = new FormData();
+                data.append('file', file);
+                $.ajax({
+                    url: $drop.data('url'),
+                    type: 'POST',
+                    data: data,
+                    processData: false,
+                    contentType: false,
+                    dataType: 'json',
+                    success: function (json) {
+                        if (!json.ok) {
+                            $item.addClass('text-danger').text(json.err);
+                            return;
+                        }
+                        $item.html('<i class="fa fa-paperclip"></i> ').append(document.createTextNode(json.name));
+                        $('<input type="hidden" name="files"/>').val(json.uuid).appendTo($form);
+                        var md = '[' + json.name.replace(/[\[\]]/g, '') + '](' + json.link + ')';
+                        if (json.is_image) {
+                            md = '!' + md;
+                        }
+                        $cnt.val($.trim($cnt.val() + '\n' + md)).trigger('keyup');
+                    },
+                    error: function () {
+                        $item.addClass('text-danger').text(file.name + ' cannot be uploaded.');
+                    }
+                });
+            }
+
+            function uploadAll(files) {
+                for (var i = 0; i < files.length; i++) {
+                    upload(files[i]);
+                }
+            }
+
+            $drop.on('dragenter dragover', function (e) {
+                e.preventDefault();
+                $drop.addClass('dragover');
+            }).on('dragleave', function () {
+                $drop.removeClass('dragover');
+            }).on('drop', function (e) {
+                e.preventDefault();
+                $drop.removeClass('dragover');
+                uploadAll(e.originalEvent.dataTransfer.files);
+            });
+            $drop.find('.attachment-select').on('click', function (e) {
+                e.preventDefault();
+                $drop.find('.attachment-input').trigger('click');
+            });
+            $drop.find('.attachment-input').on('change', function () {
+                uploadAll(this.files);
+                $(this).val('');
+            });
+        });
+    }());
+
     // issue ajax update
     (function () {
         var $cnt = $('#issue-edit-content');
@@ -553,17 +731,16 @@ function initIssue() {
     $('.assignee', '#issue').on('click', 'li', function () {
         var uid = $(this).data("uid");
         if (is_issue_bar) {
-            var assignee = $a.data("assigned");
-            if (uid != assignee) {
-                $.post($a.data("ajax"), {
-                    issue: $('#issue').data("id"),
-                    assigneeid: uid
-                }, function (json) {
-                    if (json.ok) {
-                        window.location.reload();
-                    }
-                })
-            }
+            // Clicking a user toggles the assignment, issue can have multiple assignees.
+            $.post($a.data("ajax"), {
+                issue: $('#issue').data("id"),
+                assigneeid: uid,
+                action: $(this).hasClass("checked") ? "detach" : "attach"
+            }, function (json) {
+                if (json.ok) {
+                    window.location.reload();
+                }
+            });
             return;
         }
         $('#assignee').val(uid);
@@ -620,67 +797,6 @@ function initIssue() {
     });
 
     // labels
-    var removeLabels = [];
-    $('#label-manage-btn').on("click", function () {
-        var $list = $('#label-list');
-        if ($list.hasClass("managing")) {
-            var ids = [];
-            $list.find('li').each(function (i, item) {
-                var id = $(item).data("id");
-                if (id > 0) {
-                    ids.push(id);
-                }
-            });
-            $.post($list.data("ajax"), {"ids": ids.join(","), "remove": removeLabels.join(",")}, function (json) {
-                if (json.ok) {
-                    window.location.reload();
-                }
-            })
-        } else {
-            $list.addClass("managing");
-            $list.find(".count").hide();
-            $list.find(".del").show();
-            $(this).text("Save Labels");
-            $list.on('click', 'li.label-item', function () {
-                var $this = $(this);
-                $this.after($('.label-change-li').detach().show());
-                $('#label-name-change-ipt').val($this.find('.name').text());
-                var color = $this.find('.color').data("color");
-                $('.label-change-color-picker').colorpicker("setValue", color);
-                $('#label-color-change-ipt,#label-color-change-ipt2').val(color);
-                $('#label-change-id-ipt').val($this.data("id"));
-                return false;
-            });
-        }
-    });
-    var colorRegex = new RegExp("^#([A-Fa-f0-9]{6}|[A-Fa-f0-9]{3})$");
-    $('#label-color-ipt2').on('keyup', function () {
-        var val = $(this).val();
-        if (val.length > 7) {
-            $(this).val(val.substr(0, 7));
-        }
-        if (colorRegex.test(val)) {
-            $('.label-color-picker').colorpicker("setValue", val);
-        }
-        return true;
-    });
-    $('#label-color-change-ipt2').on('keyup', function () {
-        var val = $(this).val();
-        console.log(val);
-        if (val.length > 7) {
-            $(this).val(val.substr(0, 7));
-        }
-        if (colorRegex.test(val)) {
-            $('.label-change-color-picker').colorpicker("setValue", val);
-        }
-        return true;
-    });
-    $("#label-list").on('click', '.del', function () {
-        var $p = $(this).parent();
-        removeLabels.push($p.data('id'));
-        $p.remove();
-        return false;
-    });
     $('.label-selected').each(function (i, item) {
         var $item = $(item);
         var color = $item.find('.color').data('color');
@@ -758,6 +874,138 @@ function initRepoSetting() {
     });
 }
 
+function initRepoGraphs() {
+    var $graphs = $('#repo-graphs');
+
+    function formatWeek(unix) {
+        var d = new Date(unix * 1000);
+        return d.getUTCFullYear() + '-' + (d.getUTCMonth() + 1) + '-' + d.getUTCDate();
+    }
+
+    // Draws vertical bars of given fields of every week side by side.
+    function drawWeeks($box, weeks, fields) {
+        var max = 1;
+        $.each(weeks, function (i, w) {
+            $.each(fields, function (j, f) {
+                max = Math.max(max, w[f]);
+            });
+        });
+        var html = '';
+        $.each(weeks, function (i, w) {
+            html += '<div class="graph-week" title="' + formatWeek(w.week);
+            $.each(fields, function (j, f) {
+                html += ' ' + f + ': ' + w[f];
+            });
+            html += '">';
+            $.each(fields, function (j, f) {
+                html += '<span class="graph-bar ' + f + '" style="height: ' + Math.ceil(w[f] * 100 / max) + 'px"></span>';
+            });
+            html += '</div>';
+        });
+        $box.html(html);
+    }
+
+    function drawContributors($box, contributors) {
+        var max = contributors.length ? contributors[0].commits : 1;
+        var html = '';
+        $.each(contributors, function (i, c) {
+            html += '<li class="list-group-item"><strong>' + $('<span>').text(c.name).html() + '</strong> ' +
+                c.commits + ' commits <span class="text-success">+' + c.additions + '</span> ' +
+                '<span class="text-danger">-' + c.deletions + '</span>' +
+                '<span class="graph-bar commits" style="width: ' + Math.ceil(c.commits * 100 / max) + '%"></span></li>';
+        });
+        $box.html(html);
+    }
+
+    function load(kind, draw) {
+        var $box = $graphs.find('[data-graph=' + kind + ']');
+        $.ajax({
+            url: $graphs.data('link') + '/graphs/' + kind + '/data',
+            dataType: 'json',
+            success: function (json, status, xhr) {
+                // Statistics are being computed, try again later.
+                if (xhr.status == 202) {
+                    $box.html('<p class="text-muted">Crunching the latest data, please wait...</p>');
+                    setTimeout(function () {
+                        load(kind, draw);
+                    }, 5000);
+                    return;
+                }
+                draw($box, json.data);
+            }
+        });
+    }
+
+    load('contributors', drawContributors);
+    load('commit-activity', function ($box, weeks) {
+        drawWeeks($box, weeks, ['commits']);
+    });
+    load('code-frequency', function ($box, weeks) {
+        drawWeeks($box, weeks, ['additions', 'deletions']);
+    });
+}
+
+function initProjectBoard() {
+    var $board = $('#project-board');
+    if (!$board.data('editable')) {
+        return;
+    }
+
+    var $dragging = null;
+    $board.on('dragstart', '.project-card', function (e) {
+        $dragging = $(this).addClass('dragging');
+        e.originalEvent.dataTransfer.effectAllowed = 'move';
+        e.originalEvent.dataTransfer.setData('text', $dragging.data('id'));
+    });
+    $board.on('dragend', '.project-card', function () {
+        $(this).removeClass('dragging');
+        $dragging = null;
+    });
+    $board.on('dragover', '.project-cards', function (e) {
+        if (!$dragging) {
+            return;
+        }
+        e.preventDefault();
+
+        // Put card before the first card whose middle is below the pointer.
+        var $cards = $(this), y = e.originalEvent.clientY, $before = null;
+        $cards.children('.project-card').not($dragging).each(function () {
+            var rect = this.getBoundingClientRect();
+            if (y < rect.top + rect.height / 2) {
+                $before = $(this);
+                return false;
+            }
+        });
+        if ($before) {
+            $before.before($dragging);
+        } else {
+            $cards.append($dragging);
+        }
+    });
+    $board.on('drop', '.project-cards', function (e) {
+        e.preventDefault();
+        if (!$dragging) {
+            return;
+        }
+
+        var $cards = $(this), ids = [];
+        $cards.children('.project-card').each(function () {
+            ids.push($(this).data('id'));
+        });
+        $.ajax({
+            url: $board.data('link') + '/cards/' + $dragging.data('id') + '/move',
+            type: 'POST',
+            data: {column: $cards.data('column'), cards: ids.join(',')},
+            success: function (json) {
+                if (!json.ok) {
+                    alert(json.err);
+                }
+                location.reload();
+            }
+        });
+    });
+}
+
 (function ($) {
     $(function () {
         initCore();
@@ -780,6 +1028,12 @@ function initRepoSetting() {
         if ($('#repo-setting-container').length) {
             initRepoSetting();
         }
+        if ($('#repo-graphs').length) {
+            initRepoGraphs();
+        }
+        if ($('#project-board').length) {
+            initProjectBoard();
+        }
     });
 })(jQuery);
 
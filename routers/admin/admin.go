@@ -6,6 +6,7 @@ package admin
 
 import (
 	"fmt"
+	"net/url"
 	"runtime"
 	"strings"
 	"time"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/gogits/gogs/models"
 	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
 	"github.com/gogits/gogs/modules/middleware"
 	"github.com/gogits/gogs/modules/setting"
 )
@@ -161,6 +163,76 @@ func Repositories(ctx *middleware.Context) {
 	ctx.HTML(200, "admin/repos")
 }
 
+func RepoSizeLimitPost(ctx *middleware.Context, params martini.Params) {
+	id, _ := base.StrTo(params["id"]).Int64()
+	repo, err := models.GetRepositoryById(id)
+	if err != nil {
+		if err == models.ErrRepoNotExist {
+			ctx.Handle(404, "admin.RepoSizeLimitPost(GetRepositoryById)", err)
+		} else {
+			ctx.Handle(500, "admin.RepoSizeLimitPost(GetRepositoryById)", err)
+		}
+		return
+	}
+
+	limit, err := base.StrTo(ctx.Query("size_limit")).Int64()
+	if err != nil || limit < -1 {
+		ctx.Flash.Error("Size limit must be -1, 0 or a positive number of megabytes.")
+		ctx.Redirect("/admin/repos")
+		return
+	}
+	repo.SizeLimit = limit
+	if err = models.UpdateRepository(repo); err != nil {
+		ctx.Handle(500, "admin.RepoSizeLimitPost(UpdateRepository)", err)
+		return
+	}
+	log.Trace("%s Repository size limit changed by admin(%s): %d -> %d", ctx.Req.RequestURI,
+		ctx.User.LowerName, repo.Id, limit)
+
+	ctx.Flash.Success("Repository size limit has been successfully updated.")
+	ctx.Redirect("/admin/repos")
+}
+
+const AUDIT_LOGS_PAGE_SIZE = 50
+
+func AuditLogs(ctx *middleware.Context) {
+	ctx.Data["Title"] = "Audit Log"
+	ctx.Data["PageIsAudit"] = true
+
+	opt := models.AuditLogOptions{
+		ActorName: strings.TrimSpace(ctx.Query("actor")),
+		Ip:        strings.TrimSpace(ctx.Query("ip")),
+		PageSize:  AUDIT_LOGS_PAGE_SIZE,
+	}
+	action, _ := base.StrTo(ctx.Query("action")).Int()
+	opt.Action = models.AuditAction(action)
+	opt.Page, _ = base.StrTo(ctx.Query("page")).Int()
+	if opt.Page <= 0 {
+		opt.Page = 1
+	}
+
+	logs, count, err := models.SearchAuditLogs(opt)
+	if err != nil {
+		ctx.Handle(500, "admin.AuditLogs(SearchAuditLogs)", err)
+		return
+	}
+
+	ctx.Data["Logs"] = logs
+	ctx.Data["Actions"] = models.AuditActions()
+	ctx.Data["Actor"] = opt.ActorName
+	ctx.Data["Ip"] = opt.Ip
+	ctx.Data["Action"] = action
+	link := fmt.Sprintf("/admin/audit?actor=%s&ip=%s&action=%d",
+		url.QueryEscape(opt.ActorName), url.QueryEscape(opt.Ip), action)
+	if opt.Page > 1 {
+		ctx.Data["PrevLink"] = fmt.Sprintf("%s&page=%d", link, opt.Page-1)
+	}
+	if int64(opt.Page*opt.PageSize) < count {
+		ctx.Data["NextLink"] = fmt.Sprintf("%s&page=%d", link, opt.Page+1)
+	}
+	ctx.HTML(200, "admin/audit")
+}
+
 func Auths(ctx *middleware.Context) {
 	ctx.Data["Title"] = "Auth Sources"
 	ctx.Data["PageIsAuths"] = true
@@ -188,6 +260,8 @@ func Config(ctx *middleware.Context) {
 	ctx.Data["StaticRootPath"] = setting.StaticRootPath
 	ctx.Data["LogRootPath"] = setting.LogRootPath
 	ctx.Data["ScriptType"] = setting.ScriptType
+	ctx.Data["RepoMaxSize"] = setting.RepoMaxSize
+	ctx.Data["UserMaxSize"] = setting.UserMaxSize
 
 	ctx.Data["Service"] = setting.Service
 
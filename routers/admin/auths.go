@@ -42,23 +42,32 @@ func NewAuthSourcePost(ctx *middleware.Context, form auth.AuthenticationForm) {
 	case models.LT_LDAP:
 		u = &models.LDAPConfig{
 			Ldapsource: ldap.Ldapsource{
-				Host:         form.Host,
-				Port:         form.Port,
-				UseSSL:       form.UseSSL,
-				BaseDN:       form.BaseDN,
-				Attributes:   form.Attributes,
-				Filter:       form.Filter,
-				MsAdSAFormat: form.MsAdSA,
-				Enabled:      true,
-				Name:         form.AuthName,
+				Host:              form.Host,
+				Port:              form.Port,
+				UseSSL:            form.UseSSL,
+				BindDN:            form.BindDN,
+				BindPassword:      form.BindPassword,
+				BaseDN:            form.BaseDN,
+				AttributeUsername: form.AttributeUsername,
+				AttributeName:     form.AttributeName,
+				Attributes:        form.Attributes,
+				Filter:            form.Filter,
+				MsAdSAFormat:      form.MsAdSA,
+				Enabled:           true,
+				Name:              form.AuthName,
 			},
 		}
 	case models.LT_SMTP:
 		u = &models.SMTPConfig{
-			Auth: form.SmtpAuth,
-			Host: form.SmtpHost,
-			Port: form.SmtpPort,
-			TLS:  form.Tls,
+			Auth:           form.SmtpAuth,
+			Host:           form.SmtpHost,
+			Port:           form.SmtpPort,
+			TLS:            form.Tls,
+			AllowedDomains: form.AllowedDomains,
+		}
+	case models.LT_PAM:
+		u = &models.PAMConfig{
+			ServiceName: form.PAMServiceName,
 		}
 	default:
 		ctx.Error(400)
@@ -118,25 +127,50 @@ func EditAuthSourcePost(ctx *middleware.Context, form auth.AuthenticationForm) {
 	var config core.Conversion
 	switch form.Type {
 	case models.LT_LDAP:
+		// Bind password is never sent back to browser, empty field means it is unchanged.
+		bindPassword := form.BindPassword
+		if len(bindPassword) == 0 {
+			source, err := models.GetLoginSourceById(form.Id)
+			if err != nil {
+				if err == models.ErrAuthenticationNotExist {
+					ctx.Handle(404, "admin.auths.EditAuthSourcePost(GetLoginSourceById)", err)
+				} else {
+					ctx.Handle(500, "admin.auths.EditAuthSourcePost(GetLoginSourceById)", err)
+				}
+				return
+			}
+			if cfg, ok := source.Cfg.(*models.LDAPConfig); ok {
+				bindPassword = cfg.BindPassword
+			}
+		}
 		config = &models.LDAPConfig{
 			Ldapsource: ldap.Ldapsource{
-				Host:         form.Host,
-				Port:         form.Port,
-				UseSSL:       form.UseSSL,
-				BaseDN:       form.BaseDN,
-				Attributes:   form.Attributes,
-				Filter:       form.Filter,
-				MsAdSAFormat: form.MsAdSA,
-				Enabled:      true,
-				Name:         form.AuthName,
+				Host:              form.Host,
+				Port:              form.Port,
+				UseSSL:            form.UseSSL,
+				BindDN:            form.BindDN,
+				BindPassword:      bindPassword,
+				BaseDN:            form.BaseDN,
+				AttributeUsername: form.AttributeUsername,
+				AttributeName:     form.AttributeName,
+				Attributes:        form.Attributes,
+				Filter:            form.Filter,
+				MsAdSAFormat:      form.MsAdSA,
+				Enabled:           true,
+				Name:              form.AuthName,
 			},
 		}
 	case models.LT_SMTP:
 		config = &models.SMTPConfig{
-			Auth: form.SmtpAuth,
-			Host: form.SmtpHost,
-			Port: form.SmtpPort,
-			TLS:  form.Tls,
+			Auth:           form.SmtpAuth,
+			Host:           form.SmtpHost,
+			Port:           form.SmtpPort,
+			TLS:            form.Tls,
+			AllowedDomains: form.AllowedDomains,
+		}
+	case models.LT_PAM:
+		config = &models.PAMConfig{
+			ServiceName: form.PAMServiceName,
 		}
 	default:
 		ctx.Error(400)
@@ -0,0 +1,78 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/auth"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+func prepareEmailDomains(ctx *middleware.Context) bool {
+	ctx.Data["Title"] = "E-mail Domains"
+	ctx.Data["PageIsDomains"] = true
+
+	domains, err := models.GetEmailDomains()
+	if err != nil {
+		ctx.Handle(500, "admin.prepareEmailDomains(GetEmailDomains)", err)
+		return false
+	}
+	ctx.Data["Domains"] = domains
+	return true
+}
+
+func EmailDomains(ctx *middleware.Context) {
+	if !prepareEmailDomains(ctx) {
+		return
+	}
+	ctx.HTML(200, "admin/domains")
+}
+
+func EmailDomainsPost(ctx *middleware.Context, form auth.EmailDomainForm) {
+	if !prepareEmailDomains(ctx) {
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.HTML(200, "admin/domains")
+		return
+	}
+
+	if err := models.AddEmailDomain(form.Domain, form.IsAllowed); err != nil {
+		switch err {
+		case models.ErrEmailDomainAlreadyExist:
+			ctx.Data["Err_Domain"] = true
+			ctx.RenderWithErr("Rule of this e-mail domain already exists.", "admin/domains", &form)
+		case models.ErrEmailDomainInvalid:
+			ctx.Data["Err_Domain"] = true
+			ctx.RenderWithErr("E-mail domain is not valid.", "admin/domains", &form)
+		default:
+			ctx.Handle(500, "admin.EmailDomainsPost(AddEmailDomain)", err)
+		}
+		return
+	}
+	log.Trace("%s E-mail domain rule added by admin(%s): %s", ctx.Req.RequestURI,
+		ctx.User.LowerName, form.Domain)
+
+	ctx.Flash.Success("New e-mail domain rule has been added.")
+	ctx.Redirect("/admin/domains")
+}
+
+func DeleteEmailDomain(ctx *middleware.Context, params martini.Params) {
+	id, _ := base.StrTo(params["id"]).Int64()
+	if err := models.DeleteEmailDomain(id); err != nil {
+		ctx.Handle(500, "admin.DeleteEmailDomain", err)
+		return
+	}
+	log.Trace("%s E-mail domain rule deleted by admin(%s): %d", ctx.Req.RequestURI,
+		ctx.User.LowerName, id)
+
+	ctx.Flash.Success("E-mail domain rule has been deleted.")
+	ctx.Redirect("/admin/domains")
+}
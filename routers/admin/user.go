@@ -83,6 +83,143 @@ func NewUserPost(ctx *middleware.Context, form auth.RegisterForm) {
 	ctx.Redirect("/admin/users")
 }
 
+func NewBot(ctx *middleware.Context) {
+	ctx.Data["Title"] = "New Bot Account"
+	ctx.Data["PageIsUsers"] = true
+	ctx.HTML(200, "admin/users/new_bot")
+}
+
+func NewBotPost(ctx *middleware.Context, form auth.NewBotForm) {
+	ctx.Data["Title"] = "New Bot Account"
+	ctx.Data["PageIsUsers"] = true
+
+	if ctx.HasError() {
+		ctx.HTML(200, "admin/users/new_bot")
+		return
+	}
+
+	u, err := models.RegisterUser(&models.User{
+		Name:      form.UserName,
+		Email:     form.Email,
+		Type:      models.UT_BOT,
+		IsActive:  true,
+		LoginType: models.LT_PLAIN,
+	})
+	if err != nil {
+		switch err {
+		case models.ErrUserAlreadyExist:
+			ctx.RenderWithErr("Username has been already taken", "admin/users/new_bot", &form)
+		case models.ErrEmailAlreadyUsed:
+			ctx.RenderWithErr("E-mail address has been already used", "admin/users/new_bot", &form)
+		case models.ErrUserNameIllegal:
+			ctx.RenderWithErr(models.ErrRepoNameIllegal.Error(), "admin/users/new_bot", &form)
+		default:
+			ctx.Handle(500, "admin.user.NewBotPost(RegisterUser)", err)
+		}
+		return
+	}
+
+	log.Trace("%s Bot created by admin(%s): %s", ctx.Req.RequestURI,
+		ctx.User.LowerName, u.LowerName)
+
+	ctx.Redirect(fmt.Sprintf("/admin/users/%d", u.Id))
+}
+
+// getBot returns the bot account by ID in URL, it returns nil
+// when response has been written.
+func getBot(ctx *middleware.Context, params martini.Params) *models.User {
+	uid, err := base.StrTo(params["userid"]).Int64()
+	if err != nil {
+		ctx.Handle(404, "admin.user.getBot", err)
+		return nil
+	}
+
+	u, err := models.GetUserById(uid)
+	if err != nil {
+		if err == models.ErrUserNotExist {
+			ctx.Handle(404, "admin.user.getBot(GetUserById)", err)
+		} else {
+			ctx.Handle(500, "admin.user.getBot(GetUserById)", err)
+		}
+		return nil
+	} else if !u.IsBot() {
+		ctx.Handle(404, "admin.user.getBot", nil)
+		return nil
+	}
+	return u
+}
+
+func BotTokenPost(ctx *middleware.Context, params martini.Params) {
+	u := getBot(ctx, params)
+	if u == nil {
+		return
+	}
+
+	ctx.Req.ParseForm()
+	scope, err := models.ParseScopes(strings.Join(ctx.Req.Form["scopes"], ","))
+	if err != nil {
+		ctx.Flash.Error("Unknown scope of access token.")
+		ctx.Redirect(fmt.Sprintf("/admin/users/%d", u.Id))
+		return
+	}
+
+	name := strings.TrimSpace(ctx.Query("name"))
+	if len(name) == 0 {
+		name = "bot"
+	}
+	t := &models.AccessToken{
+		Uid:   u.Id,
+		Name:  name,
+		Scope: scope,
+	}
+	if err = models.NewAccessToken(t); err != nil {
+		ctx.Handle(500, "admin.user.BotTokenPost(NewAccessToken)", err)
+		return
+	}
+	log.Trace("%s Bot access token generated by admin(%s): %s", ctx.Req.RequestURI,
+		ctx.User.LowerName, u.LowerName)
+
+	ctx.ShowSuccess("New access token has been generated, make sure to copy it now as you won't be able to see it again: " + t.Sha1)
+	EditUser(ctx, params)
+}
+
+func BotSSHKeyPost(ctx *middleware.Context, params martini.Params) {
+	u := getBot(ctx, params)
+	if u == nil {
+		return
+	}
+
+	content := strings.TrimSpace(ctx.Query("content"))
+	if len(content) < 100 || !strings.HasPrefix(content, "ssh-rsa") {
+		ctx.Flash.Error("SSH key content is not valid.")
+		ctx.Redirect(fmt.Sprintf("/admin/users/%d", u.Id))
+		return
+	}
+
+	k := &models.PublicKey{
+		OwnerId: u.Id,
+		Name:    strings.TrimSpace(ctx.Query("name")),
+		Content: content,
+	}
+	if len(k.Name) == 0 {
+		k.Name = "bot"
+	}
+	if err := models.AddPublicKey(k); err != nil {
+		if err == models.ErrKeyAlreadyExist {
+			ctx.Flash.Error("SSH key name or content has been used.")
+			ctx.Redirect(fmt.Sprintf("/admin/users/%d", u.Id))
+			return
+		}
+		ctx.Handle(500, "admin.user.BotSSHKeyPost(AddPublicKey)", err)
+		return
+	}
+	log.Trace("%s Bot SSH key added by admin(%s): %s", ctx.Req.RequestURI,
+		ctx.User.LowerName, u.LowerName)
+
+	ctx.Flash.Success("New SSH key has been added.")
+	ctx.Redirect(fmt.Sprintf("/admin/users/%d", u.Id))
+}
+
 func EditUser(ctx *middleware.Context, params martini.Params) {
 	ctx.Data["Title"] = "Edit Account"
 	ctx.Data["PageIsUsers"] = true
@@ -100,6 +237,36 @@ func EditUser(ctx *middleware.Context, params martini.Params) {
 	}
 
 	ctx.Data["User"] = u
+	ctx.Data["LoginFailures"], err = models.CountUserLoginAttempts(u.Name)
+	if err != nil {
+		ctx.Handle(500, "admin.user.EditUser(CountUserLoginAttempts)", err)
+		return
+	}
+	ctx.Data["IsLoginLocked"], err = models.IsLoginLocked(u.Name, "")
+	if err != nil {
+		ctx.Handle(500, "admin.user.EditUser(IsLoginLocked)", err)
+		return
+	}
+	ctx.Data["DiskUsage"], err = models.GetUserDiskUsage(u.Id)
+	if err != nil {
+		ctx.Handle(500, "admin.user.EditUser(GetUserDiskUsage)", err)
+		return
+	}
+
+	if u.IsBot() {
+		ctx.Data["AccessScopes"] = models.AccessScopes
+		ctx.Data["BotTokens"], err = models.ListAccessTokens(u.Id)
+		if err != nil {
+			ctx.Handle(500, "admin.user.EditUser(ListAccessTokens)", err)
+			return
+		}
+		ctx.Data["BotKeys"], err = models.ListPublicKey(u.Id)
+		if err != nil {
+			ctx.Handle(500, "admin.user.EditUser(ListPublicKey)", err)
+			return
+		}
+	}
+
 	auths, err := models.GetAuths()
 	if err != nil {
 		ctx.Handle(500, "admin.user.NewUser", err)
@@ -130,12 +297,20 @@ func EditUserPost(ctx *middleware.Context, params martini.Params, form auth.Admi
 	u.Location = form.Location
 	u.Avatar = base.EncodeMd5(form.Avatar)
 	u.AvatarEmail = form.Avatar
+	isPermChanged := u.IsActive != form.Active || u.IsAdmin != form.Admin
 	u.IsActive = form.Active
 	u.IsAdmin = form.Admin
+	if form.DiskQuota >= -1 {
+		u.DiskQuota = form.DiskQuota
+	}
 	if err := models.UpdateUser(u); err != nil {
 		ctx.Handle(500, "admin.user.EditUser", err)
 		return
 	}
+	if isPermChanged {
+		models.RecordAudit(ctx.User.Id, ctx.User.Name, models.AUDIT_CHANGE_USER_PERMISSION, ctx.RemoteAddr(),
+			fmt.Sprintf("%s: active=%v, admin=%v", u.Name, u.IsActive, u.IsAdmin))
+	}
 	log.Trace("%s User profile updated by admin(%s): %s", ctx.Req.RequestURI,
 		ctx.User.LowerName, ctx.User.LowerName)
 
@@ -144,6 +319,30 @@ func EditUserPost(ctx *middleware.Context, params martini.Params, form auth.Admi
 	ctx.Redirect("/admin/users/" + params["userid"])
 }
 
+func UnlockUser(ctx *middleware.Context, params martini.Params) {
+	uid, err := base.StrTo(params["userid"]).Int64()
+	if err != nil {
+		ctx.Handle(404, "admin.user.UnlockUser", err)
+		return
+	}
+
+	u, err := models.GetUserById(uid)
+	if err != nil {
+		ctx.Handle(500, "admin.user.UnlockUser(GetUserById)", err)
+		return
+	}
+
+	if err = models.UnlockUserLogin(u.Name); err != nil {
+		ctx.Handle(500, "admin.user.UnlockUser(UnlockUserLogin)", err)
+		return
+	}
+	log.Trace("%s User unlocked by admin(%s): %s", ctx.Req.RequestURI,
+		ctx.User.LowerName, u.LowerName)
+
+	ctx.Flash.Success("Account has been unlocked.")
+	ctx.Redirect("/admin/users/" + params["userid"])
+}
+
 func DeleteUser(ctx *middleware.Context, params martini.Params) {
 	ctx.Data["Title"] = "Delete Account"
 	ctx.Data["PageIsUsers"] = true
@@ -0,0 +1,173 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"fmt"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/auth"
+	"github.com/gogits/gogs/modules/auth/apiv1"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+// AdminCreateUser creates an active user with local password.
+func AdminCreateUser(ctx *middleware.Context, form apiv1.CreateUserForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	if err := auth.CheckPasswdPolicy(form.Password); len(err) > 0 {
+		ctx.JSON(422, &base.ApiJsonErr{auth.PasswdPolicyErrorMsg("password", err), DOC_URL})
+		return
+	}
+
+	u, err := models.RegisterUser(&models.User{
+		Name:      form.UserName,
+		FullName:  form.FullName,
+		Email:     form.Email,
+		Passwd:    form.Password,
+		IsActive:  true,
+		LoginType: models.LT_PLAIN,
+	})
+	if err != nil {
+		switch err {
+		case models.ErrUserAlreadyExist:
+			ctx.JSON(422, &base.ApiJsonErr{"username has already been taken", DOC_URL})
+		case models.ErrEmailAlreadyUsed:
+			ctx.JSON(422, &base.ApiJsonErr{"email has already been used", DOC_URL})
+		case models.ErrUserNameIllegal:
+			ctx.JSON(422, &base.ApiJsonErr{"username is not allowed", DOC_URL})
+		default:
+			log.Error("v1.AdminCreateUser(RegisterUser): %v", err)
+			ctx.JSON(500, nil)
+		}
+		return
+	}
+	log.Trace("%s User created by admin(%s): %s", ctx.Req.RequestURI, ctx.User.LowerName, u.LowerName)
+	ctx.JSON(201, toUserProfile(ctx, u))
+}
+
+// AdminDeleteUser deletes user who does not own any repository.
+func AdminDeleteUser(ctx *middleware.Context, params martini.Params) {
+	u := getApiUser(ctx, params)
+	if u == nil {
+		return
+	} else if u.Id == ctx.User.Id {
+		ctx.JSON(422, &base.ApiJsonErr{"cannot delete yourself", DOC_URL})
+		return
+	}
+
+	if err := models.DeleteUser(u); err != nil {
+		if err == models.ErrUserOwnRepos {
+			ctx.JSON(422, &base.ApiJsonErr{"user still owns repositories, they have to be deleted or transferred first", DOC_URL})
+			return
+		}
+		log.Error("v1.AdminDeleteUser(DeleteUser): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	log.Trace("%s User deleted by admin(%s): %s", ctx.Req.RequestURI, ctx.User.LowerName, u.LowerName)
+	ctx.Res.WriteHeader(204)
+}
+
+func suspendUser(ctx *middleware.Context, params martini.Params, suspended bool) {
+	u := getApiUser(ctx, params)
+	if u == nil {
+		return
+	} else if u.Id == ctx.User.Id {
+		ctx.JSON(422, &base.ApiJsonErr{"cannot suspend yourself", DOC_URL})
+		return
+	}
+
+	if u.IsSuspended != suspended {
+		if err := models.SuspendUser(u, suspended); err != nil {
+			log.Error("v1.suspendUser(SuspendUser): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+		models.RecordAudit(ctx.User.Id, ctx.User.Name, models.AUDIT_CHANGE_USER_PERMISSION, ctx.RemoteAddr(),
+			fmt.Sprintf("%s: suspended=%v", u.Name, suspended))
+		log.Trace("%s User suspension changed by admin(%s): %s -> %v", ctx.Req.RequestURI,
+			ctx.User.LowerName, u.LowerName, suspended)
+	}
+	ctx.Res.WriteHeader(204)
+}
+
+// AdminSuspendUser suspends user, who is no longer able to sign in
+// or access anything by password, access token or SSH key.
+func AdminSuspendUser(ctx *middleware.Context, params martini.Params) {
+	suspendUser(ctx, params, true)
+}
+
+func AdminUnsuspendUser(ctx *middleware.Context, params martini.Params) {
+	suspendUser(ctx, params, false)
+}
+
+// AdminCreateRepo creates a repository on behalf of user.
+func AdminCreateRepo(ctx *middleware.Context, params martini.Params, form apiv1.CreateRepoForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	u := getApiUser(ctx, params)
+	if u == nil {
+		return
+	}
+	createRepo(ctx, u, form)
+}
+
+func AdminListUserKeys(ctx *middleware.Context, params martini.Params) {
+	u := getApiUser(ctx, params)
+	if u == nil {
+		return
+	}
+	listPublicKeys(ctx, u)
+}
+
+func AdminAddUserKey(ctx *middleware.Context, params martini.Params, form apiv1.AddPublicKeyForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	u := getApiUser(ctx, params)
+	if u == nil {
+		return
+	}
+	addPublicKey(ctx, u, form)
+}
+
+func AdminDeleteUserKey(ctx *middleware.Context, params martini.Params) {
+	u := getApiUser(ctx, params)
+	if u == nil {
+		return
+	}
+	deletePublicKey(ctx, params, u)
+}
+
+// GetStatistics returns numbers of objects in this instance.
+func GetStatistics(ctx *middleware.Context) {
+	stats := models.GetStatistic()
+	ctx.JSON(200, map[string]int64{
+		"users":         stats.Counter.User,
+		"public_keys":   stats.Counter.PublicKey,
+		"repos":         stats.Counter.Repo,
+		"watches":       stats.Counter.Watch,
+		"actions":       stats.Counter.Action,
+		"accesses":      stats.Counter.Access,
+		"issues":        stats.Counter.Issue,
+		"comments":      stats.Counter.Comment,
+		"mirrors":       stats.Counter.Mirror,
+		"oauths":        stats.Counter.Oauth,
+		"releases":      stats.Counter.Release,
+		"login_sources": stats.Counter.LoginSource,
+		"webhooks":      stats.Counter.Webhook,
+		"milestones":    stats.Counter.Milestone,
+	})
+}
@@ -0,0 +1,117 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"github.com/go-martini/martini"
+	"github.com/gogits/git"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+type branchProtection struct {
+	BlockForcePush     bool `json:"block_force_push"`
+	BlockDeletion      bool `json:"block_deletion"`
+	RequirePullRequest bool `json:"require_pull_request"`
+	EnableWhitelist    bool `json:"enable_whitelist"`
+	RequiredApprovals  int  `json:"required_approvals"`
+	DismissStale       bool `json:"dismiss_stale_approvals"`
+}
+
+type branch struct {
+	Name       string            `json:"name"`
+	Commit     *repoCommit       `json:"commit"`
+	Protected  bool              `json:"protected"`
+	Protection *branchProtection `json:"protection,omitempty"`
+}
+
+// ListBranches lists branches of repository, default branch first
+// and the others by time of last commit.
+func ListBranches(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+	gitRepo, err := git.OpenRepository(models.RepoPath(repo.Owner.Name, repo.Name))
+	if err != nil {
+		log.Error("v1.ListBranches(OpenRepository): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	defaultBranch, branches, err := models.GetBranches(repo, gitRepo)
+	if err != nil {
+		log.Error("v1.ListBranches(GetBranches): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	if defaultBranch != nil {
+		branches = append([]*models.Branch{defaultBranch}, branches...)
+	}
+
+	start, end := paginate(ctx, len(branches), 30)
+	results := make([]*branch, 0, end-start)
+	for _, br := range branches[start:end] {
+		results = append(results, &branch{
+			Name:      br.Name,
+			Commit:    toRepoCommit(repo, br.Commit),
+			Protected: br.IsProtected,
+		})
+	}
+	ctx.JSON(200, results)
+}
+
+// GetBranch returns a branch with its protection rules if it is protected.
+func GetBranch(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+	repoPath := models.RepoPath(repo.Owner.Name, repo.Name)
+	name := params["_1"]
+	ref, err := models.GetGitRef(repoPath, "refs/heads/"+name)
+	if err != nil {
+		if err == models.ErrRefNotExist {
+			ctx.JSON(404, &base.ApiJsonErr{"branch not found", DOC_URL})
+		} else {
+			log.Error("v1.GetBranch(GetGitRef): %v", err)
+			ctx.JSON(500, nil)
+		}
+		return
+	}
+	gitRepo, err := git.OpenRepository(repoPath)
+	if err != nil {
+		log.Error("v1.GetBranch(OpenRepository): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	commit, err := gitRepo.GetCommit(ref.ObjectId)
+	if err != nil {
+		log.Error("v1.GetBranch(GetCommit): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+
+	result := &branch{Name: name, Commit: toRepoCommit(repo, commit)}
+	pb, err := models.GetProtectedBranchByName(repo.Id, name)
+	if err == nil {
+		result.Protected = true
+		result.Protection = &branchProtection{
+			BlockForcePush:     pb.BlockForcePush,
+			BlockDeletion:      pb.BlockDeletion,
+			RequirePullRequest: pb.RequirePullRequest,
+			EnableWhitelist:    pb.EnableWhitelist,
+			RequiredApprovals:  pb.RequiredApprovals,
+			DismissStale:       pb.DismissStale,
+		}
+	} else if err != models.ErrProtectedBranchNotExist {
+		log.Error("v1.GetBranch(GetProtectedBranchByName): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	ctx.JSON(200, result)
+}
@@ -0,0 +1,127 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/auth/apiv1"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+type collaborator struct {
+	UserName   string `json:"username"`
+	Permission string `json:"permission"`
+}
+
+func ListCollaborators(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_ADMIN)
+	if repo == nil {
+		return
+	}
+
+	collaborators, err := models.GetRepoCollaborators(repo.Owner.Name + "/" + repo.Name)
+	if err != nil {
+		log.Error("v1.ListCollaborators(GetRepoCollaborators): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+
+	results := make([]*collaborator, 0, len(collaborators))
+	for _, c := range collaborators {
+		if c.Id == repo.OwnerId {
+			continue
+		}
+		results = append(results, &collaborator{c.Name, strings.ToLower(c.ModeName())})
+	}
+	start, end := paginate(ctx, len(results), 30)
+	ctx.JSON(200, results[start:end])
+}
+
+// AddCollaborator adds collaborator or changes access of existing one.
+func AddCollaborator(ctx *middleware.Context, params martini.Params, form apiv1.AddCollaboratorForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	repo := getApiRepo(ctx, params, models.AU_ADMIN)
+	if repo == nil {
+		return
+	}
+
+	mode := models.AU_WRITABLE
+	if len(form.Permission) > 0 {
+		if mode = models.ParseAccessMode(form.Permission); mode == 0 {
+			ctx.JSON(422, &base.ApiJsonErr{"permission must be one of read, write and admin", DOC_URL})
+			return
+		}
+	}
+
+	u, err := models.GetUserByName(params["collaborator"])
+	if err != nil {
+		if err == models.ErrUserNotExist {
+			ctx.JSON(404, &base.ApiJsonErr{"user not found", DOC_URL})
+		} else {
+			ctx.JSON(500, nil)
+		}
+		return
+	} else if u.Id == repo.OwnerId {
+		ctx.JSON(422, &base.ApiJsonErr{"owner of repository cannot be a collaborator", DOC_URL})
+		return
+	}
+
+	repoLink := repo.Owner.Name + "/" + repo.Name
+	has, err := models.HasAccess(u.Name, repoLink, models.AU_READABLE)
+	if err != nil {
+		ctx.JSON(500, nil)
+		return
+	}
+	if has {
+		err = models.ChangeAccessMode(u.Name, repoLink, mode)
+	} else {
+		err = models.AddAccess(&models.Access{UserName: u.Name, RepoName: repoLink, Mode: mode})
+	}
+	if err != nil {
+		log.Error("v1.AddCollaborator: %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+
+	action := models.AUDIT_ADD_COLLABORATOR
+	if has {
+		action = models.AUDIT_CHANGE_COLLABORATOR_ACCESS
+	}
+	models.RecordAudit(ctx.User.Id, ctx.User.Name, action, ctx.RemoteAddr(),
+		fmt.Sprintf("%s to %s with %s access", u.LowerName, strings.ToLower(repoLink), models.AccessModeName(mode)))
+	ctx.Res.WriteHeader(204)
+}
+
+func RemoveCollaborator(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_ADMIN)
+	if repo == nil {
+		return
+	}
+
+	name := strings.ToLower(params["collaborator"])
+	repoLink := strings.ToLower(repo.Owner.Name + "/" + repo.Name)
+	if name == repo.Owner.LowerName {
+		ctx.JSON(422, &base.ApiJsonErr{"owner of repository cannot be removed", DOC_URL})
+		return
+	}
+	if err := models.DeleteAccess(&models.Access{UserName: name, RepoName: repoLink}); err != nil {
+		log.Error("v1.RemoveCollaborator(DeleteAccess): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	models.RecordAudit(ctx.User.Id, ctx.User.Name, models.AUDIT_REMOVE_COLLABORATOR, ctx.RemoteAddr(),
+		fmt.Sprintf("%s from %s", name, repoLink))
+	ctx.Res.WriteHeader(204)
+}
@@ -0,0 +1,309 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"strings"
+	"time"
+
+	"github.com/go-martini/martini"
+	"github.com/gogits/git"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+	"github.com/gogits/gogs/modules/setting"
+)
+
+var diffFileStatuses = map[int]string{
+	models.DIFF_FILE_ADD:    "added",
+	models.DIFF_FILE_CHANGE: "modified",
+	models.DIFF_FILE_DEL:    "removed",
+}
+
+type commitUser struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+}
+
+type commitFile struct {
+	Filename  string `json:"filename"`
+	Status    string `json:"status"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Binary    bool   `json:"binary"`
+	Patch     string `json:"patch,omitempty"`
+}
+
+type commitStats struct {
+	Additions int `json:"additions"`
+	Deletions int `json:"deletions"`
+	Total     int `json:"total"`
+}
+
+type repoCommit struct {
+	Sha       string        `json:"sha"`
+	Message   string        `json:"message"`
+	Author    *commitUser   `json:"author"`
+	Committer *commitUser   `json:"committer"`
+	Parents   []string      `json:"parents"`
+	HtmlUrl   string        `json:"html_url"`
+	Stats     *commitStats  `json:"stats,omitempty"`
+	Files     []*commitFile `json:"files,omitempty"`
+}
+
+func toRepoCommit(repo *models.Repository, c *git.Commit) *repoCommit {
+	sha := c.Id.String()
+	parents := make([]string, 0, c.ParentCount())
+	for i := 0; i < c.ParentCount(); i++ {
+		if id, err := c.ParentId(i); err == nil {
+			parents = append(parents, id.String())
+		}
+	}
+	return &repoCommit{
+		Sha:       sha,
+		Message:   c.Message(),
+		Author:    &commitUser{c.Author.Name, c.Author.Email, c.Author.When},
+		Committer: &commitUser{c.Committer.Name, c.Committer.Email, c.Committer.When},
+		Parents:   parents,
+		HtmlUrl:   setting.AppUrl + repo.Owner.Name + "/" + repo.Name + "/commit/" + sha,
+	}
+}
+
+// toCommitFiles returns changed files of diff and its stats, patch is only given
+// for files whose content is loaded in diff.
+func toCommitFiles(diff *models.Diff) ([]*commitFile, *commitStats) {
+	files := make([]*commitFile, len(diff.Files))
+	for i, f := range diff.Files {
+		files[i] = &commitFile{
+			Filename:  f.Name,
+			Status:    diffFileStatuses[f.Type],
+			Additions: f.Addition,
+			Deletions: f.Deletion,
+			Binary:    f.IsBin,
+		}
+		if f.IsBin || !diff.IsFileShown(f) {
+			continue
+		}
+		lines := make([]string, 0, f.Addition+f.Deletion)
+		for _, section := range f.Sections {
+			for _, line := range section.Lines {
+				lines = append(lines, line.Content)
+			}
+		}
+		files[i].Patch = strings.Join(lines, "\n")
+	}
+	return files, &commitStats{diff.TotalAddition, diff.TotalDeletion, diff.TotalAddition + diff.TotalDeletion}
+}
+
+// splitPatchFormat returns revision without suffix of raw patch format, and the format
+// if revision has such suffix, e.g. "master.patch" asks for master in mail format.
+func splitPatchFormat(rev string) (string, string) {
+	for _, format := range []string{models.PATCH_DIFF, models.PATCH_MAIL} {
+		if strings.HasSuffix(rev, "."+format) {
+			return strings.TrimSuffix(rev, "."+format), format
+		}
+	}
+	return rev, ""
+}
+
+// renderPatch streams changes between two commits in given raw patch format.
+func renderPatch(ctx *middleware.Context, funcName, repoPath, beforeCommitId, afterCommitId, format string) {
+	ctx.Res.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	ctx.Res.WriteHeader(200)
+	if err := models.WritePatch(ctx.Res, repoPath, beforeCommitId, afterCommitId, format); err != nil {
+		log.Error("v1.%s(WritePatch): %v", funcName, err)
+	}
+}
+
+// getApiCommit returns commit that given branch, tag or commit ID points to,
+// otherwise it responds with error and returns nil.
+func getApiCommit(ctx *middleware.Context, repoPath, rev string) *git.Commit {
+	commitId, err := models.ResolveCommitId(repoPath, rev)
+	if err != nil {
+		ctx.JSON(404, &base.ApiJsonErr{"commit not found", DOC_URL})
+		return nil
+	}
+	gitRepo, err := git.OpenRepository(repoPath)
+	if err != nil {
+		log.Error("v1.getApiCommit(OpenRepository): %v", err)
+		ctx.JSON(500, nil)
+		return nil
+	}
+	commit, err := gitRepo.GetCommit(commitId)
+	if err != nil {
+		log.Error("v1.getApiCommit(GetCommit): %v", err)
+		ctx.JSON(500, nil)
+		return nil
+	}
+	return commit
+}
+
+// getApiDiff returns first page of diff between two commits, which has stats of all
+// changed files but content of files on first page only.
+func getApiDiff(ctx *middleware.Context, funcName, repoPath, beforeCommitId, afterCommitId string) *models.Diff {
+	diff, err := models.GetDiffRangePage(repoPath, beforeCommitId, afterCommitId, 1)
+	if err != nil {
+		log.Error("v1.%s(GetDiffRangePage): %v", funcName, err)
+		ctx.JSON(500, nil)
+		return nil
+	}
+	return diff
+}
+
+// ListCommits lists commits of branch, tag or commit given by query parameter sha newest first,
+// which is default branch by default, and only those that change query parameter path if given.
+func ListCommits(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	} else if repo.IsBare {
+		ctx.JSON(409, &base.ApiJsonErr{"repository is empty", DOC_URL})
+		return
+	}
+
+	rev := ctx.Query("sha")
+	if len(rev) == 0 {
+		rev = repo.DefaultBranch
+	}
+	repoPath := models.RepoPath(repo.Owner.Name, repo.Name)
+	commitId, err := models.ResolveCommitId(repoPath, rev)
+	if err != nil {
+		ctx.JSON(404, &base.ApiJsonErr{"commit not found", DOC_URL})
+		return
+	}
+
+	page, limit := getListPage(ctx, 30)
+	commits, total, err := models.GetCommitsPage(repoPath, commitId, ctx.Query("path"), page, limit)
+	if err != nil {
+		log.Error("v1.ListCommits(GetCommitsPage): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+
+	results := make([]*repoCommit, len(commits))
+	for i := range commits {
+		results[i] = toRepoCommit(repo, commits[i])
+	}
+	setPageHeaders(ctx, page, limit, total)
+	ctx.JSON(200, results)
+}
+
+// GetCommit returns commit with its changed files against first parent,
+// or the changes as raw patch when sha ends with ".diff" or ".patch".
+func GetCommit(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+	rev, format := splitPatchFormat(params["sha"])
+	repoPath := models.RepoPath(repo.Owner.Name, repo.Name)
+	commit := getApiCommit(ctx, repoPath, rev)
+	if commit == nil {
+		return
+	}
+
+	parentId := models.EMPTY_TREE_ID
+	if commit.ParentCount() > 0 {
+		id, _ := commit.ParentId(0)
+		parentId = id.String()
+	}
+	if len(format) > 0 {
+		renderPatch(ctx, "GetCommit", repoPath, parentId, commit.Id.String(), format)
+		return
+	}
+
+	diff := getApiDiff(ctx, "GetCommit", repoPath, parentId, commit.Id.String())
+	if diff == nil {
+		return
+	}
+	result := toRepoCommit(repo, commit)
+	result.Files, result.Stats = toCommitFiles(diff)
+	ctx.JSON(200, result)
+}
+
+// CompareCommits compares two branches, tags or commits given as "base...head", i.e. commits
+// and changes of head since it diverged from base. Changes are given as raw patch when
+// comparison ends with ".diff" or ".patch".
+func CompareCommits(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+	basehead, format := splitPatchFormat(params["_1"])
+	infos := strings.SplitN(basehead, "...", 2)
+	if len(infos) != 2 {
+		ctx.JSON(422, &base.ApiJsonErr{"comparison must be given as base...head", DOC_URL})
+		return
+	}
+
+	repoPath := models.RepoPath(repo.Owner.Name, repo.Name)
+	baseCommit := getApiCommit(ctx, repoPath, infos[0])
+	if baseCommit == nil {
+		return
+	}
+	headCommit := getApiCommit(ctx, repoPath, infos[1])
+	if headCommit == nil {
+		return
+	}
+	baseId, headId := baseCommit.Id.String(), headCommit.Id.String()
+	mergeBase, err := models.GetMergeBase(repoPath, baseId, headId)
+	if err != nil {
+		ctx.JSON(422, &base.ApiJsonErr{"base and head have no common history", DOC_URL})
+		return
+	}
+	if len(format) > 0 {
+		renderPatch(ctx, "CompareCommits", repoPath, mergeBase, headId, format)
+		return
+	}
+
+	behind, err := models.CountCommits(repoPath, headId+".."+baseId, "")
+	if err != nil {
+		log.Error("v1.CompareCommits(CountCommits): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	page, limit := getListPage(ctx, 30)
+	commits, ahead, err := models.GetCommitsPage(repoPath, mergeBase+".."+headId, "", page, limit)
+	if err != nil {
+		log.Error("v1.CompareCommits(GetCommitsPage): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	diff := getApiDiff(ctx, "CompareCommits", repoPath, mergeBase, headId)
+	if diff == nil {
+		return
+	}
+
+	status := "identical"
+	switch {
+	case ahead > 0 && behind > 0:
+		status = "diverged"
+	case ahead > 0:
+		status = "ahead"
+	case behind > 0:
+		status = "behind"
+	}
+	results := make([]*repoCommit, len(commits))
+	for i := range commits {
+		results[i] = toRepoCommit(repo, commits[i])
+	}
+	files, stats := toCommitFiles(diff)
+
+	// Commits are paginated, changed files are the same on every page.
+	setPageHeaders(ctx, page, limit, ahead)
+	ctx.JSON(200, map[string]interface{}{
+		"base_commit":       toRepoCommit(repo, baseCommit),
+		"merge_base_commit": mergeBase,
+		"status":            status,
+		"ahead_by":          ahead,
+		"behind_by":         behind,
+		"commits":           results,
+		"files":             files,
+		"stats":             stats,
+	})
+}
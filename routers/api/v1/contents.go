@@ -0,0 +1,272 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"encoding/base64"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/auth/apiv1"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+type content struct {
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Sha      string `json:"sha"`
+	Size     int64  `json:"size"`
+	Encoding string `json:"encoding,omitempty"`
+	Content  string `json:"content,omitempty"`
+}
+
+func toContent(e *models.GitTreeEntry, treePath string) *content {
+	c := &content{Name: path.Base(treePath), Path: treePath, Sha: e.Id}
+	switch {
+	case e.Type == "tree":
+		c.Type = "dir"
+	case e.Type == "commit":
+		c.Type = "submodule"
+	case e.Mode == "120000":
+		c.Type = "symlink"
+	default:
+		c.Type = "file"
+	}
+	if e.Size > 0 {
+		c.Size = e.Size
+	}
+	return c
+}
+
+// GetContents returns content of file, or entries of directory, at given path
+// in branch, tag or commit by query parameter ref, which is default branch by default.
+func GetContents(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+
+	ref := ctx.Query("ref")
+	if len(ref) == 0 {
+		ref = repo.DefaultBranch
+	}
+	repoPath := models.RepoPath(repo.Owner.Name, repo.Name)
+	commitId, err := models.ResolveCommitId(repoPath, ref)
+	if err != nil {
+		ctx.JSON(404, &base.ApiJsonErr{"reference not found", DOC_URL})
+		return
+	}
+
+	treePath := models.CleanTreeName(params["_1"])
+	if len(treePath) > 0 {
+		e, err := models.GetGitTreeEntry(repoPath, commitId, treePath)
+		if err != nil {
+			if err == models.ErrGitObjectNotExist {
+				ctx.JSON(404, &base.ApiJsonErr{"path not found", DOC_URL})
+			} else {
+				log.Error("v1.GetContents(GetGitTreeEntry): %v", err)
+				ctx.JSON(500, nil)
+			}
+			return
+		}
+
+		if e.Type != "tree" {
+			c := toContent(e, treePath)
+			if e.Type == "blob" && e.Size <= MAX_BLOB_JSON_SIZE {
+				if c.Content, err = readBlobBase64(repo, e.Id); err != nil {
+					log.Error("v1.GetContents(readBlobBase64): %v", err)
+					ctx.JSON(500, nil)
+					return
+				}
+				c.Encoding = "base64"
+			}
+			renderCacheableJSON(ctx, c, time.Time{})
+			return
+		}
+	}
+
+	treeish := commitId
+	if len(treePath) > 0 {
+		treeish += ":" + treePath
+	}
+	entries, err := models.GetGitTree(repoPath, treeish, false)
+	if err != nil {
+		log.Error("v1.GetContents(GetGitTree): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	results := make([]*content, len(entries))
+	for i, e := range entries {
+		results[i] = toContent(e, path.Join(treePath, e.Path))
+	}
+	renderCacheableJSON(ctx, results, time.Time{})
+}
+
+// prepareFileChange returns change of file at path in params based on head of
+// given branch, and current entry of the file which is nil if it does not exist.
+func prepareFileChange(ctx *middleware.Context, params martini.Params, repo *models.Repository,
+	branch, newBranch, sha string) (*models.RepoFileChange, *models.GitTreeEntry, bool) {
+	treePath := models.CleanTreeName(params["_1"])
+	if len(treePath) == 0 {
+		ctx.JSON(422, &base.ApiJsonErr{"path is not valid", DOC_URL})
+		return nil, nil, false
+	}
+
+	if len(branch) == 0 {
+		branch = repo.DefaultBranch
+	}
+	if len(newBranch) == 0 {
+		newBranch = branch
+	}
+	repoPath := models.RepoPath(repo.Owner.Name, repo.Name)
+	commitId, err := models.ResolveCommitId(repoPath, "refs/heads/"+branch)
+	if err != nil {
+		ctx.JSON(422, &base.ApiJsonErr{"branch does not exist", DOC_URL})
+		return nil, nil, false
+	}
+
+	e, err := models.GetGitTreeEntry(repoPath, commitId, treePath)
+	if err != nil && err != models.ErrGitObjectNotExist {
+		log.Error("v1.prepareFileChange(GetGitTreeEntry): %v", err)
+		ctx.JSON(500, nil)
+		return nil, nil, false
+	} else if e != nil && e.Type != "blob" {
+		ctx.JSON(422, &base.ApiJsonErr{"path is not a file", DOC_URL})
+		return nil, nil, false
+	}
+
+	// Given SHA must be of current blob, so changes made by others are not overwritten.
+	switch {
+	case e == nil && len(sha) > 0:
+		ctx.JSON(404, &base.ApiJsonErr{"file not found", DOC_URL})
+		return nil, nil, false
+	case e != nil && len(sha) == 0:
+		ctx.JSON(422, &base.ApiJsonErr{"sha of current file must be given", DOC_URL})
+		return nil, nil, false
+	case e != nil && e.Id != sha:
+		ctx.JSON(409, &base.ApiJsonErr{"sha does not match current file", DOC_URL})
+		return nil, nil, false
+	}
+
+	change := &models.RepoFileChange{
+		OldBranch:    branch,
+		NewBranch:    newBranch,
+		LastCommitId: commitId,
+	}
+	if e != nil {
+		change.OldTreeName = treePath
+	}
+	return change, e, true
+}
+
+// respondFileChange responds with new content of changed file and commit of the change.
+func respondFileChange(ctx *middleware.Context, repo *models.Repository, change *models.RepoFileChange, status int) {
+	repoPath := models.RepoPath(repo.Owner.Name, repo.Name)
+	commitId, err := models.ResolveCommitId(repoPath, "refs/heads/"+change.NewBranch)
+	if err != nil {
+		log.Error("v1.respondFileChange(ResolveCommitId): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+
+	var c *content
+	if len(change.NewTreeName) > 0 {
+		e, err := models.GetGitTreeEntry(repoPath, commitId, change.NewTreeName)
+		if err != nil {
+			log.Error("v1.respondFileChange(GetGitTreeEntry): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+		c = toContent(e, change.NewTreeName)
+	}
+	ctx.JSON(status, map[string]interface{}{
+		"content": c,
+		"commit":  &gitObject{"commit", commitId},
+	})
+}
+
+// UpdateContents creates or updates a file by committing it to a branch,
+// or to a new branch created from the branch.
+func UpdateContents(ctx *middleware.Context, params martini.Params, form apiv1.UpdateFileForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	repo := getWritableGitRepo(ctx, params)
+	if repo == nil {
+		return
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.Replace(form.Content, "\n", "", -1))
+	if err != nil {
+		ctx.JSON(422, &base.ApiJsonErr{"content must be encoded in Base64", DOC_URL})
+		return
+	}
+	change, e, ok := prepareFileChange(ctx, params, repo, form.Branch, form.NewBranch, form.Sha)
+	if !ok {
+		return
+	}
+	change.NewTreeName = models.CleanTreeName(params["_1"])
+	change.Content = string(data)
+	change.Message = strings.TrimSpace(form.Message)
+	if len(change.Message) == 0 {
+		if e == nil {
+			change.Message = "Add " + path.Base(change.NewTreeName)
+		} else {
+			change.Message = "Update " + path.Base(change.NewTreeName)
+		}
+	}
+
+	if err = models.CommitRepoFileChange(ctx.User, repo, change); err != nil {
+		handleGitWriteError(ctx, "UpdateContents(CommitRepoFileChange)", err)
+		return
+	}
+	log.Trace("%s File committed from API: %s/%s:%s/%s", ctx.Req.RequestURI,
+		repo.Owner.LowerName, repo.LowerName, change.NewBranch, change.NewTreeName)
+
+	status := 200
+	if e == nil {
+		status = 201
+	}
+	respondFileChange(ctx, repo, change, status)
+}
+
+// DeleteContents deletes a file by committing the deletion to a branch,
+// or to a new branch created from the branch.
+func DeleteContents(ctx *middleware.Context, params martini.Params, form apiv1.DeleteFileForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	repo := getWritableGitRepo(ctx, params)
+	if repo == nil {
+		return
+	}
+
+	change, _, ok := prepareFileChange(ctx, params, repo, form.Branch, form.NewBranch, form.Sha)
+	if !ok {
+		return
+	}
+	change.Message = strings.TrimSpace(form.Message)
+	if len(change.Message) == 0 {
+		change.Message = "Delete " + path.Base(change.OldTreeName)
+	}
+
+	if err := models.CommitRepoFileChange(ctx.User, repo, change); err != nil {
+		handleGitWriteError(ctx, "DeleteContents(CommitRepoFileChange)", err)
+		return
+	}
+	log.Trace("%s File deleted from API: %s/%s:%s/%s", ctx.Req.RequestURI,
+		repo.Owner.LowerName, repo.LowerName, change.NewBranch, change.OldTreeName)
+
+	respondFileChange(ctx, repo, change, 200)
+}
@@ -0,0 +1,133 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/auth/apiv1"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+type deployKey struct {
+	Id          int64     `json:"id"`
+	Title       string    `json:"title"`
+	Key         string    `json:"key"`
+	Fingerprint string    `json:"fingerprint"`
+	IsWritable  bool      `json:"is_writable"`
+	Created     time.Time `json:"created_at"`
+}
+
+func toDeployKey(key *models.DeployKey) *deployKey {
+	return &deployKey{key.Id, key.Name, key.Content, key.Fingerprint, key.IsWritable, key.Created}
+}
+
+func ListDeployKeys(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_ADMIN)
+	if repo == nil {
+		return
+	}
+
+	keys, err := models.ListDeployKeys(repo.Id)
+	if err != nil {
+		log.Error("v1.ListDeployKeys(ListDeployKeys): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	results := make([]*deployKey, len(keys))
+	for i := range keys {
+		results[i] = toDeployKey(keys[i])
+	}
+	start, end := paginate(ctx, len(results), 30)
+	ctx.JSON(200, results[start:end])
+}
+
+func GetDeployKey(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_ADMIN)
+	if repo == nil {
+		return
+	}
+
+	id, _ := base.StrTo(params["id"]).Int64()
+	key, err := models.GetDeployKeyById(id)
+	if err != nil || key.RepoId != repo.Id {
+		if err != nil && err != models.ErrDeployKeyNotExist {
+			log.Error("v1.GetDeployKey(GetDeployKeyById): %v", err)
+			ctx.JSON(500, nil)
+		} else {
+			ctx.JSON(404, &base.ApiJsonErr{"deploy key not found", DOC_URL})
+		}
+		return
+	}
+	ctx.JSON(200, toDeployKey(key))
+}
+
+func AddDeployKey(ctx *middleware.Context, params martini.Params, form apiv1.AddDeployKeyForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	repo := getApiRepo(ctx, params, models.AU_ADMIN)
+	if repo == nil {
+		return
+	}
+
+	content := strings.TrimSpace(form.Key)
+	if len(content) < 100 || !strings.HasPrefix(content, "ssh-rsa") || strings.ContainsAny(content, "\r\n") {
+		ctx.JSON(422, &base.ApiJsonErr{"SSH key content is not valid", DOC_URL})
+		return
+	}
+
+	key := &models.DeployKey{
+		RepoId:     repo.Id,
+		Name:       form.Title,
+		Content:    content,
+		IsWritable: form.IsWritable,
+	}
+	if err := models.AddDeployKey(key); err != nil {
+		if err == models.ErrKeyAlreadyExist {
+			ctx.JSON(422, &base.ApiJsonErr{"deploy key title or content has been used", DOC_URL})
+			return
+		}
+		log.Error("v1.AddDeployKey(AddDeployKey): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+
+	log.Trace("%s Deploy key added: %s/%s", ctx.Req.RequestURI, repo.Owner.Name, repo.Name)
+	models.RecordAudit(ctx.User.Id, ctx.User.Name, models.AUDIT_ADD_DEPLOY_KEY, ctx.RemoteAddr(),
+		fmt.Sprintf("%s (%s) to %s/%s", key.Name, key.Fingerprint, repo.Owner.Name, repo.Name))
+	ctx.JSON(201, toDeployKey(key))
+}
+
+func DeleteDeployKey(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_ADMIN)
+	if repo == nil {
+		return
+	}
+
+	id, _ := base.StrTo(params["id"]).Int64()
+	if err := models.DeleteDeployKey(repo.Id, id); err != nil {
+		if err == models.ErrDeployKeyNotExist {
+			ctx.JSON(404, &base.ApiJsonErr{"deploy key not found", DOC_URL})
+		} else {
+			log.Error("v1.DeleteDeployKey(DeleteDeployKey): %v", err)
+			ctx.JSON(500, nil)
+		}
+		return
+	}
+
+	log.Trace("%s Deploy key deleted: %s/%s", ctx.Req.RequestURI, repo.Owner.Name, repo.Name)
+	models.RecordAudit(ctx.User.Id, ctx.User.Name, models.AUDIT_DELETE_DEPLOY_KEY, ctx.RemoteAddr(),
+		fmt.Sprintf("Key ID %d from %s/%s", id, repo.Owner.Name, repo.Name))
+	ctx.Res.WriteHeader(204)
+}
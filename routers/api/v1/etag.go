@@ -0,0 +1,70 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+// isNotModified returns true if client's cached version of resource given by
+// If-None-Match, or If-Modified-Since when the former is absent, is still current.
+func isNotModified(req *http.Request, etag string, modified time.Time) bool {
+	if match := req.Header.Get("If-None-Match"); len(match) > 0 {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == etag || tag == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if since := req.Header.Get("If-Modified-Since"); len(since) > 0 && !modified.IsZero() {
+		t, err := http.ParseTime(since)
+		return err == nil && !modified.Truncate(time.Second).After(t)
+	}
+	return false
+}
+
+// checkNotModified sets ETag and Last-Modified headers of resource, zero modified time
+// omits the latter. It responds with 304 and returns true if client's cached version
+// is still current, such response is not counted against rate limit.
+func checkNotModified(ctx *middleware.Context, etag string, modified time.Time) bool {
+	ctx.Res.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		ctx.Res.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	if !isNotModified(ctx.Req, etag, modified) {
+		return false
+	}
+
+	middleware.RefundApiRateLimit(ctx)
+	ctx.Res.WriteHeader(304)
+	return true
+}
+
+// renderCacheableJSON responds with resource in JSON, whose ETag is computed
+// from the response body so that it changes whenever anything in it does.
+func renderCacheableJSON(ctx *middleware.Context, obj interface{}, modified time.Time) {
+	data, err := ctx.JSONString(obj)
+	if err != nil {
+		log.Error("v1.renderCacheableJSON(JSONString): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	if checkNotModified(ctx, `"`+base.EncodeSha1(data)+`"`, modified) {
+		return
+	}
+
+	ctx.Res.Header().Set(middleware.ContentType, middleware.ContentJSON+"; charset=UTF-8")
+	ctx.Res.WriteHeader(200)
+	ctx.Res.Write([]byte(data))
+}
@@ -0,0 +1,149 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+var eventTypes = map[int]string{
+	models.OP_CREATE_REPO:     "create_repo",
+	models.OP_COMMIT_REPO:     "push",
+	models.OP_PUSH_TAG:        "push_tag",
+	models.OP_CREATE_ISSUE:    "issue",
+	models.OP_PULL_REQUEST:    "pull_request",
+	models.OP_COMMENT_ISSUE:   "issue_comment",
+	models.OP_TRANSFER_REPO:   "transfer_repo",
+	models.OP_PUBLISH_RELEASE: "release",
+}
+
+type event struct {
+	Id      int64       `json:"id"`
+	Type    string      `json:"type"`
+	Actor   string      `json:"actor"`
+	Repo    string      `json:"repo"`
+	Ref     string      `json:"ref,omitempty"`
+	Private bool        `json:"private"`
+	Payload interface{} `json:"payload"`
+	Created time.Time   `json:"created_at"`
+}
+
+type eventCommit struct {
+	Sha     string `json:"sha"`
+	Message string `json:"message"`
+	Author  string `json:"author"`
+	Email   string `json:"email"`
+}
+
+// eventPayload returns details of action by its type.
+func eventPayload(act *models.Action) interface{} {
+	switch act.OpType {
+	case models.OP_COMMIT_REPO:
+		var push *base.PushCommits
+		if err := json.Unmarshal([]byte(act.Content), &push); err != nil || push == nil {
+			return nil
+		}
+		commits := make([]*eventCommit, len(push.Commits))
+		for i, c := range push.Commits {
+			commits[i] = &eventCommit{c.Sha1, c.Message, c.AuthorName, c.AuthorEmail}
+		}
+		return map[string]interface{}{
+			"size":    push.Len,
+			"commits": commits,
+		}
+	case models.OP_CREATE_ISSUE, models.OP_PULL_REQUEST, models.OP_COMMENT_ISSUE:
+		infos := strings.SplitN(act.Content, "|", 2)
+		if len(infos) != 2 {
+			return nil
+		}
+		index, _ := base.StrTo(infos[0]).Int64()
+		return map[string]interface{}{
+			"number": index,
+			"title":  infos[1],
+		}
+	case models.OP_TRANSFER_REPO:
+		return map[string]interface{}{
+			"new_owner": act.Content,
+		}
+	case models.OP_PUBLISH_RELEASE:
+		infos := strings.SplitN(act.Content, "|", 2)
+		if len(infos) != 2 {
+			return nil
+		}
+		return map[string]interface{}{
+			"tag_name": infos[0],
+			"name":     infos[1],
+		}
+	}
+	return nil
+}
+
+func toEvent(act *models.Action) *event {
+	return &event{
+		Id:      act.Id,
+		Type:    eventTypes[act.OpType],
+		Actor:   act.ActUserName,
+		Repo:    act.RepoUserName + "/" + act.RepoName,
+		Ref:     act.RefName,
+		Private: act.IsPrivate,
+		Payload: eventPayload(act),
+		Created: act.Created,
+	}
+}
+
+// renderEvents responds with actions that match given conditions in page of query parameters.
+func renderEvents(ctx *middleware.Context, opts *models.ActionListOptions) {
+	page, limit := getListPage(ctx, 30)
+	opts.Page = page
+	opts.PageSize = limit
+	actions, total, err := models.ListActions(opts)
+	if err != nil {
+		log.Error("v1.renderEvents(ListActions): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+
+	results := make([]*event, len(actions))
+	for i := range actions {
+		results[i] = toEvent(actions[i])
+	}
+	setPageHeaders(ctx, page, limit, total)
+	ctx.JSON(200, results)
+}
+
+// ListUserEvents lists actions performed by user, actions on private repositories
+// are only included for the user self and site administrators.
+func ListUserEvents(ctx *middleware.Context, params martini.Params) {
+	u := getApiUser(ctx, params)
+	if u == nil {
+		return
+	}
+	viewer := privateReader(ctx)
+	renderEvents(ctx, &models.ActionListOptions{
+		ActUserId:      u.Id,
+		IncludePrivate: viewer != nil && (viewer.Id == u.Id || viewer.IsAdmin),
+	})
+}
+
+// ListRepoEvents lists actions on repository.
+func ListRepoEvents(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+	renderEvents(ctx, &models.ActionListOptions{
+		RepoId:         repo.Id,
+		IncludePrivate: true,
+	})
+}
@@ -0,0 +1,281 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/auth/apiv1"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+// MAX_BLOB_JSON_SIZE is max size of blob in bytes whose content is given in JSON,
+// larger ones must be fetched raw.
+const MAX_BLOB_JSON_SIZE = 1024 * 1024
+
+type gitRef struct {
+	Ref    string     `json:"ref"`
+	Object *gitObject `json:"object"`
+}
+
+type gitObject struct {
+	Type string `json:"type"`
+	Sha  string `json:"sha"`
+}
+
+func toGitRef(ref *models.GitRef) *gitRef {
+	return &gitRef{ref.Name, &gitObject{ref.Type, ref.ObjectId}}
+}
+
+// getWritableGitRepo returns repository whose Git data signed in user can change.
+func getWritableGitRepo(ctx *middleware.Context, params martini.Params) *models.Repository {
+	repo := getWritableApiRepo(ctx, params)
+	if repo == nil {
+		return nil
+	} else if repo.IsMirror {
+		ctx.JSON(403, &base.ApiJsonErr{"repository is a mirror", DOC_URL})
+		return nil
+	}
+	return repo
+}
+
+// handleGitWriteError responds with error of changing Git data of repository.
+func handleGitWriteError(ctx *middleware.Context, funcName string, err error) {
+	switch err {
+	case models.ErrRepoArchived, models.ErrBranchPushRestricted, models.ErrBranchRequirePullRequest,
+		models.ErrBranchForcePushBlocked, models.ErrBranchDeletionBlocked:
+		ctx.JSON(403, &base.ApiJsonErr{strings.ToLower(err.Error()), DOC_URL})
+	case models.ErrRepoFileChanged:
+		ctx.JSON(409, &base.ApiJsonErr{strings.ToLower(err.Error()), DOC_URL})
+	case models.ErrRepoFileAlreadyExist, models.ErrRepoFileNameIllegal, models.ErrRepoFileNotExist,
+		models.ErrBranchAlreadyExist, models.ErrBranchNotExist, models.ErrDeleteDefaultBranch,
+		models.ErrRefAlreadyExist, models.ErrRefNameIllegal, models.ErrGitObjectNotExist:
+		ctx.JSON(422, &base.ApiJsonErr{strings.ToLower(err.Error()), DOC_URL})
+	case models.ErrRefNotExist, models.ErrTagNotExist:
+		ctx.JSON(404, &base.ApiJsonErr{"reference not found", DOC_URL})
+	default:
+		log.Error("v1.%s: %v", funcName, err)
+		ctx.JSON(500, nil)
+	}
+}
+
+// ListGitRefs lists branch and tag references of repository, or the one reference
+// when full name is given, e.g. /git/refs/heads lists branches only.
+func ListGitRefs(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+
+	prefix := "refs/"
+	if len(params["_1"]) > 0 {
+		prefix += strings.TrimSuffix(params["_1"], "/")
+	}
+	refs, err := models.GetGitRefs(models.RepoPath(repo.Owner.Name, repo.Name), prefix)
+	if err != nil {
+		log.Error("v1.ListGitRefs(GetGitRefs): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+
+	results := make([]*gitRef, 0, len(refs))
+	for _, ref := range refs {
+		if ref.Name == prefix {
+			ctx.JSON(200, toGitRef(ref))
+			return
+		} else if prefix == "refs/" || strings.HasPrefix(ref.Name, prefix+"/") {
+			results = append(results, toGitRef(ref))
+		}
+	}
+	if len(results) == 0 {
+		ctx.JSON(404, &base.ApiJsonErr{"reference not found", DOC_URL})
+		return
+	}
+	start, end := paginate(ctx, len(results), 30)
+	ctx.JSON(200, results[start:end])
+}
+
+func CreateGitRef(ctx *middleware.Context, params martini.Params, form apiv1.CreateRefForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	repo := getWritableGitRepo(ctx, params)
+	if repo == nil {
+		return
+	}
+
+	if err := models.CreateGitRef(ctx.User, repo, form.Ref, form.Sha); err != nil {
+		handleGitWriteError(ctx, "CreateGitRef(CreateGitRef)", err)
+		return
+	}
+	log.Trace("%s Reference created: %s/%s -> %s", ctx.Req.RequestURI, repo.Owner.Name, repo.Name, form.Ref)
+
+	ref, err := models.GetGitRef(models.RepoPath(repo.Owner.Name, repo.Name), form.Ref)
+	if err != nil {
+		log.Error("v1.CreateGitRef(GetGitRef): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	ctx.JSON(201, toGitRef(ref))
+}
+
+func DeleteGitRef(ctx *middleware.Context, params martini.Params) {
+	repo := getWritableGitRepo(ctx, params)
+	if repo == nil {
+		return
+	}
+
+	refName := "refs/" + params["_1"]
+	if err := models.DeleteGitRef(ctx.User, repo, refName); err != nil {
+		handleGitWriteError(ctx, "DeleteGitRef(DeleteGitRef)", err)
+		return
+	}
+	log.Trace("%s Reference deleted: %s/%s -> %s", ctx.Req.RequestURI, repo.Owner.Name, repo.Name, refName)
+	ctx.Res.WriteHeader(204)
+}
+
+type gitTreeEntry struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+	Type string `json:"type"`
+	Sha  string `json:"sha"`
+	Size *int64 `json:"size,omitempty"`
+}
+
+func toGitTreeEntry(e *models.GitTreeEntry) *gitTreeEntry {
+	result := &gitTreeEntry{e.Path, e.Mode, e.Type, e.Id, nil}
+	if e.Size >= 0 {
+		result.Size = &e.Size
+	}
+	return result
+}
+
+// GetGitTree returns entries of tree that given SHA, branch or tag points to,
+// entries of sub-trees are included when query parameter recursive is given.
+func GetGitTree(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+
+	entries, err := models.GetGitTree(models.RepoPath(repo.Owner.Name, repo.Name),
+		params["sha"], len(ctx.Query("recursive")) > 0)
+	if err != nil {
+		if err == models.ErrGitObjectNotExist {
+			ctx.JSON(404, &base.ApiJsonErr{"tree not found", DOC_URL})
+		} else {
+			log.Error("v1.GetGitTree(GetGitTree): %v", err)
+			ctx.JSON(500, nil)
+		}
+		return
+	}
+
+	results := make([]*gitTreeEntry, len(entries))
+	for i := range entries {
+		results[i] = toGitTreeEntry(entries[i])
+	}
+	renderCacheableJSON(ctx, map[string]interface{}{
+		"sha":  params["sha"],
+		"tree": results,
+	}, time.Time{})
+}
+
+// getApiBlobSize returns size of blob by SHA in params, it responds with error
+// and returns false if blob does not exist.
+func getApiBlobSize(ctx *middleware.Context, repo *models.Repository, sha string) (int64, bool) {
+	size, err := models.GetBlobSize(models.RepoPath(repo.Owner.Name, repo.Name), sha)
+	if err != nil {
+		if err == models.ErrGitObjectNotExist {
+			ctx.JSON(404, &base.ApiJsonErr{"blob not found", DOC_URL})
+		} else {
+			log.Error("v1.getApiBlobSize(GetBlobSize): %v", err)
+			ctx.JSON(500, nil)
+		}
+		return 0, false
+	}
+	return size, true
+}
+
+// readBlobBase64 returns content of blob encoded in Base64.
+func readBlobBase64(repo *models.Repository, sha string) (string, error) {
+	r, err := models.OpenBlob(models.RepoPath(repo.Owner.Name, repo.Name), sha)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// GetGitBlob returns content of blob encoded in Base64,
+// blobs larger than MAX_BLOB_JSON_SIZE must be fetched raw.
+func GetGitBlob(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+	size, ok := getApiBlobSize(ctx, repo, params["sha"])
+	if !ok {
+		return
+	} else if size > MAX_BLOB_JSON_SIZE {
+		ctx.JSON(413, &base.ApiJsonErr{"blob is too large, fetch it raw instead", DOC_URL})
+		return
+	}
+
+	content, err := readBlobBase64(repo, params["sha"])
+	if err != nil {
+		log.Error("v1.GetGitBlob(readBlobBase64): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	renderCacheableJSON(ctx, map[string]interface{}{
+		"sha":      params["sha"],
+		"size":     size,
+		"encoding": "base64",
+		"content":  content,
+	}, time.Time{})
+}
+
+// GetRawGitBlob streams content of blob as it is, blob is identified
+// by its content so the SHA serves as ETag.
+func GetRawGitBlob(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+	size, ok := getApiBlobSize(ctx, repo, params["sha"])
+	if !ok {
+		return
+	} else if checkNotModified(ctx, `"`+params["sha"]+`"`, time.Time{}) {
+		return
+	}
+
+	r, err := models.OpenBlob(models.RepoPath(repo.Owner.Name, repo.Name), params["sha"])
+	if err != nil {
+		log.Error("v1.GetRawGitBlob(OpenBlob): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	defer r.Close()
+
+	ctx.Res.Header().Set("Content-Type", "application/octet-stream")
+	ctx.Res.Header().Set("Content-Length", base.ToStr(size))
+	ctx.Res.WriteHeader(200)
+	io.Copy(ctx.Res, r)
+}
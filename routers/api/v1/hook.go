@@ -0,0 +1,333 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/auth/apiv1"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+type hook struct {
+	Id          int64  `json:"id"`
+	Url         string `json:"url"`
+	ContentType string `json:"content_type"`
+	PushOnly    bool   `json:"push_only"`
+	Active      bool   `json:"active"`
+}
+
+func toHook(w *models.Webhook) *hook {
+	w.GetEvent()
+	h := &hook{w.Id, w.Url, "json", w.PushOnly, w.IsActive}
+	if w.ContentType == models.CT_FORM {
+		h.ContentType = "form"
+	}
+	return h
+}
+
+// parseHookContentType returns content type of webhook by its name, or zero if it is unknown.
+func parseHookContentType(name string) int {
+	switch name {
+	case "", "json":
+		return models.CT_JSON
+	case "form":
+		return models.CT_FORM
+	}
+	return 0
+}
+
+type hookDelivery struct {
+	Id         int64           `json:"id"`
+	Event      string          `json:"event"`
+	Delivered  bool            `json:"delivered"`
+	Succeeded  bool            `json:"succeeded"`
+	StatusCode int             `json:"status_code"`
+	Duration   int64           `json:"duration_ms"`
+	Created    time.Time       `json:"created_at"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	Response   string          `json:"response,omitempty"`
+}
+
+// toHookDelivery returns delivery of webhook, payload and response are
+// only included when it is detailed.
+func toHookDelivery(d *models.HookDelivery, detailed bool) *hookDelivery {
+	result := &hookDelivery{
+		Id:         d.Id,
+		Event:      d.Event,
+		Delivered:  d.IsDelivered,
+		Succeeded:  d.IsSucceed,
+		StatusCode: d.ResponseStatus,
+		Duration:   d.Duration,
+		Created:    d.Created,
+	}
+	if detailed {
+		result.Payload = json.RawMessage(d.PayloadContent)
+		result.Response = d.ResponseContent
+	}
+	return result
+}
+
+// getApiHook returns webhook of repository by ID in params.
+func getApiHook(ctx *middleware.Context, params martini.Params, repo *models.Repository) *models.Webhook {
+	id, _ := base.StrTo(params["id"]).Int64()
+	w, err := models.GetWebhookById(id)
+	if err != nil || w.RepoId != repo.Id {
+		if err != nil && err != models.ErrWebhookNotExist {
+			log.Error("v1.getApiHook(GetWebhookById): %v", err)
+			ctx.JSON(500, nil)
+		} else {
+			ctx.JSON(404, &base.ApiJsonErr{"webhook not found", DOC_URL})
+		}
+		return nil
+	}
+	return w
+}
+
+func ListHooks(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_ADMIN)
+	if repo == nil {
+		return
+	}
+
+	ws, err := models.GetWebhooksByRepoId(repo.Id)
+	if err != nil {
+		log.Error("v1.ListHooks(GetWebhooksByRepoId): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	results := make([]*hook, len(ws))
+	for i := range ws {
+		results[i] = toHook(ws[i])
+	}
+	start, end := paginate(ctx, len(results), 30)
+	ctx.JSON(200, results[start:end])
+}
+
+func GetHook(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_ADMIN)
+	if repo == nil {
+		return
+	}
+	w := getApiHook(ctx, params, repo)
+	if w == nil {
+		return
+	}
+	ctx.JSON(200, toHook(w))
+}
+
+func CreateHook(ctx *middleware.Context, params martini.Params, form apiv1.CreateHookForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	repo := getApiRepo(ctx, params, models.AU_ADMIN)
+	if repo == nil {
+		return
+	}
+
+	ct := parseHookContentType(form.ContentType)
+	if ct == 0 {
+		ctx.JSON(422, &base.ApiJsonErr{"content_type must be one of json and form", DOC_URL})
+		return
+	}
+
+	w := &models.Webhook{
+		RepoId:      repo.Id,
+		Url:         form.Url,
+		ContentType: ct,
+		Secret:      form.Secret,
+		HookEvent: &models.HookEvent{
+			PushOnly: form.PushOnly,
+		},
+		IsActive: form.Active,
+	}
+	if err := w.SaveEvent(); err != nil {
+		log.Error("v1.CreateHook(SaveEvent): %v", err)
+		ctx.JSON(500, nil)
+		return
+	} else if err = models.CreateWebhook(w); err != nil {
+		log.Error("v1.CreateHook(CreateWebhook): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	log.Trace("%s Webhook created: %s/%s -> %d", ctx.Req.RequestURI, repo.Owner.Name, repo.Name, w.Id)
+	ctx.JSON(201, toHook(w))
+}
+
+func EditHook(ctx *middleware.Context, params martini.Params, form apiv1.EditHookForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	repo := getApiRepo(ctx, params, models.AU_ADMIN)
+	if repo == nil {
+		return
+	}
+	w := getApiHook(ctx, params, repo)
+	if w == nil {
+		return
+	}
+
+	w.GetEvent()
+	if form.Url != nil {
+		if !strings.HasPrefix(*form.Url, "http://") && !strings.HasPrefix(*form.Url, "https://") {
+			ctx.JSON(422, &base.ApiJsonErr{"url must be a HTTP or HTTPS URL", DOC_URL})
+			return
+		}
+		w.Url = *form.Url
+	}
+	if form.ContentType != nil {
+		if w.ContentType = parseHookContentType(*form.ContentType); w.ContentType == 0 {
+			ctx.JSON(422, &base.ApiJsonErr{"content_type must be one of json and form", DOC_URL})
+			return
+		}
+	}
+	if form.Secret != nil {
+		w.Secret = *form.Secret
+	}
+	if form.PushOnly != nil {
+		w.PushOnly = *form.PushOnly
+	}
+	if form.Active != nil {
+		w.IsActive = *form.Active
+	}
+
+	if err := w.SaveEvent(); err != nil {
+		log.Error("v1.EditHook(SaveEvent): %v", err)
+		ctx.JSON(500, nil)
+		return
+	} else if err = models.UpdateWebhook(w); err != nil {
+		log.Error("v1.EditHook(UpdateWebhook): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	log.Trace("%s Webhook updated: %s/%s -> %d", ctx.Req.RequestURI, repo.Owner.Name, repo.Name, w.Id)
+	ctx.JSON(200, toHook(w))
+}
+
+func DeleteHook(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_ADMIN)
+	if repo == nil {
+		return
+	}
+	w := getApiHook(ctx, params, repo)
+	if w == nil {
+		return
+	}
+
+	if err := models.DeleteWebhook(w.Id); err != nil {
+		log.Error("v1.DeleteHook(DeleteWebhook): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	log.Trace("%s Webhook deleted: %s/%s -> %d", ctx.Req.RequestURI, repo.Owner.Name, repo.Name, w.Id)
+	ctx.Res.WriteHeader(204)
+}
+
+// TestHook delivers a push event of latest commit of default branch to webhook,
+// the delivery is done in background and its result can be found in deliveries.
+func TestHook(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_ADMIN)
+	if repo == nil {
+		return
+	}
+	w := getApiHook(ctx, params, repo)
+	if w == nil {
+		return
+	}
+
+	d, err := models.TestWebhook(repo, w)
+	if err != nil {
+		if err == models.ErrBranchNotExist {
+			ctx.JSON(422, &base.ApiJsonErr{"repository has no commit to test with", DOC_URL})
+		} else {
+			log.Error("v1.TestHook(TestWebhook): %v", err)
+			ctx.JSON(500, nil)
+		}
+		return
+	}
+	ctx.JSON(202, toHookDelivery(d, false))
+}
+
+func ListHookDeliveries(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_ADMIN)
+	if repo == nil {
+		return
+	}
+	w := getApiHook(ctx, params, repo)
+	if w == nil {
+		return
+	}
+
+	ds, err := models.GetHookDeliveries(w.Id)
+	if err != nil {
+		log.Error("v1.ListHookDeliveries(GetHookDeliveries): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	results := make([]*hookDelivery, len(ds))
+	for i := range ds {
+		results[i] = toHookDelivery(ds[i], false)
+	}
+	start, end := paginate(ctx, len(results), 30)
+	ctx.JSON(200, results[start:end])
+}
+
+func getApiHookDelivery(ctx *middleware.Context, params martini.Params) (*models.Webhook, *models.HookDelivery) {
+	repo := getApiRepo(ctx, params, models.AU_ADMIN)
+	if repo == nil {
+		return nil, nil
+	}
+	w := getApiHook(ctx, params, repo)
+	if w == nil {
+		return nil, nil
+	}
+
+	id, _ := base.StrTo(params["delivery"]).Int64()
+	d, err := models.GetHookDeliveryById(w.Id, id)
+	if err != nil {
+		if err == models.ErrHookDeliveryNotExist {
+			ctx.JSON(404, &base.ApiJsonErr{"delivery not found", DOC_URL})
+		} else {
+			log.Error("v1.getApiHookDelivery(GetHookDeliveryById): %v", err)
+			ctx.JSON(500, nil)
+		}
+		return nil, nil
+	}
+	return w, d
+}
+
+// GetHookDelivery returns delivery of webhook with its payload and response.
+func GetHookDelivery(ctx *middleware.Context, params martini.Params) {
+	_, d := getApiHookDelivery(ctx, params)
+	if d == nil {
+		return
+	}
+	ctx.JSON(200, toHookDelivery(d, true))
+}
+
+// RedeliverHookDelivery delivers payload of delivery again as a new delivery.
+func RedeliverHookDelivery(ctx *middleware.Context, params martini.Params) {
+	w, d := getApiHookDelivery(ctx, params)
+	if d == nil {
+		return
+	}
+
+	newDelivery, err := models.RedeliverHookDelivery(w, d)
+	if err != nil {
+		log.Error("v1.RedeliverHookDelivery(RedeliverHookDelivery): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	ctx.JSON(202, toHookDelivery(newDelivery, false))
+}
@@ -0,0 +1,806 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Unknwon/com"
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/auth/apiv1"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/mailer"
+	"github.com/gogits/gogs/modules/middleware"
+	"github.com/gogits/gogs/modules/setting"
+)
+
+type issue struct {
+	Number    int64     `json:"number"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	State     string    `json:"state"`
+	IsPull    bool      `json:"is_pull"`
+	User      string    `json:"user"`
+	Labels    []string  `json:"labels"`
+	Milestone string    `json:"milestone"`
+	Assignees []string  `json:"assignees"`
+	Comments  int       `json:"comments"`
+	DueDate   string    `json:"due_date"`
+	PinOrder  int       `json:"pin_order"`
+	Created   time.Time `json:"created_at"`
+	Updated   time.Time `json:"updated_at"`
+}
+
+// ListIssues lists issues of repository, filtered and sorted by query parameters
+// in the same way as issue list page.
+func ListIssues(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+
+	opts := &models.IssuesOptions{
+		RepoId:    repo.Id,
+		IsClosed:  ctx.Query("state") == "closed",
+		Keyword:   ctx.Query("q"),
+		DueFilter: ctx.Query("due"),
+		SortType:  ctx.Query("sort"),
+	}
+	opts.Page, opts.PageSize = getListPage(ctx, 20)
+
+	for _, name := range []string{"assignee", "author"} {
+		if len(ctx.Query(name)) == 0 {
+			continue
+		}
+		u, err := models.GetUserByName(ctx.Query(name))
+		if err != nil {
+			if err == models.ErrUserNotExist {
+				ctx.JSON(422, &base.ApiJsonErr{name + " does not exist", DOC_URL})
+			} else {
+				ctx.JSON(500, nil)
+			}
+			return
+		}
+		if name == "assignee" {
+			opts.AssigneeId = u.Id
+		} else {
+			opts.PosterId = u.Id
+		}
+	}
+
+	if idx, _ := base.StrTo(ctx.Query("milestone")).Int64(); idx > 0 {
+		m, err := models.GetMilestoneByIndex(repo.Id, idx)
+		if err != nil {
+			if err == models.ErrMilestoneNotExist {
+				ctx.JSON(422, &base.ApiJsonErr{"milestone does not exist", DOC_URL})
+			} else {
+				ctx.JSON(500, nil)
+			}
+			return
+		}
+		opts.MilestoneId = m.Id
+	}
+
+	// Labels are given by names.
+	if names := ctx.Query("labels"); len(names) > 0 {
+		labels, ok := getLabelsByNames(ctx, repo, strings.Split(names, ","))
+		if !ok {
+			return
+		}
+		ids := make([]string, len(labels))
+		for i, l := range labels {
+			ids[i] = base.ToStr(l.Id)
+		}
+		opts.LabelIds = strings.Join(ids, ",")
+	}
+
+	issues, err := models.SearchIssues(opts)
+	if err != nil {
+		log.Error("v1.ListIssues(SearchIssues): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+
+	results := make([]*issue, len(issues))
+	for i := range issues {
+		if results[i], err = toIssue(&issues[i]); err != nil {
+			log.Error("v1.ListIssues(toIssue): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+	}
+	total := models.CountIssues(opts)
+	setPageHeaders(ctx, opts.Page, opts.PageSize, total)
+	renderCacheableJSON(ctx, map[string]interface{}{
+		"ok":     true,
+		"total":  total,
+		"issues": results,
+	}, time.Time{})
+}
+
+func toIssue(i *models.Issue) (*issue, error) {
+	if err := i.GetPoster(); err != nil {
+		return nil, err
+	} else if err = i.GetLabels(); err != nil {
+		return nil, err
+	} else if err = i.GetAssignees(); err != nil {
+		return nil, err
+	}
+
+	result := &issue{
+		Number:    i.Index,
+		Title:     i.Name,
+		Body:      i.Content,
+		State:     "open",
+		IsPull:    i.IsPull,
+		User:      i.Poster.Name,
+		Labels:    make([]string, len(i.Labels)),
+		Assignees: make([]string, len(i.Assignees)),
+		Comments:  i.NumComments,
+		PinOrder:  i.PinOrder,
+		Created:   i.Created,
+		Updated:   i.Updated,
+	}
+	if i.IsClosed {
+		result.State = "closed"
+	}
+	if i.HasDeadline() {
+		result.DueDate = i.Deadline.Format("2006-01-02")
+	}
+	for j, l := range i.Labels {
+		result.Labels[j] = l.Name
+	}
+	for j, u := range i.Assignees {
+		result.Assignees[j] = u.Name
+	}
+	if i.MilestoneId > 0 {
+		m, err := models.GetMilestoneById(i.MilestoneId)
+		if err != nil && err != models.ErrMilestoneNotExist {
+			return nil, err
+		} else if err == nil {
+			result.Milestone = m.Name
+		}
+	}
+	return result, nil
+}
+
+// getApiIssue returns issue of repository by index in URL.
+func getApiIssue(ctx *middleware.Context, params martini.Params, repo *models.Repository) *models.Issue {
+	idx, _ := base.StrTo(params["index"]).Int64()
+	i, err := models.GetIssueByIndex(repo.Id, idx)
+	if err != nil {
+		if err == models.ErrIssueNotExist {
+			ctx.JSON(404, &base.ApiJsonErr{"issue not found", DOC_URL})
+		} else {
+			ctx.JSON(500, nil)
+		}
+		return nil
+	}
+	return i
+}
+
+// ListPinnedIssues lists pinned issues of repository in their order.
+func ListPinnedIssues(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+
+	issues, err := models.GetPinnedIssues(repo.Id)
+	if err != nil {
+		log.Error("v1.ListPinnedIssues(GetPinnedIssues): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	start, end := paginate(ctx, len(issues), 30)
+	results := make([]*issue, 0, end-start)
+	for _, i := range issues[start:end] {
+		result, err := toIssue(i)
+		if err != nil {
+			log.Error("v1.ListPinnedIssues(toIssue): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+		results = append(results, result)
+	}
+	ctx.JSON(200, results)
+}
+
+// PinIssue pins issue after other pinned issues of repository.
+func PinIssue(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_WRITABLE)
+	if repo == nil {
+		return
+	}
+	i := getApiIssue(ctx, params, repo)
+	if i == nil {
+		return
+	}
+
+	switch err := models.PinIssue(i); err {
+	case nil:
+		ctx.Res.WriteHeader(204)
+	case models.ErrPinnedIssuesLimit, models.ErrPinPullRequest:
+		ctx.JSON(422, &base.ApiJsonErr{err.Error(), DOC_URL})
+	default:
+		log.Error("v1.PinIssue(PinIssue): %v", err)
+		ctx.JSON(500, nil)
+	}
+}
+
+// UnpinIssue unpins issue.
+func UnpinIssue(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_WRITABLE)
+	if repo == nil {
+		return
+	}
+	i := getApiIssue(ctx, params, repo)
+	if i == nil {
+		return
+	}
+
+	switch err := models.UnpinIssue(i); err {
+	case nil:
+		ctx.Res.WriteHeader(204)
+	case models.ErrIssueNotPinned:
+		ctx.JSON(422, &base.ApiJsonErr{err.Error(), DOC_URL})
+	default:
+		log.Error("v1.UnpinIssue(UnpinIssue): %v", err)
+		ctx.JSON(500, nil)
+	}
+}
+
+// MovePinnedIssue moves pinned issue to given position starting from 1.
+func MovePinnedIssue(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_WRITABLE)
+	if repo == nil {
+		return
+	}
+	i := getApiIssue(ctx, params, repo)
+	if i == nil {
+		return
+	}
+
+	position, err := base.StrTo(params["position"]).Int()
+	if err != nil || position < 1 {
+		ctx.JSON(422, &base.ApiJsonErr{"position must be a positive number", DOC_URL})
+		return
+	}
+	switch err = models.MovePinnedIssue(i, position); err {
+	case nil:
+		ctx.Res.WriteHeader(204)
+	case models.ErrIssueNotPinned:
+		ctx.JSON(422, &base.ApiJsonErr{err.Error(), DOC_URL})
+	default:
+		log.Error("v1.MovePinnedIssue(MovePinnedIssue): %v", err)
+		ctx.JSON(500, nil)
+	}
+}
+
+// getLabelsByNames returns labels of repository with given names, it responds with
+// error and returns false if any of them does not exist.
+func getLabelsByNames(ctx *middleware.Context, repo *models.Repository, names []string) ([]*models.Label, bool) {
+	all, err := models.GetLabels(repo.Id)
+	if err != nil {
+		log.Error("v1.getLabelsByNames(GetLabels): %v", err)
+		ctx.JSON(500, nil)
+		return nil, false
+	}
+
+	labels := make([]*models.Label, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+		var label *models.Label
+		for _, l := range all {
+			if strings.EqualFold(l.Name, name) {
+				label = l
+				break
+			}
+		}
+		if label == nil {
+			ctx.JSON(422, &base.ApiJsonErr{"label " + name + " does not exist", DOC_URL})
+			return nil, false
+		}
+		labels = append(labels, label)
+	}
+	return labels, true
+}
+
+// canWriteRepo returns true if signed in user has write access to repository.
+func canWriteRepo(ctx *middleware.Context, repo *models.Repository) (bool, error) {
+	if !ctx.IsSigned {
+		return false, nil
+	} else if repo.OwnerId == ctx.User.Id || ctx.User.IsAdmin {
+		return true, nil
+	}
+	return models.HasAccess(ctx.User.Name, repo.Owner.Name+"/"+repo.Name, models.AU_WRITABLE)
+}
+
+// isApiRepoArchived responds with error and returns true if repository is archived.
+func isApiRepoArchived(ctx *middleware.Context, repo *models.Repository) bool {
+	if repo.IsArchived {
+		ctx.JSON(403, &base.ApiJsonErr{"repository is archived", DOC_URL})
+		return true
+	}
+	return false
+}
+
+// getApiAssignee returns user by given name who can be assigned to issues of repository.
+func getApiAssignee(ctx *middleware.Context, repo *models.Repository, name string) *models.User {
+	u, err := models.GetUserByName(name)
+	if err != nil {
+		if err == models.ErrUserNotExist {
+			ctx.JSON(422, &base.ApiJsonErr{"assignee " + name + " does not exist", DOC_URL})
+		} else {
+			ctx.JSON(500, nil)
+		}
+		return nil
+	}
+	if repo.OwnerId != u.Id {
+		has, err := models.HasAccess(u.Name, repo.Owner.Name+"/"+repo.Name, models.AU_READABLE)
+		if err != nil {
+			ctx.JSON(500, nil)
+			return nil
+		} else if !has {
+			ctx.JSON(422, &base.ApiJsonErr{"assignee " + name + " has no access to repository", DOC_URL})
+			return nil
+		}
+	}
+	return u
+}
+
+// getApiMilestone returns milestone of repository by index, it responds with error
+// and returns nil if it does not exist.
+func getApiMilestone(ctx *middleware.Context, repo *models.Repository, idx int64, status int) *models.Milestone {
+	m, err := models.GetMilestoneByIndex(repo.Id, idx)
+	if err != nil {
+		if err == models.ErrMilestoneNotExist {
+			ctx.JSON(status, &base.ApiJsonErr{"milestone not found", DOC_URL})
+		} else {
+			ctx.JSON(500, nil)
+		}
+		return nil
+	}
+	return m
+}
+
+// parseDueDate parses due date in form of "2006-01-02", empty value means no due date.
+func parseDueDate(s string) (time.Time, error) {
+	if len(s) == 0 {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation("2006-01-02", s, time.Local)
+}
+
+// updateMentions marks users mentioned in content of issue or comment who can access
+// repository and sends them web notifications, it returns lower names of these users.
+func updateMentions(ctx *middleware.Context, repo *models.Repository, issue *models.Issue, commentId int64, content string) ([]string, error) {
+	users, err := models.GetMentionedUsers(ctx.User, repo, content)
+	if err != nil || len(users) == 0 {
+		return nil, err
+	}
+
+	ids := make([]int64, len(users))
+	names := make([]string, len(users))
+	for i, u := range users {
+		ids[i] = u.Id
+		names[i] = u.LowerName
+	}
+	if err = models.UpdateIssueUserPairsByMentions(ids, issue.Id); err != nil {
+		return nil, err
+	} else if err = models.NewMentionNotifications(ctx.User, repo, issue, commentId, users); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// mailIssueSubscribers mails subscribers of issue and users mentioned in content of issue or comment.
+func mailIssueSubscribers(ctx *middleware.Context, repo *models.Repository, issue *models.Issue, mentions []string) error {
+	if !setting.Service.NotifyMail {
+		return nil
+	}
+
+	tos, err := mailer.SendIssueNotifyMail(ctx.User, repo.Owner, repo, issue)
+	if err != nil {
+		return err
+	}
+	tos = append(tos, ctx.User.LowerName)
+	newTos := make([]string, 0, len(mentions))
+	for _, m := range mentions {
+		if !com.IsSliceContainsStr(tos, m) {
+			newTos = append(newTos, m)
+		}
+	}
+	return mailer.SendIssueMentionMail(ctx.Render, ctx.User, repo.Owner, repo, issue, models.GetUserEmailsByNames(newTos))
+}
+
+func GetIssue(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+	i := getApiIssue(ctx, params, repo)
+	if i == nil {
+		return
+	}
+
+	result, err := toIssue(i)
+	if err != nil {
+		log.Error("v1.GetIssue(toIssue): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	renderCacheableJSON(ctx, result, i.Updated)
+}
+
+// CreateIssue creates issue in repository, labels, milestone and assignee
+// are only set when user has write access to repository.
+func CreateIssue(ctx *middleware.Context, params martini.Params, form apiv1.CreateIssueForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil || isApiRepoArchived(ctx, repo) {
+		return
+	}
+
+	deadline, err := parseDueDate(form.DueDate)
+	if err != nil {
+		ctx.JSON(422, &base.ApiJsonErr{"due_date must be in form of YYYY-MM-DD", DOC_URL})
+		return
+	}
+
+	canWrite, err := canWriteRepo(ctx, repo)
+	if err != nil {
+		ctx.JSON(500, nil)
+		return
+	}
+	var (
+		labels    []*models.Label
+		milestone *models.Milestone
+		assignee  *models.User
+		ok        bool
+	)
+	if canWrite {
+		if labels, ok = getLabelsByNames(ctx, repo, form.Labels); !ok {
+			return
+		}
+		if form.Milestone > 0 {
+			if milestone = getApiMilestone(ctx, repo, form.Milestone, 422); milestone == nil {
+				return
+			}
+		}
+		if len(form.Assignee) > 0 {
+			if assignee = getApiAssignee(ctx, repo, form.Assignee); assignee == nil {
+				return
+			}
+		}
+	}
+
+	issue := &models.Issue{
+		RepoId:   repo.Id,
+		Index:    int64(repo.NumIssues) + 1,
+		Name:     form.Title,
+		PosterId: ctx.User.Id,
+		Content:  form.Body,
+		Deadline: deadline,
+	}
+	if assignee != nil {
+		issue.AssigneeId = assignee.Id
+	}
+	if err = models.NewIssue(issue); err != nil {
+		log.Error("v1.CreateIssue(NewIssue): %v", err)
+		ctx.JSON(500, nil)
+		return
+	} else if err = models.NewIssueUserPairs(repo.Id, issue.Id, repo.OwnerId,
+		ctx.User.Id, issue.AssigneeId, repo.Name); err != nil {
+		log.Error("v1.CreateIssue(NewIssueUserPairs): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+
+	for _, l := range labels {
+		if err = models.ChangeIssueLabel(issue, l, true); err != nil {
+			log.Error("v1.CreateIssue(ChangeIssueLabel): %v", err)
+			ctx.JSON(500, nil)
+			return
+		} else if err = models.AddIssueToProjectsByLabel(issue, l.Id); err != nil {
+			log.Error("v1.CreateIssue(AddIssueToProjectsByLabel): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+	}
+	if milestone != nil {
+		issue.MilestoneId = milestone.Id
+		if err = models.ChangeMilestoneAssign(0, milestone.Id, issue); err != nil {
+			log.Error("v1.CreateIssue(ChangeMilestoneAssign): %v", err)
+			ctx.JSON(500, nil)
+			return
+		} else if err = models.UpdateIssue(issue); err != nil {
+			log.Error("v1.CreateIssue(UpdateIssue): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+	}
+
+	if err = models.UpdateIssuesByContent(ctx.User, repo, issue, issue.Content); err != nil {
+		log.Error("v1.CreateIssue(UpdateIssuesByContent): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	ms, err := updateMentions(ctx, repo, issue, 0, issue.Content)
+	if err != nil {
+		log.Error("v1.CreateIssue(updateMentions): %v", err)
+		ctx.JSON(500, nil)
+		return
+	} else if err = models.NewSubscriptionNotifications(models.NOTIFY_NEW_ISSUE, ctx.User, repo, issue, 0, ms); err != nil {
+		log.Error("v1.CreateIssue(NewSubscriptionNotifications): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+
+	if err = models.NotifyWatchers(&models.Action{
+		ActUserId:    ctx.User.Id,
+		ActUserName:  ctx.User.Name,
+		ActEmail:     ctx.User.Email,
+		OpType:       models.OP_CREATE_ISSUE,
+		Content:      fmt.Sprintf("%d|%s", issue.Index, issue.Name),
+		RepoId:       repo.Id,
+		RepoUserName: repo.Owner.Name,
+		RepoName:     repo.Name,
+		IsPrivate:    repo.IsPrivate,
+	}); err != nil {
+		log.Error("v1.CreateIssue(NotifyWatchers): %v", err)
+		ctx.JSON(500, nil)
+		return
+	} else if err = mailIssueSubscribers(ctx, repo, issue, ms); err != nil {
+		log.Error("v1.CreateIssue(mailIssueSubscribers): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	log.Trace("%s Issue created: %d", ctx.Req.RequestURI, issue.Id)
+
+	result, err := toIssue(issue)
+	if err != nil {
+		log.Error("v1.CreateIssue(toIssue): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	ctx.JSON(201, result)
+}
+
+// EditIssue changes issue, poster can change its title, body and state, and
+// only users with write access can change anything else.
+func EditIssue(ctx *middleware.Context, params martini.Params, form apiv1.EditIssueForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil || isApiRepoArchived(ctx, repo) {
+		return
+	}
+	issue := getApiIssue(ctx, params, repo)
+	if issue == nil {
+		return
+	}
+
+	canWrite, err := canWriteRepo(ctx, repo)
+	if err != nil {
+		ctx.JSON(500, nil)
+		return
+	} else if !canWrite && (issue.PosterId != ctx.User.Id ||
+		form.Assignees != nil || form.Milestone != nil || form.Labels != nil || form.DueDate != nil) {
+		ctx.JSON(403, &base.ApiJsonErr{"no write access to repository", DOC_URL})
+		return
+	}
+
+	// Check everything before any change is made.
+	if form.Title != nil && (len(*form.Title) == 0 || len(*form.Title) > 255) {
+		ctx.JSON(422, &base.ApiJsonErr{"title must contain 1 to 255 characters", DOC_URL})
+		return
+	}
+	isClosed := issue.IsClosed
+	if form.State != nil {
+		switch *form.State {
+		case "open", "closed":
+			isClosed = *form.State == "closed"
+		default:
+			ctx.JSON(422, &base.ApiJsonErr{"state must be one of open and closed", DOC_URL})
+			return
+		}
+	}
+	if isClosed && !issue.IsClosed && repo.BlockOnDependencies {
+		count, err := models.CountOpenBlockingIssues(issue.Id)
+		if err != nil {
+			ctx.JSON(500, nil)
+			return
+		} else if count > 0 {
+			ctx.JSON(422, &base.ApiJsonErr{"issue cannot be closed while it is blocked by open issues", DOC_URL})
+			return
+		}
+	}
+	var (
+		labels    []*models.Label
+		assignees []*models.User
+		mid       = issue.MilestoneId
+		ok        bool
+	)
+	if form.Labels != nil {
+		if labels, ok = getLabelsByNames(ctx, repo, *form.Labels); !ok {
+			return
+		}
+	}
+	if form.Assignees != nil {
+		for _, name := range *form.Assignees {
+			u := getApiAssignee(ctx, repo, name)
+			if u == nil {
+				return
+			}
+			assignees = append(assignees, u)
+		}
+	}
+	if form.Milestone != nil {
+		mid = 0
+		if *form.Milestone > 0 {
+			m := getApiMilestone(ctx, repo, *form.Milestone, 422)
+			if m == nil {
+				return
+			}
+			mid = m.Id
+		}
+	}
+	if form.DueDate != nil {
+		if issue.Deadline, err = parseDueDate(*form.DueDate); err != nil {
+			ctx.JSON(422, &base.ApiJsonErr{"due_date must be in form of YYYY-MM-DD", DOC_URL})
+			return
+		}
+	}
+
+	if form.Title != nil {
+		issue.Name = *form.Title
+	}
+	if form.Body != nil {
+		issue.Content = *form.Body
+	}
+	oldMid := issue.MilestoneId
+	if mid != oldMid {
+		issue.MilestoneId = mid
+		if err = models.ChangeMilestoneAssign(oldMid, mid, issue); err != nil {
+			log.Error("v1.EditIssue(ChangeMilestoneAssign): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+	}
+	if err = models.UpdateIssue(issue); err != nil {
+		log.Error("v1.EditIssue(UpdateIssue): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	if mid != oldMid {
+		if err = models.CreateMilestoneEvent(ctx.User, issue, oldMid, mid); err != nil {
+			log.Error("v1.EditIssue(CreateMilestoneEvent): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+	}
+	if form.Body != nil {
+		if err = models.UpdateIssuesByContent(ctx.User, repo, issue, issue.Content); err != nil {
+			log.Error("v1.EditIssue(UpdateIssuesByContent): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+	}
+
+	if form.Labels != nil {
+		if err = issue.GetLabels(); err != nil {
+			log.Error("v1.EditIssue(GetLabels): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+		keep := make(map[int64]bool)
+		for _, l := range labels {
+			keep[l.Id] = true
+		}
+		for _, l := range issue.Labels {
+			if keep[l.Id] {
+				continue
+			}
+			if err = models.ChangeIssueLabel(issue, l, false); err != nil {
+				log.Error("v1.EditIssue(ChangeIssueLabel): %v", err)
+				ctx.JSON(500, nil)
+				return
+			} else if err = models.CreateLabelEvent(ctx.User, issue, l, false); err != nil {
+				log.Error("v1.EditIssue(CreateLabelEvent): %v", err)
+				ctx.JSON(500, nil)
+				return
+			}
+		}
+		for _, l := range labels {
+			if issue.HasLabel(l.Id) {
+				continue
+			}
+			if err = models.ChangeIssueLabel(issue, l, true); err != nil {
+				log.Error("v1.EditIssue(ChangeIssueLabel): %v", err)
+				ctx.JSON(500, nil)
+				return
+			} else if err = models.AddIssueToProjectsByLabel(issue, l.Id); err != nil {
+				log.Error("v1.EditIssue(AddIssueToProjectsByLabel): %v", err)
+				ctx.JSON(500, nil)
+				return
+			} else if err = models.CreateLabelEvent(ctx.User, issue, l, true); err != nil {
+				log.Error("v1.EditIssue(CreateLabelEvent): %v", err)
+				ctx.JSON(500, nil)
+				return
+			}
+		}
+	}
+
+	if form.Assignees != nil {
+		if err = issue.GetAssignees(); err != nil {
+			log.Error("v1.EditIssue(GetAssignees): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+		keep := make(map[int64]bool)
+		for _, u := range assignees {
+			keep[u.Id] = true
+		}
+		for _, u := range issue.Assignees {
+			if keep[u.Id] {
+				continue
+			}
+			if err = models.ChangeIssueAssignee(issue, u.Id, false); err != nil {
+				log.Error("v1.EditIssue(ChangeIssueAssignee): %v", err)
+				ctx.JSON(500, nil)
+				return
+			} else if err = models.CreateAssigneeEvent(ctx.User, issue, u, false); err != nil {
+				log.Error("v1.EditIssue(CreateAssigneeEvent): %v", err)
+				ctx.JSON(500, nil)
+				return
+			}
+		}
+		for _, u := range assignees {
+			if issue.IsAssigned(u.Id) {
+				continue
+			}
+			if err = models.ChangeIssueAssignee(issue, u.Id, true); err != nil {
+				log.Error("v1.EditIssue(ChangeIssueAssignee): %v", err)
+				ctx.JSON(500, nil)
+				return
+			} else if err = models.CreateAssigneeEvent(ctx.User, issue, u, true); err != nil {
+				log.Error("v1.EditIssue(CreateAssigneeEvent): %v", err)
+				ctx.JSON(500, nil)
+				return
+			}
+		}
+	}
+
+	if isClosed != issue.IsClosed {
+		if err = models.ChangeIssueStatus(ctx.User, issue, isClosed); err != nil {
+			log.Error("v1.EditIssue(ChangeIssueStatus): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+	}
+	log.Trace("%s Issue edited: %d", ctx.Req.RequestURI, issue.Id)
+
+	result, err := toIssue(issue)
+	if err != nil {
+		log.Error("v1.EditIssue(toIssue): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	ctx.JSON(200, result)
+}
@@ -0,0 +1,224 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/auth/apiv1"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+type comment struct {
+	Id      int64     `json:"id"`
+	User    string    `json:"user"`
+	Body    string    `json:"body"`
+	Edits   int       `json:"edits"`
+	Created time.Time `json:"created_at"`
+}
+
+func toComment(c *models.Comment) (*comment, error) {
+	u, err := models.GetUserById(c.PosterId)
+	if err != nil {
+		if err != models.ErrUserNotExist {
+			return nil, err
+		}
+		u = &models.User{Name: "FakeUser"}
+	}
+	return &comment{c.Id, u.Name, c.Content, c.NumRevisions, c.Created}, nil
+}
+
+// ListIssueComments lists plain comments of issue, oldest first.
+func ListIssueComments(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+	issue := getApiIssue(ctx, params, repo)
+	if issue == nil {
+		return
+	}
+
+	all, err := models.GetIssueComments(issue.Id)
+	if err != nil {
+		log.Error("v1.ListIssueComments(GetIssueComments): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	comments := make([]*models.Comment, 0, len(all))
+	for i := range all {
+		if all[i].Type == models.IT_PLAIN {
+			comments = append(comments, &all[i])
+		}
+	}
+
+	start, end := paginate(ctx, len(comments), 30)
+	results := make([]*comment, 0, end-start)
+	for _, c := range comments[start:end] {
+		result, err := toComment(c)
+		if err != nil {
+			log.Error("v1.ListIssueComments(toComment): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+		results = append(results, result)
+	}
+	ctx.JSON(200, results)
+}
+
+func CreateIssueComment(ctx *middleware.Context, params martini.Params, form apiv1.CreateCommentForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil || isApiRepoArchived(ctx, repo) {
+		return
+	}
+	issue := getApiIssue(ctx, params, repo)
+	if issue == nil {
+		return
+	}
+
+	c, err := models.CreateComment(ctx.User.Id, repo.Id, issue.Id, 0, 0, models.IT_PLAIN, form.Body)
+	if err != nil {
+		log.Error("v1.CreateIssueComment(CreateComment): %v", err)
+		ctx.JSON(500, nil)
+		return
+	} else if err = models.UpdateIssuesByContent(ctx.User, repo, issue, form.Body); err != nil {
+		log.Error("v1.CreateIssueComment(UpdateIssuesByContent): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	ms, err := updateMentions(ctx, repo, issue, c.Id, form.Body)
+	if err != nil {
+		log.Error("v1.CreateIssueComment(updateMentions): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	log.Trace("%s Comment created: %d", ctx.Req.RequestURI, issue.Id)
+
+	if err = models.NotifyWatchers(&models.Action{
+		ActUserId:    ctx.User.Id,
+		ActUserName:  ctx.User.Name,
+		ActEmail:     ctx.User.Email,
+		OpType:       models.OP_COMMENT_ISSUE,
+		Content:      fmt.Sprintf("%d|%s", issue.Index, strings.Split(form.Body, "\n")[0]),
+		RepoId:       repo.Id,
+		RepoUserName: repo.Owner.Name,
+		RepoName:     repo.Name,
+		IsPrivate:    repo.IsPrivate,
+	}); err != nil {
+		log.Error("v1.CreateIssueComment(NotifyWatchers): %v", err)
+		ctx.JSON(500, nil)
+		return
+	} else if err = models.NewSubscriptionNotifications(models.NOTIFY_COMMENT, ctx.User, repo, issue, c.Id, ms); err != nil {
+		log.Error("v1.CreateIssueComment(NewSubscriptionNotifications): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	issue.Content = form.Body
+	if err = mailIssueSubscribers(ctx, repo, issue, ms); err != nil {
+		log.Error("v1.CreateIssueComment(mailIssueSubscribers): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+
+	result, err := toComment(c)
+	if err != nil {
+		log.Error("v1.CreateIssueComment(toComment): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	ctx.JSON(201, result)
+}
+
+// getApiEditableComment returns comment of issue that signed in user can edit or delete,
+// which are users with write access and poster of the comment.
+func getApiEditableComment(ctx *middleware.Context, params martini.Params) (*models.Repository, *models.Issue, *models.Comment) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil || isApiRepoArchived(ctx, repo) {
+		return nil, nil, nil
+	}
+	issue := getApiIssue(ctx, params, repo)
+	if issue == nil {
+		return nil, nil, nil
+	}
+
+	id, _ := base.StrTo(params["id"]).Int64()
+	c, err := models.GetCommentById(issue.Id, id)
+	if err != nil {
+		if err == models.ErrCommentNotExist {
+			ctx.JSON(404, &base.ApiJsonErr{"comment not found", DOC_URL})
+		} else {
+			ctx.JSON(500, nil)
+		}
+		return nil, nil, nil
+	}
+
+	if c.PosterId != ctx.User.Id {
+		canWrite, err := canWriteRepo(ctx, repo)
+		if err != nil {
+			ctx.JSON(500, nil)
+			return nil, nil, nil
+		} else if !canWrite {
+			ctx.JSON(403, &base.ApiJsonErr{"no write access to repository", DOC_URL})
+			return nil, nil, nil
+		}
+	}
+	return repo, issue, c
+}
+
+func EditIssueComment(ctx *middleware.Context, params martini.Params, form apiv1.CreateCommentForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	repo, issue, c := getApiEditableComment(ctx, params)
+	if c == nil {
+		return
+	}
+
+	if err := models.EditComment(c, ctx.User.Id, form.Body); err != nil {
+		log.Error("v1.EditIssueComment(EditComment): %v", err)
+		ctx.JSON(500, nil)
+		return
+	} else if err = models.UpdateIssuesByContent(ctx.User, repo, issue, form.Body); err != nil {
+		log.Error("v1.EditIssueComment(UpdateIssuesByContent): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	log.Trace("%s Comment edited: %d", ctx.Req.RequestURI, c.Id)
+
+	result, err := toComment(c)
+	if err != nil {
+		log.Error("v1.EditIssueComment(toComment): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	ctx.JSON(200, result)
+}
+
+func DeleteIssueComment(ctx *middleware.Context, params martini.Params) {
+	_, _, c := getApiEditableComment(ctx, params)
+	if c == nil {
+		return
+	}
+
+	if err := models.DeleteComment(c, ctx.User.Id); err != nil {
+		log.Error("v1.DeleteIssueComment(DeleteComment): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	log.Trace("%s Comment deleted: %d", ctx.Req.RequestURI, c.Id)
+	ctx.Res.WriteHeader(204)
+}
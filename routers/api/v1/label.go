@@ -0,0 +1,164 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"regexp"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/auth/apiv1"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+var labelColorPattern = regexp.MustCompile("^#[0-9a-fA-F]{6}$")
+
+type label struct {
+	Id          int64  `json:"id"`
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+	OpenIssues  int    `json:"open_issues"`
+	Issues      int    `json:"issues"`
+}
+
+func toLabel(l *models.Label) *label {
+	l.CalOpenIssues()
+	return &label{l.Id, l.Name, l.Color, l.Description, l.NumOpenIssues, l.NumIssues}
+}
+
+// getWritableApiRepo returns repository that signed in user can write to.
+func getWritableApiRepo(ctx *middleware.Context, params martini.Params) *models.Repository {
+	repo := getApiRepo(ctx, params, models.AU_WRITABLE)
+	if repo == nil || isApiRepoArchived(ctx, repo) {
+		return nil
+	}
+	return repo
+}
+
+func getApiLabel(ctx *middleware.Context, params martini.Params, repo *models.Repository) *models.Label {
+	id, _ := base.StrTo(params["id"]).Int64()
+	l, err := models.GetRepoLabelById(repo.Id, id)
+	if err != nil {
+		if err == models.ErrLabelNotExist {
+			ctx.JSON(404, &base.ApiJsonErr{"label not found", DOC_URL})
+		} else {
+			ctx.JSON(500, nil)
+		}
+		return nil
+	}
+	return l
+}
+
+func ListLabels(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+
+	labels, err := models.GetLabels(repo.Id)
+	if err != nil {
+		log.Error("v1.ListLabels(GetLabels): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	results := make([]*label, len(labels))
+	for i := range labels {
+		results[i] = toLabel(labels[i])
+	}
+	start, end := paginate(ctx, len(results), 30)
+	ctx.JSON(200, results[start:end])
+}
+
+func GetLabel(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+	l := getApiLabel(ctx, params, repo)
+	if l == nil {
+		return
+	}
+	ctx.JSON(200, toLabel(l))
+}
+
+func CreateLabel(ctx *middleware.Context, params martini.Params, form apiv1.CreateLabelForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	} else if !labelColorPattern.MatchString(form.Color) {
+		ctx.JSON(422, &base.ApiJsonErr{"color must be a hex color code like #ee0701", DOC_URL})
+		return
+	}
+	repo := getWritableApiRepo(ctx, params)
+	if repo == nil {
+		return
+	}
+
+	l := &models.Label{
+		RepoId:      repo.Id,
+		Name:        form.Name,
+		Color:       form.Color,
+		Description: form.Description,
+	}
+	if err := models.NewLabel(l); err != nil {
+		log.Error("v1.CreateLabel(NewLabel): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	log.Trace("%s Label created: %s/%s -> %s", ctx.Req.RequestURI, repo.Owner.Name, repo.Name, l.Name)
+	ctx.JSON(201, toLabel(l))
+}
+
+func EditLabel(ctx *middleware.Context, params martini.Params, form apiv1.CreateLabelForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	} else if !labelColorPattern.MatchString(form.Color) {
+		ctx.JSON(422, &base.ApiJsonErr{"color must be a hex color code like #ee0701", DOC_URL})
+		return
+	}
+	repo := getWritableApiRepo(ctx, params)
+	if repo == nil {
+		return
+	}
+	l := getApiLabel(ctx, params, repo)
+	if l == nil {
+		return
+	}
+
+	l.Name = form.Name
+	l.Color = form.Color
+	l.Description = form.Description
+	if err := models.UpdateLabel(l); err != nil {
+		log.Error("v1.EditLabel(UpdateLabel): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	log.Trace("%s Label updated: %s/%s -> %s", ctx.Req.RequestURI, repo.Owner.Name, repo.Name, l.Name)
+	ctx.JSON(200, toLabel(l))
+}
+
+func DeleteLabel(ctx *middleware.Context, params martini.Params) {
+	repo := getWritableApiRepo(ctx, params)
+	if repo == nil {
+		return
+	}
+	l := getApiLabel(ctx, params, repo)
+	if l == nil {
+		return
+	}
+
+	if err := models.DeleteLabel(repo.Id, l.Id); err != nil {
+		log.Error("v1.DeleteLabel(DeleteLabel): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	log.Trace("%s Label deleted: %s/%s -> %d", ctx.Req.RequestURI, repo.Owner.Name, repo.Name, l.Id)
+	ctx.Res.WriteHeader(204)
+}
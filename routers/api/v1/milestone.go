@@ -0,0 +1,209 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"time"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/auth/apiv1"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+type milestone struct {
+	Number       int64      `json:"number"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description"`
+	State        string     `json:"state"`
+	OpenIssues   int        `json:"open_issues"`
+	ClosedIssues int        `json:"closed_issues"`
+	DueOn        string     `json:"due_on"`
+	Closed       *time.Time `json:"closed_at"`
+}
+
+func toMilestone(m *models.Milestone) *milestone {
+	m.CalOpenIssues()
+	result := &milestone{
+		Number:       m.Index,
+		Title:        m.Name,
+		Description:  m.Content,
+		State:        "open",
+		OpenIssues:   m.NumOpenIssues,
+		ClosedIssues: m.NumClosedIssues,
+	}
+	// Milestones without due date are stored with deadline at the end of year 9999.
+	if m.Deadline.Year() != 9999 {
+		result.DueOn = m.Deadline.Format("2006-01-02")
+	}
+	if m.IsClosed {
+		result.State = "closed"
+		result.Closed = &m.ClosedDate
+	}
+	return result
+}
+
+// parseMilestoneDeadline parses due date in form of "2006-01-02",
+// empty value means no due date.
+func parseMilestoneDeadline(s string) (time.Time, error) {
+	if len(s) == 0 {
+		s = "9999-12-31"
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+func getApiRepoMilestone(ctx *middleware.Context, params martini.Params, repo *models.Repository) *models.Milestone {
+	idx, _ := base.StrTo(params["index"]).Int64()
+	return getApiMilestone(ctx, repo, idx, 404)
+}
+
+// ListMilestones lists open or closed milestones of repository by query parameter state.
+func ListMilestones(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+
+	miles, err := models.GetMilestones(repo.Id, ctx.Query("state") == "closed")
+	if err != nil {
+		log.Error("v1.ListMilestones(GetMilestones): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	results := make([]*milestone, len(miles))
+	for i := range miles {
+		results[i] = toMilestone(miles[i])
+	}
+	start, end := paginate(ctx, len(results), 30)
+	ctx.JSON(200, results[start:end])
+}
+
+func GetMilestone(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+	m := getApiRepoMilestone(ctx, params, repo)
+	if m == nil {
+		return
+	}
+	ctx.JSON(200, toMilestone(m))
+}
+
+func CreateMilestone(ctx *middleware.Context, params martini.Params, form apiv1.CreateMilestoneForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	repo := getWritableApiRepo(ctx, params)
+	if repo == nil {
+		return
+	}
+
+	deadline, err := parseMilestoneDeadline(form.DueDate)
+	if err != nil {
+		ctx.JSON(422, &base.ApiJsonErr{"due_date must be in form of YYYY-MM-DD", DOC_URL})
+		return
+	}
+
+	m := &models.Milestone{
+		RepoId:   repo.Id,
+		Index:    int64(repo.NumMilestones) + 1,
+		Name:     form.Title,
+		Content:  form.Description,
+		Deadline: deadline,
+	}
+	if err = models.NewMilestone(m); err != nil {
+		log.Error("v1.CreateMilestone(NewMilestone): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	log.Trace("%s Milestone created: %s/%s -> %s", ctx.Req.RequestURI, repo.Owner.Name, repo.Name, m.Name)
+	ctx.JSON(201, toMilestone(m))
+}
+
+func EditMilestone(ctx *middleware.Context, params martini.Params, form apiv1.EditMilestoneForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	repo := getWritableApiRepo(ctx, params)
+	if repo == nil {
+		return
+	}
+	m := getApiRepoMilestone(ctx, params, repo)
+	if m == nil {
+		return
+	}
+
+	if form.Title != nil {
+		if len(*form.Title) == 0 || len(*form.Title) > 50 {
+			ctx.JSON(422, &base.ApiJsonErr{"title must contain 1 to 50 characters", DOC_URL})
+			return
+		}
+		m.Name = *form.Title
+	}
+	if form.Description != nil {
+		m.Content = *form.Description
+	}
+	if form.DueDate != nil {
+		deadline, err := parseMilestoneDeadline(*form.DueDate)
+		if err != nil {
+			ctx.JSON(422, &base.ApiJsonErr{"due_date must be in form of YYYY-MM-DD", DOC_URL})
+			return
+		}
+		m.Deadline = deadline
+	}
+	isClosed := m.IsClosed
+	if form.State != nil {
+		switch *form.State {
+		case "open", "closed":
+			isClosed = *form.State == "closed"
+		default:
+			ctx.JSON(422, &base.ApiJsonErr{"state must be one of open and closed", DOC_URL})
+			return
+		}
+	}
+
+	if err := models.UpdateMilestone(m); err != nil {
+		log.Error("v1.EditMilestone(UpdateMilestone): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	if isClosed != m.IsClosed {
+		if isClosed {
+			m.ClosedDate = time.Now()
+		}
+		if err := models.ChangeMilestoneStatus(m, isClosed); err != nil {
+			log.Error("v1.EditMilestone(ChangeMilestoneStatus): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+	}
+	log.Trace("%s Milestone updated: %s/%s -> %s", ctx.Req.RequestURI, repo.Owner.Name, repo.Name, m.Name)
+	ctx.JSON(200, toMilestone(m))
+}
+
+func DeleteMilestone(ctx *middleware.Context, params martini.Params) {
+	repo := getWritableApiRepo(ctx, params)
+	if repo == nil {
+		return
+	}
+	m := getApiRepoMilestone(ctx, params, repo)
+	if m == nil {
+		return
+	}
+
+	if err := models.DeleteMilestone(m); err != nil {
+		log.Error("v1.DeleteMilestone(DeleteMilestone): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	log.Trace("%s Milestone deleted: %s/%s -> %d", ctx.Req.RequestURI, repo.Owner.Name, repo.Name, m.Index)
+	ctx.Res.WriteHeader(204)
+}
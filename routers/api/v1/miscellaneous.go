@@ -6,8 +6,12 @@ package v1
 
 import (
 	"io/ioutil"
+	"regexp"
 	"strings"
 
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/models"
 	"github.com/gogits/gogs/modules/auth/apiv1"
 	"github.com/gogits/gogs/modules/base"
 	"github.com/gogits/gogs/modules/middleware"
@@ -16,7 +20,22 @@ import (
 
 const DOC_URL = "http://gogs.io/docs"
 
-// Render an arbitrary Markdown document.
+var rootRelativeLinkPattern = regexp.MustCompile(`(href|src)="/([^/"])`)
+
+// absoluteLinks makes root-relative links in rendered HTML absolute,
+// so that they still work when the HTML is shown outside of this site.
+func absoluteLinks(html []byte) []byte {
+	return rootRelativeLinkPattern.ReplaceAll(html, []byte(`$1="`+setting.AppUrl+`$2`))
+}
+
+func writeHTML(ctx *middleware.Context, html []byte) {
+	ctx.Res.Header().Set(middleware.ContentType, middleware.ContentHTML+"; charset=UTF-8")
+	ctx.Write(absoluteLinks(html))
+}
+
+// Render an arbitrary Markdown document, documents in "gfm" mode are rendered
+// as in web UI, where context "owner/repo" is used to link issue references,
+// commits and relative links.
 func Markdown(ctx *middleware.Context, form apiv1.MarkdownForm) {
 	if ctx.HasApiError() {
 		ctx.JSON(422, base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
@@ -25,10 +44,22 @@ func Markdown(ctx *middleware.Context, form apiv1.MarkdownForm) {
 
 	switch form.Mode {
 	case "gfm":
-		ctx.Write(base.RenderMarkdown([]byte(form.Text),
-			setting.AppUrl+strings.TrimPrefix(form.Context, "/")))
+		var urlPrefix string
+		if len(form.Context) > 0 {
+			names := strings.Split(strings.Trim(form.Context, "/"), "/")
+			if len(names) != 2 {
+				ctx.JSON(422, base.ApiJsonErr{"context must be in form of owner/repo", DOC_URL})
+				return
+			}
+			repo := getApiRepo(ctx, martini.Params{"username": names[0], "reponame": names[1]}, models.AU_READABLE)
+			if repo == nil {
+				return
+			}
+			urlPrefix = "/" + repo.Owner.Name + "/" + repo.Name
+		}
+		writeHTML(ctx, base.RenderMarkdown([]byte(form.Text), urlPrefix))
 	default:
-		ctx.Write(base.RenderRawMarkdown([]byte(form.Text), ""))
+		writeHTML(ctx, base.RenderRawMarkdown([]byte(form.Text), ""))
 	}
 }
 
@@ -39,5 +70,5 @@ func MarkdownRaw(ctx *middleware.Context) {
 		ctx.JSON(422, base.ApiJsonErr{err.Error(), DOC_URL})
 		return
 	}
-	ctx.Write(base.RenderRawMarkdown(body, ""))
+	writeHTML(ctx, base.RenderRawMarkdown(body, ""))
 }
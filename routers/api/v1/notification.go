@@ -0,0 +1,178 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+	"github.com/gogits/gogs/modules/setting"
+)
+
+var notificationReasons = map[int]string{
+	models.NOTIFY_MENTION:        "mention",
+	models.NOTIFY_REVIEW_REQUEST: "review_requested",
+	models.NOTIFY_NEW_ISSUE:      "new_issue",
+	models.NOTIFY_COMMENT:        "comment",
+	models.NOTIFY_COMMIT_COMMENT: "commit_comment",
+}
+
+type notificationSubject struct {
+	Type     string `json:"type"`
+	Title    string `json:"title,omitempty"`
+	Number   int64  `json:"number,omitempty"`
+	CommitId string `json:"commit_id,omitempty"`
+	HtmlUrl  string `json:"html_url"`
+}
+
+type notification struct {
+	Id      int64                `json:"id"`
+	Reason  string               `json:"reason"`
+	Unread  bool                 `json:"unread"`
+	Actor   string               `json:"actor"`
+	Repo    string               `json:"repo"`
+	Subject *notificationSubject `json:"subject"`
+	Created time.Time            `json:"created_at"`
+	Updated time.Time            `json:"updated_at"`
+}
+
+// toNotification returns notification whose attributes have been loaded.
+func toNotification(n *models.Notification) *notification {
+	subject := &notificationSubject{HtmlUrl: setting.AppUrl + n.Link()[1:]}
+	if n.IsCommitComment() {
+		subject.Type = "commit"
+		subject.CommitId = n.CommitId
+	} else {
+		subject.Type = "issue"
+		if n.Issue.IsPull {
+			subject.Type = "pull_request"
+		}
+		subject.Title = n.Issue.Name
+		subject.Number = n.Issue.Index
+	}
+
+	updated := n.Updated
+	if updated.IsZero() {
+		updated = n.Created
+	}
+	return &notification{
+		Id:      n.Id,
+		Reason:  notificationReasons[n.Type],
+		Unread:  !n.IsRead,
+		Actor:   n.ActUser.Name,
+		Repo:    n.Repo.Owner.Name + "/" + n.Repo.Name,
+		Subject: subject,
+		Created: n.Created,
+		Updated: updated,
+	}
+}
+
+// ListNotifications lists unread notifications of signed in user newest first,
+// or all of them when query parameter all is true, and only those created or
+// changed since time given by query parameter since. Clients that poll should
+// send If-Modified-Since or If-None-Match, which is answered with 304 cheaply
+// if nothing has changed.
+func ListNotifications(ctx *middleware.Context) {
+	opts := &models.NotificationListOptions{
+		UserId: ctx.User.Id,
+		All:    ctx.Query("all") == "true",
+	}
+	if since := ctx.Query("since"); len(since) > 0 {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			ctx.JSON(422, &base.ApiJsonErr{"since must be a time in RFC 3339 format", DOC_URL})
+			return
+		}
+		opts.Since = t
+	}
+
+	modified, err := models.GetNotificationsModified(ctx.User.Id)
+	if err != nil {
+		log.Error("v1.ListNotifications(GetNotificationsModified): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	etag := `"` + base.EncodeSha1(fmt.Sprintf("%d|%d|%s", ctx.User.Id, modified.UnixNano(), ctx.Req.URL.RawQuery)) + `"`
+	if checkNotModified(ctx, etag, modified) {
+		return
+	}
+
+	page, limit := getListPage(ctx, 30)
+	opts.Page = page
+	opts.PageSize = limit
+	ns, total, err := models.ListNotifications(opts)
+	if err != nil {
+		log.Error("v1.ListNotifications(ListNotifications): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+
+	results := make([]*notification, len(ns))
+	for i := range ns {
+		results[i] = toNotification(ns[i])
+	}
+	setPageHeaders(ctx, page, limit, total)
+	ctx.JSON(200, results)
+}
+
+// MarkNotificationsRead marks all notifications of signed in user as read.
+func MarkNotificationsRead(ctx *middleware.Context) {
+	if err := models.MarkAllNotificationsRead(ctx.User.Id); err != nil {
+		log.Error("v1.MarkNotificationsRead(MarkAllNotificationsRead): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	ctx.Res.WriteHeader(204)
+}
+
+// getApiNotification returns notification of signed in user by ID in params with its attributes.
+func getApiNotification(ctx *middleware.Context, params martini.Params) *models.Notification {
+	id, _ := base.StrTo(params["id"]).Int64()
+	n, err := models.GetNotificationById(ctx.User.Id, id)
+	if err == nil {
+		err = n.GetAttributes()
+	}
+	if err != nil {
+		switch err {
+		case models.ErrNotificationNotExist, models.ErrUserNotExist, models.ErrRepoNotExist, models.ErrIssueNotExist:
+			ctx.JSON(404, &base.ApiJsonErr{"notification not found", DOC_URL})
+		default:
+			log.Error("v1.getApiNotification(GetNotificationById): %v", err)
+			ctx.JSON(500, nil)
+		}
+		return nil
+	}
+	return n
+}
+
+func GetNotification(ctx *middleware.Context, params martini.Params) {
+	n := getApiNotification(ctx, params)
+	if n == nil {
+		return
+	}
+	ctx.JSON(200, toNotification(n))
+}
+
+// MarkNotificationRead marks a notification of signed in user as read.
+func MarkNotificationRead(ctx *middleware.Context, params martini.Params) {
+	n := getApiNotification(ctx, params)
+	if n == nil {
+		return
+	}
+	if !n.IsRead {
+		if err := models.MarkNotificationRead(n); err != nil {
+			log.Error("v1.MarkNotificationRead(MarkNotificationRead): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+	}
+	ctx.Res.WriteHeader(204)
+}
@@ -0,0 +1,77 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/middleware"
+	"github.com/gogits/gogs/modules/setting"
+)
+
+const MAX_LIST_LIMIT = 50
+
+// getListPage returns page number starting from 1 and page size of list request
+// given by query parameters page and limit.
+func getListPage(ctx *middleware.Context, defaultLimit int) (page, limit int) {
+	page, _ = base.StrTo(ctx.Query("page")).Int()
+	if page < 1 {
+		page = 1
+	}
+	limit, _ = base.StrTo(ctx.Query("limit")).Int()
+	if limit <= 0 || limit > MAX_LIST_LIMIT {
+		limit = defaultLimit
+	}
+	return page, limit
+}
+
+// pageRange returns range of items of given page in a list of n items.
+func pageRange(n, page, limit int) (start, end int) {
+	start = (page - 1) * limit
+	if start > n {
+		start = n
+	}
+	end = start + limit
+	if end > n {
+		end = n
+	}
+	return start, end
+}
+
+// paginate returns range of items of requested page in a list of n items,
+// and sets pagination headers of response.
+func paginate(ctx *middleware.Context, n, defaultLimit int) (start, end int) {
+	page, limit := getListPage(ctx, defaultLimit)
+	setPageHeaders(ctx, page, limit, int64(n))
+	return pageRange(n, page, limit)
+}
+
+// setPageHeaders sets X-Total-Count header of list response and Link header
+// pointing to first, previous, next and last pages of the list.
+func setPageHeaders(ctx *middleware.Context, page, limit int, total int64) {
+	ctx.Res.Header().Set("X-Total-Count", base.ToStr(total))
+
+	numPages := int((total + int64(limit) - 1) / int64(limit))
+	links := make([]string, 0, 4)
+	addLink := func(p int, rel string) {
+		query := ctx.Req.URL.Query()
+		query.Set("page", base.ToStr(p))
+		links = append(links, fmt.Sprintf(`<%s%s?%s>; rel="%s"`,
+			setting.AppUrl, strings.TrimPrefix(ctx.Req.URL.Path, "/"), query.Encode(), rel))
+	}
+	if page < numPages {
+		addLink(page+1, "next")
+		addLink(numPages, "last")
+	}
+	if page > 1 {
+		addLink(1, "first")
+		addLink(page-1, "prev")
+	}
+	if len(links) > 0 {
+		ctx.Res.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
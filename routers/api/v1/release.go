@@ -0,0 +1,539 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-martini/martini"
+	"github.com/gogits/git"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/auth/apiv1"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+	"github.com/gogits/gogs/modules/setting"
+)
+
+type releaseAsset struct {
+	Id                 int64     `json:"id"`
+	Name               string    `json:"name"`
+	Size               int64     `json:"size"`
+	DownloadCount      int64     `json:"download_count"`
+	Created            time.Time `json:"created_at"`
+	BrowserDownloadUrl string    `json:"browser_download_url"`
+}
+
+func toReleaseAsset(repo *models.Repository, a *models.Attachment) *releaseAsset {
+	return &releaseAsset{
+		Id:                 a.Id,
+		Name:               a.Name,
+		Size:               a.Size,
+		DownloadCount:      a.DownloadCount,
+		Created:            a.Created,
+		BrowserDownloadUrl: setting.AppUrl + repo.Owner.Name + "/" + repo.Name + "/releases/attachments/" + a.Sha1,
+	}
+}
+
+type release struct {
+	Id              int64           `json:"id"`
+	TagName         string          `json:"tag_name"`
+	TargetCommitish string          `json:"target_commitish"`
+	Name            string          `json:"name"`
+	Body            string          `json:"body"`
+	Draft           bool            `json:"draft"`
+	Prerelease      bool            `json:"prerelease"`
+	Author          string          `json:"author"`
+	Created         time.Time       `json:"created_at"`
+	Assets          []*releaseAsset `json:"assets"`
+	HtmlUrl         string          `json:"html_url"`
+}
+
+// toRelease returns release with its publisher and assets.
+func toRelease(repo *models.Repository, rel *models.Release) (*release, error) {
+	r := &release{
+		Id:              rel.Id,
+		TagName:         rel.TagName,
+		TargetCommitish: rel.SHA1,
+		Name:            rel.Title,
+		Body:            rel.Note,
+		Draft:           rel.IsDraft,
+		Prerelease:      rel.IsPrerelease,
+		Created:         rel.Created,
+		HtmlUrl:         setting.AppUrl + repo.Owner.Name + "/" + repo.Name + "/releases",
+	}
+
+	publisher, err := models.GetUserById(rel.PublisherId)
+	if err != nil && err != models.ErrUserNotExist {
+		return nil, err
+	} else if err == nil {
+		r.Author = publisher.Name
+	}
+
+	attachments, err := models.GetAttachmentsByReleaseId(rel.Id)
+	if err != nil {
+		return nil, err
+	}
+	r.Assets = make([]*releaseAsset, len(attachments))
+	for i := range attachments {
+		r.Assets[i] = toReleaseAsset(repo, attachments[i])
+	}
+	return r, nil
+}
+
+func renderRelease(ctx *middleware.Context, status int, repo *models.Repository, rel *models.Release) {
+	r, err := toRelease(repo, rel)
+	if err != nil {
+		log.Error("v1.renderRelease(toRelease): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	ctx.JSON(status, r)
+}
+
+// checkReleaseVisible responds with error and returns false if release does not
+// belong to repository, or it is a draft and signed in user cannot write to repository.
+func checkReleaseVisible(ctx *middleware.Context, repo *models.Repository, rel *models.Release) bool {
+	visible := rel.RepoId == repo.Id
+	if visible && rel.IsDraft {
+		canWrite, err := canWriteRepo(ctx, repo)
+		if err != nil {
+			log.Error("v1.checkReleaseVisible(canWriteRepo): %v", err)
+			ctx.JSON(500, nil)
+			return false
+		}
+		visible = canWrite
+	}
+	if !visible {
+		ctx.JSON(404, &base.ApiJsonErr{"release not found", DOC_URL})
+	}
+	return visible
+}
+
+// getApiRelease returns release of repository by ID in params.
+func getApiRelease(ctx *middleware.Context, params martini.Params, repo *models.Repository) *models.Release {
+	id, _ := base.StrTo(params["id"]).Int64()
+	rel, err := models.GetReleaseById(id)
+	if err != nil {
+		if err == models.ErrReleaseNotExist {
+			ctx.JSON(404, &base.ApiJsonErr{"release not found", DOC_URL})
+		} else {
+			log.Error("v1.getApiRelease(GetReleaseById): %v", err)
+			ctx.JSON(500, nil)
+		}
+		return nil
+	} else if !checkReleaseVisible(ctx, repo, rel) {
+		return nil
+	}
+	return rel
+}
+
+// handleReleaseError responds with error of creating or updating release.
+func handleReleaseError(ctx *middleware.Context, funcName string, err error) {
+	switch err {
+	case models.ErrReleaseAlreadyExist:
+		ctx.JSON(422, &base.ApiJsonErr{"release with this tag name already exists", DOC_URL})
+	case models.ErrRefNameIllegal:
+		ctx.JSON(422, &base.ApiJsonErr{"tag name is not valid", DOC_URL})
+	default:
+		log.Error("v1.%s: %v", funcName, err)
+		ctx.JSON(500, nil)
+	}
+}
+
+// setReleaseTarget sets commit of release that given branch, tag or commit points to.
+func setReleaseTarget(ctx *middleware.Context, gitRepo *git.Repository, rel *models.Release, target string) bool {
+	commitId, err := models.ResolveCommitId(gitRepo.Path, target)
+	if err != nil {
+		ctx.JSON(422, &base.ApiJsonErr{"target_commitish does not exist", DOC_URL})
+		return false
+	}
+	commit, err := gitRepo.GetCommit(commitId)
+	if err != nil {
+		log.Error("v1.setReleaseTarget(GetCommit): %v", err)
+		ctx.JSON(500, nil)
+		return false
+	}
+	if rel.NumCommits, err = commit.CommitsCount(); err != nil {
+		log.Error("v1.setReleaseTarget(CommitsCount): %v", err)
+		ctx.JSON(500, nil)
+		return false
+	}
+	rel.SHA1 = commitId
+	return true
+}
+
+// ListReleases lists releases of repository newest first,
+// drafts are only listed to users who can write to repository.
+func ListReleases(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+	canWrite, err := canWriteRepo(ctx, repo)
+	if err != nil {
+		log.Error("v1.ListReleases(canWriteRepo): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+
+	rels, err := models.GetReleasesByRepoId(repo.Id)
+	if err != nil {
+		log.Error("v1.ListReleases(GetReleasesByRepoId): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	visible := rels[:0]
+	for _, rel := range rels {
+		if !rel.IsDraft || canWrite {
+			visible = append(visible, rel)
+		}
+	}
+
+	start, end := paginate(ctx, len(visible), 30)
+	results := make([]*release, 0, end-start)
+	for _, rel := range visible[start:end] {
+		r, err := toRelease(repo, rel)
+		if err != nil {
+			log.Error("v1.ListReleases(toRelease): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+		results = append(results, r)
+	}
+	ctx.JSON(200, results)
+}
+
+func GetRelease(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+	rel := getApiRelease(ctx, params, repo)
+	if rel == nil {
+		return
+	}
+	renderRelease(ctx, 200, repo, rel)
+}
+
+func GetReleaseByTag(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+	rel, err := models.GetReleaseByTagName(repo.Id, params["tag"])
+	if err != nil {
+		if err == models.ErrReleaseNotExist {
+			ctx.JSON(404, &base.ApiJsonErr{"release not found", DOC_URL})
+		} else {
+			log.Error("v1.GetReleaseByTag(GetReleaseByTagName): %v", err)
+			ctx.JSON(500, nil)
+		}
+		return
+	} else if !checkReleaseVisible(ctx, repo, rel) {
+		return
+	}
+	renderRelease(ctx, 200, repo, rel)
+}
+
+// CreateRelease creates a release, its tag is created unless it is a draft.
+func CreateRelease(ctx *middleware.Context, params martini.Params, form apiv1.CreateReleaseForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	repo := getWritableGitRepo(ctx, params)
+	if repo == nil {
+		return
+	}
+	gitRepo, err := git.OpenRepository(models.RepoPath(repo.Owner.Name, repo.Name))
+	if err != nil {
+		log.Error("v1.CreateRelease(OpenRepository): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+
+	rel := &models.Release{
+		RepoId:       repo.Id,
+		PublisherId:  ctx.User.Id,
+		Title:        form.Name,
+		TagName:      form.TagName,
+		Note:         form.Body,
+		IsPrerelease: form.Prerelease,
+		IsDraft:      form.Draft,
+	}
+	target := form.TargetCommitish
+	if len(target) == 0 {
+		target = repo.DefaultBranch
+	}
+	if !setReleaseTarget(ctx, gitRepo, rel, target) {
+		return
+	}
+
+	if err = models.CreateRelease(gitRepo, rel); err != nil {
+		handleReleaseError(ctx, "CreateRelease(CreateRelease)", err)
+		return
+	}
+	log.Trace("%s Release created: %s/%s:%s", ctx.Req.RequestURI, repo.Owner.Name, repo.Name, rel.TagName)
+
+	if !rel.IsDraft {
+		if err = models.PublishReleaseAction(ctx.User, repo, rel); err != nil {
+			log.Error("v1.CreateRelease(PublishReleaseAction): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+	}
+	renderRelease(ctx, 201, repo, rel)
+}
+
+// EditRelease updates a release, publishing a draft creates its tag.
+func EditRelease(ctx *middleware.Context, params martini.Params, form apiv1.EditReleaseForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	repo := getWritableGitRepo(ctx, params)
+	if repo == nil {
+		return
+	}
+	rel := getApiRelease(ctx, params, repo)
+	if rel == nil {
+		return
+	}
+	gitRepo, err := git.OpenRepository(models.RepoPath(repo.Owner.Name, repo.Name))
+	if err != nil {
+		log.Error("v1.EditRelease(OpenRepository): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+
+	// Tag of published release cannot be changed.
+	wasDraft := rel.IsDraft
+	if rel.IsDraft {
+		if form.TagName != nil {
+			rel.TagName = *form.TagName
+		}
+		if form.TargetCommitish != nil && !setReleaseTarget(ctx, gitRepo, rel, *form.TargetCommitish) {
+			return
+		}
+		if form.Draft != nil {
+			rel.IsDraft = *form.Draft
+		}
+	} else if (form.TagName != nil && *form.TagName != rel.TagName) || form.TargetCommitish != nil {
+		ctx.JSON(422, &base.ApiJsonErr{"tag of published release cannot be changed", DOC_URL})
+		return
+	} else if form.Draft != nil && *form.Draft {
+		ctx.JSON(422, &base.ApiJsonErr{"published release cannot be made a draft", DOC_URL})
+		return
+	}
+	if form.Name != nil {
+		if len(*form.Name) == 0 {
+			ctx.JSON(422, &base.ApiJsonErr{"name cannot be empty", DOC_URL})
+			return
+		}
+		rel.Title = *form.Name
+	}
+	if form.Body != nil {
+		rel.Note = *form.Body
+	}
+	if form.Prerelease != nil {
+		rel.IsPrerelease = *form.Prerelease
+	}
+
+	if err = models.UpdateRelease(gitRepo, rel); err != nil {
+		handleReleaseError(ctx, "EditRelease(UpdateRelease)", err)
+		return
+	}
+	log.Trace("%s Release updated: %s/%s:%s", ctx.Req.RequestURI, repo.Owner.Name, repo.Name, rel.TagName)
+
+	if wasDraft && !rel.IsDraft {
+		if err = models.PublishReleaseAction(ctx.User, repo, rel); err != nil {
+			log.Error("v1.EditRelease(PublishReleaseAction): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+	}
+	renderRelease(ctx, 200, repo, rel)
+}
+
+// DeleteRelease deletes a release and its assets, its tag is kept.
+func DeleteRelease(ctx *middleware.Context, params martini.Params) {
+	repo := getWritableGitRepo(ctx, params)
+	if repo == nil {
+		return
+	}
+	rel := getApiRelease(ctx, params, repo)
+	if rel == nil {
+		return
+	}
+
+	if err := models.DeleteRelease(rel); err != nil {
+		log.Error("v1.DeleteRelease(DeleteRelease): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	log.Trace("%s Release deleted: %s/%s:%s", ctx.Req.RequestURI, repo.Owner.Name, repo.Name, rel.TagName)
+	ctx.Res.WriteHeader(204)
+}
+
+func ListReleaseAssets(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+	rel := getApiRelease(ctx, params, repo)
+	if rel == nil {
+		return
+	}
+
+	attachments, err := models.GetAttachmentsByReleaseId(rel.Id)
+	if err != nil {
+		log.Error("v1.ListReleaseAssets(GetAttachmentsByReleaseId): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	results := make([]*releaseAsset, len(attachments))
+	for i := range attachments {
+		results[i] = toReleaseAsset(repo, attachments[i])
+	}
+	ctx.JSON(200, results)
+}
+
+// getApiReleaseAsset returns asset of release by ID in params.
+func getApiReleaseAsset(ctx *middleware.Context, params martini.Params, repo *models.Repository) *models.Attachment {
+	rel := getApiRelease(ctx, params, repo)
+	if rel == nil {
+		return nil
+	}
+	attachments, err := models.GetAttachmentsByReleaseId(rel.Id)
+	if err != nil {
+		log.Error("v1.getApiReleaseAsset(GetAttachmentsByReleaseId): %v", err)
+		ctx.JSON(500, nil)
+		return nil
+	}
+	id, _ := base.StrTo(params["asset"]).Int64()
+	for _, a := range attachments {
+		if a.Id == id {
+			return a
+		}
+	}
+	ctx.JSON(404, &base.ApiJsonErr{"asset not found", DOC_URL})
+	return nil
+}
+
+func GetReleaseAsset(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+	a := getApiReleaseAsset(ctx, params, repo)
+	if a == nil {
+		return
+	}
+	ctx.JSON(200, toReleaseAsset(repo, a))
+}
+
+// DownloadReleaseAsset streams content of asset.
+func DownloadReleaseAsset(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+	a := getApiReleaseAsset(ctx, params, repo)
+	if a == nil {
+		return
+	}
+
+	if err := models.IncreaseAttachmentDownloadCount(a); err != nil {
+		log.Error("v1.DownloadReleaseAsset(IncreaseAttachmentDownloadCount): %v", err)
+	}
+	ctx.ServeFile(a.LocalPath(), a.Name)
+}
+
+// UploadReleaseAsset uploads an asset to release, file is given either as field attachment
+// of multipart form, or as raw request body whose file name is given by query parameter name.
+func UploadReleaseAsset(ctx *middleware.Context, params martini.Params) {
+	repo := getWritableGitRepo(ctx, params)
+	if repo == nil {
+		return
+	}
+	rel := getApiRelease(ctx, params, repo)
+	if rel == nil {
+		return
+	}
+	if !setting.AttachmentEnabled {
+		ctx.JSON(403, &base.ApiJsonErr{"release attachments are disabled", DOC_URL})
+		return
+	}
+
+	attachments, err := models.GetAttachmentsByReleaseId(rel.Id)
+	if err != nil {
+		log.Error("v1.UploadReleaseAsset(GetAttachmentsByReleaseId): %v", err)
+		ctx.JSON(500, nil)
+		return
+	} else if len(attachments) >= setting.AttachmentMaxFiles {
+		ctx.JSON(422, &base.ApiJsonErr{fmt.Sprintf("release cannot have more than %d assets",
+			setting.AttachmentMaxFiles), DOC_URL})
+		return
+	}
+
+	name := ctx.Query("name")
+	var r io.Reader = ctx.Req.Body
+	if strings.HasPrefix(ctx.Req.Header.Get("Content-Type"), "multipart/form-data") {
+		f, fh, err := ctx.Req.FormFile("attachment")
+		if err != nil {
+			ctx.JSON(422, &base.ApiJsonErr{"missing file: attachment", DOC_URL})
+			return
+		}
+		defer f.Close()
+		if len(name) == 0 {
+			name = fh.Filename
+		}
+		r = f
+	}
+	name = filepath.Base(name)
+	if len(name) == 0 || name == "." || name == "/" {
+		ctx.JSON(422, &base.ApiJsonErr{"missing parameter: name", DOC_URL})
+		return
+	}
+
+	a, err := models.NewAttachment(rel.Id, name, r)
+	if err != nil {
+		if err == models.ErrAttachmentTooLarge {
+			ctx.JSON(413, &base.ApiJsonErr{fmt.Sprintf("asset cannot be larger than %d MB",
+				setting.AttachmentMaxSize), DOC_URL})
+		} else {
+			log.Error("v1.UploadReleaseAsset(NewAttachment): %v", err)
+			ctx.JSON(500, nil)
+		}
+		return
+	}
+	log.Trace("%s Release asset uploaded: %s/%s:%s -> %s", ctx.Req.RequestURI, repo.Owner.Name, repo.Name, rel.TagName, a.Name)
+	ctx.JSON(201, toReleaseAsset(repo, a))
+}
+
+func DeleteReleaseAsset(ctx *middleware.Context, params martini.Params) {
+	repo := getWritableGitRepo(ctx, params)
+	if repo == nil {
+		return
+	}
+	a := getApiReleaseAsset(ctx, params, repo)
+	if a == nil {
+		return
+	}
+
+	if err := models.DeleteAttachment(a); err != nil {
+		log.Error("v1.DeleteReleaseAsset(DeleteAttachment): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	log.Trace("%s Release asset deleted: %s/%s -> %s", ctx.Req.RequestURI, repo.Owner.Name, repo.Name, a.Name)
+	ctx.Res.WriteHeader(204)
+}
@@ -0,0 +1,505 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Unknwon/com"
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/git"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/auth/apiv1"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+	"github.com/gogits/gogs/modules/setting"
+)
+
+// getApiRepo returns repository in URL if current user can access it in given mode,
+// otherwise it responds with error and returns nil.
+func getApiRepo(ctx *middleware.Context, params martini.Params, mode int) *models.Repository {
+	owner, err := models.GetUserByName(params["username"])
+	if err != nil {
+		if err == models.ErrUserNotExist {
+			ctx.JSON(404, &base.ApiJsonErr{"repository not found", DOC_URL})
+		} else {
+			ctx.JSON(500, nil)
+		}
+		return nil
+	}
+	repo, err := models.GetRepositoryByName(owner.Id, params["reponame"])
+	if err != nil {
+		if err == models.ErrRepoNotExist {
+			ctx.JSON(404, &base.ApiJsonErr{"repository not found", DOC_URL})
+		} else {
+			ctx.JSON(500, nil)
+		}
+		return nil
+	}
+	repo.Owner = owner
+
+	if mode == models.AU_READABLE && !repo.IsPrivate {
+		return repo
+	} else if !ctx.IsSigned {
+		ctx.JSON(401, &base.ApiJsonErr{"authentication required", DOC_URL})
+		return nil
+	}
+	accessMode, err := models.GetAccessMode(ctx.User.Name, owner.Name+"/"+repo.Name)
+	if err != nil {
+		ctx.JSON(500, nil)
+		return nil
+	}
+	// Owner and site administrators can always manage repository.
+	if repo.OwnerId == ctx.User.Id || ctx.User.IsAdmin {
+		accessMode = models.AU_ADMIN
+	}
+	if accessMode < mode {
+		// Private repository is invisible to users without access.
+		if accessMode == 0 && repo.IsPrivate {
+			ctx.JSON(404, &base.ApiJsonErr{"repository not found", DOC_URL})
+		} else {
+			ctx.JSON(403, &base.ApiJsonErr{"no " + strings.ToLower(models.AccessModeName(mode)) + " access to repository", DOC_URL})
+		}
+		return nil
+	}
+	return repo
+}
+
+type commitStatus struct {
+	Id          int64     `json:"id"`
+	State       string    `json:"state"`
+	TargetUrl   string    `json:"target_url"`
+	Description string    `json:"description"`
+	Context     string    `json:"context"`
+	Creator     string    `json:"creator"`
+	Created     time.Time `json:"created_at"`
+}
+
+func toCommitStatus(s *models.CommitStatus) *commitStatus {
+	cs := &commitStatus{
+		Id:          s.Id,
+		State:       s.State,
+		TargetUrl:   s.TargetUrl,
+		Description: s.Description,
+		Context:     s.Context,
+		Created:     s.Created,
+	}
+	if s.Creator != nil {
+		cs.Creator = s.Creator.Name
+	}
+	return cs
+}
+
+func CreateCommitStatus(ctx *middleware.Context, params martini.Params, form apiv1.CreateCommitStatusForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	repo := getApiRepo(ctx, params, models.AU_WRITABLE)
+	if repo == nil {
+		return
+	}
+
+	status := &models.CommitStatus{
+		State:       form.State,
+		TargetUrl:   form.TargetUrl,
+		Description: form.Description,
+		Context:     form.Context,
+	}
+	if err := models.NewCommitStatus(repo, ctx.User, params["sha"], status); err != nil {
+		switch err {
+		case models.ErrCommitStatusStateIllegal:
+			ctx.JSON(422, &base.ApiJsonErr{"state must be one of pending, success, error and failure", DOC_URL})
+		case models.ErrRevisionNotExist:
+			ctx.JSON(404, &base.ApiJsonErr{"commit not found", DOC_URL})
+		default:
+			log.Error("v1.CreateCommitStatus(NewCommitStatus): %v", err)
+			ctx.JSON(500, nil)
+		}
+		return
+	}
+	ctx.JSON(201, toCommitStatus(status))
+}
+
+// loadCommitStatuses returns resolved commit ID in URL and its statuses with creators,
+// only latest status of every context is returned if latest is true.
+func loadCommitStatuses(ctx *middleware.Context, params martini.Params, latest bool) (string, []*models.CommitStatus) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return "", nil
+	}
+
+	sha, err := models.ResolveCommitId(models.RepoPath(repo.Owner.Name, repo.Name), params["sha"])
+	if err != nil {
+		if err == models.ErrRevisionNotExist {
+			ctx.JSON(404, &base.ApiJsonErr{"commit not found", DOC_URL})
+		} else {
+			ctx.JSON(500, nil)
+		}
+		return "", nil
+	}
+
+	var statuses []*models.CommitStatus
+	if latest {
+		statuses, err = models.GetLatestCommitStatuses(repo.Id, sha)
+	} else {
+		statuses, err = models.GetCommitStatuses(repo.Id, sha)
+	}
+	if err != nil {
+		ctx.JSON(500, nil)
+		return "", nil
+	}
+	for _, s := range statuses {
+		if err = s.GetCreator(); err != nil && err != models.ErrUserNotExist {
+			ctx.JSON(500, nil)
+			return "", nil
+		}
+	}
+	return sha, statuses
+}
+
+func toCommitStatuses(statuses []*models.CommitStatus) []*commitStatus {
+	results := make([]*commitStatus, len(statuses))
+	for i := range statuses {
+		results[i] = toCommitStatus(statuses[i])
+	}
+	return results
+}
+
+func ListCommitStatuses(ctx *middleware.Context, params martini.Params) {
+	sha, statuses := loadCommitStatuses(ctx, params, false)
+	if len(sha) == 0 {
+		return
+	}
+	start, end := paginate(ctx, len(statuses), 30)
+	ctx.JSON(200, toCommitStatuses(statuses[start:end]))
+}
+
+func GetCombinedCommitStatus(ctx *middleware.Context, params martini.Params) {
+	sha, statuses := loadCommitStatuses(ctx, params, true)
+	if len(sha) == 0 {
+		return
+	}
+	ctx.JSON(200, map[string]interface{}{
+		"sha":      sha,
+		"state":    models.CombineCommitStatuses(statuses),
+		"statuses": toCommitStatuses(statuses),
+	})
+}
+
+var illegalRepoNamePattern = regexp.MustCompile("[^\\d\\w-_\\.]")
+
+type repository struct {
+	Id            int64     `json:"id"`
+	Owner         string    `json:"owner"`
+	Name          string    `json:"name"`
+	FullName      string    `json:"full_name"`
+	Description   string    `json:"description"`
+	Website       string    `json:"website"`
+	Private       bool      `json:"private"`
+	Fork          bool      `json:"fork"`
+	Mirror        bool      `json:"mirror"`
+	Archived      bool      `json:"archived"`
+	Template      bool      `json:"template"`
+	DefaultBranch string    `json:"default_branch"`
+	Size          int64     `json:"size"`
+	Stars         int       `json:"stars_count"`
+	Forks         int       `json:"forks_count"`
+	Watchers      int       `json:"watchers_count"`
+	OpenIssues    int       `json:"open_issues_count"`
+	Topics        []string  `json:"topics"`
+	HtmlUrl       string    `json:"html_url"`
+	CloneUrl      string    `json:"clone_url"`
+	SshUrl        string    `json:"ssh_url"`
+	Created       time.Time `json:"created_at"`
+	Updated       time.Time `json:"updated_at"`
+}
+
+// toRepository converts repository with its owner loaded to API format.
+func toRepository(repo *models.Repository) (*repository, error) {
+	if err := repo.GetTopics(); err != nil {
+		return nil, err
+	}
+
+	ownerName := repo.Owner.LowerName
+	r := &repository{
+		Id:            repo.Id,
+		Owner:         repo.Owner.Name,
+		Name:          repo.Name,
+		FullName:      repo.Owner.Name + "/" + repo.Name,
+		Description:   repo.Description,
+		Website:       repo.Website,
+		Private:       repo.IsPrivate,
+		Fork:          repo.IsFork,
+		Mirror:        repo.IsMirror,
+		Archived:      repo.IsArchived,
+		Template:      repo.IsTemplate,
+		DefaultBranch: repo.DefaultBranch,
+		Size:          repo.Size,
+		Stars:         repo.NumStars,
+		Forks:         repo.NumForks,
+		Watchers:      repo.NumWatches,
+		OpenIssues:    repo.NumIssues - repo.NumClosedIssues,
+		Topics:        repo.Topics,
+		HtmlUrl:       setting.AppUrl + repo.Owner.Name + "/" + repo.Name,
+		CloneUrl:      fmt.Sprintf("%s%s/%s.git", setting.AppUrl, ownerName, repo.LowerName),
+		Created:       repo.Created,
+		Updated:       repo.Updated,
+	}
+	if setting.SshPort != 22 {
+		r.SshUrl = fmt.Sprintf("ssh://%s@%s/%s/%s.git", setting.RunUser, setting.Domain, ownerName, repo.LowerName)
+	} else {
+		r.SshUrl = fmt.Sprintf("%s@%s:%s/%s.git", setting.RunUser, setting.Domain, ownerName, repo.LowerName)
+	}
+	return r, nil
+}
+
+// renderRepositories responds with given page of repositories and pagination headers.
+func renderRepositories(ctx *middleware.Context, repos []*models.Repository) {
+	start, end := paginate(ctx, len(repos), 20)
+	results := make([]*repository, 0, end-start)
+	for _, repo := range repos[start:end] {
+		if repo.Owner == nil {
+			if err := repo.GetOwner(); err != nil {
+				log.Error("v1.renderRepositories(GetOwner): %v", err)
+				ctx.JSON(500, nil)
+				return
+			}
+		}
+		r, err := toRepository(repo)
+		if err != nil {
+			log.Error("v1.renderRepositories(toRepository): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+		results = append(results, r)
+	}
+	renderCacheableJSON(ctx, results, time.Time{})
+}
+
+// ListMyRepos lists repositories that signed in user owns or collaborates on.
+func ListMyRepos(ctx *middleware.Context) {
+	repos, err := models.GetRepositories(ctx.User.Id, true)
+	if err != nil {
+		log.Error("v1.ListMyRepos(GetRepositories): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	for _, repo := range repos {
+		repo.Owner = ctx.User
+	}
+	collaborative, err := models.GetCollaborativeRepos(ctx.User.Name)
+	if err != nil {
+		log.Error("v1.ListMyRepos(GetCollaborativeRepos): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	renderRepositories(ctx, append(repos, collaborative...))
+}
+
+// ListUserRepos lists repositories of given user, private ones are only listed
+// for the user and site administrators.
+func ListUserRepos(ctx *middleware.Context, params martini.Params) {
+	u, err := models.GetUserByName(params["username"])
+	if err != nil {
+		if err == models.ErrUserNotExist {
+			ctx.JSON(404, &base.ApiJsonErr{"user not found", DOC_URL})
+		} else {
+			ctx.JSON(500, nil)
+		}
+		return
+	}
+
+	showPrivate := ctx.IsSigned && (ctx.User.Id == u.Id || ctx.User.IsAdmin)
+	repos, err := models.GetRepositories(u.Id, showPrivate)
+	if err != nil {
+		log.Error("v1.ListUserRepos(GetRepositories): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	for _, repo := range repos {
+		repo.Owner = u
+	}
+	renderRepositories(ctx, repos)
+}
+
+// CreateRepo creates repository for signed in user.
+func CreateRepo(ctx *middleware.Context, form apiv1.CreateRepoForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	createRepo(ctx, ctx.User, form)
+}
+
+// createRepo creates a repository for given owner.
+func createRepo(ctx *middleware.Context, owner *models.User, form apiv1.CreateRepoForm) {
+	if len(form.Gitignore) > 0 && !com.IsSliceContainsStr(models.LanguageIgns, form.Gitignore) {
+		ctx.JSON(422, &base.ApiJsonErr{"gitignore template does not exist", DOC_URL})
+		return
+	} else if len(form.License) > 0 && !com.IsSliceContainsStr(models.Licenses, form.License) {
+		ctx.JSON(422, &base.ApiJsonErr{"license template does not exist", DOC_URL})
+		return
+	}
+
+	repo, err := models.CreateRepository(owner, form.Name, form.Description,
+		form.Gitignore, form.License, form.Private, false, form.AutoInit)
+	if err != nil {
+		switch err {
+		case models.ErrRepoAlreadyExist:
+			ctx.JSON(422, &base.ApiJsonErr{"repository name has already been used", DOC_URL})
+			return
+		case models.ErrRepoNameIllegal:
+			ctx.JSON(422, &base.ApiJsonErr{"repository name is not allowed", DOC_URL})
+			return
+		}
+		if repo != nil {
+			if errDelete := models.DeleteRepository(owner.Id, repo.Id, owner.Name); errDelete != nil {
+				log.Error("v1.createRepo(DeleteRepository): %v", errDelete)
+			}
+		}
+		log.Error("v1.createRepo(CreateRepository): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	log.Trace("%s Repository created: %s/%s", ctx.Req.RequestURI, owner.LowerName, repo.LowerName)
+
+	repo.Owner = owner
+	r, err := toRepository(repo)
+	if err != nil {
+		log.Error("v1.createRepo(toRepository): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	ctx.JSON(201, r)
+}
+
+func GetRepo(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_READABLE)
+	if repo == nil {
+		return
+	}
+	r, err := toRepository(repo)
+	if err != nil {
+		log.Error("v1.GetRepo(toRepository): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	renderCacheableJSON(ctx, r, repo.Updated)
+}
+
+// EditRepo updates options of repository, only owner and site administrators
+// can archive or unarchive it.
+func EditRepo(ctx *middleware.Context, params martini.Params, form apiv1.EditRepoForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	repo := getApiRepo(ctx, params, models.AU_ADMIN)
+	if repo == nil {
+		return
+	}
+
+	if form.Archived != nil && *form.Archived != repo.IsArchived {
+		if repo.OwnerId != ctx.User.Id && !ctx.User.IsAdmin {
+			ctx.JSON(403, &base.ApiJsonErr{"only owner can change archive state of repository", DOC_URL})
+			return
+		}
+		repo.IsArchived = *form.Archived
+	}
+	if form.Description != nil {
+		if len(*form.Description) > 255 {
+			ctx.JSON(422, &base.ApiJsonErr{"description must contain at most 255 characters", DOC_URL})
+			return
+		}
+		repo.Description = *form.Description
+	}
+	if form.Website != nil {
+		if len(*form.Website) > 255 {
+			ctx.JSON(422, &base.ApiJsonErr{"website must contain at most 255 characters", DOC_URL})
+			return
+		}
+		repo.Website = *form.Website
+	}
+	if form.Private != nil {
+		repo.IsPrivate = *form.Private
+	}
+	if form.DefaultBranch != nil && *form.DefaultBranch != repo.DefaultBranch {
+		gitRepo, err := git.OpenRepository(models.RepoPath(repo.Owner.Name, repo.Name))
+		if err != nil {
+			log.Error("v1.EditRepo(OpenRepository): %v", err)
+			ctx.JSON(500, nil)
+			return
+		} else if !gitRepo.IsBranchExist(*form.DefaultBranch) {
+			ctx.JSON(422, &base.ApiJsonErr{"default branch does not exist", DOC_URL})
+			return
+		}
+		repo.DefaultBranch = *form.DefaultBranch
+	}
+
+	if form.Name != nil && *form.Name != repo.Name {
+		newName := *form.Name
+		if len(newName) == 0 || len(newName) > 100 ||
+			illegalRepoNamePattern.MatchString(newName) || !models.IsLegalName(newName) {
+			ctx.JSON(422, &base.ApiJsonErr{"repository name is not allowed", DOC_URL})
+			return
+		}
+		isExist, err := models.IsRepositoryExist(repo.Owner, newName)
+		if err != nil {
+			log.Error("v1.EditRepo(IsRepositoryExist): %v", err)
+			ctx.JSON(500, nil)
+			return
+		} else if isExist {
+			ctx.JSON(422, &base.ApiJsonErr{"repository name has already been used", DOC_URL})
+			return
+		} else if err = models.ChangeRepositoryName(repo.Owner.Name, repo.Name, newName); err != nil {
+			log.Error("v1.EditRepo(ChangeRepositoryName): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+		log.Trace("%s Repository name changed: %s/%s -> %s", ctx.Req.RequestURI, repo.Owner.Name, repo.Name, newName)
+		repo.Name = newName
+	}
+
+	if err := models.UpdateRepository(repo); err != nil {
+		log.Error("v1.EditRepo(UpdateRepository): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	log.Trace("%s Repository updated: %s/%s", ctx.Req.RequestURI, repo.Owner.Name, repo.Name)
+
+	r, err := toRepository(repo)
+	if err != nil {
+		log.Error("v1.EditRepo(toRepository): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	ctx.JSON(200, r)
+}
+
+// DeleteRepo deletes repository, collaborators cannot delete it even with admin access.
+func DeleteRepo(ctx *middleware.Context, params martini.Params) {
+	repo := getApiRepo(ctx, params, models.AU_ADMIN)
+	if repo == nil {
+		return
+	} else if repo.OwnerId != ctx.User.Id && !ctx.User.IsAdmin {
+		ctx.JSON(403, &base.ApiJsonErr{"only owner can delete repository", DOC_URL})
+		return
+	}
+
+	if err := models.DeleteRepository(repo.OwnerId, repo.Id, repo.Owner.LowerName); err != nil {
+		log.Error("v1.DeleteRepo(DeleteRepository): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	log.Trace("%s Repository deleted: %s/%s", ctx.Req.RequestURI, repo.Owner.LowerName, repo.LowerName)
+	ctx.Res.WriteHeader(204)
+}
@@ -0,0 +1,195 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+// privateReader returns signed in user when access token in use, if any, is granted
+// to read private repositories, or nil so that only public data is shown.
+func privateReader(ctx *middleware.Context) *models.User {
+	if !ctx.IsSigned || (ctx.AccessToken != nil && !ctx.AccessToken.HasScope(models.SCOPE_REPO_READ)) {
+		return nil
+	}
+	return ctx.User
+}
+
+// getSearchOwner returns user by query parameter owner, or nil if it is not given.
+// It responds with error and returns false if the user does not exist.
+func getSearchOwner(ctx *middleware.Context) (*models.User, bool) {
+	name := ctx.Query("owner")
+	if len(name) == 0 {
+		return nil, true
+	}
+	owner, err := models.GetUserByName(name)
+	if err != nil {
+		if err == models.ErrUserNotExist {
+			ctx.JSON(404, &base.ApiJsonErr{"owner not found", DOC_URL})
+		} else {
+			log.Error("v1.getSearchOwner(GetUserByName): %v", err)
+			ctx.JSON(500, nil)
+		}
+		return nil, false
+	}
+	return owner, true
+}
+
+// SearchRepos searches repositories that are visible to signed in user by keyword
+// in name or description, and filters by owner, topic or language of code.
+func SearchRepos(ctx *middleware.Context) {
+	owner, ok := getSearchOwner(ctx)
+	if !ok {
+		return
+	}
+	page, limit := getListPage(ctx, 20)
+
+	opts := &models.SearchRepoOptions{
+		Keyword:  ctx.Query("q"),
+		Topic:    ctx.Query("topic"),
+		Viewer:   privateReader(ctx),
+		Page:     page,
+		PageSize: limit,
+	}
+	if owner != nil {
+		opts.OwnerId = owner.Id
+	}
+	if lang := ctx.Query("language"); len(lang) > 0 {
+		ids, err := models.GetRepoIdsByLanguage(lang)
+		if err != nil {
+			if err == models.ErrRepoIndexerDisabled {
+				ctx.JSON(422, &base.ApiJsonErr{"language filter requires code search to be enabled", DOC_URL})
+			} else {
+				log.Error("v1.SearchRepos(GetRepoIdsByLanguage): %v", err)
+				ctx.JSON(500, nil)
+			}
+			return
+		}
+		opts.RepoIds = ids
+	}
+
+	repos, total, err := models.SearchRepositories(opts)
+	if err != nil {
+		log.Error("v1.SearchRepos(SearchRepositories): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	results := make([]*repository, len(repos))
+	for i := range repos {
+		if results[i], err = toRepository(repos[i]); err != nil {
+			log.Error("v1.SearchRepos(toRepository): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+	}
+	setPageHeaders(ctx, page, limit, total)
+	ctx.JSON(200, results)
+}
+
+// SearchUsers searches users by keyword in user name or full name.
+func SearchUsers(ctx *middleware.Context) {
+	page, limit := getListPage(ctx, 20)
+	us, total, err := models.SearchUsers(&models.SearchUserOptions{
+		Keyword:  ctx.Query("q"),
+		Page:     page,
+		PageSize: limit,
+	})
+	if err != nil {
+		log.Error("v1.SearchUsers(SearchUsers): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	results := make([]*userProfile, len(us))
+	for i := range us {
+		results[i] = toUserProfile(ctx, us[i])
+	}
+	setPageHeaders(ctx, page, limit, total)
+	ctx.JSON(200, results)
+}
+
+type codeLine struct {
+	Num     int    `json:"num"`
+	Content string `json:"content"`
+}
+
+type codeResult struct {
+	Repo     string      `json:"repo"`
+	CommitId string      `json:"commit_id"`
+	Path     string      `json:"path"`
+	Language string      `json:"language"`
+	Lines    []*codeLine `json:"lines"`
+}
+
+// SearchCode searches code of repositories that are visible to signed in user,
+// and filters by language, repository or owner.
+func SearchCode(ctx *middleware.Context) {
+	q := ctx.Query("q")
+	if len(q) == 0 {
+		ctx.JSON(422, &base.ApiJsonErr{"missing parameter: q", DOC_URL})
+		return
+	}
+	owner, ok := getSearchOwner(ctx)
+	if !ok {
+		return
+	}
+	page, limit := getListPage(ctx, 10)
+
+	repoIds, err := models.GetSearchableRepoIds(privateReader(ctx), ctx.Query("repo"))
+	if err != nil {
+		if err == models.ErrRepoNotExist {
+			ctx.JSON(404, &base.ApiJsonErr{"repository not found", DOC_URL})
+		} else {
+			ctx.JSON(500, nil)
+		}
+		return
+	}
+	if owner != nil {
+		if repoIds, err = models.FilterRepoIdsByOwner(repoIds, owner.Id); err != nil {
+			log.Error("v1.SearchCode(FilterRepoIdsByOwner): %v", err)
+			ctx.JSON(500, nil)
+			return
+		}
+	}
+
+	rs, total, err := models.SearchCode(&models.SearchCodeOptions{
+		Keyword:  q,
+		Language: ctx.Query("language"),
+		RepoIds:  repoIds,
+		Page:     page,
+		PageSize: limit,
+	})
+	if err != nil {
+		if err == models.ErrRepoIndexerDisabled {
+			ctx.JSON(404, &base.ApiJsonErr{"code search is disabled", DOC_URL})
+		} else {
+			ctx.JSON(500, nil)
+		}
+		return
+	}
+
+	results := make([]*codeResult, len(rs))
+	for i, r := range rs {
+		results[i] = &codeResult{
+			Repo:     r.Repo.Owner.Name + "/" + r.Repo.Name,
+			CommitId: r.CommitId,
+			Path:     r.Path,
+			Language: r.Language,
+			Lines:    make([]*codeLine, len(r.Lines)),
+		}
+		for j, l := range r.Lines {
+			results[i].Lines[j] = &codeLine{l.Num, l.Content}
+		}
+	}
+
+	setPageHeaders(ctx, page, limit, int64(total))
+	ctx.Render.JSON(200, map[string]interface{}{
+		"ok":    true,
+		"total": total,
+		"data":  results,
+	})
+}
@@ -0,0 +1,182 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/auth/apiv1"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+type publicKey struct {
+	Id          int64      `json:"id"`
+	Title       string     `json:"title,omitempty"`
+	Key         string     `json:"key"`
+	Fingerprint string     `json:"fingerprint,omitempty"`
+	Created     *time.Time `json:"created_at,omitempty"`
+	Expires     *time.Time `json:"expires_at,omitempty"`
+}
+
+func toPublicKey(key *models.PublicKey) *publicKey {
+	k := &publicKey{key.Id, key.Name, key.Content, key.Fingerprint, &key.Created, nil}
+	if key.HasExpiration() {
+		k.Expires = &key.Expires
+	}
+	return k
+}
+
+// ListUserKeys lists public keys of user, only key contents are given
+// so that they can be used to grant access elsewhere.
+func ListUserKeys(ctx *middleware.Context, params martini.Params) {
+	u := getApiUser(ctx, params)
+	if u == nil {
+		return
+	}
+
+	keys, err := models.ListPublicKey(u.Id)
+	if err != nil {
+		log.Error("v1.ListUserKeys(ListPublicKey): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	results := make([]*publicKey, 0, len(keys))
+	for _, key := range keys {
+		if key.IsExpired() {
+			continue
+		}
+		results = append(results, &publicKey{Id: key.Id, Key: key.Content})
+	}
+	start, end := paginate(ctx, len(results), 30)
+	ctx.JSON(200, results[start:end])
+}
+
+// listPublicKeys lists public keys of given user with full details.
+func listPublicKeys(ctx *middleware.Context, u *models.User) {
+	keys, err := models.ListPublicKey(u.Id)
+	if err != nil {
+		log.Error("v1.listPublicKeys(ListPublicKey): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	results := make([]*publicKey, len(keys))
+	for i := range keys {
+		results[i] = toPublicKey(&keys[i])
+	}
+	start, end := paginate(ctx, len(results), 30)
+	ctx.JSON(200, results[start:end])
+}
+
+// ListMyKeys lists public keys of signed in user.
+func ListMyKeys(ctx *middleware.Context) {
+	listPublicKeys(ctx, ctx.User)
+}
+
+// getApiKey returns public key of given user by ID in params.
+func getApiKey(ctx *middleware.Context, params martini.Params, u *models.User) *models.PublicKey {
+	id, _ := base.StrTo(params["id"]).Int64()
+	key, err := models.GetPublicKeyById(id)
+	if err != nil || key.OwnerId != u.Id {
+		if err != nil && err != models.ErrKeyNotExist {
+			log.Error("v1.getApiKey(GetPublicKeyById): %v", err)
+			ctx.JSON(500, nil)
+		} else {
+			ctx.JSON(404, &base.ApiJsonErr{"public key not found", DOC_URL})
+		}
+		return nil
+	}
+	return key
+}
+
+func GetMyKey(ctx *middleware.Context, params martini.Params) {
+	key := getApiKey(ctx, params, ctx.User)
+	if key == nil {
+		return
+	}
+	ctx.JSON(200, toPublicKey(key))
+}
+
+func AddMyKey(ctx *middleware.Context, form apiv1.AddPublicKeyForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+	addPublicKey(ctx, ctx.User, form)
+}
+
+// addPublicKey adds a public key to given user, the change is audited
+// as done by signed in user.
+func addPublicKey(ctx *middleware.Context, u *models.User, form apiv1.AddPublicKeyForm) {
+	content := strings.TrimSpace(form.Key)
+	if len(content) < 100 || !strings.HasPrefix(content, "ssh-rsa") || strings.ContainsAny(content, "\r\n") {
+		ctx.JSON(422, &base.ApiJsonErr{"SSH key content is not valid", DOC_URL})
+		return
+	}
+
+	key := &models.PublicKey{
+		OwnerId: u.Id,
+		Name:    form.Title,
+		Content: content,
+	}
+	if len(form.Expires) > 0 {
+		expires, err := time.ParseInLocation("2006-01-02", form.Expires, time.Local)
+		if err != nil || expires.Before(time.Now()) {
+			ctx.JSON(422, &base.ApiJsonErr{"expires must be a future date in form of YYYY-MM-DD", DOC_URL})
+			return
+		}
+		key.Expires = expires
+	}
+	if err := models.AddPublicKey(key); err != nil {
+		if err == models.ErrKeyAlreadyExist {
+			ctx.JSON(422, &base.ApiJsonErr{"public key title or content has been used", DOC_URL})
+			return
+		}
+		log.Error("v1.addPublicKey(AddPublicKey): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+
+	log.Trace("%s User SSH key added: %s", ctx.Req.RequestURI, u.LowerName)
+	detail := fmt.Sprintf("%s (%s)", key.Name, key.Fingerprint)
+	if u.Id != ctx.User.Id {
+		detail = u.Name + ": " + detail
+	}
+	models.RecordAudit(ctx.User.Id, ctx.User.Name, models.AUDIT_ADD_SSH_KEY, ctx.RemoteAddr(), detail)
+	ctx.JSON(201, toPublicKey(key))
+}
+
+func DeleteMyKey(ctx *middleware.Context, params martini.Params) {
+	deletePublicKey(ctx, params, ctx.User)
+}
+
+// deletePublicKey deletes public key of given user by ID in params,
+// the change is audited as done by signed in user.
+func deletePublicKey(ctx *middleware.Context, params martini.Params, u *models.User) {
+	key := getApiKey(ctx, params, u)
+	if key == nil {
+		return
+	}
+
+	if err := models.DeletePublicKey(key); err != nil {
+		log.Error("v1.deletePublicKey(DeletePublicKey): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+
+	log.Trace("%s User SSH key deleted: %s", ctx.Req.RequestURI, u.LowerName)
+	detail := fmt.Sprintf("Key ID: %d", key.Id)
+	if u.Id != ctx.User.Id {
+		detail = u.Name + ": " + detail
+	}
+	models.RecordAudit(ctx.User.Id, ctx.User.Name, models.AUDIT_DELETE_SSH_KEY, ctx.RemoteAddr(), detail)
+	ctx.Res.WriteHeader(204)
+}
@@ -5,8 +5,15 @@
 package v1
 
 import (
+	"regexp"
+	"time"
+
+	"github.com/go-martini/martini"
+
 	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/auth/apiv1"
 	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
 	"github.com/gogits/gogs/modules/middleware"
 )
 
@@ -38,3 +45,156 @@ func SearchUser(ctx *middleware.Context) {
 		"data": results,
 	})
 }
+
+var emailPattern = regexp.MustCompile("^[\\w!#$%&'*+/=?^_`{|}~-]+(?:\\.[\\w!#$%&'*+/=?^_`{|}~-]+)*@(?:[\\w](?:[\\w-]*[\\w])?\\.)+[a-zA-Z0-9](?:[\\w-]*[\\w])?$")
+
+type userProfile struct {
+	Id        int64     `json:"id"`
+	UserName  string    `json:"username"`
+	FullName  string    `json:"full_name"`
+	Email     string    `json:"email,omitempty"`
+	Avatar    string    `json:"avatar"`
+	Website   string    `json:"website"`
+	Location  string    `json:"location"`
+	Type      string    `json:"type"`
+	IsAdmin   bool      `json:"is_admin"`
+	Suspended bool      `json:"suspended,omitempty"`
+	Followers int       `json:"followers"`
+	Following int       `json:"following"`
+	Repos     int       `json:"repos"`
+	Created   time.Time `json:"created_at"`
+}
+
+// toUserProfile returns profile of user, e-mail is only included when
+// signed in user is the user or a site administrator.
+func toUserProfile(ctx *middleware.Context, u *models.User) *userProfile {
+	p := &userProfile{
+		Id:        u.Id,
+		UserName:  u.Name,
+		FullName:  u.FullName,
+		Avatar:    u.AvatarLink(),
+		Website:   u.Website,
+		Location:  u.Location,
+		Type:      "user",
+		IsAdmin:   u.IsAdmin,
+		Followers: u.NumFollowers,
+		Following: u.NumFollowings,
+		Repos:     u.NumRepos,
+		Created:   u.Created,
+	}
+	switch u.Type {
+	case models.UT_ORGANIZATION:
+		p.Type = "organization"
+	case models.UT_BOT:
+		p.Type = "bot"
+	}
+	if ctx.IsSigned && (ctx.User.Id == u.Id || ctx.User.IsAdmin) {
+		p.Email = u.Email
+	}
+	if ctx.IsSigned && ctx.User.IsAdmin {
+		p.Suspended = u.IsSuspended
+	}
+	return p
+}
+
+func getApiUser(ctx *middleware.Context, params martini.Params) *models.User {
+	u, err := models.GetUserByName(params["username"])
+	if err != nil {
+		if err == models.ErrUserNotExist {
+			ctx.JSON(404, &base.ApiJsonErr{"user not found", DOC_URL})
+		} else {
+			ctx.JSON(500, nil)
+		}
+		return nil
+	}
+	return u
+}
+
+func GetUser(ctx *middleware.Context, params martini.Params) {
+	u := getApiUser(ctx, params)
+	if u == nil {
+		return
+	}
+	ctx.JSON(200, toUserProfile(ctx, u))
+}
+
+// GetAuthenticatedUser returns profile of signed in user.
+func GetAuthenticatedUser(ctx *middleware.Context) {
+	ctx.JSON(200, toUserProfile(ctx, ctx.User))
+}
+
+// EditAuthenticatedUser changes profile of signed in user.
+func EditAuthenticatedUser(ctx *middleware.Context, form apiv1.EditUserForm) {
+	if ctx.HasApiError() {
+		ctx.JSON(422, &base.ApiJsonErr{ctx.GetErrMsg(), DOC_URL})
+		return
+	}
+
+	u := ctx.User
+	if form.FullName != nil {
+		if len(*form.FullName) > 40 {
+			ctx.JSON(422, &base.ApiJsonErr{"full_name must contain at most 40 characters", DOC_URL})
+			return
+		}
+		u.FullName = *form.FullName
+	}
+	if form.Website != nil {
+		if len(*form.Website) > 50 {
+			ctx.JSON(422, &base.ApiJsonErr{"website must contain at most 50 characters", DOC_URL})
+			return
+		}
+		u.Website = *form.Website
+	}
+	if form.Location != nil {
+		if len(*form.Location) > 50 {
+			ctx.JSON(422, &base.ApiJsonErr{"location must contain at most 50 characters", DOC_URL})
+			return
+		}
+		u.Location = *form.Location
+	}
+	if form.Email != nil && *form.Email != u.Email {
+		if len(*form.Email) > 50 || !emailPattern.MatchString(*form.Email) {
+			ctx.JSON(422, &base.ApiJsonErr{"email is not a valid e-mail address", DOC_URL})
+			return
+		}
+		isUsed, err := models.IsEmailUsed(*form.Email)
+		if err != nil {
+			log.Error("v1.EditAuthenticatedUser(IsEmailUsed): %v", err)
+			ctx.JSON(500, nil)
+			return
+		} else if isUsed {
+			ctx.JSON(422, &base.ApiJsonErr{"email has already been used", DOC_URL})
+			return
+		}
+		isAllowed, err := models.IsEmailDomainAllowed(*form.Email)
+		if err != nil {
+			log.Error("v1.EditAuthenticatedUser(IsEmailDomainAllowed): %v", err)
+			ctx.JSON(500, nil)
+			return
+		} else if !isAllowed {
+			ctx.JSON(422, &base.ApiJsonErr{"email domain is not allowed", DOC_URL})
+			return
+		}
+		u.Email = *form.Email
+	}
+
+	if err := models.UpdateUser(u); err != nil {
+		log.Error("v1.EditAuthenticatedUser(UpdateUser): %v", err)
+		ctx.JSON(500, nil)
+		return
+	}
+	log.Trace("%s User setting updated: %s", ctx.Req.RequestURI, u.LowerName)
+	ctx.JSON(200, toUserProfile(ctx, u))
+}
+
+type email struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+// ListEmails lists e-mail addresses of signed in user, which is currently
+// the only one set in profile.
+func ListEmails(ctx *middleware.Context) {
+	ctx.JSON(200, []*email{{ctx.User.Email, true, ctx.User.IsActive}})
+}
@@ -0,0 +1,52 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routers
+
+import (
+	"strings"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+const _EXPLORE_PAGE_SIZE = 20
+
+func Explore(ctx *middleware.Context) {
+	ctx.Data["Title"] = "Explore"
+	ctx.Data["PageIsExplore"] = true
+
+	keyword := strings.TrimSpace(ctx.Query("q"))
+	topic := strings.ToLower(strings.TrimSpace(ctx.Query("topic")))
+	page, _ := base.StrTo(ctx.Query("p")).Int()
+	if page < 1 {
+		page = 1
+	}
+	ctx.Data["Keyword"] = keyword
+	ctx.Data["Topic"] = topic
+
+	repos, total, err := models.SearchRepositories(&models.SearchRepoOptions{
+		Keyword:  keyword,
+		Topic:    topic,
+		Page:     page,
+		PageSize: _EXPLORE_PAGE_SIZE,
+	})
+	if err != nil {
+		ctx.Handle(500, "routers.Explore(SearchRepositories)", err)
+		return
+	}
+	ctx.Data["Repos"] = repos
+	ctx.Data["Total"] = total
+	ctx.Data["LastPageNum"] = page - 1
+	if int64(page*_EXPLORE_PAGE_SIZE) < total {
+		ctx.Data["NextPageNum"] = page + 1
+	}
+
+	if ctx.Data["PopularTopics"], err = models.GetPopularTopics(20); err != nil {
+		ctx.Handle(500, "routers.Explore(GetPopularTopics)", err)
+		return
+	}
+	ctx.HTML(200, "explore")
+}
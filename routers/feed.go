@@ -0,0 +1,189 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/middleware"
+	"github.com/gogits/gogs/modules/setting"
+)
+
+const _FEED_SIZE = 30
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	Id      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Link    atomLink    `xml:"link"`
+	Updated string      `xml:"updated"`
+	Author  string      `xml:"author>name"`
+	Content atomContent `xml:"content"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	Id      string       `xml:"id"`
+	Title   string       `xml:"title"`
+	Links   []atomLink   `xml:"link"`
+	Updated string       `xml:"updated"`
+	Entries []*atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	Guid        string `xml:"guid"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+}
+
+type rssFeed struct {
+	XMLName     xml.Name   `xml:"rss"`
+	Version     string     `xml:"version,attr"`
+	Title       string     `xml:"channel>title"`
+	Link        string     `xml:"channel>link"`
+	Description string     `xml:"channel>description"`
+	Items       []*rssItem `xml:"channel>item"`
+}
+
+// rootRelativeLinkPattern matches links that are relative to root of site,
+// but not protocol-relative ones.
+var rootRelativeLinkPattern = regexp.MustCompile(`(href|src)="/([^/])`)
+
+// actionLink returns URL of page that action is about.
+func actionLink(act *models.Action) string {
+	repoLink := setting.AppUrl + act.RepoUserName + "/" + act.RepoName
+	switch act.OpType {
+	case models.OP_COMMIT_REPO:
+		return repoLink + "/commits/" + act.RefName
+	case models.OP_PUSH_TAG:
+		return repoLink + "/src/" + act.RefName
+	case models.OP_CREATE_ISSUE, models.OP_COMMENT_ISSUE:
+		return repoLink + "/issues/" + strings.SplitN(act.Content, "|", 2)[0]
+	case models.OP_PULL_REQUEST:
+		return repoLink + "/pulls/" + strings.SplitN(act.Content, "|", 2)[0]
+	case models.OP_TRANSFER_REPO:
+		return setting.AppUrl + act.Content + "/" + act.RepoName
+	case models.OP_PUBLISH_RELEASE:
+		return repoLink + "/releases"
+	}
+	return repoLink
+}
+
+// renderFeed responds with actions in Atom, or RSS when requested path ends with ".rss".
+func renderFeed(ctx *middleware.Context, title, link string, actions []*models.Action) {
+	isRss := strings.HasSuffix(ctx.Req.URL.Path, ".rss")
+	atom := &atomFeed{
+		Id:    link,
+		Title: title,
+		Links: []atomLink{
+			{Href: link},
+			{Href: setting.AppUrl + strings.TrimPrefix(ctx.Req.URL.Path, "/"), Rel: "self"},
+		},
+		Updated: time.Now().Format(time.RFC3339),
+	}
+	rss := &rssFeed{Version: "2.0", Title: title, Link: link, Description: title}
+
+	for i, act := range actions {
+		if i == 0 {
+			atom.Updated = act.Created.Format(time.RFC3339)
+		}
+		id := fmt.Sprintf("%saction/%d", setting.AppUrl, act.Id)
+		desc := rootRelativeLinkPattern.ReplaceAllString(base.ActionDesc(act), `$1="`+setting.AppUrl+`$2`)
+		if isRss {
+			rss.Items = append(rss.Items, &rssItem{
+				Guid:        id,
+				Title:       base.ActionTitle(act),
+				Link:        actionLink(act),
+				Description: desc,
+				PubDate:     act.Created.Format(time.RFC1123Z),
+			})
+			continue
+		}
+		atom.Entries = append(atom.Entries, &atomEntry{
+			Id:      id,
+			Title:   base.ActionTitle(act),
+			Link:    atomLink{Href: actionLink(act)},
+			Updated: act.Created.Format(time.RFC3339),
+			Author:  act.ActUserName,
+			Content: atomContent{"html", desc},
+		})
+	}
+
+	var feed interface{} = atom
+	contentType := "application/atom+xml"
+	if isRss {
+		feed = rss
+		contentType = "application/rss+xml"
+	}
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		ctx.Handle(500, "routers.renderFeed(MarshalIndent)", err)
+		return
+	}
+	ctx.Res.Header().Set("Content-Type", contentType+"; charset=utf-8")
+	ctx.Res.WriteHeader(200)
+	ctx.Res.Write([]byte(xml.Header))
+	ctx.Res.Write(data)
+}
+
+// UserFeed renders recent actions performed by user, actions on private
+// repositories are only included for the user self and site administrators.
+func UserFeed(ctx *middleware.Context, params martini.Params) {
+	u, err := models.GetUserByName(params["username"])
+	if err != nil {
+		if err == models.ErrUserNotExist {
+			ctx.Handle(404, "routers.UserFeed(GetUserByName)", err)
+		} else {
+			ctx.Handle(500, "routers.UserFeed(GetUserByName)", err)
+		}
+		return
+	}
+
+	actions, _, err := models.ListActions(&models.ActionListOptions{
+		ActUserId:      u.Id,
+		IncludePrivate: ctx.IsSigned && (ctx.User.Id == u.Id || ctx.User.IsAdmin),
+		PageSize:       _FEED_SIZE,
+	})
+	if err != nil {
+		ctx.Handle(500, "routers.UserFeed(ListActions)", err)
+		return
+	}
+	renderFeed(ctx, u.Name+" activity", setting.AppUrl+"user/"+u.Name, actions)
+}
+
+// RepoFeed renders recent actions on repository.
+func RepoFeed(ctx *middleware.Context) {
+	repo := ctx.Repo.Repository
+	actions, _, err := models.ListActions(&models.ActionListOptions{
+		RepoId:         repo.Id,
+		IncludePrivate: true,
+		PageSize:       _FEED_SIZE,
+	})
+	if err != nil {
+		ctx.Handle(500, "routers.RepoFeed(ListActions)", err)
+		return
+	}
+	renderFeed(ctx, ctx.Repo.Owner.Name+"/"+repo.Name+" activity",
+		setting.AppUrl+ctx.Repo.Owner.Name+"/"+repo.Name, actions)
+}
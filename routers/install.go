@@ -22,9 +22,10 @@ import (
 	"github.com/gogits/gogs/modules/cron"
 	"github.com/gogits/gogs/modules/log"
 	"github.com/gogits/gogs/modules/mailer"
+	"github.com/gogits/gogs/modules/markup"
 	"github.com/gogits/gogs/modules/middleware"
+	"github.com/gogits/gogs/modules/oauth2"
 	"github.com/gogits/gogs/modules/setting"
-	"github.com/gogits/gogs/modules/social"
 )
 
 func checkRunMode() {
@@ -40,7 +41,8 @@ func checkRunMode() {
 
 func NewServices() {
 	setting.NewServices()
-	social.NewOauthService()
+	oauth2.NewOauthService()
+	markup.NewRenderers()
 }
 
 // GlobalInit is for global configuration reload-able.
@@ -60,6 +62,9 @@ func GlobalInit() {
 		}
 
 		models.HasEngine = true
+		if err := models.NewRepoIndexer(); err != nil {
+			qlog.Fatalf("Fail to initialize repository indexer: %v", err)
+		}
 		cron.NewCronContext()
 	}
 	if models.EnableSQLite3 {
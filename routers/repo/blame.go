@@ -0,0 +1,66 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"path"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/git"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+func Blame(ctx *middleware.Context, params martini.Params) {
+	ctx.Data["IsRepoToolbarSource"] = true
+	treename := params["_1"]
+
+	entry, err := ctx.Repo.Commit.GetTreeEntryByPath(treename)
+	if err != nil {
+		if err == git.ErrNotExist {
+			ctx.Handle(404, "repo.Blame(GetTreeEntryByPath)", nil)
+		} else {
+			ctx.Handle(500, "repo.Blame(GetTreeEntryByPath)", err)
+		}
+		return
+	} else if entry.IsDir() {
+		ctx.Redirect(ctx.Repo.RepoLink + "/src/" + ctx.Repo.BranchName + "/" + treename)
+		return
+	}
+
+	blob := entry.Blob()
+	dataRc, err := blob.Data()
+	if err != nil {
+		ctx.Handle(500, "repo.Blame(Data)", err)
+		return
+	}
+	buf := make([]byte, 1024)
+	n, _ := dataRc.Read(buf)
+	dataRc.Close()
+
+	ctx.Data["Title"] = path.Base(treename) + " - blame"
+	ctx.Data["FileName"] = blob.Name()
+	ctx.Data["FileSize"] = blob.Size()
+	ctx.Data["TreeName"] = treename
+	ctx.Data["SourceLink"] = ctx.Repo.RepoLink + "/src/" + ctx.Repo.BranchName + "/" + treename
+
+	// Binary file has no lines to blame.
+	if _, isTextFile := base.IsTextFile(buf[:n]); !isTextFile {
+		ctx.HTML(200, "repo/blame")
+		return
+	}
+	ctx.Data["FileIsText"] = true
+
+	parts, err := models.GetBlame(ctx.Repo.GitRepo.Path, ctx.Repo.CommitId, treename)
+	if err != nil {
+		ctx.Handle(500, "repo.Blame(GetBlame)", err)
+		return
+	}
+	ctx.Data["BlameParts"] = parts
+	ctx.HTML(200, "repo/blame")
+}
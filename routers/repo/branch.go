@@ -7,6 +7,8 @@ package repo
 import (
 	"github.com/go-martini/martini"
 
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/log"
 	"github.com/gogits/gogs/modules/middleware"
 )
 
@@ -14,15 +16,66 @@ func Branches(ctx *middleware.Context, params martini.Params) {
 	ctx.Data["Title"] = "Branches"
 	ctx.Data["IsRepoToolbarBranches"] = true
 
-	brs, err := ctx.Repo.GitRepo.GetBranches()
+	defaultBranch, brs, err := models.GetBranches(ctx.Repo.Repository, ctx.Repo.GitRepo)
 	if err != nil {
-		ctx.Handle(500, "repo.Branches", err)
+		ctx.Handle(500, "repo.Branches(GetBranches)", err)
 		return
-	} else if len(brs) == 0 {
+	} else if defaultBranch == nil && len(brs) == 0 {
 		ctx.Handle(404, "repo.Branches", nil)
 		return
 	}
 
+	shas := make([]string, 0, len(brs)+1)
+	if defaultBranch != nil {
+		shas = append(shas, defaultBranch.Commit.Id.String())
+	}
+	for _, br := range brs {
+		shas = append(shas, br.Commit.Id.String())
+	}
+	ctx.Data["CommitStatuses"], err = models.GetCombinedCommitStatuses(ctx.Repo.Repository.Id, shas)
+	if err != nil {
+		ctx.Handle(500, "repo.Branches(GetCombinedCommitStatuses)", err)
+		return
+	}
+
+	ctx.Data["DefaultBranch"] = defaultBranch
 	ctx.Data["Branches"] = brs
 	ctx.HTML(200, "repo/branches")
 }
+
+func DeleteBranchPost(ctx *middleware.Context) {
+	branchName := ctx.Query("branch")
+	if err := models.DeleteBranch(ctx.User, ctx.Repo.Repository, branchName); err != nil {
+		switch err {
+		case models.ErrBranchNotExist:
+			ctx.Handle(404, "repo.DeleteBranchPost", nil)
+			return
+		case models.ErrDeleteDefaultBranch, models.ErrDeleteUnmergedBranch,
+			models.ErrBranchPushRestricted, models.ErrBranchDeletionBlocked, models.ErrBranchRequirePullRequest:
+			ctx.Flash.Error(err.Error())
+		default:
+			ctx.Handle(500, "repo.DeleteBranchPost(DeleteBranch)", err)
+			return
+		}
+	} else {
+		log.Trace("%s Branch deleted: %s/%s -> %s", ctx.Req.RequestURI, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name, branchName)
+		ctx.Flash.Success("Branch " + branchName + " has been deleted.")
+	}
+	ctx.Redirect(ctx.Repo.RepoLink + "/branches")
+}
+
+func ChangeDefaultBranchPost(ctx *middleware.Context) {
+	branchName := ctx.Query("branch")
+	if err := models.ChangeDefaultBranch(ctx.Repo.Repository, branchName); err != nil {
+		if err == models.ErrBranchNotExist {
+			ctx.Handle(404, "repo.ChangeDefaultBranchPost", nil)
+			return
+		}
+		ctx.Handle(500, "repo.ChangeDefaultBranchPost(ChangeDefaultBranch)", err)
+		return
+	}
+	log.Trace("%s Default branch changed: %s/%s -> %s", ctx.Req.RequestURI, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name, branchName)
+
+	ctx.Flash.Success("Default branch has been changed to " + branchName + ".")
+	ctx.Redirect(ctx.Repo.RepoLink + "/branches")
+}
@@ -5,13 +5,19 @@
 package repo
 
 import (
+	"container/list"
+	"fmt"
 	"path"
+	"strings"
 
 	"github.com/go-martini/martini"
 
 	"github.com/gogits/gogs/models"
 	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/mailer"
 	"github.com/gogits/gogs/modules/middleware"
+	"github.com/gogits/gogs/modules/setting"
 )
 
 func Commits(ctx *middleware.Context, params martini.Params) {
@@ -50,11 +56,13 @@ func Commits(ctx *middleware.Context, params martini.Params) {
 	}
 
 	// Both `git log branchName` and `git log commitId` work.
-	ctx.Data["Commits"], err = ctx.Repo.Commit.CommitsByRange(page)
+	commits, err := ctx.Repo.Commit.CommitsByRange(page)
 	if err != nil {
 		ctx.Handle(500, "repo.Commits(CommitsByRange)", err)
 		return
 	}
+	ctx.Data["Commits"] = models.LoadCommitsStatus(ctx.Repo.Repository.Id,
+		models.ParseCommitsWithSignature(ctx.Repo.GitRepo.Path, commits))
 
 	ctx.Data["Username"] = userName
 	ctx.Data["Reponame"] = repoName
@@ -79,26 +87,6 @@ func Diff(ctx *middleware.Context, params martini.Params) {
 		return
 	}
 
-	isImageFile := func(name string) bool {
-		blob, err := ctx.Repo.Commit.GetBlobByPath(name)
-		if err != nil {
-			return false
-		}
-
-		dataRc, err := blob.Data()
-		if err != nil {
-			return false
-		}
-		buf := make([]byte, 1024)
-		n, _ := dataRc.Read(buf)
-		if n > 0 {
-			buf = buf[:n]
-		}
-		dataRc.Close()
-		_, isImage := base.IsImageFile(buf)
-		return isImage
-	}
-
 	parents := make([]string, commit.ParentCount())
 	for i := 0; i < commit.ParentCount(); i++ {
 		sha, err := commit.ParentId(i)
@@ -111,7 +99,6 @@ func Diff(ctx *middleware.Context, params martini.Params) {
 
 	ctx.Data["Username"] = userName
 	ctx.Data["Reponame"] = repoName
-	ctx.Data["IsImageFile"] = isImageFile
 	ctx.Data["Title"] = commit.Summary() + " · " + base.ShortSha(commitId)
 	ctx.Data["Commit"] = commit
 	ctx.Data["Diff"] = diff
@@ -119,9 +106,82 @@ func Diff(ctx *middleware.Context, params martini.Params) {
 	ctx.Data["DiffNotAvailable"] = diff.NumFiles() == 0
 	ctx.Data["SourcePath"] = "/" + path.Join(userName, repoName, "src", commitId)
 	ctx.Data["RawPath"] = "/" + path.Join(userName, repoName, "raw", commitId)
+	if len(parents) > 0 {
+		ctx.Data["BeforeRawPath"] = "/" + path.Join(userName, repoName, "raw", parents[0])
+	}
+
+	comments, err := models.GetCommitComments(ctx.Repo.Repository.Id, commitId)
+	if err != nil {
+		ctx.Handle(500, "repo.Diff(GetCommitComments)", err)
+		return
+	}
+	for _, c := range comments {
+		c.RenderedContent = string(base.RenderMarkdown([]byte(c.Content), ctx.Repo.RepoLink))
+	}
+	ctx.Data["CommitComments"] = models.AttachCommitComments(diff, comments)
+	ctx.Data["CanCommentCommit"] = ctx.IsSigned && !ctx.Repo.Repository.IsArchived
+
+	// Changes of root commit cannot be cherry-picked or reverted.
+	if ctx.Repo.IsOwner && !ctx.Repo.Repository.IsMirror && !ctx.Repo.Repository.IsArchived && len(parents) > 0 {
+		branches, err := ctx.Repo.GitRepo.GetBranches()
+		if err != nil {
+			ctx.Handle(500, "repo.Diff(GetBranches)", err)
+			return
+		}
+		ctx.Data["CanCherryPick"] = true
+		ctx.Data["Branches"] = branches
+	}
 	ctx.HTML(200, "repo/diff")
 }
 
+func CherryPickPost(ctx *middleware.Context) {
+	commitId := ctx.Repo.CommitId
+	revert := ctx.Query("action") == "revert"
+	if !revert && ctx.Query("action") != "cherry-pick" {
+		ctx.Handle(404, "repo.CherryPickPost", nil)
+		return
+	}
+
+	branch := ctx.Query("branch")
+	newCommitId, err := models.CherryPickCommit(ctx.User, ctx.Repo.Repository, commitId, branch, "", revert)
+	newBranch := branch
+	if err == models.ErrBranchRequirePullRequest || err == models.ErrBranchPushRestricted {
+		// Changes to protected branch go to a new branch to be merged by pull request.
+		prefix := "cherry-pick-"
+		if revert {
+			prefix = "revert-"
+		}
+		newBranch = prefix + base.ShortSha(commitId)
+		for i := 2; ctx.Repo.GitRepo.IsBranchExist(newBranch); i++ {
+			newBranch = fmt.Sprintf("%s%s-%d", prefix, base.ShortSha(commitId), i)
+		}
+		newCommitId, err = models.CherryPickCommit(ctx.User, ctx.Repo.Repository, commitId, branch, newBranch, revert)
+	}
+
+	redirectTo := ctx.Repo.RepoLink + "/commit/" + commitId
+	switch err {
+	case nil:
+	case models.ErrCherryPickConflict, models.ErrCherryPickEmpty, models.ErrBranchNotExist,
+		models.ErrBranchPushRestricted, models.ErrBranchRequirePullRequest, models.ErrRepoArchived:
+		ctx.Flash.Error(err.Error())
+		ctx.Redirect(redirectTo)
+		return
+	default:
+		ctx.Handle(500, "repo.CherryPickPost(CherryPickCommit)", err)
+		return
+	}
+	log.Trace("%s Commit %s applied(revert: %v) to %s: %s/%s", ctx.Req.RequestURI, commitId, revert,
+		newBranch, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name)
+
+	if newBranch != branch {
+		ctx.Flash.Success(fmt.Sprintf("Branch %s is protected, changes have been committed to new branch %s, you can open a pull request now.", branch, newBranch))
+		ctx.Redirect(ctx.Repo.RepoLink + "/compare/" + branch + "..." + newBranch)
+		return
+	}
+	ctx.Flash.Success(fmt.Sprintf("Changes have been committed to branch %s.", branch))
+	ctx.Redirect(ctx.Repo.RepoLink + "/commit/" + newCommitId)
+}
+
 func SearchCommits(ctx *middleware.Context, params martini.Params) {
 	ctx.Data["IsSearchPage"] = true
 	ctx.Data["IsRepoToolbarCommits"] = true
@@ -154,7 +214,8 @@ func SearchCommits(ctx *middleware.Context, params martini.Params) {
 	ctx.Data["Username"] = userName
 	ctx.Data["Reponame"] = repoName
 	ctx.Data["CommitCount"] = commits.Len()
-	ctx.Data["Commits"] = commits
+	ctx.Data["Commits"] = models.LoadCommitsStatus(ctx.Repo.Repository.Id,
+		models.ParseCommitsWithSignature(ctx.Repo.GitRepo.Path, commits))
 	ctx.HTML(200, "repo/commits")
 }
 
@@ -180,10 +241,24 @@ func FileHistory(ctx *middleware.Context, params martini.Params) {
 		return
 	}
 
-	commitsCount, err := ctx.Repo.GitRepo.FileCommitsCount(branchName, fileName)
-	if err != nil {
-		ctx.Handle(500, "repo.FileHistory(GetCommitsCount)", err)
-		return
+	// History that follows renames has to be walked entirely to find them,
+	// so commits count is known from the same walk.
+	isFollowRenames := ctx.Query("follow") == "1"
+	var history *models.FileHistory
+	var commitsCount int
+	if isFollowRenames {
+		history, err = models.GetFileHistory(ctx.Repo.GitRepo.Path, ctx.Repo.Commit.Id.String(), fileName)
+		if err != nil {
+			ctx.Handle(500, "repo.FileHistory(GetFileHistory)", err)
+			return
+		}
+		commitsCount = len(history.CommitIds)
+	} else {
+		commitsCount, err = ctx.Repo.GitRepo.FileCommitsCount(branchName, fileName)
+		if err != nil {
+			ctx.Handle(500, "repo.FileHistory(GetCommitsCount)", err)
+			return
+		}
 	}
 	if commitsCount == 0 {
 		ctx.Handle(404, "repo.FileHistory", nil)
@@ -204,18 +279,109 @@ func FileHistory(ctx *middleware.Context, params martini.Params) {
 		nextPage = 0
 	}
 
-	ctx.Data["Commits"], err = ctx.Repo.GitRepo.CommitsByFileAndRange(
-		branchName, fileName, page)
+	var commits *list.List
+	if isFollowRenames {
+		commits, err = history.GetCommitsByPage(ctx.Repo.GitRepo, page, 50)
+		ctx.Data["Renames"] = history.Renames
+	} else {
+		commits, err = ctx.Repo.GitRepo.CommitsByFileAndRange(branchName, fileName, page)
+	}
 	if err != nil {
 		ctx.Handle(500, "repo.FileHistory(CommitsByRange)", err)
 		return
 	}
+	ctx.Data["Commits"] = models.LoadCommitsStatus(ctx.Repo.Repository.Id,
+		models.ParseCommitsWithSignature(ctx.Repo.GitRepo.Path, commits))
 
 	ctx.Data["Username"] = userName
 	ctx.Data["Reponame"] = repoName
 	ctx.Data["FileName"] = fileName
+	ctx.Data["IsFollowRenames"] = isFollowRenames
 	ctx.Data["CommitCount"] = commitsCount
 	ctx.Data["LastPageNum"] = lastPage
 	ctx.Data["NextPageNum"] = nextPage
 	ctx.HTML(200, "repo/commits")
 }
+
+// NewCommitComment creates a comment on commit or on a line of its diff,
+// author of commit is notified if their email belongs to a user.
+func NewCommitComment(ctx *middleware.Context) {
+	commitId := ctx.Repo.CommitId
+	link := ctx.Repo.RepoLink + "/commit/" + commitId
+
+	diff, err := models.GetDiff(ctx.Repo.GitRepo.Path, commitId)
+	if err != nil {
+		ctx.Handle(404, "repo.NewCommitComment(GetDiff)", err)
+		return
+	}
+
+	line, _ := base.StrTo(ctx.Query("line")).Int()
+	c := &models.CommitComment{
+		RepoId:    ctx.Repo.Repository.Id,
+		CommitId:  commitId,
+		PosterId:  ctx.User.Id,
+		TreePath:  ctx.Query("path"),
+		Line:      line,
+		IsOldSide: ctx.Query("side") == "old",
+		Content:   strings.TrimSpace(ctx.Query("content")),
+	}
+	if err = models.CreateCommitComment(diff, c); err != nil {
+		if err == models.ErrCommitCommentEmpty || err == models.ErrCommitLineNotExist {
+			ctx.Flash.Error(err.Error())
+			ctx.Redirect(link)
+		} else {
+			ctx.Handle(500, "repo.NewCommitComment(CreateCommitComment)", err)
+		}
+		return
+	}
+	log.Trace("%s Commit comment created: %d", ctx.Req.RequestURI, c.Id)
+
+	author, err := models.GetUserByEmail(ctx.Repo.Commit.Author.Email)
+	if err != nil && err != models.ErrUserNotExist {
+		ctx.Handle(500, "repo.NewCommitComment(GetUserByEmail)", err)
+		return
+	} else if err == nil && author.Id != ctx.User.Id && !author.IsBot() {
+		if err = models.NewCommitCommentNotification(ctx.User, ctx.Repo.Repository, c, author); err != nil {
+			ctx.Handle(500, "repo.NewCommitComment(NewCommitCommentNotification)", err)
+			return
+		}
+		if setting.Service.NotifyMail {
+			if err = mailer.SendCommitCommentMail(ctx.Render, ctx.User, ctx.Repo.Owner,
+				ctx.Repo.Repository, c, author); err != nil {
+				ctx.Handle(500, "repo.NewCommitComment(SendCommitCommentMail)", err)
+				return
+			}
+		}
+	}
+
+	ctx.Redirect(fmt.Sprintf("%s#commit-comment-%d", link, c.Id))
+}
+
+// DeleteCommitComment deletes comment on commit, only poster and owners of repository
+// can delete it.
+func DeleteCommitComment(ctx *middleware.Context, params martini.Params) {
+	id, _ := base.StrTo(params["id"]).Int64()
+	c, err := models.GetCommitCommentById(ctx.Repo.Repository.Id, id)
+	if err != nil {
+		if err == models.ErrCommitCommentNotExist {
+			ctx.Handle(404, "repo.DeleteCommitComment(GetCommitCommentById)", err)
+		} else {
+			ctx.Handle(500, "repo.DeleteCommitComment(GetCommitCommentById)", err)
+		}
+		return
+	} else if c.CommitId != ctx.Repo.CommitId {
+		ctx.Handle(404, "repo.DeleteCommitComment", nil)
+		return
+	} else if c.PosterId != ctx.User.Id && !ctx.Repo.IsOwner {
+		ctx.Error(403)
+		return
+	}
+
+	if err = models.DeleteCommitComment(c); err != nil {
+		ctx.Handle(500, "repo.DeleteCommitComment(DeleteCommitComment)", err)
+		return
+	}
+	log.Trace("%s Commit comment deleted: %d", ctx.Req.RequestURI, c.Id)
+
+	ctx.Redirect(ctx.Repo.RepoLink + "/commit/" + c.CommitId)
+}
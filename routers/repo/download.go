@@ -5,101 +5,203 @@
 package repo
 
 import (
+	"bytes"
+	"fmt"
 	"io"
-	"os"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/Unknwon/com"
 	"github.com/go-martini/martini"
 
 	"github.com/gogits/git"
 
-	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/log"
 	"github.com/gogits/gogs/modules/middleware"
 )
 
+// rawContentType returns content type of file to be served by its extension and content,
+// and whether it can be displayed in browser. Text files are always served as plain text
+// so that HTML in repository is not rendered under site origin.
+func rawContentType(name string, buf []byte) (string, bool) {
+	if models.ParseLFSPointer(buf) != nil {
+		return "text/plain; charset=utf-8", true
+	}
+
+	sniffed := http.DetectContentType(buf)
+	byExt := mime.TypeByExtension(path.Ext(name))
+	switch {
+	case strings.HasPrefix(byExt, "image/"), strings.HasPrefix(byExt, "audio/"),
+		strings.HasPrefix(byExt, "video/"), byExt == "application/pdf":
+		return byExt, true
+	case strings.HasPrefix(sniffed, "text/"):
+		return "text/plain; charset=utf-8", true
+	case strings.HasPrefix(sniffed, "image/"), strings.HasPrefix(sniffed, "audio/"),
+		strings.HasPrefix(sniffed, "video/"):
+		return sniffed, true
+	}
+	return sniffed, false
+}
+
+// parseRange parses a single byte range of Range header against size of content.
+// It returns false if range is not satisfiable, multiple ranges are not supported
+// and whole content should be served then.
+func parseRange(header string, size int64) (start, length int64, ok bool) {
+	if !strings.HasPrefix(header, "bytes=") || strings.Contains(header, ",") {
+		return 0, size, true
+	}
+	spec := strings.TrimSpace(strings.TrimPrefix(header, "bytes="))
+	i := strings.Index(spec, "-")
+	if i == -1 {
+		return 0, 0, false
+	}
+
+	first, last := strings.TrimSpace(spec[:i]), strings.TrimSpace(spec[i+1:])
+	if len(first) == 0 {
+		// Suffix range: last N bytes.
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false
+		} else if n > size {
+			n = size
+		}
+		return size - n, n, true
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, false
+	}
+	end := size - 1
+	if len(last) > 0 {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return 0, 0, false
+		} else if end >= size {
+			end = size - 1
+		}
+	}
+	return start, end - start + 1, true
+}
+
 func SingleDownload(ctx *middleware.Context, params martini.Params) {
 	treename := params["_1"]
 
 	blob, err := ctx.Repo.Commit.GetBlobByPath(treename)
 	if err != nil {
-		ctx.Handle(500, "repo.SingleDownload(GetBlobByPath)", err)
+		if err == git.ErrNotExist {
+			ctx.Handle(404, "repo.SingleDownload(GetBlobByPath)", nil)
+		} else {
+			ctx.Handle(500, "repo.SingleDownload(GetBlobByPath)", err)
+		}
 		return
 	}
 
-	dataRc, err := blob.Data()
+	// Blob ID changes whenever content changes.
+	etag := `"` + blob.Id.String() + `"`
+	if ctx.Req.Header.Get("If-None-Match") == etag {
+		ctx.Res.WriteHeader(304)
+		return
+	}
+
+	dataRc, err := models.OpenBlob(ctx.Repo.GitRepo.Path, blob.Id.String())
 	if err != nil {
-		ctx.Handle(500, "repo.SingleDownload(Data)", err)
+		ctx.Handle(500, "repo.SingleDownload(OpenBlob)", err)
 		return
 	}
+	defer dataRc.Close()
 
 	buf := make([]byte, 1024)
-	n, _ := dataRc.Read(buf)
-	if n > 0 {
-		buf = buf[:n]
+	n, _ := io.ReadFull(dataRc, buf)
+	buf = buf[:n]
+
+	contentType, isInline := rawContentType(treename, buf)
+	size := blob.Size()
+	start, length, ok := parseRange(ctx.Req.Header.Get("Range"), size)
+	if !ok {
+		ctx.Res.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		ctx.Res.WriteHeader(416)
+		return
 	}
 
-	defer func() {
-		dataRc.Close()
-	}()
-
-	contentType, isTextFile := base.IsTextFile(buf)
-	_, isImageFile := base.IsImageFile(buf)
 	ctx.Res.Header().Set("Content-Type", contentType)
-	if !isTextFile && !isImageFile {
+	ctx.Res.Header().Set("X-Content-Type-Options", "nosniff")
+	ctx.Res.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+	ctx.Res.Header().Set("ETag", etag)
+	ctx.Res.Header().Set("Accept-Ranges", "bytes")
+	ctx.Res.Header().Set("Content-Length", com.ToStr(length))
+	if !isInline {
 		ctx.Res.Header().Set("Content-Disposition", "attachment; filename="+filepath.Base(treename))
 		ctx.Res.Header().Set("Content-Transfer-Encoding", "binary")
 	}
-	ctx.Res.Write(buf)
-	io.Copy(ctx.Res, dataRc)
+	if length != size {
+		ctx.Res.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, start+length-1, size))
+		ctx.Res.WriteHeader(206)
+	}
+	// Skip bytes before range, part of them may have been read for detection.
+	content := io.MultiReader(bytes.NewReader(buf), dataRc)
+	if _, err = io.CopyN(ioutil.Discard, content, start); err == nil {
+		_, err = io.CopyN(ctx.Res, content, length)
+	}
+	if err != nil {
+		log.Error("repo.SingleDownload(%s): %v", treename, err)
+	}
 }
 
-func ZipDownload(ctx *middleware.Context, params martini.Params) {
-	commitId := ctx.Repo.CommitId
-	archivesPath := filepath.Join(ctx.Repo.GitRepo.Path, "archives/zip")
-	if !com.IsDir(archivesPath) {
-		if err := os.MkdirAll(archivesPath, 0755); err != nil {
-			ctx.Handle(500, "ZipDownload -> os.Mkdir(archivesPath)", err)
-			return
-		}
+// getCommitOfRef returns commit that given branch, tag or commit ID points to.
+func getCommitOfRef(gitRepo *git.Repository, refName string) (*git.Commit, error) {
+	if gitRepo.IsBranchExist(refName) {
+		return gitRepo.GetCommitOfBranch(refName)
+	} else if gitRepo.IsTagExist(refName) {
+		return gitRepo.GetCommitOfTag(refName)
+	} else if len(refName) == 40 {
+		return gitRepo.GetCommit(refName)
 	}
+	return nil, git.ErrNotExist
+}
 
-	archivePath := filepath.Join(archivesPath, commitId+".zip")
-
-	if com.IsFile(archivePath) {
-		ctx.ServeFile(archivePath, ctx.Repo.Repository.Name+".zip")
+func Archive(ctx *middleware.Context, params martini.Params) {
+	uri := params["_1"]
+	var format, contentType string
+	switch {
+	case strings.HasSuffix(uri, "."+models.ARCHIVE_ZIP):
+		format, contentType = models.ARCHIVE_ZIP, "application/zip"
+	case strings.HasSuffix(uri, "."+models.ARCHIVE_TARGZ):
+		format, contentType = models.ARCHIVE_TARGZ, "application/x-gzip"
+	default:
+		ctx.Handle(404, "repo.Archive", nil)
 		return
 	}
 
-	if err := ctx.Repo.Commit.CreateArchive(archivePath, git.AT_ZIP); err != nil {
-		ctx.Handle(500, "ZipDownload -> CreateArchive "+archivePath, err)
+	refName := strings.TrimSuffix(uri, "."+format)
+	commit, err := getCommitOfRef(ctx.Repo.GitRepo, refName)
+	if err != nil {
+		ctx.Handle(404, "repo.Archive(getCommitOfRef)", nil)
 		return
 	}
+	commitId := commit.Id.String()
 
-	ctx.ServeFile(archivePath, ctx.Repo.Repository.Name+".zip")
-}
-
-func TarGzDownload(ctx *middleware.Context, params martini.Params) {
-	commitId := ctx.Repo.CommitId
-	archivesPath := filepath.Join(ctx.Repo.GitRepo.Path, "archives/targz")
-	if !com.IsDir(archivesPath) {
-		if err := os.MkdirAll(archivesPath, 0755); err != nil {
-			ctx.Handle(500, "TarGzDownload -> os.Mkdir(archivesPath)", err)
-			return
-		}
-	}
-
-	archivePath := filepath.Join(archivesPath, commitId+".tar.gz")
-
+	name := ctx.Repo.Repository.Name + "-" + strings.Replace(refName, "/", "-", -1)
+	archivePath := models.RepoArchivePath(ctx.Repo.GitRepo.Path, commitId, name, format)
 	if com.IsFile(archivePath) {
-		ctx.ServeFile(archivePath, ctx.Repo.Repository.Name+".tar.gz")
+		ctx.ServeFile(archivePath, name+"."+format)
 		return
 	}
 
-	if err := ctx.Repo.Commit.CreateArchive(archivePath, git.AT_TARGZ); err != nil {
-		ctx.Handle(500, "TarGzDownload -> CreateArchive "+archivePath, err)
-		return
+	ctx.Res.Header().Set("Content-Type", contentType)
+	ctx.Res.Header().Set("Content-Disposition", "attachment; filename="+name+"."+format)
+	if err = models.WriteRepoArchive(ctx.Res, ctx.Repo.GitRepo.Path, commitId, name, format); err != nil {
+		// Response cannot be changed once archive has been partly sent.
+		if rw, ok := ctx.Res.(martini.ResponseWriter); !ok || !rw.Written() {
+			ctx.Res.Header().Del("Content-Disposition")
+			ctx.Handle(500, "repo.Archive(WriteRepoArchive)", err)
+			return
+		}
+		log.Error("repo.Archive(WriteRepoArchive): %v", err)
 	}
-
-	ctx.ServeFile(archivePath, ctx.Repo.Repository.Name+".tar.gz")
 }
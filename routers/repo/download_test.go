@@ -0,0 +1,39 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"testing"
+)
+
+var parseRangeTests = []struct {
+	header        string
+	start, length int64
+	ok            bool
+}{
+	{"", 0, 100, true},
+	{"items=0-9", 0, 100, true},
+	{"bytes=0-9,20-29", 0, 100, true},
+	{"bytes=0-49", 0, 50, true},
+	{"bytes=50-", 50, 50, true},
+	{"bytes=90-200", 90, 10, true},
+	{"bytes=-10", 90, 10, true},
+	{"bytes=-200", 0, 100, true},
+	{"bytes=100-", 0, 0, false},
+	{"bytes=50-40", 0, 0, false},
+	{"bytes=-0", 0, 0, false},
+	{"bytes=10", 0, 0, false},
+	{"bytes=a-b", 0, 0, false},
+}
+
+func TestParseRange(t *testing.T) {
+	for _, tt := range parseRangeTests {
+		start, length, ok := parseRange(tt.header, 100)
+		if start != tt.start || length != tt.length || ok != tt.ok {
+			t.Errorf("parseRange(%q, 100) = (%d, %d, %v), expected (%d, %d, %v)",
+				tt.header, start, length, ok, tt.start, tt.length, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/git"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/auth"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+const (
+	EDITOR_EDIT   = "repo/editor_edit"
+	EDITOR_DELETE = "repo/editor_delete"
+)
+
+const (
+	COMMIT_TO_CURRENT_BRANCH = "direct"
+	COMMIT_TO_NEW_BRANCH     = "new-branch"
+)
+
+// prepareEditor checks if current branch can be changed from web,
+// and assigns common data of editor pages.
+func prepareEditor(ctx *middleware.Context, params martini.Params) bool {
+	if ctx.Repo.Repository.IsMirror || !ctx.Repo.GitRepo.IsBranchExist(ctx.Repo.BranchName) {
+		ctx.Handle(404, "editor.prepareEditor", nil)
+		return false
+	}
+
+	treeName := params["_1"]
+	ctx.Data["IsRepoToolbarSource"] = true
+	ctx.Data["TreeName"] = treeName
+	ctx.Data["BranchLink"] = ctx.Repo.RepoLink + "/src/" + ctx.Repo.BranchName
+	ctx.Data["last_commit"] = ctx.Repo.CommitId
+	ctx.Data["commit_choice"] = COMMIT_TO_CURRENT_BRANCH
+	ctx.Data["new_branch_name"] = ctx.User.Name + "-patch-1"
+	return true
+}
+
+// getEditorFile returns content of text file in current commit by given path.
+func getEditorFile(ctx *middleware.Context, treeName string) (string, bool) {
+	entry, err := ctx.Repo.Commit.GetTreeEntryByPath(treeName)
+	if err != nil || entry.IsDir() {
+		if err == nil || err == git.ErrNotExist {
+			ctx.Handle(404, "editor.getEditorFile(GetTreeEntryByPath)", nil)
+		} else {
+			ctx.Handle(500, "editor.getEditorFile(GetTreeEntryByPath)", err)
+		}
+		return "", false
+	}
+
+	dataRc, err := entry.Blob().Data()
+	if err != nil {
+		ctx.Handle(500, "editor.getEditorFile(Data)", err)
+		return "", false
+	}
+	defer dataRc.Close()
+
+	buf, err := ioutil.ReadAll(dataRc)
+	if err != nil {
+		ctx.Handle(500, "editor.getEditorFile(ReadAll)", err)
+		return "", false
+	} else if _, isTextFile := base.IsTextFile(buf); !isTextFile {
+		ctx.Handle(404, "editor.getEditorFile(IsTextFile)", nil)
+		return "", false
+	}
+	return string(buf), true
+}
+
+// getCommitBranch returns branch that changes go to by user's choice.
+func getCommitBranch(ctx *middleware.Context, choice, newBranch string) (string, bool) {
+	if choice != COMMIT_TO_NEW_BRANCH {
+		return ctx.Repo.BranchName, true
+	} else if len(newBranch) == 0 {
+		ctx.Data["Err_NewBranchName"] = true
+		return "", false
+	}
+	return newBranch, true
+}
+
+// getCommitMessage builds commit message from form with default summary.
+func getCommitMessage(summary, message, defaultSummary string) string {
+	summary = strings.TrimSpace(summary)
+	if len(summary) == 0 {
+		summary = defaultSummary
+	}
+	if message = strings.TrimSpace(message); len(message) > 0 {
+		return summary + "\n\n" + message
+	}
+	return summary
+}
+
+// handleCommitError renders error message of failed commit from web,
+// or reports internal error.
+func handleCommitError(ctx *middleware.Context, err error, tpl string, form auth.Form) {
+	switch err {
+	case models.ErrRepoFileAlreadyExist, models.ErrRepoFileNameIllegal, models.ErrRepoFileNotExist:
+		ctx.Data["Err_TreeName"] = true
+		ctx.RenderWithErr(err.Error(), tpl, form)
+	case models.ErrBranchAlreadyExist:
+		ctx.Data["Err_NewBranchName"] = true
+		ctx.RenderWithErr(err.Error(), tpl, form)
+	case models.ErrRepoFileChanged, models.ErrBranchNotExist,
+		models.ErrBranchPushRestricted, models.ErrBranchRequirePullRequest,
+		models.ErrBranchForcePushBlocked, models.ErrBranchDeletionBlocked:
+		ctx.RenderWithErr(err.Error(), tpl, form)
+	default:
+		ctx.Handle(500, "editor.handleCommitError", err)
+	}
+}
+
+// redirectAfterCommit redirects to the file, or to compare page if changes went to a new branch.
+func redirectAfterCommit(ctx *middleware.Context, branch, treeName string) {
+	if branch != ctx.Repo.BranchName {
+		ctx.Redirect(ctx.Repo.RepoLink + "/compare/" + ctx.Repo.BranchName + "..." + branch)
+		return
+	}
+	ctx.Redirect(ctx.Repo.RepoLink + "/src/" + branch + "/" + treeName)
+}
+
+func NewFile(ctx *middleware.Context, params martini.Params) {
+	ctx.Data["Title"] = "New File"
+	ctx.Data["IsNewFile"] = true
+	ctx.Data["DefaultCommitSummary"] = "Add new file"
+	if !prepareEditor(ctx, params) {
+		return
+	}
+
+	if dir := params["_1"]; len(dir) > 0 {
+		ctx.Data["tree_name"] = dir + "/"
+	}
+	ctx.HTML(200, EDITOR_EDIT)
+}
+
+func EditFile(ctx *middleware.Context, params martini.Params) {
+	ctx.Data["Title"] = "Edit " + path.Base(params["_1"])
+	ctx.Data["DefaultCommitSummary"] = "Update " + path.Base(params["_1"])
+	if !prepareEditor(ctx, params) {
+		return
+	}
+
+	content, ok := getEditorFile(ctx, params["_1"])
+	if !ok {
+		return
+	}
+	ctx.Data["tree_name"] = params["_1"]
+	ctx.Data["content"] = content
+	ctx.HTML(200, EDITOR_EDIT)
+}
+
+func editFilePost(ctx *middleware.Context, params martini.Params, form auth.EditRepoFileForm, isNewFile bool) {
+	ctx.Data["IsNewFile"] = isNewFile
+	if !prepareEditor(ctx, params) {
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.HTML(200, EDITOR_EDIT)
+		return
+	}
+
+	treeName := models.CleanTreeName(form.TreeName)
+	if len(treeName) == 0 {
+		ctx.Data["Err_TreeName"] = true
+		ctx.RenderWithErr(models.ErrRepoFileNameIllegal.Error(), EDITOR_EDIT, &form)
+		return
+	}
+	branch, ok := getCommitBranch(ctx, form.CommitChoice, form.NewBranchName)
+	if !ok {
+		ctx.RenderWithErr("Name of new branch cannot be empty.", EDITOR_EDIT, &form)
+		return
+	}
+
+	change := &models.RepoFileChange{
+		OldBranch:    ctx.Repo.BranchName,
+		NewBranch:    branch,
+		LastCommitId: form.LastCommit,
+		NewTreeName:  treeName,
+		// Browsers submit line breaks of textarea as CRLF.
+		Content: strings.Replace(form.Content, "\r\n", "\n", -1),
+	}
+	if isNewFile {
+		change.Message = getCommitMessage(form.CommitSummary, form.CommitMessage, "Add "+path.Base(treeName))
+	} else {
+		change.OldTreeName = params["_1"]
+		change.Message = getCommitMessage(form.CommitSummary, form.CommitMessage, "Update "+path.Base(treeName))
+	}
+
+	if err := models.CommitRepoFileChange(ctx.User, ctx.Repo.Repository, change); err != nil {
+		handleCommitError(ctx, err, EDITOR_EDIT, &form)
+		return
+	}
+	log.Trace("%s File committed from web: %s/%s:%s/%s", ctx.Req.RequestURI,
+		ctx.Repo.Owner.LowerName, ctx.Repo.Repository.LowerName, branch, treeName)
+
+	redirectAfterCommit(ctx, branch, treeName)
+}
+
+func NewFilePost(ctx *middleware.Context, params martini.Params, form auth.EditRepoFileForm) {
+	ctx.Data["Title"] = "New File"
+	ctx.Data["DefaultCommitSummary"] = "Add new file"
+	editFilePost(ctx, params, form, true)
+}
+
+func EditFilePost(ctx *middleware.Context, params martini.Params, form auth.EditRepoFileForm) {
+	ctx.Data["Title"] = "Edit " + path.Base(params["_1"])
+	ctx.Data["DefaultCommitSummary"] = "Update " + path.Base(params["_1"])
+	editFilePost(ctx, params, form, false)
+}
+
+func DeleteFile(ctx *middleware.Context, params martini.Params) {
+	ctx.Data["Title"] = "Delete " + path.Base(params["_1"])
+	ctx.Data["DefaultCommitSummary"] = "Delete " + path.Base(params["_1"])
+	if !prepareEditor(ctx, params) {
+		return
+	} else if _, ok := getEditorFile(ctx, params["_1"]); !ok {
+		return
+	}
+	ctx.HTML(200, EDITOR_DELETE)
+}
+
+func DeleteFilePost(ctx *middleware.Context, params martini.Params, form auth.DeleteRepoFileForm) {
+	treeName := params["_1"]
+	ctx.Data["Title"] = "Delete " + path.Base(treeName)
+	ctx.Data["DefaultCommitSummary"] = "Delete " + path.Base(treeName)
+	if !prepareEditor(ctx, params) {
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.HTML(200, EDITOR_DELETE)
+		return
+	}
+
+	branch, ok := getCommitBranch(ctx, form.CommitChoice, form.NewBranchName)
+	if !ok {
+		ctx.RenderWithErr("Name of new branch cannot be empty.", EDITOR_DELETE, &form)
+		return
+	}
+
+	change := &models.RepoFileChange{
+		OldBranch:    ctx.Repo.BranchName,
+		NewBranch:    branch,
+		LastCommitId: ctx.Repo.CommitId,
+		OldTreeName:  treeName,
+		Message:      getCommitMessage(form.CommitSummary, form.CommitMessage, "Delete "+path.Base(treeName)),
+	}
+	if err := models.CommitRepoFileChange(ctx.User, ctx.Repo.Repository, change); err != nil {
+		handleCommitError(ctx, err, EDITOR_DELETE, &form)
+		return
+	}
+	log.Trace("%s File deleted from web: %s/%s:%s/%s", ctx.Req.RequestURI,
+		ctx.Repo.Owner.LowerName, ctx.Repo.Repository.LowerName, branch, treeName)
+
+	ctx.Flash.Success("File '" + treeName + "' has been deleted.")
+	if branch != ctx.Repo.BranchName {
+		redirectAfterCommit(ctx, branch, "")
+		return
+	}
+	ctx.Redirect(ctx.Repo.RepoLink + "/src/" + branch + "/" + path.Dir(treeName))
+}
@@ -0,0 +1,51 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+func Graphs(ctx *middleware.Context) {
+	ctx.Data["Title"] = "Graphs"
+	ctx.Data["IsRepoToolbarGraphs"] = true
+	ctx.HTML(200, "repo/graphs")
+}
+
+// GraphsData returns statistics of given kind as JSON,
+// it responds 202 while statistics are being computed.
+func GraphsData(ctx *middleware.Context, params martini.Params) {
+	data, err := models.GetRepoStats(ctx.Repo.Repository.Id)
+	if err == models.ErrRepoStatsNotReady {
+		ctx.JSON(202, map[string]interface{}{
+			"ok":  false,
+			"err": err.Error(),
+		})
+		return
+	} else if err != nil {
+		ctx.Handle(500, "repo.GraphsData(GetRepoStats)", err)
+		return
+	}
+
+	var result interface{}
+	switch params["kind"] {
+	case "contributors":
+		result = data.Contributors
+	case "commit-activity":
+		result = data.CommitFrequency
+	case "code-frequency":
+		result = data.CodeFrequency
+	default:
+		ctx.Handle(404, "repo.GraphsData", nil)
+		return
+	}
+	ctx.JSON(200, map[string]interface{}{
+		"ok":   true,
+		"data": result,
+	})
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/go-martini/martini"
 	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/base"
 	"github.com/gogits/gogs/modules/middleware"
 	"github.com/gogits/gogs/modules/setting"
 )
@@ -32,6 +33,11 @@ func Http(ctx *middleware.Context, params martini.Params) {
 	if strings.HasSuffix(reponame, ".git") {
 		reponame = reponame[:len(reponame)-4]
 	}
+	// Wiki shares access rules with its repository.
+	isWiki := strings.HasSuffix(reponame, ".wiki")
+	if isWiki {
+		reponame = reponame[:len(reponame)-5]
+	}
 
 	var isPull bool
 	service := ctx.Query("service")
@@ -87,23 +93,52 @@ func Http(ctx *middleware.Context, params martini.Params) {
 			ctx.Handle(401, "no basic auth and digit auth", nil)
 			return
 		}
-		authUsername, passwd, err = basicDecode(auths[1])
+		authUsername, passwd, err = base.BasicAuthDecode(auths[1])
 		if err != nil {
 			ctx.Handle(401, "no basic auth and digit auth", nil)
 			return
 		}
 
-		authUser, err = models.GetUserByName(authUsername)
+		if !middleware.IsIpAllowed(ctx.RemoteAddr(), setting.AuthAllowNets, setting.AuthDenyNets) {
+			ctx.Handle(403, "repo.Http(IsIpAllowed)", nil)
+			return
+		}
+
+		isLocked, err := models.IsLoginLocked(authUsername, ctx.RemoteAddr())
 		if err != nil {
-			ctx.Handle(401, "no basic auth and digit auth", nil)
+			ctx.Handle(500, "repo.Http(IsLoginLocked)", err)
+			return
+		} else if isLocked {
+			ctx.Handle(403, "too many failed login attempts", nil)
 			return
 		}
 
-		newUser := &models.User{Passwd: passwd, Salt: authUser.Salt}
-		newUser.EncodePasswd()
-		if authUser.Passwd != newUser.Passwd {
+		authUser, err = models.GetUserByName(authUsername)
+		if err != nil {
+			models.NewLoginAttempt(authUsername, ctx.RemoteAddr())
+			models.RecordAudit(0, authUsername, models.AUDIT_LOGIN_FAILED, ctx.RemoteAddr(), "Git over HTTP")
 			ctx.Handle(401, "no basic auth and digit auth", nil)
 			return
+		} else if authUser.IsSuspended {
+			ctx.Handle(403, "user has been suspended", nil)
+			return
+		}
+
+		if !authUser.ValidatePassword(passwd) {
+			// Password could also be an access token of the user.
+			t, err := models.GetAccessTokenBySha(passwd)
+			if err != nil || t.Uid != authUser.Id ||
+				(isPull && !t.HasScope(models.SCOPE_REPO_READ)) ||
+				(!isPull && !t.HasScope(models.SCOPE_REPO_WRITE)) {
+				models.NewLoginAttempt(authUsername, ctx.RemoteAddr())
+				models.RecordAudit(authUser.Id, authUsername, models.AUDIT_LOGIN_FAILED, ctx.RemoteAddr(), "Git over HTTP")
+				ctx.Handle(401, "no basic auth and digit auth", nil)
+				return
+			}
+			if err = models.UpdateAccessToken(t); err != nil {
+				ctx.Handle(500, "repo.Http(UpdateAccessToken)", err)
+				return
+			}
 		}
 
 		if !isPublicPull {
@@ -131,8 +166,13 @@ func Http(ctx *middleware.Context, params martini.Params) {
 		}
 	}
 
+	if !isPull && repo.IsArchived {
+		ctx.Handle(403, "repo.Http(IsArchived)", nil)
+		return
+	}
+
 	config := Config{setting.RepoRootPath, "git", true, true, func(rpc string, input []byte) {
-		if rpc == "receive-pack" {
+		if rpc == "receive-pack" && !isWiki {
 			firstLine := bytes.IndexRune(input, '\000')
 			if firstLine > -1 {
 				fields := strings.Fields(string(input[:firstLine]))
@@ -141,11 +181,29 @@ func Http(ctx *middleware.Context, params martini.Params) {
 					newCommitId := fields[1]
 					refName := fields[2]
 
-					models.Update(refName, oldCommitId, newCommitId, authUsername, username, reponame, authUser.Id)
+					// Skip updates rejected by protected branch rules.
+					if err := models.CheckBranchPush(repo, models.RepoPath(username, reponame), authUser.Id,
+						refName, oldCommitId, newCommitId); err != nil {
+						return
+					} else if _, err = models.CheckRepoQuota(repo, models.RepoPath(username, reponame)); err != nil {
+						// Rejected by update hook as well.
+						return
+					} else if !models.IsRefUpdated(models.RepoPath(username, reponame), refName, newCommitId) {
+						// Rejected by custom hooks.
+						return
+					}
+					if err := models.Update(refName, oldCommitId, newCommitId, authUsername, username, reponame, authUser.Id); err != nil {
+						log.Printf("repo.Http(Update): %v", err)
+					}
 				}
 			}
 		}
-	}}
+	}, nil}
+	// Update hook needs to know who is pushing to check protected branches.
+	if !isPull && !isWiki {
+		config.Env = []string{"isHttpPush=true", "userId=" + base.ToStr(authUser.Id),
+			"userName=" + authUsername, "repoUserName=" + username, "repoName=" + reponame}
+	}
 
 	handler := HttpBackend(&config)
 	handler(ctx.ResponseWriter, ctx.Req)
@@ -163,6 +221,7 @@ type Config struct {
 	UploadPack  bool
 	ReceivePack bool
 	OnSucceed   func(rpc string, input []byte)
+	Env         []string // Extra environment variables of git commands.
 }
 
 type handler struct {
@@ -244,6 +303,9 @@ func serviceRpc(rpc string, hr handler) {
 	args := []string{rpc, "--stateless-rpc", dir}
 	cmd := exec.Command(hr.Config.GitBinPath, args...)
 	cmd.Dir = dir
+	if len(hr.Config.Env) > 0 {
+		cmd.Env = append(os.Environ(), hr.Config.Env...)
+	}
 	in, err := cmd.StdinPipe()
 	if err != nil {
 		log.Print(err)
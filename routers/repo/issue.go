@@ -6,7 +6,10 @@ package repo
 
 import (
 	"fmt"
+	"html/template"
+	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
 	"time"
 
@@ -54,35 +57,168 @@ func Issues(ctx *middleware.Context) {
 		filterMode = models.FM_MENTION
 	}
 
+	// Issues of all users can be filtered by any assignee.
+	collaborators, err := models.GetCollaborators(strings.TrimPrefix(ctx.Repo.RepoLink, "/"))
+	if err != nil {
+		ctx.Handle(500, "issue.Issues(GetCollaborators)", err)
+		return
+	}
+	ctx.Data["Collaborators"] = collaborators
+	var filterAssignee *models.User
+	if aid, _ := base.StrTo(ctx.Query("assignee")).Int64(); aid > 0 && viewType == "all" {
+		for _, u := range collaborators {
+			if u.Id == aid {
+				filterAssignee = u
+				assigneeId = aid
+				break
+			}
+		}
+	}
+	ctx.Data["FilterAssignee"] = filterAssignee
+
+	// Issues of all users can also be filtered by any author.
+	var filterAuthor *models.User
+	if author := ctx.Query("author"); len(author) > 0 && viewType == "all" {
+		if filterAuthor, err = models.GetUserByName(author); err != nil {
+			if err != models.ErrUserNotExist {
+				ctx.Handle(500, "issue.Issues(GetUserByName)", err)
+				return
+			}
+			filterAuthor = nil
+		} else {
+			posterId = filterAuthor.Id
+		}
+	}
+	ctx.Data["FilterAuthor"] = filterAuthor
+
+	milestones, err := models.GetMilestones(ctx.Repo.Repository.Id, false)
+	if err != nil {
+		ctx.Handle(500, "issue.Issues(GetMilestones)", err)
+		return
+	}
+	ctx.Data["Milestones"] = milestones
+
 	var mid int64
+	var filterMilestone *models.Milestone
 	midx, _ := base.StrTo(ctx.Query("milestone")).Int64()
 	if midx > 0 {
-		mile, err := models.GetMilestoneByIndex(ctx.Repo.Repository.Id, midx)
+		filterMilestone, err = models.GetMilestoneByIndex(ctx.Repo.Repository.Id, midx)
 		if err != nil {
-			ctx.Handle(500, "issue.Issues(GetMilestoneByIndex): %v", err)
-			return
+			if err != models.ErrMilestoneNotExist {
+				ctx.Handle(500, "issue.Issues(GetMilestoneByIndex)", err)
+				return
+			}
+			filterMilestone = nil
+		} else {
+			mid = filterMilestone.Id
 		}
-		mid = mile.Id
 	}
+	ctx.Data["FilterMilestone"] = filterMilestone
+
+	keyword := strings.TrimSpace(ctx.Query("q"))
+	sortType := ctx.Query("sort")
+	dueFilter := ctx.Query("due")
+	if !com.IsSliceContainsStr([]string{"overdue", "soon", "none"}, dueFilter) {
+		dueFilter = ""
+	}
+	ctx.Data["DueFilter"] = dueFilter
+	ctx.Data["Keyword"] = keyword
+	ctx.Data["SortType"] = sortType
 
-	selectLabels := ctx.Query("labels")
 	labels, err := models.GetLabels(ctx.Repo.Repository.Id)
 	if err != nil {
 		ctx.Handle(500, "issue.Issues(GetLabels): %v", err)
 		return
 	}
+
+	// Issues are filtered by all selected labels, and link of each label
+	// toggles it in current selection.
+	selected := make(map[string]bool)
+	for _, id := range strings.Split(ctx.Query("labels"), ",") {
+		selected[id] = true
+	}
+	checkedIds := make([]string, 0, len(labels))
 	for _, l := range labels {
 		l.CalOpenIssues()
+		if l.IsChecked = selected[base.ToStr(l.Id)]; l.IsChecked {
+			checkedIds = append(checkedIds, base.ToStr(l.Id))
+		}
+	}
+	labelToggles := make(map[int64]string, len(labels))
+	for _, l := range labels {
+		ids := make([]string, 0, len(checkedIds)+1)
+		for _, id := range checkedIds {
+			if id != base.ToStr(l.Id) {
+				ids = append(ids, id)
+			}
+		}
+		if !l.IsChecked {
+			ids = append(ids, base.ToStr(l.Id))
+		}
+		labelToggles[l.Id] = strings.Join(ids, ",")
 	}
+	selectLabels := strings.Join(checkedIds, ",")
 	ctx.Data["Labels"] = labels
+	ctx.Data["LabelToggles"] = labelToggles
+
+	// Links of filters keep all other active filters in query.
+	filters := map[string]string{
+		"labels":    selectLabels,
+		"milestone": "",
+		"assignee":  "",
+		"author":    "",
+		"q":         keyword,
+		"sort":      sortType,
+		"due":       dueFilter,
+	}
+	if filterMilestone != nil {
+		filters["milestone"] = base.ToStr(filterMilestone.Index)
+	}
+	if filterAssignee != nil {
+		filters["assignee"] = base.ToStr(filterAssignee.Id)
+	}
+	if filterAuthor != nil {
+		filters["author"] = filterAuthor.Name
+	}
+	ctx.Data["IssueQuery"] = issueFilterQuery(filters, "")
+	filterQueries := make(map[string]template.URL, len(filters))
+	for name := range filters {
+		filterQueries[name] = issueFilterQuery(filters, name)
+	}
+	ctx.Data["FilterQueries"] = filterQueries
+
+	currentQuery := issueFilterValues(ctx.Req.URL.Query())
+	ctx.Data["CurrentFilterQuery"] = currentQuery
+	if ctx.IsSigned {
+		savedFilters, err := models.GetIssueFilters(ctx.User.Id, ctx.Repo.Repository.Id)
+		if err != nil {
+			ctx.Handle(500, "issue.Issues(GetIssueFilters)", err)
+			return
+		}
+		for _, f := range savedFilters {
+			f.Repo = ctx.Repo.Repository
+		}
+		ctx.Data["SavedFilters"] = savedFilters
+	}
 
 	page, _ := base.StrTo(ctx.Query("page")).Int()
 
 	// Get issues.
-	issues, err := models.GetIssues(assigneeId, ctx.Repo.Repository.Id, posterId, mid, page,
-		isShowClosed, selectLabels, ctx.Query("sortType"))
+	opts := &models.IssuesOptions{
+		RepoId:      ctx.Repo.Repository.Id,
+		AssigneeId:  assigneeId,
+		PosterId:    posterId,
+		MilestoneId: mid,
+		IsClosed:    isShowClosed,
+		LabelIds:    selectLabels,
+		Keyword:     keyword,
+		DueFilter:   dueFilter,
+		SortType:    sortType,
+		Page:        page,
+	}
+	issues, err := models.SearchIssues(opts)
 	if err != nil {
-		ctx.Handle(500, "issue.Issues(GetIssues): %v", err)
+		ctx.Handle(500, "issue.Issues(SearchIssues)", err)
 		return
 	}
 
@@ -115,6 +251,9 @@ func Issues(ctx *middleware.Context) {
 		if err = issues[i].GetPoster(); err != nil {
 			ctx.Handle(500, "issue.Issues(GetPoster)", fmt.Errorf("[#%d]%v", issues[i].Id, err))
 			return
+		} else if err = issues[i].GetAssignees(); err != nil {
+			ctx.Handle(500, "issue.Issues(GetAssignees)", fmt.Errorf("[#%d]%v", issues[i].Id, err))
+			return
 		}
 	}
 
@@ -123,8 +262,27 @@ func Issues(ctx *middleware.Context) {
 		uid = ctx.User.Id
 	}
 	issueStats := models.GetIssueStats(ctx.Repo.Repository.Id, uid, isShowClosed, filterMode)
+	if filterMode != models.FM_MENTION && (filterAssignee != nil || filterAuthor != nil ||
+		filterMilestone != nil || len(selectLabels) > 0 || len(keyword) > 0 || len(dueFilter) > 0) {
+		opts.IsClosed = false
+		issueStats.OpenCount = models.CountIssues(opts)
+		opts.IsClosed = true
+		issueStats.ClosedCount = models.CountIssues(opts)
+	}
+	pinned, err := models.GetPinnedIssues(ctx.Repo.Repository.Id)
+	if err != nil {
+		ctx.Handle(500, "issue.Issues(GetPinnedIssues)", err)
+		return
+	}
+	for _, issue := range pinned {
+		if err = issue.GetPoster(); err != nil {
+			ctx.Handle(500, "issue.Issues(GetPoster)", fmt.Errorf("[#%d]%v", issue.Id, err))
+			return
+		}
+	}
+	ctx.Data["PinnedIssues"] = pinned
 	ctx.Data["IssueStats"] = issueStats
-	ctx.Data["SelectLabels"], _ = base.StrTo(selectLabels).Int64()
+	ctx.Data["SelectLabels"] = selectLabels
 	ctx.Data["ViewType"] = viewType
 	ctx.Data["Issues"] = issues
 	ctx.Data["IsShowClosed"] = isShowClosed
@@ -137,10 +295,92 @@ func Issues(ctx *middleware.Context) {
 	ctx.HTML(200, "issue/list")
 }
 
+// issueFilterQuery returns query string of given issue filters except the named one,
+// every non-empty filter is prefixed by '&'.
+func issueFilterQuery(filters map[string]string, except string) template.URL {
+	vals := make(url.Values)
+	for name, val := range filters {
+		if name != except && len(val) > 0 {
+			vals.Set(name, val)
+		}
+	}
+	if len(vals) == 0 {
+		return ""
+	}
+	return template.URL("&" + vals.Encode())
+}
+
+// issueFilterValues returns normalized query string of issue list filters in given values,
+// other parameters like page number are dropped.
+func issueFilterValues(vals url.Values) string {
+	filters := make(url.Values)
+	for _, name := range []string{"type", "state", "labels", "milestone", "assignee", "author", "q", "sort", "due"} {
+		if val := strings.TrimSpace(vals.Get(name)); len(val) > 0 {
+			filters.Set(name, val)
+		}
+	}
+	return filters.Encode()
+}
+
+func SaveIssueFilter(ctx *middleware.Context, form auth.SaveIssueFilterForm) {
+	vals, _ := url.ParseQuery(form.Query)
+	query := issueFilterValues(vals)
+	issuesLink := ctx.Repo.RepoLink + "/issues?" + query
+
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
+		ctx.Redirect(issuesLink)
+		return
+	}
+
+	f := &models.IssueFilter{
+		UserId: ctx.User.Id,
+		RepoId: ctx.Repo.Repository.Id,
+		Name:   form.Title,
+		Query:  query,
+	}
+	if err := models.NewIssueFilter(f); err != nil {
+		if err == models.ErrIssueFilterExist {
+			ctx.Flash.Error("You already have a saved filter with this name.")
+			ctx.Redirect(issuesLink)
+		} else {
+			ctx.Handle(500, "issue.SaveIssueFilter(NewIssueFilter)", err)
+		}
+		return
+	}
+	log.Trace("%s Issue filter saved: %d", ctx.Req.RequestURI, f.Id)
+
+	ctx.Flash.Success("Filter has been saved.")
+	ctx.Redirect(issuesLink)
+}
+
+func DeleteIssueFilter(ctx *middleware.Context, params martini.Params) {
+	f, err := models.GetIssueFilterById(ctx.User.Id, com.StrTo(params["id"]).MustInt64())
+	if err != nil {
+		if err == models.ErrIssueFilterNotExist {
+			ctx.Handle(404, "issue.DeleteIssueFilter(GetIssueFilterById)", err)
+		} else {
+			ctx.Handle(500, "issue.DeleteIssueFilter(GetIssueFilterById)", err)
+		}
+		return
+	} else if f.RepoId != ctx.Repo.Repository.Id {
+		ctx.Handle(404, "issue.DeleteIssueFilter", nil)
+		return
+	}
+
+	if err = models.DeleteIssueFilter(ctx.User.Id, f.Id); err != nil {
+		ctx.Handle(500, "issue.DeleteIssueFilter(DeleteIssueFilter)", err)
+		return
+	}
+	ctx.Flash.Success("Filter has been deleted.")
+	ctx.Redirect(ctx.Repo.RepoLink + "/issues")
+}
+
 func CreateIssue(ctx *middleware.Context, params martini.Params) {
 	ctx.Data["Title"] = "Create issue"
 	ctx.Data["IsRepoToolbarIssues"] = true
 	ctx.Data["IsRepoToolbarIssuesList"] = false
+	prepareIssueAttachmentSettings(ctx)
 
 	var err error
 	// Get all milestones.
@@ -161,6 +401,24 @@ func CreateIssue(ctx *middleware.Context, params martini.Params) {
 		return
 	}
 	ctx.Data["Collaborators"] = us
+
+	// Only template is used to pre-fill form, otherwise user selects one of named templates.
+	templates, err := models.GetIssueTemplates(ctx.Repo.Repository)
+	if err != nil {
+		ctx.Handle(500, "issue.CreateIssue(GetIssueTemplates)", err)
+		return
+	}
+	ctx.Data["IssueTemplates"] = templates
+	ctx.Data["HasIssueTemplateChoice"] = len(templates) > 1
+	name := ctx.Query("template")
+	for _, t := range templates {
+		if t.FileName == name || len(templates) == 1 {
+			ctx.Data["IssueTemplate"] = t
+			ctx.Data["title"] = t.Title
+			ctx.Data["content"] = t.Content
+			break
+		}
+	}
 	ctx.HTML(200, "issue/create")
 }
 
@@ -168,6 +426,7 @@ func CreateIssuePost(ctx *middleware.Context, params martini.Params, form auth.C
 	ctx.Data["Title"] = "Create issue"
 	ctx.Data["IsRepoToolbarIssues"] = true
 	ctx.Data["IsRepoToolbarIssuesList"] = false
+	prepareIssueAttachmentSettings(ctx)
 
 	var err error
 	// Get all milestones.
@@ -194,6 +453,12 @@ func CreateIssuePost(ctx *middleware.Context, params martini.Params, form auth.C
 		return
 	}
 
+	deadline, err := parseIssueDeadline(form.Deadline)
+	if err != nil {
+		ctx.RenderWithErr("Due date is not valid.", "issue/create", &form)
+		return
+	}
+
 	// Only collaborators can assign.
 	if !ctx.Repo.IsOwner {
 		form.AssigneeId = 0
@@ -207,6 +472,7 @@ func CreateIssuePost(ctx *middleware.Context, params martini.Params, form auth.C
 		AssigneeId:  form.AssigneeId,
 		LabelIds:    form.Labels,
 		Content:     form.Content,
+		Deadline:    deadline,
 	}
 	if err := models.NewIssue(issue); err != nil {
 		ctx.Handle(500, "issue.CreateIssue(NewIssue)", err)
@@ -215,22 +481,33 @@ func CreateIssuePost(ctx *middleware.Context, params martini.Params, form auth.C
 		ctx.User.Id, form.AssigneeId, ctx.Repo.Repository.Name); err != nil {
 		ctx.Handle(500, "issue.CreateIssue(NewIssueUserPairs)", err)
 		return
+	} else if err := models.LinkIssueAttachments(issue.RepoId, issue.Id, 0, issueAttachmentSha1s(ctx)); err != nil {
+		ctx.Handle(500, "issue.CreateIssue(LinkIssueAttachments)", err)
+		return
+	} else if err := models.UpdateIssuesByContent(ctx.User, ctx.Repo.Repository, issue, issue.Content); err != nil {
+		ctx.Handle(500, "issue.CreateIssue(UpdateIssuesByContent)", err)
+		return
+	} else if err := issue.GetLabels(); err != nil {
+		ctx.Handle(500, "issue.CreateIssue(GetLabels)", err)
+		return
 	}
-
-	// Update mentions.
-	ms := base.MentionPattern.FindAllString(issue.Content, -1)
-	if len(ms) > 0 {
-		for i := range ms {
-			ms[i] = ms[i][1:]
-		}
-
-		ids := models.GetUserIdsByNames(ms)
-		if err := models.UpdateIssueUserPairsByMentions(ids, issue.Id); err != nil {
-			ctx.Handle(500, "issue.CreateIssue(UpdateIssueUserPairsByMentions)", err)
+	for _, l := range issue.Labels {
+		if err := models.AddIssueToProjectsByLabel(issue, l.Id); err != nil {
+			ctx.Handle(500, "issue.CreateIssue(AddIssueToProjectsByLabel)", err)
 			return
 		}
 	}
 
+	ms, err := updateMentions(ctx, issue, 0, issue.Content)
+	if err != nil {
+		ctx.Handle(500, "issue.CreateIssue(updateMentions)", err)
+		return
+	} else if err = models.NewSubscriptionNotifications(models.NOTIFY_NEW_ISSUE, ctx.User,
+		ctx.Repo.Repository, issue, 0, ms); err != nil {
+		ctx.Handle(500, "issue.CreateIssue(NewSubscriptionNotifications)", err)
+		return
+	}
+
 	act := &models.Action{
 		ActUserId:    ctx.User.Id,
 		ActUserName:  ctx.User.Name,
@@ -249,7 +526,7 @@ func CreateIssuePost(ctx *middleware.Context, params martini.Params, form auth.C
 		return
 	}
 
-	// Mail watchers and mentions.
+	// Mail subscribers and mentions.
 	if setting.Service.NotifyMail {
 		tos, err := mailer.SendIssueNotifyMail(ctx.User, ctx.Repo.Owner, ctx.Repo.Repository, issue)
 		if err != nil {
@@ -277,6 +554,28 @@ func CreateIssuePost(ctx *middleware.Context, params martini.Params, form auth.C
 	ctx.Redirect(fmt.Sprintf("/%s/%s/issues/%d", params["username"], params["reponame"], issue.Index))
 }
 
+// updateMentions marks users mentioned in content of issue or comment who can access
+// repository and sends them web notifications, it returns lower names of these users.
+func updateMentions(ctx *middleware.Context, issue *models.Issue, commentId int64, content string) ([]string, error) {
+	users, err := models.GetMentionedUsers(ctx.User, ctx.Repo.Repository, content)
+	if err != nil || len(users) == 0 {
+		return nil, err
+	}
+
+	ids := make([]int64, len(users))
+	names := make([]string, len(users))
+	for i, u := range users {
+		ids[i] = u.Id
+		names[i] = u.LowerName
+	}
+	if err = models.UpdateIssueUserPairsByMentions(ids, issue.Id); err != nil {
+		return nil, err
+	} else if err = models.NewMentionNotifications(ctx.User, ctx.Repo.Repository, issue, commentId, users); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
 func checkLabels(labels, allLabels []*models.Label) {
 	for _, l := range labels {
 		for _, l2 := range allLabels {
@@ -303,6 +602,9 @@ func ViewIssue(ctx *middleware.Context, params martini.Params) {
 			ctx.Handle(500, "issue.ViewIssue(GetIssueByIndex)", err)
 		}
 		return
+	} else if issue.IsPull {
+		ctx.Redirect(fmt.Sprintf("%s/pulls/%d", ctx.Repo.RepoLink, issue.Index))
+		return
 	}
 
 	// Get labels.
@@ -362,8 +664,8 @@ func ViewIssue(ctx *middleware.Context, params martini.Params) {
 	if err = issue.GetPoster(); err != nil {
 		ctx.Handle(500, "issue.ViewIssue(GetPoster): %v", err)
 		return
-	} else if err = issue.GetAssignee(); err != nil {
-		ctx.Handle(500, "issue.ViewIssue(GetAssignee): %v", err)
+	} else if err = issue.GetAssignees(); err != nil {
+		ctx.Handle(500, "issue.ViewIssue(GetAssignees): %v", err)
 		return
 	}
 	issue.RenderedContent = string(base.RenderMarkdown([]byte(issue.Content), ctx.Repo.RepoLink))
@@ -375,26 +677,111 @@ func ViewIssue(ctx *middleware.Context, params martini.Params) {
 		return
 	}
 
-	// Get posters.
-	for i := range comments {
-		u, err := models.GetUserById(comments[i].PosterId)
-		if err != nil {
-			ctx.Handle(500, "issue.ViewIssue(GetUserById.2): %v", err)
-			return
-		}
-		comments[i].Poster = u
-		comments[i].Content = string(base.RenderMarkdown([]byte(comments[i].Content), ctx.Repo.RepoLink))
+	if !prepareComments(ctx, issue, comments) {
+		return
+	}
+
+	ctx.Data["BlockingIssues"], err = models.GetBlockingIssues(issue.Id)
+	if err != nil {
+		ctx.Handle(500, "issue.ViewIssue(GetBlockingIssues)", err)
+		return
+	}
+	ctx.Data["BlockedIssues"], err = models.GetBlockedIssues(issue.Id)
+	if err != nil {
+		ctx.Handle(500, "issue.ViewIssue(GetBlockedIssues)", err)
+		return
 	}
 
 	ctx.Data["Title"] = issue.Name
 	ctx.Data["Issue"] = issue
 	ctx.Data["Comments"] = comments
 	ctx.Data["IsIssueOwner"] = ctx.Repo.IsOwner || (ctx.IsSigned && issue.PosterId == ctx.User.Id)
+	if !prepareIssueSubscription(ctx, issue) {
+		return
+	}
 	ctx.Data["IsRepoToolbarIssues"] = true
 	ctx.Data["IsRepoToolbarIssuesList"] = false
+	prepareIssueAttachmentSettings(ctx)
 	ctx.HTML(200, "issue/view")
 }
 
+func AddIssueDependency(ctx *middleware.Context, params martini.Params) {
+	idx, _ := base.StrTo(params["index"]).Int64()
+	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.Id, idx)
+	if err != nil {
+		if err == models.ErrIssueNotExist {
+			ctx.Handle(404, "issue.AddIssueDependency(GetIssueByIndex)", err)
+		} else {
+			ctx.Handle(500, "issue.AddIssueDependency(GetIssueByIndex)", err)
+		}
+		return
+	}
+	issueLink := fmt.Sprintf("%s/issues/%d", ctx.Repo.RepoLink, issue.Index)
+
+	blockerIdx, _ := base.StrTo(strings.TrimPrefix(strings.TrimSpace(ctx.Query("dependency")), "#")).Int64()
+	blocker, err := models.GetIssueByIndex(ctx.Repo.Repository.Id, blockerIdx)
+	if err != nil {
+		if err == models.ErrIssueNotExist {
+			ctx.Flash.Error("Issue to depend on does not exist in this repository.")
+			ctx.Redirect(issueLink)
+		} else {
+			ctx.Handle(500, "issue.AddIssueDependency(GetIssueByIndex)", err)
+		}
+		return
+	}
+
+	if err = models.CreateIssueDependency(issue, blocker); err != nil {
+		switch err {
+		case models.ErrDependencyExist:
+			ctx.Flash.Error("This issue is already blocked by #" + base.ToStr(blocker.Index) + ".")
+		case models.ErrDependencyCircular:
+			ctx.Flash.Error("Issue #" + base.ToStr(blocker.Index) + " cannot block this issue as it would create a circular dependency.")
+		default:
+			ctx.Handle(500, "issue.AddIssueDependency(CreateIssueDependency)", err)
+			return
+		}
+		ctx.Redirect(issueLink)
+		return
+	}
+	log.Trace("%s Issue dependency added: %d -> %d", ctx.Req.RequestURI, issue.Id, blocker.Id)
+	ctx.Redirect(issueLink)
+}
+
+func RemoveIssueDependency(ctx *middleware.Context, params martini.Params) {
+	idx, _ := base.StrTo(params["index"]).Int64()
+	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.Id, idx)
+	if err != nil {
+		if err == models.ErrIssueNotExist {
+			ctx.Handle(404, "issue.RemoveIssueDependency(GetIssueByIndex)", err)
+		} else {
+			ctx.Handle(500, "issue.RemoveIssueDependency(GetIssueByIndex)", err)
+		}
+		return
+	}
+
+	blockerIdx, _ := base.StrTo(params["dependency"]).Int64()
+	blocker, err := models.GetIssueByIndex(ctx.Repo.Repository.Id, blockerIdx)
+	if err != nil {
+		if err == models.ErrIssueNotExist {
+			ctx.Handle(404, "issue.RemoveIssueDependency(GetIssueByIndex)", err)
+		} else {
+			ctx.Handle(500, "issue.RemoveIssueDependency(GetIssueByIndex)", err)
+		}
+		return
+	}
+
+	if err = models.RemoveIssueDependency(issue.Id, blocker.Id); err != nil {
+		if err == models.ErrDependencyNotExist {
+			ctx.Handle(404, "issue.RemoveIssueDependency", err)
+		} else {
+			ctx.Handle(500, "issue.RemoveIssueDependency", err)
+		}
+		return
+	}
+	log.Trace("%s Issue dependency removed: %d -> %d", ctx.Req.RequestURI, issue.Id, blocker.Id)
+	ctx.Redirect(fmt.Sprintf("%s/issues/%d", ctx.Repo.RepoLink, issue.Index))
+}
+
 func UpdateIssue(ctx *middleware.Context, params martini.Params, form auth.CreateIssueForm) {
 	idx, _ := base.StrTo(params["index"]).Int64()
 	if idx <= 0 {
@@ -419,7 +806,6 @@ func UpdateIssue(ctx *middleware.Context, params martini.Params, form auth.Creat
 
 	issue.Name = form.IssueName
 	issue.MilestoneId = form.MilestoneId
-	issue.AssigneeId = form.AssigneeId
 	issue.LabelIds = form.Labels
 	issue.Content = form.Content
 	// try get content from text, ignore conflict with preview ajax
@@ -429,6 +815,9 @@ func UpdateIssue(ctx *middleware.Context, params martini.Params, form auth.Creat
 	if err = models.UpdateIssue(issue); err != nil {
 		ctx.Handle(500, "issue.UpdateIssue(UpdateIssue)", err)
 		return
+	} else if err = models.UpdateIssuesByContent(ctx.User, ctx.Repo.Repository, issue, issue.Content); err != nil {
+		ctx.Handle(500, "issue.UpdateIssue(UpdateIssuesByContent)", err)
+		return
 	}
 
 	ctx.JSON(200, map[string]interface{}{
@@ -463,12 +852,12 @@ func UpdateIssueLabel(ctx *middleware.Context, params martini.Params) {
 	isAttach := ctx.Query("action") == "attach"
 	labelStrId := ctx.Query("id")
 	labelId, _ := base.StrTo(labelStrId).Int64()
-	label, err := models.GetLabelById(labelId)
+	label, err := models.GetRepoLabelById(ctx.Repo.Repository.Id, labelId)
 	if err != nil {
 		if err == models.ErrLabelNotExist {
-			ctx.Handle(404, "issue.UpdateIssueLabel(GetLabelById)", err)
+			ctx.Handle(404, "issue.UpdateIssueLabel(GetRepoLabelById)", err)
 		} else {
-			ctx.Handle(500, "issue.UpdateIssueLabel(GetLabelById)", err)
+			ctx.Handle(500, "issue.UpdateIssueLabel(GetRepoLabelById)", err)
 		}
 		return
 	}
@@ -498,6 +887,10 @@ func UpdateIssueLabel(ctx *middleware.Context, params martini.Params) {
 			if issue.IsClosed {
 				label.NumClosedIssues++
 			}
+			if err = models.AddIssueToProjectsByLabel(issue, label.Id); err != nil {
+				ctx.Handle(500, "issue.UpdateIssueLabel(AddIssueToProjectsByLabel)", err)
+				return
+			}
 		} else {
 			label.NumIssues--
 			if issue.IsClosed {
@@ -507,6 +900,9 @@ func UpdateIssueLabel(ctx *middleware.Context, params martini.Params) {
 		if err = models.UpdateLabel(label); err != nil {
 			ctx.Handle(500, "issue.UpdateIssueLabel(UpdateLabel)", err)
 			return
+		} else if err = models.CreateLabelEvent(ctx.User, issue, label, isAttach); err != nil {
+			ctx.Handle(500, "issue.UpdateIssueLabel(CreateLabelEvent)", err)
+			return
 		}
 	}
 	ctx.JSON(200, map[string]interface{}{
@@ -514,6 +910,51 @@ func UpdateIssueLabel(ctx *middleware.Context, params martini.Params) {
 	})
 }
 
+// parseIssueDeadline parses due date in form, empty value means no due date.
+func parseIssueDeadline(s string) (time.Time, error) {
+	if len(s) == 0 {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation("2006-01-02", s, time.Local)
+}
+
+func UpdateIssueDeadline(ctx *middleware.Context, params martini.Params) {
+	idx, _ := base.StrTo(params["index"]).Int64()
+	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.Id, idx)
+	if err != nil {
+		if err == models.ErrIssueNotExist {
+			ctx.Handle(404, "issue.UpdateIssueDeadline(GetIssueByIndex)", err)
+		} else {
+			ctx.Handle(500, "issue.UpdateIssueDeadline(GetIssueByIndex)", err)
+		}
+		return
+	}
+	issueLink := fmt.Sprintf("%s/issues/%d", ctx.Repo.RepoLink, issue.Index)
+
+	if !ctx.Repo.IsOwner && ctx.User.Id != issue.PosterId {
+		ctx.Error(403)
+		return
+	}
+
+	dueDate := ctx.Query("due_date")
+	if ctx.Query("remove") == "1" {
+		dueDate = ""
+	}
+	deadline, err := parseIssueDeadline(dueDate)
+	if err != nil {
+		ctx.Flash.Error("Due date is not valid.")
+		ctx.Redirect(issueLink)
+		return
+	}
+	issue.Deadline = deadline
+	if err = models.UpdateIssue(issue); err != nil {
+		ctx.Handle(500, "issue.UpdateIssueDeadline(UpdateIssue)", err)
+		return
+	}
+	log.Trace("%s Issue due date updated: %d", ctx.Req.RequestURI, issue.Id)
+	ctx.Redirect(issueLink)
+}
+
 func UpdateIssueMilestone(ctx *middleware.Context) {
 	if !ctx.Repo.IsOwner {
 		ctx.Error(403)
@@ -553,6 +994,9 @@ func UpdateIssueMilestone(ctx *middleware.Context) {
 	} else if err = models.UpdateIssue(issue); err != nil {
 		ctx.Handle(500, "issue.UpdateIssueMilestone(UpdateIssue)", err)
 		return
+	} else if err = models.CreateMilestoneEvent(ctx.User, issue, oldMid, mid); err != nil {
+		ctx.Handle(500, "issue.UpdateIssueMilestone(CreateMilestoneEvent)", err)
+		return
 	}
 
 	ctx.JSON(200, map[string]interface{}{
@@ -580,46 +1024,283 @@ func UpdateAssignee(ctx *middleware.Context) {
 			ctx.Handle(500, "issue.UpdateAssignee(GetIssueById)", err)
 		}
 		return
+	} else if issue.RepoId != ctx.Repo.Repository.Id {
+		ctx.Handle(404, "issue.UpdateAssignee", nil)
+		return
 	}
 
+	if err = issue.GetAssignees(); err != nil {
+		ctx.Handle(500, "issue.UpdateAssignee(GetAssignees)", err)
+		return
+	}
+
+	// Assignee ID equals to 0 means clear all assignees.
 	aid, _ := base.StrTo(ctx.Query("assigneeid")).Int64()
-	// Not check for invalid assignne id and give responsibility to owners.
-	issue.AssigneeId = aid
-	if err = models.UpdateIssueUserPairByAssignee(aid, issue.Id); err != nil {
-		ctx.Handle(500, "issue.UpdateAssignee(UpdateIssueUserPairByAssignee): %v", err)
+	if aid == 0 {
+		if len(issue.Assignees) > 0 {
+			if err = models.ClearIssueAssignees(issue); err != nil {
+				ctx.Handle(500, "issue.UpdateAssignee(ClearIssueAssignees)", err)
+				return
+			} else if err = models.CreateAssigneeEvent(ctx.User, issue, nil, false); err != nil {
+				ctx.Handle(500, "issue.UpdateAssignee(CreateAssigneeEvent)", err)
+				return
+			}
+		}
+		ctx.JSON(200, map[string]interface{}{
+			"ok": true,
+		})
 		return
-	} else if err = models.UpdateIssue(issue); err != nil {
-		ctx.Handle(500, "issue.UpdateAssignee(UpdateIssue)", err)
+	}
+
+	// Only users that have access to repository can be assigned.
+	u, err := models.GetUserById(aid)
+	if err != nil {
+		if err == models.ErrUserNotExist {
+			ctx.Handle(404, "issue.UpdateAssignee(GetUserById)", err)
+		} else {
+			ctx.Handle(500, "issue.UpdateAssignee(GetUserById)", err)
+		}
 		return
 	}
+	isAttach := ctx.Query("action") != "detach"
+	if isAttach {
+		if has, err := models.HasAccess(u.Name, strings.TrimPrefix(ctx.Repo.RepoLink, "/"), models.AU_READABLE); err != nil {
+			ctx.Handle(500, "issue.UpdateAssignee(HasAccess)", err)
+			return
+		} else if !has {
+			ctx.Handle(404, "issue.UpdateAssignee(HasAccess)", nil)
+			return
+		}
+	}
+	if issue.IsAssigned(u.Id) != isAttach {
+		if err = models.ChangeIssueAssignee(issue, u.Id, isAttach); err != nil {
+			ctx.Handle(500, "issue.UpdateAssignee(ChangeIssueAssignee)", err)
+			return
+		} else if err = models.CreateAssigneeEvent(ctx.User, issue, u, isAttach); err != nil {
+			ctx.Handle(500, "issue.UpdateAssignee(CreateAssigneeEvent)", err)
+			return
+		}
+	}
 
 	ctx.JSON(200, map[string]interface{}{
 		"ok": true,
 	})
 }
 
-func Comment(ctx *middleware.Context, params martini.Params) {
-	index, err := base.StrTo(ctx.Query("issueIndex")).Int64()
-	if err != nil {
-		ctx.Handle(404, "issue.Comment(get index)", err)
-		return
-	}
-
-	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.Id, index)
-	if err != nil {
-		if err == models.ErrIssueNotExist {
-			ctx.Handle(404, "issue.Comment", err)
-		} else {
-			ctx.Handle(200, "issue.Comment(get issue)", err)
+// BulkEditIssues applies one change to all selected issues, every changed issue
+// gets one event in its timeline.
+func BulkEditIssues(ctx *middleware.Context) {
+	vals, _ := url.ParseQuery(ctx.Query("query"))
+	issuesLink := ctx.Repo.RepoLink + "/issues?" + issueFilterValues(vals)
+	repo := ctx.Repo.Repository
+
+	issues := make([]*models.Issue, 0, 10)
+	for _, s := range strings.Split(ctx.Query("issues"), ",") {
+		idx, _ := base.StrTo(s).Int64()
+		if idx <= 0 {
+			continue
+		}
+		issue, err := models.GetIssueByIndex(repo.Id, idx)
+		if err != nil {
+			if err == models.ErrIssueNotExist {
+				continue
+			}
+			ctx.Handle(500, "issue.BulkEditIssues(GetIssueByIndex)", err)
+			return
 		}
+		issues = append(issues, issue)
+	}
+	if len(issues) == 0 {
+		ctx.Flash.Error("No issues selected.")
+		ctx.Redirect(issuesLink)
 		return
 	}
 
-	// Check if issue owner changes the status of issue.
-	var newStatus string
-	if ctx.Repo.IsOwner || issue.PosterId == ctx.User.Id {
+	action := ctx.Query("action")
+	value, _ := base.StrTo(ctx.Query("value")).Int64()
+
+	var (
+		label     *models.Label
+		milestone *models.Milestone
+		assignee  *models.User
+		err       error
+	)
+	switch action {
+	case "close", "reopen":
+	case "label", "unlabel":
+		if label, err = models.GetRepoLabelById(repo.Id, value); err != nil {
+			if err == models.ErrLabelNotExist {
+				ctx.Handle(404, "issue.BulkEditIssues(GetRepoLabelById)", err)
+			} else {
+				ctx.Handle(500, "issue.BulkEditIssues(GetRepoLabelById)", err)
+			}
+			return
+		}
+	case "milestone":
+		if value > 0 {
+			if milestone, err = models.GetMilestoneById(value); err != nil || milestone.RepoId != repo.Id {
+				if err != nil && err != models.ErrMilestoneNotExist {
+					ctx.Handle(500, "issue.BulkEditIssues(GetMilestoneById)", err)
+				} else {
+					ctx.Handle(404, "issue.BulkEditIssues(GetMilestoneById)", nil)
+				}
+				return
+			}
+		}
+	case "assignee":
+		if value > 0 {
+			if assignee, err = models.GetUserById(value); err != nil {
+				if err == models.ErrUserNotExist {
+					ctx.Handle(404, "issue.BulkEditIssues(GetUserById)", err)
+				} else {
+					ctx.Handle(500, "issue.BulkEditIssues(GetUserById)", err)
+				}
+				return
+			} else if has, err := models.HasAccess(assignee.Name, strings.TrimPrefix(ctx.Repo.RepoLink, "/"), models.AU_READABLE); err != nil {
+				ctx.Handle(500, "issue.BulkEditIssues(HasAccess)", err)
+				return
+			} else if !has {
+				ctx.Handle(404, "issue.BulkEditIssues(HasAccess)", nil)
+				return
+			}
+		}
+	default:
+		ctx.Handle(404, "issue.BulkEditIssues", nil)
+		return
+	}
+
+	var numChanged, numBlocked int
+	for _, issue := range issues {
+		switch action {
+		case "close", "reopen":
+			isClosed := action == "close"
+			if issue.IsClosed == isClosed {
+				continue
+			}
+			if isClosed && repo.BlockOnDependencies {
+				count, err := models.CountOpenBlockingIssues(issue.Id)
+				if err != nil {
+					ctx.Handle(500, "issue.BulkEditIssues(CountOpenBlockingIssues)", err)
+					return
+				} else if count > 0 {
+					numBlocked++
+					continue
+				}
+			}
+			// Status change comment is the event itself.
+			if err = models.ChangeIssueStatus(ctx.User, issue, isClosed); err != nil {
+				ctx.Handle(500, "issue.BulkEditIssues(ChangeIssueStatus)", err)
+				return
+			}
+		case "label", "unlabel":
+			isAttach := action == "label"
+			if issue.HasLabel(label.Id) == isAttach {
+				continue
+			}
+			if err = models.ChangeIssueLabel(issue, label, isAttach); err != nil {
+				ctx.Handle(500, "issue.BulkEditIssues(ChangeIssueLabel)", err)
+				return
+			}
+			if isAttach {
+				if err = models.AddIssueToProjectsByLabel(issue, label.Id); err != nil {
+					ctx.Handle(500, "issue.BulkEditIssues(AddIssueToProjectsByLabel)", err)
+					return
+				}
+			}
+			if err = models.CreateLabelEvent(ctx.User, issue, label, isAttach); err != nil {
+				ctx.Handle(500, "issue.BulkEditIssues(CreateLabelEvent)", err)
+				return
+			}
+		case "milestone":
+			if issue.MilestoneId == value {
+				continue
+			}
+			oldMid := issue.MilestoneId
+			issue.MilestoneId = value
+			if err = models.ChangeMilestoneAssign(oldMid, value, issue); err != nil {
+				ctx.Handle(500, "issue.BulkEditIssues(ChangeMilestoneAssign)", err)
+				return
+			} else if err = models.UpdateIssue(issue); err != nil {
+				ctx.Handle(500, "issue.BulkEditIssues(UpdateIssue)", err)
+				return
+			} else if err = models.CreateMilestoneEvent(ctx.User, issue, oldMid, value); err != nil {
+				ctx.Handle(500, "issue.BulkEditIssues(CreateMilestoneEvent)", err)
+				return
+			}
+		case "assignee":
+			if err = issue.GetAssignees(); err != nil {
+				ctx.Handle(500, "issue.BulkEditIssues(GetAssignees)", err)
+				return
+			}
+			if assignee == nil {
+				if len(issue.Assignees) == 0 {
+					continue
+				} else if err = models.ClearIssueAssignees(issue); err != nil {
+					ctx.Handle(500, "issue.BulkEditIssues(ClearIssueAssignees)", err)
+					return
+				}
+			} else {
+				if issue.IsAssigned(assignee.Id) {
+					continue
+				} else if err = models.ChangeIssueAssignee(issue, assignee.Id, true); err != nil {
+					ctx.Handle(500, "issue.BulkEditIssues(ChangeIssueAssignee)", err)
+					return
+				}
+			}
+			if err = models.CreateAssigneeEvent(ctx.User, issue, assignee, assignee != nil); err != nil {
+				ctx.Handle(500, "issue.BulkEditIssues(CreateAssigneeEvent)", err)
+				return
+			}
+		}
+		numChanged++
+	}
+	log.Trace("%s Issues changed in bulk(%s): %d", ctx.Req.RequestURI, action, numChanged)
+
+	if numBlocked > 0 {
+		ctx.Flash.Error(fmt.Sprintf("%d issue(s) cannot be closed while they are blocked by open issues.", numBlocked))
+	} else {
+		ctx.Flash.Success(fmt.Sprintf("%d issue(s) have been updated.", numChanged))
+	}
+	ctx.Redirect(issuesLink)
+}
+
+func Comment(ctx *middleware.Context, params martini.Params) {
+	index, err := base.StrTo(ctx.Query("issueIndex")).Int64()
+	if err != nil {
+		ctx.Handle(404, "issue.Comment(get index)", err)
+		return
+	}
+
+	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.Id, index)
+	if err != nil {
+		if err == models.ErrIssueNotExist {
+			ctx.Handle(404, "issue.Comment", err)
+		} else {
+			ctx.Handle(200, "issue.Comment(get issue)", err)
+		}
+		return
+	}
+
+	// Subscribers are notified of latest comment, either status change or content.
+	var commentId int64
+
+	// Check if issue owner changes the status of issue.
+	var newStatus string
+	if ctx.Repo.IsOwner || issue.PosterId == ctx.User.Id {
 		newStatus = ctx.Query("change_status")
 	}
+
+	// Comment is still created when issue cannot be closed because of open blocking issues.
+	if strings.Contains(newStatus, "Close") && !issue.IsClosed && ctx.Repo.Repository.BlockOnDependencies {
+		count, err := models.CountOpenBlockingIssues(issue.Id)
+		if err != nil {
+			ctx.Handle(500, "issue.Comment(CountOpenBlockingIssues)", err)
+			return
+		} else if count > 0 {
+			ctx.Flash.Error("This issue cannot be closed while it is blocked by open issues.")
+			newStatus = ""
+		}
+	}
 	if len(newStatus) > 0 {
 		if (strings.Contains(newStatus, "Reopen") && issue.IsClosed) ||
 			(strings.Contains(newStatus, "Close") && !issue.IsClosed) {
@@ -637,10 +1318,12 @@ func Comment(ctx *middleware.Context, params martini.Params) {
 				cmtType = models.IT_REOPEN
 			}
 
-			if err = models.CreateComment(ctx.User.Id, ctx.Repo.Repository.Id, issue.Id, 0, 0, cmtType, ""); err != nil {
+			c, err := models.CreateComment(ctx.User.Id, ctx.Repo.Repository.Id, issue.Id, 0, 0, cmtType, "")
+			if err != nil {
 				ctx.Handle(200, "issue.Comment(create status change comment)", err)
 				return
 			}
+			commentId = c.Id
 			log.Trace("%s Issue(%d) status changed: %v", ctx.Req.RequestURI, issue.Id, !issue.IsClosed)
 		}
 	}
@@ -650,24 +1333,23 @@ func Comment(ctx *middleware.Context, params martini.Params) {
 	if len(content) > 0 {
 		switch params["action"] {
 		case "new":
-			if err = models.CreateComment(ctx.User.Id, ctx.Repo.Repository.Id, issue.Id, 0, 0, models.IT_PLAIN, content); err != nil {
+			c, err := models.CreateComment(ctx.User.Id, ctx.Repo.Repository.Id, issue.Id, 0, 0, models.IT_PLAIN, content)
+			if err != nil {
 				ctx.Handle(500, "issue.Comment(create comment)", err)
 				return
+			} else if err = models.LinkIssueAttachments(ctx.Repo.Repository.Id, issue.Id, c.Id, issueAttachmentSha1s(ctx)); err != nil {
+				ctx.Handle(500, "issue.Comment(LinkIssueAttachments)", err)
+				return
+			} else if err = models.UpdateIssuesByContent(ctx.User, ctx.Repo.Repository, issue, content); err != nil {
+				ctx.Handle(500, "issue.Comment(UpdateIssuesByContent)", err)
+				return
 			}
 
-			// Update mentions.
-			ms = base.MentionPattern.FindAllString(issue.Content, -1)
-			if len(ms) > 0 {
-				for i := range ms {
-					ms[i] = ms[i][1:]
-				}
-
-				ids := models.GetUserIdsByNames(ms)
-				if err := models.UpdateIssueUserPairsByMentions(ids, issue.Id); err != nil {
-					ctx.Handle(500, "issue.CreateIssue(UpdateIssueUserPairsByMentions)", err)
-					return
-				}
+			if ms, err = updateMentions(ctx, issue, c.Id, content); err != nil {
+				ctx.Handle(500, "issue.Comment(updateMentions)", err)
+				return
 			}
+			commentId = c.Id
 
 			log.Trace("%s Comment created: %d", ctx.Req.RequestURI, issue.Id)
 		default:
@@ -683,8 +1365,15 @@ func Comment(ctx *middleware.Context, params martini.Params) {
 		ctx.Handle(500, "issue.CreateIssue(NotifyWatchers)", err)
 		return
 	}
+	if commentId > 0 {
+		if err = models.NewSubscriptionNotifications(models.NOTIFY_COMMENT, ctx.User,
+			ctx.Repo.Repository, issue, commentId, ms); err != nil {
+			ctx.Handle(500, "issue.Comment(NewSubscriptionNotifications)", err)
+			return
+		}
+	}
 
-	// Mail watchers and mentions.
+	// Mail subscribers and mentions.
 	if setting.Service.NotifyMail {
 		issue.Content = content
 		tos, err := mailer.SendIssueNotifyMail(ctx.User, ctx.Repo.Owner, ctx.Repo.Repository, issue)
@@ -712,65 +1401,502 @@ func Comment(ctx *middleware.Context, params martini.Params) {
 	ctx.Redirect(fmt.Sprintf("%s/issues/%d", ctx.Repo.RepoLink, index))
 }
 
-func NewLabel(ctx *middleware.Context, form auth.CreateLabelForm) {
-	if ctx.HasError() {
-		Issues(ctx)
+// prepareIssueSubscription sets whether signed in user is subscribed to issue.
+func prepareIssueSubscription(ctx *middleware.Context, issue *models.Issue) bool {
+	if !ctx.IsSigned {
+		return true
+	}
+	isSubscribed, err := models.IsIssueSubscribed(ctx.User.Id, issue)
+	if err != nil {
+		ctx.Handle(500, "issue.prepareIssueSubscription(IsIssueSubscribed)", err)
+		return false
+	}
+	ctx.Data["IsIssueSubscribed"] = isSubscribed
+	return true
+}
+
+// SubscribeIssue subscribes user to or unsubscribes user from notifications of issue
+// or pull request, regardless of whether user watches the repository.
+func SubscribeIssue(ctx *middleware.Context, params martini.Params) {
+	idx, _ := base.StrTo(params["index"]).Int64()
+	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.Id, idx)
+	if err != nil {
+		if err == models.ErrIssueNotExist {
+			ctx.Handle(404, "issue.SubscribeIssue(GetIssueByIndex)", err)
+		} else {
+			ctx.Handle(500, "issue.SubscribeIssue(GetIssueByIndex)", err)
+		}
 		return
 	}
 
-	l := &models.Label{
-		RepoId: ctx.Repo.Repository.Id,
-		Name:   form.Title,
-		Color:  form.Color,
+	subscribe := ctx.Query("action") != "unsubscribe"
+	if err = models.SubscribeIssue(ctx.User.Id, issue.Id, subscribe); err != nil {
+		ctx.Handle(500, "issue.SubscribeIssue(SubscribeIssue)", err)
+		return
 	}
-	if err := models.NewLabel(l); err != nil {
-		ctx.Handle(500, "issue.NewLabel(NewLabel)", err)
+	log.Trace("%s Issue(%d) subscription changed: %v", ctx.Req.RequestURI, issue.Id, subscribe)
+
+	link := fmt.Sprintf("%s/issues/%d", ctx.Repo.RepoLink, issue.Index)
+	if issue.IsPull {
+		link = fmt.Sprintf("%s/pulls/%d", ctx.Repo.RepoLink, issue.Index)
+	}
+	ctx.Redirect(link)
+}
+
+// PinIssue pins issue above issue list or unpins it.
+func PinIssue(ctx *middleware.Context, params martini.Params) {
+	idx, _ := base.StrTo(params["index"]).Int64()
+	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.Id, idx)
+	if err != nil {
+		if err == models.ErrIssueNotExist {
+			ctx.Handle(404, "issue.PinIssue(GetIssueByIndex)", err)
+		} else {
+			ctx.Handle(500, "issue.PinIssue(GetIssueByIndex)", err)
+		}
+		return
+	}
+	issueLink := fmt.Sprintf("%s/issues/%d", ctx.Repo.RepoLink, issue.Index)
+
+	if ctx.Query("action") == "unpin" {
+		err = models.UnpinIssue(issue)
+	} else {
+		err = models.PinIssue(issue)
+	}
+	switch err {
+	case nil:
+	case models.ErrPinnedIssuesLimit:
+		ctx.Flash.Error(fmt.Sprintf("At most %d issues can be pinned, unpin another issue first.", models.MAX_PINNED_ISSUES))
+		ctx.Redirect(issueLink)
+		return
+	case models.ErrIssueNotPinned, models.ErrPinPullRequest:
+		ctx.Flash.Error(err.Error())
+		ctx.Redirect(issueLink)
+		return
+	default:
+		ctx.Handle(500, "issue.PinIssue", err)
 		return
 	}
+	log.Trace("%s Issue(%d) pin changed: %v", ctx.Req.RequestURI, issue.Id, issue.IsPinned())
+
+	ctx.Redirect(issueLink)
+}
+
+// MovePinnedIssue moves pinned issue to given position among pinned issues.
+func MovePinnedIssue(ctx *middleware.Context, params martini.Params) {
+	idx, _ := base.StrTo(params["index"]).Int64()
+	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.Id, idx)
+	if err != nil {
+		if err == models.ErrIssueNotExist {
+			ctx.Handle(404, "issue.MovePinnedIssue(GetIssueByIndex)", err)
+		} else {
+			ctx.Handle(500, "issue.MovePinnedIssue(GetIssueByIndex)", err)
+		}
+		return
+	}
+
+	position, _ := base.StrTo(ctx.Query("position")).Int()
+	if err = models.MovePinnedIssue(issue, position); err != nil {
+		if err == models.ErrIssueNotPinned {
+			ctx.Handle(404, "issue.MovePinnedIssue", err)
+		} else {
+			ctx.Handle(500, "issue.MovePinnedIssue", err)
+		}
+		return
+	}
+	log.Trace("%s Pinned issue(%d) moved to: %d", ctx.Req.RequestURI, issue.Id, position)
+
 	ctx.Redirect(ctx.Repo.RepoLink + "/issues")
 }
 
-func UpdateLabel(ctx *middleware.Context, params martini.Params, form auth.CreateLabelForm) {
-	id, _ := base.StrTo(ctx.Query("id")).Int64()
-	if id == 0 {
-		ctx.Error(404)
+var labelColorPattern = regexp.MustCompile("^#[0-9a-fA-F]{6}$")
+
+// prepareComments loads posters and attachments of comments, renders their content and
+// decides role badge of poster and whether current user can edit them.
+// Attachments of issue itself are loaded as well.
+func prepareComments(ctx *middleware.Context, issue *models.Issue, comments []models.Comment) bool {
+	attachments, err := models.GetAttachmentsByIssueId(issue.Id)
+	if err != nil {
+		ctx.Handle(500, "issue.prepareComments(GetAttachmentsByIssueId)", err)
+		return false
+	}
+	cmtAttachments := make(map[int64][]*models.Attachment)
+	for _, a := range attachments {
+		if a.CommentId == 0 {
+			issue.Attachments = append(issue.Attachments, a)
+		} else {
+			cmtAttachments[a.CommentId] = append(cmtAttachments[a.CommentId], a)
+		}
+	}
+
+	repoName := strings.TrimPrefix(ctx.Repo.RepoLink, "/")
+	roles := make(map[int64]string)
+	for i := range comments {
+		c := &comments[i]
+		c.Attachments = cmtAttachments[c.Id]
+		u, err := models.GetUserById(c.PosterId)
+		if err != nil {
+			ctx.Handle(500, "issue.prepareComments(GetUserById)", err)
+			return false
+		}
+		c.Poster = u
+		c.RenderedContent = string(base.RenderMarkdown([]byte(c.Content), ctx.Repo.RepoLink))
+		c.CanEdit = ctx.IsSigned && (ctx.Repo.IsOwner || c.PosterId == ctx.User.Id)
+
+		role, ok := roles[c.PosterId]
+		if !ok {
+			if c.PosterId == ctx.Repo.Owner.Id {
+				role = "Owner"
+			} else if isCollaborator, err := models.HasAccess(u.Name, repoName, models.AU_WRITABLE); err != nil {
+				ctx.Handle(500, "issue.prepareComments(HasAccess)", err)
+				return false
+			} else if isCollaborator {
+				role = "Collaborator"
+			} else if c.PosterId == issue.PosterId {
+				role = "Author"
+			}
+			roles[c.PosterId] = role
+		}
+		c.Role = role
+	}
+	return true
+}
+
+// getEditableComment returns comment of issue given by URL parameters
+// if current user is allowed to change it.
+func getEditableComment(ctx *middleware.Context, params martini.Params) (*models.Issue, *models.Comment) {
+	index := com.StrTo(params["index"]).MustInt64()
+	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.Id, index)
+	if err != nil {
+		if err == models.ErrIssueNotExist {
+			ctx.Handle(404, "issue.getEditableComment(GetIssueByIndex)", err)
+		} else {
+			ctx.Handle(500, "issue.getEditableComment(GetIssueByIndex)", err)
+		}
+		return nil, nil
+	}
+
+	c, err := models.GetCommentById(issue.Id, com.StrTo(params["id"]).MustInt64())
+	if err != nil {
+		if err == models.ErrCommentNotExist {
+			ctx.Handle(404, "issue.getEditableComment(GetCommentById)", err)
+		} else {
+			ctx.Handle(500, "issue.getEditableComment(GetCommentById)", err)
+		}
+		return nil, nil
+	} else if !ctx.Repo.IsOwner && c.PosterId != ctx.User.Id {
+		ctx.Handle(404, "issue.getEditableComment", nil)
+		return nil, nil
+	}
+	return issue, c
+}
+
+func EditComment(ctx *middleware.Context, params martini.Params) {
+	issue, c := getEditableComment(ctx, params)
+	if c == nil {
 		return
 	}
 
-	l := &models.Label{
-		Id:    id,
-		Name:  form.Title,
-		Color: form.Color,
+	content := ctx.Query("content")
+	if len(content) == 0 {
+		ctx.Flash.Error("Comment content cannot be empty.")
+		ctx.Redirect(fmt.Sprintf("%s/issues/%d#issue-comment-%d", ctx.Repo.RepoLink, issue.Index, c.Id))
+		return
 	}
-	if err := models.UpdateLabel(l); err != nil {
-		ctx.Handle(500, "issue.UpdateLabel(UpdateLabel)", err)
+
+	if err := models.EditComment(c, ctx.User.Id, content); err != nil {
+		ctx.Handle(500, "issue.EditComment(EditComment)", err)
+		return
+	} else if err = models.UpdateIssuesByContent(ctx.User, ctx.Repo.Repository, issue, content); err != nil {
+		ctx.Handle(500, "issue.EditComment(UpdateIssuesByContent)", err)
 		return
 	}
-	ctx.Redirect(ctx.Repo.RepoLink + "/issues")
+	log.Trace("%s Comment edited: %d", ctx.Req.RequestURI, c.Id)
+
+	ctx.Redirect(fmt.Sprintf("%s/issues/%d#issue-comment-%d", ctx.Repo.RepoLink, issue.Index, c.Id))
+}
+
+func DeleteComment(ctx *middleware.Context, params martini.Params) {
+	issue, c := getEditableComment(ctx, params)
+	if c == nil {
+		return
+	}
+
+	if err := models.DeleteComment(c, ctx.User.Id); err != nil {
+		ctx.Handle(500, "issue.DeleteComment(DeleteComment)", err)
+		return
+	}
+	log.Trace("%s Comment deleted: %d", ctx.Req.RequestURI, c.Id)
+
+	ctx.Flash.Success("Comment has been deleted.")
+	ctx.Redirect(fmt.Sprintf("%s/issues/%d", ctx.Repo.RepoLink, issue.Index))
 }
 
-func DeleteLabel(ctx *middleware.Context) {
-	removes := ctx.Query("remove")
-	if len(strings.TrimSpace(removes)) == 0 {
+func CommentHistory(ctx *middleware.Context, params martini.Params) {
+	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.Id, com.StrTo(params["index"]).MustInt64())
+	if err != nil {
+		if err == models.ErrIssueNotExist {
+			ctx.Handle(404, "issue.CommentHistory(GetIssueByIndex)", err)
+		} else {
+			ctx.Handle(500, "issue.CommentHistory(GetIssueByIndex)", err)
+		}
+		return
+	}
+
+	c, err := models.GetCommentById(issue.Id, com.StrTo(params["id"]).MustInt64())
+	if err != nil {
+		if err == models.ErrCommentNotExist {
+			ctx.Handle(404, "issue.CommentHistory(GetCommentById)", err)
+		} else {
+			ctx.Handle(500, "issue.CommentHistory(GetCommentById)", err)
+		}
+		return
+	}
+	if c.Poster, err = models.GetUserById(c.PosterId); err != nil {
+		ctx.Handle(500, "issue.CommentHistory(GetUserById)", err)
+		return
+	}
+	c.RenderedContent = string(base.RenderMarkdown([]byte(c.Content), ctx.Repo.RepoLink))
+
+	revs, err := models.GetCommentRevisions(c.Id)
+	if err != nil {
+		ctx.Handle(500, "issue.CommentHistory(GetCommentRevisions)", err)
+		return
+	}
+	for _, rev := range revs {
+		if rev.Editor, err = models.GetUserById(rev.EditorId); err != nil {
+			ctx.Handle(500, "issue.CommentHistory(GetUserById.2)", err)
+			return
+		}
+		rev.Content = string(base.RenderMarkdown([]byte(rev.Content), ctx.Repo.RepoLink))
+	}
+
+	ctx.Data["Title"] = issue.Name + " - Comment History"
+	ctx.Data["Issue"] = issue
+	ctx.Data["Comment"] = c
+	ctx.Data["Revisions"] = revs
+	ctx.Data["IsRepoToolbarIssues"] = true
+	ctx.HTML(200, "issue/comment_history")
+}
+
+func prepareIssueAttachmentSettings(ctx *middleware.Context) {
+	ctx.Data["IssueAttachmentEnabled"] = setting.IssueAttachmentEnabled
+	ctx.Data["IssueAttachmentAllowedTypes"] = strings.Join(setting.IssueAttachmentAllowedTypes, ",")
+	ctx.Data["IssueAttachmentMaxSize"] = setting.IssueAttachmentMaxSize
+	ctx.Data["IssueAttachmentMaxFiles"] = setting.IssueAttachmentMaxFiles
+}
+
+// issueAttachmentSha1s returns SHA1s of attachments uploaded along with issue or comment form.
+func issueAttachmentSha1s(ctx *middleware.Context) []string {
+	if !setting.IssueAttachmentEnabled {
+		return nil
+	}
+	ctx.Req.ParseForm()
+	files := ctx.Req.Form["files"]
+	if len(files) > setting.IssueAttachmentMaxFiles {
+		files = files[:setting.IssueAttachmentMaxFiles]
+	}
+	return files
+}
+
+func UploadIssueAttachment(ctx *middleware.Context) {
+	if !setting.IssueAttachmentEnabled {
+		ctx.Handle(404, "issue.UploadIssueAttachment", nil)
+		return
+	}
+
+	f, header, err := ctx.Req.FormFile("file")
+	if err != nil {
 		ctx.JSON(200, map[string]interface{}{
-			"ok": true,
+			"ok":  false,
+			"err": err.Error(),
 		})
 		return
 	}
+	defer f.Close()
 
-	strIds := strings.Split(removes, ",")
-	for _, strId := range strIds {
-		if err := models.DeleteLabel(ctx.Repo.Repository.Id, strId); err != nil {
-			ctx.Handle(500, "issue.DeleteLabel(DeleteLabel)", err)
+	a, err := models.NewIssueAttachment(ctx.Repo.Repository.Id, header.Filename, f)
+	if err != nil {
+		var msg string
+		switch err {
+		case models.ErrAttachmentTooLarge:
+			msg = fmt.Sprintf("Attachment cannot be larger than %d MB.", setting.IssueAttachmentMaxSize)
+		case models.ErrAttachmentTypeNotAllowed:
+			msg = "Type of file '" + header.Filename + "' is not allowed."
+		default:
+			ctx.Handle(500, "issue.UploadIssueAttachment(NewIssueAttachment)", err)
 			return
 		}
+		ctx.JSON(200, map[string]interface{}{
+			"ok":  false,
+			"err": msg,
+		})
+		return
 	}
+	log.Trace("%s Issue attachment uploaded: %s", ctx.Req.RequestURI, a.Sha1)
 
 	ctx.JSON(200, map[string]interface{}{
-		"ok": true,
+		"ok":       true,
+		"uuid":     a.Sha1,
+		"name":     a.Name,
+		"is_image": a.IsImage,
+		"link":     "issues/attachments/" + a.Sha1,
 	})
 }
 
+// getIssueAttachment returns attachment of current repository by SHA1 in URL.
+func getIssueAttachment(ctx *middleware.Context, params martini.Params) *models.Attachment {
+	a, err := models.GetAttachmentBySha1(params["sha1"])
+	if err != nil || a.RepoId != ctx.Repo.Repository.Id {
+		if err == nil || err == models.ErrAttachmentNotExist {
+			ctx.Handle(404, "issue.getIssueAttachment", err)
+		} else {
+			ctx.Handle(500, "issue.getIssueAttachment(GetAttachmentBySha1)", err)
+		}
+		return nil
+	}
+	return a
+}
+
+// serveImage serves image file inline so it can be displayed in rendered content.
+func serveImage(ctx *middleware.Context, file string) {
+	ctx.Res.Header().Set("X-Content-Type-Options", "nosniff")
+	ctx.Res.Header().Set("Cache-Control", "private, max-age=86400")
+	http.ServeFile(ctx.Res, ctx.Req, file)
+}
+
+func IssueAttachmentDownload(ctx *middleware.Context, params martini.Params) {
+	a := getIssueAttachment(ctx, params)
+	if a == nil {
+		return
+	}
+
+	if err := models.IncreaseAttachmentDownloadCount(a); err != nil {
+		log.Error("issue.IssueAttachmentDownload(IncreaseAttachmentDownloadCount): %v", err)
+	}
+	if a.IsImage {
+		serveImage(ctx, a.LocalPath())
+		return
+	}
+	ctx.ServeFile(a.LocalPath(), a.Name)
+}
+
+func IssueAttachmentThumbnail(ctx *middleware.Context, params martini.Params) {
+	a := getIssueAttachment(ctx, params)
+	if a == nil {
+		return
+	} else if !a.IsImage {
+		ctx.Handle(404, "issue.IssueAttachmentThumbnail", nil)
+		return
+	}
+
+	// Fall back to original image when thumbnail could not be generated.
+	if com.IsFile(a.ThumbnailPath()) {
+		serveImage(ctx, a.ThumbnailPath())
+	} else {
+		serveImage(ctx, a.LocalPath())
+	}
+}
+
+func prepareLabels(ctx *middleware.Context) bool {
+	ctx.Data["IsRepoToolbarLabels"] = true
+	ctx.Data["Title"] = strings.TrimPrefix(ctx.Repo.RepoLink, "/") + " - Labels"
+
+	labels, err := models.GetLabels(ctx.Repo.Repository.Id)
+	if err != nil {
+		ctx.Handle(500, "issue.prepareLabels(GetLabels)", err)
+		return false
+	}
+	for _, l := range labels {
+		l.CalOpenIssues()
+	}
+	ctx.Data["Labels"] = labels
+	return true
+}
+
+func Labels(ctx *middleware.Context) {
+	if !prepareLabels(ctx) {
+		return
+	}
+	ctx.HTML(200, "repo/labels")
+}
+
+func NewLabel(ctx *middleware.Context, form auth.CreateLabelForm) {
+	if !prepareLabels(ctx) {
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.HTML(200, "repo/labels")
+		return
+	} else if !labelColorPattern.MatchString(form.Color) {
+		ctx.Data["Err_Color"] = true
+		ctx.RenderWithErr("Label color must be a hex color code like #ee0701.", "repo/labels", &form)
+		return
+	}
+
+	l := &models.Label{
+		RepoId:      ctx.Repo.Repository.Id,
+		Name:        form.Title,
+		Color:       form.Color,
+		Description: form.Description,
+	}
+	if err := models.NewLabel(l); err != nil {
+		ctx.Handle(500, "issue.NewLabel(NewLabel)", err)
+		return
+	}
+	log.Trace("%s Label created: %s/%s -> %s", ctx.Req.RequestURI, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name, l.Name)
+
+	ctx.Flash.Success("Label " + l.Name + " has been created.")
+	ctx.Redirect(ctx.Repo.RepoLink + "/settings/labels")
+}
+
+func UpdateLabel(ctx *middleware.Context, params martini.Params, form auth.CreateLabelForm) {
+	id, _ := base.StrTo(params["id"]).Int64()
+	l, err := models.GetRepoLabelById(ctx.Repo.Repository.Id, id)
+	if err != nil {
+		if err == models.ErrLabelNotExist {
+			ctx.Handle(404, "issue.UpdateLabel(GetRepoLabelById)", nil)
+		} else {
+			ctx.Handle(500, "issue.UpdateLabel(GetRepoLabelById)", err)
+		}
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
+		ctx.Redirect(ctx.Repo.RepoLink + "/settings/labels")
+		return
+	} else if !labelColorPattern.MatchString(form.Color) {
+		ctx.Flash.Error("Label color must be a hex color code like #ee0701.")
+		ctx.Redirect(ctx.Repo.RepoLink + "/settings/labels")
+		return
+	}
+
+	l.Name = form.Title
+	l.Color = form.Color
+	l.Description = form.Description
+	if err = models.UpdateLabel(l); err != nil {
+		ctx.Handle(500, "issue.UpdateLabel(UpdateLabel)", err)
+		return
+	}
+	log.Trace("%s Label updated: %s/%s -> %s", ctx.Req.RequestURI, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name, l.Name)
+
+	ctx.Flash.Success("Label " + l.Name + " has been updated.")
+	ctx.Redirect(ctx.Repo.RepoLink + "/settings/labels")
+}
+
+func DeleteLabel(ctx *middleware.Context, params martini.Params) {
+	id, _ := base.StrTo(params["id"]).Int64()
+	if err := models.DeleteLabel(ctx.Repo.Repository.Id, id); err != nil {
+		ctx.Handle(500, "issue.DeleteLabel(DeleteLabel)", err)
+		return
+	}
+	log.Trace("%s Label deleted: %s/%s -> %d", ctx.Req.RequestURI, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name, id)
+
+	ctx.Flash.Success("Label has been deleted.")
+	ctx.Redirect(ctx.Repo.RepoLink + "/settings/labels")
+}
+
 func Milestones(ctx *middleware.Context) {
 	ctx.Data["Title"] = "Milestones"
 	ctx.Data["IsRepoToolbarIssues"] = true
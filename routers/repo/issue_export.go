@@ -0,0 +1,259 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Unknwon/com"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+	"github.com/gogits/gogs/modules/setting"
+)
+
+// Number of issues loaded from database at once when exporting.
+const _ISSUE_EXPORT_PAGE_SIZE = 50
+
+type exportedIssue struct {
+	Index     int64     `json:"number"`
+	Title     string    `json:"title"`
+	State     string    `json:"state"`
+	IsPull    bool      `json:"is_pull"`
+	Author    string    `json:"author"`
+	Assignees []string  `json:"assignees"`
+	Labels    []string  `json:"labels"`
+	Milestone string    `json:"milestone"`
+	DueDate   string    `json:"due_date"`
+	Comments  int       `json:"comments"`
+	Content   string    `json:"content"`
+	Url       string    `json:"url"`
+	Created   time.Time `json:"created_at"`
+	Updated   time.Time `json:"updated_at"`
+}
+
+var exportedIssueCsvHeader = []string{"number", "title", "state", "is_pull", "author", "assignees",
+	"labels", "milestone", "due_date", "comments", "content", "url", "created_at", "updated_at"}
+
+func (i *exportedIssue) csvRecord() []string {
+	return []string{base.ToStr(i.Index), i.Title, i.State, base.ToStr(i.IsPull), i.Author,
+		strings.Join(i.Assignees, ","), strings.Join(i.Labels, ","), i.Milestone, i.DueDate,
+		base.ToStr(i.Comments), i.Content, i.Url, i.Created.Format(time.RFC3339), i.Updated.Format(time.RFC3339)}
+}
+
+// exportIssuesOptions returns issue search options of filters in query,
+// it accepts the same parameters as issue list.
+func exportIssuesOptions(ctx *middleware.Context) (*models.IssuesOptions, int, error) {
+	opts := &models.IssuesOptions{
+		RepoId:   ctx.Repo.Repository.Id,
+		IsClosed: ctx.Query("state") == "closed",
+		Keyword:  strings.TrimSpace(ctx.Query("q")),
+		SortType: ctx.Query("sort"),
+		PageSize: _ISSUE_EXPORT_PAGE_SIZE,
+	}
+	if due := ctx.Query("due"); com.IsSliceContainsStr([]string{"overdue", "soon", "none"}, due) {
+		opts.DueFilter = due
+	}
+
+	labelIds := make([]string, 0, 3)
+	for _, id := range strings.Split(ctx.Query("labels"), ",") {
+		if lid, _ := base.StrTo(id).Int64(); lid > 0 {
+			labelIds = append(labelIds, base.ToStr(lid))
+		}
+	}
+	opts.LabelIds = strings.Join(labelIds, ",")
+
+	if midx, _ := base.StrTo(ctx.Query("milestone")).Int64(); midx > 0 {
+		m, err := models.GetMilestoneByIndex(ctx.Repo.Repository.Id, midx)
+		if err != nil && err != models.ErrMilestoneNotExist {
+			return nil, 0, err
+		} else if err == nil {
+			opts.MilestoneId = m.Id
+		}
+	}
+
+	var filterMode int
+	switch ctx.Query("type") {
+	case "assigned":
+		opts.AssigneeId = ctx.User.Id
+		filterMode = models.FM_ASSIGN
+	case "created_by":
+		opts.PosterId = ctx.User.Id
+		filterMode = models.FM_CREATE
+	case "mentioned":
+		filterMode = models.FM_MENTION
+	default:
+		opts.AssigneeId, _ = base.StrTo(ctx.Query("assignee")).Int64()
+		if author := ctx.Query("author"); len(author) > 0 {
+			u, err := models.GetUserByName(author)
+			if err != nil && err != models.ErrUserNotExist {
+				return nil, 0, err
+			} else if err == nil {
+				opts.PosterId = u.Id
+			}
+		}
+	}
+	return opts, filterMode, nil
+}
+
+// ExportIssues streams issues matching filters of issue list as CSV or JSON file.
+func ExportIssues(ctx *middleware.Context) {
+	format := ctx.Query("format")
+	if format != "csv" && format != "json" {
+		ctx.Handle(404, "issue.ExportIssues", nil)
+		return
+	}
+
+	if viewType := ctx.Query("type"); len(viewType) > 0 && viewType != "all" && !ctx.IsSigned {
+		ctx.SetCookie("redirect_to", "/"+url.QueryEscape(ctx.Req.RequestURI))
+		ctx.Redirect("/user/login")
+		return
+	}
+
+	opts, filterMode, err := exportIssuesOptions(ctx)
+	if err != nil {
+		ctx.Handle(500, "issue.ExportIssues(exportIssuesOptions)", err)
+		return
+	}
+
+	var mentioned map[int64]bool
+	if filterMode == models.FM_MENTION {
+		pairs, err := models.GetIssueUserPairs(ctx.Repo.Repository.Id, ctx.User.Id, opts.IsClosed)
+		if err != nil {
+			ctx.Handle(500, "issue.ExportIssues(GetIssueUserPairs)", err)
+			return
+		}
+		mentioned = make(map[int64]bool, len(pairs))
+		for _, p := range pairs {
+			if p.IsMentioned {
+				mentioned[p.IssueId] = true
+			}
+		}
+	}
+
+	fileName := ctx.Repo.Repository.Name + "-issues." + format
+	if format == "csv" {
+		ctx.Res.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		ctx.Res.Header().Set("Content-Type", "application/json; charset=utf-8")
+	}
+	ctx.Res.Header().Set("Content-Disposition", "attachment; filename=\""+fileName+"\"")
+
+	cw := csv.NewWriter(ctx.Res)
+	if format == "csv" {
+		cw.Write(exportedIssueCsvHeader)
+	} else {
+		ctx.Res.Write([]byte("["))
+	}
+
+	// Headers have been sent, errors from now on can only be logged.
+	repoUrl := setting.AppUrl + strings.TrimPrefix(ctx.Repo.RepoLink, "/")
+	milestones := make(map[int64]string)
+	count := 0
+	for opts.Page = 1; ; opts.Page++ {
+		issues, err := models.SearchIssues(opts)
+		if err != nil {
+			log.Error("issue.ExportIssues(SearchIssues): %v", err)
+			return
+		}
+
+		for i := range issues {
+			issue := &issues[i]
+			if mentioned != nil && !mentioned[issue.Id] {
+				continue
+			}
+			ei, err := exportIssue(issue, repoUrl, milestones)
+			if err != nil {
+				log.Error("issue.ExportIssues(exportIssue): %v", err)
+				return
+			}
+
+			if format == "csv" {
+				cw.Write(ei.csvRecord())
+				continue
+			}
+			data, err := json.Marshal(ei)
+			if err != nil {
+				log.Error("issue.ExportIssues(json.Marshal): %v", err)
+				return
+			}
+			if count > 0 {
+				ctx.Res.Write([]byte(","))
+			}
+			ctx.Res.Write(data)
+			count++
+		}
+		cw.Flush()
+
+		if len(issues) < opts.PageSize {
+			break
+		}
+	}
+
+	if format == "json" {
+		ctx.Res.Write([]byte("]"))
+	}
+	log.Trace("%s Issues exported as %s", ctx.Req.RequestURI, format)
+}
+
+// exportIssue loads related information of issue for export,
+// names of milestones are cached by given map.
+func exportIssue(issue *models.Issue, repoUrl string, milestones map[int64]string) (*exportedIssue, error) {
+	if err := issue.GetPoster(); err != nil {
+		return nil, err
+	} else if err = issue.GetLabels(); err != nil {
+		return nil, err
+	} else if err = issue.GetAssignees(); err != nil {
+		return nil, err
+	}
+
+	ei := &exportedIssue{
+		Index:     issue.Index,
+		Title:     issue.Name,
+		State:     "open",
+		IsPull:    issue.IsPull,
+		Author:    issue.Poster.Name,
+		Assignees: make([]string, 0, len(issue.Assignees)),
+		Labels:    make([]string, 0, len(issue.Labels)),
+		Comments:  issue.NumComments,
+		Content:   issue.Content,
+		Url:       repoUrl + "/issues/" + base.ToStr(issue.Index),
+		Created:   issue.Created,
+		Updated:   issue.Updated,
+	}
+	if issue.IsClosed {
+		ei.State = "closed"
+	}
+	for _, u := range issue.Assignees {
+		ei.Assignees = append(ei.Assignees, u.Name)
+	}
+	for _, l := range issue.Labels {
+		ei.Labels = append(ei.Labels, l.Name)
+	}
+	if issue.HasDeadline() {
+		ei.DueDate = issue.Deadline.Format("2006-01-02")
+	}
+
+	if issue.MilestoneId > 0 {
+		name, ok := milestones[issue.MilestoneId]
+		if !ok {
+			m, err := models.GetMilestoneById(issue.MilestoneId)
+			if err != nil && err != models.ErrMilestoneNotExist {
+				return nil, err
+			} else if err == nil {
+				name = m.Name
+			}
+			milestones[issue.MilestoneId] = name
+		}
+		ei.Milestone = name
+	}
+	return ei, nil
+}
@@ -0,0 +1,383 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"strings"
+
+	"github.com/Unknwon/com"
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/auth"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+const (
+	PROJECT_LIST = "project/list"
+	PROJECT_NEW  = "project/new"
+	PROJECT_VIEW = "project/view"
+)
+
+// getProject returns project of current repository by ID in URL,
+// it renders 404 page and returns nil when project does not exist.
+func getProject(ctx *middleware.Context, params martini.Params) *models.Project {
+	id, _ := base.StrTo(params["id"]).Int64()
+	p, err := models.GetProjectById(ctx.Repo.Repository.Id, id)
+	if err != nil {
+		if err == models.ErrProjectNotExist {
+			ctx.Handle(404, "project.getProject(GetProjectById)", err)
+		} else {
+			ctx.Handle(500, "project.getProject(GetProjectById)", err)
+		}
+		return nil
+	}
+	return p
+}
+
+// getProjectColumn returns column of project by given ID,
+// it renders 404 page and returns nil when column does not exist.
+func getProjectColumn(ctx *middleware.Context, p *models.Project, strId string) *models.ProjectColumn {
+	id, _ := base.StrTo(strId).Int64()
+	c, err := models.GetProjectColumnById(p.Id, id)
+	if err != nil {
+		if err == models.ErrProjectColumnNotExist {
+			ctx.Handle(404, "project.getProjectColumn(GetProjectColumnById)", err)
+		} else {
+			ctx.Handle(500, "project.getProjectColumn(GetProjectColumnById)", err)
+		}
+		return nil
+	}
+	return c
+}
+
+func Projects(ctx *middleware.Context) {
+	ctx.Data["Title"] = "Projects"
+	ctx.Data["IsRepoToolbarProjects"] = true
+
+	isShowClosed := ctx.Query("state") == "closed"
+	projects, err := models.GetProjects(ctx.Repo.Repository.Id, isShowClosed)
+	if err != nil {
+		ctx.Handle(500, "project.Projects(GetProjects)", err)
+		return
+	}
+	ctx.Data["Projects"] = projects
+	ctx.Data["NumOpenProjects"] = models.CountProjects(ctx.Repo.Repository.Id, false)
+	ctx.Data["NumClosedProjects"] = models.CountProjects(ctx.Repo.Repository.Id, true)
+
+	if isShowClosed {
+		ctx.Data["State"] = "closed"
+	} else {
+		ctx.Data["State"] = "open"
+	}
+	ctx.HTML(200, PROJECT_LIST)
+}
+
+func NewProject(ctx *middleware.Context) {
+	ctx.Data["Title"] = "New Project"
+	ctx.Data["IsRepoToolbarProjects"] = true
+	ctx.HTML(200, PROJECT_NEW)
+}
+
+func NewProjectPost(ctx *middleware.Context, form auth.CreateProjectForm) {
+	ctx.Data["Title"] = "New Project"
+	ctx.Data["IsRepoToolbarProjects"] = true
+
+	if ctx.HasError() {
+		ctx.HTML(200, PROJECT_NEW)
+		return
+	}
+
+	p := &models.Project{
+		RepoId:      ctx.Repo.Repository.Id,
+		Name:        form.Title,
+		Description: form.Description,
+	}
+	if err := models.NewProject(p); err != nil {
+		ctx.Handle(500, "project.NewProjectPost(NewProject)", err)
+		return
+	}
+	log.Trace("%s Project created: %d", ctx.Req.RequestURI, p.Id)
+
+	ctx.Redirect(ctx.Repo.RepoLink + "/projects/" + base.ToStr(p.Id))
+}
+
+func ViewProject(ctx *middleware.Context, params martini.Params) {
+	p := getProject(ctx, params)
+	if p == nil {
+		return
+	}
+	if err := p.GetColumns(); err != nil {
+		ctx.Handle(500, "project.ViewProject(GetColumns)", err)
+		return
+	}
+
+	labels, err := models.GetLabels(ctx.Repo.Repository.Id)
+	if err != nil {
+		ctx.Handle(500, "project.ViewProject(GetLabels)", err)
+		return
+	}
+
+	ctx.Data["Title"] = p.Name
+	ctx.Data["IsRepoToolbarProjects"] = true
+	ctx.Data["Project"] = p
+	ctx.Data["Labels"] = labels
+	ctx.Data["ProjectLink"] = ctx.Repo.RepoLink + "/projects/" + base.ToStr(p.Id)
+	ctx.HTML(200, PROJECT_VIEW)
+}
+
+func EditProject(ctx *middleware.Context, params martini.Params) {
+	p := getProject(ctx, params)
+	if p == nil {
+		return
+	}
+
+	ctx.Data["Title"] = "Edit Project"
+	ctx.Data["IsRepoToolbarProjects"] = true
+	ctx.Data["IsProjectEdit"] = true
+	ctx.Data["Project"] = p
+	ctx.Data["name"] = p.Name
+	ctx.Data["desc"] = p.Description
+	ctx.HTML(200, PROJECT_NEW)
+}
+
+func EditProjectPost(ctx *middleware.Context, params martini.Params, form auth.CreateProjectForm) {
+	p := getProject(ctx, params)
+	if p == nil {
+		return
+	}
+
+	ctx.Data["Title"] = "Edit Project"
+	ctx.Data["IsRepoToolbarProjects"] = true
+	ctx.Data["IsProjectEdit"] = true
+	ctx.Data["Project"] = p
+
+	if ctx.HasError() {
+		ctx.HTML(200, PROJECT_NEW)
+		return
+	}
+
+	p.Name = form.Title
+	p.Description = form.Description
+	if err := models.UpdateProject(p); err != nil {
+		ctx.Handle(500, "project.EditProjectPost(UpdateProject)", err)
+		return
+	}
+	ctx.Redirect(ctx.Repo.RepoLink + "/projects/" + base.ToStr(p.Id))
+}
+
+// ChangeProjectStatus opens, closes or deletes project by action in URL.
+func ChangeProjectStatus(ctx *middleware.Context, params martini.Params) {
+	p := getProject(ctx, params)
+	if p == nil {
+		return
+	}
+
+	var err error
+	switch params["action"] {
+	case "open", "close":
+		p.IsClosed = params["action"] == "close"
+		if err = models.UpdateProject(p); err != nil {
+			ctx.Handle(500, "project.ChangeProjectStatus(UpdateProject)", err)
+			return
+		}
+	case "delete":
+		if err = models.DeleteProject(p); err != nil {
+			ctx.Handle(500, "project.ChangeProjectStatus(DeleteProject)", err)
+			return
+		}
+		log.Trace("%s Project deleted: %d", ctx.Req.RequestURI, p.Id)
+		ctx.Redirect(ctx.Repo.RepoLink + "/projects")
+		return
+	default:
+		ctx.Handle(404, "project.ChangeProjectStatus", nil)
+		return
+	}
+	ctx.Redirect(ctx.Repo.RepoLink + "/projects/" + base.ToStr(p.Id))
+}
+
+func NewProjectColumnPost(ctx *middleware.Context, params martini.Params, form auth.ProjectColumnForm) {
+	p := getProject(ctx, params)
+	if p == nil {
+		return
+	}
+	projectLink := ctx.Repo.RepoLink + "/projects/" + base.ToStr(p.Id)
+
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
+		ctx.Redirect(projectLink)
+		return
+	}
+
+	c := &models.ProjectColumn{
+		ProjectId:  p.Id,
+		Name:       form.Title,
+		LabelId:    form.LabelId,
+		IssueState: form.IssueState,
+	}
+	if err := models.NewProjectColumn(c); err != nil {
+		ctx.Handle(500, "project.NewProjectColumnPost(NewProjectColumn)", err)
+		return
+	}
+	ctx.Redirect(projectLink)
+}
+
+func EditProjectColumnPost(ctx *middleware.Context, params martini.Params, form auth.ProjectColumnForm) {
+	p := getProject(ctx, params)
+	if p == nil {
+		return
+	}
+	c := getProjectColumn(ctx, p, params["column"])
+	if c == nil {
+		return
+	}
+	projectLink := ctx.Repo.RepoLink + "/projects/" + base.ToStr(p.Id)
+
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
+		ctx.Redirect(projectLink)
+		return
+	}
+
+	c.Name = form.Title
+	c.LabelId = form.LabelId
+	c.IssueState = form.IssueState
+	if err := models.UpdateProjectColumn(c); err != nil {
+		ctx.Handle(500, "project.EditProjectColumnPost(UpdateProjectColumn)", err)
+		return
+	}
+	ctx.Redirect(projectLink)
+}
+
+func DeleteProjectColumn(ctx *middleware.Context, params martini.Params) {
+	p := getProject(ctx, params)
+	if p == nil {
+		return
+	}
+	c := getProjectColumn(ctx, p, params["column"])
+	if c == nil {
+		return
+	}
+
+	if err := models.DeleteProjectColumn(c); err != nil {
+		ctx.Handle(500, "project.DeleteProjectColumn(DeleteProjectColumn)", err)
+		return
+	}
+	ctx.Redirect(ctx.Repo.RepoLink + "/projects/" + base.ToStr(p.Id))
+}
+
+// NewProjectCardPost adds a card to column, content in form "#index" refers to an issue
+// of repository, and any other content becomes a note.
+func NewProjectCardPost(ctx *middleware.Context, params martini.Params) {
+	p := getProject(ctx, params)
+	if p == nil {
+		return
+	}
+	c := getProjectColumn(ctx, p, ctx.Query("column"))
+	if c == nil {
+		return
+	}
+	projectLink := ctx.Repo.RepoLink + "/projects/" + base.ToStr(p.Id)
+
+	content := strings.TrimSpace(ctx.Query("content"))
+	if len(content) == 0 {
+		ctx.Redirect(projectLink)
+		return
+	}
+
+	card := &models.ProjectCard{
+		ProjectId: p.Id,
+		ColumnId:  c.Id,
+	}
+	idx, err := base.StrTo(strings.TrimPrefix(content, "#")).Int64()
+	if content[0] == '#' && err == nil {
+		issue, err := models.GetIssueByIndex(ctx.Repo.Repository.Id, idx)
+		if err != nil {
+			if err == models.ErrIssueNotExist {
+				ctx.Flash.Error("Issue #" + base.ToStr(idx) + " does not exist.")
+				ctx.Redirect(projectLink)
+			} else {
+				ctx.Handle(500, "project.NewProjectCardPost(GetIssueByIndex)", err)
+			}
+			return
+		}
+		card.IssueId = issue.Id
+	} else {
+		card.Note = content
+	}
+
+	if err = models.NewProjectCard(card); err != nil {
+		if err == models.ErrProjectCardExist {
+			ctx.Flash.Error("Issue is already in this project.")
+			ctx.Redirect(projectLink)
+		} else {
+			ctx.Handle(500, "project.NewProjectCardPost(NewProjectCard)", err)
+		}
+		return
+	}
+	ctx.Redirect(projectLink)
+}
+
+func DeleteProjectCard(ctx *middleware.Context, params martini.Params) {
+	p := getProject(ctx, params)
+	if p == nil {
+		return
+	}
+
+	id, _ := base.StrTo(params["card"]).Int64()
+	card, err := models.GetProjectCardById(p.Id, id)
+	if err != nil {
+		if err == models.ErrProjectCardNotExist {
+			ctx.Handle(404, "project.DeleteProjectCard(GetProjectCardById)", err)
+		} else {
+			ctx.Handle(500, "project.DeleteProjectCard(GetProjectCardById)", err)
+		}
+		return
+	}
+
+	if err = models.DeleteProjectCard(card); err != nil {
+		ctx.Handle(500, "project.DeleteProjectCard(DeleteProjectCard)", err)
+		return
+	}
+	ctx.Redirect(ctx.Repo.RepoLink + "/projects/" + base.ToStr(p.Id))
+}
+
+// MoveProjectCard handles dropping a card on board, it receives target column
+// and comma-separated card IDs of that column in new order.
+func MoveProjectCard(ctx *middleware.Context, params martini.Params) {
+	p, err := models.GetProjectById(ctx.Repo.Repository.Id, com.StrTo(params["id"]).MustInt64())
+	if err != nil {
+		ctx.JSON(200, map[string]interface{}{"ok": false, "err": err.Error()})
+		return
+	}
+	card, err := models.GetProjectCardById(p.Id, com.StrTo(params["card"]).MustInt64())
+	if err != nil {
+		ctx.JSON(200, map[string]interface{}{"ok": false, "err": err.Error()})
+		return
+	}
+	column, err := models.GetProjectColumnById(p.Id, com.StrTo(ctx.Query("column")).MustInt64())
+	if err != nil {
+		ctx.JSON(200, map[string]interface{}{"ok": false, "err": err.Error()})
+		return
+	}
+
+	strIds := strings.Split(ctx.Query("cards"), ",")
+	cardIds := make([]int64, 0, len(strIds))
+	for _, s := range strIds {
+		if id := com.StrTo(s).MustInt64(); id > 0 {
+			cardIds = append(cardIds, id)
+		}
+	}
+
+	if err = models.MoveProjectCard(ctx.User, ctx.Repo.Repository, card, column, cardIds); err != nil {
+		ctx.Handle(500, "project.MoveProjectCard(MoveProjectCard)", err)
+		return
+	}
+	ctx.JSON(200, map[string]interface{}{
+		"ok": true,
+	})
+}
@@ -5,12 +5,1492 @@
 package repo
 
 import (
+	"container/list"
+	"fmt"
+	"path"
+	"strings"
+
 	"github.com/go-martini/martini"
 
+	"github.com/gogits/git"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/auth"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/mailer"
 	"github.com/gogits/gogs/modules/middleware"
+	"github.com/gogits/gogs/modules/setting"
+)
+
+const (
+	PULLS          = "repo/pulls"
+	PULL_COMPARE   = "repo/pull_compare"
+	PULL_VIEW      = "repo/pull_view"
+	PULL_CONFLICTS = "repo/pull_conflicts"
+	DIFF_FILES     = "repo/diff_files"
 )
 
 func Pulls(ctx *middleware.Context, params martini.Params) {
+	ctx.Data["Title"] = "Pull Requests"
+	ctx.Data["IsRepoToolbarPulls"] = true
+
+	isShowClosed := ctx.Query("state") == "closed"
+	page, _ := base.StrTo(ctx.Query("page")).Int()
+	draft := ctx.Query("draft")
+	if draft != "only" && draft != "exclude" {
+		draft = ""
+	}
+
+	pulls, err := models.GetPullRequests(ctx.Repo.Repository.Id, isShowClosed, draft, page)
+	if err != nil {
+		ctx.Handle(500, "pull.Pulls(GetPullRequests)", err)
+		return
+	}
+	drafts := make(map[int64]bool)
+	for _, issue := range pulls {
+		if err = issue.GetPoster(); err != nil {
+			ctx.Handle(500, "pull.Pulls(GetPoster)", err)
+			return
+		}
+		pr, err := models.GetPullRequestByIssueId(issue.Id)
+		if err != nil {
+			ctx.Handle(500, "pull.Pulls(GetPullRequestByIssueId)", err)
+			return
+		}
+		drafts[issue.Id] = pr.IsDraft
+	}
+
+	ctx.Data["OpenCount"], ctx.Data["ClosedCount"] = models.GetPullRequestCount(ctx.Repo.Repository.Id)
+	ctx.Data["Pulls"] = pulls
+	ctx.Data["Drafts"] = drafts
+	ctx.Data["DraftFilter"] = draft
+	ctx.Data["IsShowClosed"] = isShowClosed
+	ctx.HTML(200, PULLS)
+}
+
+// prepareCompareDiff assigns commits and changes between merge base and head commit.
+func prepareCompareDiff(ctx *middleware.Context, mergeBase, headCommitId string) bool {
+	repoPath := ctx.Repo.GitRepo.Path
+
+	commits, err := models.GetCommitsRange(repoPath, mergeBase, headCommitId)
+	if err != nil {
+		ctx.Handle(500, "pull.prepareCompareDiff(GetCommitsRange)", err)
+		return false
+	}
+	page, _ := base.StrTo(ctx.Query("page")).Int()
+	diff, err := models.GetDiffRangePage(repoPath, mergeBase, headCommitId, page)
+	if err != nil {
+		ctx.Handle(500, "pull.prepareCompareDiff(GetDiffRangePage)", err)
+		return false
+	}
+	ctx.Data["Commits"] = models.LoadCommitsStatus(ctx.Repo.Repository.Id,
+		models.ParseCommitsWithSignature(repoPath, commits))
+	ctx.Data["CommitCount"] = commits.Len()
+	ctx.Data["DiffNotAvailable"] = diff.NumFiles() == 0
+	setDiffData(ctx, diff, mergeBase, headCommitId)
+	return true
+}
+
+// setDiffData sets diff between merge base and head commit with links to files for rendering.
+func setDiffData(ctx *middleware.Context, diff *models.Diff, mergeBase, headCommitId string) {
+	userName := ctx.Repo.Owner.Name
+	repoName := ctx.Repo.Repository.Name
+	ctx.Data["Username"] = userName
+	ctx.Data["Reponame"] = repoName
+	ctx.Data["Diff"] = diff
+	ctx.Data["MergeBase"] = mergeBase
+	ctx.Data["HeadCommitId"] = headCommitId
+	ctx.Data["SourcePath"] = "/" + path.Join(userName, repoName, "src", headCommitId)
+	ctx.Data["RawPath"] = "/" + path.Join(userName, repoName, "raw", headCommitId)
+	ctx.Data["BeforeRawPath"] = "/" + path.Join(userName, repoName, "raw", mergeBase)
+}
+
+// getHeadCommitId returns commit ID that head branch, tag or commit points to,
+// reference of a fork is fetched into current repository first.
+func getHeadCommitId(ctx *middleware.Context, headRepo *models.Repository, headRef string) (string, error) {
+	if headRepo.Id == ctx.Repo.Repository.Id {
+		return models.ResolveCommitId(ctx.Repo.GitRepo.Path, headRef)
+	}
+	return models.FetchForkRef(ctx.Repo.GitRepo.Path, models.RepoPath(headRepo.Owner.Name, headRepo.Name),
+		headRepo.Id, headRef)
+}
+
+// parseCompareInfo parses base and head references in URL and checks if they exist,
+// head can be given as "user:ref" to compare with a fork of current repository.
+// References can be branches, tags or commits, but pull request can only be created
+// when both of them are branches.
+func parseCompareInfo(ctx *middleware.Context, params martini.Params) (*models.Repository, string, string, bool) {
+	infos := strings.SplitN(params["_1"], "...", 2)
+	baseBranch, headInfo := ctx.Repo.Repository.DefaultBranch, infos[0]
+	if len(infos) == 2 {
+		baseBranch, headInfo = infos[0], infos[1]
+	}
+
+	headRepo, headBranch := ctx.Repo.Repository, headInfo
+	headGitRepo := ctx.Repo.GitRepo
+	if i := strings.Index(headInfo, ":"); i > -1 {
+		headBranch = headInfo[i+1:]
+		headUser, err := models.GetUserByName(headInfo[:i])
+		if err != nil {
+			if err == models.ErrUserNotExist {
+				ctx.Handle(404, "pull.parseCompareInfo(GetUserByName)", nil)
+			} else {
+				ctx.Handle(500, "pull.parseCompareInfo(GetUserByName)", err)
+			}
+			return nil, "", "", false
+		}
+
+		if headUser.Id != ctx.Repo.Owner.Id {
+			fork, has := models.HasForkedRepo(headUser.Id, ctx.Repo.Repository.Id)
+			if !has {
+				ctx.Handle(404, "pull.parseCompareInfo(HasForkedRepo)", nil)
+				return nil, "", "", false
+			}
+			fork.Owner = headUser
+			headRepo = fork
+
+			if headGitRepo, err = git.OpenRepository(models.RepoPath(headUser.Name, fork.Name)); err != nil {
+				ctx.Handle(500, "pull.parseCompareInfo(OpenRepository)", err)
+				return nil, "", "", false
+			}
+			ctx.Data["IsCrossRepo"] = true
+		}
+	}
+
+	if _, err := models.ResolveCommitId(ctx.Repo.GitRepo.Path, baseBranch); err != nil {
+		ctx.Handle(404, "pull.parseCompareInfo(ResolveCommitId)", nil)
+		return nil, "", "", false
+	} else if _, err = models.ResolveCommitId(headGitRepo.Path, headBranch); err != nil {
+		ctx.Handle(404, "pull.parseCompareInfo(ResolveCommitId)", nil)
+		return nil, "", "", false
+	}
+	ctx.Data["IsBranchCompare"] = ctx.Repo.GitRepo.IsBranchExist(baseBranch) && headGitRepo.IsBranchExist(headBranch)
+
+	headBranches, err := headGitRepo.GetBranches()
+	if err != nil {
+		ctx.Handle(500, "pull.parseCompareInfo(GetBranches)", err)
+		return nil, "", "", false
+	}
+	ctx.Data["HeadRepo"] = headRepo
+	ctx.Data["HeadBranches"] = headBranches
+	ctx.Data["BaseBranch"] = baseBranch
+	ctx.Data["HeadBranch"] = headBranch
+	ctx.Data["HeadInfo"] = headInfo
+	return headRepo, baseBranch, headBranch, true
+}
+
+// prepareCompare assigns information of comparison between given branches,
+// it returns false when nothing needs to be rendered further.
+func prepareCompare(ctx *middleware.Context, headRepo *models.Repository, baseBranch, headBranch string) bool {
+	if ctx.Data["IsBranchCompare"].(bool) {
+		pr, err := models.GetUnmergedPullRequest(headRepo.Id, ctx.Repo.Repository.Id, headBranch, baseBranch)
+		if err == nil {
+			if pr.Issue, err = models.GetIssueById(pr.IssueId); err != nil {
+				ctx.Handle(500, "pull.prepareCompare(GetIssueById)", err)
+				return false
+			}
+			ctx.Data["ExistPullRequest"] = pr
+		} else if err != models.ErrPullRequestNotExist {
+			ctx.Handle(500, "pull.prepareCompare(GetUnmergedPullRequest)", err)
+			return false
+		}
+	}
+
+	baseCommitId, err := models.ResolveCommitId(ctx.Repo.GitRepo.Path, baseBranch)
+	if err != nil {
+		ctx.Handle(500, "pull.prepareCompare(ResolveCommitId)", err)
+		return false
+	}
+	headCommitId, err := getHeadCommitId(ctx, headRepo, headBranch)
+	if err != nil {
+		ctx.Handle(500, "pull.prepareCompare(getHeadCommitId)", err)
+		return false
+	}
+	mergeBase, err := models.GetMergeBase(ctx.Repo.GitRepo.Path, baseCommitId, headCommitId)
+	if err != nil {
+		ctx.Handle(500, "pull.prepareCompare(GetMergeBase)", err)
+		return false
+	}
+	ctx.Data["IsNothingToCompare"] = mergeBase == headCommitId
+	if !prepareCompareDiff(ctx, mergeBase, headCommitId) {
+		return false
+	}
+
+	var posterId int64
+	if ctx.IsSigned {
+		posterId = ctx.User.Id
+	}
+	reviewers, err := getSuggestedReviewers(ctx, ctx.Data["Diff"].(*models.Diff), posterId)
+	if err != nil {
+		ctx.Handle(500, "pull.prepareCompare(getSuggestedReviewers)", err)
+		return false
+	}
+	ctx.Data["SuggestedReviewers"] = reviewers
+	return true
+}
+
+// getSuggestedReviewers returns code owners of changed files as reviewers,
+// except poster of pull request.
+func getSuggestedReviewers(ctx *middleware.Context, diff *models.Diff, posterId int64) ([]*models.User, error) {
+	paths := make([]string, len(diff.Files))
+	for i := range diff.Files {
+		paths[i] = diff.Files[i].Name
+	}
+	owners, err := models.GetCodeOwners(ctx.Repo.Repository, paths)
+	if err != nil {
+		return nil, err
+	}
+	reviewers := make([]*models.User, 0, len(owners))
+	for _, u := range owners {
+		if u.Id != posterId {
+			reviewers = append(reviewers, u)
+		}
+	}
+	return reviewers, nil
+}
+
+// prepareForks assigns forks of current repository to compare with.
+func prepareForks(ctx *middleware.Context) bool {
+	forks, err := models.GetForks(ctx.Repo.Repository)
+	if err != nil {
+		ctx.Handle(500, "pull.prepareForks(GetForks)", err)
+		return false
+	}
+	for _, fork := range forks {
+		if err = fork.GetOwner(); err != nil {
+			ctx.Handle(500, "pull.prepareForks(GetOwner)", err)
+			return false
+		}
+	}
+	ctx.Data["Forks"] = forks
+	return true
+}
+
+func CompareAndPullRequest(ctx *middleware.Context, params martini.Params) {
+	ctx.Data["Title"] = "Compare Changes"
+	ctx.Data["IsRepoToolbarPulls"] = true
+
+	if ctx.Repo.Repository.IsBare {
+		ctx.Handle(404, "pull.CompareAndPullRequest", nil)
+		return
+	}
+
+	branches, err := ctx.Repo.GitRepo.GetBranches()
+	if err != nil {
+		ctx.Handle(500, "pull.CompareAndPullRequest(GetBranches)", err)
+		return
+	}
+	ctx.Data["Branches"] = branches
+	if !prepareForks(ctx) {
+		return
+	}
+
+	// Branches are chosen from a form without JavaScript.
+	if len(params["_1"]) == 0 {
+		if base, head := ctx.Query("base"), ctx.Query("head"); len(base) > 0 && len(head) > 0 {
+			ctx.Redirect(fmt.Sprintf("%s/compare/%s...%s", ctx.Repo.RepoLink, base, head))
+			return
+		}
+		ctx.Data["HeadRepo"] = ctx.Repo.Repository
+		ctx.Data["HeadBranches"] = branches
+		ctx.Data["BaseBranch"] = ctx.Repo.Repository.DefaultBranch
+		ctx.Data["HeadBranch"] = ""
+		ctx.HTML(200, PULL_COMPARE)
+		return
+	}
+
+	headRepo, baseBranch, headBranch, ok := parseCompareInfo(ctx, params)
+	if !ok || !prepareCompare(ctx, headRepo, baseBranch, headBranch) {
+		return
+	}
+
+	// Template in head branch takes precedence so it can be changed within the pull request.
+	if ctx.Data["IsBranchCompare"].(bool) && ctx.Data["ExistPullRequest"] == nil {
+		content := models.GetPullRequestTemplate(models.RepoPath(headRepo.Owner.Name, headRepo.Name), headBranch)
+		if len(content) == 0 {
+			content = models.GetPullRequestTemplate(ctx.Repo.GitRepo.Path, baseBranch)
+		}
+		ctx.Data["content"] = content
+	}
+	ctx.HTML(200, PULL_COMPARE)
+}
+
+func CompareAndPullRequestPost(ctx *middleware.Context, params martini.Params, form auth.CreatePullRequestForm) {
+	ctx.Data["Title"] = "Compare Changes"
+	ctx.Data["IsRepoToolbarPulls"] = true
+
+	if ctx.Repo.Repository.IsBare {
+		ctx.Handle(404, "pull.CompareAndPullRequestPost", nil)
+		return
+	}
+
+	branches, err := ctx.Repo.GitRepo.GetBranches()
+	if err != nil {
+		ctx.Handle(500, "pull.CompareAndPullRequestPost(GetBranches)", err)
+		return
+	}
+	ctx.Data["Branches"] = branches
+	if !prepareForks(ctx) {
+		return
+	}
+
+	headRepo, baseBranch, headBranch, ok := parseCompareInfo(ctx, params)
+	if !ok || !prepareCompare(ctx, headRepo, baseBranch, headBranch) {
+		return
+	}
+
+	if !ctx.Data["IsBranchCompare"].(bool) {
+		ctx.Handle(404, "pull.CompareAndPullRequestPost", nil)
+		return
+	} else if ctx.HasError() {
+		ctx.HTML(200, PULL_COMPARE)
+		return
+	} else if ctx.Data["ExistPullRequest"] != nil {
+		ctx.RenderWithErr(models.ErrPullRequestAlreadyExist.Error(), PULL_COMPARE, &form)
+		return
+	} else if ctx.Data["IsNothingToCompare"].(bool) {
+		ctx.RenderWithErr(models.ErrPullRequestNoChanges.Error(), PULL_COMPARE, &form)
+		return
+	}
+
+	issue := &models.Issue{
+		RepoId:   ctx.Repo.Repository.Id,
+		Index:    int64(ctx.Repo.Repository.NumIssues) + 1,
+		Name:     form.Title,
+		PosterId: ctx.User.Id,
+		Content:  form.Content,
+	}
+	// First code owner of changed files is assigned as reviewer, unless it is a draft.
+	reviewers := ctx.Data["SuggestedReviewers"].([]*models.User)
+	if form.IsDraft {
+		reviewers = nil
+	} else if len(reviewers) > 0 {
+		issue.AssigneeId = reviewers[0].Id
+	}
+	pr := &models.PullRequest{
+		HeadRepoId: headRepo.Id,
+		BaseRepoId: ctx.Repo.Repository.Id,
+		HeadBranch: headBranch,
+		BaseBranch: baseBranch,
+		MergeBase:  ctx.Data["MergeBase"].(string),
+		IsDraft:    form.IsDraft,
+	}
+	if err = models.NewPullRequest(ctx.Repo.Repository, issue, pr); err != nil {
+		ctx.Handle(500, "pull.CompareAndPullRequestPost(NewPullRequest)", err)
+		return
+	} else if err = models.NewIssueUserPairs(issue.RepoId, issue.Id, ctx.Repo.Owner.Id,
+		ctx.User.Id, issue.AssigneeId, ctx.Repo.Repository.Name); err != nil {
+		ctx.Handle(500, "pull.CompareAndPullRequestPost(NewIssueUserPairs)", err)
+		return
+	} else if err = models.UpdateIssuesByContent(ctx.User, ctx.Repo.Repository, issue, issue.Content); err != nil {
+		ctx.Handle(500, "pull.CompareAndPullRequestPost(UpdateIssuesByContent)", err)
+		return
+	}
+	if reviewers, err = models.RequestReviews(ctx.User, ctx.Repo.Repository, issue, reviewers); err != nil {
+		ctx.Handle(500, "pull.CompareAndPullRequestPost(RequestReviews)", err)
+		return
+	}
+
+	act := &models.Action{
+		ActUserId:    ctx.User.Id,
+		ActUserName:  ctx.User.Name,
+		ActEmail:     ctx.User.Email,
+		OpType:       models.OP_PULL_REQUEST,
+		Content:      fmt.Sprintf("%d|%s", issue.Index, issue.Name),
+		RepoId:       ctx.Repo.Repository.Id,
+		RepoUserName: ctx.Repo.Owner.Name,
+		RepoName:     ctx.Repo.Repository.Name,
+		RefName:      baseBranch,
+		IsPrivate:    ctx.Repo.Repository.IsPrivate,
+	}
+	if err = models.NotifyWatchers(act); err != nil {
+		ctx.Handle(500, "pull.CompareAndPullRequestPost(NotifyWatchers)", err)
+		return
+	}
+
+	ms, err := updateMentions(ctx, issue, 0, issue.Content)
+	if err != nil {
+		ctx.Handle(500, "pull.CompareAndPullRequestPost(updateMentions)", err)
+		return
+	}
+	// Requested reviewers have been notified already.
+	skips := ms
+	for _, u := range reviewers {
+		skips = append(skips, u.LowerName)
+	}
+	if err = models.NewSubscriptionNotifications(models.NOTIFY_NEW_ISSUE, ctx.User,
+		ctx.Repo.Repository, issue, 0, skips); err != nil {
+		ctx.Handle(500, "pull.CompareAndPullRequestPost(NewSubscriptionNotifications)", err)
+		return
+	}
+	if setting.Service.NotifyMail {
+		if err = mailer.SendIssueMentionMail(ctx.Render, ctx.User, ctx.Repo.Owner,
+			ctx.Repo.Repository, issue, models.GetUserEmailsByNames(ms)); err != nil {
+			ctx.Handle(500, "pull.CompareAndPullRequestPost(SendIssueMentionMail)", err)
+			return
+		} else if err = mailer.SendReviewRequestMail(ctx.Render, ctx.User, ctx.Repo.Owner,
+			ctx.Repo.Repository, issue, reviewers); err != nil {
+			ctx.Handle(500, "pull.CompareAndPullRequestPost(SendReviewRequestMail)", err)
+			return
+		}
+	}
+	log.Trace("%s Pull request created: %d", ctx.Req.RequestURI, issue.Id)
+
+	ctx.Redirect(fmt.Sprintf("%s/pulls/%d", ctx.Repo.RepoLink, issue.Index))
+}
+
+// getPullRequest returns pull request issue by index in URL.
+func getPullRequest(ctx *middleware.Context, params martini.Params) *models.PullRequest {
+	idx, _ := base.StrTo(params["index"]).Int64()
+	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.Id, idx)
+	if err != nil {
+		if err == models.ErrIssueNotExist {
+			ctx.Handle(404, "pull.getPullRequest(GetIssueByIndex)", nil)
+		} else {
+			ctx.Handle(500, "pull.getPullRequest(GetIssueByIndex)", err)
+		}
+		return nil
+	} else if !issue.IsPull {
+		ctx.Redirect(fmt.Sprintf("%s/issues/%d", ctx.Repo.RepoLink, issue.Index))
+		return nil
+	}
+
+	pr, err := models.GetPullRequestByIssueId(issue.Id)
+	if err != nil {
+		ctx.Handle(500, "pull.getPullRequest(GetPullRequestByIssueId)", err)
+		return nil
+	}
+	pr.Issue = issue
+	if err = issue.GetPoster(); err != nil {
+		ctx.Handle(500, "pull.getPullRequest(GetPoster)", err)
+		return nil
+	}
+
+	ctx.Data["Title"] = issue.Name
 	ctx.Data["IsRepoToolbarPulls"] = true
-	ctx.HTML(200, "repo/pulls")
+	ctx.Data["Issue"] = issue
+	ctx.Data["PullRequest"] = pr
+	ctx.Data["IsIssueOwner"] = ctx.Repo.IsOwner || (ctx.IsSigned && issue.PosterId == ctx.User.Id)
+	return pr
+}
+
+// preparePullInfo assigns commits and changes of pull request.
+func preparePullInfo(ctx *middleware.Context, pr *models.PullRequest) bool {
+	ctx.Data["HeadLabel"] = pr.HeadBranch
+	if pr.IsCrossRepo() {
+		if err := pr.GetHeadRepo(); err == nil {
+			ctx.Data["HeadLabel"] = pr.HeadRepo.Owner.Name + ":" + pr.HeadBranch
+		} else if err != models.ErrRepoNotExist {
+			ctx.Handle(500, "pull.preparePullInfo(GetHeadRepo)", err)
+			return false
+		}
+	} else {
+		pr.HeadRepo = ctx.Repo.Repository
+	}
+
+	mergeBase, headCommitId, err := getPullDiffRange(ctx, pr)
+	if err != nil {
+		ctx.Handle(500, "pull.preparePullInfo(getPullDiffRange)", err)
+		return false
+	} else if len(headCommitId) == 0 {
+		ctx.Data["IsBranchMissing"] = true
+		ctx.Data["Commits"] = list.New()
+		ctx.Data["DiffNotAvailable"] = true
+		return true
+	}
+	return prepareCompareDiff(ctx, mergeBase, headCommitId)
+}
+
+// getPullHeadRepo loads head repository of pull request, it is nil if repository has been deleted.
+func getPullHeadRepo(ctx *middleware.Context, pr *models.PullRequest) error {
+	if !pr.IsCrossRepo() {
+		pr.HeadRepo = ctx.Repo.Repository
+	} else if err := pr.GetHeadRepo(); err != nil && err != models.ErrRepoNotExist {
+		return err
+	}
+	return nil
+}
+
+// getPullDiffRange returns merge base and head commit that diff of pull request is between,
+// they are empty if head repository or branches have been deleted. Head repository must
+// have been loaded if it still exists.
+func getPullDiffRange(ctx *middleware.Context, pr *models.PullRequest) (string, string, error) {
+	if pr.HasMerged {
+		return pr.MergeBase, pr.HeadCommitId, nil
+	}
+
+	var headCommitId string
+	var err error
+	if pr.HeadRepo != nil && ctx.Repo.GitRepo.IsBranchExist(pr.BaseBranch) {
+		headCommitId, err = getHeadCommitId(ctx, pr.HeadRepo, pr.HeadBranch)
+	}
+	if len(headCommitId) == 0 || err != nil {
+		return "", "", nil
+	}
+
+	mergeBase, err := models.GetMergeBase(ctx.Repo.GitRepo.Path, pr.BaseBranch, headCommitId)
+	if err != nil {
+		return "", "", err
+	}
+	return mergeBase, headCommitId, nil
+}
+
+func ViewPull(ctx *middleware.Context, params martini.Params) {
+	pr := getPullRequest(ctx, params)
+	if pr == nil || !preparePullInfo(ctx, pr) {
+		return
+	}
+	ctx.Data["IsPullConversation"] = true
+	if !prepareReviewThreads(ctx, pr) || !preparePullReviews(ctx, pr) || !prepareIssueSubscription(ctx, pr.Issue) {
+		return
+	}
+
+	if headCommitId, ok := ctx.Data["HeadCommitId"].(string); ok {
+		statuses, err := models.GetLatestCommitStatuses(ctx.Repo.Repository.Id, headCommitId)
+		if err != nil {
+			ctx.Handle(500, "pull.ViewPull(GetLatestCommitStatuses)", err)
+			return
+		}
+		ctx.Data["CommitStatuses"] = statuses
+		ctx.Data["CommitStatus"] = models.CombineCommitStatuses(statuses)
+	}
+
+	issue := pr.Issue
+	if pr.HasMerged {
+		merger, err := models.GetUserById(pr.MergerId)
+		if err != nil && err != models.ErrUserNotExist {
+			ctx.Handle(500, "pull.ViewPull(GetUserById)", err)
+			return
+		}
+		ctx.Data["Merger"] = merger
+
+		if canWriteHeadRepo(ctx, pr) {
+			if pr.IsHeadDeleted {
+				ctx.Data["CanRestoreHeadBranch"] = pr.IsHeadBranchRestorable()
+			} else if pr.HeadRepo != nil && pr.HeadBranch != pr.HeadRepo.DefaultBranch {
+				headCommitId, err := models.ResolveCommitId(models.RepoPath(pr.HeadRepo.Owner.Name, pr.HeadRepo.Name),
+					"refs/heads/"+pr.HeadBranch)
+				ctx.Data["CanDeleteHeadBranch"] = err == nil && headCommitId == pr.HeadCommitId
+			}
+		}
+	} else {
+		ctx.Data["DeleteMergedHead"] = ctx.Repo.Repository.DeleteMergedHead && canWriteHeadRepo(ctx, pr)
+		ctx.Data["CanResolveConflicts"] = pr.Status == models.PULL_STATUS_CONFLICT && canWriteHeadRepo(ctx, pr)
+		if pr.IsAutoMerge() {
+			merger, err := models.GetUserById(pr.AutoMergerId)
+			if err != nil && err != models.ErrUserNotExist {
+				ctx.Handle(500, "pull.ViewPull(GetUserById)", err)
+				return
+			}
+			ctx.Data["AutoMerger"] = merger
+		}
+
+		if pr.HeadRepo != nil {
+			pr.BaseRepo = ctx.Repo.Repository
+			styles := pr.BaseRepo.AllowedMergeStyles()
+			messages := make(map[string]string, len(styles))
+			for _, style := range styles {
+				messages[style] = pr.MergeMessage(style)
+			}
+			ctx.Data["MergeStyles"] = styles
+			ctx.Data["DefaultMergeStyle"] = pr.BaseRepo.GetDefaultMergeStyle()
+			ctx.Data["MergeMessages"] = messages
+
+			if !preparePullApprovals(ctx, pr) {
+				return
+			}
+		}
+	}
+
+	if ctx.IsSigned {
+		if err := models.UpdateIssueUserPairByRead(ctx.User.Id, issue.Id); err != nil {
+			ctx.Handle(500, "pull.ViewPull(UpdateIssueUserPairByRead)", err)
+			return
+		}
+	}
+	issue.RenderedContent = string(base.RenderMarkdown([]byte(issue.Content), ctx.Repo.RepoLink))
+
+	if err := issue.GetAssignees(); err != nil {
+		ctx.Handle(500, "pull.ViewPull(GetAssignees)", err)
+		return
+	}
+	collaborators, err := models.GetCollaborators(strings.TrimPrefix(ctx.Repo.RepoLink, "/"))
+	if err != nil {
+		ctx.Handle(500, "pull.ViewPull(GetCollaborators)", err)
+		return
+	}
+	ctx.Data["Collaborators"] = collaborators
+
+	comments, err := models.GetIssueComments(issue.Id)
+	if err != nil {
+		ctx.Handle(500, "pull.ViewPull(GetIssueComments)", err)
+		return
+	}
+	if !prepareComments(ctx, issue, comments) {
+		return
+	}
+	prepareIssueAttachmentSettings(ctx)
+	ctx.Data["Comments"] = comments
+	ctx.HTML(200, PULL_VIEW)
+}
+
+// preparePullApprovals assigns approvals of pull request and whether they are
+// enough for protection rules of base branch.
+func preparePullApprovals(ctx *middleware.Context, pr *models.PullRequest) bool {
+	approvals, err := models.GetPullApprovals(pr.IssueId)
+	if err != nil {
+		ctx.Handle(500, "pull.preparePullApprovals(GetPullApprovals)", err)
+		return false
+	}
+	pb, err := pr.ApprovalRule()
+	if err != nil {
+		ctx.Handle(500, "pull.preparePullApprovals(ApprovalRule)", err)
+		return false
+	}
+	if pb != nil {
+		headCommitId, _ := ctx.Data["HeadCommitId"].(string)
+		count, err := pr.CountApprovals(pb, approvals, headCommitId)
+		if err != nil {
+			ctx.Handle(500, "pull.preparePullApprovals(CountApprovals)", err)
+			return false
+		}
+		ctx.Data["RequiredApprovals"] = pb.RequiredApprovals
+		ctx.Data["ApprovalCount"] = count
+		ctx.Data["IsApprovalMissing"] = count < pb.RequiredApprovals
+	}
+
+	staleApprovers := make(map[int64]bool)
+	for _, a := range approvals {
+		if a.IsStale {
+			staleApprovers[a.ReviewerId] = true
+		}
+	}
+	ctx.Data["StaleApprovers"] = staleApprovers
+	return true
+}
+
+// preparePullReviews assigns submitted reviews of pull request, latest verdict of
+// each reviewer and pending review of signed in user.
+func preparePullReviews(ctx *middleware.Context, pr *models.PullRequest) bool {
+	reviews, err := models.GetPullReviews(pr.IssueId)
+	if err != nil {
+		ctx.Handle(500, "pull.preparePullReviews(GetPullReviews)", err)
+		return false
+	}
+
+	// Verdict of reviewer is replaced by later ones, but not by plain comments.
+	reviewers := make([]*models.PullReview, 0, len(reviews))
+	latest := make(map[int64]int, len(reviews))
+	for _, r := range reviews {
+		r.RenderedContent = string(base.RenderMarkdown([]byte(r.Content), ctx.Repo.RepoLink))
+		if i, ok := latest[r.ReviewerId]; !ok {
+			latest[r.ReviewerId] = len(reviewers)
+			reviewers = append(reviewers, r)
+		} else if r.Type != models.REVIEW_COMMENT || reviewers[i].Type == models.REVIEW_COMMENT {
+			reviewers[i] = r
+		}
+	}
+	ctx.Data["Reviews"] = reviews
+	ctx.Data["Reviewers"] = reviewers
+
+	if ctx.Data["ReviewRequests"], err = models.GetReviewRequests(pr.IssueId); err != nil {
+		ctx.Handle(500, "pull.preparePullReviews(GetReviewRequests)", err)
+		return false
+	}
+
+	if ctx.IsSigned {
+		r, err := models.GetPendingReview(pr.IssueId, ctx.User.Id)
+		if err != nil && err != models.ErrPullReviewNotExist {
+			ctx.Handle(500, "pull.preparePullReviews(GetPendingReview)", err)
+			return false
+		}
+		ctx.Data["PendingReview"] = r
+	}
+	return true
+}
+
+// prepareReviewThreads assigns review threads of pull request and attaches them
+// to lines of its current diff.
+func prepareReviewThreads(ctx *middleware.Context, pr *models.PullRequest) bool {
+	var viewerId int64
+	if ctx.IsSigned {
+		viewerId = ctx.User.Id
+	}
+	threads, err := models.GetReviewThreads(pr.IssueId, viewerId)
+	if err != nil {
+		ctx.Handle(500, "pull.prepareReviewThreads(GetReviewThreads)", err)
+		return false
+	}
+
+	diff, ok := ctx.Data["Diff"].(*models.Diff)
+	if ok {
+		if err = models.AttachReviewThreads(diff, threads, ctx.Data["HeadCommitId"].(string)); err != nil {
+			ctx.Handle(500, "pull.prepareReviewThreads(AttachReviewThreads)", err)
+			return false
+		}
+	}
+
+	// Suggested changes are committed to head branch by users who can push to it.
+	canApply := !pr.HasMerged && !pr.Issue.IsClosed && canWriteHeadRepo(ctx, pr)
+	outdated := make([]*models.ReviewThread, 0, len(threads))
+	numUnresolved := 0
+	for _, t := range threads {
+		if !ok {
+			t.IsOutdated = true
+		}
+		for _, c := range t.Comments {
+			c.RenderedContent = string(base.RenderMarkdown([]byte(c.Content), ctx.Repo.RepoLink))
+			if _, has := c.Suggestion(); has {
+				c.CanApply = canApply && !c.IsApplied && !c.IsPending && !t.IsOutdated && !t.IsOldSide
+			}
+		}
+		if t.IsOutdated {
+			outdated = append(outdated, t)
+		}
+		if !t.IsResolved {
+			numUnresolved++
+		}
+	}
+	ctx.Data["ReviewThreads"] = threads
+	ctx.Data["OutdatedReviewThreads"] = outdated
+	ctx.Data["NumUnresolvedThreads"] = numUnresolved
+	ctx.Data["CanReview"] = ctx.IsSigned && !ctx.Repo.Repository.IsArchived && !pr.Issue.IsClosed
+	return true
+}
+
+func ViewPullCommits(ctx *middleware.Context, params martini.Params) {
+	pr := getPullRequest(ctx, params)
+	if pr == nil || !preparePullInfo(ctx, pr) {
+		return
+	}
+	ctx.Data["IsPullCommits"] = true
+	ctx.HTML(200, PULL_VIEW)
+}
+
+func ViewPullFiles(ctx *middleware.Context, params martini.Params) {
+	pr := getPullRequest(ctx, params)
+	if pr == nil || !preparePullInfo(ctx, pr) {
+		return
+	}
+	ctx.Data["IsPullFiles"] = true
+	ctx.Data["DiffFileLink"] = fmt.Sprintf("%s/pulls/%d/files/diff", ctx.Repo.RepoLink, pr.Issue.Index)
+	if !prepareReviewThreads(ctx, pr) || !preparePullReviews(ctx, pr) {
+		return
+	}
+	ctx.HTML(200, PULL_VIEW)
+}
+
+// ViewPullFileDiff renders full diff of a single file of pull request, it is used to load
+// files that are collapsed in diff.
+func ViewPullFileDiff(ctx *middleware.Context, params martini.Params) {
+	pr := getPullRequest(ctx, params)
+	if pr == nil {
+		return
+	} else if err := getPullHeadRepo(ctx, pr); err != nil {
+		ctx.Handle(500, "pull.ViewPullFileDiff(getPullHeadRepo)", err)
+		return
+	}
+
+	mergeBase, headCommitId, err := getPullDiffRange(ctx, pr)
+	if err != nil {
+		ctx.Handle(500, "pull.ViewPullFileDiff(getPullDiffRange)", err)
+		return
+	} else if len(headCommitId) == 0 {
+		ctx.Handle(404, "pull.ViewPullFileDiff", nil)
+		return
+	}
+	diff, err := models.GetDiffRangeFile(ctx.Repo.GitRepo.Path, mergeBase, headCommitId, ctx.Query("path"))
+	if err != nil {
+		if err == models.ErrDiffFileNotExist {
+			ctx.Handle(404, "pull.ViewPullFileDiff", nil)
+		} else {
+			ctx.Handle(500, "pull.ViewPullFileDiff(GetDiffRangeFile)", err)
+		}
+		return
+	}
+	setDiffData(ctx, diff, mergeBase, headCommitId)
+	if !prepareReviewThreads(ctx, pr) || !preparePullReviews(ctx, pr) {
+		return
+	}
+	ctx.HTML(200, DIFF_FILES)
+}
+
+func MergePullRequest(ctx *middleware.Context, params martini.Params) {
+	if !ctx.Repo.IsOwner {
+		ctx.Handle(404, "pull.MergePullRequest", nil)
+		return
+	}
+
+	pr := getPullRequest(ctx, params)
+	if pr == nil {
+		return
+	} else if pr.Issue.IsClosed {
+		ctx.Handle(404, "pull.MergePullRequest", nil)
+		return
+	}
+
+	link := fmt.Sprintf("%s/pulls/%d", ctx.Repo.RepoLink, pr.Issue.Index)
+	if pr.IsCrossRepo() {
+		if err := pr.GetHeadRepo(); err != nil && err != models.ErrRepoNotExist {
+			ctx.Handle(500, "pull.MergePullRequest(GetHeadRepo)", err)
+			return
+		}
+	} else {
+		pr.HeadRepo = ctx.Repo.Repository
+	}
+	style := ctx.Query("merge_style")
+	if len(style) == 0 {
+		style = ctx.Repo.Repository.GetDefaultMergeStyle()
+	}
+	if err := pr.Merge(ctx.User, style, strings.TrimSpace(ctx.Query("merge_message"))); err != nil {
+		switch err {
+		case models.ErrPullRequestNotMergeable, models.ErrPullRequestMerged, models.ErrMergeStyleNotAllowed,
+			models.ErrPullRequestNotApproved, models.ErrPullRequestIsDraft:
+			ctx.Flash.Error(err.Error())
+			ctx.Redirect(link)
+		default:
+			ctx.Handle(500, "pull.MergePullRequest(Merge)", err)
+		}
+		return
+	}
+	log.Trace("%s Pull request merged(%s): %d", ctx.Req.RequestURI, style, pr.Issue.Id)
+
+	if ctx.Query("delete_head") == "on" && canWriteHeadRepo(ctx, pr) {
+		if err := pr.DeleteHeadBranch(ctx.User); err != nil {
+			// Pull request has been merged, so failing to delete branch is not fatal.
+			ctx.Flash.Error("Pull request has been merged, but head branch cannot be deleted: " + err.Error())
+			ctx.Redirect(link)
+			return
+		}
+		log.Trace("%s Head branch of pull request deleted: %d", ctx.Req.RequestURI, pr.Issue.Id)
+	}
+
+	ctx.Flash.Success("Pull request has been merged.")
+	ctx.Redirect(link)
+}
+
+// AutoMergePullRequest enables auto-merge of pull request with chosen merge style,
+// or disables it if action is "disable". Pull request is merged right away if it can be.
+func AutoMergePullRequest(ctx *middleware.Context, params martini.Params) {
+	if !ctx.Repo.IsOwner {
+		ctx.Handle(404, "pull.AutoMergePullRequest", nil)
+		return
+	}
+
+	pr := getPullRequest(ctx, params)
+	if pr == nil {
+		return
+	} else if pr.Issue.IsClosed {
+		ctx.Handle(404, "pull.AutoMergePullRequest", nil)
+		return
+	}
+	pr.BaseRepo = ctx.Repo.Repository
+
+	link := fmt.Sprintf("%s/pulls/%d", ctx.Repo.RepoLink, pr.Issue.Index)
+	if ctx.Query("action") == "disable" {
+		if err := pr.DisableAutoMerge(ctx.User, ""); err != nil && err != models.ErrAutoMergeNotEnabled {
+			ctx.Handle(500, "pull.AutoMergePullRequest(DisableAutoMerge)", err)
+			return
+		}
+		log.Trace("%s Auto-merge disabled: %d", ctx.Req.RequestURI, pr.Issue.Id)
+
+		ctx.Flash.Success("Auto-merge has been disabled.")
+		ctx.Redirect(link)
+		return
+	}
+
+	style := ctx.Query("merge_style")
+	if len(style) == 0 {
+		style = ctx.Repo.Repository.GetDefaultMergeStyle()
+	}
+	if err := pr.EnableAutoMerge(ctx.User, style); err != nil {
+		switch err {
+		case models.ErrPullRequestMerged, models.ErrMergeStyleNotAllowed, models.ErrPullRequestIsDraft:
+			ctx.Flash.Error(err.Error())
+			ctx.Redirect(link)
+		default:
+			ctx.Handle(500, "pull.AutoMergePullRequest(EnableAutoMerge)", err)
+		}
+		return
+	}
+	log.Trace("%s Auto-merge enabled(%s): %d", ctx.Req.RequestURI, style, pr.Issue.Id)
+
+	merged, err := pr.TryAutoMerge()
+	if err != nil {
+		ctx.Handle(500, "pull.AutoMergePullRequest(TryAutoMerge)", err)
+		return
+	} else if merged {
+		ctx.Flash.Success("Pull request has been merged.")
+	} else if pr.IsAutoMerge() {
+		ctx.Flash.Success("Auto-merge has been enabled, pull request will be merged once all checks pass.")
+	}
+	ctx.Redirect(link)
+}
+
+// ReadyPullRequest marks draft pull request as ready for review,
+// code owners of changed files are requested to review it.
+func ReadyPullRequest(ctx *middleware.Context, params martini.Params) {
+	pr := getPullRequest(ctx, params)
+	if pr == nil {
+		return
+	} else if !pr.IsDraft || pr.Issue.IsClosed || !ctx.Data["IsIssueOwner"].(bool) {
+		ctx.Handle(404, "pull.ReadyPullRequest", nil)
+		return
+	} else if !preparePullInfo(ctx, pr) {
+		return
+	}
+
+	var reviewers []*models.User
+	if diff, ok := ctx.Data["Diff"].(*models.Diff); ok {
+		var err error
+		if reviewers, err = getSuggestedReviewers(ctx, diff, pr.Issue.PosterId); err != nil {
+			ctx.Handle(500, "pull.ReadyPullRequest(getSuggestedReviewers)", err)
+			return
+		}
+	}
+	reviewers, err := pr.MarkReadyForReview(ctx.User, ctx.Repo.Repository, reviewers)
+	if err != nil {
+		ctx.Handle(500, "pull.ReadyPullRequest(MarkReadyForReview)", err)
+		return
+	}
+	if setting.Service.NotifyMail {
+		if err = mailer.SendReviewRequestMail(ctx.Render, ctx.User, ctx.Repo.Owner,
+			ctx.Repo.Repository, pr.Issue, reviewers); err != nil {
+			ctx.Handle(500, "pull.ReadyPullRequest(SendReviewRequestMail)", err)
+			return
+		}
+	}
+	log.Trace("%s Pull request marked ready for review: %d", ctx.Req.RequestURI, pr.Issue.Id)
+
+	ctx.Flash.Success("Pull request is ready for review.")
+	ctx.Redirect(fmt.Sprintf("%s/pulls/%d", ctx.Repo.RepoLink, pr.Issue.Index))
+}
+
+// RequestPullReview requests a user to review pull request, only author of pull request
+// and owners of repository can request reviews.
+func RequestPullReview(ctx *middleware.Context, params martini.Params) {
+	pr := getPullRequest(ctx, params)
+	if pr == nil {
+		return
+	} else if pr.Issue.IsClosed || !ctx.Data["IsIssueOwner"].(bool) {
+		ctx.Handle(404, "pull.RequestPullReview", nil)
+		return
+	}
+
+	link := fmt.Sprintf("%s/pulls/%d", ctx.Repo.RepoLink, pr.Issue.Index)
+	u, err := models.GetUserByName(ctx.Query("reviewer"))
+	if err != nil {
+		if err == models.ErrUserNotExist {
+			ctx.Flash.Error("User does not exist.")
+			ctx.Redirect(link)
+			return
+		}
+		ctx.Handle(500, "pull.RequestPullReview(GetUserByName)", err)
+		return
+	}
+
+	reviewers, err := models.RequestReview(ctx.User, ctx.Repo.Repository, pr.Issue, u)
+	if err != nil {
+		switch err {
+		case models.ErrReviewRequestOwnPull, models.ErrReviewRequestNoAccess:
+			ctx.Flash.Error(err.Error())
+			ctx.Redirect(link)
+		default:
+			ctx.Handle(500, "pull.RequestPullReview(RequestReview)", err)
+		}
+		return
+	}
+	if setting.Service.NotifyMail {
+		if err = mailer.SendReviewRequestMail(ctx.Render, ctx.User, ctx.Repo.Owner,
+			ctx.Repo.Repository, pr.Issue, reviewers); err != nil {
+			ctx.Handle(500, "pull.RequestPullReview(SendReviewRequestMail)", err)
+			return
+		}
+	}
+	log.Trace("%s Review requested from %s: %d", ctx.Req.RequestURI, u.Name, pr.Issue.Id)
+
+	ctx.Redirect(link)
+}
+
+// RemovePullReviewRequest removes pending review request of a user.
+func RemovePullReviewRequest(ctx *middleware.Context, params martini.Params) {
+	pr := getPullRequest(ctx, params)
+	if pr == nil {
+		return
+	} else if !ctx.Data["IsIssueOwner"].(bool) {
+		ctx.Handle(404, "pull.RemovePullReviewRequest", nil)
+		return
+	}
+
+	uid, _ := base.StrTo(params["uid"]).Int64()
+	if err := models.DeleteReviewRequest(pr.IssueId, uid); err != nil {
+		ctx.Handle(500, "pull.RemovePullReviewRequest(DeleteReviewRequest)", err)
+		return
+	}
+	log.Trace("%s Review request removed: %d", ctx.Req.RequestURI, pr.Issue.Id)
+
+	ctx.Redirect(fmt.Sprintf("%s/pulls/%d", ctx.Repo.RepoLink, pr.Issue.Index))
+}
+
+// SubmitPullReview submits pending review of signed in user, or a new review without
+// comments, with a verdict on latest commit of head branch.
+func SubmitPullReview(ctx *middleware.Context, params martini.Params) {
+	pr := getPullRequest(ctx, params)
+	if pr == nil {
+		return
+	} else if pr.HasMerged || pr.Issue.IsClosed {
+		ctx.Handle(404, "pull.SubmitPullReview", nil)
+		return
+	}
+
+	var reviewType int
+	switch ctx.Query("verdict") {
+	case "comment":
+		reviewType = models.REVIEW_COMMENT
+	case "approve":
+		reviewType = models.REVIEW_APPROVE
+	case "request_changes":
+		reviewType = models.REVIEW_REQUEST_CHANGES
+	default:
+		ctx.Handle(404, "pull.SubmitPullReview", nil)
+		return
+	}
+
+	link := fmt.Sprintf("%s/pulls/%d", ctx.Repo.RepoLink, pr.Issue.Index)
+	if pr.IsCrossRepo() {
+		if err := pr.GetHeadRepo(); err != nil && err != models.ErrRepoNotExist {
+			ctx.Handle(500, "pull.SubmitPullReview(GetHeadRepo)", err)
+			return
+		}
+	} else {
+		pr.HeadRepo = ctx.Repo.Repository
+	}
+	var headCommitId string
+	var err error
+	if pr.HeadRepo != nil {
+		headCommitId, err = getHeadCommitId(ctx, pr.HeadRepo, pr.HeadBranch)
+	}
+	if len(headCommitId) == 0 || err != nil {
+		ctx.Flash.Error("Branches of this pull request no longer exist.")
+		ctx.Redirect(link)
+		return
+	}
+
+	r, err := models.SubmitPullReview(pr, ctx.User, reviewType, strings.TrimSpace(ctx.Query("content")), headCommitId)
+	if err != nil {
+		switch err {
+		case models.ErrPullApprovalOwnRequest, models.ErrPullReviewEmpty:
+			ctx.Flash.Error(err.Error())
+			ctx.Redirect(link + "/files#pull-review-form")
+		default:
+			ctx.Handle(500, "pull.SubmitPullReview(SubmitPullReview)", err)
+		}
+		return
+	}
+	log.Trace("%s Pull request review submitted(%d): %d", ctx.Req.RequestURI, reviewType, r.Id)
+
+	ctx.Flash.Success("Your review has been submitted.")
+	ctx.Redirect(fmt.Sprintf("%s#pull-review-%d", link, r.Id))
+}
+
+// pendingReviewId returns ID of pending review of signed in user when review comment
+// is added to a review rather than posted alone, a review is started if user has none.
+func pendingReviewId(ctx *middleware.Context, pr *models.PullRequest) (int64, error) {
+	if len(ctx.Query("review")) == 0 {
+		return 0, nil
+	}
+	r, err := models.GetOrCreatePendingReview(pr.IssueId, ctx.User.Id)
+	if err != nil {
+		return 0, err
+	}
+	return r.Id, nil
+}
+
+// NewReviewThread starts a review thread on a line of current diff of pull request.
+func NewReviewThread(ctx *middleware.Context, params martini.Params) {
+	pr := getPullRequest(ctx, params)
+	if pr == nil {
+		return
+	} else if pr.Issue.IsClosed {
+		ctx.Handle(404, "pull.NewReviewThread", nil)
+		return
+	} else if err := getPullHeadRepo(ctx, pr); err != nil {
+		ctx.Handle(500, "pull.NewReviewThread(getPullHeadRepo)", err)
+		return
+	}
+
+	link := fmt.Sprintf("%s/pulls/%d/files", ctx.Repo.RepoLink, pr.Issue.Index)
+	if page, _ := base.StrTo(ctx.Query("page")).Int(); page > 1 {
+		link += "?page=" + base.ToStr(page)
+	}
+	content := strings.TrimSpace(ctx.Query("content"))
+	mergeBase, headCommitId, err := getPullDiffRange(ctx, pr)
+	if err != nil {
+		ctx.Handle(500, "pull.NewReviewThread(getPullDiffRange)", err)
+		return
+	} else if len(headCommitId) == 0 {
+		ctx.Flash.Error("Branches of this pull request no longer exist.")
+		ctx.Redirect(link)
+		return
+	} else if len(content) == 0 {
+		ctx.Flash.Error("Review comment cannot be empty.")
+		ctx.Redirect(link)
+		return
+	}
+
+	// Only the commented file is needed, it may be collapsed or on any page of diff.
+	line, _ := base.StrTo(ctx.Query("line")).Int()
+	t := &models.ReviewThread{
+		IssueId:   pr.IssueId,
+		PosterId:  ctx.User.Id,
+		TreePath:  ctx.Query("path"),
+		Line:      line,
+		IsOldSide: ctx.Query("side") == "old",
+		CommitId:  headCommitId,
+	}
+	diff, err := models.GetDiffRangeFile(ctx.Repo.GitRepo.Path, mergeBase, headCommitId, t.TreePath)
+	if err == models.ErrDiffFileNotExist {
+		ctx.Flash.Error(models.ErrReviewLineNotExist.Error())
+		ctx.Redirect(link)
+		return
+	} else if err != nil {
+		ctx.Handle(500, "pull.NewReviewThread(GetDiffRangeFile)", err)
+		return
+	}
+	reviewId, err := pendingReviewId(ctx, pr)
+	if err != nil {
+		ctx.Handle(500, "pull.NewReviewThread(pendingReviewId)", err)
+		return
+	}
+	if err = models.NewReviewThread(diff, t, reviewId, content); err != nil {
+		if err == models.ErrReviewLineNotExist {
+			ctx.Flash.Error(err.Error())
+			ctx.Redirect(link)
+		} else {
+			ctx.Handle(500, "pull.NewReviewThread(NewReviewThread)", err)
+		}
+		return
+	}
+	log.Trace("%s Review thread created: %d", ctx.Req.RequestURI, t.Id)
+
+	ctx.Redirect(fmt.Sprintf("%s#review-thread-%d", link, t.Id))
+}
+
+// getReviewThread returns review thread of pull request by ID in URL,
+// it returns nil if response has been written.
+func getReviewThread(ctx *middleware.Context, params martini.Params, pr *models.PullRequest) *models.ReviewThread {
+	id, _ := base.StrTo(params["id"]).Int64()
+	t, err := models.GetReviewThreadById(pr.IssueId, id)
+	if err != nil {
+		if err == models.ErrReviewThreadNotExist {
+			ctx.Handle(404, "pull.getReviewThread(GetReviewThreadById)", nil)
+		} else {
+			ctx.Handle(500, "pull.getReviewThread(GetReviewThreadById)", err)
+		}
+		return nil
+	}
+	return t
+}
+
+// reviewThreadLink returns link to review thread in the tab of pull request it is commented from.
+func reviewThreadLink(ctx *middleware.Context, pr *models.PullRequest, t *models.ReviewThread) string {
+	link := fmt.Sprintf("%s/pulls/%d", ctx.Repo.RepoLink, pr.Issue.Index)
+	if ctx.Query("from") == "files" {
+		link += "/files"
+	}
+	return fmt.Sprintf("%s#review-thread-%d", link, t.Id)
+}
+
+// ReplyReviewThread adds a comment to review thread.
+func ReplyReviewThread(ctx *middleware.Context, params martini.Params) {
+	pr := getPullRequest(ctx, params)
+	if pr == nil {
+		return
+	}
+	t := getReviewThread(ctx, params, pr)
+	if t == nil {
+		return
+	}
+
+	link := reviewThreadLink(ctx, pr, t)
+	content := strings.TrimSpace(ctx.Query("content"))
+	if len(content) == 0 {
+		ctx.Flash.Error("Review comment cannot be empty.")
+		ctx.Redirect(link)
+		return
+	}
+	reviewId, err := pendingReviewId(ctx, pr)
+	if err != nil {
+		ctx.Handle(500, "pull.ReplyReviewThread(pendingReviewId)", err)
+		return
+	}
+	if err = models.NewReviewComment(t, ctx.User.Id, reviewId, content); err != nil {
+		ctx.Handle(500, "pull.ReplyReviewThread(NewReviewComment)", err)
+		return
+	}
+	log.Trace("%s Review thread replied: %d", ctx.Req.RequestURI, t.Id)
+
+	ctx.Redirect(link)
+}
+
+// ResolveReviewThread marks review thread as resolved, or unresolved if action is "unresolve".
+// Collaborators, author of pull request and starter of thread can resolve it.
+func ResolveReviewThread(ctx *middleware.Context, params martini.Params) {
+	pr := getPullRequest(ctx, params)
+	if pr == nil {
+		return
+	}
+	t := getReviewThread(ctx, params, pr)
+	if t == nil {
+		return
+	}
+	if !ctx.Repo.IsOwner && ctx.User.Id != pr.Issue.PosterId && ctx.User.Id != t.PosterId {
+		ctx.Handle(403, "pull.ResolveReviewThread", nil)
+		return
+	}
+
+	isResolved := ctx.Query("action") != "unresolve"
+	if err := models.ResolveReviewThread(t, ctx.User, isResolved); err != nil {
+		ctx.Handle(500, "pull.ResolveReviewThread(ResolveReviewThread)", err)
+		return
+	}
+	log.Trace("%s Review thread resolved(%v): %d", ctx.Req.RequestURI, isResolved, t.Id)
+
+	ctx.Redirect(reviewThreadLink(ctx, pr, t))
+}
+
+// ApplyReviewSuggestion commits suggested change of a review comment to head branch.
+func ApplyReviewSuggestion(ctx *middleware.Context, params martini.Params) {
+	pr := getPullRequest(ctx, params)
+	if pr == nil {
+		return
+	} else if pr.HasMerged || pr.Issue.IsClosed {
+		ctx.Handle(404, "pull.ApplyReviewSuggestion", nil)
+		return
+	}
+	t := getReviewThread(ctx, params, pr)
+	if t == nil {
+		return
+	}
+	cid, _ := base.StrTo(ctx.Query("comment")).Int64()
+	c, err := models.GetReviewCommentById(t.Id, cid)
+	if err != nil {
+		if err == models.ErrReviewCommentNotExist {
+			ctx.Handle(404, "pull.ApplyReviewSuggestion(GetReviewCommentById)", nil)
+		} else {
+			ctx.Handle(500, "pull.ApplyReviewSuggestion(GetReviewCommentById)", err)
+		}
+		return
+	}
+
+	if err = pr.GetHeadRepo(); err != nil && err != models.ErrRepoNotExist {
+		ctx.Handle(500, "pull.ApplyReviewSuggestion(GetHeadRepo)", err)
+		return
+	} else if !canWriteHeadRepo(ctx, pr) {
+		ctx.Handle(403, "pull.ApplyReviewSuggestion", nil)
+		return
+	}
+
+	link := reviewThreadLink(ctx, pr, t)
+	switch err = models.ApplyReviewSuggestion(ctx.User, pr, t, c); err {
+	case nil:
+	case models.ErrSuggestionNotExist, models.ErrSuggestionApplied, models.ErrSuggestionOutdated,
+		models.ErrBranchNotExist, models.ErrRepoFileChanged, models.ErrBranchPushRestricted,
+		models.ErrBranchRequirePullRequest, models.ErrRepoArchived:
+		ctx.Flash.Error(err.Error())
+		ctx.Redirect(link)
+		return
+	default:
+		ctx.Handle(500, "pull.ApplyReviewSuggestion(ApplyReviewSuggestion)", err)
+		return
+	}
+	log.Trace("%s Review suggestion applied: %d", ctx.Req.RequestURI, c.Id)
+
+	ctx.Flash.Success(fmt.Sprintf("Suggested change has been committed to branch %s.", pr.HeadBranch))
+	ctx.Redirect(link)
+}
+
+// getConflictedPullRequest returns open pull request that has conflicts which signed in
+// user can resolve by pushing to its head branch.
+func getConflictedPullRequest(ctx *middleware.Context, params martini.Params) *models.PullRequest {
+	pr := getPullRequest(ctx, params)
+	if pr == nil {
+		return nil
+	} else if pr.HasMerged || pr.Issue.IsClosed || pr.Status != models.PULL_STATUS_CONFLICT {
+		ctx.Handle(404, "pull.getConflictedPullRequest", nil)
+		return nil
+	}
+
+	if err := pr.GetHeadRepo(); err != nil {
+		if err == models.ErrRepoNotExist {
+			ctx.Handle(404, "pull.getConflictedPullRequest(GetHeadRepo)", nil)
+		} else {
+			ctx.Handle(500, "pull.getConflictedPullRequest(GetHeadRepo)", err)
+		}
+		return nil
+	} else if !canWriteHeadRepo(ctx, pr) {
+		ctx.Handle(403, "pull.getConflictedPullRequest", nil)
+		return nil
+	}
+	pr.BaseRepo = ctx.Repo.Repository
+	return pr
+}
+
+// PullConflicts shows files that have conflicts with base branch in an editor.
+func PullConflicts(ctx *middleware.Context, params martini.Params) {
+	pr := getConflictedPullRequest(ctx, params)
+	if pr == nil {
+		return
+	}
+
+	headCommitId, files, err := pr.GetConflictFiles()
+	if err != nil {
+		if err == models.ErrPullRequestNoConflict {
+			ctx.Flash.Success("Pull request has no conflicts any more.")
+			ctx.Redirect(fmt.Sprintf("%s/pulls/%d", ctx.Repo.RepoLink, pr.Issue.Index))
+			return
+		}
+		ctx.Handle(500, "pull.PullConflicts(GetConflictFiles)", err)
+		return
+	}
+	hasBinary := false
+	for _, f := range files {
+		hasBinary = hasBinary || f.IsBinary
+	}
+
+	ctx.Data["HeadCommitId"] = headCommitId
+	ctx.Data["ConflictFiles"] = files
+	ctx.Data["HasBinaryConflict"] = hasBinary
+	ctx.HTML(200, PULL_CONFLICTS)
+}
+
+// PullConflictsPost commits resolution of conflicts as merge of base branch into head branch.
+func PullConflictsPost(ctx *middleware.Context, params martini.Params) {
+	pr := getConflictedPullRequest(ctx, params)
+	if pr == nil {
+		return
+	}
+
+	ctx.Req.ParseForm()
+	paths, contents := ctx.Req.Form["path"], ctx.Req.Form["content"]
+	if len(paths) != len(contents) {
+		ctx.Handle(400, "pull.PullConflictsPost", nil)
+		return
+	}
+	resolved := make(map[string]string, len(paths))
+	files := make([]*models.ConflictFile, len(paths))
+	for i := range paths {
+		resolved[paths[i]] = contents[i]
+		files[i] = &models.ConflictFile{Name: paths[i], Content: contents[i]}
+	}
+
+	headCommitId := ctx.Query("head_commit")
+	switch err := pr.ResolveConflicts(ctx.User, headCommitId, resolved); err {
+	case nil:
+	case models.ErrConflictNotResolved, models.ErrConflictBinaryFile, models.ErrRepoFileChanged,
+		models.ErrBranchPushRestricted, models.ErrBranchRequirePullRequest, models.ErrRepoArchived:
+		// Keep resolution of user so it can be corrected.
+		ctx.Data["HeadCommitId"] = headCommitId
+		ctx.Data["ConflictFiles"] = files
+		ctx.RenderWithErr(err.Error(), PULL_CONFLICTS, nil)
+		return
+	case models.ErrPullRequestNoConflict:
+		ctx.Flash.Success("Pull request has no conflicts any more.")
+		ctx.Redirect(fmt.Sprintf("%s/pulls/%d", ctx.Repo.RepoLink, pr.Issue.Index))
+		return
+	default:
+		ctx.Handle(500, "pull.PullConflictsPost(ResolveConflicts)", err)
+		return
+	}
+	log.Trace("%s Pull request conflicts resolved: %d", ctx.Req.RequestURI, pr.Issue.Id)
+
+	ctx.Flash.Success(fmt.Sprintf("Conflicts have been resolved and committed to branch %s.", pr.HeadBranch))
+	ctx.Redirect(fmt.Sprintf("%s/pulls/%d", ctx.Repo.RepoLink, pr.Issue.Index))
+}
+
+// canWriteHeadRepo returns true if signed in user can push to head repository of pull request.
+func canWriteHeadRepo(ctx *middleware.Context, pr *models.PullRequest) bool {
+	if !ctx.IsSigned || pr.HeadRepo == nil {
+		return false
+	} else if !pr.IsCrossRepo() {
+		return ctx.Repo.IsOwner
+	} else if ctx.User.IsAdmin || pr.HeadRepo.OwnerId == ctx.User.Id {
+		return true
+	}
+	mode, err := models.GetAccessMode(ctx.User.Name, pr.HeadRepo.Owner.Name+"/"+pr.HeadRepo.Name)
+	if err != nil {
+		log.Error("pull.canWriteHeadRepo(GetAccessMode): %v", err)
+	}
+	return mode >= models.AU_WRITABLE
+}
+
+// getMergedPullRequest returns merged pull request whose head branch can be changed by signed in user,
+// it returns nil if response has been written.
+func getMergedPullRequest(ctx *middleware.Context, params martini.Params) *models.PullRequest {
+	pr := getPullRequest(ctx, params)
+	if pr == nil {
+		return nil
+	}
+	if pr.IsCrossRepo() {
+		if err := pr.GetHeadRepo(); err != nil && err != models.ErrRepoNotExist {
+			ctx.Handle(500, "pull.getMergedPullRequest(GetHeadRepo)", err)
+			return nil
+		}
+	} else {
+		pr.HeadRepo = ctx.Repo.Repository
+	}
+	if !pr.HasMerged || !canWriteHeadRepo(ctx, pr) {
+		ctx.Handle(404, "pull.getMergedPullRequest", nil)
+		return nil
+	}
+	return pr
+}
+
+func DeletePullHeadBranch(ctx *middleware.Context, params martini.Params) {
+	pr := getMergedPullRequest(ctx, params)
+	if pr == nil {
+		return
+	}
+
+	link := fmt.Sprintf("%s/pulls/%d", ctx.Repo.RepoLink, pr.Issue.Index)
+	if err := pr.DeleteHeadBranch(ctx.User); err != nil {
+		switch err {
+		case models.ErrBranchNotExist, models.ErrHeadBranchChanged, models.ErrDeleteDefaultBranch, models.ErrRepoArchived,
+			models.ErrBranchPushRestricted, models.ErrBranchDeletionBlocked, models.ErrBranchRequirePullRequest:
+			ctx.Flash.Error(err.Error())
+			ctx.Redirect(link)
+		default:
+			ctx.Handle(500, "pull.DeletePullHeadBranch(DeleteHeadBranch)", err)
+		}
+		return
+	}
+	log.Trace("%s Head branch of pull request deleted: %d", ctx.Req.RequestURI, pr.Issue.Id)
+
+	ctx.Flash.Success("Branch " + pr.HeadBranch + " has been deleted.")
+	ctx.Redirect(link)
+}
+
+func RestorePullHeadBranch(ctx *middleware.Context, params martini.Params) {
+	pr := getMergedPullRequest(ctx, params)
+	if pr == nil {
+		return
+	}
+
+	link := fmt.Sprintf("%s/pulls/%d", ctx.Repo.RepoLink, pr.Issue.Index)
+	if err := pr.RestoreHeadBranch(ctx.User); err != nil {
+		switch err {
+		case models.ErrHeadBranchNotRestorable, models.ErrRepoArchived,
+			models.ErrBranchPushRestricted, models.ErrBranchRequirePullRequest:
+			ctx.Flash.Error(err.Error())
+			ctx.Redirect(link)
+		default:
+			ctx.Handle(500, "pull.RestorePullHeadBranch(RestoreHeadBranch)", err)
+		}
+		return
+	}
+	log.Trace("%s Head branch of pull request restored: %d", ctx.Req.RequestURI, pr.Issue.Id)
+
+	ctx.Flash.Success("Branch " + pr.HeadBranch + " has been restored.")
+	ctx.Redirect(link)
 }
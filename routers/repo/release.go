@@ -5,13 +5,18 @@
 package repo
 
 import (
+	"fmt"
+	"mime/multipart"
 	"sort"
 
+	"github.com/go-martini/martini"
+
 	"github.com/gogits/gogs/models"
 	"github.com/gogits/gogs/modules/auth"
 	"github.com/gogits/gogs/modules/base"
 	"github.com/gogits/gogs/modules/log"
 	"github.com/gogits/gogs/modules/middleware"
+	"github.com/gogits/gogs/modules/setting"
 )
 
 type ReleaseSorter struct {
@@ -52,11 +57,17 @@ func Releases(ctx *middleware.Context) {
 		return
 	}
 
+	verifications, err := models.GetTagsVerification(ctx.Repo.GitRepo.Path)
+	if err != nil {
+		ctx.Handle(500, "release.Releases(GetTagsVerification)", err)
+		return
+	}
+
 	var tags ReleaseSorter
 	tags.rels = make([]*models.Release, len(rawTags))
 	for i, rawTag := range rawTags {
 		for _, rel := range rels {
-			if rel.TagName == rawTag {
+			if rel.TagName == rawTag && (!rel.IsDraft || ctx.Repo.IsOwner) {
 				rel.Publisher, err = models.GetUserById(rel.PublisherId)
 				if err != nil {
 					ctx.Handle(500, "release.Releases(GetUserById)", err)
@@ -64,6 +75,11 @@ func Releases(ctx *middleware.Context) {
 				}
 				rel.NumCommitsBehind = commitsCount - rel.NumCommits
 				rel.Note = base.RenderMarkdownString(rel.Note, ctx.Repo.RepoLink)
+				rel.Attachments, err = models.GetAttachmentsByReleaseId(rel.Id)
+				if err != nil {
+					ctx.Handle(500, "release.Releases(GetAttachmentsByReleaseId)", err)
+					return
+				}
 				tags.rels[i] = rel
 				break
 			}
@@ -88,10 +104,34 @@ func Releases(ctx *middleware.Context) {
 			}
 			tags.rels[i].NumCommitsBehind = commitsCount - tags.rels[i].NumCommits
 		}
+		tags.rels[i].Verification = verifications[rawTag]
 	}
 
 	sort.Sort(&tags)
 
+	// Drafts have no tag yet and are only visible to owners.
+	if ctx.Repo.IsOwner {
+		drafts := make([]*models.Release, 0, 2)
+		for _, rel := range rels {
+			if !rel.IsDraft || ctx.Repo.GitRepo.IsTagExist(rel.TagName) {
+				continue
+			}
+			rel.Publisher, err = models.GetUserById(rel.PublisherId)
+			if err != nil {
+				ctx.Handle(500, "release.Releases(GetUserById)", err)
+				return
+			}
+			rel.Note = base.RenderMarkdownString(rel.Note, ctx.Repo.RepoLink)
+			rel.Attachments, err = models.GetAttachmentsByReleaseId(rel.Id)
+			if err != nil {
+				ctx.Handle(500, "release.Releases(GetAttachmentsByReleaseId)", err)
+				return
+			}
+			drafts = append(drafts, rel)
+		}
+		tags.rels = append(drafts, tags.rels...)
+	}
+
 	ctx.Data["Releases"] = tags.rels
 	ctx.HTML(200, "release/list")
 }
@@ -105,6 +145,7 @@ func ReleasesNew(ctx *middleware.Context) {
 	ctx.Data["Title"] = "New Release"
 	ctx.Data["IsRepoToolbarReleases"] = true
 	ctx.Data["IsRepoReleaseNew"] = true
+	prepareAttachmentSettings(ctx)
 	ctx.HTML(200, "release/new")
 }
 
@@ -117,12 +158,19 @@ func ReleasesNewPost(ctx *middleware.Context, form auth.NewReleaseForm) {
 	ctx.Data["Title"] = "New Release"
 	ctx.Data["IsRepoToolbarReleases"] = true
 	ctx.Data["IsRepoReleaseNew"] = true
+	prepareAttachmentSettings(ctx)
 
 	if ctx.HasError() {
 		ctx.HTML(200, "release/new")
 		return
 	}
 
+	files := releaseAttachments(ctx)
+	if len(files) > setting.AttachmentMaxFiles {
+		ctx.RenderWithErr(fmt.Sprintf("Cannot upload more than %d files at once.", setting.AttachmentMaxFiles), "release/new", &form)
+		return
+	}
+
 	commitsCount, err := ctx.Repo.Commit.CommitsCount()
 	if err != nil {
 		ctx.Handle(500, "release.ReleasesNewPost(CommitsCount)", err)
@@ -138,11 +186,14 @@ func ReleasesNewPost(ctx *middleware.Context, form auth.NewReleaseForm) {
 		NumCommits:   commitsCount,
 		Note:         form.Content,
 		IsPrerelease: form.Prerelease,
+		IsDraft:      len(form.Draft) > 0,
 	}
 
 	if err = models.CreateRelease(ctx.Repo.GitRepo, rel); err != nil {
 		if err == models.ErrReleaseAlreadyExist {
 			ctx.RenderWithErr("Release with this tag name has already existed", "release/new", &form)
+		} else if err == models.ErrRefNameIllegal {
+			ctx.RenderWithErr("Tag name is not valid", "release/new", &form)
 		} else {
 			ctx.Handle(500, "release.ReleasesNewPost(IsReleaseExist)", err)
 		}
@@ -150,5 +201,233 @@ func ReleasesNewPost(ctx *middleware.Context, form auth.NewReleaseForm) {
 	}
 	log.Trace("%s Release created: %s/%s:%s", ctx.Req.RequestURI, ctx.User.LowerName, ctx.Repo.Repository.Name, form.TagName)
 
+	if !rel.IsDraft {
+		if err = models.PublishReleaseAction(ctx.User, ctx.Repo.Repository, rel); err != nil {
+			ctx.Handle(500, "release.ReleasesNewPost(PublishReleaseAction)", err)
+			return
+		}
+	}
+
+	if err = uploadAttachments(rel, files); err != nil {
+		if err == models.ErrAttachmentTooLarge {
+			ctx.Flash.Error(fmt.Sprintf("Attachment cannot be larger than %d MB.", setting.AttachmentMaxSize))
+			ctx.Redirect(fmt.Sprintf("%s/releases/edit/%d", ctx.Repo.RepoLink, rel.Id))
+			return
+		}
+		ctx.Handle(500, "release.ReleasesNewPost(uploadAttachments)", err)
+		return
+	}
+
+	ctx.Redirect(ctx.Repo.RepoLink + "/releases")
+}
+
+func prepareAttachmentSettings(ctx *middleware.Context) {
+	ctx.Data["AttachmentEnabled"] = setting.AttachmentEnabled
+	ctx.Data["AttachmentMaxSize"] = setting.AttachmentMaxSize
+	ctx.Data["AttachmentMaxFiles"] = setting.AttachmentMaxFiles
+}
+
+// releaseAttachments returns files uploaded along with release form.
+func releaseAttachments(ctx *middleware.Context) []*multipart.FileHeader {
+	if !setting.AttachmentEnabled || ctx.Req.MultipartForm == nil {
+		return nil
+	}
+	return ctx.Req.MultipartForm.File["attachments"]
+}
+
+// uploadAttachments saves uploaded files as attachments of release.
+func uploadAttachments(rel *models.Release, files []*multipart.FileHeader) error {
+	for _, fh := range files {
+		f, err := fh.Open()
+		if err != nil {
+			return err
+		}
+		_, err = models.NewAttachment(rel.Id, fh.Filename, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getRelease returns release of current repository by ID in URL.
+func getRelease(ctx *middleware.Context, params martini.Params) *models.Release {
+	id, _ := base.StrTo(params["id"]).Int64()
+	rel, err := models.GetReleaseById(id)
+	if err != nil || rel.RepoId != ctx.Repo.Repository.Id {
+		if err == nil || err == models.ErrReleaseNotExist {
+			ctx.Handle(404, "release.getRelease", nil)
+		} else {
+			ctx.Handle(500, "release.getRelease(GetReleaseById)", err)
+		}
+		return nil
+	}
+
+	rel.Attachments, err = models.GetAttachmentsByReleaseId(rel.Id)
+	if err != nil {
+		ctx.Handle(500, "release.getRelease(GetAttachmentsByReleaseId)", err)
+		return nil
+	}
+	return rel
+}
+
+func ReleasesEdit(ctx *middleware.Context, params martini.Params) {
+	if !ctx.Repo.IsOwner {
+		ctx.Handle(404, "release.ReleasesEdit", nil)
+		return
+	}
+
+	ctx.Data["Title"] = "Edit Release"
+	ctx.Data["IsRepoToolbarReleases"] = true
+	ctx.Data["IsRepoReleaseNew"] = true
+	prepareAttachmentSettings(ctx)
+
+	rel := getRelease(ctx, params)
+	if rel == nil {
+		return
+	}
+	ctx.Data["Release"] = rel
+	ctx.Data["tag_name"] = rel.TagName
+	ctx.Data["title"] = rel.Title
+	ctx.Data["content"] = rel.Note
+	ctx.Data["prerelease"] = rel.IsPrerelease
+	ctx.HTML(200, "release/new")
+}
+
+func ReleasesEditPost(ctx *middleware.Context, params martini.Params, form auth.NewReleaseForm) {
+	if !ctx.Repo.IsOwner {
+		ctx.Handle(404, "release.ReleasesEditPost", nil)
+		return
+	}
+
+	ctx.Data["Title"] = "Edit Release"
+	ctx.Data["IsRepoToolbarReleases"] = true
+	ctx.Data["IsRepoReleaseNew"] = true
+	prepareAttachmentSettings(ctx)
+
+	rel := getRelease(ctx, params)
+	if rel == nil {
+		return
+	}
+	ctx.Data["Release"] = rel
+
+	if ctx.HasError() {
+		ctx.HTML(200, "release/new")
+		return
+	}
+
+	files := releaseAttachments(ctx)
+	if len(rel.Attachments)+len(files) > setting.AttachmentMaxFiles {
+		ctx.RenderWithErr(fmt.Sprintf("Release cannot have more than %d attachments.", setting.AttachmentMaxFiles), "release/new", &form)
+		return
+	}
+
+	// Tag of published release cannot be changed.
+	wasDraft := rel.IsDraft
+	if rel.IsDraft {
+		rel.TagName = form.TagName
+		rel.IsDraft = len(form.Draft) > 0
+		if !rel.IsDraft {
+			rel.SHA1 = ctx.Repo.Commit.Id.String()
+			commitsCount, err := ctx.Repo.Commit.CommitsCount()
+			if err != nil {
+				ctx.Handle(500, "release.ReleasesEditPost(CommitsCount)", err)
+				return
+			}
+			rel.NumCommits = commitsCount
+		}
+	}
+	rel.Title = form.Title
+	rel.Note = form.Content
+	rel.IsPrerelease = form.Prerelease
+
+	if err := models.UpdateRelease(ctx.Repo.GitRepo, rel); err != nil {
+		if err == models.ErrReleaseAlreadyExist {
+			ctx.RenderWithErr("Release with this tag name has already existed", "release/new", &form)
+		} else if err == models.ErrRefNameIllegal {
+			ctx.RenderWithErr("Tag name is not valid", "release/new", &form)
+		} else {
+			ctx.Handle(500, "release.ReleasesEditPost(UpdateRelease)", err)
+		}
+		return
+	}
+	log.Trace("%s Release updated: %s/%s:%s", ctx.Req.RequestURI, ctx.User.LowerName, ctx.Repo.Repository.Name, rel.TagName)
+
+	if wasDraft && !rel.IsDraft {
+		if err := models.PublishReleaseAction(ctx.User, ctx.Repo.Repository, rel); err != nil {
+			ctx.Handle(500, "release.ReleasesEditPost(PublishReleaseAction)", err)
+			return
+		}
+	}
+
+	if err := uploadAttachments(rel, files); err != nil {
+		if err == models.ErrAttachmentTooLarge {
+			ctx.Flash.Error(fmt.Sprintf("Attachment cannot be larger than %d MB.", setting.AttachmentMaxSize))
+			ctx.Redirect(fmt.Sprintf("%s/releases/edit/%d", ctx.Repo.RepoLink, rel.Id))
+			return
+		}
+		ctx.Handle(500, "release.ReleasesEditPost(uploadAttachments)", err)
+		return
+	}
+
 	ctx.Redirect(ctx.Repo.RepoLink + "/releases")
 }
+
+// getReleaseAttachment returns attachment by SHA1 in URL
+// that belongs to a release of current repository.
+func getReleaseAttachment(ctx *middleware.Context, params martini.Params) (*models.Release, *models.Attachment) {
+	a, err := models.GetAttachmentBySha1(params["sha1"])
+	if err != nil {
+		if err == models.ErrAttachmentNotExist {
+			ctx.Handle(404, "release.getReleaseAttachment", nil)
+		} else {
+			ctx.Handle(500, "release.getReleaseAttachment(GetAttachmentBySha1)", err)
+		}
+		return nil, nil
+	}
+
+	rel, err := models.GetReleaseById(a.ReleaseId)
+	if err != nil || rel.RepoId != ctx.Repo.Repository.Id || (rel.IsDraft && !ctx.Repo.IsOwner) {
+		if err == nil || err == models.ErrReleaseNotExist {
+			ctx.Handle(404, "release.getReleaseAttachment", nil)
+		} else {
+			ctx.Handle(500, "release.getReleaseAttachment(GetReleaseById)", err)
+		}
+		return nil, nil
+	}
+	return rel, a
+}
+
+func ReleaseAttachmentDownload(ctx *middleware.Context, params martini.Params) {
+	_, a := getReleaseAttachment(ctx, params)
+	if a == nil {
+		return
+	}
+
+	if err := models.IncreaseAttachmentDownloadCount(a); err != nil {
+		log.Error("release.ReleaseAttachmentDownload(IncreaseAttachmentDownloadCount): %v", err)
+	}
+	ctx.ServeFile(a.LocalPath(), a.Name)
+}
+
+func ReleaseAttachmentDelete(ctx *middleware.Context, params martini.Params) {
+	if !ctx.Repo.IsOwner {
+		ctx.Handle(404, "release.ReleaseAttachmentDelete", nil)
+		return
+	}
+
+	rel, a := getReleaseAttachment(ctx, params)
+	if a == nil {
+		return
+	}
+
+	if err := models.DeleteAttachment(a); err != nil {
+		ctx.Handle(500, "release.ReleaseAttachmentDelete(DeleteAttachment)", err)
+		return
+	}
+	log.Trace("%s Release attachment deleted: %s/%s:%s", ctx.Req.RequestURI, ctx.User.LowerName, ctx.Repo.Repository.Name, a.Name)
+
+	ctx.Flash.Success("Attachment '" + a.Name + "' has been deleted.")
+	ctx.Redirect(fmt.Sprintf("%s/releases/edit/%d", ctx.Repo.RepoLink, rel.Id))
+}
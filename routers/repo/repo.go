@@ -6,10 +6,9 @@ package repo
 
 import (
 	"bytes"
-	"encoding/base64"
-	"errors"
 	"fmt"
 	"io/ioutil"
+	"mime"
 	"path"
 	"path/filepath"
 	"strings"
@@ -22,7 +21,9 @@ import (
 	"github.com/gogits/gogs/modules/auth"
 	"github.com/gogits/gogs/modules/base"
 	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/markup"
 	"github.com/gogits/gogs/modules/middleware"
+	"github.com/gogits/gogs/modules/migrations"
 )
 
 func Create(ctx *middleware.Context) {
@@ -30,6 +31,14 @@ func Create(ctx *middleware.Context) {
 	ctx.Data["PageIsNewRepo"] = true
 	ctx.Data["LanguageIgns"] = models.LanguageIgns
 	ctx.Data["Licenses"] = models.Licenses
+
+	templates, err := models.GetRepoTemplates(ctx.User)
+	if err != nil {
+		ctx.Handle(500, "repo.Create(GetRepoTemplates)", err)
+		return
+	}
+	ctx.Data["Templates"] = templates
+	ctx.Data["template"], _ = base.StrTo(ctx.Query("template")).Int64()
 	ctx.HTML(200, "repo/create")
 }
 
@@ -39,13 +48,36 @@ func CreatePost(ctx *middleware.Context, form auth.CreateRepoForm) {
 	ctx.Data["LanguageIgns"] = models.LanguageIgns
 	ctx.Data["Licenses"] = models.Licenses
 
+	templates, err := models.GetRepoTemplates(ctx.User)
+	if err != nil {
+		ctx.Handle(500, "repo.CreatePost(GetRepoTemplates)", err)
+		return
+	}
+	ctx.Data["Templates"] = templates
+	ctx.Data["template"] = form.Template
+
 	if ctx.HasError() {
 		ctx.HTML(200, "repo/create")
 		return
 	}
 
-	repo, err := models.CreateRepository(ctx.User, form.RepoName, form.Description,
-		form.Language, form.License, form.Private, false, form.InitReadme)
+	var repo *models.Repository
+	if form.Template > 0 {
+		var tpl *models.Repository
+		if tpl, err = models.GetRepoTemplateById(ctx.User, form.Template); err != nil {
+			if err == models.ErrRepoNotExist {
+				ctx.RenderWithErr("Template repository does not exist.", "repo/create", &form)
+			} else {
+				ctx.Handle(500, "repo.CreatePost(GetRepoTemplateById)", err)
+			}
+			return
+		}
+		repo, err = models.CreateRepositoryFromTemplate(ctx.User, tpl, form.RepoName, form.Description,
+			form.Private, form.CopyLabels, form.CopyHooks)
+	} else {
+		repo, err = models.CreateRepository(ctx.User, form.RepoName, form.Description,
+			form.Language, form.License, form.Private, false, form.InitReadme)
+	}
 	if err == nil {
 		log.Trace("%s Repository created: %s/%s", ctx.Req.RequestURI, ctx.User.LowerName, form.RepoName)
 		ctx.Redirect("/" + ctx.User.Name + "/" + form.RepoName)
@@ -69,6 +101,7 @@ func CreatePost(ctx *middleware.Context, form auth.CreateRepoForm) {
 func Migrate(ctx *middleware.Context) {
 	ctx.Data["Title"] = "Migrate repository"
 	ctx.Data["PageIsNewRepo"] = true
+	ctx.Data["service"] = ""
 	ctx.HTML(200, "repo/migrate")
 }
 
@@ -81,14 +114,38 @@ func MigratePost(ctx *middleware.Context, form auth.MigrateRepoForm) {
 		return
 	}
 
-	authStr := strings.Replace(fmt.Sprintf("://%s:%s",
-		form.AuthUserName, form.AuthPasswd), "@", "%40", -1)
-	url := strings.Replace(form.Url, "://", authStr+"@", 1)
+	// Metadata can only be imported from known project hosting services.
+	service := form.Service
+	if len(service) == 0 {
+		service = migrations.DetectService(form.Url)
+	}
+	var downloader migrations.Downloader
+	if form.Metadata {
+		var err error
+		if downloader, err = migrations.NewDownloader(service, form.Url, form.AuthToken); err != nil {
+			ctx.Data["Err_Url"] = true
+			ctx.RenderWithErr(err.Error(), "repo/migrate", &form)
+			return
+		}
+	}
+
 	repo, err := models.MigrateRepository(ctx.User, form.RepoName, form.Description, form.Private,
-		form.Mirror, url)
+		form.Mirror, form.Url, form.AuthUserName, form.AuthPasswd)
 	if err == nil {
 		log.Trace("%s Repository migrated: %s/%s", ctx.Req.RequestURI, ctx.User.LowerName, form.RepoName)
-		ctx.Redirect("/" + ctx.User.Name + "/" + form.RepoName)
+		if downloader == nil {
+			ctx.Redirect("/" + ctx.User.Name + "/" + form.RepoName)
+			return
+		}
+
+		m := &models.Migration{RepoId: repo.Id, Service: service}
+		if err = models.NewMigration(m); err != nil {
+			ctx.Handle(500, "repo.MigratePost(NewMigration)", err)
+			return
+		}
+		// Importing metadata takes long, progress is shown in migration page.
+		go models.RunMigration(ctx.User, repo, downloader, m)
+		ctx.Redirect("/" + ctx.User.Name + "/" + form.RepoName + "/settings/migration")
 		return
 	} else if err == models.ErrRepoAlreadyExist {
 		ctx.RenderWithErr("Repository name has already been used", "repo/migrate", &form)
@@ -111,6 +168,97 @@ func MigratePost(ctx *middleware.Context, form auth.MigrateRepoForm) {
 	ctx.Handle(500, "repo.Migrate", err)
 }
 
+// checkForkable returns false and renders error page if current user cannot fork repository,
+// user who has already forked it is redirected to the fork.
+func checkForkable(ctx *middleware.Context) bool {
+	if ctx.Repo.Repository.OwnerId == ctx.User.Id {
+		ctx.Handle(404, "repo.checkForkable", nil)
+		return false
+	} else if ctx.Repo.Repository.IsBare {
+		ctx.Flash.Error("Cannot fork a bare repository.")
+		ctx.Redirect(ctx.Repo.RepoLink)
+		return false
+	}
+
+	if fork, has := models.HasForkedRepo(ctx.User.Id, ctx.Repo.Repository.Id); has {
+		ctx.Redirect("/" + ctx.User.Name + "/" + fork.Name)
+		return false
+	}
+	return true
+}
+
+func Fork(ctx *middleware.Context) {
+	ctx.Data["Title"] = "Fork repository"
+	if !checkForkable(ctx) {
+		return
+	}
+
+	ctx.Data["repo"] = ctx.Repo.Repository.Name
+	ctx.Data["desc"] = ctx.Repo.Repository.Description
+	ctx.HTML(200, "repo/fork")
+}
+
+func ForkPost(ctx *middleware.Context, form auth.ForkRepoForm) {
+	ctx.Data["Title"] = "Fork repository"
+	if !checkForkable(ctx) {
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.HTML(200, "repo/fork")
+		return
+	}
+
+	repo, err := models.ForkRepository(ctx.User, ctx.Repo.Repository, form.RepoName, form.Description)
+	if err != nil {
+		switch err {
+		case models.ErrRepoAlreadyExist:
+			ctx.RenderWithErr("Repository name has already been used", "repo/fork", &form)
+		case models.ErrRepoNameIllegal:
+			ctx.RenderWithErr(models.ErrRepoNameIllegal.Error(), "repo/fork", &form)
+		default:
+			ctx.Handle(500, "repo.ForkPost(ForkRepository)", err)
+		}
+		return
+	}
+	log.Trace("%s Repository forked: %s/%s -> %s/%s", ctx.Req.RequestURI,
+		ctx.Repo.Owner.LowerName, ctx.Repo.Repository.LowerName, ctx.User.LowerName, repo.LowerName)
+
+	ctx.Redirect("/" + ctx.User.Name + "/" + repo.Name)
+}
+
+func Forks(ctx *middleware.Context) {
+	ctx.Data["Title"] = "Forks"
+
+	forks, err := models.GetForks(ctx.Repo.Repository)
+	if err != nil {
+		ctx.Handle(500, "repo.Forks(GetForks)", err)
+		return
+	}
+
+	// Private forks are only visible to users who can read them.
+	visible := make([]*models.Repository, 0, len(forks))
+	for _, fork := range forks {
+		if err = fork.GetOwner(); err != nil {
+			ctx.Handle(500, "repo.Forks(GetOwner)", err)
+			return
+		}
+		if fork.IsPrivate {
+			if !ctx.IsSigned {
+				continue
+			} else if has, err := models.HasAccess(ctx.User.Name, fork.Owner.Name+"/"+fork.Name, models.AU_READABLE); err != nil {
+				ctx.Handle(500, "repo.Forks(HasAccess)", err)
+				return
+			} else if !has {
+				continue
+			}
+		}
+		visible = append(visible, fork)
+	}
+	ctx.Data["Forks"] = visible
+	ctx.HTML(200, "repo/forks")
+}
+
 func Single(ctx *middleware.Context, params martini.Params) {
 	branchName := ctx.Repo.BranchName
 	userName := ctx.Repo.Owner.Name
@@ -130,8 +278,19 @@ func Single(ctx *middleware.Context, params martini.Params) {
 
 	ctx.Data["IsRepoToolbarSource"] = true
 
+	// Topics are only shown on home page of repository.
+	if len(treename) == 0 {
+		if err := ctx.Repo.Repository.GetTopics(); err != nil {
+			ctx.Handle(500, "repo.Single(GetTopics)", err)
+			return
+		}
+	}
+
 	isViewBranch := ctx.Repo.IsBranch
 	ctx.Data["IsViewBranch"] = isViewBranch
+	// Files can only be changed from web on branches, not tags or commits.
+	ctx.Data["CanEditFile"] = ctx.Repo.IsOwner && !ctx.Repo.Repository.IsMirror &&
+		!ctx.Repo.Repository.IsArchived && ctx.Repo.GitRepo.IsBranchExist(branchName)
 
 	treePath := treename
 	if len(treePath) != 0 {
@@ -164,6 +323,9 @@ func Single(ctx *middleware.Context, params martini.Params) {
 			}
 			ctx.Data["FileExt"] = ext
 			ctx.Data["FileLink"] = rawLink + "/" + treename
+			// Ownership is always defined by CODEOWNERS file in default branch.
+			ctx.Data["CodeOwners"] = models.MatchCodeOwners(
+				models.GetCodeOwnerRules(ctx.Repo.GitRepo.Path, ctx.Repo.Repository.DefaultBranch), treename)
 
 			buf := make([]byte, 1024)
 			n, _ := dataRc.Read(buf)
@@ -177,19 +339,38 @@ func Single(ctx *middleware.Context, params martini.Params) {
 
 			_, isTextFile := base.IsTextFile(buf)
 			_, isImageFile := base.IsImageFile(buf)
-			ctx.Data["FileIsText"] = isTextFile
+			lfsPointer := models.ParseLFSPointer(buf)
+			ctx.Data["FileIsText"] = isTextFile && lfsPointer == nil
 
+			fileType := mime.TypeByExtension(path.Ext(blob.Name()))
 			switch {
+			case lfsPointer != nil:
+				// Large file is stored outside of repository, only pointer can be shown.
+				ctx.Data["LFSPointer"] = lfsPointer
 			case isImageFile:
 				ctx.Data["IsImageFile"] = true
+			case strings.HasPrefix(fileType, "video/"):
+				ctx.Data["IsVideoFile"] = true
+			case strings.HasPrefix(fileType, "audio/"):
+				ctx.Data["IsAudioFile"] = true
 			case isTextFile:
 				d, _ := ioutil.ReadAll(dataRc)
 				buf = append(buf, d...)
-				readmeExist := base.IsMarkdownFile(blob.Name()) || base.IsReadmeFile(blob.Name())
+				readmeExist := markup.IsMarkupFile(blob.Name()) || base.IsReadmeFile(blob.Name())
 				ctx.Data["ReadmeExist"] = readmeExist
-				if readmeExist {
+				switch {
+				case markup.IsMarkupFile(blob.Name()):
+					if html, err := markup.Render(blob.Name(), buf, ""); err != nil {
+						// Falls back to show source when renderer fails.
+						log.Error("repo.Single(markup.Render): %v", err)
+						ctx.Data["ReadmeExist"] = false
+						ctx.Data["FileContent"] = string(buf)
+					} else {
+						ctx.Data["FileContent"] = string(html)
+					}
+				case readmeExist:
 					ctx.Data["FileContent"] = string(base.RenderMarkdown(buf, ""))
-				} else {
+				default:
 					ctx.Data["FileContent"] = string(buf)
 				}
 			}
@@ -205,6 +386,12 @@ func Single(ctx *middleware.Context, params martini.Params) {
 		entries := tree.ListEntries()
 		entries.Sort()
 
+		submodules, err := models.GetSubmodules(ctx.Repo.GitRepo.Path, ctx.Repo.CommitId, treename)
+		if err != nil {
+			ctx.Handle(500, "repo.Single(GetSubmodules)", err)
+			return
+		}
+
 		files := make([][]interface{}, 0, len(entries))
 
 		for _, te := range entries {
@@ -214,19 +401,23 @@ func Single(ctx *middleware.Context, params martini.Params) {
 				return
 			}
 
-			files = append(files, []interface{}{te, c})
+			// Third element is nil if entry is not a submodule.
+			files = append(files, []interface{}{te, c, submodules[te.Name()]})
 		}
 
 		ctx.Data["Files"] = files
 
 		var readmeFile *git.Blob
 
+		// README that can be rendered is preferred.
 		for _, f := range entries {
 			if f.IsDir() || !base.IsReadmeFile(f.Name()) {
 				continue
-			} else {
+			} else if readmeFile == nil || markup.IsMarkupFile(f.Name()) {
 				readmeFile = f.Blob()
-				break
+				if markup.IsMarkupFile(f.Name()) {
+					break
+				}
 			}
 		}
 
@@ -255,10 +446,14 @@ func Single(ctx *middleware.Context, params martini.Params) {
 				if isTextFile {
 					d, _ := ioutil.ReadAll(dataRc)
 					buf = append(buf, d...)
-					switch {
-					case base.IsMarkdownFile(readmeFile.Name()):
-						buf = base.RenderMarkdown(buf, branchLink)
-					default:
+					if markup.IsMarkupFile(readmeFile.Name()) {
+						if html, err := markup.Render(readmeFile.Name(), buf, branchLink); err != nil {
+							log.Error("repo.Single(markup.Render): %v", err)
+							buf = bytes.Replace(buf, []byte("\n"), []byte(`<br>`), -1)
+						} else {
+							buf = html
+						}
+					} else {
 						buf = bytes.Replace(buf, []byte("\n"), []byte(`<br>`), -1)
 					}
 					ctx.Data["FileContent"] = string(buf)
@@ -294,27 +489,6 @@ func Single(ctx *middleware.Context, params martini.Params) {
 	ctx.HTML(200, "repo/single")
 }
 
-func basicEncode(username, password string) string {
-	auth := username + ":" + password
-	return base64.StdEncoding.EncodeToString([]byte(auth))
-}
-
-func basicDecode(encoded string) (user string, name string, err error) {
-	var s []byte
-	s, err = base64.StdEncoding.DecodeString(encoded)
-	if err != nil {
-		return
-	}
-
-	a := strings.Split(string(s), ":")
-	if len(a) == 2 {
-		user, name = a[0], a[1]
-	} else {
-		err = errors.New("decode failed")
-	}
-	return
-}
-
 func authRequired(ctx *middleware.Context) {
 	ctx.ResponseWriter.Header().Set("WWW-Authenticate", "Basic realm=\".\"")
 	ctx.Data["ErrorMsg"] = "no basic auth and digit auth"
@@ -23,19 +23,79 @@ import (
 func Setting(ctx *middleware.Context) {
 	ctx.Data["IsRepoToolbarSetting"] = true
 	ctx.Data["Title"] = strings.TrimPrefix(ctx.Repo.RepoLink, "/") + " - settings"
+
+	transfer, err := models.GetRepoTransfer(ctx.Repo.Repository.Id)
+	if err != nil && err != models.ErrRepoTransferNotExist {
+		ctx.Handle(500, "setting.Setting(GetRepoTransfer)", err)
+		return
+	}
+	ctx.Data["RepoTransfer"] = transfer
+
+	if err = ctx.Repo.Repository.GetTopics(); err != nil {
+		ctx.Handle(500, "setting.Setting(GetTopics)", err)
+		return
+	}
+	ctx.Data["Topics"] = strings.Join(ctx.Repo.Repository.Topics, ", ")
+
+	if ctx.Data["QuotaUsage"], err = models.GetQuotaUsage(ctx.Repo.Repository); err != nil {
+		ctx.Handle(500, "setting.Setting(GetQuotaUsage)", err)
+		return
+	}
+	ctx.Data["MergeMessagePlaceholders"] = models.MergeMessagePlaceholders
 	ctx.HTML(200, "repo/setting")
 }
 
+func CancelTransfer(ctx *middleware.Context) {
+	if err := models.CancelRepoTransfer(ctx.Repo.Repository.Id); err != nil {
+		ctx.Handle(500, "setting.CancelTransfer(CancelRepoTransfer)", err)
+		return
+	}
+	log.Trace("%s Repository transfer canceled: %s/%s", ctx.Req.RequestURI, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name)
+
+	ctx.Flash.Success("Repository transfer has been canceled.")
+	ctx.Redirect(fmt.Sprintf("/%s/%s/settings", ctx.Repo.Owner.Name, ctx.Repo.Repository.Name))
+}
+
 func SettingPost(ctx *middleware.Context, form auth.RepoSettingForm) {
 	ctx.Data["IsRepoToolbarSetting"] = true
 
 	switch ctx.Query("action") {
 	case "update":
+		ctx.Data["Topics"] = form.Topics
 		if ctx.HasError() {
 			ctx.HTML(200, "repo/setting")
 			return
 		}
 
+		topics, err := models.ParseTopics(form.Topics)
+		if err != nil {
+			if err == models.ErrTopicNameIllegal {
+				ctx.RenderWithErr("Topics can only contain lowercase letters, digits and '-', '.', '+', '#', and must not be longer than 35 characters.", "repo/setting", &form)
+			} else if err == models.ErrTooManyTopics {
+				ctx.RenderWithErr("Repository cannot have more than 20 topics.", "repo/setting", &form)
+			} else {
+				ctx.Handle(500, "setting.SettingPost(ParseTopics)", err)
+			}
+			return
+		}
+
+		mergeStyles := make([]string, 0, 3)
+		if form.MergeStyleMerge {
+			mergeStyles = append(mergeStyles, models.MERGE_STYLE_MERGE)
+		}
+		if form.MergeStyleSquash {
+			mergeStyles = append(mergeStyles, models.MERGE_STYLE_SQUASH)
+		}
+		if form.MergeStyleRebase {
+			mergeStyles = append(mergeStyles, models.MERGE_STYLE_REBASE)
+		}
+		if len(mergeStyles) == 0 {
+			ctx.RenderWithErr("At least one merge style must be allowed for pull requests.", "repo/setting", &form)
+			return
+		} else if !models.IsValidMergeStyle(form.DefaultMergeStyle) {
+			form.DefaultMergeStyle = models.MERGE_STYLE_MERGE
+		}
+
 		newRepoName := form.RepoName
 		// Check if repository name has been changed.
 		if ctx.Repo.Repository.Name != newRepoName {
@@ -64,21 +124,24 @@ func SettingPost(ctx *middleware.Context, form auth.RepoSettingForm) {
 		ctx.Repo.Repository.Website = form.Website
 		ctx.Repo.Repository.IsPrivate = form.Private
 		ctx.Repo.Repository.IsGoget = form.GoGet
+		ctx.Repo.Repository.IsTemplate = form.IsTemplate
+		ctx.Repo.Repository.DeleteMergedHead = form.DeleteMergedHead
+		ctx.Repo.Repository.BlockOnDependencies = form.BlockOnDependencies
+		ctx.Repo.Repository.MergeStyles = ""
+		if len(mergeStyles) < 3 {
+			ctx.Repo.Repository.MergeStyles = strings.Join(mergeStyles, ",")
+		}
+		ctx.Repo.Repository.DefaultMergeStyle = form.DefaultMergeStyle
+		ctx.Repo.Repository.MergeMessageTemplate = strings.TrimSpace(form.MergeMessage)
 		if err := models.UpdateRepository(ctx.Repo.Repository); err != nil {
 			ctx.Handle(404, "setting.SettingPost(update)", err)
 			return
 		}
-		log.Trace("%s Repository updated: %s/%s", ctx.Req.RequestURI, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name)
-
-		if ctx.Repo.Repository.IsMirror {
-			if form.Interval > 0 {
-				ctx.Repo.Mirror.Interval = form.Interval
-				ctx.Repo.Mirror.NextUpdate = time.Now().Add(time.Duration(form.Interval) * time.Hour)
-				if err := models.UpdateMirror(ctx.Repo.Mirror); err != nil {
-					log.Error("setting.SettingPost(UpdateMirror): %v", err)
-				}
-			}
+		if err = models.SaveRepoTopics(ctx.Repo.Repository, topics); err != nil {
+			ctx.Handle(500, "setting.SettingPost(SaveRepoTopics)", err)
+			return
 		}
+		log.Trace("%s Repository updated: %s/%s", ctx.Req.RequestURI, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name)
 
 		ctx.Flash.Success("Repository options has been successfully updated.")
 		ctx.Redirect(fmt.Sprintf("/%s/%s/settings", ctx.Repo.Owner.Name, ctx.Repo.Repository.Name))
@@ -91,22 +154,76 @@ func SettingPost(ctx *middleware.Context, form auth.RepoSettingForm) {
 			return
 		}
 
-		newOwner := ctx.Query("owner")
-		// Check if new owner exists.
-		isExist, err := models.IsUserExist(newOwner)
+		// Collaborators cannot give away repository.
+		if ctx.User.Id != ctx.Repo.Owner.Id && !ctx.User.IsAdmin {
+			ctx.Error(403)
+			return
+		}
+
+		newOwner, err := models.GetUserByName(ctx.Query("owner"))
 		if err != nil {
-			ctx.Handle(500, "setting.SettingPost(transfer: check existence)", err)
+			if err == models.ErrUserNotExist {
+				ctx.RenderWithErr("Please make sure you entered owner name is correct.", "repo/setting", nil)
+			} else {
+				ctx.Handle(500, "setting.SettingPost(transfer: GetUserByName)", err)
+			}
 			return
-		} else if !isExist {
-			ctx.RenderWithErr("Please make sure you entered owner name is correct.", "repo/setting", nil)
+		} else if newOwner.IsBot() {
+			ctx.RenderWithErr("Repository cannot be transferred to a machine account.", "repo/setting", nil)
 			return
-		} else if err = models.TransferOwnership(ctx.User, newOwner, ctx.Repo.Repository); err != nil {
-			ctx.Handle(500, "setting.SettingPost(transfer repository)", err)
+		}
+
+		// Organization has no one to confirm transfer, so only admin can transfer to it directly.
+		if newOwner.Type == models.UT_ORGANIZATION {
+			if !ctx.User.IsAdmin {
+				ctx.RenderWithErr("You do not have admin rights of this organization.", "repo/setting", nil)
+				return
+			}
+			err = models.TransferOwnership(ctx.Repo.Owner, newOwner.Name, ctx.Repo.Repository)
+		} else {
+			err = models.StartRepoTransfer(ctx.User, newOwner, ctx.Repo.Repository)
+		}
+		if err != nil {
+			switch err {
+			case models.ErrRepoTransferToOwner:
+				ctx.RenderWithErr("Repository is already owned by this user.", "repo/setting", nil)
+			case models.ErrRepoAlreadyExist:
+				ctx.RenderWithErr("New owner already has a repository with same name.", "repo/setting", nil)
+			default:
+				ctx.Handle(500, "setting.SettingPost(transfer repository)", err)
+			}
 			return
 		}
-		log.Trace("%s Repository transfered: %s/%s -> %s", ctx.Req.RequestURI, ctx.User.Name, ctx.Repo.Repository.Name, newOwner)
 
-		ctx.Redirect("/")
+		if newOwner.Type == models.UT_ORGANIZATION {
+			log.Trace("%s Repository transfered: %s/%s -> %s", ctx.Req.RequestURI, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name, newOwner.Name)
+			ctx.Redirect("/" + newOwner.Name + "/" + ctx.Repo.Repository.Name)
+			return
+		}
+		log.Trace("%s Repository transfer started: %s/%s -> %s", ctx.Req.RequestURI, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name, newOwner.Name)
+
+		ctx.Flash.Success("Repository transfer has been sent, it will be done after " + newOwner.Name + " accepts it.")
+		ctx.Redirect(fmt.Sprintf("/%s/%s/settings", ctx.Repo.Owner.Name, ctx.Repo.Repository.Name))
+	case "archive", "unarchive":
+		// Collaborators cannot change archive state of repository.
+		if ctx.User.Id != ctx.Repo.Owner.Id && !ctx.User.IsAdmin {
+			ctx.Error(403)
+			return
+		}
+
+		ctx.Repo.Repository.IsArchived = ctx.Query("action") == "archive"
+		if err := models.UpdateRepository(ctx.Repo.Repository); err != nil {
+			ctx.Handle(500, "setting.SettingPost(archive: UpdateRepository)", err)
+			return
+		}
+		log.Trace("%s Repository archive state changed(%v): %s/%s", ctx.Req.RequestURI, ctx.Repo.Repository.IsArchived, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name)
+
+		if ctx.Repo.Repository.IsArchived {
+			ctx.Flash.Success("Repository has been archived and is now read-only.")
+		} else {
+			ctx.Flash.Success("Repository has been unarchived.")
+		}
+		ctx.Redirect(fmt.Sprintf("/%s/%s/settings", ctx.Repo.Owner.Name, ctx.Repo.Repository.Name))
 	case "delete":
 		if len(ctx.Repo.Repository.Name) == 0 || ctx.Repo.Repository.Name != ctx.Query("repository") {
 			ctx.RenderWithErr("Please make sure you entered repository name is correct.", "repo/setting", nil)
@@ -123,6 +240,78 @@ func SettingPost(ctx *middleware.Context, form auth.RepoSettingForm) {
 	}
 }
 
+func MirrorSettingPost(ctx *middleware.Context, form auth.MirrorSettingForm) {
+	ctx.Data["IsRepoToolbarSetting"] = true
+	ctx.Data["Title"] = strings.TrimPrefix(ctx.Repo.RepoLink, "/") + " - settings"
+
+	if !ctx.Repo.Repository.IsMirror {
+		ctx.Handle(404, "setting.MirrorSettingPost", nil)
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.HTML(200, "repo/setting")
+		return
+	} else if form.Interval <= 0 {
+		ctx.Data["Err_Interval"] = true
+		ctx.RenderWithErr("Mirror interval must be a positive number of hours.", "repo/setting", &form)
+		return
+	}
+
+	m := ctx.Repo.Mirror
+	m.Address = form.Address
+	m.AuthUserName = form.AuthUserName
+	// Empty password means keeping the saved one.
+	if len(form.AuthPasswd) > 0 {
+		m.AuthPasswd = form.AuthPasswd
+	} else if len(form.AuthUserName) == 0 {
+		m.AuthPasswd = ""
+	}
+	m.Interval = form.Interval
+	m.NextUpdate = m.LastSync.Add(time.Duration(form.Interval) * time.Hour)
+	if err := models.UpdateMirror(m); err != nil {
+		ctx.Handle(500, "setting.MirrorSettingPost(UpdateMirror)", err)
+		return
+	}
+	log.Trace("%s Mirror settings updated: %s/%s", ctx.Req.RequestURI, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name)
+
+	ctx.Flash.Success("Mirror settings have been successfully updated.")
+	ctx.Redirect(ctx.Repo.RepoLink + "/settings")
+}
+
+func MirrorSync(ctx *middleware.Context) {
+	if !ctx.Repo.Repository.IsMirror {
+		ctx.Handle(404, "setting.MirrorSync", nil)
+		return
+	}
+
+	if ctx.Repo.Mirror.IsSyncing {
+		ctx.Flash.Error(models.ErrMirrorSyncing.Error() + ".")
+	} else {
+		// Fetching may take long, result is shown in mirror status.
+		go models.SyncMirror(ctx.Repo.Mirror)
+		ctx.Flash.Success("Mirror synchronization has been started, refresh page later to see the result.")
+	}
+	ctx.Redirect(ctx.Repo.RepoLink + "/settings")
+}
+
+func MigrationStatus(ctx *middleware.Context) {
+	ctx.Data["IsRepoToolbarSetting"] = true
+	ctx.Data["Title"] = strings.TrimPrefix(ctx.Repo.RepoLink, "/") + " - Migration"
+
+	m, err := models.GetMigrationByRepoId(ctx.Repo.Repository.Id)
+	if err != nil {
+		if err == models.ErrMigrationNotExist {
+			ctx.Handle(404, "setting.MigrationStatus(GetMigrationByRepoId)", nil)
+		} else {
+			ctx.Handle(500, "setting.MigrationStatus(GetMigrationByRepoId)", err)
+		}
+		return
+	}
+	ctx.Data["Migration"] = m
+	ctx.HTML(200, "repo/migration")
+}
+
 func Collaboration(ctx *middleware.Context) {
 	repoLink := strings.TrimPrefix(ctx.Repo.RepoLink, "/")
 	ctx.Data["IsRepoToolbarCollaboration"] = true
@@ -135,28 +324,42 @@ func Collaboration(ctx *middleware.Context) {
 			ctx.Handle(500, "setting.Collaboration(DeleteAccess)", err)
 			return
 		}
+		models.RecordAudit(ctx.User.Id, ctx.User.Name, models.AUDIT_REMOVE_COLLABORATOR, ctx.RemoteAddr(),
+			fmt.Sprintf("%s from %s", remove, repoLink))
 		ctx.Flash.Success("Collaborator has been removed.")
 		ctx.Redirect(ctx.Repo.RepoLink + "/settings/collaboration")
 		return
 	}
 
-	names, err := models.GetCollaboratorNames(repoLink)
+	collaborators, err := models.GetRepoCollaborators(repoLink)
 	if err != nil {
-		ctx.Handle(500, "setting.Collaboration(GetCollaborators)", err)
+		ctx.Handle(500, "setting.Collaboration(GetRepoCollaborators)", err)
 		return
 	}
 
-	us := make([]*models.User, len(names))
-	for i, name := range names {
-		us[i], err = models.GetUserByName(name)
-		if err != nil {
-			ctx.Handle(500, "setting.Collaboration(GetUserByName)", err)
-			return
-		}
+	ctx.Data["Collaborators"] = collaborators
+	ctx.HTML(200, "repo/collaboration")
+}
+
+func ChangeCollaborationAccessMode(ctx *middleware.Context) {
+	repoLink := strings.TrimPrefix(ctx.Repo.RepoLink, "/")
+	name := strings.ToLower(ctx.Query("collaborator"))
+	mode, _ := base.StrTo(ctx.Query("mode")).Int()
+	if len(name) == 0 || name == ctx.Repo.Owner.LowerName || !models.IsValidAccessMode(mode) {
+		ctx.Handle(404, "setting.ChangeCollaborationAccessMode", nil)
+		return
 	}
 
-	ctx.Data["Collaborators"] = us
-	ctx.HTML(200, "repo/collaboration")
+	if err := models.ChangeAccessMode(name, repoLink, mode); err != nil {
+		ctx.Handle(500, "setting.ChangeCollaborationAccessMode(ChangeAccessMode)", err)
+		return
+	}
+	models.RecordAudit(ctx.User.Id, ctx.User.Name, models.AUDIT_CHANGE_COLLABORATOR_ACCESS, ctx.RemoteAddr(),
+		fmt.Sprintf("%s to %s of %s", name, models.AccessModeName(mode), repoLink))
+	log.Trace("%s Collaborator access changed(%s: %d): %s", ctx.Req.RequestURI, name, mode, repoLink)
+
+	ctx.Flash.Success("Access of collaborator has been changed.")
+	ctx.Redirect(ctx.Repo.RepoLink + "/settings/collaboration")
 }
 
 func CollaborationPost(ctx *middleware.Context) {
@@ -166,7 +369,12 @@ func CollaborationPost(ctx *middleware.Context) {
 		ctx.Redirect(ctx.Req.RequestURI)
 		return
 	}
-	has, err := models.HasAccess(name, repoLink, models.AU_WRITABLE)
+	mode, _ := base.StrTo(ctx.Query("mode")).Int()
+	if !models.IsValidAccessMode(mode) {
+		mode = models.AU_WRITABLE
+	}
+
+	has, err := models.HasAccess(name, repoLink, models.AU_READABLE)
 	if err != nil {
 		ctx.Handle(500, "setting.CollaborationPost(HasAccess)", err)
 		return
@@ -187,7 +395,7 @@ func CollaborationPost(ctx *middleware.Context) {
 	}
 
 	if err = models.AddAccess(&models.Access{UserName: name, RepoName: repoLink,
-		Mode: models.AU_WRITABLE}); err != nil {
+		Mode: mode}); err != nil {
 		ctx.Handle(500, "setting.CollaborationPost(AddAccess)", err)
 		return
 	}
@@ -199,6 +407,8 @@ func CollaborationPost(ctx *middleware.Context) {
 		}
 	}
 
+	models.RecordAudit(ctx.User.Id, ctx.User.Name, models.AUDIT_ADD_COLLABORATOR, ctx.RemoteAddr(),
+		fmt.Sprintf("%s to %s with %s access", name, repoLink, models.AccessModeName(mode)))
 	ctx.Flash.Success("New collaborator has been added.")
 	ctx.Redirect(ctx.Req.RequestURI)
 }
@@ -229,6 +439,67 @@ func WebHooks(ctx *middleware.Context) {
 	ctx.HTML(200, "repo/hooks")
 }
 
+func GitHooks(ctx *middleware.Context) {
+	ctx.Data["IsRepoToolbarGitHooks"] = true
+	ctx.Data["Title"] = strings.TrimPrefix(ctx.Repo.RepoLink, "/") + " - Git Hooks"
+
+	if !ctx.Data["CanEditGitHooks"].(bool) {
+		ctx.Handle(404, "setting.GitHooks", nil)
+		return
+	}
+
+	hooks, err := models.GetGitHooks(models.RepoPath(ctx.Repo.Owner.Name, ctx.Repo.Repository.Name))
+	if err != nil {
+		ctx.Handle(500, "setting.GitHooks(GetGitHooks)", err)
+		return
+	}
+	ctx.Data["GitHooks"] = hooks
+	ctx.HTML(200, "repo/git_hooks")
+}
+
+func GitHooksEdit(ctx *middleware.Context, params martini.Params) {
+	ctx.Data["IsRepoToolbarGitHooks"] = true
+	ctx.Data["Title"] = strings.TrimPrefix(ctx.Repo.RepoLink, "/") + " - Git Hooks"
+
+	if !ctx.Data["CanEditGitHooks"].(bool) {
+		ctx.Handle(404, "setting.GitHooksEdit", nil)
+		return
+	}
+
+	h, err := models.GetGitHook(models.RepoPath(ctx.Repo.Owner.Name, ctx.Repo.Repository.Name), params["name"])
+	if err != nil {
+		if err == models.ErrGitHookNotExist {
+			ctx.Handle(404, "setting.GitHooksEdit(GetGitHook)", nil)
+		} else {
+			ctx.Handle(500, "setting.GitHooksEdit(GetGitHook)", err)
+		}
+		return
+	}
+	ctx.Data["GitHook"] = h
+	ctx.HTML(200, "repo/git_hooks_edit")
+}
+
+func GitHooksEditPost(ctx *middleware.Context, params martini.Params) {
+	if !ctx.Data["CanEditGitHooks"].(bool) {
+		ctx.Handle(404, "setting.GitHooksEditPost", nil)
+		return
+	}
+
+	h := &models.GitHook{Name: params["name"], Content: ctx.Query("content")}
+	if err := models.UpdateGitHook(models.RepoPath(ctx.Repo.Owner.Name, ctx.Repo.Repository.Name), h); err != nil {
+		if err == models.ErrGitHookNotExist {
+			ctx.Handle(404, "setting.GitHooksEditPost(UpdateGitHook)", nil)
+		} else {
+			ctx.Handle(500, "setting.GitHooksEditPost(UpdateGitHook)", err)
+		}
+		return
+	}
+	log.Trace("%s Git hook updated(%s): %s/%s", ctx.Req.RequestURI, h.Name, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name)
+
+	ctx.Flash.Success("Git hook has been updated.")
+	ctx.Redirect(ctx.Repo.RepoLink + "/settings/hooks/git/" + h.Name)
+}
+
 func WebHooksAdd(ctx *middleware.Context) {
 	ctx.Data["IsRepoToolbarWebHooks"] = true
 	ctx.Data["Title"] = strings.TrimPrefix(ctx.Repo.RepoLink, "/") + " - Add Webhook"
@@ -338,3 +609,312 @@ func WebHooksEditPost(ctx *middleware.Context, params martini.Params, form auth.
 	ctx.Flash.Success("Webhook has been updated.")
 	ctx.Redirect(fmt.Sprintf("%s/settings/hooks/%d", ctx.Repo.RepoLink, hookId))
 }
+
+func prepareDeployKeys(ctx *middleware.Context) bool {
+	ctx.Data["IsRepoToolbarDeployKeys"] = true
+	ctx.Data["Title"] = strings.TrimPrefix(ctx.Repo.RepoLink, "/") + " - Deploy Keys"
+
+	keys, err := models.ListDeployKeys(ctx.Repo.Repository.Id)
+	if err != nil {
+		ctx.Handle(500, "setting.DeployKeys(ListDeployKeys)", err)
+		return false
+	}
+	ctx.Data["DeployKeys"] = keys
+	return true
+}
+
+func DeployKeys(ctx *middleware.Context) {
+	if !prepareDeployKeys(ctx) {
+		return
+	}
+	ctx.HTML(200, "repo/deploy_keys")
+}
+
+func DeployKeysPost(ctx *middleware.Context, form auth.AddDeployKeyForm) {
+	if !prepareDeployKeys(ctx) {
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.HTML(200, "repo/deploy_keys")
+		return
+	}
+
+	content := strings.TrimSpace(form.Content)
+	if len(content) < 100 || !strings.HasPrefix(content, "ssh-rsa") || strings.ContainsAny(content, "\r\n") {
+		ctx.RenderWithErr("SSH key content is not valid.", "repo/deploy_keys", &form)
+		return
+	}
+
+	key := &models.DeployKey{
+		RepoId:     ctx.Repo.Repository.Id,
+		Name:       form.Title,
+		Content:    content,
+		IsWritable: form.IsWritable,
+	}
+	if err := models.AddDeployKey(key); err != nil {
+		if err == models.ErrKeyAlreadyExist {
+			ctx.RenderWithErr("Deploy key title or content has been used.", "repo/deploy_keys", &form)
+			return
+		}
+		ctx.Handle(500, "setting.DeployKeysPost(AddDeployKey)", err)
+		return
+	}
+
+	log.Trace("%s Deploy key added: %s/%s", ctx.Req.RequestURI, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name)
+	models.RecordAudit(ctx.User.Id, ctx.User.Name, models.AUDIT_ADD_DEPLOY_KEY, ctx.RemoteAddr(),
+		fmt.Sprintf("%s (%s) to %s/%s", key.Name, key.Fingerprint, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name))
+	ctx.Flash.Success("New deploy key has been added.")
+	ctx.Redirect(ctx.Repo.RepoLink + "/settings/keys")
+}
+
+func DeployKeysDelete(ctx *middleware.Context) {
+	id, _ := base.StrTo(ctx.Query("id")).Int64()
+	if err := models.DeleteDeployKey(ctx.Repo.Repository.Id, id); err != nil {
+		if err != models.ErrDeployKeyNotExist {
+			ctx.Handle(500, "setting.DeployKeysDelete(DeleteDeployKey)", err)
+			return
+		}
+	} else {
+		log.Trace("%s Deploy key deleted: %s/%s", ctx.Req.RequestURI, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name)
+		models.RecordAudit(ctx.User.Id, ctx.User.Name, models.AUDIT_DELETE_DEPLOY_KEY, ctx.RemoteAddr(),
+			fmt.Sprintf("Key ID %d from %s/%s", id, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name))
+		ctx.Flash.Success("Deploy key has been removed.")
+	}
+	ctx.Redirect(ctx.Repo.RepoLink + "/settings/keys")
+}
+
+func prepareProtectedBranches(ctx *middleware.Context) bool {
+	ctx.Data["IsRepoToolbarProtectedBranches"] = true
+	ctx.Data["Title"] = strings.TrimPrefix(ctx.Repo.RepoLink, "/") + " - Protected Branches"
+
+	pbs, err := models.GetProtectedBranches(ctx.Repo.Repository.Id)
+	if err != nil {
+		ctx.Handle(500, "setting.prepareProtectedBranches(GetProtectedBranches)", err)
+		return false
+	}
+	for _, pb := range pbs {
+		if err = pb.GetWhitelistUsers(); err != nil {
+			ctx.Handle(500, "setting.prepareProtectedBranches(GetWhitelistUsers)", err)
+			return false
+		}
+	}
+	ctx.Data["ProtectedBranches"] = pbs
+	return true
+}
+
+// parseProtectedBranchForm fills protection rules by form,
+// it returns error message when whitelist contains unknown users.
+func parseProtectedBranchForm(pb *models.ProtectedBranch, form auth.ProtectedBranchForm) (string, error) {
+	pb.BlockForcePush = form.BlockForcePush
+	pb.BlockDeletion = form.BlockDeletion
+	pb.RequirePullRequest = form.RequirePullRequest
+	pb.EnableWhitelist = form.EnableWhitelist
+	pb.DismissStale = form.DismissStale
+	pb.RequiredApprovals = form.RequiredApprovals
+	if pb.RequiredApprovals < 0 {
+		pb.RequiredApprovals = 0
+	}
+
+	uids := make([]int64, 0, 5)
+	for _, name := range strings.Split(form.Whitelist, ",") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+		u, err := models.GetUserByName(name)
+		if err != nil {
+			if err == models.ErrUserNotExist {
+				return "User '" + name + "' does not exist.", nil
+			}
+			return "", err
+		}
+		uids = append(uids, u.Id)
+	}
+	pb.SetWhitelistUsers(uids)
+	return "", nil
+}
+
+func ProtectedBranches(ctx *middleware.Context) {
+	if !prepareProtectedBranches(ctx) {
+		return
+	}
+	ctx.HTML(200, "repo/protected_branches")
+}
+
+func ProtectedBranchesPost(ctx *middleware.Context, form auth.ProtectedBranchForm) {
+	if !prepareProtectedBranches(ctx) {
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.HTML(200, "repo/protected_branches")
+		return
+	}
+
+	if !ctx.Repo.GitRepo.IsBranchExist(form.BranchName) {
+		ctx.Data["Err_BranchName"] = true
+		ctx.RenderWithErr("Branch does not exist.", "repo/protected_branches", &form)
+		return
+	}
+
+	pb := &models.ProtectedBranch{
+		RepoId:     ctx.Repo.Repository.Id,
+		BranchName: form.BranchName,
+	}
+	if msg, err := parseProtectedBranchForm(pb, form); err != nil {
+		ctx.Handle(500, "setting.ProtectedBranchesPost(parseProtectedBranchForm)", err)
+		return
+	} else if len(msg) > 0 {
+		ctx.RenderWithErr(msg, "repo/protected_branches", &form)
+		return
+	}
+
+	if err := models.AddProtectedBranch(pb); err != nil {
+		if err == models.ErrProtectedBranchAlreadyExist {
+			ctx.Data["Err_BranchName"] = true
+			ctx.RenderWithErr(err.Error(), "repo/protected_branches", &form)
+			return
+		}
+		ctx.Handle(500, "setting.ProtectedBranchesPost(AddProtectedBranch)", err)
+		return
+	}
+	log.Trace("%s Branch protected: %s/%s:%s", ctx.Req.RequestURI,
+		ctx.Repo.Owner.Name, ctx.Repo.Repository.Name, pb.BranchName)
+
+	ctx.Flash.Success("Branch '" + pb.BranchName + "' is now protected.")
+	ctx.Redirect(ctx.Repo.RepoLink + "/settings/branches")
+}
+
+func UpdateProtectedBranch(ctx *middleware.Context, params martini.Params, form auth.ProtectedBranchForm) {
+	id, _ := base.StrTo(params["id"]).Int64()
+	pb, err := models.GetProtectedBranchById(ctx.Repo.Repository.Id, id)
+	if err != nil {
+		if err == models.ErrProtectedBranchNotExist {
+			ctx.Handle(404, "setting.UpdateProtectedBranch(GetProtectedBranchById)", nil)
+		} else {
+			ctx.Handle(500, "setting.UpdateProtectedBranch(GetProtectedBranchById)", err)
+		}
+		return
+	}
+
+	if msg, err := parseProtectedBranchForm(pb, form); err != nil {
+		ctx.Handle(500, "setting.UpdateProtectedBranch(parseProtectedBranchForm)", err)
+		return
+	} else if len(msg) > 0 {
+		ctx.Flash.Error(msg)
+		ctx.Redirect(ctx.Repo.RepoLink + "/settings/branches")
+		return
+	}
+
+	if err = models.UpdateProtectedBranch(pb); err != nil {
+		ctx.Handle(500, "setting.UpdateProtectedBranch(UpdateProtectedBranch)", err)
+		return
+	}
+	log.Trace("%s Protected branch updated: %s/%s:%s", ctx.Req.RequestURI,
+		ctx.Repo.Owner.Name, ctx.Repo.Repository.Name, pb.BranchName)
+
+	ctx.Flash.Success("Protection rules of branch '" + pb.BranchName + "' have been updated.")
+	ctx.Redirect(ctx.Repo.RepoLink + "/settings/branches")
+}
+
+func DeleteProtectedBranch(ctx *middleware.Context, params martini.Params) {
+	id, _ := base.StrTo(params["id"]).Int64()
+	if err := models.DeleteProtectedBranch(ctx.Repo.Repository.Id, id); err != nil {
+		if err == models.ErrProtectedBranchNotExist {
+			ctx.Handle(404, "setting.DeleteProtectedBranch", err)
+		} else {
+			ctx.Handle(500, "setting.DeleteProtectedBranch", err)
+		}
+		return
+	}
+	log.Trace("%s Protected branch deleted: %s/%s", ctx.Req.RequestURI, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name)
+
+	ctx.Flash.Success("Branch protection has been removed.")
+	ctx.Redirect(ctx.Repo.RepoLink + "/settings/branches")
+}
+
+func preparePushMirrors(ctx *middleware.Context) bool {
+	ctx.Data["IsRepoToolbarPushMirrors"] = true
+	ctx.Data["Title"] = strings.TrimPrefix(ctx.Repo.RepoLink, "/") + " - Push Mirrors"
+
+	mirrors, err := models.GetPushMirrors(ctx.Repo.Repository.Id)
+	if err != nil {
+		ctx.Handle(500, "setting.preparePushMirrors(GetPushMirrors)", err)
+		return false
+	}
+	ctx.Data["PushMirrors"] = mirrors
+	return true
+}
+
+func PushMirrors(ctx *middleware.Context) {
+	if !preparePushMirrors(ctx) {
+		return
+	}
+	ctx.HTML(200, "repo/push_mirrors")
+}
+
+func PushMirrorsPost(ctx *middleware.Context, form auth.PushMirrorForm) {
+	if !preparePushMirrors(ctx) {
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.HTML(200, "repo/push_mirrors")
+		return
+	}
+
+	m := &models.PushMirror{
+		RepoId:       ctx.Repo.Repository.Id,
+		Address:      form.Address,
+		AuthUserName: form.AuthUserName,
+		AuthPasswd:   form.AuthPasswd,
+	}
+	if err := models.AddPushMirror(m); err != nil {
+		ctx.Handle(500, "setting.PushMirrorsPost(AddPushMirror)", err)
+		return
+	}
+	log.Trace("%s Push mirror added: %s/%s -> %s", ctx.Req.RequestURI,
+		ctx.Repo.Owner.Name, ctx.Repo.Repository.Name, m.Address)
+
+	ctx.Flash.Success("Push mirror has been added, repository will be pushed to it in a minute.")
+	ctx.Redirect(ctx.Repo.RepoLink + "/settings/push_mirrors")
+}
+
+func SyncPushMirror(ctx *middleware.Context, params martini.Params) {
+	id, _ := base.StrTo(params["id"]).Int64()
+	m, err := models.GetPushMirrorById(ctx.Repo.Repository.Id, id)
+	if err != nil {
+		if err == models.ErrPushMirrorNotExist {
+			ctx.Handle(404, "setting.SyncPushMirror(GetPushMirrorById)", nil)
+		} else {
+			ctx.Handle(500, "setting.SyncPushMirror(GetPushMirrorById)", err)
+		}
+		return
+	}
+
+	if m.IsSyncing {
+		ctx.Flash.Error(models.ErrMirrorSyncing.Error() + ".")
+	} else {
+		// Pushing may take long, result is shown in mirror status.
+		go models.SyncPushMirror(m)
+		ctx.Flash.Success("Pushing to mirror has been started, refresh page later to see the result.")
+	}
+	ctx.Redirect(ctx.Repo.RepoLink + "/settings/push_mirrors")
+}
+
+func DeletePushMirror(ctx *middleware.Context, params martini.Params) {
+	id, _ := base.StrTo(params["id"]).Int64()
+	if err := models.DeletePushMirror(ctx.Repo.Repository.Id, id); err != nil {
+		if err == models.ErrPushMirrorNotExist {
+			ctx.Handle(404, "setting.DeletePushMirror", err)
+		} else {
+			ctx.Handle(500, "setting.DeletePushMirror", err)
+		}
+		return
+	}
+	log.Trace("%s Push mirror deleted: %s/%s", ctx.Req.RequestURI, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name)
+
+	ctx.Flash.Success("Push mirror has been removed.")
+	ctx.Redirect(ctx.Repo.RepoLink + "/settings/push_mirrors")
+}
@@ -0,0 +1,79 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/auth"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+// prepareTags loads tags of repository, it returns false if response has been written.
+func prepareTags(ctx *middleware.Context) bool {
+	ctx.Data["Title"] = "Tags"
+	ctx.Data["IsRepoToolbarReleases"] = true
+
+	tags, err := models.GetTags(ctx.Repo.GitRepo)
+	if err != nil {
+		ctx.Handle(500, "release.prepareTags(GetTags)", err)
+		return false
+	}
+	ctx.Data["Tags"] = tags
+	return true
+}
+
+func Tags(ctx *middleware.Context) {
+	if !prepareTags(ctx) {
+		return
+	}
+	ctx.Data["target"] = ctx.Repo.Repository.DefaultBranch
+	ctx.HTML(200, "release/tags")
+}
+
+func NewTagPost(ctx *middleware.Context, form auth.NewTagForm) {
+	if !prepareTags(ctx) {
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.HTML(200, "release/tags")
+		return
+	}
+
+	if err := models.CreateTag(ctx.User, ctx.Repo.Repository, form.TagName, form.Target, form.Message); err != nil {
+		switch err {
+		case models.ErrTagAlreadyExist, models.ErrTagNameIllegal:
+			ctx.Data["Err_TagName"] = true
+			ctx.RenderWithErr(err.Error()+".", "release/tags", &form)
+		case models.ErrTagTargetIllegal:
+			ctx.Data["Err_Target"] = true
+			ctx.RenderWithErr(err.Error()+".", "release/tags", &form)
+		default:
+			ctx.Handle(500, "release.NewTagPost(CreateTag)", err)
+		}
+		return
+	}
+	log.Trace("%s Tag created: %s/%s:%s", ctx.Req.RequestURI, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name, form.TagName)
+
+	ctx.Flash.Success("Tag " + form.TagName + " has been created.")
+	ctx.Redirect(ctx.Repo.RepoLink + "/tags")
+}
+
+func DeleteTagPost(ctx *middleware.Context) {
+	tagName := ctx.Query("tag")
+	if err := models.DeleteTag(ctx.User, ctx.Repo.Repository, tagName); err != nil {
+		if err == models.ErrTagNotExist {
+			ctx.Handle(404, "release.DeleteTagPost", nil)
+			return
+		}
+		ctx.Handle(500, "release.DeleteTagPost(DeleteTag)", err)
+		return
+	}
+	log.Trace("%s Tag deleted: %s/%s:%s", ctx.Req.RequestURI, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name, tagName)
+
+	ctx.Flash.Success("Tag " + tagName + " has been deleted.")
+	ctx.Redirect(ctx.Repo.RepoLink + "/tags")
+}
@@ -0,0 +1,231 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/auth"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+const (
+	WIKI_VIEW    = "wiki/view"
+	WIKI_NEW     = "wiki/new"
+	WIKI_PAGES   = "wiki/pages"
+	WIKI_HISTORY = "wiki/history"
+)
+
+func prepareWiki(ctx *middleware.Context) {
+	ctx.Data["IsRepoToolbarWiki"] = true
+	ctx.Data["WikiLink"] = ctx.Repo.RepoLink + "/wiki"
+	ctx.Data["WikiCloneLink"] = map[string]string{
+		"SSH":   strings.TrimSuffix(ctx.Repo.CloneLink.SSH, ".git") + ".wiki.git",
+		"HTTPS": strings.TrimSuffix(ctx.Repo.CloneLink.HTTPS, ".git") + ".wiki.git",
+	}
+}
+
+func Wiki(ctx *middleware.Context, params martini.Params) {
+	prepareWiki(ctx)
+
+	name := params["page"]
+	if len(name) == 0 {
+		name = models.WIKI_HOME_PAGE
+	}
+
+	page, err := models.GetWikiPage(ctx.Repo.Owner.Name, ctx.Repo.Repository.Name, name)
+	if err != nil {
+		if err != models.ErrWikiPageNotExist {
+			ctx.Handle(500, "repo.Wiki(GetWikiPage)", err)
+			return
+		} else if ctx.Repo.IsOwner {
+			ctx.Redirect(ctx.Repo.RepoLink + "/wiki/_new?title=" + url.QueryEscape(models.WikiPageTitle(name)))
+			return
+		} else if name != models.WIKI_HOME_PAGE {
+			ctx.Handle(404, "repo.Wiki(GetWikiPage)", nil)
+			return
+		}
+		ctx.Data["Title"] = "Wiki"
+		ctx.Data["IsEmptyWiki"] = true
+		ctx.HTML(200, WIKI_VIEW)
+		return
+	}
+
+	ctx.Data["Title"] = page.Title + " - Wiki"
+	ctx.Data["Page"] = page
+	ctx.Data["PageContent"] = string(base.RenderMarkdown(page.Content, ctx.Repo.RepoLink+"/wiki"))
+	ctx.HTML(200, WIKI_VIEW)
+}
+
+func WikiPages(ctx *middleware.Context) {
+	prepareWiki(ctx)
+	ctx.Data["Title"] = "Pages - Wiki"
+
+	pages, err := models.GetWikiPages(ctx.Repo.Owner.Name, ctx.Repo.Repository.Name)
+	if err != nil {
+		ctx.Handle(500, "repo.WikiPages(GetWikiPages)", err)
+		return
+	}
+	ctx.Data["Pages"] = pages
+	ctx.HTML(200, WIKI_PAGES)
+}
+
+func WikiHistory(ctx *middleware.Context, params martini.Params) {
+	prepareWiki(ctx)
+
+	name := params["page"]
+	ctx.Data["Title"] = models.WikiPageTitle(name) + " History - Wiki"
+	ctx.Data["PageName"] = name
+	ctx.Data["PageTitle"] = models.WikiPageTitle(name)
+
+	page, _ := base.StrTo(ctx.Query("p")).Int()
+	if page < 1 {
+		page = 1
+	}
+
+	count, commits, err := models.GetWikiPageHistory(ctx.Repo.Owner.Name, ctx.Repo.Repository.Name, name, page)
+	if err != nil {
+		if err == models.ErrWikiPageNotExist {
+			ctx.Handle(404, "repo.WikiHistory(GetWikiPageHistory)", nil)
+		} else {
+			ctx.Handle(500, "repo.WikiHistory(GetWikiPageHistory)", err)
+		}
+		return
+	}
+
+	lastPage := page - 1
+	nextPage := page + 1
+	if nextPage*50 > count {
+		nextPage = 0
+	}
+
+	ctx.Data["Commits"] = commits
+	ctx.Data["CommitCount"] = count
+	ctx.Data["LastPageNum"] = lastPage
+	ctx.Data["NextPageNum"] = nextPage
+	ctx.HTML(200, WIKI_HISTORY)
+}
+
+func NewWikiPage(ctx *middleware.Context) {
+	prepareWiki(ctx)
+	ctx.Data["Title"] = "New Page - Wiki"
+	ctx.Data["IsWikiPageNew"] = true
+
+	title := ctx.Query("title")
+	if len(title) == 0 && !models.HasWiki(ctx.Repo.Owner.Name, ctx.Repo.Repository.Name) {
+		title = models.WIKI_HOME_PAGE
+	}
+	ctx.Data["title"] = title
+	ctx.HTML(200, WIKI_NEW)
+}
+
+func NewWikiPagePost(ctx *middleware.Context, form auth.WikiPageForm) {
+	prepareWiki(ctx)
+	ctx.Data["Title"] = "New Page - Wiki"
+	ctx.Data["IsWikiPageNew"] = true
+
+	if ctx.HasError() {
+		ctx.HTML(200, WIKI_NEW)
+		return
+	}
+
+	name := models.WikiPageName(form.Title)
+	if err := models.AddWikiPage(ctx.User, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name,
+		name, form.Content, form.Message); err != nil {
+		switch err {
+		case models.ErrWikiPageAlreadyExist:
+			ctx.Data["Err_Title"] = true
+			ctx.RenderWithErr("Page with this title already exists.", WIKI_NEW, &form)
+		case models.ErrWikiPageNameIllegal:
+			ctx.Data["Err_Title"] = true
+			ctx.RenderWithErr(err.Error(), WIKI_NEW, &form)
+		default:
+			ctx.Handle(500, "repo.NewWikiPagePost(AddWikiPage)", err)
+		}
+		return
+	}
+	log.Trace("%s Wiki page created: %s/%s:%s", ctx.Req.RequestURI,
+		ctx.Repo.Owner.LowerName, ctx.Repo.Repository.LowerName, name)
+
+	ctx.Redirect(ctx.Repo.RepoLink + "/wiki/" + url.QueryEscape(name))
+}
+
+func EditWikiPage(ctx *middleware.Context, params martini.Params) {
+	prepareWiki(ctx)
+
+	page, err := models.GetWikiPage(ctx.Repo.Owner.Name, ctx.Repo.Repository.Name, params["page"])
+	if err != nil {
+		if err == models.ErrWikiPageNotExist {
+			ctx.Handle(404, "repo.EditWikiPage(GetWikiPage)", nil)
+		} else {
+			ctx.Handle(500, "repo.EditWikiPage(GetWikiPage)", err)
+		}
+		return
+	}
+
+	ctx.Data["Title"] = "Edit " + page.Title + " - Wiki"
+	ctx.Data["Page"] = page
+	ctx.Data["title"] = page.Title
+	ctx.Data["content"] = string(page.Content)
+	ctx.HTML(200, WIKI_NEW)
+}
+
+func EditWikiPagePost(ctx *middleware.Context, params martini.Params, form auth.WikiPageForm) {
+	prepareWiki(ctx)
+
+	oldName := params["page"]
+	ctx.Data["Title"] = "Edit " + models.WikiPageTitle(oldName) + " - Wiki"
+	ctx.Data["Page"] = &models.WikiPage{Name: oldName, Title: models.WikiPageTitle(oldName)}
+
+	if ctx.HasError() {
+		ctx.HTML(200, WIKI_NEW)
+		return
+	}
+
+	name := models.WikiPageName(form.Title)
+	if err := models.EditWikiPage(ctx.User, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name,
+		oldName, name, form.Content, form.Message); err != nil {
+		switch err {
+		case models.ErrWikiPageNotExist:
+			ctx.Handle(404, "repo.EditWikiPagePost(EditWikiPage)", nil)
+		case models.ErrWikiPageAlreadyExist:
+			ctx.Data["Err_Title"] = true
+			ctx.RenderWithErr("Page with this title already exists.", WIKI_NEW, &form)
+		case models.ErrWikiPageNameIllegal:
+			ctx.Data["Err_Title"] = true
+			ctx.RenderWithErr(err.Error(), WIKI_NEW, &form)
+		default:
+			ctx.Handle(500, "repo.EditWikiPagePost(EditWikiPage)", err)
+		}
+		return
+	}
+	log.Trace("%s Wiki page edited: %s/%s:%s", ctx.Req.RequestURI,
+		ctx.Repo.Owner.LowerName, ctx.Repo.Repository.LowerName, name)
+
+	ctx.Redirect(ctx.Repo.RepoLink + "/wiki/" + url.QueryEscape(name))
+}
+
+func DeleteWikiPage(ctx *middleware.Context, params martini.Params) {
+	name := params["page"]
+	if err := models.DeleteWikiPage(ctx.User, ctx.Repo.Owner.Name, ctx.Repo.Repository.Name, name); err != nil {
+		if err == models.ErrWikiPageNotExist {
+			ctx.Handle(404, "repo.DeleteWikiPage", nil)
+		} else {
+			ctx.Handle(500, "repo.DeleteWikiPage", err)
+		}
+		return
+	}
+	log.Trace("%s Wiki page deleted: %s/%s:%s", ctx.Req.RequestURI,
+		ctx.Repo.Owner.LowerName, ctx.Repo.Repository.LowerName, name)
+
+	ctx.Flash.Success("Page '" + models.WikiPageTitle(name) + "' has been deleted.")
+	ctx.Redirect(ctx.Repo.RepoLink + "/wiki/_pages")
+}
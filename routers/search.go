@@ -0,0 +1,72 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package routers
+
+import (
+	"strings"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/middleware"
+	"github.com/gogits/gogs/modules/setting"
+)
+
+const _CODE_SEARCH_PAGE_SIZE = 10
+
+func SearchCode(ctx *middleware.Context) {
+	ctx.Data["Title"] = "Search Code"
+	ctx.Data["PageIsSearch"] = true
+
+	if !setting.RepoIndexerEnabled {
+		ctx.Handle(404, "routers.SearchCode", nil)
+		return
+	}
+
+	keyword := strings.TrimSpace(ctx.Query("q"))
+	lang := ctx.Query("l")
+	repoLink := ctx.Query("repo")
+	page, _ := base.StrTo(ctx.Query("p")).Int()
+	if page < 1 {
+		page = 1
+	}
+	ctx.Data["Keyword"] = keyword
+	ctx.Data["Language"] = lang
+	ctx.Data["SearchRepo"] = repoLink
+	ctx.Data["Languages"] = models.CodeLanguages()
+
+	if len(keyword) == 0 {
+		ctx.HTML(200, "search")
+		return
+	}
+
+	repoIds, err := models.GetSearchableRepoIds(ctx.User, repoLink)
+	if err != nil {
+		if err == models.ErrRepoNotExist {
+			ctx.Handle(404, "routers.SearchCode(GetSearchableRepoIds)", err)
+		} else {
+			ctx.Handle(500, "routers.SearchCode(GetSearchableRepoIds)", err)
+		}
+		return
+	}
+
+	results, total, err := models.SearchCode(&models.SearchCodeOptions{
+		Keyword:  keyword,
+		Language: lang,
+		RepoIds:  repoIds,
+		Page:     page,
+		PageSize: _CODE_SEARCH_PAGE_SIZE,
+	})
+	if err != nil {
+		ctx.Handle(500, "routers.SearchCode(SearchCode)", err)
+		return
+	}
+	ctx.Data["Results"] = results
+	ctx.Data["Total"] = total
+	ctx.Data["LastPageNum"] = page - 1
+	if page*_CODE_SEARCH_PAGE_SIZE < total {
+		ctx.Data["NextPageNum"] = page + 1
+	}
+	ctx.HTML(200, "search")
+}
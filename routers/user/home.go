@@ -6,6 +6,7 @@ package user
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/Unknwon/com"
 	"github.com/go-martini/martini"
@@ -15,25 +16,44 @@ import (
 	"github.com/gogits/gogs/modules/base"
 	"github.com/gogits/gogs/modules/log"
 	"github.com/gogits/gogs/modules/middleware"
+	"github.com/gogits/gogs/modules/setting"
 )
 
 func Dashboard(ctx *middleware.Context) {
 	ctx.Data["Title"] = "Dashboard"
 	ctx.Data["PageIsUserDashboard"] = true
+	ctx.Data["IsActivationPending"] = !ctx.User.IsActive && setting.Service.RegisterEmailConfirm
 
-	var err error
-	ctx.Data["MyRepos"], err = models.GetRepositories(ctx.User.Id, true)
+	myRepos, err := models.GetRepositories(ctx.User.Id, true)
 	if err != nil {
 		ctx.Handle(500, "home.Dashboard(GetRepositories)", err)
 		return
 	}
-
-	ctx.Data["CollaborativeRepos"], err = models.GetCollaborativeRepos(ctx.User.Name)
+	collaRepos, err := models.GetCollaborativeRepos(ctx.User.Name)
 	if err != nil {
 		ctx.Handle(500, "home.Dashboard(GetCollaborativeRepos)", err)
 		return
 	}
 
+	topic := strings.ToLower(ctx.Query("topic"))
+	ctx.Data["Topic"] = topic
+	hideArchived := ctx.Query("hide_archived") == "1"
+	ctx.Data["HideArchived"] = hideArchived
+	if ctx.Data["MyRepos"], err = filterRepos(myRepos, topic, hideArchived); err != nil {
+		ctx.Handle(500, "home.Dashboard(filterRepos)", err)
+		return
+	}
+	if ctx.Data["CollaborativeRepos"], err = filterRepos(collaRepos, topic, hideArchived); err != nil {
+		ctx.Handle(500, "home.Dashboard(filterRepos)", err)
+		return
+	}
+
+	ctx.Data["NumRepoTransfers"], err = models.CountIncomingRepoTransfers(ctx.User.Id)
+	if err != nil {
+		ctx.Handle(500, "home.Dashboard(CountIncomingRepoTransfers)", err)
+		return
+	}
+
 	actions, err := models.GetFeeds(ctx.User.Id, 0, false)
 	if err != nil {
 		ctx.Handle(500, "home.Dashboard(GetFeeds)", err)
@@ -52,9 +72,37 @@ func Dashboard(ctx *middleware.Context) {
 		feeds = append(feeds, act)
 	}
 	ctx.Data["Feeds"] = feeds
+
+	ctx.Data["IssueFilters"], err = models.GetUserIssueFilters(ctx.User)
+	if err != nil {
+		ctx.Handle(500, "home.Dashboard(GetUserIssueFilters)", err)
+		return
+	}
+	ctx.Data["ReviewRequests"], err = models.GetRequestedReviewPulls(ctx.User)
+	if err != nil {
+		ctx.Handle(500, "home.Dashboard(GetRequestedReviewPulls)", err)
+		return
+	}
 	ctx.HTML(200, "user/dashboard")
 }
 
+// filterRepos loads topics of repositories and returns the ones have given topic,
+// topic is not checked if it is empty, archived repositories are skipped if asked.
+func filterRepos(repos []*models.Repository, topic string, hideArchived bool) ([]*models.Repository, error) {
+	filtered := make([]*models.Repository, 0, len(repos))
+	for _, repo := range repos {
+		if hideArchived && repo.IsArchived {
+			continue
+		} else if err := repo.GetTopics(); err != nil {
+			return nil, err
+		}
+		if len(topic) == 0 || repo.HasTopic(topic) {
+			filtered = append(filtered, repo)
+		}
+	}
+	return filtered, nil
+}
+
 func Profile(ctx *middleware.Context, params martini.Params) {
 	ctx.Data["Title"] = "Profile"
 	ctx.Data["PageIsUserProfile"] = true
@@ -264,3 +312,75 @@ func Pulls(ctx *middleware.Context) {
 func Stars(ctx *middleware.Context) {
 	ctx.HTML(200, "user/stars")
 }
+
+func Notifications(ctx *middleware.Context) {
+	ctx.Data["Title"] = "Notifications"
+	ctx.Data["PageIsNotifications"] = true
+
+	page, _ := base.StrTo(ctx.Query("page")).Int()
+	if page <= 0 {
+		page = 1
+	}
+	ns, err := models.GetNotifications(ctx.User.Id, page)
+	if err != nil {
+		ctx.Handle(500, "user.Notifications(GetNotifications)", err)
+		return
+	}
+	ctx.Data["Notifications"] = ns
+	ctx.Data["Page"] = page
+	ctx.Data["PreviousPage"] = page - 1
+	ctx.Data["NextPage"] = page + 1
+	ctx.Data["HasNextPage"] = len(ns) == 20
+	ctx.HTML(200, "user/notifications")
+}
+
+func NotificationRedirect(ctx *middleware.Context, params martini.Params) {
+	id, _ := base.StrTo(params["id"]).Int64()
+	n, err := models.GetNotificationById(ctx.User.Id, id)
+	if err != nil {
+		if err == models.ErrNotificationNotExist {
+			ctx.Handle(404, "user.NotificationRedirect(GetNotificationById)", err)
+		} else {
+			ctx.Handle(500, "user.NotificationRedirect(GetNotificationById)", err)
+		}
+		return
+	}
+
+	if !n.IsRead {
+		if err = models.MarkNotificationRead(n); err != nil {
+			ctx.Handle(500, "user.NotificationRedirect(MarkNotificationRead)", err)
+			return
+		}
+	}
+
+	if n.Repo, err = models.GetRepositoryById(n.RepoId); err != nil {
+		if err == models.ErrRepoNotExist {
+			ctx.Handle(404, "user.NotificationRedirect(GetRepositoryById)", err)
+		} else {
+			ctx.Handle(500, "user.NotificationRedirect(GetRepositoryById)", err)
+		}
+		return
+	} else if err = n.Repo.GetOwner(); err != nil {
+		ctx.Handle(500, "user.NotificationRedirect(GetOwner)", err)
+		return
+	} else if n.IsCommitComment() {
+		ctx.Redirect(n.Link())
+		return
+	} else if n.Issue, err = models.GetIssueById(n.IssueId); err != nil {
+		if err == models.ErrIssueNotExist {
+			ctx.Handle(404, "user.NotificationRedirect(GetIssueById)", err)
+		} else {
+			ctx.Handle(500, "user.NotificationRedirect(GetIssueById)", err)
+		}
+		return
+	}
+	ctx.Redirect(n.Link())
+}
+
+func MarkNotificationsRead(ctx *middleware.Context) {
+	if err := models.MarkAllNotificationsRead(ctx.User.Id); err != nil {
+		ctx.Handle(500, "user.MarkNotificationsRead", err)
+		return
+	}
+	ctx.Redirect("/notifications")
+}
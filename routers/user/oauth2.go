@@ -0,0 +1,161 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"crypto/subtle"
+	"net/url"
+	"strings"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+// oauthRedirect redirects back to OAuth application with given parameters.
+func oauthRedirect(ctx *middleware.Context, uri string, params url.Values) {
+	if state := ctx.Query("state"); len(state) > 0 {
+		params.Set("state", state)
+	}
+	if strings.Contains(uri, "?") {
+		uri += "&" + params.Encode()
+	} else {
+		uri += "?" + params.Encode()
+	}
+	ctx.Redirect(uri)
+}
+
+// prepareOauthAuthorize validates authorization request and returns
+// the application, redirect URI and scope that user is granting.
+func prepareOauthAuthorize(ctx *middleware.Context) (*models.OauthApplication, string, string, bool) {
+	app, err := models.GetOauthApplicationByClientId(ctx.Query("client_id"))
+	if err != nil {
+		if err == models.ErrOauthAppNotExist {
+			ctx.Handle(404, "user.prepareOauthAuthorize(GetOauthApplicationByClientId)", err)
+		} else {
+			ctx.Handle(500, "user.prepareOauthAuthorize(GetOauthApplicationByClientId)", err)
+		}
+		return nil, "", "", false
+	}
+
+	redirectUri := ctx.Query("redirect_uri")
+	if len(redirectUri) == 0 {
+		redirectUri = app.DefaultRedirectUri()
+	} else if !app.IsValidRedirectUri(redirectUri) {
+		ctx.Handle(400, "user.prepareOauthAuthorize(IsValidRedirectUri)", models.ErrOauthRedirectInvalid)
+		return nil, "", "", false
+	}
+
+	if rt := ctx.Query("response_type"); len(rt) > 0 && rt != "code" {
+		oauthRedirect(ctx, redirectUri, url.Values{"error": {"unsupported_response_type"}})
+		return nil, "", "", false
+	}
+
+	// Application must ask for scopes explicitly, token without any scope has no access.
+	scope, err := models.ParseScopes(ctx.Query("scope"))
+	if err != nil || len(scope) == 0 {
+		oauthRedirect(ctx, redirectUri, url.Values{"error": {"invalid_scope"}})
+		return nil, "", "", false
+	}
+	return app, redirectUri, scope, true
+}
+
+func OauthAuthorize(ctx *middleware.Context) {
+	ctx.Data["Title"] = "Authorize Application"
+
+	app, redirectUri, scope, ok := prepareOauthAuthorize(ctx)
+	if !ok {
+		return
+	}
+
+	owner, err := models.GetUserById(app.Uid)
+	if err != nil {
+		ctx.Handle(500, "user.OauthAuthorize(GetUserById)", err)
+		return
+	}
+
+	ctx.Data["App"] = app
+	ctx.Data["AppOwner"] = owner
+	ctx.Data["RedirectUri"] = redirectUri
+	ctx.Data["Scope"] = scope
+	ctx.Data["Scopes"] = strings.Split(scope, ",")
+	ctx.Data["State"] = ctx.Query("state")
+	ctx.HTML(200, "user/authorize")
+}
+
+func OauthAuthorizePost(ctx *middleware.Context) {
+	app, redirectUri, scope, ok := prepareOauthAuthorize(ctx)
+	if !ok {
+		return
+	}
+
+	if ctx.Query("granted") != "true" {
+		oauthRedirect(ctx, redirectUri, url.Values{"error": {"access_denied"}})
+		return
+	}
+
+	c, err := models.NewOauthCode(app, ctx.User.Id, redirectUri, scope)
+	if err != nil {
+		ctx.Handle(500, "user.OauthAuthorizePost(NewOauthCode)", err)
+		return
+	}
+	log.Trace("%s OAuth application authorized: %s -> %s", ctx.Req.RequestURI, ctx.User.LowerName, app.Name)
+
+	oauthRedirect(ctx, redirectUri, url.Values{"code": {c.Code}})
+}
+
+func oauthError(ctx *middleware.Context, status int, err, desc string) {
+	ctx.JSON(status, map[string]interface{}{
+		"error":             err,
+		"error_description": desc,
+	})
+}
+
+func OauthAccessToken(ctx *middleware.Context) {
+	if gt := ctx.Query("grant_type"); len(gt) > 0 && gt != "authorization_code" {
+		oauthError(ctx, 400, "unsupported_grant_type", "Only authorization_code grant type is supported.")
+		return
+	}
+
+	// Client credentials can be passed by form or basic authentication.
+	clientId, clientSecret := ctx.Query("client_id"), ctx.Query("client_secret")
+	if auths := strings.Fields(ctx.Req.Header.Get("Authorization")); len(auths) == 2 && auths[0] == "Basic" {
+		clientId, clientSecret, _ = base.BasicAuthDecode(auths[1])
+	}
+
+	app, err := models.GetOauthApplicationByClientId(clientId)
+	if err != nil {
+		if err == models.ErrOauthAppNotExist {
+			oauthError(ctx, 401, "invalid_client", "Client ID or secret is not correct.")
+		} else {
+			oauthError(ctx, 500, "server_error", err.Error())
+		}
+		return
+	} else if subtle.ConstantTimeCompare([]byte(app.ClientSecret), []byte(clientSecret)) != 1 {
+		oauthError(ctx, 401, "invalid_client", "Client ID or secret is not correct.")
+		return
+	}
+
+	t, err := models.ExchangeOauthCode(app, ctx.Query("code"), ctx.Query("redirect_uri"))
+	if err != nil {
+		switch err {
+		case models.ErrOauthCodeNotExist:
+			oauthError(ctx, 400, "invalid_grant", "Authorization code is not valid or has expired.")
+		case models.ErrOauthRedirectInvalid:
+			oauthError(ctx, 400, "invalid_grant", "Redirect URI does not match.")
+		default:
+			oauthError(ctx, 500, "server_error", err.Error())
+		}
+		return
+	}
+	log.Trace("%s OAuth access token issued: %s -> %d", ctx.Req.RequestURI, app.Name, t.Uid)
+
+	ctx.JSON(200, map[string]interface{}{
+		"access_token": t.Sha1,
+		"token_type":   "bearer",
+		"scope":        t.Scope,
+	})
+}
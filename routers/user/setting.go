@@ -5,13 +5,21 @@
 package user
 
 import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"net/url"
 	"strings"
+	"time"
+
+	"code.google.com/p/rsc/qr"
 
 	"github.com/gogits/gogs/models"
 	"github.com/gogits/gogs/modules/auth"
 	"github.com/gogits/gogs/modules/base"
 	"github.com/gogits/gogs/modules/log"
 	"github.com/gogits/gogs/modules/middleware"
+	"github.com/gogits/gogs/modules/setting"
 )
 
 func Setting(ctx *middleware.Context) {
@@ -75,8 +83,12 @@ func SettingSocial(ctx *middleware.Context) {
 	// Unbind social account.
 	remove, _ := base.StrTo(ctx.Query("remove")).Int64()
 	if remove > 0 {
-		if err := models.DeleteOauth2ById(remove); err != nil {
-			ctx.Handle(500, "user.SettingSocial(DeleteOauth2ById)", err)
+		if err := models.DeleteUserOauth2ById(ctx.User.Id, remove); err != nil {
+			if err == models.ErrOauth2RecordNotExist {
+				ctx.Handle(404, "user.SettingSocial(DeleteUserOauth2ById)", err)
+			} else {
+				ctx.Handle(500, "user.SettingSocial(DeleteUserOauth2ById)", err)
+			}
 			return
 		}
 		ctx.Flash.Success("OAuth2 has been unbinded.")
@@ -84,6 +96,11 @@ func SettingSocial(ctx *middleware.Context) {
 		return
 	}
 
+	if setting.OauthService != nil {
+		ctx.Data["OauthEnabled"] = true
+		ctx.Data["OauthService"] = setting.OauthService
+	}
+
 	var err error
 	ctx.Data["Socials"], err = models.GetOauthByUserId(ctx.User.Id)
 	if err != nil {
@@ -110,12 +127,7 @@ func SettingPasswordPost(ctx *middleware.Context, form auth.UpdatePasswdForm) {
 		return
 	}
 
-	tmpUser := &models.User{
-		Passwd: form.OldPasswd,
-		Salt:   ctx.User.Salt,
-	}
-	tmpUser.EncodePasswd()
-	if ctx.User.Passwd != tmpUser.Passwd {
+	if !ctx.User.ValidatePassword(form.OldPasswd) {
 		ctx.Flash.Error("Old password is not correct.")
 	} else if form.NewPasswd != form.RetypePasswd {
 		ctx.Flash.Error("New password and re-type password are not same.")
@@ -126,8 +138,12 @@ func SettingPasswordPost(ctx *middleware.Context, form auth.UpdatePasswdForm) {
 		if err := models.UpdateUser(ctx.User); err != nil {
 			ctx.Handle(200, "setting.SettingPassword", err)
 			return
+		} else if err = models.DeleteUserRememberTokens(ctx.User.Id); err != nil {
+			ctx.Handle(500, "setting.SettingPassword(DeleteUserRememberTokens)", err)
+			return
 		}
 		log.Trace("%s User password updated: %s", ctx.Req.RequestURI, ctx.User.LowerName)
+		models.RecordAudit(ctx.User.Id, ctx.User.Name, models.AUDIT_CHANGE_PASSWORD, ctx.RemoteAddr(), "")
 		ctx.Flash.Success("Password is changed successfully. You can now sign in via new password.")
 	}
 	ctx.Redirect("/user/settings/password")
@@ -158,6 +174,8 @@ func SettingSSHKeys(ctx *middleware.Context, form auth.AddSSHKeyForm) {
 			})
 		} else {
 			log.Trace("%s User SSH key deleted: %s", ctx.Req.RequestURI, ctx.User.LowerName)
+			models.RecordAudit(ctx.User.Id, ctx.User.Name, models.AUDIT_DELETE_SSH_KEY, ctx.RemoteAddr(),
+				fmt.Sprintf("Key ID: %d", id))
 			ctx.JSON(200, map[string]interface{}{
 				"ok": true,
 			})
@@ -180,7 +198,8 @@ func SettingSSHKeys(ctx *middleware.Context, form auth.AddSSHKeyForm) {
 			return
 		}
 
-		if len(form.KeyContent) < 100 || !strings.HasPrefix(form.KeyContent, "ssh-rsa") {
+		if len(form.KeyContent) < 100 || !strings.HasPrefix(form.KeyContent, "ssh-rsa") ||
+			strings.ContainsAny(form.KeyContent, "\r\n") {
 			ctx.Flash.Error("SSH key content is not valid.")
 			ctx.Redirect("/user/settings/ssh")
 			return
@@ -191,6 +210,15 @@ func SettingSSHKeys(ctx *middleware.Context, form auth.AddSSHKeyForm) {
 			Name:    form.KeyName,
 			Content: form.KeyContent,
 		}
+		if len(form.Expires) > 0 {
+			expires, err := time.ParseInLocation("2006-01-02", form.Expires, time.Local)
+			if err != nil || expires.Before(time.Now()) {
+				ctx.Flash.Error("SSH key expiration date must be a future date in format YYYY-MM-DD.")
+				ctx.Redirect("/user/settings/ssh")
+				return
+			}
+			k.Expires = expires
+		}
 
 		if err := models.AddPublicKey(k); err != nil {
 			if err.Error() == models.ErrKeyAlreadyExist.Error() {
@@ -201,6 +229,8 @@ func SettingSSHKeys(ctx *middleware.Context, form auth.AddSSHKeyForm) {
 			return
 		} else {
 			log.Trace("%s User SSH key added: %s", ctx.Req.RequestURI, ctx.User.LowerName)
+			models.RecordAudit(ctx.User.Id, ctx.User.Name, models.AUDIT_ADD_SSH_KEY, ctx.RemoteAddr(),
+				fmt.Sprintf("%s (%s)", k.Name, k.Fingerprint))
 			ctx.Flash.Success("New SSH Key has been added!")
 			ctx.Redirect("/user/settings/ssh")
 			return
@@ -210,6 +240,219 @@ func SettingSSHKeys(ctx *middleware.Context, form auth.AddSSHKeyForm) {
 	ctx.HTML(200, "user/publickey")
 }
 
+// prepareGPGKeys fills GPG keys of signed in user, it returns false
+// when response has been written.
+func prepareGPGKeys(ctx *middleware.Context) bool {
+	keys, err := models.ListGPGKeys(ctx.User.Id)
+	if err != nil {
+		ctx.Handle(500, "user.prepareGPGKeys(ListGPGKeys)", err)
+		return false
+	}
+	ctx.Data["Keys"] = keys
+	return true
+}
+
+func SettingGPGKeys(ctx *middleware.Context) {
+	ctx.Data["Title"] = "GPG Keys"
+	ctx.Data["PageIsUserSetting"] = true
+	ctx.Data["IsUserPageSettingGPG"] = true
+
+	if !prepareGPGKeys(ctx) {
+		return
+	}
+	ctx.HTML(200, "user/gpg_keys")
+}
+
+func SettingGPGKeysPost(ctx *middleware.Context, form auth.AddGPGKeyForm) {
+	ctx.Data["Title"] = "GPG Keys"
+	ctx.Data["PageIsUserSetting"] = true
+	ctx.Data["IsUserPageSettingGPG"] = true
+
+	if !prepareGPGKeys(ctx) {
+		return
+	}
+	if ctx.HasError() {
+		ctx.HTML(200, "user/gpg_keys")
+		return
+	}
+
+	key, err := models.AddGPGKey(ctx.User.Id, form.Content)
+	if err != nil {
+		switch err {
+		case models.ErrGPGKeyInvalid, models.ErrGPGKeyAlreadyExist:
+			ctx.Data["Err_Content"] = true
+			ctx.RenderWithErr(err.Error(), "user/gpg_keys", &form)
+		default:
+			ctx.Handle(500, "user.SettingGPGKeysPost(AddGPGKey)", err)
+		}
+		return
+	}
+	log.Trace("%s GPG key added: %s(%s)", ctx.Req.RequestURI, ctx.User.LowerName, key.KeyId)
+
+	ctx.Flash.Success("GPG key " + key.KeyId + " has been added.")
+	ctx.Redirect("/user/settings/gpg")
+}
+
+func SettingGPGKeysDelete(ctx *middleware.Context) {
+	id, _ := base.StrTo(ctx.Query("id")).Int64()
+	if err := models.DeleteGPGKey(ctx.User.Id, id); err != nil {
+		if err == models.ErrGPGKeyNotExist {
+			ctx.Handle(404, "user.SettingGPGKeysDelete(DeleteGPGKey)", err)
+		} else {
+			ctx.Handle(500, "user.SettingGPGKeysDelete(DeleteGPGKey)", err)
+		}
+		return
+	}
+	log.Trace("%s GPG key deleted: %s", ctx.Req.RequestURI, ctx.User.LowerName)
+
+	ctx.Flash.Success("GPG key has been deleted.")
+	ctx.Redirect("/user/settings/gpg")
+}
+
+// prepareApplications fills personal access tokens, OAuth applications
+// and authorized applications of current user.
+func prepareApplications(ctx *middleware.Context) bool {
+	ctx.Data["AccessScopes"] = models.AccessScopes
+
+	var err error
+	ctx.Data["Tokens"], err = models.ListAccessTokens(ctx.User.Id)
+	if err != nil {
+		ctx.Handle(500, "user.prepareApplications(ListAccessTokens)", err)
+		return false
+	}
+	ctx.Data["Apps"], err = models.GetOauthApplicationsByUid(ctx.User.Id)
+	if err != nil {
+		ctx.Handle(500, "user.prepareApplications(GetOauthApplicationsByUid)", err)
+		return false
+	}
+	ctx.Data["AuthorizedTokens"], err = models.ListOauthAccessTokens(ctx.User.Id)
+	if err != nil {
+		ctx.Handle(500, "user.prepareApplications(ListOauthAccessTokens)", err)
+		return false
+	}
+	return true
+}
+
+func SettingApplications(ctx *middleware.Context) {
+	ctx.Data["Title"] = "Applications"
+	ctx.Data["PageIsUserSetting"] = true
+	ctx.Data["IsUserPageSettingApps"] = true
+
+	if !prepareApplications(ctx) {
+		return
+	}
+	ctx.HTML(200, "user/applications")
+}
+
+func SettingApplicationsPost(ctx *middleware.Context, form auth.NewAccessTokenForm) {
+	ctx.Data["Title"] = "Applications"
+	ctx.Data["PageIsUserSetting"] = true
+	ctx.Data["IsUserPageSettingApps"] = true
+
+	if ctx.HasError() {
+		if !prepareApplications(ctx) {
+			return
+		}
+		ctx.HTML(200, "user/applications")
+		return
+	}
+
+	scope, err := models.ParseScopes(strings.Join(form.Scopes, ","))
+	if err != nil {
+		ctx.Flash.Error("Unknown scope of access token.")
+		ctx.Redirect("/user/settings/applications")
+		return
+	}
+
+	t := &models.AccessToken{
+		Uid:   ctx.User.Id,
+		Name:  form.TokenName,
+		Scope: scope,
+	}
+	if err := models.NewAccessToken(t); err != nil {
+		ctx.Handle(500, "user.SettingApplicationsPost(NewAccessToken)", err)
+		return
+	}
+	log.Trace("%s Access token generated: %s", ctx.Req.RequestURI, ctx.User.LowerName)
+
+	ctx.ShowSuccess("New access token has been generated, make sure to copy it now as you won't be able to see it again: " + t.Sha1)
+	SettingApplications(ctx)
+}
+
+func SettingOauthApplicationPost(ctx *middleware.Context, form auth.NewOauthApplicationForm) {
+	ctx.Data["Title"] = "Applications"
+	ctx.Data["PageIsUserSetting"] = true
+	ctx.Data["IsUserPageSettingApps"] = true
+
+	if ctx.HasError() {
+		if !prepareApplications(ctx) {
+			return
+		}
+		ctx.HTML(200, "user/applications")
+		return
+	}
+
+	uris := make([]string, 0, 2)
+	for _, uri := range strings.Split(form.RedirectUris, "\n") {
+		uri = strings.TrimSpace(uri)
+		if len(uri) == 0 {
+			continue
+		} else if u, err := url.Parse(uri); err != nil || !u.IsAbs() {
+			ctx.Flash.Error("Redirect URI is not valid: " + uri)
+			ctx.Redirect("/user/settings/applications")
+			return
+		}
+		uris = append(uris, uri)
+	}
+
+	app := &models.OauthApplication{
+		Uid:          ctx.User.Id,
+		Name:         form.AppName,
+		Website:      form.Website,
+		RedirectUris: strings.Join(uris, "\n"),
+	}
+	if err := models.NewOauthApplication(app); err != nil {
+		ctx.Handle(500, "user.SettingOauthApplicationPost(NewOauthApplication)", err)
+		return
+	}
+	log.Trace("%s OAuth application registered: %s -> %s", ctx.Req.RequestURI, ctx.User.LowerName, app.Name)
+
+	ctx.Flash.Success("OAuth application has been registered.")
+	ctx.Redirect("/user/settings/applications")
+}
+
+func SettingOauthApplicationDelete(ctx *middleware.Context) {
+	id, _ := base.StrTo(ctx.Query("id")).Int64()
+	if err := models.DeleteOauthApplication(ctx.User.Id, id); err != nil {
+		if err == models.ErrOauthAppNotExist {
+			ctx.Handle(404, "user.SettingOauthApplicationDelete(DeleteOauthApplication)", err)
+		} else {
+			ctx.Handle(500, "user.SettingOauthApplicationDelete(DeleteOauthApplication)", err)
+		}
+		return
+	}
+	log.Trace("%s OAuth application deleted: %s -> %d", ctx.Req.RequestURI, ctx.User.LowerName, id)
+
+	ctx.Flash.Success("OAuth application has been deleted.")
+	ctx.Redirect("/user/settings/applications")
+}
+
+func SettingApplicationsDelete(ctx *middleware.Context) {
+	id, _ := base.StrTo(ctx.Query("id")).Int64()
+	if err := models.DeleteAccessTokenOfUserById(ctx.User.Id, id); err != nil {
+		if err == models.ErrAccessTokenNotExist {
+			ctx.Handle(404, "user.SettingApplicationsDelete(DeleteAccessTokenOfUserById)", err)
+		} else {
+			ctx.Handle(500, "user.SettingApplicationsDelete(DeleteAccessTokenOfUserById)", err)
+		}
+		return
+	}
+	log.Trace("%s Access token deleted: %s", ctx.Req.RequestURI, ctx.User.LowerName)
+
+	ctx.Flash.Success("Access token has been deleted.")
+	ctx.Redirect("/user/settings/applications")
+}
+
 func SettingNotification(ctx *middleware.Context) {
 	// TODO: user setting notification
 	ctx.Data["Title"] = "Notification"
@@ -219,9 +462,179 @@ func SettingNotification(ctx *middleware.Context) {
 }
 
 func SettingSecurity(ctx *middleware.Context) {
-	// TODO: user setting security
 	ctx.Data["Title"] = "Security"
 	ctx.Data["PageIsUserSetting"] = true
 	ctx.Data["IsUserPageSettingSecurity"] = true
+
+	t, err := models.GetTwoFactorByUid(ctx.User.Id)
+	if err != nil && err != models.ErrTwoFactorNotEnrolled {
+		ctx.Handle(500, "user.SettingSecurity(GetTwoFactorByUid)", err)
+		return
+	}
+	ctx.Data["TwoFactor"] = t
 	ctx.HTML(200, "user/security")
 }
+
+// prepareTwoFactorEnroll generates a new TOTP secret if needed
+// and fills QR code of it for enrollment page.
+func prepareTwoFactorEnroll(ctx *middleware.Context) bool {
+	secret, ok := ctx.Session.Get("twofaSecret").(string)
+	if !ok {
+		secret = base.NewTotpSecret()
+		ctx.Session.Set("twofaSecret", secret)
+	}
+
+	code, err := qr.Encode(base.TotpUri(setting.AppName, ctx.User.Name, secret), qr.M)
+	if err != nil {
+		ctx.Handle(500, "user.prepareTwoFactorEnroll(qr.Encode)", err)
+		return false
+	}
+	ctx.Data["TwoFactorSecret"] = secret
+	ctx.Data["QrImage"] = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(code.PNG()))
+	return true
+}
+
+func SettingTwoFactorEnroll(ctx *middleware.Context) {
+	ctx.Data["Title"] = "Enroll Two-factor Authentication"
+	ctx.Data["PageIsUserSetting"] = true
+	ctx.Data["IsUserPageSettingSecurity"] = true
+
+	if _, err := models.GetTwoFactorByUid(ctx.User.Id); err == nil {
+		ctx.Flash.Error("You have already enrolled two-factor authentication.")
+		ctx.Redirect("/user/settings/security")
+		return
+	}
+
+	if !prepareTwoFactorEnroll(ctx) {
+		return
+	}
+	ctx.HTML(200, "user/twofa_enroll")
+}
+
+func SettingTwoFactorEnrollPost(ctx *middleware.Context, form auth.TwoFactorAuthForm) {
+	ctx.Data["Title"] = "Enroll Two-factor Authentication"
+	ctx.Data["PageIsUserSetting"] = true
+	ctx.Data["IsUserPageSettingSecurity"] = true
+
+	if !prepareTwoFactorEnroll(ctx) {
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.HTML(200, "user/twofa_enroll")
+		return
+	}
+
+	secret := ctx.Data["TwoFactorSecret"].(string)
+	step := base.MatchTotp(secret, form.Passcode, time.Now())
+	if step < 0 {
+		ctx.RenderWithErr("Passcode is not correct, please make sure your device time is accurate.", "user/twofa_enroll", &form)
+		return
+	}
+
+	// Passcode used for enrollment cannot be used to sign in.
+	t := &models.TwoFactor{
+		Uid:          ctx.User.Id,
+		Secret:       secret,
+		LastTotpStep: step,
+	}
+	if err := models.NewTwoFactor(t); err != nil {
+		ctx.Handle(500, "user.SettingTwoFactorEnrollPost(NewTwoFactor)", err)
+		return
+	}
+	ctx.Session.Delete("twofaSecret")
+
+	log.Trace("%s Two-factor authentication enrolled: %s", ctx.Req.RequestURI, ctx.User.LowerName)
+	ctx.ShowSuccess("Two-factor authentication has been enrolled, please keep your scratch token in a safe place: " + t.ScratchToken)
+	SettingSecurity(ctx)
+}
+
+func SettingTwoFactorRegenerateScratch(ctx *middleware.Context) {
+	t, err := models.GetTwoFactorByUid(ctx.User.Id)
+	if err != nil {
+		if err == models.ErrTwoFactorNotEnrolled {
+			ctx.Handle(404, "user.SettingTwoFactorRegenerateScratch(GetTwoFactorByUid)", err)
+		} else {
+			ctx.Handle(500, "user.SettingTwoFactorRegenerateScratch(GetTwoFactorByUid)", err)
+		}
+		return
+	}
+
+	t.GenerateScratchToken()
+	if err = models.UpdateTwoFactor(t); err != nil {
+		ctx.Handle(500, "user.SettingTwoFactorRegenerateScratch(UpdateTwoFactor)", err)
+		return
+	}
+	ctx.ShowSuccess("New scratch token has been generated: " + t.ScratchToken)
+	SettingSecurity(ctx)
+}
+
+func SettingTwoFactorDisable(ctx *middleware.Context) {
+	if err := models.DisableTwoFactor(ctx.User, ctx.Query("password"), ctx.Query("passcode")); err != nil {
+		switch err {
+		case models.ErrTwoFactorNotConfirmed:
+			ctx.Flash.Error(err.Error() + ".")
+			ctx.Redirect("/user/settings/security")
+		case models.ErrTwoFactorNotEnrolled:
+			ctx.Handle(404, "user.SettingTwoFactorDisable(DisableTwoFactor)", err)
+		default:
+			ctx.Handle(500, "user.SettingTwoFactorDisable(DisableTwoFactor)", err)
+		}
+		return
+	}
+
+	log.Trace("%s Two-factor authentication disabled: %s", ctx.Req.RequestURI, ctx.User.LowerName)
+	ctx.Flash.Success("Two-factor authentication has been disabled.")
+	ctx.Redirect("/user/settings/security")
+}
+
+func SettingSessions(ctx *middleware.Context) {
+	ctx.Data["Title"] = "Sessions"
+	ctx.Data["PageIsUserSetting"] = true
+	ctx.Data["IsUserPageSettingSessions"] = true
+
+	sessions, err := models.GetUserSessions(ctx.User.Id, ctx.Session.SessionID())
+	if err != nil {
+		ctx.Handle(500, "user.SettingSessions(GetUserSessions)", err)
+		return
+	}
+	ctx.Data["Sessions"] = sessions
+	ctx.HTML(200, "user/sessions")
+}
+
+func SettingSessionsRevoke(ctx *middleware.Context) {
+	id, _ := base.StrTo(ctx.Query("id")).Int64()
+	if err := models.DeleteUserSession(ctx.User.Id, id); err != nil {
+		if err == models.ErrUserSessionNotExist {
+			ctx.Handle(404, "user.SettingSessionsRevoke(DeleteUserSession)", err)
+		} else {
+			ctx.Handle(500, "user.SettingSessionsRevoke(DeleteUserSession)", err)
+		}
+		return
+	}
+	log.Trace("%s Session revoked: %s", ctx.Req.RequestURI, ctx.User.LowerName)
+
+	ctx.Flash.Success("Session has been revoked.")
+	ctx.Redirect("/user/settings/sessions")
+}
+
+// SettingSessionsRevokeAll signs out user from all sessions include current one,
+// persistent logins of remember cookies are invalidated as well.
+func SettingSessionsRevokeAll(ctx *middleware.Context) {
+	if err := models.DeleteUserSessions(ctx.User.Id); err != nil {
+		ctx.Handle(500, "user.SettingSessionsRevokeAll(DeleteUserSessions)", err)
+		return
+	}
+	if err := models.DeleteUserRememberTokens(ctx.User.Id); err != nil {
+		ctx.Handle(500, "user.SettingSessionsRevokeAll(DeleteUserRememberTokens)", err)
+		return
+	}
+	log.Trace("%s Signed out everywhere: %s", ctx.Req.RequestURI, ctx.User.LowerName)
+
+	ctx.Session.Delete("userId")
+	ctx.Session.Delete("userName")
+	ctx.SetCookie(setting.CookieUserName, "", -1)
+	ctx.SetCookie(setting.CookieRememberName, "", -1)
+	ctx.Flash.Success("You have been signed out from all sessions.")
+	ctx.Redirect("/user/login")
+}
@@ -5,6 +5,7 @@
 package user
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,30 +14,40 @@ import (
 
 	"github.com/go-martini/martini"
 
+	oauth "github.com/gogits/oauth2"
+
 	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/base"
 	"github.com/gogits/gogs/modules/log"
 	"github.com/gogits/gogs/modules/middleware"
+	"github.com/gogits/gogs/modules/oauth2"
 	"github.com/gogits/gogs/modules/setting"
-	"github.com/gogits/gogs/modules/social"
 )
 
+// extractPath returns path of given URL, or "/" if it is not a path within the site.
+// Browsers treat leading "//" and "/\" as another host.
 func extractPath(next string) string {
 	n, err := url.Parse(next)
-	if err != nil {
+	if err != nil || !strings.HasPrefix(n.Path, "/") ||
+		strings.HasPrefix(n.Path, "//") || strings.HasPrefix(n.Path, "/\\") {
 		return "/"
 	}
 	return n.Path
 }
 
+// isValidSocialState returns true if state returned by provider matches the one saved in session.
+func isValidSocialState(saved, state string) bool {
+	return len(saved) > 0 && subtle.ConstantTimeCompare([]byte(saved), []byte(state)) == 1
+}
+
 func SocialSignIn(ctx *middleware.Context, params martini.Params) {
 	if setting.OauthService == nil {
 		ctx.Handle(404, "social.SocialSignIn(oauth service not enabled)", nil)
 		return
 	}
 
-	next := extractPath(ctx.Query("next"))
 	name := params["name"]
-	connect, ok := social.SocialMap[name]
+	connect, ok := oauth2.SocialMap[name]
 	if !ok {
 		ctx.Handle(404, "social.SocialSignIn(social login not enabled)", errors.New(name))
 		return
@@ -44,19 +55,33 @@ func SocialSignIn(ctx *middleware.Context, params martini.Params) {
 
 	code := ctx.Query("code")
 	if code == "" {
-		// redirect to social login page
+		// redirect to social login page, state is used to prevent CSRF.
+		state := base.GetRandomString(20)
+		ctx.Session.Set("socialState", state)
+		ctx.Session.Set("socialNext", extractPath(ctx.Query("next")))
 		connect.SetRedirectUrl(strings.TrimSuffix(setting.AppUrl, "/") + ctx.Req.URL.Path)
-		ctx.Redirect(connect.AuthCodeURL(next))
+		ctx.Redirect(connect.AuthCodeURL(state))
 		return
 	}
 
 	// handle call back
+	state, _ := ctx.Session.Get("socialState").(string)
+	ctx.Session.Delete("socialState")
+	if !isValidSocialState(state, ctx.Query("state")) {
+		ctx.Handle(400, "social.SocialSignIn(state mismatch)", nil)
+		return
+	}
+	next, _ := ctx.Session.Get("socialNext").(string)
+	ctx.Session.Delete("socialNext")
+	if len(next) == 0 {
+		next = "/"
+	}
+
 	tk, err := connect.Exchange(code)
 	if err != nil {
 		ctx.Handle(500, "social.SocialSignIn(Exchange)", err)
 		return
 	}
-	next = extractPath(ctx.Query("state"))
 	log.Trace("social.SocialSignIn(Got token)")
 
 	ui, err := connect.UserInfo(tk, ctx.Req.URL)
@@ -66,11 +91,29 @@ func SocialSignIn(ctx *middleware.Context, params martini.Params) {
 	}
 	log.Info("social.SocialSignIn(social login): %s", ui)
 
+	// Signed in user is linking a new identity to the account.
+	if ctx.IsSigned {
+		socialLink(ctx, connect.Type(), ui, tk)
+		return
+	}
+
 	oa, err := models.GetOauth2(ui.Identity)
 	switch err {
 	case nil:
-		ctx.Session.Set("userId", oa.User.Id)
-		ctx.Session.Set("userName", oa.User.Name)
+		// Users with two-factor authentication must pass it as well.
+		if _, err = models.GetTwoFactorByUid(oa.User.Id); err == nil {
+			ctx.Session.Set("twofaUid", oa.User.Id)
+			ctx.Session.Set("twofaRemember", false)
+			ctx.Redirect("/user/login/two_factor")
+			return
+		} else if err != models.ErrTwoFactorNotEnrolled {
+			ctx.Handle(500, "social.SocialSignIn(GetTwoFactorByUid)", err)
+			return
+		}
+		if err = signInSession(ctx, oa.User); err != nil {
+			ctx.Handle(500, "social.SocialSignIn(signInSession)", err)
+			return
+		}
 	case models.ErrOauth2RecordNotExist:
 		raw, _ := json.Marshal(tk)
 		oa = &models.Oauth2{
@@ -97,3 +140,42 @@ func SocialSignIn(ctx *middleware.Context, params martini.Params) {
 	log.Trace("social.SocialSignIn(social ID): %v", oa.Id)
 	ctx.Redirect(next)
 }
+
+// socialLink links social identity to current signed in user.
+func socialLink(ctx *middleware.Context, tp int, ui *oauth2.BasicUserInfo, tk *oauth.Token) {
+	raw, _ := json.Marshal(tk)
+	oa, err := models.GetOauth2(ui.Identity)
+	switch err {
+	case nil:
+		if oa.Uid != ctx.User.Id {
+			ctx.Flash.Error("This social account has been linked to another user.")
+		} else {
+			ctx.Flash.Success("This social account has already been linked to your account.")
+		}
+		ctx.Redirect("/user/settings/social")
+		return
+	case models.ErrOauth2NotAssociated:
+		if err = models.BindUserOauth2(ctx.User.Id, oa.Id); err != nil {
+			ctx.Handle(500, "social.socialLink(BindUserOauth2)", err)
+			return
+		}
+	case models.ErrOauth2RecordNotExist:
+		oa = &models.Oauth2{
+			Uid:      ctx.User.Id,
+			Type:     tp,
+			Identity: ui.Identity,
+			Token:    string(raw),
+		}
+		if err = models.AddOauth2(oa); err != nil {
+			ctx.Handle(500, "social.socialLink(AddOauth2)", err)
+			return
+		}
+	default:
+		ctx.Handle(500, "social.socialLink(GetOauth2)", err)
+		return
+	}
+
+	log.Trace("%s OAuth linked: %s -> %s", ctx.Req.RequestURI, ctx.User.Name, ui.Identity)
+	ctx.Flash.Success("Social account has been linked to your account.")
+	ctx.Redirect("/user/settings/social")
+}
@@ -0,0 +1,52 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"testing"
+)
+
+var isValidSocialStateTests = []struct {
+	saved, state string
+	expected     bool
+}{
+	{"", "", false},
+	{"", "abc", false},
+	{"abc", "", false},
+	{"abc", "abd", false},
+	{"abc", "abcd", false},
+	{"abc", "abc", true},
+}
+
+func TestIsValidSocialState(t *testing.T) {
+	for _, tt := range isValidSocialStateTests {
+		if ok := isValidSocialState(tt.saved, tt.state); ok != tt.expected {
+			t.Errorf("isValidSocialState(%q, %q) = %v, expected %v", tt.saved, tt.state, ok, tt.expected)
+		}
+	}
+}
+
+var extractPathTests = []struct {
+	next, expected string
+}{
+	{"", "/"},
+	{"/", "/"},
+	{"/user/settings?tab=social#top", "/user/settings"},
+	{"repo", "/"},
+	{"https://example.com", "/"},
+	{"https://example.com/user", "/user"},
+	{"//example.com/user", "/user"},
+	{"///example.com", "/"},
+	{"/\\example.com", "/"},
+	{"%zz", "/"},
+}
+
+func TestExtractPath(t *testing.T) {
+	for _, tt := range extractPathTests {
+		if p := extractPath(tt.next); p != tt.expected {
+			t.Errorf("extractPath(%q) = %q, expected %q", tt.next, p, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2014 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"github.com/go-martini/martini"
+
+	"github.com/gogits/gogs/models"
+	"github.com/gogits/gogs/modules/base"
+	"github.com/gogits/gogs/modules/log"
+	"github.com/gogits/gogs/modules/middleware"
+)
+
+func Transfers(ctx *middleware.Context) {
+	ctx.Data["Title"] = "Repository Transfers"
+	ctx.Data["PageIsUserDashboard"] = true
+
+	transfers, err := models.GetIncomingRepoTransfers(ctx.User.Id)
+	if err != nil {
+		ctx.Handle(500, "user.Transfers(GetIncomingRepoTransfers)", err)
+		return
+	}
+	ctx.Data["Transfers"] = transfers
+	ctx.HTML(200, "user/transfers")
+}
+
+// getTransfer returns transfer in URL that is sent to current user.
+func getTransfer(ctx *middleware.Context, params martini.Params) *models.RepoTransfer {
+	id, _ := base.StrTo(params["id"]).Int64()
+	t, err := models.GetRepoTransferById(ctx.User.Id, id)
+	if err != nil {
+		if err == models.ErrRepoTransferNotExist {
+			ctx.Handle(404, "user.getTransfer", nil)
+		} else {
+			ctx.Handle(500, "user.getTransfer(GetRepoTransferById)", err)
+		}
+		return nil
+	}
+	return t
+}
+
+func AcceptTransfer(ctx *middleware.Context, params martini.Params) {
+	t := getTransfer(ctx, params)
+	if t == nil {
+		return
+	}
+
+	if err := models.AcceptRepoTransfer(t); err != nil {
+		if err == models.ErrRepoAlreadyExist {
+			ctx.Flash.Error("You already have a repository named " + t.Repo.Name + ", please rename or delete it first.")
+			ctx.Redirect("/user/transfers")
+			return
+		}
+		ctx.Handle(500, "user.AcceptTransfer(AcceptRepoTransfer)", err)
+		return
+	}
+	log.Trace("%s Repository transfered: %s/%s -> %s", ctx.Req.RequestURI, t.Repo.Owner.Name, t.Repo.Name, ctx.User.Name)
+
+	ctx.Flash.Success("Repository has been transferred to you.")
+	ctx.Redirect("/" + ctx.User.Name + "/" + t.Repo.Name)
+}
+
+func DeclineTransfer(ctx *middleware.Context, params martini.Params) {
+	t := getTransfer(ctx, params)
+	if t == nil {
+		return
+	}
+
+	if err := models.CancelRepoTransfer(t.RepoId); err != nil {
+		ctx.Handle(500, "user.DeclineTransfer(CancelRepoTransfer)", err)
+		return
+	}
+	log.Trace("%s Repository transfer declined: %s/%s -> %s", ctx.Req.RequestURI, t.Repo.Owner.Name, t.Repo.Name, ctx.User.Name)
+
+	ctx.Flash.Success("Repository transfer has been declined.")
+	ctx.Redirect("/user/transfers")
+}
@@ -10,7 +10,6 @@ import (
 
 	"github.com/gogits/gogs/models"
 	"github.com/gogits/gogs/modules/auth"
-	"github.com/gogits/gogs/modules/base"
 	"github.com/gogits/gogs/modules/log"
 	"github.com/gogits/gogs/modules/mailer"
 	"github.com/gogits/gogs/modules/middleware"
@@ -20,6 +19,13 @@ import (
 func SignIn(ctx *middleware.Context) {
 	ctx.Data["Title"] = "Log In"
 
+	// Users are signed in by reverse proxy.
+	if setting.Service.EnableReverseProxyAuth {
+		ctx.Data["DisableLoginForm"] = true
+		ctx.HTML(200, "user/signin")
+		return
+	}
+
 	if _, ok := ctx.Session.Get("socialId").(int64); ok {
 		ctx.Data["IsSocialLogin"] = true
 		ctx.HTML(200, "user/signin")
@@ -32,39 +38,34 @@ func SignIn(ctx *middleware.Context) {
 	}
 
 	// Check auto-login.
-	uname := ctx.GetCookie(setting.CookieUserName)
-	if len(uname) == 0 {
+	series, token := parseRememberCookie(ctx)
+	if len(series) == 0 {
 		ctx.HTML(200, "user/signin")
 		return
 	}
 
-	isSucceed := false
-	defer func() {
-		if !isSucceed {
-			log.Trace("user.SignIn(auto-login cookie cleared): %s", uname)
-			ctx.SetCookie(setting.CookieUserName, "", -1)
-			ctx.SetCookie(setting.CookieRememberName, "", -1)
+	user, token, err := models.UseRememberToken(series, token)
+	if err != nil {
+		switch err {
+		case models.ErrRememberTokenNotExist:
+		case models.ErrRememberTokenTheft:
+			log.Warn("user.SignIn(auto-login): remember cookie reused from %s, all persistent logins are invalidated", ctx.RemoteAddr())
+		default:
+			ctx.Handle(500, "user.SignIn(UseRememberToken)", err)
 			return
 		}
-	}()
-
-	user, err := models.GetUserByName(uname)
-	if err != nil {
-		ctx.Handle(500, "user.SignIn(GetUserByName)", err)
+		log.Trace("user.SignIn(auto-login cookie cleared): %s", series)
+		ctx.SetCookie(setting.CookieUserName, "", -1)
+		ctx.SetCookie(setting.CookieRememberName, "", -1)
+		ctx.HTML(200, "user/signin")
 		return
 	}
+	setRememberCookie(ctx, user, series, token)
 
-	secret := base.EncodeMd5(user.Rands + user.Passwd)
-	value, _ := ctx.GetSecureCookie(secret, setting.CookieRememberName)
-	if value != user.Name {
-		ctx.HTML(200, "user/signin")
+	if err = signInSession(ctx, user); err != nil {
+		ctx.Handle(500, "user.SignIn(signInSession)", err)
 		return
 	}
-
-	isSucceed = true
-
-	ctx.Session.Set("userId", user.Id)
-	ctx.Session.Set("userName", user.Name)
 	if redirectTo, _ := url.QueryUnescape(ctx.GetCookie("redirect_to")); len(redirectTo) > 0 {
 		ctx.SetCookie("redirect_to", "", -1)
 		ctx.Redirect(redirectTo)
@@ -77,8 +78,12 @@ func SignIn(ctx *middleware.Context) {
 func SignInPost(ctx *middleware.Context, form auth.LogInForm) {
 	ctx.Data["Title"] = "Log In"
 
-	sid, isOauth := ctx.Session.Get("socialId").(int64)
-	if isOauth {
+	if setting.Service.EnableReverseProxyAuth {
+		ctx.Handle(403, "user.SignInPost", nil)
+		return
+	}
+
+	if _, isOauth := ctx.Session.Get("socialId").(int64); isOauth {
 		ctx.Data["IsSocialLogin"] = true
 	} else if setting.OauthService != nil {
 		ctx.Data["OauthEnabled"] = true
@@ -90,41 +95,88 @@ func SignInPost(ctx *middleware.Context, form auth.LogInForm) {
 		return
 	}
 
+	isLocked, err := models.IsLoginLocked(form.UserName, ctx.RemoteAddr())
+	if err != nil {
+		ctx.Handle(500, "user.SignInPost(IsLoginLocked)", err)
+		return
+	} else if isLocked {
+		log.Trace("%s Log in locked: %s", ctx.Req.RequestURI, form.UserName)
+		ctx.RenderWithErr("Too many failed login attempts, please try again later.", "user/signin", &form)
+		return
+	}
+
 	user, err := models.UserSignIn(form.UserName, form.Password)
 	if err != nil {
 		if err == models.ErrUserNotExist {
 			log.Trace("%s Log in failed: %s", ctx.Req.RequestURI, form.UserName)
+			models.RecordAudit(0, form.UserName, models.AUDIT_LOGIN_FAILED, ctx.RemoteAddr(), "Web")
+			if err = models.NewLoginAttempt(form.UserName, ctx.RemoteAddr()); err != nil {
+				ctx.Handle(500, "user.SignInPost(NewLoginAttempt)", err)
+				return
+			}
 			ctx.RenderWithErr("Username or password is not correct", "user/signin", &form)
 			return
 		}
 
 		ctx.Handle(500, "user.SignInPost(UserSignIn)", err)
 		return
+	} else if user.IsSuspended {
+		log.Trace("%s Log in of suspended user: %s", ctx.Req.RequestURI, user.Name)
+		ctx.RenderWithErr("Your account has been suspended, please contact site administrator.", "user/signin", &form)
+		return
+	}
+
+	// Users with two-factor authentication enrolled must supply a passcode
+	// before they are actually signed in, failed attempts are not cleared
+	// until then so signing in again does not reset lockout of passcodes.
+	if _, err = models.GetTwoFactorByUid(user.Id); err == nil {
+		ctx.Session.Set("twofaUid", user.Id)
+		ctx.Session.Set("twofaRemember", form.Remember)
+		ctx.Redirect("/user/login/two_factor")
+		return
+	} else if err != models.ErrTwoFactorNotEnrolled {
+		ctx.Handle(500, "user.SignInPost(GetTwoFactorByUid)", err)
+		return
 	}
 
-	if form.Remember {
-		secret := base.EncodeMd5(user.Rands + user.Passwd)
-		days := 86400 * setting.LogInRememberDays
-		ctx.SetCookie(setting.CookieUserName, user.Name, days)
-		ctx.SetSecureCookie(secret, setting.CookieRememberName, user.Name, days)
+	if err = models.UnlockUserLogin(form.UserName); err != nil {
+		ctx.Handle(500, "user.SignInPost(UnlockUserLogin)", err)
+		return
+	}
+	handleSignIn(ctx, user, form.Remember)
+}
+
+// handleSignIn signs in given user after all verifications have been passed.
+func handleSignIn(ctx *middleware.Context, user *models.User, remember bool) {
+	if remember {
+		series, token, err := models.NewRememberToken(user.Id)
+		if err != nil {
+			ctx.Handle(500, "user.handleSignIn(NewRememberToken)", err)
+			return
+		}
+		setRememberCookie(ctx, user, series, token)
 	}
 
 	// Bind with social account.
-	if isOauth {
-		if err = models.BindUserOauth2(user.Id, sid); err != nil {
+	if sid, isOauth := ctx.Session.Get("socialId").(int64); isOauth {
+		if err := models.BindUserOauth2(user.Id, sid); err != nil {
 			if err == models.ErrOauth2RecordNotExist {
-				ctx.Handle(404, "user.SignInPost(GetOauth2ById)", err)
+				ctx.Handle(404, "user.handleSignIn(GetOauth2ById)", err)
 			} else {
-				ctx.Handle(500, "user.SignInPost(GetOauth2ById)", err)
+				ctx.Handle(500, "user.handleSignIn(GetOauth2ById)", err)
 			}
 			return
 		}
 		ctx.Session.Delete("socialId")
-		log.Trace("%s OAuth binded: %s -> %d", ctx.Req.RequestURI, form.UserName, sid)
+		log.Trace("%s OAuth binded: %s -> %d", ctx.Req.RequestURI, user.Name, sid)
 	}
 
-	ctx.Session.Set("userId", user.Id)
-	ctx.Session.Set("userName", user.Name)
+	ctx.Session.Delete("twofaUid")
+	ctx.Session.Delete("twofaRemember")
+	if err := signInSession(ctx, user); err != nil {
+		ctx.Handle(500, "user.handleSignIn(signInSession)", err)
+		return
+	}
 	if redirectTo, _ := url.QueryUnescape(ctx.GetCookie("redirect_to")); len(redirectTo) > 0 {
 		ctx.SetCookie("redirect_to", "", -1)
 		ctx.Redirect(redirectTo)
@@ -134,12 +186,192 @@ func SignInPost(ctx *middleware.Context, form auth.LogInForm) {
 	ctx.Redirect("/")
 }
 
+// parseRememberCookie returns series and token of persistent login
+// that are stored in remember cookie.
+func parseRememberCookie(ctx *middleware.Context) (series, token string) {
+	fields := strings.SplitN(ctx.GetCookie(setting.CookieRememberName), ":", 2)
+	if len(fields) != 2 {
+		return "", ""
+	}
+	return fields[0], fields[1]
+}
+
+// setRememberCookie stores series and token of persistent login in cookie.
+func setRememberCookie(ctx *middleware.Context, user *models.User, series, token string) {
+	days := 86400 * setting.LogInRememberDays
+	ctx.SetCookie(setting.CookieUserName, user.Name, days)
+	ctx.SetCookie(setting.CookieRememberName, series+":"+token, days, "/", "", false, true)
+}
+
+// signInSession marks current session as signed in by given user
+// and records it so that it can be revoked later.
+func signInSession(ctx *middleware.Context, user *models.User) error {
+	if err := models.NewUserSession(user.Id, ctx.Session.SessionID(),
+		ctx.RemoteAddr(), ctx.Req.UserAgent()); err != nil {
+		return err
+	}
+	ctx.Session.Set("userId", user.Id)
+	ctx.Session.Set("userName", user.Name)
+	models.RecordAudit(user.Id, user.Name, models.AUDIT_LOGIN, ctx.RemoteAddr(), "Web")
+	return nil
+}
+
+// twoFactorUser returns the user who passed password verification
+// but is still pending two-factor authentication, it returns nil
+// when response has been written.
+func twoFactorUser(ctx *middleware.Context) (*models.User, *models.TwoFactor) {
+	uid, ok := ctx.Session.Get("twofaUid").(int64)
+	if !ok {
+		ctx.Redirect("/user/login")
+		return nil, nil
+	}
+
+	u, err := models.GetUserById(uid)
+	if err != nil {
+		ctx.Handle(500, "user.twoFactorUser(GetUserById)", err)
+		return nil, nil
+	}
+
+	t, err := models.GetTwoFactorByUid(uid)
+	if err != nil {
+		ctx.Handle(500, "user.twoFactorUser(GetTwoFactorByUid)", err)
+		return nil, nil
+	}
+	return u, t
+}
+
+// isTwoFactorLocked returns true and renders given template with error if user has too many
+// failed login attempts, failed two-factor attempts count as failed login attempts.
+func isTwoFactorLocked(ctx *middleware.Context, u *models.User, tpl string, form auth.Form) bool {
+	isLocked, err := models.IsLoginLocked(u.Name, ctx.RemoteAddr())
+	if err != nil {
+		ctx.Handle(500, "user.isTwoFactorLocked(IsLoginLocked)", err)
+		return true
+	} else if isLocked {
+		log.Trace("%s Two-factor authentication locked: %s", ctx.Req.RequestURI, u.Name)
+		ctx.RenderWithErr("Too many failed login attempts, please try again later.", tpl, form)
+		return true
+	}
+	return false
+}
+
+// failTwoFactor records a failed two-factor attempt of user and renders given template with error.
+func failTwoFactor(ctx *middleware.Context, u *models.User, msg, tpl string, form auth.Form) {
+	log.Trace("%s Two-factor authentication failed: %s", ctx.Req.RequestURI, u.Name)
+	if err := models.NewLoginAttempt(u.Name, ctx.RemoteAddr()); err != nil {
+		ctx.Handle(500, "user.failTwoFactor(NewLoginAttempt)", err)
+		return
+	}
+	ctx.RenderWithErr(msg, tpl, form)
+}
+
+func TwoFactor(ctx *middleware.Context) {
+	ctx.Data["Title"] = "Two-factor Authentication"
+
+	if _, ok := ctx.Session.Get("twofaUid").(int64); !ok {
+		ctx.Redirect("/user/login")
+		return
+	}
+	ctx.HTML(200, "user/twofa")
+}
+
+func TwoFactorPost(ctx *middleware.Context, form auth.TwoFactorAuthForm) {
+	ctx.Data["Title"] = "Two-factor Authentication"
+
+	u, t := twoFactorUser(ctx)
+	if u == nil {
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.HTML(200, "user/twofa")
+		return
+	} else if isTwoFactorLocked(ctx, u, "user/twofa", &form) {
+		return
+	}
+
+	valid, err := t.UseTotp(form.Passcode)
+	if err != nil {
+		ctx.Handle(500, "user.TwoFactorPost(UseTotp)", err)
+		return
+	} else if !valid {
+		failTwoFactor(ctx, u, "Passcode is not correct.", "user/twofa", &form)
+		return
+	}
+
+	if err = models.UnlockUserLogin(u.Name); err != nil {
+		ctx.Handle(500, "user.TwoFactorPost(UnlockUserLogin)", err)
+		return
+	}
+	remember, _ := ctx.Session.Get("twofaRemember").(bool)
+	handleSignIn(ctx, u, remember)
+}
+
+func TwoFactorScratch(ctx *middleware.Context) {
+	ctx.Data["Title"] = "Two-factor Authentication"
+
+	if _, ok := ctx.Session.Get("twofaUid").(int64); !ok {
+		ctx.Redirect("/user/login")
+		return
+	}
+	ctx.HTML(200, "user/twofa_scratch")
+}
+
+func TwoFactorScratchPost(ctx *middleware.Context, form auth.TwoFactorScratchAuthForm) {
+	ctx.Data["Title"] = "Two-factor Authentication"
+
+	u, t := twoFactorUser(ctx)
+	if u == nil {
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.HTML(200, "user/twofa_scratch")
+		return
+	} else if isTwoFactorLocked(ctx, u, "user/twofa_scratch", &form) {
+		return
+	}
+
+	if !t.ValidateScratchToken(form.Token) {
+		failTwoFactor(ctx, u, "Scratch token is not correct.", "user/twofa_scratch", &form)
+		return
+	}
+
+	// Scratch token can only be used once.
+	t.GenerateScratchToken()
+	if err := models.UpdateTwoFactor(t); err != nil {
+		ctx.Handle(500, "user.TwoFactorScratchPost(UpdateTwoFactor)", err)
+		return
+	}
+	log.Trace("%s Two-factor scratch token used: %s", ctx.Req.RequestURI, u.Name)
+
+	if err := models.UnlockUserLogin(u.Name); err != nil {
+		ctx.Handle(500, "user.TwoFactorScratchPost(UnlockUserLogin)", err)
+		return
+	}
+
+	// New scratch token is not shown here, since message would be stored in cookie.
+	ctx.Flash.Success("Your scratch token has been used, please generate a new one in security settings.")
+	remember, _ := ctx.Session.Get("twofaRemember").(bool)
+	handleSignIn(ctx, u, remember)
+}
+
 func SignOut(ctx *middleware.Context) {
+	if err := models.DeleteUserSessionBySid(ctx.Session.SessionID()); err != nil {
+		log.Error("user.SignOut(DeleteUserSessionBySid): %v", err)
+	}
 	ctx.Session.Delete("userId")
 	ctx.Session.Delete("userName")
 	ctx.Session.Delete("socialId")
 	ctx.Session.Delete("socialName")
 	ctx.Session.Delete("socialEmail")
+	ctx.Session.Delete("twofaUid")
+	ctx.Session.Delete("twofaRemember")
+	if series, _ := parseRememberCookie(ctx); len(series) > 0 {
+		if err := models.DeleteRememberToken(series); err != nil {
+			log.Error("user.SignOut(DeleteRememberToken): %v", err)
+		}
+	}
 	ctx.SetCookie(setting.CookieUserName, "", -1)
 	ctx.SetCookie(setting.CookieRememberName, "", -1)
 	ctx.Redirect("/")
@@ -149,7 +381,7 @@ func SignUp(ctx *middleware.Context) {
 	ctx.Data["Title"] = "Sign Up"
 	ctx.Data["PageIsSignUp"] = true
 
-	if setting.Service.DisableRegistration {
+	if setting.Service.DisableRegistration || setting.Service.EnableReverseProxyAuth {
 		ctx.Data["DisableRegistration"] = true
 		ctx.HTML(200, "user/signup")
 		return
@@ -187,7 +419,7 @@ func SignUpPost(ctx *middleware.Context, form auth.RegisterForm) {
 	ctx.Data["Title"] = "Sign Up"
 	ctx.Data["PageIsSignUp"] = true
 
-	if setting.Service.DisableRegistration {
+	if setting.Service.DisableRegistration || setting.Service.EnableReverseProxyAuth {
 		ctx.Handle(403, "user.SignUpPost", nil)
 		return
 	}
@@ -273,12 +505,7 @@ func DeletePost(ctx *middleware.Context) {
 	ctx.Data["PageIsUserSetting"] = true
 	ctx.Data["IsUserPageSettingDelete"] = true
 
-	tmpUser := models.User{
-		Passwd: ctx.Query("password"),
-		Salt:   ctx.User.Salt,
-	}
-	tmpUser.EncodePasswd()
-	if tmpUser.Passwd != ctx.User.Passwd {
+	if !ctx.User.ValidatePassword(ctx.Query("password")) {
 		ctx.Flash.Error("Password is not correct. Make sure you are owner of this account.")
 	} else {
 		if err := models.DeleteUser(ctx.User); err != nil {
@@ -301,6 +528,10 @@ func DeletePost(ctx *middleware.Context) {
 func Activate(ctx *middleware.Context) {
 	code := ctx.Query("code")
 	if len(code) == 0 {
+		if !ctx.IsSigned {
+			ctx.Redirect("/user/login")
+			return
+		}
 		ctx.Data["IsActivatePage"] = true
 		if ctx.User.IsActive {
 			ctx.Handle(404, "user.Activate", nil)
@@ -326,20 +557,19 @@ func Activate(ctx *middleware.Context) {
 	}
 
 	// Verify code.
-	if user := models.VerifyUserActiveCode(code); user != nil {
-		user.IsActive = true
-		user.Rands = models.GetUserSalt()
-		if err := models.UpdateUser(user); err != nil {
-			ctx.Handle(404, "user.Activate", err)
-			return
-		}
-
+	user, err := models.ActivateUserByCode(code)
+	if err == nil {
 		log.Trace("%s User activated: %s", ctx.Req.RequestURI, user.Name)
 
-		ctx.Session.Set("userId", user.Id)
-		ctx.Session.Set("userName", user.Name)
+		if err = signInSession(ctx, user); err != nil {
+			ctx.Handle(500, "user.Activate(signInSession)", err)
+			return
+		}
 		ctx.Redirect("/")
 		return
+	} else if err != models.ErrActivationNotExist {
+		ctx.Handle(500, "user.Activate(ActivateUserByCode)", err)
+		return
 	}
 
 	ctx.Data["IsActivateFailed"] = true
@@ -370,6 +600,9 @@ func ForgotPasswdPost(ctx *middleware.Context) {
 
 	email := ctx.Query("email")
 	u, err := models.GetUserByEmail(email)
+	if err == nil && u.IsBot() {
+		err = models.ErrUserNotExist
+	}
 	if err != nil {
 		if err == models.ErrUserNotExist {
 			ctx.RenderWithErr("This e-mail address does not associate to any account.", "user/forgot_passwd", nil)
@@ -420,13 +653,18 @@ func ResetPasswdPost(ctx *middleware.Context) {
 	ctx.Data["Code"] = code
 
 	if u := models.VerifyUserActiveCode(code); u != nil {
-		// Validate password length.
+		// Validate password length and policy.
 		passwd := ctx.Query("passwd")
 		if len(passwd) < 6 || len(passwd) > 30 {
 			ctx.Data["IsResetForm"] = true
 			ctx.RenderWithErr("Password length should be in 6 and 30.", "user/reset_passwd", nil)
 			return
 		}
+		if err := auth.CheckPasswdPolicy(passwd); len(err) > 0 {
+			ctx.Data["IsResetForm"] = true
+			ctx.RenderWithErr(auth.PasswdPolicyErrorMsg("Password", err)+".", "user/reset_passwd", nil)
+			return
+		}
 
 		u.Passwd = passwd
 		u.Rands = models.GetUserSalt()
@@ -435,9 +673,13 @@ func ResetPasswdPost(ctx *middleware.Context) {
 		if err := models.UpdateUser(u); err != nil {
 			ctx.Handle(500, "user.ResetPasswd(UpdateUser)", err)
 			return
+		} else if err = models.DeleteUserRememberTokens(u.Id); err != nil {
+			ctx.Handle(500, "user.ResetPasswd(DeleteUserRememberTokens)", err)
+			return
 		}
 
 		log.Trace("%s User password reset: %s", ctx.Req.RequestURI, u.Name)
+		models.RecordAudit(u.Id, u.Name, models.AUDIT_RESET_PASSWORD, ctx.RemoteAddr(), "")
 		ctx.Redirect("/user/login")
 		return
 	}